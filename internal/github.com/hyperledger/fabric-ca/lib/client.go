@@ -22,6 +22,7 @@ package lib
 
 import (
 	"bytes"
+	gotls "crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -59,6 +60,17 @@ type Client struct {
 	csp core.CryptoSuite
 	// HTTP client associated with this Fabric CA client
 	httpClient *http.Client
+	// HTTPClient is an optional custom HTTP client used to communicate with the server
+	HTTPClient *http.Client
+	// Transport is an optional custom transport used to communicate with the server
+	// (ignored if HTTPClient is set)
+	Transport http.RoundTripper
+	// RequestHook is invoked with each request before it is signed and sent
+	RequestHook RequestHook
+	// ResponseHook is invoked with each response received from the server
+	ResponseHook ResponseHook
+	// TokenSigner is an optional signer of the authorization tokens of the requests
+	TokenSigner TokenSigner
 }
 
 // Init initializes the client
@@ -108,25 +120,54 @@ func (c *Client) Init() error {
 }
 
 func (c *Client) initHTTPClient() error {
+	if c.HTTPClient != nil {
+		c.httpClient = c.HTTPClient
+		return nil
+	}
+
+	if c.Transport != nil {
+		transport := c.Transport
+		if tr, ok := transport.(*http.Transport); ok && tr.TLSClientConfig == nil && c.Config.TLS.Enabled {
+			tlsConfig, err := c.clientTLSConfig()
+			if err != nil {
+				return err
+			}
+			// Set the TLS config on a copy so that the caller's transport isn't modified
+			tr = cloneTransport(tr)
+			tr.TLSClientConfig = tlsConfig
+			transport = tr
+		}
+		c.httpClient = &http.Client{Transport: transport}
+		return nil
+	}
+
 	tr := new(http.Transport)
 	if c.Config.TLS.Enabled {
-		log.Info("TLS Enabled")
-
-		err := tls.AbsTLSClient(&c.Config.TLS, c.HomeDir)
+		tlsConfig, err := c.clientTLSConfig()
 		if err != nil {
 			return err
 		}
-
-		tlsConfig, err2 := tls.GetClientTLSConfig(&c.Config.TLS, c.csp)
-		if err2 != nil {
-			return fmt.Errorf("Failed to get client TLS config: %s", err2)
-		}
 		tr.TLSClientConfig = tlsConfig
 	}
 	c.httpClient = &http.Client{Transport: tr}
 	return nil
 }
 
+func (c *Client) clientTLSConfig() (*gotls.Config, error) {
+	log.Info("TLS Enabled")
+
+	err := tls.AbsTLSClient(&c.Config.TLS, c.HomeDir)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig, err2 := tls.GetClientTLSConfig(&c.Config.TLS, c.csp)
+	if err2 != nil {
+		return nil, fmt.Errorf("Failed to get client TLS config: %s", err2)
+	}
+	return tlsConfig, nil
+}
+
 // GetServerInfoResponse is the response from the GetServerInfo call
 type GetServerInfoResponse struct {
 	// CAName is the name of the CA
@@ -195,6 +236,9 @@ func (c *Client) Enroll(req *api.EnrollmentRequest) (*EnrollmentResponse, error)
 		return nil, err
 	}
 	post.SetBasicAuth(req.Name, req.Secret)
+	if _, err = c.prepareRequest(post, body); err != nil {
+		return nil, err
+	}
 	var result enrollmentResponseNet
 	err = c.SendReq(post, &result)
 	if err != nil {
@@ -320,6 +364,9 @@ func (c *Client) SendReq(req *http.Request, result interface{}) (err error) {
 	if err != nil {
 		return errors.Wrapf(err, "%s failure of request: %s", req.Method, reqStr)
 	}
+	if c.ResponseHook != nil {
+		c.ResponseHook(resp)
+	}
 	var respBody []byte
 	if resp.Body != nil {
 		respBody, err = ioutil.ReadAll(resp.Body)
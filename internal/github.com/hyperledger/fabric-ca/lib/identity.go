@@ -155,7 +155,12 @@ func (i *Identity) Post(endpoint string, reqBody []byte, result interface{}, que
 			addQueryParm(req, key, value)
 		}
 	}
-	err = i.addTokenAuthHdr(req, reqBody)
+	// The token must be computed over the final body, i.e. after the request hook (if any) was invoked
+	body, err := i.client.prepareRequest(req, reqBody)
+	if err != nil {
+		return err
+	}
+	err = i.addTokenAuthHdr(req, body)
 	if err != nil {
 		return err
 	}
@@ -166,7 +171,7 @@ func (i *Identity) addTokenAuthHdr(req *http.Request, body []byte) error {
 	log.Debug("Adding token-based authorization header")
 	cert := i.ecert.cert
 	key := i.ecert.key
-	token, err := util.CreateToken(i.CSP, cert, key, body)
+	token, err := i.client.createToken(i.CSP, cert, key, body)
 	if err != nil {
 		return errors.WithMessage(err, "Failed to add token authorization header")
 	}
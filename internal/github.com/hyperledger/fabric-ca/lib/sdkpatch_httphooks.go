@@ -0,0 +1,77 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+/*
+Notice: This file has been modified for Hyperledger Fabric SDK Go usage.
+Please review third_party pinning scripts and patches for more details.
+*/
+
+package lib
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// RequestHook is invoked with each request before it is signed and sent to the fabric-ca-server.
+// The hook may modify the request (e.g. add headers). Returning an error aborts the request.
+type RequestHook func(req *http.Request) error
+
+// ResponseHook is invoked with each response that is received from the fabric-ca-server
+// (before the response body is read).
+type ResponseHook func(resp *http.Response)
+
+// prepareRequest invokes the request hook (if any) and returns the final request body,
+// over which the authorization token must be computed.
+func (c *Client) prepareRequest(req *http.Request, body []byte) ([]byte, error) {
+	if c.RequestHook == nil {
+		return body, nil
+	}
+
+	if err := c.RequestHook(req); err != nil {
+		return nil, errors.WithMessage(err, "request hook failed")
+	}
+
+	if req.Body == nil {
+		return nil, nil
+	}
+
+	finalBody, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read request body")
+	}
+	if err := req.Body.Close(); err != nil {
+		return nil, errors.Wrap(err, "failed to close request body")
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(finalBody))
+	req.ContentLength = int64(len(finalBody))
+
+	return finalBody, nil
+}
+
+// cloneTransport returns a copy of the given transport (http.Transport.Clone requires Go 1.13)
+func cloneTransport(tr *http.Transport) *http.Transport {
+	return &http.Transport{
+		Proxy:                  tr.Proxy,
+		DialContext:            tr.DialContext,
+		Dial:                   tr.Dial,
+		DialTLS:                tr.DialTLS,
+		TLSClientConfig:        tr.TLSClientConfig,
+		TLSHandshakeTimeout:    tr.TLSHandshakeTimeout,
+		DisableKeepAlives:      tr.DisableKeepAlives,
+		DisableCompression:     tr.DisableCompression,
+		MaxIdleConns:           tr.MaxIdleConns,
+		MaxIdleConnsPerHost:    tr.MaxIdleConnsPerHost,
+		IdleConnTimeout:        tr.IdleConnTimeout,
+		ResponseHeaderTimeout:  tr.ResponseHeaderTimeout,
+		ExpectContinueTimeout:  tr.ExpectContinueTimeout,
+		TLSNextProto:           tr.TLSNextProto,
+		ProxyConnectHeader:     tr.ProxyConnectHeader,
+		MaxResponseHeaderBytes: tr.MaxResponseHeaderBytes,
+	}
+}
@@ -0,0 +1,41 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+/*
+Notice: This file has been modified for Hyperledger Fabric SDK Go usage.
+Please review third_party pinning scripts and patches for more details.
+*/
+
+package lib
+
+import (
+	"github.com/hyperledger/fabric-sdk-go/internal/github.com/hyperledger/fabric-ca/util"
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
+	"github.com/pkg/errors"
+)
+
+// TokenSigner signs the token of a request to the fabric-ca-server with the given private key
+// (e.g. the Sign function of the SDK's signing manager). The message is the content of the token.
+type TokenSigner func(msg []byte, key core.Key) ([]byte, error)
+
+// createToken creates the authorization token of a request: the token is signed with the
+// token signer of the client if set, otherwise with the crypto suite of the identity
+func (c *Client) createToken(csp core.CryptoSuite, cert []byte, key core.Key, body []byte) (string, error) {
+	if c.TokenSigner == nil {
+		return util.CreateToken(csp, cert, key, body)
+	}
+
+	// same format as util.CreateToken: <base64 cert>.<base64 signature over <base64 body>.<base64 cert>>
+	b64body := util.B64Encode(body)
+	b64cert := util.B64Encode(cert)
+	signature, err := c.TokenSigner([]byte(b64body+"."+b64cert), key)
+	if err != nil {
+		return "", errors.WithMessage(err, "token signer failed")
+	}
+	if len(signature) == 0 {
+		return "", errors.New("token signer returned an empty signature")
+	}
+	return b64cert + "." + util.B64Encode(signature), nil
+}
@@ -52,13 +52,19 @@ type ClientAuth struct {
 type ClientTLSConfig struct {
 	Enabled   bool     `skip:"true"`
 	CertFiles []string `help:"A list of comma-separated PEM-encoded trusted certificate files (e.g. root1.pem,root2.pem)"`
-	Client    KeyCertFiles
+	// CertPems are PEM-encoded trusted certificates, which are used instead of CertFiles if provided
+	CertPems [][]byte `skip:"true"`
+	Client   KeyCertFiles
 }
 
 // KeyCertFiles defines the files need for client on TLS
 type KeyCertFiles struct {
 	KeyFile  string `help:"PEM-encoded key file when mutual authentication is enabled"`
 	CertFile string `help:"PEM-encoded certificate file when mutual authenticate is enabled"`
+	// KeyPem and CertPem are the PEM-encoded key and certificate, which are used instead of
+	// KeyFile and CertFile if provided
+	KeyPem  []byte `skip:"true"`
+	CertPem []byte `skip:"true"`
 }
 
 // GetClientTLSConfig creates a tls.Config object from certs and roots
@@ -73,7 +79,14 @@ func GetClientTLSConfig(cfg *ClientTLSConfig, csp core.CryptoSuite) (*tls.Config
 	log.Debugf("Client Cert File: %s\n", cfg.Client.CertFile)
 	log.Debugf("Client Key File: %s\n", cfg.Client.KeyFile)
 
-	if cfg.Client.CertFile != "" {
+	if len(cfg.Client.CertPem) > 0 {
+		clientCert, err := loadClientCertPEM(&cfg.Client, csp)
+		if err != nil {
+			return nil, err
+		}
+
+		certs = append(certs, *clientCert)
+	} else if cfg.Client.CertFile != "" {
 		err := checkCertDates(cfg.Client.CertFile)
 		if err != nil {
 			return nil, err
@@ -89,18 +102,27 @@ func GetClientTLSConfig(cfg *ClientTLSConfig, csp core.CryptoSuite) (*tls.Config
 		log.Debug("Client TLS certificate and/or key file not provided")
 	}
 	rootCAPool := x509.NewCertPool()
-	if len(cfg.CertFiles) == 0 {
-		return nil, errors.New("No TLS certificate files were provided")
-	}
-
-	for _, cacert := range cfg.CertFiles {
-		caCert, err := ioutil.ReadFile(cacert)
-		if err != nil {
-			return nil, errors.Wrapf(err, "Failed to read '%s'", cacert)
+	if len(cfg.CertPems) > 0 {
+		for i, caCert := range cfg.CertPems {
+			ok := rootCAPool.AppendCertsFromPEM(caCert)
+			if !ok {
+				return nil, errors.Errorf("Failed to process embedded certificate #%d", i+1)
+			}
+		}
+	} else {
+		if len(cfg.CertFiles) == 0 {
+			return nil, errors.New("No TLS certificate files were provided")
 		}
-		ok := rootCAPool.AppendCertsFromPEM(caCert)
-		if !ok {
-			return nil, errors.Errorf("Failed to process certificate from file %s", cacert)
+
+		for _, cacert := range cfg.CertFiles {
+			caCert, err := ioutil.ReadFile(cacert)
+			if err != nil {
+				return nil, errors.Wrapf(err, "Failed to read '%s'", cacert)
+			}
+			ok := rootCAPool.AppendCertsFromPEM(caCert)
+			if !ok {
+				return nil, errors.Errorf("Failed to process certificate from file %s", cacert)
+			}
 		}
 	}
 
@@ -137,13 +159,35 @@ func AbsTLSClient(cfg *ClientTLSConfig, configDir string) error {
 	return nil
 }
 
+// loadClientCertPEM loads the embedded client certificate. The key file is read if the key isn't embedded.
+func loadClientCertPEM(cfg *KeyCertFiles, csp core.CryptoSuite) (*tls.Certificate, error) {
+	if err := checkCertPEMDates(cfg.CertPem); err != nil {
+		return nil, err
+	}
+
+	keyPEM := cfg.KeyPem
+	if len(keyPEM) == 0 && cfg.KeyFile != "" {
+		var err error
+		keyPEM, err = ioutil.ReadFile(cfg.KeyFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to read file '%s'", cfg.KeyFile)
+		}
+	}
+
+	return util.LoadX509KeyPairPEM(cfg.CertPem, keyPEM, csp)
+}
+
 func checkCertDates(certFile string) error {
-	log.Debug("Check client TLS certificate for valid dates")
 	certPEM, err := ioutil.ReadFile(certFile)
 	if err != nil {
 		return errors.Wrapf(err, "Failed to read file '%s'", certFile)
 	}
 
+	return checkCertPEMDates(certPEM)
+}
+
+func checkCertPEMDates(certPEM []byte) error {
+	log.Debug("Check client TLS certificate for valid dates")
 	cert, err := util.GetX509CertificateFromPEM(certPEM)
 	if err != nil {
 		return err
@@ -179,6 +179,36 @@ func LoadX509KeyPair(certFile, keyFile string, csp core.CryptoSuite) (*tls.Certi
 		return nil, err
 	}
 
+	var loadKey func() ([]byte, error)
+	if keyFile != "" {
+		loadKey = func() ([]byte, error) {
+			log.Debugf("Attempting fallback with certfile %s and keyfile %s", certFile, keyFile)
+			return ioutil.ReadFile(keyFile)
+		}
+	}
+
+	return loadX509KeyPair(certPEMBlock, "file "+certFile, "key file "+keyFile, loadKey, csp)
+}
+
+// LoadX509KeyPairPEM parses a public/private key pair from PEM encoded data (see LoadX509KeyPair).
+// The key is only used if the private key of the certificate can't be loaded with BCCSP, so it may be nil.
+func LoadX509KeyPairPEM(certPEM, keyPEM []byte, csp core.CryptoSuite) (*tls.Certificate, error) {
+	var loadKey func() ([]byte, error)
+	if len(keyPEM) > 0 {
+		loadKey = func() ([]byte, error) {
+			log.Debug("Attempting fallback with the embedded certificate and key")
+			return keyPEM, nil
+		}
+	}
+
+	return loadX509KeyPair(certPEM, "the embedded certificate", "embedded key", loadKey, csp)
+}
+
+// loadX509KeyPair parses the certificate and gets its private key with BCCSP, falling back
+// to the key that's returned by loadKey (if any)
+func loadX509KeyPair(certPEMBlock []byte, certSource, keySource string, loadKey func() ([]byte, error), csp core.CryptoSuite) (*tls.Certificate, error) {
+	certPEM := certPEMBlock
+
 	cert := &tls.Certificate{}
 	var skippedBlockTypes []string
 	for {
@@ -196,12 +226,12 @@ func LoadX509KeyPair(certFile, keyFile string, csp core.CryptoSuite) (*tls.Certi
 
 	if len(cert.Certificate) == 0 {
 		if len(skippedBlockTypes) == 0 {
-			return nil, errors.Errorf("Failed to find PEM block in file %s", certFile)
+			return nil, errors.Errorf("Failed to find PEM block in %s", certSource)
 		}
 		if len(skippedBlockTypes) == 1 && strings.HasSuffix(skippedBlockTypes[0], "PRIVATE KEY") {
-			return nil, errors.Errorf("Failed to find certificate PEM data in file %s, but did find a private key; PEM inputs may have been switched", certFile)
+			return nil, errors.Errorf("Failed to find certificate PEM data in %s, but did find a private key; PEM inputs may have been switched", certSource)
 		}
-		return nil, errors.Errorf("Failed to find \"CERTIFICATE\" PEM block in file %s after skipping PEM blocks of the following types: %v", certFile, skippedBlockTypes)
+		return nil, errors.Errorf("Failed to find \"CERTIFICATE\" PEM block in %s after skipping PEM blocks of the following types: %v", certSource, skippedBlockTypes)
 	}
 
 	x509Cert, err := x509.ParseCertificate(cert.Certificate[0])
@@ -211,18 +241,19 @@ func LoadX509KeyPair(certFile, keyFile string, csp core.CryptoSuite) (*tls.Certi
 
 	_, cert.PrivateKey, err = GetSignerFromCert(x509Cert, csp)
 	if err != nil {
-		if keyFile != "" {
-			log.Debugf("Could not load TLS certificate with BCCSP: %s", err)
-			log.Debugf("Attempting fallback with certfile %s and keyfile %s", certFile, keyFile)
-			fallbackCerts, err := tls.LoadX509KeyPair(certFile, keyFile)
-			if err != nil {
-				return nil, errors.Wrapf(err, "Could not get the private key %s that matches %s", keyFile, certFile)
-			}
-			cert = &fallbackCerts
-		} else {
+		if loadKey == nil {
 			return nil, errors.WithMessage(err, "Could not load TLS certificate with BCCSP")
 		}
-
+		log.Debugf("Could not load TLS certificate with BCCSP: %s", err)
+		keyPEM, err := loadKey()
+		if err != nil {
+			return nil, errors.Wrapf(err, "Could not read the private key %s", keySource)
+		}
+		fallbackCerts, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Could not get the private key %s that matches %s", keySource, certSource)
+		}
+		cert = &fallbackCerts
 	}
 
 	return cert, nil
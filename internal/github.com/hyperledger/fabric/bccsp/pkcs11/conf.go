@@ -25,6 +25,7 @@ import (
 	"encoding/asn1"
 	"fmt"
 	"hash"
+	"time"
 
 	"golang.org/x/crypto/sha3"
 )
@@ -101,6 +102,11 @@ type PKCS11Opts struct {
 	Pin        string `mapstructure:"pin" json:"pin"`
 	Sensitive  bool   `mapstructure:"sensitivekeys,omitempty" json:"sensitivekeys,omitempty"`
 	SoftVerify bool   `mapstructure:"softwareverify,omitempty" json:"softwareverify,omitempty"`
+
+	// Session pool options, the defaults of the pool are used when not specified
+	SessionCacheSize int           `mapstructure:"sessioncachesize,omitempty" json:"sessioncachesize,omitempty"`
+	SessionTimeout   time.Duration `mapstructure:"sessiontimeout,omitempty" json:"sessiontimeout,omitempty"`
+	SessionRetries   int           `mapstructure:"sessionretries,omitempty" json:"sessionretries,omitempty"`
 }
 
 // Since currently only ECDSA operations go to PKCS11, need a keystore still
@@ -32,13 +32,13 @@ import (
 	"github.com/hyperledger/fabric-sdk-go/internal/github.com/hyperledger/fabric/bccsp/sw"
 	"github.com/hyperledger/fabric-sdk-go/internal/github.com/hyperledger/fabric/bccsp/utils"
 	flogging "github.com/hyperledger/fabric-sdk-go/internal/github.com/hyperledger/fabric/sdkpatch/logbridge"
+	sessionpool "github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite/common/pkcs11"
 	"github.com/miekg/pkcs11"
 	"github.com/pkg/errors"
 )
 
 var (
-	logger           = flogging.MustGetLogger("bccsp_p11")
-	sessionCacheSize = 10
+	logger = flogging.MustGetLogger("bccsp_p11")
 )
 
 // New returns a new instance of the software-based BCCSP
@@ -64,18 +64,37 @@ func New(opts PKCS11Opts, keyStore bccsp.KeyStore) (bccsp.BCCSP, error) {
 	lib := opts.Library
 	pin := opts.Pin
 	label := opts.Label
-	ctx, slot, session, err := loadLib(lib, pin, label)
+	ctx, slot, err := loadLib(lib, pin, label)
 	if err != nil {
 		return nil, errors.Wrapf(err, "Failed initializing PKCS11 library %s %s",
 			lib, label)
 	}
 
-	sessions := make(chan pkcs11.SessionHandle, sessionCacheSize)
+	sessions, err := sessionpool.NewSessionPool(ctx, slot, pin, sessionPoolOpts(opts)...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed initializing PKCS11 sessions %s %s",
+			lib, label)
+	}
+
 	csp := &impl{swCSP, conf, keyStore, ctx, sessions, slot, lib, opts.Sensitive, opts.SoftVerify}
-	csp.returnSession(*session)
 	return csp, nil
 }
 
+// sessionPoolOpts returns the options of the session pool, the defaults of the pool are used for the unset options
+func sessionPoolOpts(opts PKCS11Opts) []sessionpool.Option {
+	var poolOpts []sessionpool.Option
+	if opts.SessionCacheSize > 0 {
+		poolOpts = append(poolOpts, sessionpool.WithSize(opts.SessionCacheSize))
+	}
+	if opts.SessionTimeout > 0 {
+		poolOpts = append(poolOpts, sessionpool.WithTimeout(opts.SessionTimeout))
+	}
+	if opts.SessionRetries > 0 {
+		poolOpts = append(poolOpts, sessionpool.WithRetries(opts.SessionRetries))
+	}
+	return poolOpts
+}
+
 type impl struct {
 	bccsp.BCCSP
 
@@ -83,7 +102,7 @@ type impl struct {
 	ks   bccsp.KeyStore
 
 	ctx      *pkcs11.Ctx
-	sessions chan pkcs11.SessionHandle
+	sessions *sessionpool.SessionPool
 	slot     uint
 
 	lib          string
@@ -91,6 +110,11 @@ type impl struct {
 	softVerify   bool
 }
 
+// SessionMetrics returns the session churn counters of the PKCS11 sessions
+func (csp *impl) SessionMetrics() sessionpool.Metrics {
+	return csp.sessions.Metrics()
+}
+
 // KeyGen generates a key using opts.
 func (csp *impl) KeyGen(opts bccsp.KeyGenOpts) (k bccsp.Key, err error) {
 	// Validate arguments
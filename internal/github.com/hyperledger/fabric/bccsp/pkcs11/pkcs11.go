@@ -22,24 +22,25 @@ import (
 
 	logging "github.com/hyperledger/fabric-sdk-go/internal/github.com/hyperledger/fabric/sdkpatch/logbridge"
 	"github.com/miekg/pkcs11"
+	"github.com/pkg/errors"
 )
 
-func loadLib(lib, pin, label string) (*pkcs11.Ctx, uint, *pkcs11.SessionHandle, error) {
+func loadLib(lib, pin, label string) (*pkcs11.Ctx, uint, error) {
 	var slot uint = 0
 	logger.Debugf("Loading pkcs11 library [%s]\n", lib)
 	if lib == "" {
-		return nil, slot, nil, fmt.Errorf("No PKCS11 library default")
+		return nil, slot, fmt.Errorf("No PKCS11 library default")
 	}
 
 	ctx := pkcs11.New(lib)
 	if ctx == nil {
-		return nil, slot, nil, fmt.Errorf("Instantiate failed [%s]", lib)
+		return nil, slot, fmt.Errorf("Instantiate failed [%s]", lib)
 	}
 
 	ctx.Initialize()
 	slots, err := ctx.GetSlotList(true)
 	if err != nil {
-		return nil, slot, nil, fmt.Errorf("Could not get Slot List [%s]", err)
+		return nil, slot, fmt.Errorf("Could not get Slot List [%s]", err)
 	}
 	found := false
 	for _, s := range slots {
@@ -55,78 +56,28 @@ func loadLib(lib, pin, label string) (*pkcs11.Ctx, uint, *pkcs11.SessionHandle,
 		}
 	}
 	if !found {
-		return nil, slot, nil, fmt.Errorf("Could not find token with label %s", label)
+		return nil, slot, fmt.Errorf("Could not find token with label %s", label)
 	}
 
-	var session pkcs11.SessionHandle
-	for i := 0; i < 10; i++ {
-		session, err = ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION|pkcs11.CKF_RW_SESSION)
-		if err != nil {
-			logger.Warningf("OpenSession failed, retrying [%s]\n", err)
-		} else {
-			break
-		}
-	}
-	if err != nil {
-		logger.Fatalf("OpenSession [%s]\n", err)
-	}
-	logger.Debugf("Created new pkcs11 session %+v on slot %d\n", session, slot)
-
 	if pin == "" {
-		return nil, slot, nil, fmt.Errorf("No PIN set\n")
-	}
-	err = ctx.Login(session, pkcs11.CKU_USER, pin)
-	if err != nil {
-		if err != pkcs11.Error(pkcs11.CKR_USER_ALREADY_LOGGED_IN) {
-			return nil, slot, nil, fmt.Errorf("Login failed [%s]\n", err)
-		}
+		return nil, slot, fmt.Errorf("No PIN set\n")
 	}
 
-	return ctx, slot, &session, nil
-}
-
-func (csp *impl) getSession() (session pkcs11.SessionHandle) {
-	select {
-	case session = <-csp.sessions:
-		logger.Debugf("Reusing existing pkcs11 session %+v on slot %d\n", session, csp.slot)
-
-	default:
-		// cache is empty (or completely in use), create a new session
-		var s pkcs11.SessionHandle
-		var err error = nil
-		for i := 0; i < 10; i++ {
-			s, err = csp.ctx.OpenSession(csp.slot, pkcs11.CKF_SERIAL_SESSION|pkcs11.CKF_RW_SESSION)
-			if err != nil {
-				logger.Warningf("OpenSession failed, retrying [%s]\n", err)
-			} else {
-				break
-			}
-		}
-		if err != nil {
-			panic(fmt.Errorf("OpenSession failed [%s]\n", err))
-		}
-		logger.Debugf("Created new pkcs11 session %+v on slot %d\n", s, csp.slot)
-		session = s
-	}
-	return session
-}
-
-func (csp *impl) returnSession(session pkcs11.SessionHandle) {
-	select {
-	case csp.sessions <- session:
-		// returned session back to session cache
-	default:
-		// have plenty of sessions in cache, dropping
-		csp.ctx.CloseSession(session)
-	}
+	return ctx, slot, nil
 }
 
 // Look for an EC key by SKI, stored in CKA_ID
 // This function can probably be adapted for both EC and RSA keys.
 func (csp *impl) getECKey(ski []byte) (pubKey *ecdsa.PublicKey, isPriv bool, err error) {
+	err = csp.sessions.Do(func(session pkcs11.SessionHandle) (opErr error) {
+		pubKey, isPriv, opErr = csp.getECKeyWithSession(session, ski)
+		return
+	})
+	return pubKey, isPriv, err
+}
+
+func (csp *impl) getECKeyWithSession(session pkcs11.SessionHandle, ski []byte) (pubKey *ecdsa.PublicKey, isPriv bool, err error) {
 	p11lib := csp.ctx
-	session := csp.getSession()
-	defer csp.returnSession(session)
 	isPriv = true
 	_, err = findKeyPairFromSKI(p11lib, session, ski, privateKeyFlag)
 	if err != nil {
@@ -136,12 +87,12 @@ func (csp *impl) getECKey(ski []byte) (pubKey *ecdsa.PublicKey, isPriv bool, err
 
 	publicKey, err := findKeyPairFromSKI(p11lib, session, ski, publicKeyFlag)
 	if err != nil {
-		return nil, false, fmt.Errorf("Public key not found [%s] for SKI [%s]", err, hex.EncodeToString(ski))
+		return nil, false, errors.Wrapf(err, "Public key not found for SKI [%s]", hex.EncodeToString(ski))
 	}
 
 	ecpt, marshaledOid, err := ecPoint(p11lib, session, *publicKey)
 	if err != nil {
-		return nil, false, fmt.Errorf("Public key not found [%s] for SKI [%s]", err, hex.EncodeToString(ski))
+		return nil, false, errors.Wrapf(err, "Public key not found for SKI [%s]", hex.EncodeToString(ski))
 	}
 
 	curveOid := new(asn1.ObjectIdentifier)
@@ -215,9 +166,15 @@ func oidFromNamedCurve(curve elliptic.Curve) (asn1.ObjectIdentifier, bool) {
 }
 
 func (csp *impl) generateECKey(curve asn1.ObjectIdentifier, ephemeral bool) (ski []byte, pubKey *ecdsa.PublicKey, err error) {
+	err = csp.sessions.Do(func(session pkcs11.SessionHandle) (opErr error) {
+		ski, pubKey, opErr = csp.generateECKeyWithSession(session, curve, ephemeral)
+		return
+	})
+	return ski, pubKey, err
+}
+
+func (csp *impl) generateECKeyWithSession(session pkcs11.SessionHandle, curve asn1.ObjectIdentifier, ephemeral bool) (ski []byte, pubKey *ecdsa.PublicKey, err error) {
 	p11lib := csp.ctx
-	session := csp.getSession()
-	defer csp.returnSession(session)
 
 	id := nextIDCtr()
 	publabel := fmt.Sprintf("BCPUB%s", id.Text(16))
@@ -258,7 +215,7 @@ func (csp *impl) generateECKey(curve asn1.ObjectIdentifier, ephemeral bool) (ski
 		pubkey_t, prvkey_t)
 
 	if err != nil {
-		return nil, nil, fmt.Errorf("P11: keypair generate failed [%s]\n", err)
+		return nil, nil, errors.Wrap(err, "P11: keypair generate failed")
 	}
 
 	ecpt, _, _ := ecPoint(p11lib, session, pub)
@@ -274,12 +231,12 @@ func (csp *impl) generateECKey(curve asn1.ObjectIdentifier, ephemeral bool) (ski
 	logger.Infof("Generated new P11 key, SKI %x\n", ski)
 	err = p11lib.SetAttributeValue(session, pub, setski_t)
 	if err != nil {
-		return nil, nil, fmt.Errorf("P11: set-ID-to-SKI[public] failed [%s]\n", err)
+		return nil, nil, errors.Wrap(err, "P11: set-ID-to-SKI[public] failed")
 	}
 
 	err = p11lib.SetAttributeValue(session, prv, setski_t)
 	if err != nil {
-		return nil, nil, fmt.Errorf("P11: set-ID-to-SKI[private] failed [%s]\n", err)
+		return nil, nil, errors.Wrap(err, "P11: set-ID-to-SKI[private] failed")
 	}
 
 	nistCurve := namedCurveFromOID(curve)
@@ -302,25 +259,31 @@ func (csp *impl) generateECKey(curve asn1.ObjectIdentifier, ephemeral bool) (ski
 }
 
 func (csp *impl) signP11ECDSA(ski []byte, msg []byte) (R, S *big.Int, err error) {
+	err = csp.sessions.Do(func(session pkcs11.SessionHandle) (opErr error) {
+		R, S, opErr = csp.signP11ECDSAWithSession(session, ski, msg)
+		return
+	})
+	return R, S, err
+}
+
+func (csp *impl) signP11ECDSAWithSession(session pkcs11.SessionHandle, ski []byte, msg []byte) (R, S *big.Int, err error) {
 	p11lib := csp.ctx
-	session := csp.getSession()
-	defer csp.returnSession(session)
 
 	privateKey, err := findKeyPairFromSKI(p11lib, session, ski, privateKeyFlag)
 	if err != nil {
-		return nil, nil, fmt.Errorf("Private key not found [%s]\n", err)
+		return nil, nil, errors.Wrap(err, "Private key not found")
 	}
 
 	err = p11lib.SignInit(session, []*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_ECDSA, nil)}, *privateKey)
 	if err != nil {
-		return nil, nil, fmt.Errorf("Sign-initialize  failed [%s]\n", err)
+		return nil, nil, errors.Wrap(err, "Sign-initialize failed")
 	}
 
 	var sig []byte
 
 	sig, err = p11lib.Sign(session, msg)
 	if err != nil {
-		return nil, nil, fmt.Errorf("P11: sign failed [%s]\n", err)
+		return nil, nil, errors.Wrap(err, "P11: sign failed")
 	}
 
 	R = new(big.Int)
@@ -332,15 +295,21 @@ func (csp *impl) signP11ECDSA(ski []byte, msg []byte) (R, S *big.Int, err error)
 }
 
 func (csp *impl) verifyP11ECDSA(ski []byte, msg []byte, R, S *big.Int, byteSize int) (valid bool, err error) {
+	err = csp.sessions.Do(func(session pkcs11.SessionHandle) (opErr error) {
+		valid, opErr = csp.verifyP11ECDSAWithSession(session, ski, msg, R, S, byteSize)
+		return
+	})
+	return valid, err
+}
+
+func (csp *impl) verifyP11ECDSAWithSession(session pkcs11.SessionHandle, ski []byte, msg []byte, R, S *big.Int, byteSize int) (valid bool, err error) {
 	p11lib := csp.ctx
-	session := csp.getSession()
-	defer csp.returnSession(session)
 
 	logger.Debugf("Verify ECDSA\n")
 
 	publicKey, err := findKeyPairFromSKI(p11lib, session, ski, publicKeyFlag)
 	if err != nil {
-		return false, fmt.Errorf("Public key not found [%s]\n", err)
+		return false, errors.Wrap(err, "Public key not found")
 	}
 
 	r := R.Bytes()
@@ -354,14 +323,14 @@ func (csp *impl) verifyP11ECDSA(ski []byte, msg []byte, R, S *big.Int, byteSize
 	err = p11lib.VerifyInit(session, []*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_ECDSA, nil)},
 		*publicKey)
 	if err != nil {
-		return false, fmt.Errorf("PKCS11: Verify-initialize [%s]\n", err)
+		return false, errors.Wrap(err, "PKCS11: Verify-initialize failed")
 	}
 	err = p11lib.Verify(session, msg, sig)
 	if err == pkcs11.Error(pkcs11.CKR_SIGNATURE_INVALID) {
 		return false, nil
 	}
 	if err != nil {
-		return false, fmt.Errorf("PKCS11: Verify failed [%s]\n", err)
+		return false, errors.Wrap(err, "PKCS11: Verify failed")
 	}
 
 	return true, nil
@@ -369,8 +338,6 @@ func (csp *impl) verifyP11ECDSA(ski []byte, msg []byte, R, S *big.Int, byteSize
 
 func (csp *impl) importECKey(curve asn1.ObjectIdentifier, privKey, ecPt []byte, ephemeral bool, keyType bool) (ski []byte, err error) {
 	p11lib := csp.ctx
-	session := csp.getSession()
-	defer csp.returnSession(session)
 
 	marshaledOID, err := asn1.Marshal(curve)
 	if err != nil {
@@ -425,13 +392,20 @@ func (csp *impl) importECKey(curve asn1.ObjectIdentifier, privKey, ecPt []byte,
 		}
 	}
 
-	keyHandle, err := p11lib.CreateObject(session, keyTemplate)
-	if err != nil {
-		return nil, fmt.Errorf("P11: keypair generate failed [%s]\n", err)
-	}
+	// the session is borrowed after the public key of a private key was imported
+	err = csp.sessions.Do(func(session pkcs11.SessionHandle) error {
+		keyHandle, err := p11lib.CreateObject(session, keyTemplate)
+		if err != nil {
+			return errors.Wrap(err, "P11: keypair generate failed")
+		}
 
-	if logger.IsEnabledFor(logging.DEBUG) {
-		listAttrs(p11lib, session, keyHandle)
+		if logger.IsEnabledFor(logging.DEBUG) {
+			listAttrs(p11lib, session, keyHandle)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return ski, nil
@@ -521,7 +495,7 @@ func ecPoint(p11lib *pkcs11.Ctx, session pkcs11.SessionHandle, key pkcs11.Object
 
 	attr, err := p11lib.GetAttributeValue(session, key, template)
 	if err != nil {
-		return nil, nil, fmt.Errorf("PKCS11: get(EC point) [%s]\n", err)
+		return nil, nil, errors.Wrap(err, "PKCS11: get(EC point) failed")
 	}
 
 	for _, a := range attr {
@@ -581,9 +555,19 @@ func listAttrs(p11lib *pkcs11.Ctx, session pkcs11.SessionHandle, obj pkcs11.Obje
 }
 
 func (csp *impl) getSecretValue(ski []byte) []byte {
+	var value []byte
+	err := csp.sessions.Do(func(session pkcs11.SessionHandle) error {
+		value = csp.getSecretValueWithSession(session, ski)
+		return nil
+	})
+	if err != nil {
+		logger.Warningf("P11: get secret value failed [%s]\n", err)
+	}
+	return value
+}
+
+func (csp *impl) getSecretValueWithSession(session pkcs11.SessionHandle, ski []byte) []byte {
 	p11lib := csp.ctx
-	session := csp.getSession()
-	defer csp.returnSession(session)
 
 	keyHandle, err := findKeyPairFromSKI(p11lib, session, ski, privateKeyFlag)
 
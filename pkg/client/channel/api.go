@@ -7,10 +7,13 @@ SPDX-License-Identifier: Apache-2.0
 package channel
 
 import (
+	"context"
 	"time"
 
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel/invoke"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
 	"github.com/hyperledger/fabric-sdk-go/pkg/errors/retry"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/peer"
 	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
 )
 
@@ -29,9 +32,17 @@ type Registration interface {
 
 // opts allows the user to specify more advanced options
 type opts struct {
-	ProposalProcessors []fab.ProposalProcessor // targets
-	Timeout            time.Duration
-	Retry              retry.Opts
+	ProposalProcessors  []fab.ProposalProcessor // targets
+	Timeout             time.Duration
+	Retry               retry.Opts
+	PeerGroup           string
+	Budget              *invoke.DeadlineBudget
+	TargetFilter        fab.TargetFilter
+	TargetURLs          []string
+	ResponseValidators  []invoke.ResponseValidator
+	EndorsementMajority bool
+	HandlerChain        []invoke.Handler
+	Timeouts            invoke.Timeouts
 }
 
 //Option func for each Opts argument
@@ -39,9 +50,11 @@ type Option func(opts *opts) error
 
 // Request contains the parameters to query and execute an invocation transaction
 type Request struct {
-	ChaincodeID  string
-	Fcn          string
-	Args         [][]byte
+	ChaincodeID string
+	Fcn         string
+	Args        [][]byte
+	// TransientMap is private data that's sent to the endorsers with the proposal. It's
+	// excluded from the transaction that's sent to the orderer.
 	TransientMap map[string][]byte
 }
 
@@ -52,6 +65,26 @@ type Response struct {
 	TxValidationCode pb.TxValidationCode
 	Proposal         *fab.TransactionProposal
 	Responses        []*fab.TransactionProposalResponse
+	// SignedEnvelope is only set by handler chains that sign the transaction without sending it
+	// (see invoke.NewSignedEnvelopeHandler)
+	SignedEnvelope *fab.SignedEnvelope
+}
+
+// TxCommitStatus contains the final status of a transaction that was executed with ExecuteAsync.
+// Err is set if the transaction is invalid, or if its status isn't known because the commit wasn't
+// received (e.g. the event hub was disconnected or the request timed out).
+type TxCommitStatus struct {
+	TxID           fab.TransactionID
+	ValidationCode pb.TxValidationCode
+	BlockNumber    uint64
+	Err            error
+}
+
+// Timeouts are the time limits of the stages of an invocation (see WithTimeouts)
+type Timeouts struct {
+	Endorsement time.Duration
+	Broadcast   time.Duration
+	Commit      time.Duration
 }
 
 //WithTimeout encapsulates time.Duration to Option
@@ -62,6 +95,19 @@ func WithTimeout(timeout time.Duration) Option {
 	}
 }
 
+// WithTimeouts option to limit the time of each stage of the invocation independently, so that e.g. the
+// endorsement fails fast while the commit may take longer. If a stage times out then an
+// *invoke.EndorsementTimeoutError (which lists the endorsers that did and didn't respond),
+// *invoke.BroadcastTimeoutError or *invoke.CommitTimeoutError is returned. Stages without a timeout
+// default to the endorser and orderer response timeouts of the configuration, and to the timeout of
+// the request for the commit. The overall timeout of the request still applies.
+func WithTimeouts(timeouts Timeouts) Option {
+	return func(o *opts) error {
+		o.Timeouts = invoke.Timeouts(timeouts)
+		return nil
+	}
+}
+
 //WithProposalProcessor encapsulates ProposalProcessors to Option
 func WithProposalProcessor(proposalProcessors ...fab.ProposalProcessor) Option {
 	return func(o *opts) error {
@@ -70,10 +116,115 @@ func WithProposalProcessor(proposalProcessors ...fab.ProposalProcessor) Option {
 	}
 }
 
-// WithRetry option to configure retries
+// WithTargets option to send the proposal to the given peers instead of the endorsers that
+// are selected for the chaincode
+func WithTargets(targets ...fab.Peer) Option {
+	return func(o *opts) error {
+		o.ProposalProcessors = peer.PeersToTxnProcessors(targets)
+		return nil
+	}
+}
+
+// WithTargetURLs option to send the proposal to the peers with the given URLs. The peers are
+// looked up by URL among the channel's peers and then in the network configuration, so that
+// the TLS settings of the configuration apply. An error is returned if a URL isn't configured.
+func WithTargetURLs(urls ...string) Option {
+	return func(o *opts) error {
+		o.TargetURLs = urls
+		return nil
+	}
+}
+
+// WithTargetFilter option to only send the proposal to the targets (the given targets or the
+// endorsers that are selected for the chaincode) that are accepted by the filter. An
+// *invoke.NoTargetsError is returned if the filter doesn't accept any of the targets.
+func WithTargetFilter(filter fab.TargetFilter) Option {
+	return func(o *opts) error {
+		o.TargetFilter = filter
+		return nil
+	}
+}
+
+// WithEndorsementMajority option to accept the proposal responses of more than half of the endorsers
+// if the responses of the endorsers don't all match. By default an *invoke.EndorsementMismatchError
+// is returned if any of the responses differ.
+func WithEndorsementMajority() Option {
+	return func(o *opts) error {
+		o.EndorsementMajority = true
+		return nil
+	}
+}
+
+// WithResponseValidators option to validate the proposal responses with the given validators, which
+// are run in order after the responses of the endorsers have been checked for consistency
+func WithResponseValidators(validators ...invoke.ResponseValidator) Option {
+	return func(o *opts) error {
+		o.ResponseValidators = append(o.ResponseValidators, validators...)
+		return nil
+	}
+}
+
+// WithHandlerChain option to run the given handlers, in order, instead of the default handlers of Execute
+// or Query. The chain stops at the first handler that fails. The default handlers are available as
+// constructors in the invoke package, e.g. to endorse a transaction and return its signed envelope
+// without sending it to the orderer:
+//
+//	WithHandlerChain(invoke.NewQueryHandler(), invoke.NewSignedEnvelopeHandler())
+func WithHandlerChain(handlers ...invoke.Handler) Option {
+	return func(o *opts) error {
+		o.HandlerChain = handlers
+		return nil
+	}
+}
+
+// WithRetry option to configure retries (see retry.DefaultChannelOpts). Each attempt of an Execute
+// creates a new transaction ID. Once the transaction has been sent to the orderer it's only retried if
+// it was invalidated with a retryable validation code (e.g. MVCC_READ_CONFLICT). If the options don't
+// specify any attempts then Execute and Query use the retry policy of the client configuration
+// (client.channelClient.retry) if there's one.
 func WithRetry(retryOpt retry.Opts) Option {
 	return func(o *opts) error {
 		o.Retry = retryOpt
 		return nil
 	}
 }
+
+// WithPeerGroup option to select the targets from the given peer group (defined in
+// the network configuration) using weighted random selection. This option is
+// ignored if the targets are provided using WithProposalProcessor.
+func WithPeerGroup(name string) Option {
+	return func(o *opts) error {
+		o.PeerGroup = name
+		return nil
+	}
+}
+
+// WithDeadlineBudget option to split the given timeout across the endorse, broadcast and commit
+// stages of an invocation. The time for each stage is allocated when the stage starts, according
+// to the given allocations (or invoke.DefaultStageAllocations), so that time not used by a stage
+// is given to the subsequent stages. If a stage runs out of time then an
+// invoke.DeadlineExceededError naming the stage is returned.
+func WithDeadlineBudget(timeout time.Duration, allocations ...invoke.StageAllocation) Option {
+	return func(o *opts) error {
+		budget, err := invoke.NewDeadlineBudget(time.Now().Add(timeout), nil, allocations...)
+		if err != nil {
+			return err
+		}
+		o.Budget = budget
+		return nil
+	}
+}
+
+// WithDeadlineBudgetContext option to split the time remaining until the deadline of the given
+// context across the stages of an invocation (see WithDeadlineBudget). The invocation is aborted
+// if the context is cancelled.
+func WithDeadlineBudgetContext(ctx context.Context, allocations ...invoke.StageAllocation) Option {
+	return func(o *opts) error {
+		budget, err := invoke.NewDeadlineBudgetFromContext(ctx, allocations...)
+		if err != nil {
+			return err
+		}
+		o.Budget = budget
+		return nil
+	}
+}
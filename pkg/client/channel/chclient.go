@@ -8,6 +8,7 @@ SPDX-License-Identifier: Apache-2.0
 package channel
 
 import (
+	"fmt"
 	"reflect"
 	"time"
 
@@ -16,11 +17,14 @@ import (
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel/invoke"
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/common/discovery"
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/common/discovery/greylist"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/common/selection/peergroup"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config/urlutil"
 	"github.com/hyperledger/fabric-sdk-go/pkg/errors/multi"
 	"github.com/hyperledger/fabric-sdk-go/pkg/errors/retry"
 	"github.com/hyperledger/fabric-sdk-go/pkg/errors/status"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/peer"
 	"github.com/hyperledger/fabric-sdk-go/pkg/logging"
 	"github.com/pkg/errors"
 )
@@ -29,6 +33,7 @@ var logger = logging.NewLogger("fabric_sdk_go")
 
 const (
 	defaultHandlerTimeout = time.Second * 10
+	budgetGracePeriod     = time.Second
 )
 
 // Client enables access to a channel on a Fabric network.
@@ -44,6 +49,7 @@ type Client struct {
 	transactor fab.Transactor
 	eventHub   fab.EventHub
 	greylist   *greylist.Filter
+	peerGroups *peergroup.Selector
 }
 
 // Context holds the providers and services needed to create a Client.
@@ -56,7 +62,8 @@ type Context struct {
 
 // New returns a Client instance.
 func New(c Context) (*Client, error) {
-	greylistProvider := greylist.New(c.Config().TimeoutOrDefault(core.DiscoveryGreylistExpiry))
+	config := c.Config()
+	greylistProvider := greylist.New(config.Timeout(core.DiscoveryGreylistExpiry))
 
 	eventHub, err := c.ChannelService.EventHub()
 	if err != nil {
@@ -74,6 +81,11 @@ func New(c Context) (*Client, error) {
 		return nil, errors.WithMessage(err, "channel client creation failed")
 	}
 
+	peerGroups, err := peergroup.New(config)
+	if err != nil {
+		return nil, errors.WithMessage(err, "peer group selector creation failed")
+	}
+
 	channelClient := Client{
 		greylist:   greylistProvider,
 		context:    c,
@@ -82,6 +94,7 @@ func New(c Context) (*Client, error) {
 		channel:    channel,
 		transactor: transactor,
 		eventHub:   eventHub,
+		peerGroups: peerGroups,
 	}
 
 	return &channelClient, nil
@@ -89,12 +102,32 @@ func New(c Context) (*Client, error) {
 
 // Query chaincode using request and optional options provided
 func (cc *Client) Query(request Request, options ...Option) (Response, error) {
-	return cc.InvokeHandler(invoke.NewQueryHandler(), request, cc.addDefaultTimeout(core.Query, options...)...)
+	options = cc.addDefaultRetry(cc.addDefaultTimeout(core.Query, options...)...)
+	return cc.InvokeHandler(handlerOrChain(invoke.NewQueryHandler(), options...), request, options...)
 }
 
 // Execute prepares and executes transaction using request and optional options provided
 func (cc *Client) Execute(request Request, options ...Option) (Response, error) {
-	return cc.InvokeHandler(invoke.NewExecuteHandler(), request, cc.addDefaultTimeout(core.Execute, options...)...)
+	options = cc.addDefaultRetry(cc.addDefaultTimeout(core.Execute, options...)...)
+	return cc.InvokeHandler(handlerOrChain(invoke.NewExecuteHandler(), options...), request, options...)
+}
+
+// ExecuteAsync prepares a transaction and returns once it has been accepted by the orderer, without
+// waiting for it to be committed. The final status of the transaction is sent exactly once on the
+// returned channel. The validation code of the response isn't set. The commit is awaited for the
+// timeout of the request; the transaction isn't retried once it has been sent to the orderer.
+func (cc *Client) ExecuteAsync(request Request, options ...Option) (Response, <-chan TxCommitStatus, error) {
+	commitStatus := make(chan TxCommitStatus, 1)
+	notify := func(s invoke.TxStatus) {
+		commitStatus <- TxCommitStatus(s)
+	}
+
+	options = cc.addDefaultRetry(cc.addDefaultTimeout(core.Execute, options...)...)
+	resp, err := cc.InvokeHandler(invoke.NewExecuteAsyncHandler(notify), request, options...)
+	if err != nil {
+		return resp, nil, err
+	}
+	return resp, commitStatus, nil
 }
 
 //InvokeHandler invokes handler using request and options provided
@@ -105,6 +138,13 @@ func (cc *Client) InvokeHandler(handler invoke.Handler, request Request, options
 		return Response{}, err
 	}
 
+	if len(txnOpts.TargetURLs) > 0 {
+		txnOpts.ProposalProcessors, err = cc.resolveTargetURLs(txnOpts.TargetURLs)
+		if err != nil {
+			return Response{}, err
+		}
+	}
+
 	//Prepare context objects for handler
 	requestContext, clientContext, err := cc.prepareHandlerContexts(request, txnOpts)
 	if err != nil {
@@ -137,6 +177,11 @@ func (cc *Client) resolveRetry(ctx *invoke.RequestContext, o opts) bool {
 		errs = append(errs, ctx.Error)
 	}
 	for _, e := range errs {
+		// Once the transaction has been broadcast it may still be committed, so it's only
+		// retried if it was invalidated (the retry creates a new transaction ID)
+		if ctx.Broadcast && !isValidationError(e) {
+			continue
+		}
 		if ctx.RetryHandler.Required(e) {
 			logger.Infof("Retrying on error %s", e)
 			cc.greylist.Greylist(e)
@@ -145,6 +190,7 @@ func (cc *Client) resolveRetry(ctx *invoke.RequestContext, o opts) bool {
 			ctx.Opts.ProposalProcessors = o.ProposalProcessors
 			ctx.Error = nil
 			ctx.Response = invoke.Response{}
+			ctx.Broadcast = false
 
 			return true
 		}
@@ -152,6 +198,12 @@ func (cc *Client) resolveRetry(ctx *invoke.RequestContext, o opts) bool {
 	return false
 }
 
+// isValidationError returns true if the error is a transaction validation code reported by the event hub
+func isValidationError(err error) bool {
+	s, ok := status.FromError(err)
+	return ok && s.Group == status.EventServerStatus
+}
+
 //prepareHandlerContexts prepares context objects for handlers
 func (cc *Client) prepareHandlerContexts(request Request, o opts) (*invoke.RequestContext, *invoke.ClientContext, error) {
 
@@ -165,6 +217,7 @@ func (cc *Client) prepareHandlerContexts(request Request, o opts) (*invoke.Reque
 		Channel:    cc.channel,
 		Transactor: cc.transactor,
 		EventHub:   cc.eventHub,
+		PeerGroups: cc.peerGroups,
 	}
 
 	requestContext := &invoke.RequestContext{
@@ -178,9 +231,30 @@ func (cc *Client) prepareHandlerContexts(request Request, o opts) (*invoke.Reque
 		requestContext.Opts.Timeout = defaultHandlerTimeout
 	}
 
+	if o.Budget == nil {
+		requestContext.Opts.Timeouts = cc.defaultTimeouts(o.Timeouts)
+	} else {
+		// The stages are bounded by the budget so only wait a little longer than the
+		// deadline in order to give the handler a chance to report the stage that timed out
+		requestContext.Opts.Timeout = time.Until(o.Budget.Deadline()) + budgetGracePeriod
+	}
+
 	return requestContext, clientContext, nil
 }
 
+// defaultTimeouts sets the endorsement and broadcast timeouts that aren't provided to the endorser and
+// orderer response timeouts of the configuration. The commit timeout defaults to the request timeout.
+func (cc *Client) defaultTimeouts(timeouts invoke.Timeouts) invoke.Timeouts {
+	config := cc.context.Config()
+	if timeouts.Endorsement == 0 {
+		timeouts.Endorsement = config.Timeout(core.Endorser)
+	}
+	if timeouts.Broadcast == 0 {
+		timeouts.Broadcast = config.Timeout(core.OrdererResponse)
+	}
+	return timeouts
+}
+
 //prepareOptsFromOptions Reads apitxn.Opts from Option array
 func (cc *Client) prepareOptsFromOptions(options ...Option) (opts, error) {
 	txnOpts := opts{}
@@ -193,6 +267,19 @@ func (cc *Client) prepareOptsFromOptions(options ...Option) (opts, error) {
 	return txnOpts, nil
 }
 
+// handlerOrChain returns the handler chain of the options (see WithHandlerChain) if there's one,
+// otherwise the given default handler
+func handlerOrChain(defaultHandler invoke.Handler, options ...Option) invoke.Handler {
+	txnOpts := opts{}
+	for _, option := range options {
+		option(&txnOpts)
+	}
+	if len(txnOpts.HandlerChain) == 0 {
+		return defaultHandler
+	}
+	return invoke.NewChain(txnOpts.HandlerChain...)
+}
+
 //addDefaultTimeout adds given default timeout if it is missing in options
 func (cc *Client) addDefaultTimeout(timeOutType core.TimeoutType, options ...Option) []Option {
 	txnOpts := opts{}
@@ -201,11 +288,103 @@ func (cc *Client) addDefaultTimeout(timeOutType core.TimeoutType, options ...Opt
 	}
 
 	if txnOpts.Timeout == 0 {
-		return append(options, WithTimeout(cc.context.Config().TimeoutOrDefault(timeOutType)))
+		return append(options, WithTimeout(cc.context.Config().Timeout(timeOutType)))
 	}
 	return options
 }
 
+// resolveTargetURLs returns the peers with the given URLs. The peers are looked up among the channel's peers
+// and then in the network configuration, so that the TLS settings of the configuration apply.
+func (cc *Client) resolveTargetURLs(urls []string) ([]fab.ProposalProcessor, error) {
+	channelPeers, err := cc.discovery.GetPeers()
+	if err != nil {
+		return nil, errors.WithMessage(err, "GetPeers failed")
+	}
+
+	var netPeers []core.NetworkPeer
+	var targets []fab.ProposalProcessor
+	for _, url := range urls {
+		if p := findPeerByURL(channelPeers, url); p != nil {
+			targets = append(targets, p)
+			continue
+		}
+
+		if netPeers == nil {
+			netPeers, err = cc.context.Config().NetworkPeers()
+			if err != nil {
+				return nil, errors.WithMessage(err, "unable to read configuration for network peers")
+			}
+		}
+		peerCfg := findPeerConfigByURL(netPeers, url)
+		if peerCfg == nil {
+			return nil, status.New(status.ClientStatus, status.NoPeersFound.ToInt32(),
+				fmt.Sprintf("peer with URL %s isn't in the network configuration", url), nil)
+		}
+		p, err := peer.New(cc.context.Config(), peer.FromPeerConfig(peerCfg), peer.WithConnector(cc.context.Connector()))
+		if err != nil {
+			return nil, errors.WithMessage(err, "NewPeerFromConfig failed")
+		}
+		targets = append(targets, p)
+	}
+	return targets, nil
+}
+
+func findPeerByURL(peers []fab.Peer, url string) fab.Peer {
+	for _, p := range peers {
+		if urlutil.ToAddress(p.URL()) == urlutil.ToAddress(url) {
+			return p
+		}
+	}
+	return nil
+}
+
+func findPeerConfigByURL(peers []core.NetworkPeer, url string) *core.NetworkPeer {
+	for i := range peers {
+		if urlutil.ToAddress(peers[i].URL) == urlutil.ToAddress(url) {
+			return &peers[i]
+		}
+	}
+	return nil
+}
+
+// addDefaultRetry adds the retry policy from the client configuration if the options don't specify retry attempts
+func (cc *Client) addDefaultRetry(options ...Option) []Option {
+	txnOpts := opts{}
+	for _, option := range options {
+		option(&txnOpts)
+	}
+	if txnOpts.Retry.Attempts != 0 {
+		return options
+	}
+
+	client, err := cc.context.Config().Client()
+	if err != nil {
+		logger.Warnf("Unable to read the default retry policy from the client config: %s", err)
+		return options
+	}
+	if client.ChannelClient.Retry.Attempts == 0 {
+		return options
+	}
+	return append(options, WithRetry(retryOptsFromConfig(client.ChannelClient.Retry)))
+}
+
+// retryOptsFromConfig returns the retry options for a policy from the client configuration.
+// Unset values are taken from retry.DefaultChannelOpts.
+func retryOptsFromConfig(c core.RetryConfig) retry.Opts {
+	o := retry.DefaultChannelOpts
+	o.Attempts = c.Attempts
+	if c.InitialBackoff > 0 {
+		o.InitialBackoff = c.InitialBackoff
+	}
+	if c.MaxBackoff > 0 {
+		o.MaxBackoff = c.MaxBackoff
+	}
+	if c.BackoffFactor > 0 {
+		o.BackoffFactor = c.BackoffFactor
+	}
+	return o
+}
+
 // Close releases channel client resources (disconnects event hub etc.)
 func (cc *Client) Close() error {
 	if cc.eventHub.IsConnected() == true {
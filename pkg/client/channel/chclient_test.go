@@ -7,27 +7,47 @@ SPDX-License-Identifier: Apache-2.0
 package channel
 
 import (
+	reqContext "context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
 	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/hyperledger/fabric-sdk-go/internal/github.com/hyperledger/fabric/msp"
+	po "github.com/hyperledger/fabric-sdk-go/internal/github.com/hyperledger/fabric/protos/orderer"
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel/invoke"
 	txnmocks "github.com/hyperledger/fabric-sdk-go/pkg/client/common/mocks"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+	bccspwrapper "github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite/bccsp/wrapper"
 	"github.com/hyperledger/fabric-sdk-go/pkg/errors/retry"
 	"github.com/hyperledger/fabric-sdk-go/pkg/errors/status"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/channel"
+	fabcomm "github.com/hyperledger/fabric-sdk-go/pkg/fab/comm"
+	deliverconn "github.com/hyperledger/fabric-sdk-go/pkg/fab/events/deliverclient/connection"
+	eventmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/events/mocks"
+	idmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/identitymgr/mocks"
 	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/orderer"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/peer"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/signingmgr"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/txn"
 	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
 	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+	protos_utils "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/utils"
+	"google.golang.org/grpc"
+	grpcCodes "google.golang.org/grpc/codes"
 )
 
 const (
@@ -222,6 +242,68 @@ func TestExecuteTx(t *testing.T) {
 
 }
 
+type optsRecordingHandler struct {
+	opts invoke.Opts
+}
+
+func (h *optsRecordingHandler) Handle(requestContext *invoke.RequestContext, clientContext *invoke.ClientContext) {
+	h.opts = requestContext.Opts
+}
+
+func TestExecuteTxWithDeadlineBudget(t *testing.T) {
+	chClient := setupChannelClient(nil, t)
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}
+
+	handler := &optsRecordingHandler{}
+	_, err := chClient.InvokeHandler(handler, request, WithDeadlineBudget(2*time.Second))
+	if err != nil {
+		t.Fatalf("Failed to invoke handler with deadline budget: %s", err)
+	}
+	if handler.opts.Budget == nil {
+		t.Fatalf("Expecting deadline budget to be passed to the handler")
+	}
+	if handler.opts.Timeout > 2*time.Second+budgetGracePeriod || handler.opts.Timeout < 2*time.Second {
+		t.Fatalf("Expecting request timeout to be derived from the budget but got %s", handler.opts.Timeout)
+	}
+
+	_, err = chClient.Execute(request, WithDeadlineBudgetContext(reqContext.Background()))
+	if err == nil {
+		t.Fatalf("Should have failed for context without a deadline")
+	}
+
+	_, err = chClient.Execute(request, WithDeadlineBudget(time.Second, invoke.StageAllocation{Stage: invoke.EndorseStage, Ratio: 1}))
+	if err == nil {
+		t.Fatalf("Should have failed for missing stage allocations")
+	}
+}
+
+func TestConfiguredTimeouts(t *testing.T) {
+	chClient := setupChannelClient(nil, t)
+	chClient.context.(Context).ProviderContext.(*fcmocks.MockContext).SetConfig(fcmocks.NewMockConfigWithTimeouts(map[core.TimeoutType]time.Duration{
+		core.Query:   7 * time.Second,
+		core.Execute: 9 * time.Second,
+	}))
+
+	for timeoutType, expected := range map[core.TimeoutType]time.Duration{core.Query: 7 * time.Second, core.Execute: 9 * time.Second} {
+		txnOpts, err := chClient.prepareOptsFromOptions(chClient.addDefaultTimeout(timeoutType)...)
+		if err != nil {
+			t.Fatalf("Failed to prepare options: %s", err)
+		}
+		if txnOpts.Timeout != expected {
+			t.Fatalf("Expecting the configured timeout %s but got %s", expected, txnOpts.Timeout)
+		}
+	}
+
+	// The timeout option takes precedence
+	txnOpts, err := chClient.prepareOptsFromOptions(chClient.addDefaultTimeout(core.Query, WithTimeout(time.Second))...)
+	if err != nil {
+		t.Fatalf("Failed to prepare options: %s", err)
+	}
+	if txnOpts.Timeout != time.Second {
+		t.Fatalf("Expecting the timeout option to take precedence but got %s", txnOpts.Timeout)
+	}
+}
+
 type customHandler struct {
 	expectedPayload []byte
 }
@@ -613,7 +695,12 @@ func setupChannelClientWithError(discErr error, selectionErr error, peers []fab.
 func setupChannelClientWithNodes(peers []fab.Peer,
 	orderers []fab.Orderer, t *testing.T) *Client {
 
-	fabCtx := setupTestContext()
+	return setupChannelClientWithContext(setupTestContext(), peers, orderers, t)
+}
+
+func setupChannelClientWithContext(fabCtx context.Context, peers []fab.Peer,
+	orderers []fab.Orderer, t *testing.T) *Client {
+
 	testChannelSvc, err := setupTestChannelService(fabCtx, orderers)
 	assert.Nil(t, err, "Got error %s", err)
 
@@ -635,6 +722,153 @@ func setupChannelClientWithNodes(peers []fab.Peer,
 	return ch
 }
 
+// countingSigner counts the messages that are signed by the signing manager
+type countingSigner struct {
+	count int32
+}
+
+func (s *countingSigner) Sign(msg []byte, keyRef string) ([]byte, error) {
+	atomic.AddInt32(&s.count, 1)
+	return []byte("signature"), nil
+}
+
+func (s *countingSigner) signed() int32 {
+	return atomic.LoadInt32(&s.count)
+}
+
+func TestSigningManagerInvocations(t *testing.T) {
+	signer := &countingSigner{}
+	signingManager, err := signingmgr.NewWithSigner(signer)
+	if err != nil {
+		t.Fatalf("Failed to create signing manager: %s", err)
+	}
+
+	// the private key of the user is held by the signer
+	user := fcmocks.NewMockUser("test").(*fcmocks.MockUser)
+	user.SetPrivateKey(signingmgr.NewPrivateKeyRef(bccspwrapper.GetKey(&idmocks.MockKey{})))
+	fabCtx := &fcmocks.MockContext{
+		MockProviderContext: fcmocks.NewMockProviderContextCustom(fcmocks.NewMockConfig(), &fcmocks.MockCryptoSuite{}, signingManager),
+		IdentityContext:     user,
+	}
+
+	testPeer := newFlakyPeer(0, nil)
+	broadcasts := make(chan *fab.SignedEnvelope, 2)
+	chClient := setupChannelClientWithContext(fabCtx, []fab.Peer{testPeer}, []fab.Orderer{fcmocks.NewMockOrderer("", broadcasts)}, t)
+	mockEventHub := fcmocks.NewMockEventHub()
+	chClient.eventHub = mockEventHub
+	respondToTxEvents(t, mockEventHub, nil, nil)
+
+	// a query signs the proposal
+	_, err = chClient.Query(Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}})
+	assert.Nil(t, err, "expected query to succeed")
+	assert.EqualValues(t, 1, signer.signed(), "expected the proposal of the query to be signed once")
+
+	// an execute signs the proposal and the transaction envelope
+	for i := 1; i <= 2; i++ {
+		_, err = chClient.Execute(Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}})
+		assert.Nil(t, err, "expected execute to succeed")
+		assert.EqualValues(t, 1+2*i, signer.signed(), "expected the proposal and the envelope of each execute to be signed once")
+	}
+}
+
+// callCounter counts the calls and the streams that are intercepted
+type callCounter struct {
+	mutex   sync.Mutex
+	methods map[string]int
+}
+
+func (c *callCounter) add(ctx reqContext.Context, method string, t *testing.T) {
+	if _, ok := fabcomm.TargetFromContext(ctx); !ok {
+		t.Errorf("Expected the target of %s in the context", method)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.methods[method]++
+}
+
+func (c *callCounter) count(method string) int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.methods[method]
+}
+
+func TestGRPCInterceptorInvocations(t *testing.T) {
+	const numEndorsers = 2
+
+	counter := &callCounter{methods: make(map[string]int)}
+	unary := func(ctx reqContext.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		counter.add(ctx, method, t)
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+	stream := func(ctx reqContext.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		counter.add(ctx, method, t)
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+
+	fabCtx := setupTestContext().(*fcmocks.MockContext)
+	fabCtx.SetConnector(fabcomm.NewInterceptingConnector(&fabcomm.DirectConnector{},
+		[]grpc.UnaryClientInterceptor{unary}, []grpc.StreamClientInterceptor{stream}))
+
+	// the endorsements are signed, so that they pass the signature validation of Execute
+	endorserKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate endorser key: %s", err)
+	}
+
+	var peers []fab.Peer
+	for i := 0; i < numEndorsers; i++ {
+		url := startNodeServer(t, endorserKey)
+		p, err := peer.New(fabCtx.Config(), peer.WithURL(url), peer.WithConnector(fabCtx.Connector()))
+		if err != nil {
+			t.Fatalf("Failed to create peer: %s", err)
+		}
+		peers = append(peers, p)
+	}
+
+	ordererURL := startNodeServer(t, nil)
+	testOrderer, err := orderer.New(fabCtx.Config(), orderer.WithURL(ordererURL), orderer.WithInsecure(), orderer.WithConnector(fabCtx.Connector()))
+	if err != nil {
+		t.Fatalf("Failed to create orderer: %s", err)
+	}
+
+	chClient := setupChannelClientWithContext(fabCtx, peers, []fab.Orderer{testOrderer}, t)
+	mockEventHub := fcmocks.NewMockEventHub()
+	chClient.eventHub = mockEventHub
+	respondToTxEvents(t, mockEventHub, nil)
+
+	// the event client's deliver stream
+	deliverConn, err := deliverconn.New(fabCtx, "testChannel", deliverconn.Deliver, peers[0].URL())
+	if err != nil {
+		t.Fatalf("Failed to connect to deliver server: %s", err)
+	}
+	defer deliverConn.Close()
+
+	_, err = chClient.Execute(Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}})
+	if err != nil {
+		t.Fatalf("Failed to execute: %s", err)
+	}
+
+	assert.Equal(t, numEndorsers, counter.count("/protos.Endorser/ProcessProposal"), "Expected an intercepted call for each endorsement")
+	assert.Equal(t, 1, counter.count("/orderer.AtomicBroadcast/Broadcast"), "Expected an intercepted broadcast stream")
+	assert.Equal(t, 1, counter.count("/protos.Deliver/Deliver"), "Expected an intercepted deliver stream")
+}
+
+// startNodeServer starts a server with the endorser (signing its endorsements with the given key),
+// broadcast and deliver services and returns its URL
+func startNodeServer(t *testing.T, endorserKey crypto.Signer) string {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error starting test server: %s", err)
+	}
+	grpcServer := grpc.NewServer()
+	pb.RegisterEndorserServer(grpcServer, &fcmocks.MockEndorserServer{Signer: endorserKey})
+	po.RegisterAtomicBroadcastServer(grpcServer, &fcmocks.MockBroadcastServer{})
+	pb.RegisterDeliverServer(grpcServer, eventmocks.NewMockDeliverServer())
+	go grpcServer.Serve(lis)
+
+	return "grpc://" + lis.Addr().String()
+}
+
 func createAndSendTestTransactionProposal(sender fab.ProposalSender, chrequest *invoke.Request, targets []fab.ProposalProcessor) ([]*fab.TransactionProposalResponse, fab.TransactionID, error) {
 	request := fab.ChaincodeInvokeRequest{
 		ChaincodeID:  chrequest.ChaincodeID,
@@ -656,3 +890,709 @@ func createAndSendTestTransactionProposal(sender fab.ProposalSender, chrequest *
 	tpr, err := sender.SendTransactionProposal(tpreq, targets)
 	return tpr, tpreq.TxnID, err
 }
+
+// flakyPeer is an endorser that fails the first proposals and records the proposals it receives
+type flakyPeer struct {
+	*fcmocks.MockPeer
+	mutex     sync.Mutex
+	failures  int
+	err       error
+	proposals []string
+}
+
+func newFlakyPeer(failures int, err error) *flakyPeer {
+	return &flakyPeer{MockPeer: fcmocks.NewMockPeer("Peer1", "http://peer1.com"), failures: failures, err: err}
+}
+
+func (p *flakyPeer) ProcessTransactionProposal(tp fab.ProcessProposalRequest) (*fab.TransactionProposalResponse, error) {
+	p.mutex.Lock()
+	p.proposals = append(p.proposals, string(tp.SignedProposal.ProposalBytes))
+	fail := len(p.proposals) <= p.failures
+	p.mutex.Unlock()
+
+	if fail {
+		return nil, p.err
+	}
+	return p.MockPeer.ProcessTransactionProposal(tp)
+}
+
+func (p *flakyPeer) receivedProposals() []string {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return append([]string(nil), p.proposals...)
+}
+
+var testRetryOpts = retry.Opts{
+	Attempts:       3,
+	InitialBackoff: time.Millisecond,
+	MaxBackoff:     10 * time.Millisecond,
+	BackoffFactor:  2,
+	RetryableCodes: retry.ChannelClientRetryableCodes,
+}
+
+// respondToTxEvents answers the registered transaction events with the given errors in order
+func respondToTxEvents(t *testing.T, eventHub *fcmocks.MockEventHub, errs ...error) {
+	go func() {
+		for _, err := range errs {
+			select {
+			case callback := <-eventHub.RegisteredTxCallbacks:
+				code := pb.TxValidationCode_VALID
+				if s, ok := status.FromError(err); ok && s.Group == status.EventServerStatus {
+					code = pb.TxValidationCode(s.Code)
+				}
+				callback("txid", code, err)
+			case <-time.After(5 * time.Second):
+				t.Error("Timed out waiting for execute Tx to register event callback")
+				return
+			}
+		}
+	}()
+}
+
+func TestQueryRetryFlakyEndorser(t *testing.T) {
+	testPeer := newFlakyPeer(2, status.New(status.EndorserClientStatus, status.ConnectionFailed.ToInt32(), "unavailable", nil))
+	testPeer.Payload = []byte("value")
+	chClient := setupChannelClient([]fab.Peer{testPeer}, t)
+
+	resp, err := chClient.Query(Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}},
+		WithRetry(testRetryOpts))
+	assert.Nil(t, err, "expected query to succeed after retries")
+	assert.Equal(t, []byte("value"), resp.Payload, "expected correct response")
+	assert.Len(t, testPeer.receivedProposals(), 3, "expected the endorser to be called three times")
+
+	// Too many failures
+	testPeer = newFlakyPeer(4, status.New(status.EndorserClientStatus, status.ConnectionFailed.ToInt32(), "unavailable", nil))
+	chClient = setupChannelClient([]fab.Peer{testPeer}, t)
+	_, err = chClient.Query(Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}},
+		WithRetry(testRetryOpts))
+	assert.NotNil(t, err, "expected query to fail after exhausting the retries")
+	assert.Len(t, testPeer.receivedProposals(), 4, "expected the endorser to be called once per attempt")
+}
+
+func TestExecuteRetryNewTxnID(t *testing.T) {
+	testPeer := newFlakyPeer(1, status.New(status.EndorserServerStatus, int32(common.Status_SERVICE_UNAVAILABLE), "unavailable", nil))
+	chClient := setupChannelClient([]fab.Peer{testPeer}, t)
+	mockEventHub := fcmocks.NewMockEventHub()
+	chClient.eventHub = mockEventHub
+	respondToTxEvents(t, mockEventHub, nil)
+
+	_, err := chClient.Execute(Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}},
+		WithRetry(testRetryOpts))
+	assert.Nil(t, err, "expected execute to succeed after retry")
+
+	proposals := testPeer.receivedProposals()
+	assert.Len(t, proposals, 2, "expected the endorser to be called twice")
+	assert.NotEqual(t, proposals[0], proposals[1], "expected a new transaction ID for each attempt")
+}
+
+func TestExecuteRetryOnValidationCode(t *testing.T) {
+	testPeer := newFlakyPeer(0, nil)
+	chClient := setupChannelClient([]fab.Peer{testPeer}, t)
+	mockEventHub := fcmocks.NewMockEventHub()
+	chClient.eventHub = mockEventHub
+	respondToTxEvents(t, mockEventHub,
+		status.New(status.EventServerStatus, int32(pb.TxValidationCode_MVCC_READ_CONFLICT), "conflict", nil), nil)
+
+	resp, err := chClient.Execute(Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}},
+		WithRetry(testRetryOpts))
+	assert.Nil(t, err, "expected execute to succeed after retry")
+	assert.Equal(t, pb.TxValidationCode_VALID, resp.TxValidationCode)
+
+	proposals := testPeer.receivedProposals()
+	assert.Len(t, proposals, 2, "expected the transaction to be endorsed again")
+	assert.NotEqual(t, proposals[0], proposals[1], "expected a new transaction ID for the retry")
+}
+
+func TestExecuteNoRetryAfterBroadcast(t *testing.T) {
+	testPeer := newFlakyPeer(0, nil)
+	chClient := setupChannelClient([]fab.Peer{testPeer}, t)
+	mockEventHub := fcmocks.NewMockEventHub()
+	chClient.eventHub = mockEventHub
+
+	// A transient error that isn't a validation code doesn't say whether the transaction was committed
+	respondToTxEvents(t, mockEventHub,
+		status.New(status.GRPCTransportStatus, int32(grpcCodes.Unavailable), "unavailable", nil))
+
+	_, err := chClient.Execute(Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}},
+		WithRetry(testRetryOpts))
+	assert.NotNil(t, err, "expected execute to fail")
+	assert.Len(t, testPeer.receivedProposals(), 1, "expected no retry after the transaction was broadcast")
+}
+
+// retryConfig is a config with a default channel client retry policy
+type retryConfig struct {
+	core.Config
+	retry core.RetryConfig
+}
+
+func (c *retryConfig) Client() (*core.ClientConfig, error) {
+	client, err := c.Config.Client()
+	if err != nil {
+		return nil, err
+	}
+	client.ChannelClient.Retry = c.retry
+	return client, nil
+}
+
+func TestRetryFromConfig(t *testing.T) {
+	testPeer := newFlakyPeer(2, status.New(status.EndorserClientStatus, status.ConnectionFailed.ToInt32(), "unavailable", nil))
+	chClient := setupChannelClient([]fab.Peer{testPeer}, t)
+	mockContext := chClient.context.(Context).ProviderContext.(*fcmocks.MockContext)
+	mockContext.SetConfig(&retryConfig{Config: mockContext.Config(), retry: core.RetryConfig{Attempts: 2, InitialBackoff: time.Millisecond}})
+
+	_, err := chClient.Query(Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}})
+	assert.Nil(t, err, "expected query to succeed with the retry policy from the config")
+	assert.Len(t, testPeer.receivedProposals(), 3, "expected two retries")
+
+	// The retry options of the call override the config
+	testPeer = newFlakyPeer(2, status.New(status.EndorserClientStatus, status.ConnectionFailed.ToInt32(), "unavailable", nil))
+	chClient = setupChannelClient([]fab.Peer{testPeer}, t)
+	mockContext = chClient.context.(Context).ProviderContext.(*fcmocks.MockContext)
+	mockContext.SetConfig(&retryConfig{Config: mockContext.Config(), retry: core.RetryConfig{Attempts: 2, InitialBackoff: time.Millisecond}})
+	opts := testRetryOpts
+	opts.Attempts = 1
+	_, err = chClient.Query(Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}},
+		WithRetry(opts))
+	assert.NotNil(t, err, "expected query to fail with a single retry")
+	assert.Len(t, testPeer.receivedProposals(), 2, "expected one retry")
+}
+
+var testTransientMap = map[string][]byte{"price": []byte("100"), "owner": []byte("Org1")}
+
+// unmarshalProposal returns the chaincode proposal payload and header extension of a proposal
+func unmarshalProposal(t *testing.T, proposalBytes []byte) (*pb.Proposal, *pb.ChaincodeProposalPayload, *pb.ChaincodeHeaderExtension) {
+	proposal := &pb.Proposal{}
+	if err := proto.Unmarshal(proposalBytes, proposal); err != nil {
+		t.Fatalf("Failed to unmarshal proposal: %s", err)
+	}
+	payload, err := protos_utils.GetChaincodeProposalPayload(proposal.Payload)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal chaincode proposal payload: %s", err)
+	}
+	hdr, err := protos_utils.GetHeader(proposal.Header)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal proposal header: %s", err)
+	}
+	hdrExt, err := protos_utils.GetChaincodeHeaderExtension(hdr)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal chaincode header extension: %s", err)
+	}
+	return proposal, payload, hdrExt
+}
+
+func TestQueryTransientMap(t *testing.T) {
+	testPeer := newFlakyPeer(0, nil)
+	testPeer.Payload = []byte("value")
+	chClient := setupChannelClient([]fab.Peer{testPeer}, t)
+
+	_, err := chClient.Query(Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}, TransientMap: testTransientMap})
+	assert.Nil(t, err, "expected query to succeed")
+
+	proposals := testPeer.receivedProposals()
+	if len(proposals) != 1 {
+		t.Fatalf("Expected one proposal, got %d", len(proposals))
+	}
+	_, payload, hdrExt := unmarshalProposal(t, []byte(proposals[0]))
+	assert.Equal(t, testTransientMap, payload.TransientMap, "expected the transient map in the proposal payload")
+	assert.Nil(t, hdrExt.PayloadVisibility, "expected full payload visibility")
+	assert.Equal(t, "testCC", hdrExt.ChaincodeId.Name)
+}
+
+func TestExecuteTransientMap(t *testing.T) {
+	testPeer := newFlakyPeer(0, nil)
+	broadcasts := make(chan *fab.SignedEnvelope, 1)
+	chClient := setupChannelClientWithNodes([]fab.Peer{testPeer}, []fab.Orderer{fcmocks.NewMockOrderer("", broadcasts)}, t)
+	mockEventHub := fcmocks.NewMockEventHub()
+	chClient.eventHub = mockEventHub
+	respondToTxEvents(t, mockEventHub, nil)
+
+	_, err := chClient.Execute(Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}, TransientMap: testTransientMap})
+	assert.Nil(t, err, "expected execute to succeed")
+
+	proposals := testPeer.receivedProposals()
+	if len(proposals) != 1 {
+		t.Fatalf("Expected one proposal, got %d", len(proposals))
+	}
+	proposal, proposalPayload, hdrExt := unmarshalProposal(t, []byte(proposals[0]))
+	assert.Equal(t, testTransientMap, proposalPayload.TransientMap, "expected the transient map in the proposal payload")
+	assert.Nil(t, hdrExt.PayloadVisibility, "expected full payload visibility")
+
+	var envelope *fab.SignedEnvelope
+	select {
+	case envelope = <-broadcasts:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the transaction to be broadcast")
+	}
+	payload, err := protos_utils.GetPayload(&common.Envelope{Payload: envelope.Payload, Signature: envelope.Signature})
+	if err != nil {
+		t.Fatalf("Failed to unmarshal transaction payload: %s", err)
+	}
+	tx, err := protos_utils.GetTransaction(payload.Data)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal transaction: %s", err)
+	}
+	actionPayload, err := protos_utils.GetChaincodeActionPayload(tx.Actions[0].Payload)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal chaincode action payload: %s", err)
+	}
+	txPayload, err := protos_utils.GetChaincodeProposalPayload(actionPayload.ChaincodeProposalPayload)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal chaincode proposal payload of the transaction: %s", err)
+	}
+	assert.Nil(t, txPayload.TransientMap, "expected the transient map to be excluded from the transaction")
+	assert.Equal(t, proposalPayload.Input, txPayload.Input, "expected the chaincode input of the proposal in the transaction")
+
+	// The committer checks the proposal hash of the endorsements against the header and the proposal
+	// payload of the transaction, which the endorser computes without the transient map
+	noTransient, err := proto.Marshal(&pb.ChaincodeProposalPayload{Input: proposalPayload.Input})
+	if err != nil {
+		t.Fatalf("Failed to marshal chaincode proposal payload: %s", err)
+	}
+	assert.Equal(t, noTransient, actionPayload.ChaincodeProposalPayload, "expected the proposal payload without the transient map")
+	hdr, err := protos_utils.GetHeader(proposal.Header)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal proposal header: %s", err)
+	}
+	assert.Equal(t, hdr.ChannelHeader, payload.Header.ChannelHeader, "expected the channel header of the proposal in the transaction")
+	assert.Equal(t, hdr.SignatureHeader, payload.Header.SignatureHeader, "expected the signature header of the proposal in the transaction")
+}
+
+func TestHandlerChainSignedEnvelope(t *testing.T) {
+	testPeer := newFlakyPeer(0, nil)
+	broadcasts := make(chan *fab.SignedEnvelope, 1)
+	chClient := setupChannelClientWithNodes([]fab.Peer{testPeer}, []fab.Orderer{fcmocks.NewMockOrderer("", broadcasts)}, t)
+
+	chain := WithHandlerChain(
+		invoke.NewProposalProcessorHandler(),
+		invoke.NewEndorsementHandler(),
+		invoke.NewEndorsementValidationHandler(),
+		invoke.NewSignatureValidationHandler(),
+		invoke.NewSignedEnvelopeHandler(),
+	)
+	response, err := chClient.Execute(Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}, chain)
+	if err != nil {
+		t.Fatalf("Failed to execute the custom handler chain: %s", err)
+	}
+	if response.SignedEnvelope == nil {
+		t.Fatal("Expected the signed envelope in the response")
+	}
+	assert.Len(t, testPeer.receivedProposals(), 1, "expected the proposal to be endorsed")
+
+	select {
+	case <-broadcasts:
+		t.Fatal("Expected the transaction not to be sent to the orderer")
+	default:
+	}
+
+	payload, err := protos_utils.GetPayload(&common.Envelope{Payload: response.SignedEnvelope.Payload, Signature: response.SignedEnvelope.Signature})
+	if err != nil {
+		t.Fatalf("Failed to unmarshal transaction payload: %s", err)
+	}
+	channelHeader, err := protos_utils.UnmarshalChannelHeader(payload.Header.ChannelHeader)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal channel header: %s", err)
+	}
+	assert.Equal(t, string(response.TransactionID), channelHeader.TxId, "expected the envelope of the endorsed transaction")
+	assert.NotEmpty(t, response.SignedEnvelope.Signature, "expected the envelope to be signed")
+}
+
+func TestHandlerChainError(t *testing.T) {
+	testPeer := newFlakyPeer(0, nil)
+	chClient := setupChannelClientWithNodes([]fab.Peer{testPeer}, nil, t)
+
+	var ran bool
+	chain := WithHandlerChain(
+		invoke.NewProposalProcessorHandler(),
+		handlerFunc(func(requestContext *invoke.RequestContext, clientContext *invoke.ClientContext) {
+			requestContext.Error = errors.New("rejected")
+		}),
+		handlerFunc(func(requestContext *invoke.RequestContext, clientContext *invoke.ClientContext) {
+			ran = true
+		}),
+	)
+	_, err := chClient.Query(Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}, chain)
+	if err == nil {
+		t.Fatal("Expected the error of the failed handler")
+	}
+	assert.Contains(t, err.Error(), "rejected")
+	assert.False(t, ran, "expected the chain to stop at the failed handler")
+	assert.Empty(t, testPeer.receivedProposals(), "expected the default handlers not to run")
+}
+
+// handlerFunc adapts a function to invoke.Handler
+type handlerFunc func(requestContext *invoke.RequestContext, clientContext *invoke.ClientContext)
+
+func (f handlerFunc) Handle(requestContext *invoke.RequestContext, clientContext *invoke.ClientContext) {
+	f(requestContext, clientContext)
+}
+
+// blockingOrderer is an orderer that doesn't return from a broadcast until it's released
+type blockingOrderer struct {
+	fab.Orderer
+	release chan struct{}
+}
+
+func (o *blockingOrderer) SendBroadcast(envelope *fab.SignedEnvelope) (*common.Status, error) {
+	<-o.release
+	return o.Orderer.SendBroadcast(envelope)
+}
+
+func receiveCommitStatus(t *testing.T, commitStatus <-chan TxCommitStatus) TxCommitStatus {
+	select {
+	case s := <-commitStatus:
+		select {
+		case s2 := <-commitStatus:
+			t.Fatalf("Expected the commit status to be sent once, got %+v", s2)
+		case <-time.After(10 * time.Millisecond):
+		}
+		return s
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the commit status")
+	}
+	return TxCommitStatus{}
+}
+
+func TestExecuteAsyncCommitAfterReturn(t *testing.T) {
+	chClient := setupChannelClient([]fab.Peer{fcmocks.NewMockPeer("Peer1", "http://peer1.com")}, t)
+	mockEventHub := fcmocks.NewMockEventHub()
+	chClient.eventHub = mockEventHub
+
+	resp, commitStatus, err := chClient.ExecuteAsync(Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}})
+	if err != nil {
+		t.Fatalf("Failed to execute transaction asynchronously: %s", err)
+	}
+	assert.NotEmpty(t, resp.TransactionID, "expected transaction ID")
+
+	select {
+	case s := <-commitStatus:
+		t.Fatalf("Expected no commit status before the commit, got %+v", s)
+	default:
+	}
+
+	var callback func(fab.TransactionID, pb.TxValidationCode, uint64, error)
+	select {
+	case callback = <-mockEventHub.RegisteredTxStatusCallbacks:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the transaction status registration")
+	}
+	callback(resp.TransactionID, pb.TxValidationCode_MVCC_READ_CONFLICT, 7,
+		status.New(status.EventServerStatus, int32(pb.TxValidationCode_MVCC_READ_CONFLICT), "received invalid transaction", nil))
+
+	s := receiveCommitStatus(t, commitStatus)
+	assert.Equal(t, resp.TransactionID, s.TxID)
+	assert.Equal(t, pb.TxValidationCode_MVCC_READ_CONFLICT, s.ValidationCode)
+	assert.EqualValues(t, 7, s.BlockNumber)
+	assert.NotNil(t, s.Err, "expected an error for an invalid transaction")
+}
+
+func TestExecuteAsyncCommitBeforeReturn(t *testing.T) {
+	orderer := &blockingOrderer{Orderer: fcmocks.NewMockOrderer("", nil), release: make(chan struct{})}
+	chClient := setupChannelClientWithNodes([]fab.Peer{fcmocks.NewMockPeer("Peer1", "http://peer1.com")}, []fab.Orderer{orderer}, t)
+	mockEventHub := fcmocks.NewMockEventHub()
+	chClient.eventHub = mockEventHub
+
+	// The transaction is committed while the broadcast is still in progress
+	go func() {
+		select {
+		case callback := <-mockEventHub.RegisteredTxStatusCallbacks:
+			callback("txid", pb.TxValidationCode_VALID, 3, nil)
+			close(orderer.release)
+		case <-time.After(5 * time.Second):
+			t.Error("Timed out waiting for the transaction status registration")
+		}
+	}()
+
+	_, commitStatus, err := chClient.ExecuteAsync(Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}})
+	if err != nil {
+		t.Fatalf("Failed to execute transaction asynchronously: %s", err)
+	}
+
+	s := receiveCommitStatus(t, commitStatus)
+	assert.Nil(t, s.Err, "expected the transaction to be committed")
+	assert.Equal(t, pb.TxValidationCode_VALID, s.ValidationCode)
+	assert.EqualValues(t, 3, s.BlockNumber)
+}
+
+func TestExecuteAsyncEventHubDisconnected(t *testing.T) {
+	chClient := setupChannelClient([]fab.Peer{fcmocks.NewMockPeer("Peer1", "http://peer1.com")}, t)
+	mockEventHub := fcmocks.NewMockEventHub()
+	chClient.eventHub = mockEventHub
+
+	_, commitStatus, err := chClient.ExecuteAsync(Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}})
+	if err != nil {
+		t.Fatalf("Failed to execute transaction asynchronously: %s", err)
+	}
+
+	// The event hub notifies the registrants when it's disconnected
+	select {
+	case callback := <-mockEventHub.RegisteredTxStatusCallbacks:
+		callback("txid", pb.TxValidationCode_INVALID_OTHER_REASON, 0, errors.New("event hub was disconnected"))
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the transaction status registration")
+	}
+
+	s := receiveCommitStatus(t, commitStatus)
+	assert.NotNil(t, s.Err, "expected an error if the event hub is disconnected")
+}
+
+func TestExecuteAsyncTimeout(t *testing.T) {
+	chClient := setupChannelClient([]fab.Peer{fcmocks.NewMockPeer("Peer1", "http://peer1.com")}, t)
+	chClient.eventHub = fcmocks.NewMockEventHub()
+
+	_, commitStatus, err := chClient.ExecuteAsync(Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}},
+		WithTimeout(100*time.Millisecond))
+	if err != nil {
+		t.Fatalf("Failed to execute transaction asynchronously: %s", err)
+	}
+
+	s := receiveCommitStatus(t, commitStatus)
+	assert.NotNil(t, s.Err, "expected an error if the commit isn't received")
+}
+
+func TestExecuteAsyncOrdererError(t *testing.T) {
+	orderer := fcmocks.NewMockOrderer("", nil)
+	orderer.(fcmocks.MockOrderer).EnqueueSendBroadcastError(errors.New("orderer unavailable"))
+	chClient := setupChannelClientWithNodes([]fab.Peer{fcmocks.NewMockPeer("Peer1", "http://peer1.com")}, []fab.Orderer{orderer}, t)
+	chClient.eventHub = fcmocks.NewMockEventHub()
+
+	_, commitStatus, err := chClient.ExecuteAsync(Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}})
+	assert.NotNil(t, err, "expected an error if the transaction isn't accepted by the orderer")
+	assert.Nil(t, commitStatus, "expected no commit status channel")
+}
+
+func newRecordingPeer(name, url string) *flakyPeer {
+	p := newFlakyPeer(0, nil)
+	p.MockPeer = fcmocks.NewMockPeer(name, url)
+	return p
+}
+
+func TestWithTargets(t *testing.T) {
+	peer1 := newRecordingPeer("Peer1", "grpcs://peer1.example.com:7051")
+	peer2 := newRecordingPeer("Peer2", "grpcs://peer2.example.com:7051")
+	chClient := setupChannelClient([]fab.Peer{peer1}, t)
+	mockEventHub := fcmocks.NewMockEventHub()
+	chClient.eventHub = mockEventHub
+	respondToTxEvents(t, mockEventHub, nil)
+
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}
+	_, err := chClient.Query(request, WithTargets(peer2))
+	assert.Nil(t, err, "expected query to succeed")
+	_, err = chClient.Execute(request, WithTargets(peer2))
+	assert.Nil(t, err, "expected execute to succeed")
+
+	assert.Empty(t, peer1.receivedProposals(), "expected the selected endorser not to be used")
+	assert.Len(t, peer2.receivedProposals(), 2, "expected the proposals to be sent to the target")
+}
+
+func TestWithTargetURLs(t *testing.T) {
+	peer1 := newRecordingPeer("Peer1", "grpcs://peer1.example.com:7051")
+	peer2 := newRecordingPeer("Peer2", "grpcs://peer2.example.com:7051")
+	chClient := setupChannelClient([]fab.Peer{peer1, peer2}, t)
+	discoveryService, err := setupTestDiscovery(nil, []fab.Peer{peer1, peer2})
+	assert.Nil(t, err, "Failed to setup discovery service")
+	chClient.discovery = discoveryService
+
+	// The URLs are matched without the protocol
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}
+	_, err = chClient.Query(request, WithTargetURLs("peer2.example.com:7051"))
+	assert.Nil(t, err, "expected query to succeed")
+	assert.Empty(t, peer1.receivedProposals(), "expected the proposal not to be sent to the other peer")
+	assert.Len(t, peer2.receivedProposals(), 1, "expected the proposal to be sent to the target")
+
+	_, err = chClient.Query(request, WithTargetURLs("grpcs://peer3.example.com:7051"))
+	s, ok := status.FromError(err)
+	assert.True(t, ok, "expected status error for an unknown URL")
+	assert.EqualValues(t, status.NoPeersFound.ToInt32(), s.Code)
+	assert.Len(t, peer2.receivedProposals(), 1, "expected no proposal to be sent")
+}
+
+// networkPeersConfig is a config with the given network peers
+type networkPeersConfig struct {
+	core.Config
+	peers []core.NetworkPeer
+}
+
+func (c *networkPeersConfig) NetworkPeers() ([]core.NetworkPeer, error) {
+	return c.peers, nil
+}
+
+func TestResolveTargetURLsFromConfig(t *testing.T) {
+	chClient := setupChannelClient(nil, t)
+	mockContext := chClient.context.(Context).ProviderContext.(*fcmocks.MockContext)
+	mockContext.SetConfig(&networkPeersConfig{
+		Config: mockContext.Config(),
+		peers: []core.NetworkPeer{{PeerConfig: core.PeerConfig{
+			URL:         "grpc://peer3.example.com:7051",
+			GRPCOptions: map[string]interface{}{"ssl-target-name-override": "peer3", "allow-insecure": true},
+		}, MspID: "Org1MSP"}},
+	})
+
+	targets, err := chClient.resolveTargetURLs([]string{"grpc://peer3.example.com:7051"})
+	if err != nil {
+		t.Fatalf("Failed to resolve target URL: %s", err)
+	}
+	if len(targets) != 1 {
+		t.Fatalf("Expected one target, got %d", len(targets))
+	}
+	p, ok := targets[0].(*peer.Peer)
+	if !ok {
+		t.Fatalf("Expected the target to be created from the peer config, got %T", targets[0])
+	}
+	assert.Equal(t, "grpc://peer3.example.com:7051", p.URL())
+	assert.Equal(t, "Org1MSP", p.MSPID(), "expected the peer's config to be used")
+}
+
+func TestWithTargetFilter(t *testing.T) {
+	peer1 := newRecordingPeer("Peer1", "grpcs://peer1.example.com:7051")
+	peer2 := newRecordingPeer("Peer2", "grpcs://peer2.example.com:7051")
+	chClient := setupChannelClient([]fab.Peer{peer1, peer2}, t)
+	mockEventHub := fcmocks.NewMockEventHub()
+	chClient.eventHub = mockEventHub
+	respondToTxEvents(t, mockEventHub, nil)
+
+	onlyPeer2 := fab.TargetFilterFunc(func(p fab.Peer) bool { return p.URL() == peer2.URL() })
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}
+	_, err := chClient.Query(request, WithTargetFilter(onlyPeer2))
+	assert.Nil(t, err, "expected query to succeed")
+	_, err = chClient.Execute(request, WithTargetFilter(onlyPeer2))
+	assert.Nil(t, err, "expected execute to succeed")
+	assert.Empty(t, peer1.receivedProposals(), "expected the filtered out peer not to be used")
+	assert.Len(t, peer2.receivedProposals(), 2, "expected the proposals to be sent to the accepted peer")
+
+	// The filter also applies to the given targets
+	_, err = chClient.Query(request, WithTargets(peer1, peer2), WithTargetFilter(onlyPeer2))
+	assert.Nil(t, err, "expected query to succeed")
+	assert.Empty(t, peer1.receivedProposals(), "expected the filtered out target not to be used")
+
+	none := fab.TargetFilterFunc(func(p fab.Peer) bool { return false })
+	_, err = chClient.Query(request, WithTargetFilter(none))
+	noTargetsErr, ok := errors.Cause(err).(*invoke.NoTargetsError)
+	if !ok {
+		t.Fatalf("Expected NoTargetsError, got %v", err)
+	}
+	assert.Equal(t, []string{peer1.URL(), peer2.URL()}, noTargetsErr.FilteredOut)
+}
+
+func TestQueryEndorsementMismatch(t *testing.T) {
+	peer1 := fcmocks.NewMockPeer("Peer1", "grpcs://peer1.example.com:7051")
+	peer1.Payload = []byte("value")
+	peer1.ProposalResponsePayload = []byte("rwset")
+	peer2 := fcmocks.NewMockPeer("Peer2", "grpcs://peer2.example.com:7051")
+	peer2.Payload = []byte("stale value")
+	peer2.ProposalResponsePayload = []byte("stale rwset")
+	peer3 := fcmocks.NewMockPeer("Peer3", "grpcs://peer3.example.com:7051")
+	peer3.Payload = []byte("value")
+	peer3.ProposalResponsePayload = []byte("rwset")
+	chClient := setupChannelClient([]fab.Peer{peer1, peer2, peer3}, t)
+
+	request := Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}}
+	_, err := chClient.Query(request)
+	mismatchErr, ok := errors.Cause(err).(*invoke.EndorsementMismatchError)
+	if !ok {
+		t.Fatalf("Expected EndorsementMismatchError, got %v", err)
+	}
+	assert.Equal(t, []string{peer2.URL()}, mismatchErr.Divergent)
+
+	resp, err := chClient.Query(request, WithEndorsementMajority())
+	assert.Nil(t, err, "expected the majority's response to be accepted")
+	assert.Equal(t, []byte("value"), resp.Payload)
+	assert.Len(t, resp.Responses, 2, "expected the divergent response to be dropped")
+}
+
+// slowPeer is a peer that doesn't respond to proposals until it's released
+type slowPeer struct {
+	*fcmocks.MockPeer
+	release chan struct{}
+}
+
+func (p *slowPeer) ProcessTransactionProposal(tp fab.ProcessProposalRequest) (*fab.TransactionProposalResponse, error) {
+	<-p.release
+	return p.MockPeer.ProcessTransactionProposal(tp)
+}
+
+func TestEndorsementTimeout(t *testing.T) {
+	peer1 := fcmocks.NewMockPeer("Peer1", "grpcs://peer1.example.com:7051")
+	peer2 := &slowPeer{MockPeer: fcmocks.NewMockPeer("Peer2", "grpcs://peer2.example.com:7051"), release: make(chan struct{})}
+	defer close(peer2.release)
+	chClient := setupChannelClient([]fab.Peer{peer1, peer2}, t)
+
+	start := time.Now()
+	_, err := chClient.Query(Request{ChaincodeID: "testCC", Fcn: "invoke", Args: [][]byte{[]byte("query"), []byte("b")}},
+		WithTimeouts(Timeouts{Endorsement: 50 * time.Millisecond, Commit: 30 * time.Second}))
+	timeoutErr, ok := errors.Cause(err).(*invoke.EndorsementTimeoutError)
+	if !ok {
+		t.Fatalf("Expected EndorsementTimeoutError, got %v", err)
+	}
+	assert.True(t, time.Since(start) < 5*time.Second, "expected the endorsement to fail fast")
+	assert.Equal(t, 50*time.Millisecond, timeoutErr.Timeout)
+	assert.Equal(t, []string{peer1.URL()}, timeoutErr.Responded)
+	assert.Equal(t, []string{peer2.URL()}, timeoutErr.Pending)
+
+	s, ok := status.FromError(err)
+	assert.True(t, ok, "expected status to be extracted from the error")
+	assert.Equal(t, status.ClientStatus, s.Group)
+	assert.EqualValues(t, status.Timeout.ToInt32(), s.Code)
+}
+
+// unregisteringEventHub records the transactions that are unregistered
+type unregisteringEventHub struct {
+	*fcmocks.MockEventHub
+	unregistered chan fab.TransactionID
+}
+
+func (e *unregisteringEventHub) UnregisterTxEvent(txnID fab.TransactionID) {
+	e.unregistered <- txnID
+}
+
+func TestCommitTimeout(t *testing.T) {
+	chClient := setupChannelClient([]fab.Peer{fcmocks.NewMockPeer("Peer1", "http://peer1.com")}, t)
+	eventHub := &unregisteringEventHub{MockEventHub: fcmocks.NewMockEventHub(), unregistered: make(chan fab.TransactionID, 1)}
+	chClient.eventHub = eventHub
+
+	// The transaction is never committed
+	resp, err := chClient.Execute(Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}},
+		WithTimeouts(Timeouts{Endorsement: 30 * time.Second, Commit: 50 * time.Millisecond}))
+	timeoutErr, ok := errors.Cause(err).(*invoke.CommitTimeoutError)
+	if !ok {
+		t.Fatalf("Expected CommitTimeoutError, got %v", err)
+	}
+	assert.Equal(t, 50*time.Millisecond, timeoutErr.Timeout)
+	assert.NotEmpty(t, timeoutErr.TxID, "expected the ID of the transaction")
+
+	select {
+	case txnID := <-eventHub.unregistered:
+		assert.Equal(t, timeoutErr.TxID, txnID, "expected the transaction event to be unregistered")
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the transaction event to be unregistered after the commit timeout")
+	}
+	assert.Empty(t, resp.Payload, "expected no response")
+}
+
+func TestBroadcastTimeout(t *testing.T) {
+	orderer := &blockingOrderer{Orderer: fcmocks.NewMockOrderer("", nil), release: make(chan struct{})}
+	defer close(orderer.release)
+	chClient := setupChannelClientWithNodes([]fab.Peer{fcmocks.NewMockPeer("Peer1", "http://peer1.com")}, []fab.Orderer{orderer}, t)
+	chClient.eventHub = fcmocks.NewMockEventHub()
+
+	_, err := chClient.Execute(Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}},
+		WithTimeouts(Timeouts{Broadcast: 50 * time.Millisecond}))
+	timeoutErr, ok := errors.Cause(err).(*invoke.BroadcastTimeoutError)
+	if !ok {
+		t.Fatalf("Expected BroadcastTimeoutError, got %v", err)
+	}
+	assert.Equal(t, 50*time.Millisecond, timeoutErr.Timeout)
+}
+
+func TestDefaultTimeouts(t *testing.T) {
+	chClient := setupChannelClient(nil, t)
+	config := chClient.context.Config()
+
+	timeouts := chClient.defaultTimeouts(invoke.Timeouts{})
+	assert.Equal(t, config.Timeout(core.Endorser), timeouts.Endorsement)
+	assert.Equal(t, config.Timeout(core.OrdererResponse), timeouts.Broadcast)
+	assert.Zero(t, timeouts.Commit, "expected the commit to default to the request timeout")
+
+	timeouts = chClient.defaultTimeouts(invoke.Timeouts{Endorsement: 2 * time.Second, Commit: 30 * time.Second})
+	assert.Equal(t, 2*time.Second, timeouts.Endorsement)
+	assert.Equal(t, 30*time.Second, timeouts.Commit)
+}
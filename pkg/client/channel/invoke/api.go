@@ -18,9 +18,25 @@ import (
 
 // Opts allows the user to specify more advanced options
 type Opts struct {
-	ProposalProcessors []fab.ProposalProcessor // targets
-	Timeout            time.Duration
-	Retry              retry.Opts
+	ProposalProcessors  []fab.ProposalProcessor // targets
+	Timeout             time.Duration
+	Retry               retry.Opts
+	PeerGroup           string
+	Budget              *DeadlineBudget
+	TargetFilter        fab.TargetFilter
+	TargetURLs          []string // resolved to ProposalProcessors by the channel client
+	ResponseValidators  []ResponseValidator
+	EndorsementMajority bool
+	HandlerChain        []Handler
+	Timeouts            Timeouts
+}
+
+// Timeouts are the time limits of the stages of an invocation. A stage with a zero timeout is only limited
+// by the deadline budget or the timeout of the request.
+type Timeouts struct {
+	Endorsement time.Duration
+	Broadcast   time.Duration
+	Commit      time.Duration
 }
 
 // Request contains the parameters to execute transaction
@@ -38,6 +54,23 @@ type Response struct {
 	TxValidationCode pb.TxValidationCode
 	Proposal         *fab.TransactionProposal
 	Responses        []*fab.TransactionProposalResponse
+	// SignedEnvelope is only set by handler chains that sign the transaction without sending it
+	// (see invoke.NewSignedEnvelopeHandler)
+	SignedEnvelope *fab.SignedEnvelope
+}
+
+// TxStatus contains the final status of a transaction that's committed asynchronously
+type TxStatus struct {
+	TxID           fab.TransactionID
+	ValidationCode pb.TxValidationCode
+	BlockNumber    uint64
+	Err            error
+}
+
+// ResponseValidator validates the proposal responses of the endorsers. It returns the responses that are used
+// for the invocation (e.g. only the responses of a majority of the endorsers) or an error if they're invalid.
+type ResponseValidator interface {
+	Validate(responses []*fab.TransactionProposalResponse) ([]*fab.TransactionProposalResponse, error)
 }
 
 //Handler for chaining transaction executions
@@ -53,6 +86,12 @@ type ClientContext struct {
 	Channel     fab.Channel // TODO: this should be removed when we have MSP split out.
 	Transactor  fab.Transactor
 	EventHub    fab.EventHub
+	PeerGroups  PeerGroupSelector
+}
+
+// PeerGroupSelector selects peers from a named peer group
+type PeerGroupSelector interface {
+	Select(group string, peers []fab.Peer) ([]fab.Peer, error)
 }
 
 //RequestContext contains request, opts, response parameters for handler execution
@@ -62,4 +101,6 @@ type RequestContext struct {
 	Response     Response
 	Error        error
 	RetryHandler retry.Handler
+	// Broadcast is set once the transaction has been sent to the orderer
+	Broadcast bool
 }
@@ -0,0 +1,151 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Stage is a stage of a transaction invocation
+type Stage string
+
+const (
+	// EndorseStage is the stage in which the proposal is sent to the endorsers
+	EndorseStage Stage = "endorse"
+	// BroadcastStage is the stage in which the transaction is sent to the orderer
+	BroadcastStage Stage = "broadcast"
+	// CommitStage is the stage in which the client waits for the transaction to be committed
+	CommitStage Stage = "commit"
+)
+
+var stages = []Stage{EndorseStage, BroadcastStage, CommitStage}
+
+// StageAllocation specifies the share of the remaining time that is allocated to a stage.
+// Ratio is relative to the ratios of the stages that haven't yet completed and Floor
+// is the minimum amount of time given to the stage (as long as there's time remaining).
+type StageAllocation struct {
+	Stage Stage
+	Ratio float64
+	Floor time.Duration
+}
+
+// DefaultStageAllocations are used when no allocations are provided to the budget
+var DefaultStageAllocations = []StageAllocation{
+	{Stage: EndorseStage, Ratio: 0.4, Floor: 100 * time.Millisecond},
+	{Stage: BroadcastStage, Ratio: 0.2, Floor: 100 * time.Millisecond},
+	{Stage: CommitStage, Ratio: 0.4, Floor: 100 * time.Millisecond},
+}
+
+// DeadlineExceededError is returned when a stage of the invocation doesn't complete
+// within the time allocated to it by the deadline budget
+type DeadlineExceededError struct {
+	Stage     Stage
+	Allocated time.Duration
+}
+
+func (e *DeadlineExceededError) Error() string {
+	return fmt.Sprintf("deadline exceeded in %s stage (allocated %s)", e.Stage, e.Allocated)
+}
+
+// DeadlineBudget splits the time remaining until a deadline across the stages of an
+// invocation. The allocation for a stage is computed when the stage starts so that
+// time not used by a stage is made available to the subsequent stages.
+type DeadlineBudget struct {
+	deadline    time.Time
+	done        <-chan struct{}
+	allocations map[Stage]StageAllocation
+}
+
+// NewDeadlineBudget returns a budget that expires at the given deadline. The done channel
+// (which may be nil) aborts the current stage when it is closed.
+func NewDeadlineBudget(deadline time.Time, done <-chan struct{}, allocations ...StageAllocation) (*DeadlineBudget, error) {
+	if len(allocations) == 0 {
+		allocations = DefaultStageAllocations
+	}
+
+	allocMap := make(map[Stage]StageAllocation)
+	for _, a := range allocations {
+		if a.Ratio < 0 || a.Floor < 0 {
+			return nil, errors.Errorf("invalid allocation for %s stage: ratio and floor must not be negative", a.Stage)
+		}
+		allocMap[a.Stage] = a
+	}
+	for _, s := range stages {
+		if _, ok := allocMap[s]; !ok {
+			return nil, errors.Errorf("no allocation provided for %s stage", s)
+		}
+	}
+
+	return &DeadlineBudget{deadline: deadline, done: done, allocations: allocMap}, nil
+}
+
+// Deadline returns the deadline of the budget
+func (b *DeadlineBudget) Deadline() time.Time {
+	return b.deadline
+}
+
+// Allocate returns the time allocated to the given stage, based on the time remaining
+// until the deadline. A DeadlineExceededError is returned if there's no time remaining.
+func (b *DeadlineBudget) Allocate(stage Stage) (time.Duration, error) {
+	remaining := time.Until(b.deadline)
+	if remaining <= 0 {
+		return 0, &DeadlineExceededError{Stage: stage}
+	}
+
+	alloc := b.allocations[stage]
+
+	var ratios float64
+	var reserved time.Duration
+	for i := stageIndex(stage); i < len(stages); i++ {
+		a := b.allocations[stages[i]]
+		ratios += a.Ratio
+		if stages[i] != stage {
+			reserved += a.Floor
+		}
+	}
+
+	allocated := remaining
+	if ratios > 0 {
+		allocated = time.Duration(float64(remaining) * alloc.Ratio / ratios)
+	}
+
+	// Leave enough time for the floors of the subsequent stages, but no less than this stage's floor
+	if allocated > remaining-reserved {
+		allocated = remaining - reserved
+	}
+	if allocated < alloc.Floor {
+		allocated = alloc.Floor
+	}
+	if allocated > remaining {
+		allocated = remaining
+	}
+
+	return allocated, nil
+}
+
+// NewDeadlineBudgetFromContext returns a budget that expires at the deadline of the given
+// context. The current stage is aborted if the context is cancelled.
+func NewDeadlineBudgetFromContext(ctx context.Context, allocations ...StageAllocation) (*DeadlineBudget, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return nil, errors.New("context does not have a deadline")
+	}
+	return NewDeadlineBudget(deadline, ctx.Done(), allocations...)
+}
+
+func stageIndex(stage Stage) int {
+	for i, s := range stages {
+		if s == stage {
+			return i
+		}
+	}
+	return len(stages)
+}
@@ -0,0 +1,179 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+)
+
+const allocTolerance = 50 * time.Millisecond
+
+func TestBudgetAllocation(t *testing.T) {
+	budget, err := NewDeadlineBudget(time.Now().Add(10*time.Second), nil)
+	if err != nil {
+		t.Fatalf("error creating budget: %s", err)
+	}
+	checkAllocation(t, budget, EndorseStage, 4*time.Second)
+	checkAllocation(t, budget, BroadcastStage, 10*time.Second/3)
+	checkAllocation(t, budget, CommitStage, 10*time.Second)
+
+	// The floors of the subsequent stages are reserved
+	budget, err = NewDeadlineBudget(time.Now().Add(time.Second), nil,
+		StageAllocation{Stage: EndorseStage, Ratio: 1},
+		StageAllocation{Stage: BroadcastStage, Ratio: 0, Floor: 200 * time.Millisecond},
+		StageAllocation{Stage: CommitStage, Ratio: 0, Floor: 300 * time.Millisecond},
+	)
+	if err != nil {
+		t.Fatalf("error creating budget: %s", err)
+	}
+	checkAllocation(t, budget, EndorseStage, 500*time.Millisecond)
+	checkAllocation(t, budget, BroadcastStage, 700*time.Millisecond)
+	checkAllocation(t, budget, CommitStage, time.Second)
+
+	// A stage is given at least its floor
+	budget, err = NewDeadlineBudget(time.Now().Add(time.Second), nil,
+		StageAllocation{Stage: EndorseStage, Ratio: 0.1, Floor: 600 * time.Millisecond},
+		StageAllocation{Stage: BroadcastStage, Ratio: 0.1},
+		StageAllocation{Stage: CommitStage, Ratio: 0.8, Floor: 600 * time.Millisecond},
+	)
+	if err != nil {
+		t.Fatalf("error creating budget: %s", err)
+	}
+	checkAllocation(t, budget, EndorseStage, 600*time.Millisecond)
+
+	// No time remaining
+	budget, err = NewDeadlineBudget(time.Now().Add(-time.Second), nil)
+	if err != nil {
+		t.Fatalf("error creating budget: %s", err)
+	}
+	_, err = budget.Allocate(CommitStage)
+	checkDeadlineExceeded(t, err, CommitStage)
+}
+
+func TestInvalidBudget(t *testing.T) {
+	if _, err := NewDeadlineBudget(time.Now(), nil, StageAllocation{Stage: EndorseStage, Ratio: 1}); err == nil {
+		t.Fatalf("expecting error when allocations are missing for some stages")
+	}
+	if _, err := NewDeadlineBudget(time.Now(), nil,
+		StageAllocation{Stage: EndorseStage, Ratio: -1},
+		StageAllocation{Stage: BroadcastStage, Ratio: 1},
+		StageAllocation{Stage: CommitStage, Ratio: 1},
+	); err == nil {
+		t.Fatalf("expecting error for negative ratio")
+	}
+	if _, err := NewDeadlineBudgetFromContext(context.Background()); err == nil {
+		t.Fatalf("expecting error for context without a deadline")
+	}
+}
+
+func TestExecuteHandlerBudget(t *testing.T) {
+	// Endorsement takes longer than its allocation
+	requestContext, clientContext, eventHub := setupBudgetTest(t, 500*time.Millisecond, 400*time.Millisecond, 0)
+	NewExecuteHandler().Handle(requestContext, clientContext)
+	checkDeadlineExceeded(t, requestContext.Error, EndorseStage)
+
+	// Broadcast takes longer than its allocation
+	requestContext, clientContext, eventHub = setupBudgetTest(t, 500*time.Millisecond, 0, 400*time.Millisecond)
+	NewExecuteHandler().Handle(requestContext, clientContext)
+	checkDeadlineExceeded(t, requestContext.Error, BroadcastStage)
+
+	// Commit event is never received
+	requestContext, clientContext, eventHub = setupBudgetTest(t, 500*time.Millisecond, 0, 0)
+	NewExecuteHandler().Handle(requestContext, clientContext)
+	checkDeadlineExceeded(t, requestContext.Error, CommitStage)
+
+	// The time not used by the endorse and broadcast stages is given to the commit
+	// stage, so the commit succeeds even though it takes longer than the commit
+	// stage's initial share (40% of one second)
+	requestContext, clientContext, eventHub = setupBudgetTest(t, time.Second, 0, 0)
+	go func() {
+		callback := <-eventHub.RegisteredTxCallbacks
+		time.Sleep(700 * time.Millisecond)
+		callback("txid", 0, nil)
+	}()
+	NewExecuteHandler().Handle(requestContext, clientContext)
+	if requestContext.Error != nil {
+		t.Fatalf("expecting execute to succeed within the budget but got: %s", requestContext.Error)
+	}
+
+	// Cancelled context
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	requestContext, clientContext, _ = setupBudgetTest(t, time.Second, 0, 0)
+	requestContext.Opts.Budget, _ = NewDeadlineBudgetFromContext(ctx)
+	time.AfterFunc(100*time.Millisecond, cancel)
+	start := time.Now()
+	NewExecuteHandler().Handle(requestContext, clientContext)
+	if requestContext.Error == nil {
+		t.Fatalf("expecting error when context is cancelled")
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("expecting execute to be aborted when context is cancelled but it took %s", elapsed)
+	}
+}
+
+type delayingTransactor struct {
+	fab.Transactor
+	proposalDelay time.Duration
+	sendDelay     time.Duration
+}
+
+func (t *delayingTransactor) SendTransactionProposal(proposal *fab.TransactionProposal, targets []fab.ProposalProcessor) ([]*fab.TransactionProposalResponse, error) {
+	time.Sleep(t.proposalDelay)
+	return t.Transactor.SendTransactionProposal(proposal, targets)
+}
+
+func (t *delayingTransactor) SendTransaction(tx *fab.Transaction) (*fab.TransactionResponse, error) {
+	time.Sleep(t.sendDelay)
+	return t.Transactor.SendTransaction(tx)
+}
+
+func setupBudgetTest(t *testing.T, timeout, proposalDelay, sendDelay time.Duration) (*RequestContext, *ClientContext, *fcmocks.MockEventHub) {
+	request := Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}
+
+	budget, err := NewDeadlineBudget(time.Now().Add(timeout), nil)
+	if err != nil {
+		t.Fatalf("error creating budget: %s", err)
+	}
+	requestContext := prepareRequestContext(request, Opts{Budget: budget}, t)
+
+	mockPeer1 := &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockRoles: []string{}, MockCert: nil, MockMSP: "Org1MSP", Status: 200, Payload: []byte("value")}
+	clientContext := setupChannelClientContext(nil, nil, []fab.Peer{mockPeer1}, t)
+	clientContext.Transactor = &delayingTransactor{Transactor: clientContext.Transactor, proposalDelay: proposalDelay, sendDelay: sendDelay}
+
+	eventHub := fcmocks.NewMockEventHub()
+	clientContext.EventHub = eventHub
+
+	return requestContext, clientContext, eventHub
+}
+
+func checkAllocation(t *testing.T, budget *DeadlineBudget, stage Stage, expected time.Duration) {
+	allocated, err := budget.Allocate(stage)
+	if err != nil {
+		t.Fatalf("error allocating time to %s stage: %s", stage, err)
+	}
+	if allocated > expected || allocated < expected-allocTolerance {
+		t.Fatalf("expecting %s to be allocated to %s stage but got %s", expected, stage, allocated)
+	}
+}
+
+func checkDeadlineExceeded(t *testing.T, err error, stage Stage) {
+	if err == nil {
+		t.Fatalf("expecting deadline exceeded error in %s stage but got none", stage)
+	}
+	deadlineErr, ok := err.(*DeadlineExceededError)
+	if !ok {
+		t.Fatalf("expecting DeadlineExceededError but got %T: %s", err, err)
+	}
+	if deadlineErr.Stage != stage {
+		t.Fatalf("expecting deadline to be exceeded in %s stage but got %s stage", stage, deadlineErr.Stage)
+	}
+}
@@ -0,0 +1,92 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/errors/status"
+)
+
+// EndorsementMismatchError is returned if the proposal responses of the endorsers don't match
+type EndorsementMismatchError struct {
+	// Divergent contains the URLs of the endorsers whose responses differ from the responses
+	// of the largest group of endorsers that agree (the first endorser's group if there's a tie)
+	Divergent []string
+}
+
+func (e *EndorsementMismatchError) Error() string {
+	return fmt.Sprintf("ProposalResponsePayloads do not match (divergent endorsers: %s)", strings.Join(e.Divergent, ", "))
+}
+
+// Status returns the status of the error (so that the invocation may be retried)
+func (e *EndorsementMismatchError) Status() *status.Status {
+	return status.New(status.EndorserClientStatus, status.EndorsementMismatch.ToInt32(), "ProposalResponsePayloads do not match", nil)
+}
+
+// consistencyValidator checks that the proposal responses (the read/write sets and the chaincode
+// response) of the endorsers match
+type consistencyValidator struct {
+	majority bool
+}
+
+// NewConsistencyValidator returns a validator that checks that the proposal responses of the endorsers
+// match. If majority is set then the responses of more than half of the endorsers are accepted if the
+// responses don't all match.
+func NewConsistencyValidator(majority bool) ResponseValidator {
+	return &consistencyValidator{majority: majority}
+}
+
+// Validate returns the responses that agree, or an *EndorsementMismatchError
+func (v *consistencyValidator) Validate(responses []*fab.TransactionProposalResponse) ([]*fab.TransactionProposalResponse, error) {
+	if len(responses) < 2 {
+		return responses, nil
+	}
+
+	// Group the responses by their key, in the order of the first response of each group
+	var keys []string
+	groups := make(map[string][]*fab.TransactionProposalResponse)
+	for _, r := range responses {
+		key := responseKey(r)
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], r)
+	}
+	if len(keys) == 1 {
+		return responses, nil
+	}
+
+	largest := keys[0]
+	for _, key := range keys[1:] {
+		if len(groups[key]) > len(groups[largest]) {
+			largest = key
+		}
+	}
+	var divergent []string
+	for _, r := range responses {
+		if responseKey(r) != largest {
+			divergent = append(divergent, r.Endorser)
+		}
+	}
+
+	if v.majority && 2*len(groups[largest]) > len(responses) {
+		logger.Warnf("Ignoring the proposal responses of the endorsers that differ from the majority: %s", strings.Join(divergent, ", "))
+		return groups[largest], nil
+	}
+	return nil, &EndorsementMismatchError{Divergent: divergent}
+}
+
+// responseKey returns the key of the proposal response payload (which contains the read/write set)
+// and of the chaincode response payload. The payloads are compared as is rather than by their hash,
+// so that the comparison doesn't depend on the hash function of the security config.
+func responseKey(r *fab.TransactionProposalResponse) string {
+	payload := r.ProposalResponse.GetPayload()
+	return fmt.Sprintf("%d:%s%s", len(payload), payload, r.ProposalResponse.GetResponse().GetPayload())
+}
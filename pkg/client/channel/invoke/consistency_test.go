@@ -0,0 +1,107 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/errors/status"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+)
+
+func newTestResponse(endorser string, rwSet, payload string) *fab.TransactionProposalResponse {
+	return &fab.TransactionProposalResponse{
+		Endorser: endorser,
+		Status:   200,
+		ProposalResponse: &pb.ProposalResponse{
+			Payload:  []byte(rwSet),
+			Response: &pb.Response{Status: 200, Payload: []byte(payload)},
+		},
+	}
+}
+
+func TestConsistencyValidatorMatching(t *testing.T) {
+	responses := []*fab.TransactionProposalResponse{
+		newTestResponse("peer1", "rwset", "value"),
+		newTestResponse("peer2", "rwset", "value"),
+		newTestResponse("peer3", "rwset", "value"),
+	}
+
+	for _, majority := range []bool{false, true} {
+		validated, err := NewConsistencyValidator(majority).Validate(responses)
+		assert.Nil(t, err, "Expected matching responses to be valid")
+		assert.Equal(t, responses, validated)
+	}
+
+	validated, err := NewConsistencyValidator(false).Validate(responses[:1])
+	assert.Nil(t, err, "Expected a single response to be valid")
+	assert.Len(t, validated, 1)
+}
+
+func TestConsistencyValidatorMismatch(t *testing.T) {
+	// The stale peer's read/write set differs although the chaincode response is the same
+	responses := []*fab.TransactionProposalResponse{
+		newTestResponse("peer1", "rwset", "value"),
+		newTestResponse("peer2", "stale rwset", "value"),
+		newTestResponse("peer3", "rwset", "value"),
+	}
+
+	_, err := NewConsistencyValidator(false).Validate(responses)
+	mismatchErr, ok := err.(*EndorsementMismatchError)
+	if !ok {
+		t.Fatalf("Expected EndorsementMismatchError, got %v", err)
+	}
+	assert.Equal(t, []string{"peer2"}, mismatchErr.Divergent)
+
+	// The error has the status of an endorsement mismatch, also when it's wrapped
+	s, ok := status.FromError(errors.WithMessage(err, "endorsement validation failed"))
+	assert.True(t, ok, "Expected status error")
+	assert.Equal(t, status.EndorserClientStatus, s.Group)
+	assert.EqualValues(t, status.EndorsementMismatch.ToInt32(), s.Code)
+
+	// The chaincode responses differ
+	responses = []*fab.TransactionProposalResponse{
+		newTestResponse("peer1", "rwset", "value1"),
+		newTestResponse("peer2", "rwset", "value2"),
+	}
+	_, err = NewConsistencyValidator(false).Validate(responses)
+	mismatchErr, ok = err.(*EndorsementMismatchError)
+	if !ok {
+		t.Fatalf("Expected EndorsementMismatchError, got %v", err)
+	}
+	assert.Equal(t, []string{"peer2"}, mismatchErr.Divergent, "Expected the first endorser's group to win a tie")
+}
+
+func TestConsistencyValidatorMajority(t *testing.T) {
+	responses := []*fab.TransactionProposalResponse{
+		newTestResponse("peer1", "stale rwset", "value"),
+		newTestResponse("peer2", "rwset", "value"),
+		newTestResponse("peer3", "rwset", "value"),
+	}
+
+	validated, err := NewConsistencyValidator(true).Validate(responses)
+	assert.Nil(t, err, "Expected the majority's responses to be accepted")
+	assert.Equal(t, responses[1:], validated)
+
+	// Half of the endorsers isn't a majority
+	responses = []*fab.TransactionProposalResponse{
+		newTestResponse("peer1", "rwset1", "value"),
+		newTestResponse("peer2", "rwset1", "value"),
+		newTestResponse("peer3", "rwset2", "value"),
+		newTestResponse("peer4", "rwset2", "value"),
+	}
+	_, err = NewConsistencyValidator(true).Validate(responses)
+	mismatchErr, ok := err.(*EndorsementMismatchError)
+	if !ok {
+		t.Fatalf("Expected EndorsementMismatchError without a majority, got %v", err)
+	}
+	assert.Equal(t, []string{"peer3", "peer4"}, mismatchErr.Divergent)
+}
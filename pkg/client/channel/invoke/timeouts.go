@@ -0,0 +1,223 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/errors/status"
+	"github.com/pkg/errors"
+)
+
+// EndorsementTimeoutError is returned if the endorsers don't all respond within the endorsement timeout
+type EndorsementTimeoutError struct {
+	Timeout time.Duration
+	// Responded contains the URLs of the endorsers that responded in time
+	Responded []string
+	// Pending contains the URLs of the endorsers that didn't respond
+	Pending []string
+}
+
+func (e *EndorsementTimeoutError) Error() string {
+	return fmt.Sprintf("endorsement timed out after %s (responded: [%s], pending: [%s])",
+		e.Timeout, strings.Join(e.Responded, ", "), strings.Join(e.Pending, ", "))
+}
+
+// Status returns the status of the error, so that it's handled like the other timeouts of the client
+func (e *EndorsementTimeoutError) Status() *status.Status {
+	return timeoutStatus(e)
+}
+
+// BroadcastTimeoutError is returned if the orderer doesn't accept the transaction within the broadcast timeout
+type BroadcastTimeoutError struct {
+	Timeout time.Duration
+}
+
+func (e *BroadcastTimeoutError) Error() string {
+	return fmt.Sprintf("broadcast timed out after %s", e.Timeout)
+}
+
+// Status returns the status of the error, so that it's handled like the other timeouts of the client
+func (e *BroadcastTimeoutError) Status() *status.Status {
+	return timeoutStatus(e)
+}
+
+// CommitTimeoutError is returned if the commit of the transaction isn't received within the commit
+// timeout. The transaction may still be committed.
+type CommitTimeoutError struct {
+	TxID    fab.TransactionID
+	Timeout time.Duration
+}
+
+func (e *CommitTimeoutError) Error() string {
+	return fmt.Sprintf("commit of transaction %s timed out after %s", e.TxID, e.Timeout)
+}
+
+// Status returns the status of the error, so that it's handled like the other timeouts of the client
+func (e *CommitTimeoutError) Status() *status.Status {
+	return timeoutStatus(e)
+}
+
+func timeoutStatus(err error) *status.Status {
+	return status.New(status.ClientStatus, status.Timeout.ToInt32(), err.Error(), nil)
+}
+
+// stageLimit is the time limit of a stage of an invocation: the timeout of the stage or the time allocated
+// to the stage by the deadline budget, whichever is shorter. The limit is enforced by the deadline of ctx.
+type stageLimit struct {
+	ctx      context.Context
+	cancel   context.CancelFunc
+	stage    Stage
+	duration time.Duration
+	budgeted bool
+	aborted  <-chan struct{}
+}
+
+// newStageLimit returns the time limit of the given stage. A stage without a timeout or a budget isn't limited.
+func newStageLimit(budget *DeadlineBudget, stage Stage, timeout time.Duration) (*stageLimit, error) {
+	l := &stageLimit{stage: stage, duration: timeout}
+	if budget != nil {
+		allocated, err := budget.Allocate(stage)
+		if err != nil {
+			return nil, err
+		}
+		logger.Debugf("Allocated %s to %s stage", allocated, stage)
+		if timeout <= 0 || allocated < timeout {
+			l.duration = allocated
+			l.budgeted = true
+		}
+		l.aborted = budget.done
+	}
+
+	if l.duration > 0 {
+		l.ctx, l.cancel = context.WithTimeout(context.Background(), l.duration)
+	} else {
+		l.ctx, l.cancel = context.WithCancel(context.Background())
+	}
+	return l, nil
+}
+
+// limited returns true if the stage has a time limit
+func (l *stageLimit) limited() bool {
+	return l.duration > 0 || l.aborted != nil
+}
+
+// exceeded returns the error for the stage not completing in time: a DeadlineExceededError if the limit is
+// the time allocated by the budget, otherwise the error returned by timeoutErr
+func (l *stageLimit) exceeded(timeoutErr func(timeout time.Duration) error) error {
+	if l.budgeted {
+		return &DeadlineExceededError{Stage: l.stage, Allocated: l.duration}
+	}
+	return timeoutErr(l.duration)
+}
+
+// abortedErr is returned if the invocation is aborted during the stage
+func (l *stageLimit) abortedErr() error {
+	return errors.Errorf("invocation aborted in %s stage", l.stage)
+}
+
+// run runs the given function within the limit. The function is run in a separate Go routine and is
+// abandoned if it doesn't complete in time. If the stage isn't limited then the function is simply invoked.
+func (l *stageLimit) run(fn func(), timeoutErr func(timeout time.Duration) error) error {
+	defer l.cancel()
+
+	if !l.limited() {
+		fn()
+		return nil
+	}
+
+	complete := make(chan struct{})
+	go func() {
+		fn()
+		close(complete)
+	}()
+
+	select {
+	case <-complete:
+		return nil
+	case <-l.ctx.Done():
+		return l.exceeded(timeoutErr)
+	case <-l.aborted:
+		return l.abortedErr()
+	}
+}
+
+// responseTracker records which of the targets of a proposal have responded
+type responseTracker struct {
+	mutex     sync.Mutex
+	urls      []string
+	responded map[string]bool
+}
+
+// trackedTarget reports its response to the tracker
+type trackedTarget struct {
+	fab.ProposalProcessor
+	url     string
+	tracker *responseTracker
+}
+
+// newResponseTracker returns a tracker for the given targets and the targets to send the proposal to,
+// which report their responses to the tracker
+func newResponseTracker(targets []fab.ProposalProcessor) (*responseTracker, []fab.ProposalProcessor) {
+	tracker := &responseTracker{responded: make(map[string]bool)}
+	tracked := make([]fab.ProposalProcessor, len(targets))
+	for i, target := range targets {
+		url := targetURL(target, i)
+		tracker.urls = append(tracker.urls, url)
+		tracked[i] = &trackedTarget{ProposalProcessor: target, url: url, tracker: tracker}
+	}
+	return tracker, tracked
+}
+
+// ProcessTransactionProposal sends the proposal to the target and records its response
+func (t *trackedTarget) ProcessTransactionProposal(request fab.ProcessProposalRequest) (*fab.TransactionProposalResponse, error) {
+	resp, err := t.ProposalProcessor.ProcessTransactionProposal(request)
+	t.tracker.mutex.Lock()
+	t.tracker.responded[t.url] = true
+	t.tracker.mutex.Unlock()
+	return resp, err
+}
+
+// TLSCertHash returns the TLS cert hash of the target, so that the proposal is bound to it
+func (t *trackedTarget) TLSCertHash() []byte {
+	if hasher, ok := t.ProposalProcessor.(fab.TLSCertHasher); ok {
+		return hasher.TLSCertHash()
+	}
+	return nil
+}
+
+// timeoutError returns the error for the targets not responding within the given timeout
+func (r *responseTracker) timeoutError(timeout time.Duration) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	e := &EndorsementTimeoutError{Timeout: timeout}
+	for _, url := range r.urls {
+		if r.responded[url] {
+			e.Responded = append(e.Responded, url)
+		} else {
+			e.Pending = append(e.Pending, url)
+		}
+	}
+	sort.Strings(e.Responded)
+	sort.Strings(e.Pending)
+	return e
+}
+
+// targetURL returns the URL of a target, or its position in the targets if it isn't a peer
+func targetURL(target fab.ProposalProcessor, i int) string {
+	if p, ok := target.(fab.Peer); ok {
+		return p.URL()
+	}
+	return fmt.Sprintf("target %d", i)
+}
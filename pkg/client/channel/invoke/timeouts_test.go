@@ -0,0 +1,72 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package invoke
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+)
+
+func TestStageLimit(t *testing.T) {
+	timeoutErr := func(timeout time.Duration) error {
+		return &BroadcastTimeoutError{Timeout: timeout}
+	}
+
+	limit, err := newStageLimit(nil, BroadcastStage, 0)
+	assert.Nil(t, err)
+	assert.False(t, limit.limited(), "expected no limit without a timeout or a budget")
+	assert.Nil(t, limit.run(func() {}, timeoutErr))
+
+	limit, err = newStageLimit(nil, BroadcastStage, 10*time.Millisecond)
+	assert.Nil(t, err)
+	err = limit.run(func() { time.Sleep(time.Second) }, timeoutErr)
+	assert.Equal(t, &BroadcastTimeoutError{Timeout: 10 * time.Millisecond}, err)
+
+	// The budget allocation applies if it's shorter than the stage timeout
+	budget, err := NewDeadlineBudget(time.Now().Add(time.Second), nil)
+	assert.Nil(t, err)
+	limit, err = newStageLimit(budget, BroadcastStage, time.Minute)
+	assert.Nil(t, err)
+	assert.True(t, limit.budgeted, "expected the budget allocation to be the limit")
+	_, ok := limit.exceeded(timeoutErr).(*DeadlineExceededError)
+	assert.True(t, ok, "expected DeadlineExceededError when the budget allocation is exceeded")
+
+	limit, err = newStageLimit(budget, BroadcastStage, time.Millisecond)
+	assert.Nil(t, err)
+	assert.False(t, limit.budgeted, "expected the stage timeout to be the limit")
+	limit.cancel()
+}
+
+type hashingPeer struct {
+	*fcmocks.MockPeer
+	hash []byte
+}
+
+func (p *hashingPeer) TLSCertHash() []byte {
+	return p.hash
+}
+
+func TestResponseTracker(t *testing.T) {
+	peer1 := &hashingPeer{MockPeer: fcmocks.NewMockPeer("Peer1", "grpcs://peer1.example.com:7051"), hash: []byte("hash")}
+	peer2 := fcmocks.NewMockPeer("Peer2", "grpcs://peer2.example.com:7051")
+	tracker, targets := newResponseTracker([]fab.ProposalProcessor{peer1, peer2})
+
+	assert.Equal(t, []byte("hash"), targets[0].(fab.TLSCertHasher).TLSCertHash(), "expected the TLS cert hash of the target")
+	assert.Nil(t, targets[1].(fab.TLSCertHasher).TLSCertHash())
+
+	_, err := targets[0].ProcessTransactionProposal(fab.ProcessProposalRequest{})
+	assert.Nil(t, err)
+
+	err = tracker.timeoutError(time.Second)
+	assert.Equal(t, &EndorsementTimeoutError{Timeout: time.Second, Responded: []string{peer1.URL()}, Pending: []string{peer2.URL()}}, err)
+	assert.Contains(t, err.Error(), "pending: [grpcs://peer2.example.com:7051]")
+}
@@ -7,7 +7,8 @@ SPDX-License-Identifier: Apache-2.0
 package invoke
 
 import (
-	"bytes"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
@@ -18,6 +19,7 @@ import (
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/txn"
 	"github.com/hyperledger/fabric-sdk-go/pkg/logging"
 	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
 )
 
 var logger = logging.NewLogger("fabric_sdk_go")
@@ -36,10 +38,27 @@ func (e *EndorsementHandler) Handle(requestContext *RequestContext, clientContex
 	}
 
 	// Endorse Tx
-	transactionProposalResponses, proposal, err := createAndSendTransactionProposal(clientContext.Transactor, &requestContext.Request, requestContext.Opts.ProposalProcessors)
+	var transactionProposalResponses []*fab.TransactionProposalResponse
+	var proposal *fab.TransactionProposal
+	var err error
+	request := requestContext.Request
+	limit, err := newStageLimit(requestContext.Opts.Budget, EndorseStage, requestContext.Opts.Timeouts.Endorsement)
+	if err != nil {
+		requestContext.Error = err
+		return
+	}
+	tracker, targets := newResponseTracker(requestContext.Opts.ProposalProcessors)
+	if stageErr := limit.run(func() {
+		transactionProposalResponses, proposal, err = createAndSendTransactionProposal(clientContext.Transactor, &request, targets)
+	}, tracker.timeoutError); stageErr != nil {
+		requestContext.Error = stageErr
+		return
+	}
 
-	requestContext.Response.Proposal = proposal
-	requestContext.Response.TransactionID = proposal.TxnID // TODO: still needed?
+	if proposal != nil {
+		requestContext.Response.Proposal = proposal
+		requestContext.Response.TransactionID = proposal.TxnID // TODO: still needed?
+	}
 
 	if err != nil {
 		requestContext.Error = err
@@ -65,6 +84,7 @@ type ProposalProcessorHandler struct {
 //Handle selects proposal processors
 func (h *ProposalProcessorHandler) Handle(requestContext *RequestContext, clientContext *ClientContext) {
 	//Get proposal processor, if not supplied then use discovery service to get available peers as endorser
+	//If a peer group is specified then select the endorsers from the peer group
 	//If selection service available then get endorser peers for this chaincode
 	if len(requestContext.Opts.ProposalProcessors) == 0 {
 		// Use discovery service to figure out proposal processors
@@ -74,7 +94,13 @@ func (h *ProposalProcessorHandler) Handle(requestContext *RequestContext, client
 			return
 		}
 		endorsers := peers
-		if clientContext.Selection != nil {
+		if requestContext.Opts.PeerGroup != "" {
+			endorsers, err = selectFromPeerGroup(requestContext.Opts.PeerGroup, peers, clientContext)
+			if err != nil {
+				requestContext.Error = err
+				return
+			}
+		} else if clientContext.Selection != nil {
 			endorsers, err = clientContext.Selection.GetEndorsersForChaincode(peers, requestContext.Request.ChaincodeID)
 			if err != nil {
 				requestContext.Error = errors.WithMessage(err, "Failed to get endorsing peers")
@@ -84,12 +110,64 @@ func (h *ProposalProcessorHandler) Handle(requestContext *RequestContext, client
 		requestContext.Opts.ProposalProcessors = peer.PeersToTxnProcessors(endorsers)
 	}
 
+	if requestContext.Opts.TargetFilter != nil {
+		targets, err := filterTargets(requestContext.Opts.ProposalProcessors, requestContext.Opts.TargetFilter)
+		if err != nil {
+			requestContext.Error = err
+			return
+		}
+		requestContext.Opts.ProposalProcessors = targets
+	}
+
 	//Delegate to next step if any
 	if h.next != nil {
 		h.next.Handle(requestContext, clientContext)
 	}
 }
 
+func selectFromPeerGroup(peerGroup string, peers []fab.Peer, clientContext *ClientContext) ([]fab.Peer, error) {
+	if clientContext.PeerGroups == nil {
+		return nil, errors.New("peer groups are not supported by the client")
+	}
+	endorsers, err := clientContext.PeerGroups.Select(peerGroup, peers)
+	if err != nil {
+		return nil, status.New(status.ClientStatus, status.NoPeersFound.ToInt32(), err.Error(), nil)
+	}
+	return endorsers, nil
+}
+
+// NoTargetsError is returned if the target filter of a request doesn't accept any of the targets
+type NoTargetsError struct {
+	// FilteredOut contains the URLs of the targets that weren't accepted by the filter
+	FilteredOut []string
+}
+
+func (e *NoTargetsError) Error() string {
+	return fmt.Sprintf("no targets remain after filtering (filtered out: %s)", strings.Join(e.FilteredOut, ", "))
+}
+
+// filterTargets returns the targets that are accepted by the filter. Targets that aren't peers are kept.
+func filterTargets(targets []fab.ProposalProcessor, filter fab.TargetFilter) ([]fab.ProposalProcessor, error) {
+	if len(targets) == 0 {
+		return targets, nil
+	}
+
+	var accepted []fab.ProposalProcessor
+	var filteredOut []string
+	for _, target := range targets {
+		p, ok := target.(fab.Peer)
+		if ok && !filter.Accept(p) {
+			filteredOut = append(filteredOut, p.URL())
+			continue
+		}
+		accepted = append(accepted, target)
+	}
+	if len(accepted) == 0 {
+		return nil, &NoTargetsError{FilteredOut: filteredOut}
+	}
+	return accepted, nil
+}
+
 //EndorsementValidationHandler for transaction proposal response filtering
 type EndorsementValidationHandler struct {
 	next Handler
@@ -99,12 +177,28 @@ type EndorsementValidationHandler struct {
 func (f *EndorsementValidationHandler) Handle(requestContext *RequestContext, clientContext *ClientContext) {
 
 	//Filter tx proposal responses
-	err := f.validate(requestContext.Response.Responses)
+	responses := requestContext.Response.Responses
+	err := f.validate(responses)
 	if err != nil {
 		requestContext.Error = errors.WithMessage(err, "endorsement validation failed")
 		return
 	}
 
+	validators := append([]ResponseValidator{NewConsistencyValidator(requestContext.Opts.EndorsementMajority)}, requestContext.Opts.ResponseValidators...)
+	for _, validator := range validators {
+		responses, err = validator.Validate(responses)
+		if err != nil {
+			requestContext.Error = errors.WithMessage(err, "endorsement validation failed")
+			return
+		}
+	}
+	if len(responses) == 0 {
+		requestContext.Error = errors.New("endorsement validation failed: no proposal responses remain")
+		return
+	}
+	requestContext.Response.Responses = responses
+	requestContext.Response.Payload = responses[0].ProposalResponse.GetResponse().Payload
+
 	//Delegate to next step if any
 	if f.next != nil {
 		f.next.Handle(requestContext, clientContext)
@@ -112,20 +206,10 @@ func (f *EndorsementValidationHandler) Handle(requestContext *RequestContext, cl
 }
 
 func (f *EndorsementValidationHandler) validate(txProposalResponse []*fab.TransactionProposalResponse) error {
-	var a1 []byte
-	for n, r := range txProposalResponse {
+	for _, r := range txProposalResponse {
 		if r.ProposalResponse.GetResponse().Status != int32(common.Status_SUCCESS) {
 			return status.NewFromProposalResponse(r.ProposalResponse, r.Endorser)
 		}
-		if n == 0 {
-			a1 = r.ProposalResponse.GetResponse().Payload
-			continue
-		}
-
-		if bytes.Compare(a1, r.ProposalResponse.GetResponse().Payload) != 0 {
-			return status.New(status.EndorserClientStatus, status.EndorsementMismatch.ToInt32(),
-				"ProposalResponsePayloads do not match", nil)
-		}
 	}
 
 	return nil
@@ -152,11 +236,19 @@ func (c *CommitTxHandler) Handle(requestContext *RequestContext, clientContext *
 
 	//Register Tx event
 	statusNotifier := txn.RegisterStatus(txnID, clientContext.EventHub)
-	_, err := createAndSendTransaction(clientContext.Transactor, requestContext.Response.Proposal, requestContext.Response.Responses)
+	defer clientContext.EventHub.UnregisterTxEvent(txnID)
+
+	if err := broadcast(requestContext, clientContext); err != nil {
+		requestContext.Error = err
+		return
+	}
+
+	limit, err := newStageLimit(requestContext.Opts.Budget, CommitStage, commitTimeout(requestContext))
 	if err != nil {
-		requestContext.Error = errors.Wrap(err, "CreateAndSendTransaction failed")
+		requestContext.Error = err
 		return
 	}
+	defer limit.cancel()
 
 	select {
 	case result := <-statusNotifier:
@@ -166,17 +258,190 @@ func (c *CommitTxHandler) Handle(requestContext *RequestContext, clientContext *
 			requestContext.Error = result.Error
 			return
 		}
-	case <-time.After(requestContext.Opts.Timeout):
-		requestContext.Error = errors.New("Execute didn't receive block event")
+	case <-limit.ctx.Done():
+		requestContext.Error = limit.exceeded(commitTimeoutError(txnID))
+		return
+	case <-limit.aborted:
+		requestContext.Error = limit.abortedErr()
+		return
+	}
+
+	//Delegate to next step if any
+	if c.next != nil {
+		c.next.Handle(requestContext, clientContext)
+	}
+}
+
+//AsyncCommitTxHandler for committing transactions without waiting for them to be committed
+type AsyncCommitTxHandler struct {
+	notify func(TxStatus)
+	next   Handler
+}
+
+//Handle sends the transaction to the orderer and notifies the final status of the transaction once it's known
+func (c *AsyncCommitTxHandler) Handle(requestContext *RequestContext, clientContext *ClientContext) {
+
+	//Connect to Event hub if not yet connected
+	if clientContext.EventHub.IsConnected() == false {
+		err := clientContext.EventHub.Connect()
+		if err != nil {
+			requestContext.Error = err
+			return
+		}
+	}
+
+	txnID := requestContext.Response.TransactionID
+
+	//Register Tx event before broadcasting, so that the commit isn't missed if it's received before the broadcast returns
+	statusNotifier := registerTxStatus(txnID, clientContext.EventHub)
+
+	if err := broadcast(requestContext, clientContext); err != nil {
+		clientContext.EventHub.UnregisterTxEvent(txnID)
+		requestContext.Error = err
 		return
 	}
 
+	limit, err := newStageLimit(requestContext.Opts.Budget, CommitStage, commitTimeout(requestContext))
+
+	go func() {
+		defer clientContext.EventHub.UnregisterTxEvent(txnID)
+
+		if err != nil {
+			c.notify(TxStatus{TxID: txnID, Err: err})
+			return
+		}
+		defer limit.cancel()
+
+		select {
+		case result := <-statusNotifier:
+			c.notify(result)
+		case <-limit.ctx.Done():
+			c.notify(TxStatus{TxID: txnID, Err: limit.exceeded(commitTimeoutError(txnID))})
+		case <-limit.aborted:
+			c.notify(TxStatus{TxID: txnID, Err: limit.abortedErr()})
+		}
+	}()
+
 	//Delegate to next step if any
 	if c.next != nil {
 		c.next.Handle(requestContext, clientContext)
 	}
 }
 
+// broadcast sends the endorsed transaction to the orderer within the time limit of the broadcast stage
+func broadcast(requestContext *RequestContext, clientContext *ClientContext) error {
+	limit, err := newStageLimit(requestContext.Opts.Budget, BroadcastStage, requestContext.Opts.Timeouts.Broadcast)
+	if err != nil {
+		return err
+	}
+
+	proposal := requestContext.Response.Proposal
+	responses := requestContext.Response.Responses
+	if stageErr := limit.run(func() {
+		_, err = createAndSendTransaction(clientContext.Transactor, proposal, responses)
+	}, func(timeout time.Duration) error {
+		return &BroadcastTimeoutError{Timeout: timeout}
+	}); stageErr != nil {
+		return stageErr
+	}
+	if err != nil {
+		return errors.Wrap(err, "CreateAndSendTransaction failed")
+	}
+	requestContext.Broadcast = true
+	return nil
+}
+
+// commitTimeout returns the commit timeout of the request, which defaults to the timeout of the request
+// unless the commit is limited by a deadline budget
+func commitTimeout(requestContext *RequestContext) time.Duration {
+	if requestContext.Opts.Timeouts.Commit > 0 || requestContext.Opts.Budget != nil {
+		return requestContext.Opts.Timeouts.Commit
+	}
+	return requestContext.Opts.Timeout
+}
+
+func commitTimeoutError(txnID fab.TransactionID) func(time.Duration) error {
+	return func(timeout time.Duration) error {
+		return &CommitTimeoutError{TxID: txnID, Timeout: timeout}
+	}
+}
+
+// registerTxStatus registers for the status of the transaction. The block number and the disconnection
+// of the event hub are only reported if the event hub implements fab.TxStatusEventHub.
+func registerTxStatus(txnID fab.TransactionID, eventHub fab.EventHub) <-chan TxStatus {
+	statusNotifier := make(chan TxStatus, 1)
+	notify := func(s TxStatus) {
+		select {
+		case statusNotifier <- s:
+		default:
+			// The status has already been reported
+		}
+	}
+
+	if txStatusEventHub, ok := eventHub.(fab.TxStatusEventHub); ok {
+		txStatusEventHub.RegisterTxStatusEvent(txnID, func(txID fab.TransactionID, code pb.TxValidationCode, blockNumber uint64, err error) {
+			notify(TxStatus{TxID: txID, ValidationCode: code, BlockNumber: blockNumber, Err: err})
+		})
+	} else {
+		eventHub.RegisterTxEvent(txnID, func(txID fab.TransactionID, code pb.TxValidationCode, err error) {
+			notify(TxStatus{TxID: txID, ValidationCode: code, Err: err})
+		})
+	}
+	return statusNotifier
+}
+
+//SignedEnvelopeHandler for creating the signed envelope of an endorsed transaction without sending it to the orderer
+type SignedEnvelopeHandler struct {
+	next Handler
+}
+
+//Handle creates the transaction from the proposal responses and returns its signed envelope in the response
+func (h *SignedEnvelopeHandler) Handle(requestContext *RequestContext, clientContext *ClientContext) {
+	signer, ok := clientContext.Transactor.(fab.TransactionSigner)
+	if !ok {
+		requestContext.Error = errors.New("transactor doesn't support signing transactions")
+		return
+	}
+
+	txnRequest := fab.TransactionRequest{
+		Proposal:          requestContext.Response.Proposal,
+		ProposalResponses: requestContext.Response.Responses,
+	}
+
+	tx, err := clientContext.Transactor.CreateTransaction(txnRequest)
+	if err != nil {
+		requestContext.Error = errors.WithMessage(err, "CreateTransaction failed")
+		return
+	}
+
+	envelope, err := signer.SignTransaction(tx)
+	if err != nil {
+		requestContext.Error = errors.WithMessage(err, "SignTransaction failed")
+		return
+	}
+	requestContext.Response.SignedEnvelope = envelope
+
+	//Delegate to next step if any
+	if h.next != nil {
+		h.next.Handle(requestContext, clientContext)
+	}
+}
+
+// chainHandler runs a sequence of handlers
+type chainHandler struct {
+	handlers []Handler
+}
+
+//Handle runs the handlers in order until one of them fails
+func (c *chainHandler) Handle(requestContext *RequestContext, clientContext *ClientContext) {
+	for _, handler := range c.handlers {
+		handler.Handle(requestContext, clientContext)
+		if requestContext.Error != nil {
+			return
+		}
+	}
+}
+
 //NewQueryHandler returns query handler with EndorseTxHandler & EndorsementValidationHandler Chained
 func NewQueryHandler(next ...Handler) Handler {
 	return NewProposalProcessorHandler(
@@ -199,6 +464,18 @@ func NewExecuteHandler(next ...Handler) Handler {
 	)
 }
 
+//NewExecuteAsyncHandler returns a handler that endorses a transaction and sends it to the orderer without
+//waiting for it to be committed. The final status of the transaction is passed to notify once it's known.
+func NewExecuteAsyncHandler(notify func(TxStatus), next ...Handler) Handler {
+	return NewProposalProcessorHandler(
+		NewEndorsementHandler(
+			NewEndorsementValidationHandler(
+				NewSignatureValidationHandler(NewAsyncCommitHandler(notify, next...)),
+			),
+		),
+	)
+}
+
 //NewProposalProcessorHandler returns a handler that selects proposal processors
 func NewProposalProcessorHandler(next ...Handler) *ProposalProcessorHandler {
 	return &ProposalProcessorHandler{next: getNext(next)}
@@ -219,6 +496,24 @@ func NewCommitHandler(next ...Handler) *CommitTxHandler {
 	return &CommitTxHandler{next: getNext(next)}
 }
 
+//NewAsyncCommitHandler returns a handler that sends transaction proposal responses to the orderer and passes
+//the final status of the transaction to notify once it's known
+func NewAsyncCommitHandler(notify func(TxStatus), next ...Handler) *AsyncCommitTxHandler {
+	return &AsyncCommitTxHandler{notify: notify, next: getNext(next)}
+}
+
+//NewSignedEnvelopeHandler returns a handler that creates the signed envelope of an endorsed transaction,
+//which can be sent to the orderer later, without sending it
+func NewSignedEnvelopeHandler(next ...Handler) *SignedEnvelopeHandler {
+	return &SignedEnvelopeHandler{next: getNext(next)}
+}
+
+//NewChain returns a handler that runs the given handlers in order. The chain stops at the first handler
+//that sets the error of the request context.
+func NewChain(handlers ...Handler) Handler {
+	return &chainHandler{handlers: handlers}
+}
+
 func getNext(next []Handler) Handler {
 	if len(next) > 0 {
 		return next[0]
@@ -260,6 +555,9 @@ func createAndSendTransactionProposal(transactor fab.Transactor, chrequest *Requ
 	}
 
 	txh, err := transactor.CreateTransactionHeader()
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "creating transaction header failed")
+	}
 
 	proposal, err := txn.CreateChaincodeInvokeProposal(txh, request)
 	if err != nil {
@@ -20,6 +20,7 @@ import (
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/channel"
 	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
 )
 
 const (
@@ -86,6 +87,96 @@ func TestExecuteTxHandlerSuccess(t *testing.T) {
 	assert.Nil(t, requestContext.Error)
 }
 
+func TestExecuteAsyncHandler(t *testing.T) {
+	request := Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}
+	requestContext := prepareRequestContext(request, Opts{}, t)
+
+	mockPeer1 := &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockRoles: []string{}, MockCert: nil, MockMSP: "Org1MSP", Status: 200, Payload: []byte("value")}
+	clientContext := setupChannelClientContext(nil, nil, []fab.Peer{mockPeer1}, t)
+
+	// An event hub that doesn't report the block numbers of the transactions
+	mockEventHub := fcmocks.NewMockEventHub()
+	clientContext.EventHub = struct{ fab.EventHub }{mockEventHub}
+
+	statuses := make(chan TxStatus, 2)
+	executeHandler := NewExecuteAsyncHandler(func(s TxStatus) { statuses <- s })
+	executeHandler.Handle(requestContext, clientContext)
+	assert.Nil(t, requestContext.Error)
+	assert.True(t, requestContext.Broadcast, "expected the transaction to be broadcast")
+
+	select {
+	case callback := <-mockEventHub.RegisteredTxCallbacks:
+		callback(requestContext.Response.TransactionID, pb.TxValidationCode_VALID, nil)
+		callback(requestContext.Response.TransactionID, pb.TxValidationCode_DUPLICATE_TXID, nil)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the transaction event registration")
+	}
+
+	select {
+	case s := <-statuses:
+		assert.Equal(t, requestContext.Response.TransactionID, s.TxID)
+		assert.Equal(t, pb.TxValidationCode_VALID, s.ValidationCode)
+		assert.Nil(t, s.Err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the transaction status")
+	}
+	select {
+	case s := <-statuses:
+		t.Fatalf("Expected the transaction status to be notified once, got %+v", s)
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+func TestSignedEnvelopeHandler(t *testing.T) {
+	request := Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}
+	requestContext := prepareRequestContext(request, Opts{}, t)
+
+	mockPeer1 := &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockRoles: []string{}, MockCert: nil, MockMSP: "Org1MSP", Status: 200, Payload: []byte("value")}
+	broadcasts := make(chan *fab.SignedEnvelope, 1)
+	clientContext := setupChannelClientContext(nil, nil, []fab.Peer{mockPeer1}, t)
+	clientContext.Transactor.(*txnmocks.MockTransactor).Orderers = []fab.Orderer{fcmocks.NewMockOrderer("", broadcasts)}
+
+	NewChain(NewQueryHandler(), NewSignedEnvelopeHandler()).Handle(requestContext, clientContext)
+	assert.Nil(t, requestContext.Error)
+	assert.NotNil(t, requestContext.Response.SignedEnvelope, "expected the signed envelope")
+	assert.False(t, requestContext.Broadcast, "expected the transaction not to be broadcast")
+	assert.Empty(t, broadcasts, "expected the transaction not to be sent to the orderer")
+
+	// A transactor that can't sign transactions
+	requestContext = prepareRequestContext(request, Opts{}, t)
+	clientContext.Transactor = struct{ fab.Transactor }{clientContext.Transactor}
+	NewChain(NewQueryHandler(), NewSignedEnvelopeHandler()).Handle(requestContext, clientContext)
+	assert.EqualError(t, requestContext.Error, "transactor doesn't support signing transactions")
+}
+
+func TestChain(t *testing.T) {
+	var calls []string
+	handler := func(name string, err error) Handler {
+		return handlerFunc(func(requestContext *RequestContext, clientContext *ClientContext) {
+			calls = append(calls, name)
+			requestContext.Error = err
+		})
+	}
+
+	requestContext := &RequestContext{}
+	NewChain(handler("first", nil), handler("second", nil)).Handle(requestContext, &ClientContext{})
+	assert.Nil(t, requestContext.Error)
+	assert.Equal(t, []string{"first", "second"}, calls)
+
+	calls = nil
+	requestContext = &RequestContext{}
+	NewChain(handler("first", errors.New("failed")), handler("second", nil)).Handle(requestContext, &ClientContext{})
+	assert.EqualError(t, requestContext.Error, "failed")
+	assert.Equal(t, []string{"first"}, calls, "expected the chain to stop at the failed handler")
+}
+
+// handlerFunc adapts a function to Handler
+type handlerFunc func(requestContext *RequestContext, clientContext *ClientContext)
+
+func (f handlerFunc) Handle(requestContext *RequestContext, clientContext *ClientContext) {
+	f(requestContext, clientContext)
+}
+
 func TestQueryHandlerErrors(t *testing.T) {
 
 	//Error Scenario 1
@@ -152,6 +243,68 @@ func TestExecuteTxHandlerErrors(t *testing.T) {
 	}
 }
 
+// rejectingValidator rejects the responses of an endorser
+type rejectingValidator struct {
+	endorser string
+}
+
+func (v *rejectingValidator) Validate(responses []*fab.TransactionProposalResponse) ([]*fab.TransactionProposalResponse, error) {
+	var accepted []*fab.TransactionProposalResponse
+	for _, r := range responses {
+		if r.Endorser != v.endorser {
+			accepted = append(accepted, r)
+		}
+	}
+	return accepted, nil
+}
+
+func TestEndorsementValidationHandler(t *testing.T) {
+	responses := []*fab.TransactionProposalResponse{
+		newTestResponse("peer1", "stale rwset", "stale value"),
+		newTestResponse("peer2", "rwset", "value"),
+		newTestResponse("peer3", "rwset", "value"),
+	}
+	clientContext := setupChannelClientContext(nil, nil, nil, t)
+
+	requestContext := &RequestContext{Response: Response{Responses: responses, Payload: []byte("stale value")}}
+	NewEndorsementValidationHandler().Handle(requestContext, clientContext)
+	if _, ok := errors.Cause(requestContext.Error).(*EndorsementMismatchError); !ok {
+		t.Fatalf("Expected EndorsementMismatchError, got %v", requestContext.Error)
+	}
+
+	// The majority's responses are used
+	requestContext = &RequestContext{Opts: Opts{EndorsementMajority: true}, Response: Response{Responses: responses, Payload: []byte("stale value")}}
+	NewEndorsementValidationHandler().Handle(requestContext, clientContext)
+	assert.Nil(t, requestContext.Error)
+	assert.Equal(t, responses[1:], requestContext.Response.Responses)
+	assert.Equal(t, []byte("value"), requestContext.Response.Payload)
+
+	// Custom validators are run after the consistency check
+	requestContext = &RequestContext{
+		Opts:     Opts{ResponseValidators: []ResponseValidator{&rejectingValidator{endorser: "peer1"}}},
+		Response: Response{Responses: responses, Payload: []byte("stale value")},
+	}
+	NewEndorsementValidationHandler().Handle(requestContext, clientContext)
+	if _, ok := errors.Cause(requestContext.Error).(*EndorsementMismatchError); !ok {
+		t.Fatalf("Expected EndorsementMismatchError before the custom validators, got %v", requestContext.Error)
+	}
+
+	requestContext = &RequestContext{
+		Opts:     Opts{ResponseValidators: []ResponseValidator{&rejectingValidator{endorser: "peer2"}}},
+		Response: Response{Responses: responses[1:]},
+	}
+	NewEndorsementValidationHandler().Handle(requestContext, clientContext)
+	assert.Nil(t, requestContext.Error)
+	assert.Equal(t, responses[2:], requestContext.Response.Responses)
+
+	requestContext = &RequestContext{
+		Opts:     Opts{ResponseValidators: []ResponseValidator{&rejectingValidator{endorser: "peer2"}, &rejectingValidator{endorser: "peer3"}}},
+		Response: Response{Responses: responses[1:]},
+	}
+	NewEndorsementValidationHandler().Handle(requestContext, clientContext)
+	assert.NotNil(t, requestContext.Error, "Expected an error if the validators reject all the responses")
+}
+
 func TestEndorsementHandler(t *testing.T) {
 	request := Request{ChaincodeID: "test", Fcn: "invoke", Args: [][]byte{[]byte("move"), []byte("a"), []byte("b"), []byte("1")}}
 
@@ -192,6 +345,29 @@ func TestProposalProcessorHandler(t *testing.T) {
 		t.Fatalf("Didn't get expected peers")
 	}
 
+	// Select the proposal processors from a peer group
+	peerGroups := &mockPeerGroupSelector{peers: []fab.Peer{peer2}}
+	clientContext := setupChannelClientContext(nil, selectionErr, discoveryPeers, t)
+	clientContext.PeerGroups = peerGroups
+	requestContext = prepareRequestContext(request, Opts{PeerGroup: "readers"}, t)
+	handler.Handle(requestContext, clientContext)
+	if requestContext.Error != nil {
+		t.Fatalf("Got error: %s", requestContext.Error)
+	}
+	if peerGroups.group != "readers" {
+		t.Fatalf("Expecting peer group [readers] but got [%s]", peerGroups.group)
+	}
+	if len(requestContext.Opts.ProposalProcessors) != 1 || requestContext.Opts.ProposalProcessors[0] != peer2 {
+		t.Fatalf("Didn't get expected peers")
+	}
+
+	// Peer group without peer group selector
+	requestContext = prepareRequestContext(request, Opts{PeerGroup: "readers"}, t)
+	handler.Handle(requestContext, setupChannelClientContext(nil, nil, discoveryPeers, t))
+	if requestContext.Error == nil {
+		t.Fatalf("Expecting error for peer group without peer group selector")
+	}
+
 	// Directly pass in the proposal processors. In this case it should use those directly
 	requestContext = prepareRequestContext(request, Opts{ProposalProcessors: []fab.ProposalProcessor{peer2}}, t)
 	handler.Handle(requestContext, setupChannelClientContext(nil, nil, discoveryPeers, t))
@@ -206,6 +382,16 @@ func TestProposalProcessorHandler(t *testing.T) {
 	}
 }
 
+type mockPeerGroupSelector struct {
+	group string
+	peers []fab.Peer
+}
+
+func (s *mockPeerGroupSelector) Select(group string, peers []fab.Peer) ([]fab.Peer, error) {
+	s.group = group
+	return s.peers, nil
+}
+
 //prepareHandlerContexts prepares context objects for handlers
 func prepareRequestContext(request Request, opts Opts, t *testing.T) *RequestContext {
 
@@ -7,6 +7,7 @@ SPDX-License-Identifier: Apache-2.0
 package staticdiscovery
 
 import (
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
 
@@ -20,7 +21,8 @@ import (
 
 // DiscoveryProvider implements discovery provider
 type DiscoveryProvider struct {
-	config core.Config
+	config    core.Config
+	connector api.Connector
 }
 
 // discoveryService implements discovery service
@@ -34,6 +36,11 @@ func New(config core.Config) (*DiscoveryProvider, error) {
 	return &DiscoveryProvider{config: config}, nil
 }
 
+// SetConnector sets the connector that dials the connections to the discovered peers
+func (dp *DiscoveryProvider) SetConnector(connector api.Connector) {
+	dp.connector = connector
+}
+
 // NewDiscoveryService return discovery service for specific channel
 func (dp *DiscoveryProvider) NewDiscoveryService(channelID string) (fab.DiscoveryService, error) {
 
@@ -49,7 +56,7 @@ func (dp *DiscoveryProvider) NewDiscoveryService(channelID string) (fab.Discover
 
 		for _, p := range chPeers {
 
-			newPeer, err := peer.New(dp.config, peer.FromPeerConfig(&p.NetworkPeer))
+			newPeer, err := peer.New(dp.config, peer.FromPeerConfig(&p.NetworkPeer), peer.WithConnector(dp.connector))
 			if err != nil || newPeer == nil {
 				return nil, errors.WithMessage(err, "NewPeer failed")
 			}
@@ -65,7 +72,7 @@ func (dp *DiscoveryProvider) NewDiscoveryService(channelID string) (fab.Discover
 		}
 
 		for _, p := range netPeers {
-			newPeer, err := peer.New(dp.config, peer.FromPeerConfig(&p))
+			newPeer, err := peer.New(dp.config, peer.FromPeerConfig(&p), peer.WithConnector(dp.connector))
 			if err != nil {
 				return nil, errors.WithMessage(err, "NewPeerFromConfig failed")
 			}
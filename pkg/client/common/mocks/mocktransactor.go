@@ -44,3 +44,8 @@ func (t *MockTransactor) CreateTransaction(request fab.TransactionRequest) (*fab
 func (t *MockTransactor) SendTransaction(tx *fab.Transaction) (*fab.TransactionResponse, error) {
 	return txn.Send(t.Ctx, tx, t.Orderers)
 }
+
+// SignTransaction creates the signed envelope of a transaction without sending it to the orderer.
+func (t *MockTransactor) SignTransaction(tx *fab.Transaction) (*fab.SignedEnvelope, error) {
+	return txn.SignTransaction(t.Ctx, tx)
+}
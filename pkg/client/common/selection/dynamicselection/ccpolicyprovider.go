@@ -12,13 +12,13 @@ import (
 	"strings"
 	"sync"
 
-	"github.com/golang/protobuf/proto"
 	"github.com/pkg/errors"
 
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
-	peerImpl "github.com/hyperledger/fabric-sdk-go/pkg/fab/peer"
+	fabchannel "github.com/hyperledger/fabric-sdk-go/pkg/fab/channel"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk"
+	sdkApi "github.com/hyperledger/fabric-sdk-go/pkg/fabsdk/api"
 	"github.com/hyperledger/fabric-sdk-go/pkg/logging"
 	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/core/common/ccprovider"
 	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
@@ -26,11 +26,6 @@ import (
 
 var logger = logging.NewLogger("fabric_sdk_go")
 
-const (
-	ccDataProviderSCC      = "lscc"
-	ccDataProviderfunction = "getccdata"
-)
-
 // CCPolicyProvider retrieves policy for the given chaincode ID
 type CCPolicyProvider interface {
 	GetChaincodePolicy(chaincodeID string) (*common.SignaturePolicyEnvelope, error)
@@ -54,16 +49,17 @@ func newCCPolicyProvider(sdk *fabsdk.FabricSDK, channelID string, userName strin
 		return nil, errors.WithMessage(err, "unable to read configuration for channel peers")
 	}
 
-	return &ccPolicyProvider{config: sdk.Config(), client: client, channelID: channelID, targetPeers: targetPeers, ccDataMap: make(map[string]*ccprovider.ChaincodeData)}, nil
+	return &ccPolicyProvider{config: sdk.Config(), fabricProvider: sdk.FabricProvider(), client: client, channelID: channelID, targetPeers: targetPeers, ccDataMap: make(map[string]*ccprovider.ChaincodeData)}, nil
 }
 
 type ccPolicyProvider struct {
-	config      core.Config
-	client      *fabsdk.ClientContext
-	channelID   string
-	targetPeers []core.ChannelPeer
-	ccDataMap   map[string]*ccprovider.ChaincodeData // TODO: Add expiry and configurable timeout for map entries
-	mutex       sync.RWMutex
+	config         core.Config
+	fabricProvider sdkApi.FabricProvider
+	client         *fabsdk.ClientContext
+	channelID      string
+	targetPeers    []core.ChannelPeer
+	ccDataMap      map[string]*ccprovider.ChaincodeData // TODO: Add expiry and configurable timeout for map entries
+	mutex          sync.RWMutex
 }
 
 func (dp *ccPolicyProvider) GetChaincodePolicy(chaincodeID string) (*common.SignaturePolicyEnvelope, error) {
@@ -78,36 +74,26 @@ func (dp *ccPolicyProvider) GetChaincodePolicy(chaincodeID string) (*common.Sign
 	ccData = dp.ccDataMap[chaincodeID]
 	dp.mutex.RUnlock()
 	if ccData != nil {
-		return unmarshalPolicy(ccData.Policy)
+		return fabchannel.UnmarshalChaincodePolicy(ccData)
 	}
 
 	dp.mutex.Lock()
 	defer dp.mutex.Unlock()
 
-	response, err := dp.queryChaincode(ccDataProviderSCC, ccDataProviderfunction, [][]byte{[]byte(dp.channelID), []byte(chaincodeID)})
+	cir := fabchannel.ChaincodeDataInvokeRequest(dp.channelID, chaincodeID)
+	response, err := dp.queryChaincode(cir.ChaincodeID, cir.Fcn, cir.Args)
 	if err != nil {
 		return nil, errors.WithMessage(err, fmt.Sprintf("error querying chaincode data for chaincode [%s] on channel [%s]", chaincodeID, dp.channelID))
 	}
 
-	ccData = &ccprovider.ChaincodeData{}
-	err = proto.Unmarshal(response, ccData)
+	ccData, err = fabchannel.UnmarshalChaincodeData(response)
 	if err != nil {
-		return nil, errors.WithMessage(err, "Error unmarshalling chaincode data")
+		return nil, err
 	}
 
 	dp.ccDataMap[key.String()] = ccData
 
-	return unmarshalPolicy(ccData.Policy)
-}
-
-func unmarshalPolicy(policy []byte) (*common.SignaturePolicyEnvelope, error) {
-
-	sigPolicyEnv := &common.SignaturePolicyEnvelope{}
-	if err := proto.Unmarshal(policy, sigPolicyEnv); err != nil {
-		return nil, errors.WithMessage(err, "error unmarshalling SignaturePolicyEnvelope")
-	}
-
-	return sigPolicyEnv, nil
+	return fabchannel.UnmarshalChaincodePolicy(ccData)
 }
 
 func (dp *ccPolicyProvider) queryChaincode(ccID string, ccFcn string, ccArgs [][]byte) ([]byte, error) {
@@ -123,7 +109,8 @@ func (dp *ccPolicyProvider) queryChaincode(ccID string, ccFcn string, ccArgs [][
 
 	for _, p := range dp.targetPeers {
 
-		peer, err := peerImpl.New(dp.config, peerImpl.FromPeerConfig(&p.NetworkPeer))
+		// The peers of the fabric provider share the connections of the SDK
+		peer, err := dp.fabricProvider.CreatePeerFromConfig(&p.NetworkPeer)
 		if err != nil {
 			queryErrors = append(queryErrors, err.Error())
 			continue
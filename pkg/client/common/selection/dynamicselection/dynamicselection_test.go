@@ -15,6 +15,7 @@ import (
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
 	"github.com/hyperledger/fabric-sdk-go/pkg/core/config"
+	fabchannel "github.com/hyperledger/fabric-sdk-go/pkg/fab/channel"
 	mocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk/factory/defsvc"
@@ -242,7 +243,7 @@ func newMockCCDataProvider(channelID string) *mockCCDataProvider {
 }
 
 func (p *mockCCDataProvider) GetChaincodePolicy(chaincodeID string) (*common.SignaturePolicyEnvelope, error) {
-	return unmarshalPolicy(p.ccData[newResolverKey(p.channelID, chaincodeID).String()].Policy)
+	return fabchannel.UnmarshalChaincodePolicy(p.ccData[newResolverKey(p.channelID, chaincodeID).String()])
 }
 
 func (p *mockCCDataProvider) add(chaincodeID string, policy *ccprovider.ChaincodeData) *mockCCDataProvider {
@@ -0,0 +1,146 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package peergroup selects peers from the peer groups defined in the network configuration
+// using weighted random selection.
+package peergroup
+
+import (
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config/urlutil"
+	"github.com/hyperledger/fabric-sdk-go/pkg/logging"
+	"github.com/pkg/errors"
+)
+
+var logger = logging.NewLogger("fabric_sdk_go")
+
+type member struct {
+	address string
+	weight  int
+}
+
+type group struct {
+	use     core.PeerGroupUse
+	members []member
+}
+
+// Selector selects peers from the configured peer groups. Members are selected at random,
+// with a probability that is proportional to their weight within the group.
+type Selector struct {
+	groups map[string]*group
+	mutex  sync.Mutex
+	rand   *rand.Rand
+}
+
+func newRand() *rand.Rand {
+	return rand.New(rand.NewSource(time.Now().UnixNano()))
+}
+
+// New returns a new Selector for the peer groups defined in the given config
+func New(config core.Config) (*Selector, error) {
+	netConfig, err := config.NetworkConfig()
+	if err != nil {
+		return nil, errors.WithMessage(err, "unable to load network config")
+	}
+
+	groups := make(map[string]*group)
+	if netConfig == nil {
+		return &Selector{groups: groups}, nil
+	}
+
+	for name, groupConfig := range netConfig.PeerGroups {
+		g := &group{use: groupConfig.Use}
+		for _, m := range groupConfig.Members {
+			// viper lowercases all key maps
+			peerConfig, ok := netConfig.Peers[strings.ToLower(m.Peer)]
+			if !ok {
+				return nil, errors.Errorf("peer group [%s] references unknown peer [%s]", name, m.Peer)
+			}
+			if m.Weight <= 0 {
+				return nil, errors.Errorf("invalid weight [%d] for peer [%s] in peer group [%s]", m.Weight, m.Peer, name)
+			}
+			g.members = append(g.members, member{address: urlutil.ToAddress(peerConfig.URL), weight: m.Weight})
+		}
+		// viper lowercases all key maps
+		groups[strings.ToLower(name)] = g
+	}
+
+	return &Selector{groups: groups}, nil
+}
+
+// Select returns the members of the given peer group that are contained in the given set of peers.
+// If the intended use of the group is 'query' then a single peer is returned, otherwise all available
+// members are returned in weighted random order (i.e. the first peer is chosen by weighted random
+// selection, the second is chosen from the remaining members, etc.). Peers that are not in the given
+// set (for example, because they were filtered out by discovery) are never returned.
+func (s *Selector) Select(groupName string, peers []fab.Peer) ([]fab.Peer, error) {
+	g, ok := s.groups[strings.ToLower(groupName)]
+	if !ok {
+		return nil, errors.Errorf("peer group [%s] not found", groupName)
+	}
+
+	peersByAddress := make(map[string]fab.Peer)
+	for _, p := range peers {
+		peersByAddress[urlutil.ToAddress(p.URL())] = p
+	}
+
+	var candidates []fab.Peer
+	var weights []int
+	for _, m := range g.members {
+		if p, ok := peersByAddress[m.address]; ok {
+			candidates = append(candidates, p)
+			weights = append(weights, m.weight)
+		}
+	}
+
+	if len(candidates) == 0 {
+		return nil, errors.Errorf("no peers available in peer group [%s]", groupName)
+	}
+
+	sorted := s.shuffle(candidates, weights)
+	if g.use == core.PeerGroupUseQuery {
+		sorted = sorted[:1]
+	}
+
+	logger.Debugf("Selected peers from peer group [%s]: %v", groupName, sorted)
+	return sorted, nil
+}
+
+// shuffle returns the given peers in weighted random order
+func (s *Selector) shuffle(peers []fab.Peer, weights []int) []fab.Peer {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.rand == nil {
+		s.rand = newRand()
+	}
+
+	total := 0
+	for _, w := range weights {
+		total += w
+	}
+
+	var sorted []fab.Peer
+	for len(peers) > 0 {
+		n := s.rand.Intn(total)
+		i := 0
+		for ; n >= weights[i]; i++ {
+			n -= weights[i]
+		}
+
+		sorted = append(sorted, peers[i])
+		total -= weights[i]
+		peers = append(peers[:i:i], peers[i+1:]...)
+		weights = append(weights[:i:i], weights[i+1:]...)
+	}
+	return sorted
+}
@@ -0,0 +1,165 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package peergroup
+
+import (
+	"io/ioutil"
+	"math"
+	"testing"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/common/discovery"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/common/discovery/greylist"
+	txnmocks "github.com/hyperledger/fabric-sdk-go/pkg/client/common/mocks"
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config"
+	"github.com/hyperledger/fabric-sdk-go/pkg/errors/status"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+)
+
+const (
+	configTestFilePath = "../../../../../test/fixtures/config/config_test.yaml"
+	peer1URL           = "peer0.org1.example.com:7051"
+	peer2URL           = "peer0.org2.example.com:8051"
+	iterations         = 10000
+	tolerance          = 0.03
+)
+
+const peerGroups = `
+peerGroups:
+  readers:
+    use: query
+    members:
+      - peer: peer0.org1.example.com
+        weight: 80
+      - peer: peer0.org2.example.com
+        weight: 20
+  endorsers:
+    use: endorse
+    members:
+      - peer: peer0.org1.example.com
+        weight: 1
+      - peer: peer0.org2.example.com
+        weight: 3
+`
+
+func TestQueryGroupDistribution(t *testing.T) {
+	selector := newSelector(t)
+
+	peer1 := fcmocks.NewMockPeer("peer1", peer1URL)
+	peer2 := fcmocks.NewMockPeer("peer2", peer2URL)
+	peers := []fab.Peer{peer1, peer2}
+
+	count := 0
+	for i := 0; i < iterations; i++ {
+		selected, err := selector.Select("readers", peers)
+		if err != nil {
+			t.Fatalf("Select returned error: %s", err)
+		}
+		if len(selected) != 1 {
+			t.Fatalf("Expecting 1 peer to be selected for query group but got %d", len(selected))
+		}
+		if selected[0] == peer1 {
+			count++
+		}
+	}
+
+	checkDistribution(t, count, 0.8)
+}
+
+func TestEndorseGroupDistribution(t *testing.T) {
+	selector := newSelector(t)
+
+	peer1 := fcmocks.NewMockPeer("peer1", peer1URL)
+	peer2 := fcmocks.NewMockPeer("peer2", peer2URL)
+	peers := []fab.Peer{peer1, peer2}
+
+	count := 0
+	for i := 0; i < iterations; i++ {
+		selected, err := selector.Select("endorsers", peers)
+		if err != nil {
+			t.Fatalf("Select returned error: %s", err)
+		}
+		if len(selected) != 2 {
+			t.Fatalf("Expecting 2 peers to be selected for endorse group but got %d", len(selected))
+		}
+		if selected[0] == peer2 {
+			count++
+		}
+	}
+
+	checkDistribution(t, count, 0.75)
+}
+
+func TestGreylistedMembersExcluded(t *testing.T) {
+	selector := newSelector(t)
+
+	peer1 := fcmocks.NewMockPeer("peer1", peer1URL)
+	peer2 := fcmocks.NewMockPeer("peer2", peer2URL)
+
+	discoveryProvider, err := txnmocks.NewMockDiscoveryProvider(nil, []fab.Peer{peer1, peer2})
+	if err != nil {
+		t.Fatalf("Failed to create discovery provider: %s", err)
+	}
+	discoveryService, err := discoveryProvider.NewDiscoveryService("mychannel")
+	if err != nil {
+		t.Fatalf("Failed to create discovery service: %s", err)
+	}
+
+	filter := greylist.New(math.MaxInt64)
+	filter.Greylist(status.New(status.EndorserClientStatus, status.ConnectionFailed.ToInt32(), "", []interface{}{peer1URL}))
+	filterService := discovery.NewDiscoveryFilterService(discoveryService, filter)
+
+	for i := 0; i < 100; i++ {
+		peers, err := filterService.GetPeers()
+		if err != nil {
+			t.Fatalf("GetPeers returned error: %s", err)
+		}
+		selected, err := selector.Select("readers", peers)
+		if err != nil {
+			t.Fatalf("Select returned error: %s", err)
+		}
+		if selected[0] != peer2 {
+			t.Fatalf("Expecting greylisted peer to be excluded from the selection")
+		}
+	}
+}
+
+func TestSelectErrors(t *testing.T) {
+	selector := newSelector(t)
+
+	if _, err := selector.Select("unknown", []fab.Peer{fcmocks.NewMockPeer("peer1", peer1URL)}); err == nil {
+		t.Fatalf("Expecting error for unknown peer group")
+	}
+	if _, err := selector.Select("readers", []fab.Peer{fcmocks.NewMockPeer("other", "other.example.com:7051")}); err == nil {
+		t.Fatalf("Expecting error when no members of the peer group are available")
+	}
+}
+
+func newSelector(t *testing.T) *Selector {
+	cBytes, err := ioutil.ReadFile(configTestFilePath)
+	if err != nil {
+		t.Fatalf("Failed to read config file: %s", err)
+	}
+
+	c, err := config.FromRaw(append(cBytes, []byte(peerGroups)...), "yaml")()
+	if err != nil {
+		t.Fatalf("Failed to load config: %s", err)
+	}
+
+	selector, err := New(c)
+	if err != nil {
+		t.Fatalf("Failed to create selector: %s", err)
+	}
+	return selector
+}
+
+func checkDistribution(t *testing.T, count int, expected float64) {
+	actual := float64(count) / iterations
+	if math.Abs(actual-expected) > tolerance {
+		t.Fatalf("Expecting ratio of %.2f (+/- %.2f) but got %.2f", expected, tolerance, actual)
+	}
+}
@@ -0,0 +1,220 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package idmgmt enables management (enrollment, registration and revocation) of the identities of an
+// organization with its Fabric CA.
+package idmgmt
+
+import (
+	"github.com/pkg/errors"
+
+	contextApi "github.com/hyperledger/fabric-sdk-go/pkg/context/api"
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/identity"
+)
+
+// RegistrationRequest defines the attributes required to register an identity with the CA
+type RegistrationRequest struct {
+	// Name is the unique name (enrollment ID) of the identity
+	Name string
+	// Type of identity being registered (e.g. "peer", "app", "user")
+	Type string
+	// MaxEnrollments is the number of times the secret can be reused to enroll.
+	// If omitted, this defaults to max_enrollments configured on the CA.
+	MaxEnrollments int
+	// Affiliation of the identity, e.g. org1.department1. If omitted, the affiliation of the registrar is used.
+	Affiliation string
+	// Attributes of the identity
+	Attributes []Attribute
+	// Secret is an optional enrollment secret. If omitted, a random secret is generated.
+	Secret string
+}
+
+// Attribute is an attribute of a registered identity
+type Attribute struct {
+	Name  string
+	Value string
+	// ECert is true if the attribute is added to the enrollment certificate by default.
+	// A value with the ":ecert" suffix (e.g. "true:ecert") sets it as well.
+	ECert bool
+}
+
+// RevocationRequest defines the attributes required to revoke the certificates of an identity
+type RevocationRequest struct {
+	// Name of the identity whose certificates are revoked (the identity is revoked as well).
+	// If omitted, Serial and AKI must be specified.
+	Name string
+	// Serial number of the certificate to be revoked
+	Serial string
+	// AKI (Authority Key Identifier) of the certificate to be revoked
+	AKI string
+	// Reason is the reason for revocation. See https://godoc.org/golang.org/x/crypto/ocsp
+	// for valid values. The default value is 0 (ocsp.Unspecified).
+	Reason string
+	// GenCRL specifies whether the CA generates a CRL, which is returned in the response
+	GenCRL bool
+}
+
+// RevocationResponse contains the revoked certificates and the CRL (if requested)
+type RevocationResponse struct {
+	// RevokedCerts are the certificates that were revoked
+	RevokedCerts []RevokedCert
+	// CRL is the PEM-encoded certificate revocation list that contains all unexpired revoked certificates
+	CRL []byte
+}
+
+// RevokedCert represents a revoked certificate
+type RevokedCert struct {
+	// Serial number of the revoked certificate
+	Serial string
+	// AKI of the revoked certificate
+	AKI string
+}
+
+// Client enrolls, reenrolls, registers and revokes the identities of an organization with the CA of the organization
+// (see the certificateAuthorities section of the configuration). The enrolled identities are stored
+// in the credential store, so that they can be used right away, e.g. with sdk.Context(fabsdk.WithUser(name)).
+type Client struct {
+	identityManager fab.IdentityManager
+}
+
+// Context holds the identity manager of the organization needed to create a Client.
+type Context struct {
+	IdentityManager fab.IdentityManager
+}
+
+// New returns an identity management Client for the organization of the identity manager.
+func New(c Context) (*Client, error) {
+	if c.IdentityManager == nil {
+		return nil, errors.New("identity manager is required")
+	}
+
+	client := Client{
+		identityManager: c.IdentityManager,
+	}
+	return &client, nil
+}
+
+// Enroll enrolls a registered identity with the CA. The private key is generated by the crypto suite (so
+// it may be kept in an HSM), the CSR is submitted to the CA and the resulting identity is stored in the
+// credential store. The options set the hosts of the CSR, the signing profile and the requested attributes.
+func (c *Client) Enroll(enrollmentID string, secret string, options ...EnrollOpt) error {
+	if enrollmentID == "" {
+		return errors.New("enrollmentID is required")
+	}
+	if secret == "" {
+		return errors.New("secret is required")
+	}
+
+	opts, err := prepareEnrollOpts(options...)
+	if err != nil {
+		return err
+	}
+
+	req := fab.EnrollmentRequest{
+		Name:     enrollmentID,
+		Secret:   secret,
+		Hosts:    opts.Hosts,
+		Profile:  opts.Profile,
+		AttrReqs: opts.AttrReqs,
+	}
+	if _, _, err := c.identityManager.EnrollWithRequest(&req); err != nil {
+		return errors.WithMessage(err, "enroll failed")
+	}
+	return nil
+}
+
+// Reenroll reenrolls an enrolled identity with the CA, e.g. to renew its certificate before it expires.
+// The identity is loaded from the credential store (or the configuration) and the reenrolled identity
+// is stored in the credential store.
+func (c *Client) Reenroll(enrollmentID string) error {
+	if enrollmentID == "" {
+		return errors.New("enrollmentID is required")
+	}
+
+	signingIdentity, err := c.identityManager.GetSigningIdentity(enrollmentID)
+	if err != nil {
+		if errors.Cause(err) == contextApi.ErrUserNotFound {
+			return errors.Errorf("user %s is not enrolled", enrollmentID)
+		}
+		return errors.WithMessage(err, "failed to get signing identity")
+	}
+	if len(signingIdentity.EnrollmentCert) == 0 || signingIdentity.PrivateKey == nil {
+		return errors.Errorf("user %s is not enrolled", enrollmentID)
+	}
+
+	user := identity.NewUser(signingIdentity.MspID, enrollmentID)
+	user.SetEnrollmentCertificate(signingIdentity.EnrollmentCert)
+	user.SetPrivateKey(signingIdentity.PrivateKey)
+	if _, _, err := c.identityManager.Reenroll(user); err != nil {
+		return errors.WithMessage(err, "reenroll failed")
+	}
+	return nil
+}
+
+// Register registers an identity with the CA and returns its enrollment secret. The request is signed by
+// the registrar of the organization (see the registrar of the CA in the configuration), which must be allowed
+// to register identities of the given type, affiliation and attributes.
+func (c *Client) Register(request *RegistrationRequest) (string, error) {
+	if request == nil {
+		return "", errors.New("registration request is required")
+	}
+
+	var attributes []fab.Attribute
+	for _, attr := range request.Attributes {
+		attributes = append(attributes, fab.Attribute{Name: attr.Name, Value: attr.Value, ECert: attr.ECert})
+	}
+	req := fab.RegistrationRequest{
+		Name:           request.Name,
+		Type:           request.Type,
+		MaxEnrollments: request.MaxEnrollments,
+		Affiliation:    request.Affiliation,
+		Attributes:     attributes,
+		Secret:         request.Secret,
+	}
+	secret, err := c.identityManager.Register(&req)
+	if err != nil {
+		return "", errors.WithMessage(err, "register failed")
+	}
+	return secret, nil
+}
+
+// Revoke revokes an identity and all of its certificates or a single certificate with the CA. The request is
+// signed by the registrar of the organization, which must have the hf.Revoker attribute.
+func (c *Client) Revoke(request *RevocationRequest) (*RevocationResponse, error) {
+	if request == nil {
+		return nil, errors.New("revocation request is required")
+	}
+
+	req := fab.RevocationRequest{
+		Name:   request.Name,
+		Serial: request.Serial,
+		AKI:    request.AKI,
+		Reason: request.Reason,
+		GenCRL: request.GenCRL,
+	}
+	resp, err := c.identityManager.Revoke(&req)
+	if err != nil {
+		return nil, errors.WithMessage(err, "revoke failed")
+	}
+
+	var revokedCerts []RevokedCert
+	for _, revokedCert := range resp.RevokedCerts {
+		revokedCerts = append(revokedCerts, RevokedCert{Serial: revokedCert.Serial, AKI: revokedCert.AKI})
+	}
+	return &RevocationResponse{RevokedCerts: revokedCerts, CRL: resp.CRL}, nil
+}
+
+func prepareEnrollOpts(options ...EnrollOpt) (EnrollOpts, error) {
+	opts := EnrollOpts{}
+	for _, option := range options {
+		err := option(&opts)
+		if err != nil {
+			return opts, errors.WithMessage(err, "Failed to read opts")
+		}
+	}
+	return opts, nil
+}
@@ -0,0 +1,156 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package idmgmt
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	contextApi "github.com/hyperledger/fabric-sdk-go/pkg/context/api"
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+	camocks "github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab/mocks"
+	bccspwrapper "github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite/bccsp/wrapper"
+	idmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/identitymgr/mocks"
+)
+
+func TestEnroll(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	identityManager := camocks.NewMockIdentityManager(ctrl)
+	client := setupClient(t, identityManager)
+
+	identityManager.EXPECT().EnrollWithRequest(&fab.EnrollmentRequest{Name: "user1", Secret: "secret"}).Return(nil, []byte("cert"), nil)
+	err := client.Enroll("user1", "secret")
+	assert.Nil(t, err, "Enroll failed")
+
+	expected := &fab.EnrollmentRequest{
+		Name:     "peer1",
+		Secret:   "secret",
+		Hosts:    []string{"peer1.example.com", "127.0.0.1"},
+		Profile:  TLSProfile,
+		AttrReqs: []fab.AttributeRequest{{Name: "hf.Type"}, {Name: "app.role", Optional: true}},
+	}
+	identityManager.EXPECT().EnrollWithRequest(expected).Return(nil, []byte("cert"), nil)
+	err = client.Enroll("peer1", "secret", WithHosts("peer1.example.com", "127.0.0.1"), WithProfile(TLSProfile),
+		WithAttributeRequests(fab.AttributeRequest{Name: "hf.Type"}), WithAttributeRequests(fab.AttributeRequest{Name: "app.role", Optional: true}))
+	assert.Nil(t, err, "Enroll with options failed")
+
+	identityManager.EXPECT().EnrollWithRequest(gomock.Any()).Return(nil, nil, errors.New("CA error"))
+	err = client.Enroll("user1", "secret")
+	assert.NotNil(t, err, "expected the error of the CA")
+
+	// The requests are invalid, the CA isn't called
+	assert.NotNil(t, client.Enroll("", "secret"), "expected an error without enrollment ID")
+	assert.NotNil(t, client.Enroll("user1", ""), "expected an error without secret")
+	assert.NotNil(t, client.Enroll("user1", "secret", WithAttributeRequests(fab.AttributeRequest{})), "expected an error for an attribute request without name")
+}
+
+func TestReenroll(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	identityManager := camocks.NewMockIdentityManager(ctrl)
+	client := setupClient(t, identityManager)
+
+	key := bccspwrapper.GetKey(&idmocks.MockKey{})
+	signingIdentity := &contextApi.SigningIdentity{MspID: "Org1MSP", EnrollmentCert: []byte("cert"), PrivateKey: key}
+	identityManager.EXPECT().GetSigningIdentity("user1").Return(signingIdentity, nil)
+	identityManager.EXPECT().Reenroll(gomock.Any()).Do(func(user contextApi.User) {
+		assert.Equal(t, "user1", user.Name())
+		assert.Equal(t, "Org1MSP", user.MspID())
+		assert.Equal(t, []byte("cert"), user.EnrollmentCertificate())
+		assert.Equal(t, key, user.PrivateKey())
+	}).Return(nil, []byte("new cert"), nil)
+	err := client.Reenroll("user1")
+	assert.Nil(t, err, "Reenroll failed")
+
+	identityManager.EXPECT().GetSigningIdentity("user2").Return(nil, contextApi.ErrUserNotFound)
+	err = client.Reenroll("user2")
+	if assert.NotNil(t, err, "expected an error for a user that isn't enrolled") {
+		assert.Contains(t, err.Error(), "not enrolled")
+	}
+
+	identityManager.EXPECT().GetSigningIdentity("user3").Return(&contextApi.SigningIdentity{MspID: "Org1MSP", EnrollmentCert: []byte("cert")}, nil)
+	err = client.Reenroll("user3")
+	assert.NotNil(t, err, "expected an error for a user without private key")
+
+	assert.NotNil(t, client.Reenroll(""), "expected an error without enrollment ID")
+}
+
+func TestRegister(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	identityManager := camocks.NewMockIdentityManager(ctrl)
+	client := setupClient(t, identityManager)
+
+	expected := &fab.RegistrationRequest{
+		Name:           "peer1",
+		Type:           "peer",
+		MaxEnrollments: 2,
+		Affiliation:    "org1.department1",
+		Attributes:     []fab.Attribute{{Name: "app.role", Value: "admin", ECert: true}},
+		Secret:         "peer1pw",
+	}
+	identityManager.EXPECT().Register(expected).Return("peer1pw", nil)
+	secret, err := client.Register(&RegistrationRequest{
+		Name:           "peer1",
+		Type:           "peer",
+		MaxEnrollments: 2,
+		Affiliation:    "org1.department1",
+		Attributes:     []Attribute{{Name: "app.role", Value: "admin", ECert: true}},
+		Secret:         "peer1pw",
+	})
+	assert.Nil(t, err, "Register failed")
+	assert.Equal(t, "peer1pw", secret)
+
+	identityManager.EXPECT().Register(gomock.Any()).Return("", errors.New("CA error"))
+	_, err = client.Register(&RegistrationRequest{Name: "peer1"})
+	assert.NotNil(t, err, "expected the error of the CA")
+
+	_, err = client.Register(nil)
+	assert.NotNil(t, err, "expected an error without request")
+}
+
+func TestRevoke(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	identityManager := camocks.NewMockIdentityManager(ctrl)
+	client := setupClient(t, identityManager)
+
+	expected := &fab.RevocationRequest{Serial: "1234", AKI: "abcd", Reason: "keyCompromise", GenCRL: true}
+	identityManager.EXPECT().Revoke(expected).Return(&fab.RevocationResponse{
+		RevokedCerts: []fab.RevokedCert{{Serial: "1234", AKI: "abcd"}},
+		CRL:          []byte("crl"),
+	}, nil)
+	resp, err := client.Revoke(&RevocationRequest{Serial: "1234", AKI: "abcd", Reason: "keyCompromise", GenCRL: true})
+	if assert.Nil(t, err, "Revoke failed") {
+		assert.Equal(t, []RevokedCert{{Serial: "1234", AKI: "abcd"}}, resp.RevokedCerts)
+		assert.Equal(t, []byte("crl"), resp.CRL)
+	}
+
+	identityManager.EXPECT().Revoke(gomock.Any()).Return(nil, errors.New("CA error"))
+	_, err = client.Revoke(&RevocationRequest{Name: "peer1"})
+	assert.NotNil(t, err, "expected the error of the CA")
+
+	_, err = client.Revoke(nil)
+	assert.NotNil(t, err, "expected an error without request")
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(Context{})
+	assert.NotNil(t, err, "expected an error without identity manager")
+}
+
+func setupClient(t *testing.T, identityManager fab.IdentityManager) *Client {
+	client, err := New(Context{IdentityManager: identityManager})
+	if err != nil {
+		t.Fatalf("Failed to create identity management client: %s", err)
+	}
+	return client
+}
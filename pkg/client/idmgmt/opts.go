@@ -0,0 +1,56 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package idmgmt
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+)
+
+// TLSProfile is the signing profile that the Fabric CA uses to issue TLS certificates
+const TLSProfile = "tls"
+
+// EnrollOpts contains the options for enrollment
+type EnrollOpts struct {
+	Hosts    []string               // hosts of the CSR (default is the host name of the client)
+	Profile  string                 // signing profile of the CA (default is the default profile of the CA)
+	AttrReqs []fab.AttributeRequest // attributes of the identity that are added to the certificate
+}
+
+// EnrollOpt func for each EnrollOpts argument
+type EnrollOpt func(opts *EnrollOpts) error
+
+// WithHosts sets the host names and IP addresses that are added to the CSR as subject alternative names
+func WithHosts(hosts ...string) EnrollOpt {
+	return func(opts *EnrollOpts) error {
+		opts.Hosts = hosts
+		return nil
+	}
+}
+
+// WithProfile sets the signing profile that the CA uses to issue the certificate, e.g. TLSProfile
+func WithProfile(profile string) EnrollOpt {
+	return func(opts *EnrollOpts) error {
+		opts.Profile = profile
+		return nil
+	}
+}
+
+// WithAttributeRequests requests attributes of the identity to be added to the certificate. The enrollment
+// fails if the identity doesn't have a requested attribute, unless the request is optional.
+func WithAttributeRequests(attrReqs ...fab.AttributeRequest) EnrollOpt {
+	return func(opts *EnrollOpts) error {
+		for _, attrReq := range attrReqs {
+			if attrReq.Name == "" {
+				return errors.New("attribute name is required")
+			}
+		}
+		opts.AttrReqs = append(opts.AttrReqs, attrReqs...)
+		return nil
+	}
+}
@@ -0,0 +1,336 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package ledger enables queries of the ledger of a channel, through the query system chaincode (qscc).
+package ledger
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/errors/multi"
+	"github.com/hyperledger/fabric-sdk-go/pkg/errors/status"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/chconfig"
+	"github.com/hyperledger/fabric-sdk-go/pkg/logging"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+)
+
+var logger = logging.NewLogger("fabric_sdk_go")
+
+// Client enables queries of the ledger of a channel.
+//
+// Each query is sent to each of the targets separately so that the responses can be verified:
+// the query only succeeds if the minimum number of targets respond (see WithMinTargets) and
+// the responses of all the targets that respond match.
+type Client struct {
+	channelID string
+	discovery fab.DiscoveryService
+	ledger    fab.ChannelLedger
+}
+
+// Context holds the channel ID and the services needed to create a Client.
+type Context struct {
+	ChannelID        string
+	DiscoveryService fab.DiscoveryService
+	ChannelService   fab.ChannelService
+}
+
+// Opts contains the options for ledger queries
+type Opts struct {
+	Targets      []fab.Peer // targets
+	TargetFilter fab.TargetFilter
+	MinTargets   int // number of targets that must respond (default 1)
+	MaxTargets   int // number of targets that are queried (default MinTargets)
+}
+
+//RequestOption func for each Opts argument
+type RequestOption func(opts *Opts) error
+
+// MismatchError is returned if the responses of the targets of a query don't match
+type MismatchError struct {
+	// Divergent contains the endorsers whose responses don't match the response of most targets
+	Divergent []string
+}
+
+func (e *MismatchError) Error() string {
+	return fmt.Sprintf("responses of the targets don't match (divergent: %s)", strings.Join(e.Divergent, ", "))
+}
+
+// New returns a ledger Client for the channel of the given services.
+func New(c Context) (*Client, error) {
+	ledger, err := c.ChannelService.Ledger()
+	if err != nil {
+		return nil, errors.WithMessage(err, "ledger creation failed")
+	}
+
+	client := Client{
+		channelID: c.ChannelID,
+		discovery: c.DiscoveryService,
+		ledger:    ledger,
+	}
+	return &client, nil
+}
+
+// QueryInfo queries for various useful information on the state of the channel (e.g. its height).
+func (c *Client) QueryInfo(options ...RequestOption) (*fab.BlockchainInfoResponse, error) {
+	resp, endorser, err := c.query(func(targets []fab.ProposalProcessor) (proto.Message, error) {
+		infos, err := c.ledger.QueryInfo(targets)
+		if err != nil || len(infos) == 0 {
+			return nil, noResponse(err)
+		}
+		return infos[0], nil
+	}, options...)
+	if err != nil {
+		return nil, err
+	}
+	return &fab.BlockchainInfoResponse{BCI: resp.(*common.BlockchainInfo), Endorser: endorser}, nil
+}
+
+// QueryBlock queries the ledger for the block with the given number.
+func (c *Client) QueryBlock(blockNumber uint64, options ...RequestOption) (*common.Block, error) {
+	number := int(blockNumber)
+	if number < 0 || uint64(number) != blockNumber {
+		return nil, errors.Errorf("block number %d is out of range", blockNumber)
+	}
+
+	return c.queryBlock(func(targets []fab.ProposalProcessor) ([]*common.Block, error) {
+		return c.ledger.QueryBlock(number, targets)
+	}, options...)
+}
+
+// QueryBlockByHash queries the ledger for the block with the given hash.
+func (c *Client) QueryBlockByHash(blockHash []byte, options ...RequestOption) (*common.Block, error) {
+	if len(blockHash) == 0 {
+		return nil, errors.New("blockHash is required")
+	}
+
+	return c.queryBlock(func(targets []fab.ProposalProcessor) ([]*common.Block, error) {
+		return c.ledger.QueryBlockByHash(blockHash, targets)
+	}, options...)
+}
+
+// QueryBlockByTxID queries the ledger for the block that contains the given transaction.
+func (c *Client) QueryBlockByTxID(txID string, options ...RequestOption) (*common.Block, error) {
+	if txID == "" {
+		return nil, errors.New("txID is required")
+	}
+
+	return c.queryBlock(func(targets []fab.ProposalProcessor) ([]*common.Block, error) {
+		return c.ledger.QueryBlockByTxID(fab.TransactionID(txID), targets)
+	}, options...)
+}
+
+// QueryTransaction queries the ledger for the processed transaction with the given ID.
+func (c *Client) QueryTransaction(txID string, options ...RequestOption) (*pb.ProcessedTransaction, error) {
+	if txID == "" {
+		return nil, errors.New("txID is required")
+	}
+
+	resp, _, err := c.query(func(targets []fab.ProposalProcessor) (proto.Message, error) {
+		txs, err := c.ledger.QueryTransaction(fab.TransactionID(txID), targets)
+		if err != nil || len(txs) == 0 {
+			return nil, noResponse(err)
+		}
+		return txs[0], nil
+	}, options...)
+	if err != nil {
+		return nil, err
+	}
+	return resp.(*pb.ProcessedTransaction), nil
+}
+
+// QueryConfig queries the ledger for the latest config block of the channel and returns the parsed
+// channel configuration. The raw config envelope is available from the returned configuration.
+func (c *Client) QueryConfig(options ...RequestOption) (fab.ChannelCfg, error) {
+	resp, _, err := c.query(func(targets []fab.ProposalProcessor) (proto.Message, error) {
+		return c.ledger.QueryConfigBlock(targets, 1)
+	}, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := chconfig.FromConfigEnvelope(c.channelID, resp.(*common.ConfigEnvelope))
+	if err != nil {
+		return nil, errors.WithMessage(err, "parse channel config failed")
+	}
+	return config, nil
+}
+
+func (c *Client) queryBlock(fn func(targets []fab.ProposalProcessor) ([]*common.Block, error), options ...RequestOption) (*common.Block, error) {
+	resp, _, err := c.query(func(targets []fab.ProposalProcessor) (proto.Message, error) {
+		blocks, err := fn(targets)
+		if err != nil || len(blocks) == 0 {
+			return nil, noResponse(err)
+		}
+		return blocks[0], nil
+	}, options...)
+	if err != nil {
+		return nil, err
+	}
+	return resp.(*common.Block), nil
+}
+
+// targetResponse is the response of a target to a query
+type targetResponse struct {
+	endorser string
+	message  proto.Message
+}
+
+// query sends the query to each of the targets and verifies the responses. It returns the verified
+// response and the endorser of the response.
+func (c *Client) query(fn func(targets []fab.ProposalProcessor) (proto.Message, error), options ...RequestOption) (proto.Message, string, error) {
+	opts, err := c.prepareOpts(options...)
+	if err != nil {
+		return nil, "", err
+	}
+
+	targets, err := c.resolveTargets(opts)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	var responses []targetResponse
+	var errs error
+	for _, target := range targets {
+		wg.Add(1)
+		go func(target fab.Peer) {
+			defer wg.Done()
+
+			message, err := fn([]fab.ProposalProcessor{target})
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			if err != nil {
+				errs = multi.Append(errs, errors.WithMessage(err, "From target: "+target.URL()))
+				return
+			}
+			responses = append(responses, targetResponse{endorser: target.URL(), message: message})
+		}(target)
+	}
+	wg.Wait()
+
+	if len(responses) < opts.MinTargets {
+		return nil, "", errors.Errorf("%d of %d targets responded but %d responses are required: %v", len(responses), len(targets), opts.MinTargets, errs)
+	}
+	if errs != nil {
+		logger.Debugf("Some of the targets failed to respond to the ledger query: %s", errs)
+	}
+
+	return verify(responses)
+}
+
+// verify checks that the responses match. If they don't then a MismatchError naming the endorsers that
+// don't agree with most of the targets is returned.
+func verify(responses []targetResponse) (proto.Message, string, error) {
+	sort.Slice(responses, func(i, j int) bool {
+		return responses[i].endorser < responses[j].endorser
+	})
+
+	var groups [][]targetResponse
+	for _, resp := range responses {
+		matched := false
+		for i, group := range groups {
+			if proto.Equal(group[0].message, resp.message) {
+				groups[i] = append(group, resp)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			groups = append(groups, []targetResponse{resp})
+		}
+	}
+
+	majority := 0
+	for i, group := range groups {
+		if len(group) > len(groups[majority]) {
+			majority = i
+		}
+	}
+
+	if len(groups) > 1 {
+		var divergent []string
+		for i, group := range groups {
+			if i == majority {
+				continue
+			}
+			for _, resp := range group {
+				divergent = append(divergent, resp.endorser)
+			}
+		}
+		sort.Strings(divergent)
+		return nil, "", &MismatchError{Divergent: divergent}
+	}
+
+	return groups[majority][0].message, groups[majority][0].endorser, nil
+}
+
+// resolveTargets returns the targets of the query: the requested number of targets that are accepted
+// by the target filter, among the given targets or the peers of the channel
+func (c *Client) resolveTargets(opts Opts) ([]fab.Peer, error) {
+	targets := opts.Targets
+	if len(targets) == 0 {
+		peers, err := c.discovery.GetPeers()
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to discover peers")
+		}
+		targets = peers
+	}
+
+	if opts.TargetFilter != nil {
+		var accepted []fab.Peer
+		for _, target := range targets {
+			if opts.TargetFilter.Accept(target) {
+				accepted = append(accepted, target)
+			}
+		}
+		targets = accepted
+	}
+
+	if len(targets) < opts.MinTargets {
+		return nil, status.New(status.ClientStatus, status.NoPeersFound.ToInt32(),
+			fmt.Sprintf("%d targets are required but only %d are available", opts.MinTargets, len(targets)), nil)
+	}
+	if len(targets) > opts.MaxTargets {
+		targets = targets[:opts.MaxTargets]
+	}
+	return targets, nil
+}
+
+func (c *Client) prepareOpts(options ...RequestOption) (Opts, error) {
+	opts := Opts{}
+	for _, option := range options {
+		err := option(&opts)
+		if err != nil {
+			return opts, errors.WithMessage(err, "Failed to read opts")
+		}
+	}
+
+	if opts.MinTargets == 0 {
+		opts.MinTargets = 1
+	}
+	if opts.MaxTargets < opts.MinTargets {
+		opts.MaxTargets = opts.MinTargets
+	}
+	return opts, nil
+}
+
+// noResponse returns the error of a target that didn't return a response
+func noResponse(err error) error {
+	if err != nil {
+		return err
+	}
+	return errors.New("no response")
+}
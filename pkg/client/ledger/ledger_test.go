@@ -0,0 +1,200 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ledger
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	txnmocks "github.com/hyperledger/fabric-sdk-go/pkg/client/common/mocks"
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/errors/status"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/channel"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+)
+
+const (
+	testChannel        = "mychannel"
+	fixtureConfigBlock = "../../../test/fixtures/fabric/v1.1.0-alpha/channel/twoorgs.genesis.block"
+)
+
+var (
+	testBlock      = &common.Block{Header: &common.BlockHeader{Number: 7, PreviousHash: []byte("previous"), DataHash: []byte("data")}}
+	divergentBlock = &common.Block{Header: &common.BlockHeader{Number: 7, PreviousHash: []byte("previous"), DataHash: []byte("forked")}}
+)
+
+func TestQueryBlock(t *testing.T) {
+	peer1 := newFixturePeer(t, "grpcs://peer1.example.com:7051", testBlock)
+	peer2 := newFixturePeer(t, "grpcs://peer2.example.com:7051", testBlock)
+	client := setupLedgerClient(t, peer1, peer2)
+
+	block, err := client.QueryBlock(7)
+	assert.Nil(t, err, "QueryBlock failed")
+	assert.True(t, proto.Equal(testBlock, block), "expected the block of the endorser")
+	assert.Equal(t, 1, peer1.ProcessProposalCalls+peer2.ProcessProposalCalls, "expected one target to be queried by default")
+
+	block, err = client.QueryBlockByHash([]byte("hash"), WithMinTargets(2))
+	assert.Nil(t, err, "QueryBlockByHash failed")
+	assert.True(t, proto.Equal(testBlock, block), "expected the block of the endorsers")
+	assert.Equal(t, 3, peer1.ProcessProposalCalls+peer2.ProcessProposalCalls, "expected both targets to be queried")
+
+	block, err = client.QueryBlockByTxID("txid", WithTargets(peer2))
+	assert.Nil(t, err, "QueryBlockByTxID failed")
+	assert.True(t, proto.Equal(testBlock, block), "expected the block of the endorser")
+	assert.Equal(t, 2, peer2.ProcessProposalCalls, "expected the given target to be queried")
+}
+
+func TestQueryBlockMismatch(t *testing.T) {
+	peer1 := newFixturePeer(t, "grpcs://peer1.example.com:7051", testBlock)
+	peer2 := newFixturePeer(t, "grpcs://peer2.example.com:7051", divergentBlock)
+	peer3 := newFixturePeer(t, "grpcs://peer3.example.com:7051", testBlock)
+	client := setupLedgerClient(t, peer1, peer2, peer3)
+
+	_, err := client.QueryBlock(7, WithMinTargets(3))
+	mismatchErr, ok := errors.Cause(err).(*MismatchError)
+	if !ok {
+		t.Fatalf("Expected MismatchError, got %v", err)
+	}
+	assert.Equal(t, []string{peer2.URL()}, mismatchErr.Divergent)
+
+	// The matching targets agree
+	block, err := client.QueryBlock(7, WithTargets(peer1, peer3), WithMinTargets(2))
+	assert.Nil(t, err, "expected the responses of the matching targets to be accepted")
+	assert.True(t, proto.Equal(testBlock, block))
+}
+
+func TestQueryMinTargets(t *testing.T) {
+	peer1 := newFixturePeer(t, "grpcs://peer1.example.com:7051", testBlock)
+	peer2 := newFixturePeer(t, "grpcs://peer2.example.com:7051", testBlock)
+	peer2.Status = 500
+	client := setupLedgerClient(t, peer1, peer2)
+
+	_, err := client.QueryBlock(7, WithMinTargets(2))
+	assert.NotNil(t, err, "expected an error if fewer than the minimum number of targets respond")
+	assert.Contains(t, err.Error(), "1 of 2 targets responded but 2 responses are required")
+
+	// The query succeeds as long as the minimum number of targets respond
+	block, err := client.QueryBlock(7, WithMaxTargets(2))
+	assert.Nil(t, err, "expected the query to succeed with one response")
+	assert.True(t, proto.Equal(testBlock, block))
+
+	_, err = client.QueryBlock(7, WithMinTargets(3))
+	s, ok := status.FromError(err)
+	assert.True(t, ok, "expected status error")
+	assert.EqualValues(t, status.NoPeersFound.ToInt32(), s.Code, "expected no peers found if there aren't enough targets")
+
+	_, err = client.QueryBlock(7, WithTargetFilter(fab.TargetFilterFunc(func(peer fab.Peer) bool { return false })))
+	s, ok = status.FromError(err)
+	assert.True(t, ok, "expected status error")
+	assert.EqualValues(t, status.NoPeersFound.ToInt32(), s.Code, "expected no peers found if the filter rejects the targets")
+
+	_, err = client.QueryBlock(7, WithMinTargets(0))
+	assert.NotNil(t, err, "expected an error for an invalid minimum number of targets")
+}
+
+func TestQueryInfo(t *testing.T) {
+	info := &common.BlockchainInfo{Height: 8, CurrentBlockHash: []byte("current"), PreviousBlockHash: []byte("previous")}
+	peer := newFixturePeer(t, "grpcs://peer1.example.com:7051", info)
+	client := setupLedgerClient(t, peer)
+
+	resp, err := client.QueryInfo()
+	assert.Nil(t, err, "QueryInfo failed")
+	assert.True(t, proto.Equal(info, resp.BCI), "expected the blockchain info of the endorser")
+	assert.Equal(t, peer.URL(), resp.Endorser)
+}
+
+func TestQueryTransaction(t *testing.T) {
+	tx := &pb.ProcessedTransaction{TransactionEnvelope: &common.Envelope{Payload: []byte("payload")}, ValidationCode: int32(pb.TxValidationCode_VALID)}
+	divergentTx := &pb.ProcessedTransaction{TransactionEnvelope: &common.Envelope{Payload: []byte("payload")}, ValidationCode: int32(pb.TxValidationCode_MVCC_READ_CONFLICT)}
+	peer1 := newFixturePeer(t, "grpcs://peer1.example.com:7051", tx)
+	peer2 := newFixturePeer(t, "grpcs://peer2.example.com:7051", divergentTx)
+	client := setupLedgerClient(t, peer1, peer2)
+
+	resp, err := client.QueryTransaction("txid", WithTargets(peer1))
+	assert.Nil(t, err, "QueryTransaction failed")
+	assert.True(t, proto.Equal(tx, resp), "expected the processed transaction of the endorser")
+
+	_, err = client.QueryTransaction("txid", WithMinTargets(2))
+	_, ok := errors.Cause(err).(*MismatchError)
+	assert.True(t, ok, "expected MismatchError, got %v", err)
+
+	_, err = client.QueryTransaction("")
+	assert.NotNil(t, err, "expected an error without a transaction ID")
+}
+
+func TestQueryConfig(t *testing.T) {
+	blockBytes, err := ioutil.ReadFile(fixtureConfigBlock)
+	if err != nil {
+		t.Fatalf("Failed to read fixture config block: %s", err)
+	}
+	peer1 := fcmocks.NewMockPeer("grpcs://peer1.example.com:7051", "grpcs://peer1.example.com:7051")
+	peer1.Payload = blockBytes
+	peer2 := newFixturePeer(t, "grpcs://peer2.example.com:7051", testBlock)
+	client := setupLedgerClient(t, peer1, peer2)
+
+	cfg, err := client.QueryConfig(WithTargets(peer1))
+	if err != nil {
+		t.Fatalf("QueryConfig failed: %s", err)
+	}
+	assert.Equal(t, testChannel, cfg.Name())
+	assert.Equal(t, []string{"OrdererMSP", "Org1MSP", "Org2MSP"}, cfg.MSPIDs())
+	assert.Equal(t, []string{"orderer.example.com:7050"}, cfg.Orderers())
+	assert.Equal(t, uint32(10), cfg.BatchSize().MaxMessageCount)
+	assert.NotNil(t, cfg.ConfigEnvelope(), "expected the raw config envelope")
+
+	_, err = client.QueryConfig(WithTargets(peer2))
+	assert.NotNil(t, err, "expected an error if the target doesn't return a config block")
+}
+
+func newFixturePeer(t *testing.T, url string, fixture proto.Message) *fcmocks.MockPeer {
+	payload, err := proto.Marshal(fixture)
+	if err != nil {
+		t.Fatalf("Failed to marshal fixture: %s", err)
+	}
+	peer := fcmocks.NewMockPeer(url, url)
+	peer.Payload = payload
+	return peer
+}
+
+func setupLedgerClient(t *testing.T, peers ...fab.Peer) *Client {
+	ctx := fcmocks.NewMockContext(fcmocks.NewMockUser("test"))
+
+	chLedger, err := channel.NewLedger(ctx, testChannel)
+	if err != nil {
+		t.Fatalf("Failed to create ledger: %s", err)
+	}
+	chProvider, err := fcmocks.NewMockChannelProvider(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create channel provider: %s", err)
+	}
+	chProvider.SetLedger(chLedger)
+	chService, err := chProvider.ChannelService(ctx, testChannel)
+	if err != nil {
+		t.Fatalf("Failed to create channel service: %s", err)
+	}
+
+	discoveryProvider, err := txnmocks.NewMockDiscoveryProvider(nil, peers)
+	if err != nil {
+		t.Fatalf("Failed to create discovery provider: %s", err)
+	}
+	discoveryService, err := discoveryProvider.NewDiscoveryService(testChannel)
+	if err != nil {
+		t.Fatalf("Failed to create discovery service: %s", err)
+	}
+
+	client, err := New(Context{ChannelID: testChannel, DiscoveryService: discoveryService, ChannelService: chService})
+	if err != nil {
+		t.Fatalf("Failed to create ledger client: %s", err)
+	}
+	return client
+}
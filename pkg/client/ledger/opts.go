@@ -0,0 +1,54 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ledger
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+)
+
+//WithTargets encapsulates fab.Peer targets to ledger RequestOption
+func WithTargets(targets ...fab.Peer) RequestOption {
+	return func(opts *Opts) error {
+		opts.Targets = targets
+		return nil
+	}
+}
+
+//WithTargetFilter encapsulates fab.TargetFilter to ledger RequestOption. Only the targets (the given
+//targets or the peers of the channel) that are accepted by the filter are queried.
+func WithTargetFilter(targetFilter fab.TargetFilter) RequestOption {
+	return func(opts *Opts) error {
+		opts.TargetFilter = targetFilter
+		return nil
+	}
+}
+
+//WithMinTargets sets the number of targets that must respond to a query. The responses of all the
+//targets that respond must match, otherwise a *MismatchError is returned.
+func WithMinTargets(minTargets int) RequestOption {
+	return func(opts *Opts) error {
+		if minTargets < 1 {
+			return errors.New("minimum number of targets must be at least 1")
+		}
+		opts.MinTargets = minTargets
+		return nil
+	}
+}
+
+//WithMaxTargets sets the number of targets that are queried, which may be greater than the number of
+//targets that must respond (see WithMinTargets) so that the query succeeds if some of them fail
+func WithMaxTargets(maxTargets int) RequestOption {
+	return func(opts *Opts) error {
+		if maxTargets < 1 {
+			return errors.New("maximum number of targets must be at least 1")
+		}
+		opts.MaxTargets = maxTargets
+		return nil
+	}
+}
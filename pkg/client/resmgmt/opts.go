@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/errors/retry"
 )
 
 //WithTargets encapsulates fab.Peer targets to resmgmtclient RequestOption
@@ -36,10 +37,38 @@ func WithTimeout(timeout time.Duration) RequestOption {
 	}
 }
 
-//WithOrdererID encapsulates OrdererID to RequestOption
+//WithRetry sets the retry options of JoinChannel. The genesis block retrieval and the join of each
+//target are retried on transient errors (see retry.DefaultRetryableCodes). There are no retries by default.
+func WithRetry(retryOpt retry.Opts) RequestOption {
+	return func(opts *Opts) error {
+		opts.Retry = retryOpt
+		return nil
+	}
+}
+
+//WithAlreadyJoinedOK reports the targets of JoinChannel that have already joined the channel as joined
+//instead of failed
+func WithAlreadyJoinedOK() RequestOption {
+	return func(opts *Opts) error {
+		opts.AlreadyJoinedOK = true
+		return nil
+	}
+}
+
+//WithOrdererID encapsulates OrdererID to RequestOption (the orderer of SaveChannel and JoinChannel)
 func WithOrdererID(ordererID string) RequestOption {
 	return func(opts *Opts) error {
 		opts.OrdererID = ordererID
 		return nil
 	}
 }
+
+//WithProbeBackoff sets the initial and maximum delay between the probes sent by WarmChaincode.
+//The delay doubles after each failed probe.
+func WithProbeBackoff(initial, max time.Duration) RequestOption {
+	return func(opts *Opts) error {
+		opts.ProbeBackoffInitial = initial
+		opts.ProbeBackoffMax = max
+		return nil
+	}
+}
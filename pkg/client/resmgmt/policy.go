@@ -0,0 +1,307 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package resmgmt
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+	mb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/msp"
+)
+
+// PrincipalClassification indicates how a policy principal identifies a signer
+type PrincipalClassification string
+
+const (
+	// RolePrincipal identifies signers by their MSP role (member, admin, client, peer, orderer)
+	RolePrincipal PrincipalClassification = "role"
+	// OUPrincipal identifies signers by their organizational unit
+	OUPrincipal PrincipalClassification = "ou"
+	// IdentityPrincipal identifies a single signer by its identity
+	IdentityPrincipal PrincipalClassification = "identity"
+)
+
+// Principal is a signer that is referenced by a signature policy
+type Principal struct {
+	Classification PrincipalClassification
+	MSPID          string
+	// Role is one of member, admin, client, peer or orderer (role principals only)
+	Role string
+	// OU is the organizational unit identifier (OU principals only)
+	OU string
+}
+
+// String returns the principal in the form MSPID.role, MSPID.ou(OU) or MSPID.identity
+func (p *Principal) String() string {
+	switch p.Classification {
+	case RolePrincipal:
+		return p.MSPID + "." + p.Role
+	case OUPrincipal:
+		return fmt.Sprintf("%s.ou(%s)", p.MSPID, p.OU)
+	default:
+		return p.MSPID + "." + string(p.Classification)
+	}
+}
+
+// PolicyNode is a node in a parsed signature policy. A node is either a rule that
+// requires N of its children to be satisfied, or a leaf that requires the signature
+// of a principal.
+type PolicyNode struct {
+	// N is the number of children that must be satisfied (rules only)
+	N         int
+	Children  []*PolicyNode
+	Principal *Principal
+}
+
+// String returns a human-readable rendering of the policy, for example
+// AND(Org1MSP.peer, OR(Org2MSP.peer, Org3MSP.peer)). A rule that requires all
+// of its children is rendered as AND, a rule that requires one child is rendered
+// as OR and any other rule is rendered as OutOf(N, ...). A rule that requires its
+// only child is rendered as the child.
+func (n *PolicyNode) String() string {
+	if n.Principal != nil {
+		return n.Principal.String()
+	}
+	if n.N == 1 && len(n.Children) == 1 {
+		return n.Children[0].String()
+	}
+
+	children := make([]string, len(n.Children))
+	for i, child := range n.Children {
+		children[i] = child.String()
+	}
+	list := strings.Join(children, ", ")
+
+	switch {
+	case n.N == len(n.Children):
+		return "AND(" + list + ")"
+	case n.N == 1:
+		return "OR(" + list + ")"
+	default:
+		return fmt.Sprintf("OutOf(%d, %s)", n.N, list)
+	}
+}
+
+// SignaturePolicy contains a signature policy in both raw and parsed form
+type SignaturePolicy struct {
+	Envelope *common.SignaturePolicyEnvelope
+	Root     *PolicyNode
+}
+
+// String returns a human-readable rendering of the policy
+func (p *SignaturePolicy) String() string {
+	return p.Root.String()
+}
+
+// CollectionConfig contains the configuration of a private data collection
+type CollectionConfig struct {
+	Name              string
+	RequiredPeerCount int32
+	MaximumPeerCount  int32
+	BlockToLive       uint64
+	MemberOrgsPolicy  *SignaturePolicy
+}
+
+// ParseSignaturePolicy parses the given signature policy envelope
+func ParseSignaturePolicy(envelope *common.SignaturePolicyEnvelope) (*SignaturePolicy, error) {
+	if envelope == nil || envelope.Rule == nil {
+		return nil, errors.New("signature policy is empty")
+	}
+
+	principals := make([]*Principal, len(envelope.Identities))
+	for i, identity := range envelope.Identities {
+		p, err := parsePrincipal(identity)
+		if err != nil {
+			return nil, errors.WithMessage(err, fmt.Sprintf("invalid principal at index %d", i))
+		}
+		principals[i] = p
+	}
+
+	root, err := parsePolicyRule(envelope.Rule, principals)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SignaturePolicy{Envelope: envelope, Root: root}, nil
+}
+
+func parsePolicyRule(rule *common.SignaturePolicy, principals []*Principal) (*PolicyNode, error) {
+	switch t := rule.Type.(type) {
+	case *common.SignaturePolicy_SignedBy:
+		if t.SignedBy < 0 || int(t.SignedBy) >= len(principals) {
+			return nil, errors.Errorf("signed-by index %d is out of range", t.SignedBy)
+		}
+		return &PolicyNode{Principal: principals[t.SignedBy]}, nil
+	case *common.SignaturePolicy_NOutOf_:
+		if t.NOutOf == nil {
+			return nil, errors.New("n-out-of rule is empty")
+		}
+		node := &PolicyNode{N: int(t.NOutOf.N)}
+		for _, r := range t.NOutOf.Rules {
+			child, err := parsePolicyRule(r, principals)
+			if err != nil {
+				return nil, err
+			}
+			node.Children = append(node.Children, child)
+		}
+		return node, nil
+	default:
+		return nil, errors.Errorf("unsupported signature policy type: %T", rule.Type)
+	}
+}
+
+func parsePrincipal(principal *mb.MSPPrincipal) (*Principal, error) {
+	switch principal.PrincipalClassification {
+	case mb.MSPPrincipal_ROLE:
+		role := &mb.MSPRole{}
+		if err := proto.Unmarshal(principal.Principal, role); err != nil {
+			return nil, errors.Wrap(err, "unmarshal of MSP role failed")
+		}
+		return &Principal{Classification: RolePrincipal, MSPID: role.MspIdentifier, Role: strings.ToLower(role.Role.String())}, nil
+	case mb.MSPPrincipal_ORGANIZATION_UNIT:
+		ou := &mb.OrganizationUnit{}
+		if err := proto.Unmarshal(principal.Principal, ou); err != nil {
+			return nil, errors.Wrap(err, "unmarshal of organization unit failed")
+		}
+		return &Principal{Classification: OUPrincipal, MSPID: ou.MspIdentifier, OU: ou.OrganizationalUnitIdentifier}, nil
+	case mb.MSPPrincipal_IDENTITY:
+		identity := &mb.SerializedIdentity{}
+		if err := proto.Unmarshal(principal.Principal, identity); err != nil {
+			return nil, errors.Wrap(err, "unmarshal of serialized identity failed")
+		}
+		return &Principal{Classification: IdentityPrincipal, MSPID: identity.Mspid}, nil
+	default:
+		return nil, errors.Errorf("unsupported principal classification: %s", principal.PrincipalClassification)
+	}
+}
+
+// GetEndorsementPolicy returns the endorsement policy of the given chaincode on the given channel,
+// both as the raw SignaturePolicyEnvelope and in parsed form.
+// Valid options: WithTargets, WithTargetFilter
+func (rc *Client) GetEndorsementPolicy(channelID string, ccID string, options ...RequestOption) (*SignaturePolicy, error) {
+	ledger, targets, err := rc.prepareChaincodeQuery(channelID, ccID, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	policies, err := ledger.QueryChaincodePolicy(ccID, targets)
+	if len(policies) == 0 {
+		if err == nil {
+			err = errors.New("no responses from targets")
+		}
+		return nil, errors.WithMessage(err, "query for chaincode policy failed")
+	}
+
+	for _, p := range policies[1:] {
+		if !proto.Equal(policies[0], p) {
+			return nil, errors.New("chaincode policies from targets do not match")
+		}
+	}
+
+	return ParseSignaturePolicy(policies[0])
+}
+
+// GetCollectionsConfig returns the private data collections configuration of the given chaincode
+// on the given channel. The member orgs policy of each collection is returned in parsed form.
+// Valid options: WithTargets, WithTargetFilter
+func (rc *Client) GetCollectionsConfig(channelID string, ccID string, options ...RequestOption) ([]*CollectionConfig, error) {
+	ledger, targets, err := rc.prepareChaincodeQuery(channelID, ccID, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	packages, err := ledger.QueryCollectionsConfig(ccID, targets)
+	if len(packages) == 0 {
+		if err == nil {
+			err = errors.New("no responses from targets")
+		}
+		return nil, errors.WithMessage(err, "query for collections config failed")
+	}
+
+	for _, p := range packages[1:] {
+		if !proto.Equal(packages[0], p) {
+			return nil, errors.New("collections configs from targets do not match")
+		}
+	}
+
+	var configs []*CollectionConfig
+	for _, c := range packages[0].Config {
+		static := c.GetStaticCollectionConfig()
+		if static == nil {
+			return nil, errors.Errorf("unsupported collection config type: %T", c.Payload)
+		}
+
+		config := &CollectionConfig{
+			Name:              static.Name,
+			RequiredPeerCount: static.RequiredPeerCount,
+			MaximumPeerCount:  static.MaximumPeerCount,
+			BlockToLive:       static.BlockToLive,
+		}
+		if envelope := static.MemberOrgsPolicy.GetSignaturePolicy(); envelope != nil {
+			policy, err := ParseSignaturePolicy(envelope)
+			if err != nil {
+				return nil, errors.WithMessage(err, fmt.Sprintf("invalid member orgs policy for collection [%s]", static.Name))
+			}
+			config.MemberOrgsPolicy = policy
+		}
+		configs = append(configs, config)
+	}
+
+	return configs, nil
+}
+
+func (rc *Client) prepareChaincodeQuery(channelID string, ccID string, options ...RequestOption) (fab.ChannelLedger, []fab.ProposalProcessor, error) {
+	if channelID == "" || ccID == "" {
+		return nil, nil, errors.New("must provide channel ID and chaincode ID")
+	}
+
+	opts, err := rc.prepareResmgmtOpts(options...)
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "failed to get opts for chaincode query")
+	}
+
+	discovery, err := rc.discoveryProvider.NewDiscoveryService(channelID)
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "failed to create channel discovery service")
+	}
+
+	if len(opts.Targets) == 0 {
+		opts.Targets, err = rc.getDefaultTargets(discovery)
+		if err != nil {
+			return nil, nil, errors.WithMessage(err, "failed to get default targets for chaincode query")
+		}
+	}
+
+	targets, err := rc.calculateTargets(discovery, opts.Targets, opts.TargetFilter)
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "failed to determine target peers for chaincode query")
+	}
+
+	if len(targets) == 0 {
+		return nil, nil, errors.New("No targets available for chaincode query")
+	}
+
+	channelService, err := rc.channelProvider.ChannelService(rc.identity, channelID)
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "Unable to get channel service")
+	}
+
+	ledger, err := channelService.Ledger()
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "get channel ledger failed")
+	}
+	if ledger == nil {
+		return nil, nil, errors.New("channel ledger is not available")
+	}
+
+	return ledger, peersToTxnProcessors(targets), nil
+}
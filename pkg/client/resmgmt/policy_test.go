@@ -0,0 +1,213 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package resmgmt
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/channel"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/common/cauthdsl"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/core/common/ccprovider"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+	mb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/msp"
+)
+
+var policyFixtures = []struct {
+	policy   string
+	expected string
+}{
+	{"AND('Org1MSP.peer', OR('Org2MSP.peer', 'Org3MSP.peer'))", "AND(Org1MSP.peer, OR(Org2MSP.peer, Org3MSP.peer))"},
+	{"OR('Org1MSP.member', 'Org2MSP.member')", "OR(Org1MSP.member, Org2MSP.member)"},
+	{"OutOf(2, 'Org1MSP.member', 'Org2MSP.admin', 'Org3MSP.client')", "OutOf(2, Org1MSP.member, Org2MSP.admin, Org3MSP.client)"},
+	{"OR(AND('Org1MSP.admin', 'Org2MSP.admin'), OutOf(2, 'Org1MSP.peer', 'Org2MSP.peer', 'Org3MSP.peer'))", "OR(AND(Org1MSP.admin, Org2MSP.admin), OutOf(2, Org1MSP.peer, Org2MSP.peer, Org3MSP.peer))"},
+	{"AND('Org1MSP.member')", "Org1MSP.member"},
+	{"OutOf(1, 'Org1MSP.member', OutOf(3, 'Org2MSP.peer', 'Org3MSP.peer'))", "OR(Org1MSP.member, OutOf(3, Org2MSP.peer, Org3MSP.peer))"},
+}
+
+func TestParseSignaturePolicy(t *testing.T) {
+	for _, f := range policyFixtures {
+		envelope, err := cauthdsl.FromString(f.policy)
+		if err != nil {
+			t.Fatalf("error creating policy from [%s]: %s", f.policy, err)
+		}
+
+		policy, err := ParseSignaturePolicy(envelope)
+		if err != nil {
+			t.Fatalf("error parsing policy [%s]: %s", f.policy, err)
+		}
+		if policy.Envelope != envelope {
+			t.Fatalf("expecting raw envelope to be returned with parsed policy")
+		}
+		if policy.String() != f.expected {
+			t.Fatalf("expecting policy [%s] to be rendered as [%s] but got [%s]", f.policy, f.expected, policy.String())
+		}
+	}
+}
+
+func TestParseSignaturePolicyPrincipals(t *testing.T) {
+	policy, err := ParseSignaturePolicy(cauthdsl.SignedByMspAdmin("Org1MSP"))
+	if err != nil {
+		t.Fatalf("error parsing policy: %s", err)
+	}
+	if len(policy.Root.Children) != 1 {
+		t.Fatalf("expecting policy with one rule but got %d", len(policy.Root.Children))
+	}
+	p := policy.Root.Children[0].Principal
+	if p == nil || p.Classification != RolePrincipal || p.MSPID != "Org1MSP" || p.Role != "admin" {
+		t.Fatalf("expecting admin role principal for Org1MSP but got %+v", p)
+	}
+
+	ouBytes, err := proto.Marshal(&mb.OrganizationUnit{MspIdentifier: "Org2MSP", OrganizationalUnitIdentifier: "finance"})
+	if err != nil {
+		t.Fatalf("error marshalling organization unit: %s", err)
+	}
+	idBytes, err := proto.Marshal(&mb.SerializedIdentity{Mspid: "Org3MSP", IdBytes: []byte("cert")})
+	if err != nil {
+		t.Fatalf("error marshalling identity: %s", err)
+	}
+
+	envelope := &common.SignaturePolicyEnvelope{
+		Rule: cauthdsl.Or(cauthdsl.SignedBy(0), cauthdsl.SignedBy(1)),
+		Identities: []*mb.MSPPrincipal{
+			{PrincipalClassification: mb.MSPPrincipal_ORGANIZATION_UNIT, Principal: ouBytes},
+			{PrincipalClassification: mb.MSPPrincipal_IDENTITY, Principal: idBytes},
+		},
+	}
+	policy, err = ParseSignaturePolicy(envelope)
+	if err != nil {
+		t.Fatalf("error parsing policy: %s", err)
+	}
+	if expected := "OR(Org2MSP.ou(finance), Org3MSP.identity)"; policy.String() != expected {
+		t.Fatalf("expecting policy to be rendered as [%s] but got [%s]", expected, policy.String())
+	}
+
+	// Signed by index out of range
+	envelope.Rule = cauthdsl.SignedBy(2)
+	if _, err := ParseSignaturePolicy(envelope); err == nil {
+		t.Fatalf("expecting error parsing policy with invalid principal index")
+	}
+
+	if _, err := ParseSignaturePolicy(nil); err == nil {
+		t.Fatalf("expecting error parsing nil policy")
+	}
+}
+
+func TestGetEndorsementPolicy(t *testing.T) {
+	rc := setupDefaultResMgmtClient(t)
+
+	envelope, err := cauthdsl.FromString(policyFixtures[0].policy)
+	if err != nil {
+		t.Fatalf("error creating policy: %s", err)
+	}
+	policyBytes, err := proto.Marshal(envelope)
+	if err != nil {
+		t.Fatalf("error marshalling policy: %s", err)
+	}
+	ccData, err := proto.Marshal(&ccprovider.ChaincodeData{Name: "mycc", Version: "v1", Policy: policyBytes})
+	if err != nil {
+		t.Fatalf("error marshalling chaincode data: %s", err)
+	}
+
+	peer1 := &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockMSP: "Org1MSP", Status: 200, Payload: ccData}
+
+	// Ledger not available
+	if _, err := rc.GetEndorsementPolicy("mychannel", "mycc", WithTargets(peer1)); err == nil {
+		t.Fatalf("expecting error when channel ledger is not available")
+	}
+
+	setupTestLedger(t, rc)
+
+	if _, err := rc.GetEndorsementPolicy("", "mycc", WithTargets(peer1)); err == nil {
+		t.Fatalf("expecting error when channel ID is not provided")
+	}
+
+	policy, err := rc.GetEndorsementPolicy("mychannel", "mycc", WithTargets(peer1))
+	if err != nil {
+		t.Fatalf("error getting endorsement policy: %s", err)
+	}
+	if !proto.Equal(policy.Envelope, envelope) {
+		t.Fatalf("expecting raw policy to match instantiated policy")
+	}
+	if policy.String() != policyFixtures[0].expected {
+		t.Fatalf("expecting policy to be rendered as [%s] but got [%s]", policyFixtures[0].expected, policy.String())
+	}
+
+	// Targets return different policies
+	otherData, err := proto.Marshal(&ccprovider.ChaincodeData{Name: "mycc", Version: "v1"})
+	if err != nil {
+		t.Fatalf("error marshalling chaincode data: %s", err)
+	}
+	peer2 := &fcmocks.MockPeer{MockName: "Peer2", MockURL: "http://peer2.com", MockMSP: "Org1MSP", Status: 200, Payload: otherData}
+	if _, err := rc.GetEndorsementPolicy("mychannel", "mycc", WithTargets(peer1, peer2)); err == nil {
+		t.Fatalf("expecting error when policies from targets do not match")
+	}
+
+	// Target returns bad status
+	peer3 := &fcmocks.MockPeer{MockName: "Peer3", MockURL: "http://peer3.com", MockMSP: "Org1MSP", Status: 500}
+	if _, err := rc.GetEndorsementPolicy("mychannel", "mycc", WithTargets(peer3)); err == nil {
+		t.Fatalf("expecting error when query fails")
+	}
+}
+
+func TestGetCollectionsConfig(t *testing.T) {
+	rc := setupDefaultResMgmtClient(t)
+	setupTestLedger(t, rc)
+
+	memberPolicy, err := cauthdsl.FromString(policyFixtures[1].policy)
+	if err != nil {
+		t.Fatalf("error creating policy: %s", err)
+	}
+	collConfig, err := proto.Marshal(&common.CollectionConfigPackage{
+		Config: []*common.CollectionConfig{
+			{
+				Payload: &common.CollectionConfig_StaticCollectionConfig{
+					StaticCollectionConfig: &common.StaticCollectionConfig{
+						Name: "collection1",
+						MemberOrgsPolicy: &common.CollectionPolicyConfig{
+							Payload: &common.CollectionPolicyConfig_SignaturePolicy{SignaturePolicy: memberPolicy},
+						},
+						RequiredPeerCount: 1,
+						MaximumPeerCount:  2,
+						BlockToLive:       100,
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("error marshalling collections config: %s", err)
+	}
+
+	peer1 := &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockMSP: "Org1MSP", Status: 200, Payload: collConfig}
+
+	configs, err := rc.GetCollectionsConfig("mychannel", "mycc", WithTargets(peer1))
+	if err != nil {
+		t.Fatalf("error getting collections config: %s", err)
+	}
+	if len(configs) != 1 {
+		t.Fatalf("expecting 1 collection config but got %d", len(configs))
+	}
+
+	c := configs[0]
+	if c.Name != "collection1" || c.RequiredPeerCount != 1 || c.MaximumPeerCount != 2 || c.BlockToLive != 100 {
+		t.Fatalf("unexpected collection config: %+v", c)
+	}
+	if c.MemberOrgsPolicy == nil || c.MemberOrgsPolicy.String() != policyFixtures[1].expected {
+		t.Fatalf("expecting member orgs policy to be rendered as [%s] but got [%v]", policyFixtures[1].expected, c.MemberOrgsPolicy)
+	}
+}
+
+func setupTestLedger(t *testing.T, rc *Client) {
+	ledger, err := channel.NewLedger(setupTestContext("test", "Org1MSP"), "mychannel")
+	if err != nil {
+		t.Fatalf("error creating channel ledger: %s", err)
+	}
+	rc.channelProvider.(*fcmocks.MockChannelProvider).SetLedger(ledger)
+}
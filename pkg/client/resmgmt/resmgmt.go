@@ -8,18 +8,27 @@ SPDX-License-Identifier: Apache-2.0
 package resmgmt
 
 import (
+	"fmt"
+	"io"
 	"io/ioutil"
 	"time"
 
+	"github.com/golang/protobuf/proto"
+
+	"github.com/hyperledger/fabric-sdk-go/internal/github.com/hyperledger/fabric/common/channelconfig"
 	config "github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
 	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
 
 	"github.com/hyperledger/fabric-sdk-go/pkg/context"
 	"github.com/hyperledger/fabric-sdk-go/pkg/errors/multi"
+	"github.com/hyperledger/fabric-sdk-go/pkg/errors/retry"
+	"github.com/hyperledger/fabric-sdk-go/pkg/errors/status"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/ccpackager"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/channel"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/orderer"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/peer"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/policy"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/resource"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/resource/api"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/txn"
@@ -27,6 +36,7 @@ import (
 	"github.com/hyperledger/fabric-sdk-go/pkg/logging"
 	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
 	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
 )
 
 // TargetFilter allows for filtering target peers
@@ -41,6 +51,9 @@ type InstallCCRequest struct {
 	Path    string
 	Version string
 	Package *api.CCPackage
+	// DeploymentPackage is a package created by ccpackager (a chaincode deployment spec or a signed package)
+	// that is installed as is instead of Package. The name and version are read from the package.
+	DeploymentPackage []byte
 }
 
 // InstallCCResponse contains install chaincode response status
@@ -48,44 +61,99 @@ type InstallCCResponse struct {
 	Target string
 	Status int32
 	Info   string
+	Err    error // error installing the chaincode on the target, if the install failed
 }
 
 // InstantiateCCRequest contains instantiate chaincode request parameters
 type InstantiateCCRequest struct {
-	Name       string
-	Path       string
-	Version    string
-	Args       [][]byte
-	Policy     *common.SignaturePolicyEnvelope
-	CollConfig []*common.CollectionConfig
+	Name         string
+	Path         string
+	Version      string
+	Args         [][]byte
+	Policy       *common.SignaturePolicyEnvelope
+	PolicyString string // endorsement policy in string form (see policy.FromString), used if Policy isn't provided
+	CollConfig   []*common.CollectionConfig
+}
+
+// InstantiateCCResponse contains the response of instantiate chaincode
+type InstantiateCCResponse struct {
+	TransactionID    fab.TransactionID
+	TxValidationCode pb.TxValidationCode
+	Responses        []*fab.TransactionProposalResponse // endorsement response of each target
+}
+
+// UpgradeCCResponse contains the response of upgrade chaincode
+type UpgradeCCResponse struct {
+	TransactionID    fab.TransactionID
+	TxValidationCode pb.TxValidationCode
+	Responses        []*fab.TransactionProposalResponse // endorsement response of each target
 }
 
 // UpgradeCCRequest contains upgrade chaincode request parameters
 type UpgradeCCRequest struct {
-	Name       string
-	Path       string
-	Version    string
-	Args       [][]byte
-	Policy     *common.SignaturePolicyEnvelope
-	CollConfig []*common.CollectionConfig
+	Name         string
+	Path         string
+	Version      string
+	Args         [][]byte
+	Policy       *common.SignaturePolicyEnvelope
+	PolicyString string // endorsement policy in string form (see policy.FromString), used if Policy isn't provided
+	CollConfig   []*common.CollectionConfig
+}
+
+// ChaincodeInfo contains the details of a chaincode that is installed on a peer or instantiated on a channel
+type ChaincodeInfo struct {
+	Name    string
+	Version string
+	Path    string
+	ID      []byte
+}
+
+// AccessDeniedError is returned if a peer rejects a query because the identity of the client
+// isn't authorized to make it
+type AccessDeniedError struct {
+	Target string
+	Err    error
+}
+
+func (e *AccessDeniedError) Error() string {
+	return fmt.Sprintf("access denied by %s, the query requires an admin identity: %s", e.Target, e.Err)
 }
 
 //Opts contains options for operations performed by ResourceMgmtClient
 type Opts struct {
-	Targets      []fab.Peer    // target peers
-	TargetFilter TargetFilter  // target filter
-	Timeout      time.Duration //timeout options for instantiate and upgrade CC
-	OrdererID    string        // use specific orderer
+	Targets         []fab.Peer    // target peers
+	TargetFilter    TargetFilter  // target filter
+	Timeout         time.Duration //timeout options for join channel, install, instantiate and upgrade CC
+	OrdererID       string        // use specific orderer
+	Retry           retry.Opts    // retry options for join channel
+	AlreadyJoinedOK bool          // peers that have already joined the channel are reported as joined
+
+	ProbeBackoffInitial time.Duration // initial delay between warm chaincode probes
+	ProbeBackoffMax     time.Duration // maximum delay between warm chaincode probes
+}
+
+// JoinChannelResponse contains the result of joining a target peer to the channel
+type JoinChannelResponse struct {
+	Target        string
+	AlreadyJoined bool  // the target had already joined the channel (see WithAlreadyJoinedOK)
+	Err           error // error joining the target to the channel, if the join failed
 }
 
 //SaveChannelRequest used to save channel request
 type SaveChannelRequest struct {
 	// Channel Name (ID)
 	ChannelID string
-	// Path to channel configuration file
-	ChannelConfig string
-	// User that signs channel configuration
-	SigningIdentity context.IdentityContext
+	// Path to channel configuration file (configtx), used if ChannelConfig isn't provided
+	ChannelConfigPath string
+	// Channel configuration (configtx)
+	ChannelConfig io.Reader
+	// Users that sign channel configuration (default is the client's user)
+	SigningIdentities []context.IdentityContext
+}
+
+//SaveChannelResponse contains the response of save channel request
+type SaveChannelResponse struct {
+	TransactionID fab.TransactionID
 }
 
 //RequestOption func for each Opts argument
@@ -177,58 +245,231 @@ func New(ctx Context, opts ...ClientOption) (*Client, error) {
 	return resourceClient, nil
 }
 
-// JoinChannel allows for peers to join existing channel with optional custom options (specific peers, filtered peers)
-func (rc *Client) JoinChannel(channelID string, options ...RequestOption) error {
+// JoinChannel allows for peers to join existing channel with optional custom options (specific peers, filtered peers,
+// orderer, timeout, retry). The genesis block of the channel is retrieved from the orderer and each of the targets
+// joins the channel with it. The result of the join of each target is returned, as well as an error if any of the
+// targets failed to join.
+func (rc *Client) JoinChannel(channelID string, options ...RequestOption) ([]JoinChannelResponse, error) {
 
 	if channelID == "" {
-		return errors.New("must provide channel ID")
+		return nil, errors.New("must provide channel ID")
 	}
 
 	opts, err := rc.prepareResmgmtOpts(options...)
 	if err != nil {
-		return errors.WithMessage(err, "failed to get opts for JoinChannel")
+		return nil, errors.WithMessage(err, "failed to get opts for JoinChannel")
 	}
 
 	targets, err := rc.calculateTargets(rc.discovery, opts.Targets, opts.TargetFilter)
 	if err != nil {
-		return errors.WithMessage(err, "failed to determine target peers for JoinChannel")
+		return nil, errors.WithMessage(err, "failed to determine target peers for JoinChannel")
 	}
 
 	if len(targets) == 0 {
-		return errors.New("No targets available")
+		return nil, errors.New("No targets available")
+	}
+
+	orderer, err := rc.joinChannelOrderer(channelID, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// The timeout covers the retrieval of the genesis block as well as the join of the targets. Once it
+	// expires, done is closed so that the requests aren't retried anymore.
+	var done chan struct{}
+	if opts.Timeout > 0 {
+		done = make(chan struct{})
+		timer := time.AfterFunc(opts.Timeout, func() { close(done) })
+		defer timer.Stop()
+	}
+
+	genesisBlock, err := rc.genesisBlock(channelID, orderer, opts, done)
+	if err != nil {
+		return nil, errors.WithMessage(err, "genesis block retrieval failed")
+	}
+
+	responses := rc.joinChannel(channelID, genesisBlock, targets, opts, done)
+
+	var errs multi.Errors
+	for _, response := range responses {
+		if response.Err != nil {
+			errs = append(errs, errors.WithMessage(response.Err, "From target: "+response.Target))
+		}
+	}
+	if len(errs) > 0 {
+		return responses, errors.WithMessage(errs, "join channel failed")
+	}
+
+	return responses, nil
+}
+
+// joinChannelOrderer returns the orderer to retrieve the genesis block of the channel from: the orderer
+// of the options or the first orderer of the channel
+func (rc *Client) joinChannelOrderer(channelID string, opts Opts) (fab.Orderer, error) {
+	if opts.OrdererID != "" {
+		ordererCfg, err := rc.provider.Config().OrdererConfig(opts.OrdererID)
+		if err != nil || ordererCfg == nil {
+			return nil, errors.Errorf("failed to retrieve orderer config: %s", err)
+		}
+		orderer, err := rc.fabricProvider.CreateOrdererFromConfig(ordererCfg)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to create orderer from config")
+		}
+		return orderer, nil
 	}
 
 	// TODO: should the code to get orderers from sdk config be part of channel service?
 	oConfig, err := rc.provider.Config().ChannelOrderers(channelID)
 	if err != nil {
-		return errors.WithMessage(err, "failed to load orderer config")
+		return nil, errors.WithMessage(err, "failed to load orderer config")
 	}
 	if len(oConfig) == 0 {
-		return errors.Errorf("no orderers are configured for channel %s", channelID)
+		return nil, errors.Errorf("no orderers are configured for channel %s", channelID)
 	}
 
 	// TODO: handle more than the first orderer.
 	orderer, err := rc.fabricProvider.CreateOrdererFromConfig(&oConfig[0])
 	if err != nil {
-		return errors.WithMessage(err, "failed to create orderers from config")
+		return nil, errors.WithMessage(err, "failed to create orderers from config")
 	}
+	return orderer, nil
+}
 
-	genesisBlock, err := rc.resource.GenesisBlockFromOrderer(channelID, orderer)
-	if err != nil {
-		return errors.WithMessage(err, "genesis block retrieval failed")
+// genesisBlock retrieves the genesis block of the channel from the orderer, failing if done is closed first
+func (rc *Client) genesisBlock(channelID string, orderer fab.Orderer, opts Opts, done <-chan struct{}) (*common.Block, error) {
+	type result struct {
+		block *common.Block
+		err   error
 	}
 
-	joinChannelRequest := api.JoinChannelRequest{
-		Targets:      peersToTxnProcessors(targets),
+	resultCh := make(chan result, 1)
+	go func() {
+		block, err := rc.retrieveGenesisBlock(channelID, orderer, opts, done)
+		resultCh <- result{block: block, err: err}
+	}()
+
+	select {
+	case r := <-resultCh:
+		return r.block, r.err
+	case <-done:
+		return nil, errors.Errorf("genesis block retrieval timed out after %s", opts.Timeout)
+	}
+}
+
+// retrieveGenesisBlock retrieves the genesis block of the channel from the orderer, retrying according to the
+// options until done is closed
+func (rc *Client) retrieveGenesisBlock(channelID string, orderer fab.Orderer, opts Opts, done <-chan struct{}) (*common.Block, error) {
+	retryHandler := retry.New(opts.Retry)
+	for {
+		genesisBlock, err := rc.resource.GenesisBlockFromOrderer(channelID, orderer)
+		if err == nil {
+			return genesisBlock, nil
+		}
+		if !retryHandler.Required(err) {
+			return nil, err
+		}
+		if isClosed(done) {
+			logger.Debugf("Not retrying genesis block retrieval of channel %s since the request timed out: %s", channelID, err)
+			return nil, err
+		}
+		logger.Debugf("Retrying genesis block retrieval of channel %s: %s", channelID, err)
+	}
+}
+
+// joinChannel joins each of the targets to the channel concurrently and returns the response of each
+// target. The targets that don't respond before done is closed are reported as failed.
+func (rc *Client) joinChannel(channelID string, genesisBlock *common.Block, targets []fab.Peer, opts Opts, done <-chan struct{}) []JoinChannelResponse {
+	type targetResponse struct {
+		index    int
+		response JoinChannelResponse
+	}
+
+	responses := make([]JoinChannelResponse, len(targets))
+	received := make([]bool, len(targets))
+	responseCh := make(chan targetResponse, len(targets))
+	for i, target := range targets {
+		go func(i int, target fab.Peer) {
+			responseCh <- targetResponse{index: i, response: rc.joinChannelOnTarget(channelID, genesisBlock, target, opts, done)}
+		}(i, target)
+	}
+
+	for range targets {
+		select {
+		case r := <-responseCh:
+			responses[r.index] = r.response
+			received[r.index] = true
+		case <-done:
+			for i, target := range targets {
+				if !received[i] {
+					responses[i] = JoinChannelResponse{Target: target.URL(), Err: errors.Errorf("join channel timed out after %s", opts.Timeout)}
+				}
+			}
+			return responses
+		}
+	}
+	return responses
+}
+
+// joinChannelOnTarget joins the given target to the channel, retrying according to the options until done is
+// closed. A join that's in progress when done is closed isn't interrupted.
+func (rc *Client) joinChannelOnTarget(channelID string, genesisBlock *common.Block, target fab.Peer, opts Opts, done <-chan struct{}) JoinChannelResponse {
+	request := api.JoinChannelRequest{
+		Name:         channelID,
+		Targets:      peer.PeersToTxnProcessors([]fab.Peer{target}),
 		GenesisBlock: genesisBlock,
 	}
 
-	err = rc.resource.JoinChannel(joinChannelRequest)
-	if err != nil {
-		return errors.WithMessage(err, "join channel failed")
+	retryHandler := retry.New(opts.Retry)
+	for {
+		err := rc.resource.JoinChannel(request)
+		if err == nil {
+			return JoinChannelResponse{Target: target.URL()}
+		}
+		if opts.AlreadyJoinedOK && rc.isAlreadyJoined(channelID, target, err) {
+			logger.Debugf("Target %s has already joined channel %s", target.URL(), channelID)
+			return JoinChannelResponse{Target: target.URL(), AlreadyJoined: true}
+		}
+		if !retryHandler.Required(err) {
+			return JoinChannelResponse{Target: target.URL(), Err: err}
+		}
+		if isClosed(done) {
+			logger.Debugf("Not retrying join of target %s to channel %s since the request timed out: %s", target.URL(), channelID, err)
+			return JoinChannelResponse{Target: target.URL(), Err: err}
+		}
+		logger.Debugf("Retrying join of target %s to channel %s: %s", target.URL(), channelID, err)
 	}
+}
 
-	return nil
+// isClosed returns true if the given channel is closed (false if it's nil)
+func isClosed(done <-chan struct{}) bool {
+	select {
+	case <-done:
+		return true
+	default:
+		return false
+	}
+}
+
+// isAlreadyJoined returns true if the join failed because the peer has already joined the channel. The peer
+// rejects the join with the same status as its other failures, so if it rejected the join, the channels
+// it has joined are queried.
+func (rc *Client) isAlreadyJoined(channelID string, target fab.Peer, err error) bool {
+	s, ok := status.FromError(err)
+	if !ok || s.Group != status.EndorserServerStatus {
+		return false
+	}
+
+	response, err := rc.resource.QueryChannels(target)
+	if err != nil {
+		logger.Debugf("Unable to query the channels of target %s: %s", target.URL(), err)
+		return false
+	}
+	for _, channel := range response.Channels {
+		if channel.ChannelId == channelID {
+			return true
+		}
+	}
+	return false
 }
 
 // filterTargets is helper method to filter peers
@@ -304,7 +545,7 @@ func (rc *Client) isChaincodeInstalled(req InstallCCRequest, peer fab.Peer) (boo
 	logger.Debugf("isChaincodeInstalled: %v", chaincodeQueryResponse)
 
 	for _, chaincode := range chaincodeQueryResponse.Chaincodes {
-		if chaincode.Name == req.Name && chaincode.Version == req.Version && chaincode.Path == req.Path {
+		if chaincode.Name == req.Name && chaincode.Version == req.Version {
 			return true, nil
 		}
 	}
@@ -312,13 +553,16 @@ func (rc *Client) isChaincodeInstalled(req InstallCCRequest, peer fab.Peer) (boo
 	return false, nil
 }
 
-// InstallCC installs chaincode with optional custom options (specific peers, filtered peers)
+// InstallCC installs chaincode with optional custom options (specific peers, filtered peers, timeout).
+// Targets that already have the chaincode with the same name and version installed are skipped. A response
+// is returned for each target; if the chaincode couldn't be installed on some of the targets then the
+// responses of those targets contain the error and an error is also returned.
 func (rc *Client) InstallCC(req InstallCCRequest, options ...RequestOption) ([]InstallCCResponse, error) {
 
 	// For each peer query if chaincode installed. If cc is installed treat as success with message 'already installed'.
 	// If cc is not installed try to install, and if that fails add to the list with error and peer name.
 
-	err := checkRequiredInstallCCParams(req)
+	err := checkRequiredInstallCCParams(&req)
 	if err != nil {
 		return nil, err
 	}
@@ -346,15 +590,15 @@ func (rc *Client) InstallCC(req InstallCCRequest, options ...RequestOption) ([]I
 	}
 
 	responses := make([]InstallCCResponse, 0)
-	var errs multi.Errors
 
 	// Targets will be adjusted if cc has already been installed
 	newTargets := make([]fab.Peer, 0)
 	for _, target := range targets {
 		installed, err := rc.isChaincodeInstalled(req, target)
 		if err != nil {
-			// Add to errors with unable to verify error message
-			errs = append(errs, errors.Errorf("unable to verify if cc is installed on %s", target.URL()))
+			// Add to responses with unable to verify error message
+			err = errors.WithMessage(err, fmt.Sprintf("unable to verify if cc is installed on %s", target.URL()))
+			responses = append(responses, InstallCCResponse{Target: target.URL(), Err: err})
 			continue
 		}
 		if installed {
@@ -367,49 +611,211 @@ func (rc *Client) InstallCC(req InstallCCRequest, options ...RequestOption) ([]I
 		}
 	}
 
-	if len(newTargets) == 0 {
-		// CC is already installed on all targets and/or
-		// we are unable to verify if cc is installed on target(s)
-		return responses, nil
+	responses = append(responses, rc.installCC(req, newTargets, opts.Timeout)...)
+
+	var errs multi.Errors
+	for _, response := range responses {
+		if response.Err != nil {
+			errs = append(errs, errors.WithMessage(response.Err, "From target: "+response.Target))
+		}
 	}
+	if len(errs) > 0 {
+		return responses, errors.WithMessage(errs, "InstallChaincode failed")
+	}
+
+	return responses, nil
+}
 
-	icr := api.InstallChaincodeRequest{Name: req.Name, Path: req.Path, Version: req.Version, Package: req.Package, Targets: peer.PeersToTxnProcessors(newTargets)}
+// installCC installs the chaincode on each of the targets concurrently and returns the response of each
+// target. If a timeout is given then the targets that don't respond in time are reported as failed.
+func (rc *Client) installCC(req InstallCCRequest, targets []fab.Peer, timeout time.Duration) []InstallCCResponse {
+	type targetResponse struct {
+		index    int
+		response InstallCCResponse
+	}
+
+	responses := make([]InstallCCResponse, len(targets))
+	received := make([]bool, len(targets))
+	responseCh := make(chan targetResponse, len(targets))
+	for i, target := range targets {
+		go func(i int, target fab.Peer) {
+			responseCh <- targetResponse{index: i, response: rc.installCCOnTarget(req, target)}
+		}(i, target)
+	}
+
+	var timer <-chan time.Time
+	if timeout > 0 {
+		timer = time.After(timeout)
+	}
+
+	for range targets {
+		select {
+		case r := <-responseCh:
+			responses[r.index] = r.response
+			received[r.index] = true
+		case <-timer:
+			for i, target := range targets {
+				if !received[i] {
+					responses[i] = InstallCCResponse{Target: target.URL(), Err: errors.Errorf("install cc timed out after %s", timeout)}
+				}
+			}
+			return responses
+		}
+	}
+	return responses
+}
+
+// installCCOnTarget installs the chaincode on the given target
+func (rc *Client) installCCOnTarget(req InstallCCRequest, target fab.Peer) InstallCCResponse {
+	icr := api.InstallChaincodeRequest{Name: req.Name, Path: req.Path, Version: req.Version, Package: req.Package, DeploymentPackage: req.DeploymentPackage, Targets: peer.PeersToTxnProcessors([]fab.Peer{target})}
 	transactionProposalResponse, _, err := rc.resource.InstallChaincode(icr)
+
+	response := InstallCCResponse{Target: target.URL(), Err: err}
 	for _, v := range transactionProposalResponse {
 		logger.Debugf("Install chaincode '%s' endorser '%s' returned ProposalResponse status:%v", req.Name, v.Endorser, v.Status)
+		response.Status = v.Status
+	}
+	return response
+}
 
-		response := InstallCCResponse{Target: v.Endorser, Status: v.Status}
-		responses = append(responses, response)
+// checkRequiredInstallCCParams checks the install request. The name and version of the chaincode of a
+// deployment package are read from the package: if the request has a name or version then they must match.
+func checkRequiredInstallCCParams(req *InstallCCRequest) error {
+	if len(req.DeploymentPackage) == 0 {
+		if req.Name == "" || req.Version == "" || req.Path == "" || req.Package == nil {
+			return errors.New("Chaincode name, version, path and chaincode package are required")
+		}
+		return nil
 	}
 
+	if req.Package != nil {
+		return errors.New("chaincode package and deployment package are mutually exclusive")
+	}
+	ccID, err := ccpackager.ChaincodeID(req.DeploymentPackage)
 	if err != nil {
-		return responses, errors.WithMessage(err, "InstallChaincode failed")
+		return errors.WithMessage(err, "invalid deployment package")
 	}
-
-	return responses, nil
-}
-
-func checkRequiredInstallCCParams(req InstallCCRequest) error {
-	if req.Name == "" || req.Version == "" || req.Path == "" || req.Package == nil {
-		return errors.New("Chaincode name, version, path and chaincode package are required")
+	if (req.Name != "" && req.Name != ccID.Name) || (req.Version != "" && req.Version != ccID.Version) {
+		return errors.Errorf("chaincode %s:%s doesn't match the chaincode %s:%s of the deployment package", req.Name, req.Version, ccID.Name, ccID.Version)
 	}
+	req.Name = ccID.Name
+	req.Version = ccID.Version
 	return nil
 }
 
-// InstantiateCC instantiates chaincode using default settings
-func (rc *Client) InstantiateCC(channelID string, req InstantiateCCRequest, options ...RequestOption) error {
+// InstantiateCC instantiates chaincode with optional custom options (specific peers, filtered peers, timeout).
+// The instantiate transaction is sent to the orderer and InstantiateCC waits for it to be committed.
+func (rc *Client) InstantiateCC(channelID string, req InstantiateCCRequest, options ...RequestOption) (InstantiateCCResponse, error) {
 	return rc.sendCCProposal(channel.InstantiateChaincode, channelID, req, options...)
 }
 
-// UpgradeCC upgrades chaincode  with optional custom options (specific peers, filtered peers, timeout)
-func (rc *Client) UpgradeCC(channelID string, req UpgradeCCRequest, options ...RequestOption) error {
-	return rc.sendCCProposal(channel.UpgradeChaincode, channelID, InstantiateCCRequest(req), options...)
+// UpgradeCC upgrades chaincode  with optional custom options (specific peers, filtered peers, timeout).
+// The chaincode must be instantiated on the channel with a different version.
+func (rc *Client) UpgradeCC(channelID string, req UpgradeCCRequest, options ...RequestOption) (UpgradeCCResponse, error) {
+	resp, err := rc.sendCCProposal(channel.UpgradeChaincode, channelID, InstantiateCCRequest(req), options...)
+	return UpgradeCCResponse(resp), err
 }
 
-// QueryInstalledChaincodes queries the installed chaincodes on a peer.
+// QueryInstalledChaincodes queries the installed chaincodes on a peer. The query requires an admin identity
+// of the peer, otherwise an *AccessDeniedError is returned.
 // Returns the details of all chaincodes installed on a peer.
-func (rc *Client) QueryInstalledChaincodes(proposalProcessor fab.ProposalProcessor) (*pb.ChaincodeQueryResponse, error) {
-	return rc.resource.QueryInstalledChaincodes(proposalProcessor)
+func (rc *Client) QueryInstalledChaincodes(proposalProcessor fab.ProposalProcessor) ([]ChaincodeInfo, error) {
+	if proposalProcessor == nil {
+		return nil, errors.New("target is required")
+	}
+
+	response, err := rc.resource.QueryInstalledChaincodes(proposalProcessor)
+	if err != nil {
+		return nil, queryError(err, proposalProcessor, "query installed chaincodes failed")
+	}
+	return chaincodeInfos(response), nil
+}
+
+// QueryInstantiatedChaincodes queries the chaincodes instantiated on a channel, from the given peer. The query
+// requires an admin identity, otherwise an *AccessDeniedError is returned.
+// Returns the details of all chaincodes instantiated on the channel.
+func (rc *Client) QueryInstantiatedChaincodes(channelID string, proposalProcessor fab.ProposalProcessor) ([]ChaincodeInfo, error) {
+	if channelID == "" {
+		return nil, errors.New("must provide channel ID")
+	}
+	if proposalProcessor == nil {
+		return nil, errors.New("target is required")
+	}
+
+	channelService, err := rc.channelProvider.ChannelService(rc.identity, channelID)
+	if err != nil {
+		return nil, errors.WithMessage(err, "Unable to get channel service")
+	}
+	return queryInstantiatedChaincodes(channelService, proposalProcessor)
+}
+
+func queryInstantiatedChaincodes(channelService fab.ChannelService, proposalProcessor fab.ProposalProcessor) ([]ChaincodeInfo, error) {
+	ledger, err := channelService.Ledger()
+	if err != nil {
+		return nil, errors.WithMessage(err, "get channel ledger failed")
+	}
+	if ledger == nil {
+		return nil, errors.New("channel ledger is not available")
+	}
+
+	responses, err := ledger.QueryInstantiatedChaincodes([]fab.ProposalProcessor{proposalProcessor})
+	if err != nil {
+		return nil, queryError(err, proposalProcessor, "query instantiated chaincodes failed")
+	}
+	if len(responses) == 0 {
+		return nil, errors.New("query instantiated chaincodes failed: no response")
+	}
+	return chaincodeInfos(responses[0]), nil
+}
+
+// verifyUpgradeVersion checks that the chaincode is instantiated on the channel with a version
+// other than the version of the upgrade
+func verifyUpgradeVersion(channelService fab.ChannelService, channelID string, req InstantiateCCRequest, target fab.ProposalProcessor) error {
+	chaincodes, err := queryInstantiatedChaincodes(channelService, target)
+	if err != nil {
+		return errors.WithMessage(err, "unable to verify the instantiated version of the chaincode")
+	}
+
+	for _, chaincode := range chaincodes {
+		if chaincode.Name != req.Name {
+			continue
+		}
+		if chaincode.Version == req.Version {
+			return errors.Errorf("chaincode %s is already instantiated on channel %s with version %s", req.Name, channelID, req.Version)
+		}
+		return nil
+	}
+	return errors.Errorf("chaincode %s is not instantiated on channel %s", req.Name, channelID)
+}
+
+// chaincodeInfos returns the details of the chaincodes of an lscc query response
+func chaincodeInfos(response *pb.ChaincodeQueryResponse) []ChaincodeInfo {
+	chaincodes := make([]ChaincodeInfo, len(response.Chaincodes))
+	for i, chaincode := range response.Chaincodes {
+		chaincodes[i] = ChaincodeInfo{Name: chaincode.Name, Version: chaincode.Version, Path: chaincode.Path, ID: chaincode.Id}
+	}
+	return chaincodes
+}
+
+// queryError returns an *AccessDeniedError if the target rejected the query because of its ACLs (the status
+// of the response is FORBIDDEN, or the call is denied with PermissionDenied), otherwise the error with the
+// given message
+func queryError(err error, target fab.ProposalProcessor, message string) error {
+	if s, ok := status.FromError(err); ok {
+		if (s.Group == status.EndorserServerStatus && s.Code == int32(common.Status_FORBIDDEN)) ||
+			(s.Group == status.GRPCTransportStatus && status.ToGRPCStatusCode(s.Code) == codes.PermissionDenied) {
+			return &AccessDeniedError{Target: targetURL(target), Err: err}
+		}
+	}
+	return errors.WithMessage(err, message)
+}
+
+// targetURL returns the URL of the target if it's a peer
+func targetURL(target fab.ProposalProcessor) string {
+	if p, ok := target.(fab.Peer); ok {
+		return p.URL()
+	}
+	return "target"
 }
 
 // QueryChannels queries the names of all the channels that a peer has joined.
@@ -419,52 +825,70 @@ func (rc *Client) QueryChannels(proposalProcessor fab.ProposalProcessor) (*pb.Ch
 }
 
 // sendCCProposal sends proposal for type  Instantiate, Upgrade
-func (rc *Client) sendCCProposal(ccProposalType channel.ChaincodeProposalType, channelID string, req InstantiateCCRequest, options ...RequestOption) error {
+func (rc *Client) sendCCProposal(ccProposalType channel.ChaincodeProposalType, channelID string, req InstantiateCCRequest, options ...RequestOption) (InstantiateCCResponse, error) {
 
 	if err := checkRequiredCCProposalParams(channelID, req); err != nil {
-		return err
+		return InstantiateCCResponse{}, err
+	}
+
+	ccPolicy, err := chaincodePolicy(req)
+	if err != nil {
+		return InstantiateCCResponse{}, err
 	}
 
 	opts, err := rc.prepareResmgmtOpts(options...)
 	if err != nil {
-		return errors.WithMessage(err, "failed to get opts for cc proposal")
+		return InstantiateCCResponse{}, errors.WithMessage(err, "failed to get opts for cc proposal")
 	}
 
 	// per channel discovery service
 	discovery, err := rc.discoveryProvider.NewDiscoveryService(channelID)
 	if err != nil {
-		return errors.WithMessage(err, "failed to create channel discovery service")
+		return InstantiateCCResponse{}, errors.WithMessage(err, "failed to create channel discovery service")
 	}
 
 	//Default targets when targets are not provided in options
 	if len(opts.Targets) == 0 {
 		opts.Targets, err = rc.getDefaultTargets(discovery)
 		if err != nil {
-			return errors.WithMessage(err, "failed to get default targets for cc proposal")
+			return InstantiateCCResponse{}, errors.WithMessage(err, "failed to get default targets for cc proposal")
 		}
 	}
 
 	targets, err := rc.calculateTargets(discovery, opts.Targets, opts.TargetFilter)
 	if err != nil {
-		return errors.WithMessage(err, "failed to determine target peers for cc proposal")
+		return InstantiateCCResponse{}, errors.WithMessage(err, "failed to determine target peers for cc proposal")
 	}
 
 	if len(targets) == 0 {
-		return errors.New("No targets available for cc proposal")
+		return InstantiateCCResponse{}, errors.New("No targets available for cc proposal")
 	}
 
 	// Get transactor on the channel to create and send the deploy proposal
 	channelService, err := rc.channelProvider.ChannelService(rc.identity, channelID)
 	if err != nil {
-		return errors.WithMessage(err, "Unable to get channel service")
+		return InstantiateCCResponse{}, errors.WithMessage(err, "Unable to get channel service")
 	}
 	transactor, err := channelService.Transactor()
 	if err != nil {
-		return errors.WithMessage(err, "get channel transactor failed")
+		return InstantiateCCResponse{}, errors.WithMessage(err, "get channel transactor failed")
+	}
+
+	if ccProposalType == channel.UpgradeChaincode {
+		if err := verifyUpgradeVersion(channelService, channelID, req, targets[0]); err != nil {
+			return InstantiateCCResponse{}, err
+		}
 	}
 
 	// create a transaction proposal for chaincode deployment
-	deployProposal := channel.ChaincodeDeployRequest(req)
+	deployProposal := channel.ChaincodeDeployRequest{
+		Name:       req.Name,
+		Path:       req.Path,
+		Version:    req.Version,
+		Args:       req.Args,
+		Policy:     ccPolicy,
+		CollConfig: req.CollConfig,
+	}
 	deployCtx := fabContext{
 		ProviderContext: rc.provider,
 		IdentityContext: rc.identity,
@@ -472,55 +896,61 @@ func (rc *Client) sendCCProposal(ccProposalType channel.ChaincodeProposalType, c
 
 	txid, err := txn.NewHeader(&deployCtx, channelID)
 	if err != nil {
-		return errors.WithMessage(err, "create transaction ID failed")
+		return InstantiateCCResponse{}, errors.WithMessage(err, "create transaction ID failed")
 	}
 	tp, err := channel.CreateChaincodeDeployProposal(txid, ccProposalType, channelID, deployProposal)
 	if err != nil {
-		return errors.WithMessage(err, "creating chaincode deploy transaction proposal failed")
+		return InstantiateCCResponse{}, errors.WithMessage(err, "creating chaincode deploy transaction proposal failed")
 	}
 
 	// Process and send transaction proposal
 	txProposalResponse, err := transactor.SendTransactionProposal(tp, peersToTxnProcessors(targets))
+	response := InstantiateCCResponse{TransactionID: tp.TxnID, Responses: txProposalResponse}
 	if err != nil {
-		return errors.WithMessage(err, "sending deploy transaction proposal failed")
+		return response, errors.WithMessage(err, "sending deploy transaction proposal failed")
 	}
 
 	eventHub, err := channelService.EventHub()
 	if err != nil {
-		return errors.WithMessage(err, "Unable to get EventHub")
+		return response, errors.WithMessage(err, "Unable to get EventHub")
 	}
 	if eventHub.IsConnected() == false {
 		err := eventHub.Connect()
 		if err != nil {
-			return err
+			return response, err
 		}
 		defer eventHub.Disconnect()
 	}
 
 	// Register for commit event
 	statusNotifier := txn.RegisterStatus(tp.TxnID, eventHub)
+	defer eventHub.UnregisterTxEvent(tp.TxnID)
 
 	transactionRequest := fab.TransactionRequest{
 		Proposal:          tp,
 		ProposalResponses: txProposalResponse,
 	}
 	if _, err = createAndSendTransaction(transactor, transactionRequest); err != nil {
-		return errors.WithMessage(err, "CreateAndSendTransaction failed")
+		return response, errors.WithMessage(err, "CreateAndSendTransaction failed")
 	}
 
-	timeout := rc.provider.Config().TimeoutOrDefault(config.Execute)
+	timeout := rc.provider.Config().Timeout(config.Execute)
 	if opts.Timeout != 0 {
 		timeout = opts.Timeout
 	}
 
 	select {
 	case result := <-statusNotifier:
-		if result.Error == nil {
-			return nil
+		response.TxValidationCode = result.Code
+		if result.Error != nil {
+			return response, errors.WithMessage(result.Error, "instantiateOrUpgradeCC failed")
 		}
-		return errors.WithMessage(result.Error, "instantiateOrUpgradeCC failed")
+		if result.Code != pb.TxValidationCode_VALID {
+			return response, status.New(status.EventServerStatus, int32(result.Code), fmt.Sprintf("instantiateOrUpgradeCC failed with validation code %s", result.Code), nil)
+		}
+		return response, nil
 	case <-time.After(timeout):
-		return errors.New("instantiateOrUpgradeCC timeout")
+		return response, errors.New("instantiateOrUpgradeCC timeout")
 	}
 
 }
@@ -531,12 +961,29 @@ func checkRequiredCCProposalParams(channelID string, req InstantiateCCRequest) e
 		return errors.New("must provide channel ID")
 	}
 
-	if req.Name == "" || req.Version == "" || req.Path == "" || req.Policy == nil {
+	if req.Name == "" || req.Version == "" || req.Path == "" || (req.Policy == nil && req.PolicyString == "") {
 		return errors.New("Chaincode name, version, path and policy are required")
 	}
+
+	if req.Policy != nil && req.PolicyString != "" {
+		return errors.New("Chaincode policy must be provided either as a policy or as a policy string")
+	}
 	return nil
 }
 
+// chaincodePolicy returns the endorsement policy of the request, parsing the policy string if there's no policy
+func chaincodePolicy(req InstantiateCCRequest) (*common.SignaturePolicyEnvelope, error) {
+	if req.Policy != nil {
+		return req.Policy, nil
+	}
+
+	envelope, err := policy.FromString(req.PolicyString)
+	if err != nil {
+		return nil, errors.WithMessage(err, "parse of chaincode policy failed")
+	}
+	return envelope, nil
+}
+
 //prepareResmgmtOpts Reads Opts from Option array
 func (rc *Client) prepareResmgmtOpts(options ...RequestOption) (Opts, error) {
 	resmgmtOpts := Opts{}
@@ -579,53 +1026,65 @@ func peersToTxnProcessors(peers []fab.Peer) []fab.ProposalProcessor {
 	return tpp
 }
 
-// SaveChannel creates or updates channel
-func (rc *Client) SaveChannel(req SaveChannelRequest, options ...RequestOption) error {
+// SaveChannel creates or updates channel. The channel configuration is a configtx envelope
+// containing the config update of a channel creation or of a channel update, which is signed
+// by each of the signing identities of the request.
+func (rc *Client) SaveChannel(req SaveChannelRequest, options ...RequestOption) (SaveChannelResponse, error) {
 
 	opts, err := rc.prepareSaveChannelOpts(options...)
 	if err != nil {
-		return err
+		return SaveChannelResponse{}, err
 	}
 
-	if req.ChannelID == "" || req.ChannelConfig == "" {
-		return errors.New("must provide channel ID and channel config")
+	if req.ChannelID == "" || (req.ChannelConfigPath == "" && req.ChannelConfig == nil) {
+		return SaveChannelResponse{}, errors.New("must provide channel ID and channel config")
 	}
 
-	logger.Debugf("***** Saving channel: %s *****\n", req.ChannelID)
-
 	// Signing user has to belong to one of configured channel organisations
 	// In case that order org is one of channel orgs we can use context user
-	signer := rc.identity
-	if req.SigningIdentity != nil {
-		// Retrieve custom signing identity here
-		signer = req.SigningIdentity
+	signers := req.SigningIdentities
+	if len(signers) == 0 {
+		signers = []context.IdentityContext{rc.identity}
 	}
-
-	if signer == nil {
-		return errors.New("must provide signing user")
+	for _, signer := range signers {
+		if signer == nil {
+			return SaveChannelResponse{}, errors.New("must provide signing user")
+		}
 	}
 
-	configTx, err := ioutil.ReadFile(req.ChannelConfig)
+	configTx, err := readChannelConfig(req)
 	if err != nil {
-		return errors.WithMessage(err, "reading channel config file failed")
+		return SaveChannelResponse{}, err
 	}
 
-	chConfig, err := resource.ExtractChannelConfig(configTx)
+	configUpdateEnvelope, err := resource.ExtractConfigUpdateEnvelope(configTx)
 	if err != nil {
-		return errors.WithMessage(err, "extracting channel config failed")
+		return SaveChannelResponse{}, errors.WithMessage(err, "extracting channel config failed")
 	}
 
-	sigCtx := Context{
-		IdentityContext: signer,
-		ProviderContext: rc.provider,
-	}
-	configSignature, err := resource.CreateConfigSignature(&sigCtx, chConfig)
+	create, err := isChannelCreation(req.ChannelID, configUpdateEnvelope.ConfigUpdate)
 	if err != nil {
-		return errors.WithMessage(err, "signing configuration failed")
+		return SaveChannelResponse{}, err
+	}
+	if create {
+		logger.Debugf("***** Creating channel: %s *****\n", req.ChannelID)
+	} else {
+		logger.Debugf("***** Updating channel: %s *****\n", req.ChannelID)
 	}
 
-	var configSignatures []*common.ConfigSignature
-	configSignatures = append(configSignatures, configSignature)
+	// Signatures that are already in the envelope are kept
+	configSignatures := configUpdateEnvelope.Signatures
+	for _, signer := range signers {
+		sigCtx := Context{
+			IdentityContext: signer,
+			ProviderContext: rc.provider,
+		}
+		configSignature, err := resource.CreateConfigSignature(&sigCtx, configUpdateEnvelope.ConfigUpdate)
+		if err != nil {
+			return SaveChannelResponse{}, errors.WithMessage(err, "signing configuration failed")
+		}
+		configSignatures = append(configSignatures, configSignature)
+	}
 
 	// Figure out orderer configuration
 	var ordererCfg *config.OrdererConfig
@@ -638,27 +1097,68 @@ func (rc *Client) SaveChannel(req SaveChannelRequest, options ...RequestOption)
 
 	// Check if retrieving orderer configuration went ok
 	if err != nil || ordererCfg == nil {
-		return errors.Errorf("failed to retrieve orderer config: %s", err)
+		return SaveChannelResponse{}, errors.Errorf("failed to retrieve orderer config: %s", err)
 	}
 
-	orderer, err := orderer.New(rc.provider.Config(), orderer.FromOrdererConfig(ordererCfg))
+	orderer, err := orderer.New(rc.provider.Config(), orderer.FromOrdererConfig(ordererCfg), orderer.WithConnector(rc.provider.Connector()))
 	if err != nil {
-		return errors.WithMessage(err, "failed to create new orderer from config")
+		return SaveChannelResponse{}, errors.WithMessage(err, "failed to create new orderer from config")
 	}
 
 	request := api.CreateChannelRequest{
 		Name:       req.ChannelID,
 		Orderer:    orderer,
-		Config:     chConfig,
+		Config:     configUpdateEnvelope.ConfigUpdate,
 		Signatures: configSignatures,
 	}
 
-	_, err = rc.resource.CreateChannel(request)
+	txID, err := rc.resource.CreateChannel(request)
 	if err != nil {
-		return errors.WithMessage(err, "create channel failed")
+		if create {
+			return SaveChannelResponse{}, errors.WithMessage(err, "create channel failed")
+		}
+		return SaveChannelResponse{}, errors.WithMessage(err, "update channel failed")
 	}
 
-	return nil
+	return SaveChannelResponse{TransactionID: txID}, nil
+}
+
+// readChannelConfig reads the channel configuration of the request, from its reader or from its path
+func readChannelConfig(req SaveChannelRequest) ([]byte, error) {
+	if req.ChannelConfig != nil {
+		configTx, err := ioutil.ReadAll(req.ChannelConfig)
+		if err != nil {
+			return nil, errors.Wrap(err, "reading channel config failed")
+		}
+		return configTx, nil
+	}
+
+	configTx, err := ioutil.ReadFile(req.ChannelConfigPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading channel config file failed")
+	}
+	return configTx, nil
+}
+
+// isChannelCreation returns true if the config update creates the channel, false if it updates
+// the configuration of an existing channel. The config update of a channel creation reads the
+// consortium of the channel.
+func isChannelCreation(channelID string, configUpdateBytes []byte) (bool, error) {
+	configUpdate := &common.ConfigUpdate{}
+	err := proto.Unmarshal(configUpdateBytes, configUpdate)
+	if err != nil {
+		return false, errors.Wrap(err, "unmarshal config update failed")
+	}
+
+	if configUpdate.ChannelId != channelID {
+		return false, errors.Errorf("channel config is for channel %s, not %s", configUpdate.ChannelId, channelID)
+	}
+
+	if configUpdate.ReadSet == nil {
+		return false, nil
+	}
+	_, ok := configUpdate.ReadSet.Values[channelconfig.ConsortiumKey]
+	return ok, nil
 }
 
 //prepareSaveChannelOpts Reads chmgmt.Opts from chmgmt.Option array
@@ -7,21 +7,33 @@ SPDX-License-Identifier: Apache-2.0
 package resmgmt
 
 import (
+	"bytes"
 	"fmt"
+	"io/ioutil"
 	"net"
+	"reflect"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/golang/protobuf/proto"
 	"google.golang.org/grpc"
+	grpccodes "google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
 
 	"github.com/hyperledger/fabric-sdk-go/pkg/context"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/errors/retry"
+	"github.com/hyperledger/fabric-sdk-go/pkg/errors/status"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/peer"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/policy"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/resource"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/resource/api"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk/provider/fabpvdr"
 	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/common/cauthdsl"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
 	"github.com/pkg/errors"
 
 	txnmocks "github.com/hyperledger/fabric-sdk-go/pkg/client/common/mocks"
@@ -32,7 +44,8 @@ import (
 	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
 )
 
-const channelConfig = "./testdata/test.tx"
+const channelConfig = "../../../test/fixtures/fabric/v1.1/channel/mychannel.tx"
+const channelUpdateConfig = "../../../test/fixtures/fabric/v1.1/channel/mychannelOrg1MSPanchors.tx"
 const networkCfg = "../../../test/fixtures/config/config_test.yaml"
 
 func TestJoinChannelFail(t *testing.T) {
@@ -46,12 +59,15 @@ func TestJoinChannelFail(t *testing.T) {
 	rc.resource = fcmocks.NewMockInvalidResource()
 
 	// Setup target peers
-	peer1, _ := peer.New(fcmocks.NewMockConfig())
+	peer1 := &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com"}
 
-	// Test fail genesis block retrieval (no orderer)
-	err := rc.JoinChannel("mychannel", WithTargets(peer1))
+	// Test fail join channel
+	responses, err := rc.JoinChannel("mychannel", WithTargets(peer1))
 	if err == nil {
-		t.Fatal("Should have failed to get genesis block")
+		t.Fatal("Should have failed to join channel")
+	}
+	if len(responses) != 1 || responses[0].Err == nil {
+		t.Fatalf("Expecting the join of the target to fail, got %v", responses)
 	}
 
 }
@@ -83,7 +99,7 @@ func TestJoinChannel(t *testing.T) {
 	peers = append(peers, peer1)
 
 	// Test valid join channel request (success)
-	err = rc.JoinChannel("mychannel", WithTargets(peer1))
+	_, err = rc.JoinChannel("mychannel", WithTargets(peer1))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -125,7 +141,7 @@ func TestJoinChannelWithFilter(t *testing.T) {
 	peers = append(peers, peer1)
 
 	// Test valid join channel request (success)
-	err = rc.JoinChannel("mychannel", WithTargets(peer1))
+	_, err = rc.JoinChannel("mychannel", WithTargets(peer1))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -169,13 +185,13 @@ func TestJoinChannelRequiredParameters(t *testing.T) {
 	rc := setupDefaultResMgmtClient(t)
 
 	// Test empty channel name
-	err := rc.JoinChannel("")
+	_, err := rc.JoinChannel("")
 	if err == nil {
 		t.Fatalf("Should have failed for empty channel name")
 	}
 
 	// Test error when creating channel from configuration
-	err = rc.JoinChannel("error")
+	_, err = rc.JoinChannel("error")
 	if err == nil {
 		t.Fatalf("Should have failed with generated error in NewChannel")
 	}
@@ -189,7 +205,7 @@ func TestJoinChannelRequiredParameters(t *testing.T) {
 	rc = setupResMgmtClient(ctx, nil, t)
 
 	// Test missing default targets
-	err = rc.JoinChannel("mychannel")
+	_, err = rc.JoinChannel("mychannel")
 	if err == nil || !strings.Contains(err.Error(), "No targets available") {
 		t.Fatalf("InstallCC should have failed with no default targets error")
 	}
@@ -201,7 +217,7 @@ func TestJoinChannelWithOptsRequiredParameters(t *testing.T) {
 	rc := setupDefaultResMgmtClient(t)
 
 	// Test empty channel name for request with no opts
-	err := rc.JoinChannel("")
+	_, err := rc.JoinChannel("")
 	if err == nil {
 		t.Fatalf("Should have failed for empty channel name")
 	}
@@ -211,25 +227,25 @@ func TestJoinChannelWithOptsRequiredParameters(t *testing.T) {
 	peers = append(peers, &peer)
 
 	// Test both targets and filter provided (error condition)
-	err = rc.JoinChannel("mychannel", WithTargets(peers...), WithTargetFilter(&MSPFilter{mspID: "MspID"}))
+	_, err = rc.JoinChannel("mychannel", WithTargets(peers...), WithTargetFilter(&MSPFilter{mspID: "MspID"}))
 	if err == nil || !strings.Contains(err.Error(), "If targets are provided, filter cannot be provided") {
 		t.Fatalf("Should have failed if both target and filter provided")
 	}
 
 	// Test targets only
-	err = rc.JoinChannel("mychannel", WithTargets(peers...))
+	_, err = rc.JoinChannel("mychannel", WithTargets(peers...))
 	if err != nil {
 		t.Fatalf(err.Error())
 	}
 
 	// Test filter only (filter has no match)
-	err = rc.JoinChannel("mychannel", WithTargetFilter(&MSPFilter{mspID: "MspID"}))
+	_, err = rc.JoinChannel("mychannel", WithTargetFilter(&MSPFilter{mspID: "MspID"}))
 	if err == nil || !strings.Contains(err.Error(), "No targets available") {
 		t.Fatalf("InstallCC should have failed with no targets error")
 	}
 
 	// Test filter only (filter has a match)
-	err = rc.JoinChannel("mychannel", WithTargetFilter(&MSPFilter{mspID: "Org1MSP"}))
+	_, err = rc.JoinChannel("mychannel", WithTargetFilter(&MSPFilter{mspID: "Org1MSP"}))
 	if err != nil {
 		t.Fatalf(err.Error())
 	}
@@ -246,13 +262,13 @@ func TestJoinChannelDiscoveryError(t *testing.T) {
 	// Create resource management client with discovery service that will generate an error
 	rc := setupResMgmtClient(ctx, errors.New("Test Error"), t)
 
-	err := rc.JoinChannel("mychannel")
+	_, err := rc.JoinChannel("mychannel")
 	if err == nil {
 		t.Fatalf("Should have failed to join channel with discovery error")
 	}
 
 	// If targets are not provided discovery service is used
-	err = rc.JoinChannel("mychannel")
+	_, err = rc.JoinChannel("mychannel")
 	if err == nil {
 		t.Fatalf("Should have failed to join channel with discovery error")
 	}
@@ -271,7 +287,7 @@ func TestJoinChannelNoOrdererConfig(t *testing.T) {
 	ctx.SetConfig(noOrdererConfig)
 	rc := setupResMgmtClient(ctx, nil, t)
 
-	err = rc.JoinChannel("mychannel")
+	_, err = rc.JoinChannel("mychannel")
 	if err == nil {
 		t.Fatalf("Should have failed to join channel since no orderer has been configured")
 	}
@@ -285,7 +301,7 @@ func TestJoinChannelNoOrdererConfig(t *testing.T) {
 
 	rc = setupResMgmtClient(ctx, nil, t)
 
-	err = rc.JoinChannel("mychannel")
+	_, err = rc.JoinChannel("mychannel")
 	if err == nil {
 		t.Fatalf("Should have failed to join channel since channel orderer has been misconfigured")
 	}
@@ -298,7 +314,7 @@ func TestJoinChannelNoOrdererConfig(t *testing.T) {
 	ctx.SetConfig(invalidOrdererConfig)
 	rc = setupResMgmtClient(ctx, nil, t)
 
-	err = rc.JoinChannel("mychannel")
+	_, err = rc.JoinChannel("mychannel")
 	if err == nil {
 		t.Fatalf("Should have failed to join channel since global orderer certs are not configured properly")
 	}
@@ -531,6 +547,66 @@ func TestInstallCCRequiredParameters(t *testing.T) {
 
 }
 
+func TestInstallCCDeploymentPackage(t *testing.T) {
+
+	rc := setupDefaultResMgmtClient(t)
+	recorder := &recordingResource{Resource: rc.resource}
+	rc.resource = recorder
+
+	cds, err := proto.Marshal(&pb.ChaincodeDeploymentSpec{
+		ChaincodeSpec: &pb.ChaincodeSpec{Type: pb.ChaincodeSpec_GOLANG, ChaincodeId: &pb.ChaincodeID{Name: "ID", Path: "path", Version: "v0"}},
+		CodePackage:   []byte("code"),
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal chaincode deployment spec: %s", err)
+	}
+
+	// The name and version are read from the package
+	responses, err := rc.InstallCC(InstallCCRequest{DeploymentPackage: cds})
+	if err != nil {
+		t.Fatalf("InstallCC failed: %s", err)
+	}
+	if len(responses) != 1 || len(recorder.installRequests) != 1 {
+		t.Fatalf("Expected the package to be installed on one target")
+	}
+	installed := recorder.installRequests[0]
+	if !bytes.Equal(cds, installed.DeploymentPackage) || installed.Name != "ID" || installed.Version != "v0" {
+		t.Fatalf("Expected the deployment package to be installed as is")
+	}
+
+	// The chaincode of the package is installed already
+	cds, err = proto.Marshal(&pb.ChaincodeDeploymentSpec{
+		ChaincodeSpec: &pb.ChaincodeSpec{Type: pb.ChaincodeSpec_GOLANG, ChaincodeId: &pb.ChaincodeID{Name: "name", Path: "path", Version: "version"}},
+		CodePackage:   []byte("code"),
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal chaincode deployment spec: %s", err)
+	}
+	responses, err = rc.InstallCC(InstallCCRequest{Name: "name", DeploymentPackage: cds})
+	if err != nil {
+		t.Fatalf("InstallCC failed: %s", err)
+	}
+	if len(responses) != 1 || !strings.Contains(responses[0].Info, "already installed") {
+		t.Fatalf("Should have 'already installed' info set")
+	}
+
+	// The request must match the package
+	_, err = rc.InstallCC(InstallCCRequest{Name: "name", Version: "other", DeploymentPackage: cds})
+	if err == nil || !strings.Contains(err.Error(), "doesn't match") {
+		t.Fatalf("Should have failed for a version that doesn't match the package: %v", err)
+	}
+
+	_, err = rc.InstallCC(InstallCCRequest{DeploymentPackage: cds, Package: &api.CCPackage{Type: 1, Code: []byte("code")}})
+	if err == nil {
+		t.Fatalf("Should have failed for both a chaincode package and a deployment package")
+	}
+
+	_, err = rc.InstallCC(InstallCCRequest{DeploymentPackage: []byte("invalid")})
+	if err == nil {
+		t.Fatalf("Should have failed for an invalid deployment package")
+	}
+}
+
 func TestInstallCCWithOptsRequiredParameters(t *testing.T) {
 
 	rc := setupDefaultResMgmtClient(t)
@@ -631,6 +707,242 @@ func TestInstallCCDiscoveryError(t *testing.T) {
 
 }
 
+// failingResource fails to query, install chaincode or join the channel on some targets or blocks the install until released
+type failingResource struct {
+	api.Resource
+	failures   map[string]bool
+	unverified map[string]bool
+	blocked    map[string]bool
+	release    chan struct{}
+	joined     map[string]bool
+	mutex      sync.Mutex
+	transient  map[string]int
+}
+
+func (r *failingResource) QueryInstalledChaincodes(target fab.ProposalProcessor) (*pb.ChaincodeQueryResponse, error) {
+	url := target.(fab.Peer).URL()
+	if r.unverified[url] {
+		return nil, errors.Errorf("query failed on %s", url)
+	}
+	return r.Resource.QueryInstalledChaincodes(target)
+}
+
+func (r *failingResource) InstallChaincode(req api.InstallChaincodeRequest) ([]*fab.TransactionProposalResponse, fab.TransactionID, error) {
+	url := req.Targets[0].(fab.Peer).URL()
+	if r.blocked[url] {
+		<-r.release
+	}
+	if r.failures[url] {
+		return nil, "", errors.Errorf("install failed on %s", url)
+	}
+	return []*fab.TransactionProposalResponse{{Endorser: url, Status: 200}}, "1234", nil
+}
+
+func (r *failingResource) JoinChannel(req api.JoinChannelRequest) error {
+	url := req.Targets[0].(fab.Peer).URL()
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.transient[url] > 0 {
+		r.transient[url]--
+		return status.New(status.EndorserServerStatus, int32(common.Status_SERVICE_UNAVAILABLE), "service unavailable", nil)
+	}
+	if r.joined[url] {
+		return status.New(status.EndorserServerStatus, int32(common.Status_INTERNAL_SERVER_ERROR), "Cannot create ledger from genesis block, due to LedgerID already exists", nil)
+	}
+	if r.failures[url] {
+		return status.New(status.EndorserServerStatus, int32(common.Status_INTERNAL_SERVER_ERROR), "join failed on "+url, nil)
+	}
+	return nil
+}
+
+func (r *failingResource) QueryChannels(target fab.ProposalProcessor) (*pb.ChannelQueryResponse, error) {
+	response := &pb.ChannelQueryResponse{}
+	if r.joined[target.(fab.Peer).URL()] {
+		response.Channels = append(response.Channels, &pb.ChannelInfo{ChannelId: "mychannel"})
+	}
+	return response, nil
+}
+
+func (r *failingResource) GenesisBlockFromOrderer(channelName string, orderer fab.Orderer) (*common.Block, error) {
+	if r.blocked[orderer.URL()] {
+		<-r.release
+	}
+	return r.Resource.GenesisBlockFromOrderer(channelName, orderer)
+}
+
+func TestJoinChannelPartialFailure(t *testing.T) {
+
+	rc := setupDefaultResMgmtClient(t)
+	rc.resource = &failingResource{Resource: rc.resource, failures: map[string]bool{"http://peer2.com": true}, joined: map[string]bool{"http://peer3.com": true}}
+
+	peer1 := &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com"}
+	peer2 := &fcmocks.MockPeer{MockName: "Peer2", MockURL: "http://peer2.com"}
+	peer3 := &fcmocks.MockPeer{MockName: "Peer3", MockURL: "http://peer3.com"}
+
+	responses, err := rc.JoinChannel("mychannel", WithTargets(peer1, peer2, peer3))
+	if err == nil {
+		t.Fatal("Should have failed to join peers 2 and 3")
+	}
+	if !strings.Contains(err.Error(), "From target: http://peer2.com") || !strings.Contains(err.Error(), "From target: http://peer3.com") {
+		t.Fatalf("Expecting errors of peers 2 and 3, got %s", err)
+	}
+	if len(responses) != 3 || responses[0].Err != nil || responses[1].Err == nil || responses[2].Err == nil {
+		t.Fatalf("Expecting peer 1 to join and peers 2 and 3 to fail, got %v", responses)
+	}
+
+	// Peers that have already joined are reported as joined
+	responses, err = rc.JoinChannel("mychannel", WithTargets(peer1, peer2, peer3), WithAlreadyJoinedOK())
+	if err == nil || strings.Contains(err.Error(), "http://peer3.com") {
+		t.Fatalf("Expecting peer 2 only to fail, got %v", err)
+	}
+	if responses[0].Target != peer1.MockURL || responses[0].Err != nil || responses[0].AlreadyJoined {
+		t.Fatalf("Expecting peer 1 to join, got %v", responses[0])
+	}
+	if responses[1].Target != peer2.MockURL || responses[1].Err == nil {
+		t.Fatalf("Expecting peer 2 to fail, got %v", responses[1])
+	}
+	if responses[2].Target != peer3.MockURL || responses[2].Err != nil || !responses[2].AlreadyJoined {
+		t.Fatalf("Expecting peer 3 to have already joined, got %v", responses[2])
+	}
+}
+
+func TestJoinChannelGenesisBlockTimeout(t *testing.T) {
+
+	rc := setupDefaultResMgmtClient(t)
+	orderer, err := rc.joinChannelOrderer("mychannel", Opts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resource := &failingResource{Resource: rc.resource, blocked: map[string]bool{orderer.URL(): true}, release: make(chan struct{})}
+	defer close(resource.release)
+	rc.resource = resource
+
+	peer1 := &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com"}
+	_, err = rc.JoinChannel("mychannel", WithTargets(peer1), WithTimeout(50*time.Millisecond))
+	if err == nil || !strings.Contains(err.Error(), "genesis block retrieval timed out") {
+		t.Fatalf("Expecting the genesis block retrieval to time out, got %v", err)
+	}
+}
+
+func TestJoinChannelTimeoutStopsRetries(t *testing.T) {
+
+	rc := setupDefaultResMgmtClient(t)
+	resource := &failingResource{Resource: rc.resource, transient: map[string]int{"http://peer1.com": 1000}}
+	rc.resource = resource
+
+	peer1 := &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com"}
+	retryOpts := retry.Opts{Attempts: 1000, InitialBackoff: 10 * time.Millisecond, MaxBackoff: 10 * time.Millisecond, BackoffFactor: 1}
+
+	responses, err := rc.JoinChannel("mychannel", WithTargets(peer1), WithRetry(retryOpts), WithTimeout(50*time.Millisecond))
+	if err == nil || responses[0].Err == nil || !strings.Contains(responses[0].Err.Error(), "timed out") {
+		t.Fatalf("Expecting the join to time out, got %v", err)
+	}
+
+	// The join that's in progress when the timeout expires may complete, but the join isn't retried anymore
+	remaining := func() int {
+		resource.mutex.Lock()
+		defer resource.mutex.Unlock()
+		return resource.transient["http://peer1.com"]
+	}
+	before := remaining()
+	time.Sleep(100 * time.Millisecond)
+	if attempts := before - remaining(); attempts > 1 {
+		t.Fatalf("Expecting the join not to be retried after the timeout, got %d attempts", attempts)
+	}
+}
+
+func TestJoinChannelRetry(t *testing.T) {
+
+	rc := setupDefaultResMgmtClient(t)
+	resource := &failingResource{Resource: rc.resource, transient: map[string]int{"http://peer1.com": 2}}
+	rc.resource = resource
+
+	peer1 := &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com"}
+	retryOpts := retry.Opts{Attempts: 1, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond, BackoffFactor: 1}
+
+	// Not enough attempts
+	_, err := rc.JoinChannel("mychannel", WithTargets(peer1), WithRetry(retryOpts))
+	if err == nil {
+		t.Fatal("Should have failed to join after one retry")
+	}
+
+	resource.transient["http://peer1.com"] = 2
+	retryOpts.Attempts = 2
+	responses, err := rc.JoinChannel("mychannel", WithTargets(peer1), WithRetry(retryOpts))
+	if err != nil || responses[0].Err != nil {
+		t.Fatalf("Expecting join to succeed after retries: %v", err)
+	}
+}
+
+func TestInstallCCPartialFailure(t *testing.T) {
+
+	rc := setupDefaultResMgmtClient(t)
+	rc.resource = &failingResource{Resource: rc.resource, failures: map[string]bool{"http://peer2.com": true}, unverified: map[string]bool{"http://peer3.com": true}}
+
+	peer1 := &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockMSP: "Org1MSP"}
+	peer2 := &fcmocks.MockPeer{MockName: "Peer2", MockURL: "http://peer2.com", MockMSP: "Org1MSP"}
+	peer3 := &fcmocks.MockPeer{MockName: "Peer3", MockURL: "http://peer3.com", MockMSP: "Org1MSP"}
+
+	// Path isn't compared, the chaincode is already installed if it has the same name and version
+	req := InstallCCRequest{Name: "name", Version: "version", Path: "otherpath", Package: &api.CCPackage{Type: 1, Code: []byte("code")}}
+	responses, err := rc.InstallCC(req, WithTargets(peer1, peer2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, response := range responses {
+		if response.Info != "already installed" {
+			t.Fatalf("Expecting chaincode to be already installed on %s", response.Target)
+		}
+	}
+
+	req = InstallCCRequest{Name: "ID", Version: "v0", Path: "path", Package: &api.CCPackage{Type: 1, Code: []byte("code")}}
+	responses, err = rc.InstallCC(req, WithTargets(peer1, peer2, peer3))
+	if err == nil {
+		t.Fatal("Should have failed since install cc fails on some of the targets")
+	}
+	if !strings.Contains(err.Error(), "install failed on http://peer2.com") {
+		t.Fatalf("Expecting error of failed target, got %s", err)
+	}
+	if !strings.Contains(err.Error(), "unable to verify if cc is installed on http://peer3.com") {
+		t.Fatalf("Expecting error of unverified target, got %s", err)
+	}
+	if len(responses) != 3 {
+		t.Fatalf("Expecting a response for each target, got %d", len(responses))
+	}
+	for _, response := range responses {
+		failed := response.Target != peer1.MockURL
+		if failed != (response.Err != nil) {
+			t.Fatalf("Unexpected response for %s: %v", response.Target, response)
+		}
+		if !failed && response.Status != 200 {
+			t.Fatalf("Expecting status 200 for %s, got %d", response.Target, response.Status)
+		}
+	}
+}
+
+func TestInstallCCTimeout(t *testing.T) {
+
+	rc := setupDefaultResMgmtClient(t)
+	resource := &failingResource{Resource: rc.resource, blocked: map[string]bool{"http://peer2.com": true}, release: make(chan struct{})}
+	defer close(resource.release)
+	rc.resource = resource
+
+	peer1 := &fcmocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockMSP: "Org1MSP"}
+	peer2 := &fcmocks.MockPeer{MockName: "Peer2", MockURL: "http://peer2.com", MockMSP: "Org1MSP"}
+
+	req := InstallCCRequest{Name: "ID", Version: "v0", Path: "path", Package: &api.CCPackage{Type: 1, Code: []byte("code")}}
+	responses, err := rc.InstallCC(req, WithTargets(peer1, peer2), WithTimeout(50*time.Millisecond))
+	if err == nil {
+		t.Fatal("Should have failed since install cc times out on one of the targets")
+	}
+	if len(responses) != 2 || responses[0].Err != nil || responses[1].Err == nil {
+		t.Fatalf("Expecting the install to time out on the blocked target only: %v", responses)
+	}
+	if !strings.Contains(responses[1].Err.Error(), "timed out") {
+		t.Fatalf("Expecting timeout error, got %s", responses[1].Err)
+	}
+}
+
 func TestInstantiateCCRequiredParameters(t *testing.T) {
 
 	rc := setupDefaultResMgmtClient(t)
@@ -639,41 +951,41 @@ func TestInstantiateCCRequiredParameters(t *testing.T) {
 	req := InstantiateCCRequest{}
 
 	// Test empty channel name
-	err := rc.InstantiateCC("", req)
+	_, err := rc.InstantiateCC("", req)
 	if err == nil {
 		t.Fatalf("Should have failed for empty request")
 	}
 
 	// Test empty request
-	err = rc.InstantiateCC("mychannel", req)
+	_, err = rc.InstantiateCC("mychannel", req)
 	if err == nil {
 		t.Fatalf("Should have failed for empty request")
 	}
 
 	// Test missing chaincode ID
 	req = InstantiateCCRequest{Name: "", Version: "v0", Path: "path"}
-	err = rc.InstantiateCC("mychannel", req)
+	_, err = rc.InstantiateCC("mychannel", req)
 	if err == nil {
 		t.Fatalf("Should have failed for empty cc name")
 	}
 
 	// Test missing chaincode version
 	req = InstantiateCCRequest{Name: "ID", Version: "", Path: "path"}
-	err = rc.InstantiateCC("mychannel", req)
+	_, err = rc.InstantiateCC("mychannel", req)
 	if err == nil {
 		t.Fatalf("Should have failed for empty cc version")
 	}
 
 	// Test missing chaincode path
 	req = InstantiateCCRequest{Name: "ID", Version: "v0", Path: ""}
-	err = rc.InstantiateCC("mychannel", req)
+	_, err = rc.InstantiateCC("mychannel", req)
 	if err == nil {
 		t.Fatalf("Should have failed for empty cc path")
 	}
 
 	// Test missing chaincode policy
 	req = InstantiateCCRequest{Name: "ID", Version: "v0", Path: "path"}
-	err = rc.InstantiateCC("mychannel", req)
+	_, err = rc.InstantiateCC("mychannel", req)
 	if err == nil {
 		t.Fatalf("Should have failed for nil chaincode policy")
 	}
@@ -691,7 +1003,7 @@ func TestInstantiateCCRequiredParameters(t *testing.T) {
 	req = InstantiateCCRequest{Name: "name", Version: "version", Path: "path", Policy: ccPolicy}
 
 	// Test missing default targets
-	err = rc.InstantiateCC("mychannel", req)
+	_, err = rc.InstantiateCC("mychannel", req)
 	if err == nil {
 		t.Fatalf("InstallCC should have failed with no default targets error")
 	}
@@ -706,41 +1018,41 @@ func TestInstantiateCCWithOptsRequiredParameters(t *testing.T) {
 	req := InstantiateCCRequest{}
 
 	// Test empty channel name
-	err := rc.InstantiateCC("", req)
+	_, err := rc.InstantiateCC("", req)
 	if err == nil {
 		t.Fatalf("Should have failed for empty channel name")
 	}
 
 	// Test empty request
-	err = rc.InstantiateCC("mychannel", req)
+	_, err = rc.InstantiateCC("mychannel", req)
 	if err == nil {
 		t.Fatalf("Should have failed for empty instantiate cc request")
 	}
 
 	// Test missing chaincode ID
 	req = InstantiateCCRequest{Name: "", Version: "v0", Path: "path"}
-	err = rc.InstantiateCC("mychannel", req)
+	_, err = rc.InstantiateCC("mychannel", req)
 	if err == nil {
 		t.Fatalf("Should have failed for empty cc name")
 	}
 
 	// Test missing chaincode version
 	req = InstantiateCCRequest{Name: "ID", Version: "", Path: "path"}
-	err = rc.InstantiateCC("mychannel", req)
+	_, err = rc.InstantiateCC("mychannel", req)
 	if err == nil {
 		t.Fatalf("Should have failed for empty cc version")
 	}
 
 	// Test missing chaincode path
 	req = InstantiateCCRequest{Name: "ID", Version: "v0", Path: ""}
-	err = rc.InstantiateCC("mychannel", req)
+	_, err = rc.InstantiateCC("mychannel", req)
 	if err == nil {
 		t.Fatalf("Should have failed for empty cc path")
 	}
 
 	// Test missing chaincode policy
 	req = InstantiateCCRequest{Name: "ID", Version: "v0", Path: "path"}
-	err = rc.InstantiateCC("mychannel", req)
+	_, err = rc.InstantiateCC("mychannel", req)
 	if err == nil {
 		t.Fatalf("Should have failed for missing chaincode policy")
 	}
@@ -755,7 +1067,7 @@ func TestInstantiateCCWithOptsRequiredParameters(t *testing.T) {
 	peers = append(peers, &peer)
 
 	// Test both targets and filter provided (error condition)
-	err = rc.InstantiateCC("mychannel", req, WithTargets(peers...), WithTargetFilter(&MSPFilter{mspID: "Org1MSP"}))
+	_, err = rc.InstantiateCC("mychannel", req, WithTargets(peers...), WithTargetFilter(&MSPFilter{mspID: "Org1MSP"}))
 	if err == nil {
 		t.Fatalf("Should have failed if both target and filter provided")
 	}
@@ -769,13 +1081,13 @@ func TestInstantiateCCWithOptsRequiredParameters(t *testing.T) {
 	rc = setupResMgmtClient(ctx, nil, t)
 
 	// No targets and no filter -- default filter msp doesn't match discovery service peer msp
-	err = rc.InstantiateCC("mychannel", req)
+	_, err = rc.InstantiateCC("mychannel", req)
 	if err == nil {
 		t.Fatalf("Should have failed with no targets error")
 	}
 
 	// Test filter only provided (filter rejects discovery service peer msp)
-	err = rc.InstantiateCC("mychannel", req, WithTargetFilter(&MSPFilter{mspID: "Org2MSP"}))
+	_, err = rc.InstantiateCC("mychannel", req, WithTargetFilter(&MSPFilter{mspID: "Org2MSP"}))
 	if err == nil {
 		t.Fatalf("Should have failed with no targets since filter rejected all discovery targets")
 	}
@@ -795,26 +1107,26 @@ func TestInstantiateCCDiscoveryError(t *testing.T) {
 	req := InstantiateCCRequest{Name: "name", Version: "version", Path: "path", Policy: ccPolicy}
 
 	// Test InstantiateCC create new discovery service per channel error
-	err := rc.InstantiateCC("error", req)
+	_, err := rc.InstantiateCC("error", req)
 	if err == nil {
 		t.Fatalf("Should have failed to instantiate cc with create discovery service error")
 	}
 
 	// Test InstantiateCC discovery service get peers error
-	err = rc.InstantiateCC("mychannel", req)
+	_, err = rc.InstantiateCC("mychannel", req)
 	if err == nil {
 		t.Fatalf("Should have failed to instantiate cc with get peers discovery error")
 	}
 
 	// Test InstantiateCCWithOpts create new discovery service per channel error
-	err = rc.InstantiateCC("error", req)
+	_, err = rc.InstantiateCC("error", req)
 	if err == nil {
 		t.Fatalf("Should have failed to instantiate cc with opts with create discovery service error")
 	}
 
 	// Test InstantiateCCWithOpts discovery service get peers error
 	// if targets are not provided discovery service is used
-	err = rc.InstantiateCC("mychannel", req)
+	_, err = rc.InstantiateCC("mychannel", req)
 	if err == nil {
 		t.Fatalf("Should have failed to instantiate cc with opts with get peers discovery error")
 	}
@@ -829,41 +1141,41 @@ func TestUpgradeCCRequiredParameters(t *testing.T) {
 	req := UpgradeCCRequest{}
 
 	// Test empty channel name
-	err := rc.UpgradeCC("", req)
+	_, err := rc.UpgradeCC("", req)
 	if err == nil {
 		t.Fatalf("Should have failed for empty channel name")
 	}
 
 	// Test empty request
-	err = rc.UpgradeCC("mychannel", req)
+	_, err = rc.UpgradeCC("mychannel", req)
 	if err == nil {
 		t.Fatalf("Should have failed for empty upgrade cc request")
 	}
 
 	// Test missing chaincode ID
 	req = UpgradeCCRequest{Name: "", Version: "v0", Path: "path"}
-	err = rc.UpgradeCC("mychannel", req)
+	_, err = rc.UpgradeCC("mychannel", req)
 	if err == nil {
 		t.Fatalf("Should have failed for empty cc name")
 	}
 
 	// Test missing chaincode version
 	req = UpgradeCCRequest{Name: "ID", Version: "", Path: "path"}
-	err = rc.UpgradeCC("mychannel", req)
+	_, err = rc.UpgradeCC("mychannel", req)
 	if err == nil {
 		t.Fatalf("Should have failed for empty cc version")
 	}
 
 	// Test missing chaincode path
 	req = UpgradeCCRequest{Name: "ID", Version: "v0", Path: ""}
-	err = rc.UpgradeCC("mychannel", req)
+	_, err = rc.UpgradeCC("mychannel", req)
 	if err == nil {
 		t.Fatalf("Should have failed for empty cc path")
 	}
 
 	// Test missing chaincode policy
 	req = UpgradeCCRequest{Name: "ID", Version: "v0", Path: "path"}
-	err = rc.UpgradeCC("mychannel", req)
+	_, err = rc.UpgradeCC("mychannel", req)
 	if err == nil {
 		t.Fatalf("Should have failed for nil chaincode policy")
 	}
@@ -881,7 +1193,7 @@ func TestUpgradeCCRequiredParameters(t *testing.T) {
 	req = UpgradeCCRequest{Name: "name", Version: "version", Path: "path", Policy: ccPolicy}
 
 	// Test missing default targets
-	err = rc.UpgradeCC("mychannel", req)
+	_, err = rc.UpgradeCC("mychannel", req)
 	if err == nil {
 		t.Fatalf("Should have failed with no default targets error")
 	}
@@ -896,41 +1208,41 @@ func TestUpgradeCCWithOptsRequiredParameters(t *testing.T) {
 	req := UpgradeCCRequest{}
 
 	// Test empty channel name
-	err := rc.UpgradeCC("", req)
+	_, err := rc.UpgradeCC("", req)
 	if err == nil {
 		t.Fatalf("Should have failed for empty channel name")
 	}
 
 	// Test empty request
-	err = rc.UpgradeCC("mychannel", req)
+	_, err = rc.UpgradeCC("mychannel", req)
 	if err == nil {
 		t.Fatalf("Should have failed for empty upgrade cc request")
 	}
 
 	// Test missing chaincode ID
 	req = UpgradeCCRequest{Name: "", Version: "v0", Path: "path"}
-	err = rc.UpgradeCC("mychannel", req)
+	_, err = rc.UpgradeCC("mychannel", req)
 	if err == nil {
 		t.Fatalf("Should have failed for empty cc name")
 	}
 
 	// Test missing chaincode version
 	req = UpgradeCCRequest{Name: "ID", Version: "", Path: "path"}
-	err = rc.UpgradeCC("mychannel", req)
+	_, err = rc.UpgradeCC("mychannel", req)
 	if err == nil {
 		t.Fatalf("Should have failed for empty cc version")
 	}
 
 	// Test missing chaincode path
 	req = UpgradeCCRequest{Name: "ID", Version: "v0", Path: ""}
-	err = rc.UpgradeCC("mychannel", req)
+	_, err = rc.UpgradeCC("mychannel", req)
 	if err == nil {
 		t.Fatalf("UpgradeCC should have failed for empty cc path")
 	}
 
 	// Test missing chaincode policy
 	req = UpgradeCCRequest{Name: "ID", Version: "v0", Path: "path"}
-	err = rc.UpgradeCC("mychannel", req)
+	_, err = rc.UpgradeCC("mychannel", req)
 	if err == nil {
 		t.Fatalf("UpgradeCC should have failed for missing chaincode policy")
 	}
@@ -945,7 +1257,7 @@ func TestUpgradeCCWithOptsRequiredParameters(t *testing.T) {
 	peers = append(peers, &peer)
 
 	// Test both targets and filter provided (error condition)
-	err = rc.UpgradeCC("mychannel", req, WithTargets(peers...), WithTargetFilter(&MSPFilter{mspID: "Org1MSP"}))
+	_, err = rc.UpgradeCC("mychannel", req, WithTargets(peers...), WithTargetFilter(&MSPFilter{mspID: "Org1MSP"}))
 	if err == nil {
 		t.Fatalf("Should have failed if both target and filter provided")
 	}
@@ -959,13 +1271,13 @@ func TestUpgradeCCWithOptsRequiredParameters(t *testing.T) {
 	rc = setupResMgmtClient(ctx, nil, t)
 
 	// No targets and no filter -- default filter msp doesn't match discovery service peer msp
-	err = rc.UpgradeCC("mychannel", req)
+	_, err = rc.UpgradeCC("mychannel", req)
 	if err == nil {
 		t.Fatalf("Should have failed with no targets error")
 	}
 
 	// Test filter only provided (filter rejects discovery service peer msp)
-	err = rc.UpgradeCC("mychannel", req, WithTargetFilter(&MSPFilter{mspID: "Org2MSP"}))
+	_, err = rc.UpgradeCC("mychannel", req, WithTargetFilter(&MSPFilter{mspID: "Org2MSP"}))
 	if err == nil {
 		t.Fatalf("Should have failed with no targets since filter rejected all discovery targets")
 	}
@@ -986,26 +1298,26 @@ func TestUpgradeCCDiscoveryError(t *testing.T) {
 	req := UpgradeCCRequest{Name: "name", Version: "version", Path: "path", Policy: ccPolicy}
 
 	// Test error while creating discovery service for channel "error"
-	err := rc.UpgradeCC("error", req)
+	_, err := rc.UpgradeCC("error", req)
 	if err == nil {
 		t.Fatalf("Should have failed to upgrade cc with discovery error")
 	}
 
 	// Test error in discovery service while getting peers
-	err = rc.UpgradeCC("mychannel", req)
+	_, err = rc.UpgradeCC("mychannel", req)
 	if err == nil {
 		t.Fatalf("Should have failed to upgrade cc with discovery error")
 	}
 
 	// Test UpgradeCCWithOpts discovery service error when creating discovery service for channel 'error'
-	err = rc.UpgradeCC("error", req)
+	_, err = rc.UpgradeCC("error", req)
 	if err == nil {
 		t.Fatalf("Should have failed to upgrade cc with opts with discovery error")
 	}
 
 	// Test UpgradeCCWithOpts discovery service error
 	// if targets are not provided discovery service is used to get targets
-	err = rc.UpgradeCC("mychannel", req)
+	_, err = rc.UpgradeCC("mychannel", req)
 	if err == nil {
 		t.Fatalf("Should have failed to upgrade cc with opts with discovery error")
 	}
@@ -1054,6 +1366,7 @@ func TestCCProposal(t *testing.T) {
 		Orderers:  []fab.Orderer{orderer},
 	}
 	rc.channelProvider.(*fcmocks.MockChannelProvider).SetTransactor(&transactor)
+	rc.channelProvider.(*fcmocks.MockChannelProvider).SetLedger(setupLedger(ctx, t))
 
 	ccPolicy := cauthdsl.SignedByMspMember("Org1MSP")
 	instantiateReq := InstantiateCCRequest{Name: "name", Version: "version", Path: "path", Policy: ccPolicy}
@@ -1061,13 +1374,13 @@ func TestCCProposal(t *testing.T) {
 	// Test failed proposal error handling (endorser returns an error)
 	endorserServer.ProposalError = errors.New("Test Error")
 
-	err = rc.InstantiateCC("mychannel", instantiateReq, WithTargets(peers...))
+	_, err = rc.InstantiateCC("mychannel", instantiateReq, WithTargets(peers...))
 	if err == nil {
 		t.Fatalf("Should have failed to instantiate cc due to endorser error")
 	}
 
 	upgradeRequest := UpgradeCCRequest{Name: "name", Version: "version", Path: "path", Policy: ccPolicy}
-	err = rc.UpgradeCC("mychannel", upgradeRequest, WithTargets(peers...))
+	_, err = rc.UpgradeCC("mychannel", upgradeRequest, WithTargets(peers...))
 	if err == nil {
 		t.Fatalf("Should have failed to upgrade cc due to endorser error")
 	}
@@ -1076,7 +1389,7 @@ func TestCCProposal(t *testing.T) {
 	endorserServer.ProposalError = nil
 
 	// Test error connecting to event hub
-	err = rc.InstantiateCC("mychannel", instantiateReq)
+	_, err = rc.InstantiateCC("mychannel", instantiateReq)
 	if err == nil {
 		t.Fatalf("Should have failed to get event hub since not setup")
 	}
@@ -1089,13 +1402,13 @@ func TestCCProposal(t *testing.T) {
 	defer eventServer.Stop()
 
 	// Test error in commit
-	err = rc.InstantiateCC("mychannel", instantiateReq)
+	_, err = rc.InstantiateCC("mychannel", instantiateReq)
 	if err == nil {
 		t.Fatalf("Should have failed due to error in commit")
 	}
 
 	// Test invalid function (only 'instatiate' and 'upgrade' are supported)
-	err = rc.sendCCProposal(3, "mychannel", instantiateReq, WithTargets(peers...))
+	_, err = rc.sendCCProposal(3, "mychannel", instantiateReq, WithTargets(peers...))
 	if err == nil {
 		t.Fatalf("Should have failed for invalid function name")
 	}
@@ -1107,12 +1420,243 @@ func TestCCProposal(t *testing.T) {
 	}
 	ctx.SetConfig(cfg)
 	rc = setupResMgmtClient(ctx, nil, t, getDefaultTargetFilterOption())
-	err = rc.InstantiateCC("mychannel", instantiateReq)
+	_, err = rc.InstantiateCC("mychannel", instantiateReq)
 	if err == nil {
 		t.Fatalf("Should have failed since no event source has been configured")
 	}
 }
 
+func TestInstantiateCCCommit(t *testing.T) {
+
+	ctx := setupTestContext("Admin", "Org1MSP")
+	ctx.SetConfig(getNetworkConfig(t))
+	rc := setupResMgmtClient(ctx, nil, t)
+
+	orderer := fcmocks.NewMockOrderer("", nil)
+	transactor := txnmocks.MockTransactor{
+		Ctx:       ctx,
+		ChannelID: "mychannel",
+		Orderers:  []fab.Orderer{orderer},
+	}
+	eventHub := fcmocks.NewMockEventHub()
+	chProvider := rc.channelProvider.(*fcmocks.MockChannelProvider)
+	chProvider.SetTransactor(&transactor)
+	chProvider.SetEventHub(eventHub)
+	chProvider.SetLedger(setupLedger(ctx, t))
+
+	peer1 := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	peer1.Status = 200
+	peer1.Payload = instantiatedChaincodesPayload(t, &pb.ChaincodeInfo{Name: "name", Version: "v0", Path: "path"})
+	ccPolicy := cauthdsl.SignedByMspMember("Org1MSP")
+	req := InstantiateCCRequest{Name: "name", Version: "version", Path: "path", Policy: ccPolicy}
+
+	commit := func(code pb.TxValidationCode) {
+		select {
+		case callback := <-eventHub.RegisteredTxCallbacks:
+			callback("txid", code, nil)
+		case <-time.After(5 * time.Second):
+			t.Error("Timed out waiting for instantiate cc to register for the commit")
+		}
+	}
+
+	// Instantiate waits for the commit
+	go commit(pb.TxValidationCode_VALID)
+	resp, err := rc.InstantiateCC("mychannel", req, WithTargets(peer1))
+	if err != nil {
+		t.Fatalf("InstantiateCC failed: %s", err)
+	}
+	if resp.TransactionID == "" || resp.TxValidationCode != pb.TxValidationCode_VALID {
+		t.Fatalf("Unexpected response: %v", resp)
+	}
+	if len(resp.Responses) != 1 || resp.Responses[0].Endorser != peer1.MockURL {
+		t.Fatalf("Expecting the endorsement of %s, got %v", peer1.MockURL, resp.Responses)
+	}
+
+	// Instantiate with the policy in string form
+	go commit(pb.TxValidationCode_VALID)
+	_, err = rc.InstantiateCC("mychannel", InstantiateCCRequest{Name: "name", Version: "version", Path: "path", PolicyString: "OR('Org1MSP.member', 'Org2MSP.member')"}, WithTargets(peer1))
+	if err != nil {
+		t.Fatalf("InstantiateCC with policy string failed: %s", err)
+	}
+
+	// Invalid transaction
+	go commit(pb.TxValidationCode_MVCC_READ_CONFLICT)
+	upgradeResp, err := rc.UpgradeCC("mychannel", UpgradeCCRequest(req), WithTargets(peer1))
+	if err == nil {
+		t.Fatal("Should have failed since the transaction is invalid")
+	}
+	if upgradeResp.TxValidationCode != pb.TxValidationCode_MVCC_READ_CONFLICT {
+		t.Fatalf("Expecting validation code %s, got %s", pb.TxValidationCode_MVCC_READ_CONFLICT, upgradeResp.TxValidationCode)
+	}
+	s, ok := status.FromError(err)
+	if !ok || status.ToTransactionValidationCode(s.Code) != pb.TxValidationCode_MVCC_READ_CONFLICT {
+		t.Fatalf("Expecting status error with the validation code, got %v", err)
+	}
+
+	// The commit isn't received in time
+	_, err = rc.InstantiateCC("mychannel", req, WithTargets(peer1), WithTimeout(50*time.Millisecond))
+	if err == nil || !strings.Contains(err.Error(), "timeout") {
+		t.Fatalf("Should have timed out waiting for the commit: %v", err)
+	}
+	<-eventHub.RegisteredTxCallbacks
+}
+
+func TestCCPolicyString(t *testing.T) {
+
+	rc := setupDefaultResMgmtClient(t)
+	ccPolicy := cauthdsl.SignedByMspMember("Org1MSP")
+
+	_, err := rc.InstantiateCC("mychannel", InstantiateCCRequest{Name: "name", Version: "version", Path: "path", Policy: ccPolicy, PolicyString: "AND('Org1MSP.member')"})
+	if err == nil {
+		t.Fatal("Should have failed since both the policy and the policy string are provided")
+	}
+
+	_, err = rc.UpgradeCC("mychannel", UpgradeCCRequest{Name: "name", Version: "version", Path: "path", PolicyString: "AND('Org1MSP.member'"})
+	if err == nil || !strings.Contains(err.Error(), "parse of chaincode policy failed") {
+		t.Fatalf("Should have failed to parse the policy string: %v", err)
+	}
+
+	envelope, err := chaincodePolicy(InstantiateCCRequest{PolicyString: "AND('Org1MSP.member')"})
+	if err != nil {
+		t.Fatalf("Failed to parse policy string: %s", err)
+	}
+	expected, err := policy.FromString("AND('Org1MSP.member')")
+	if err != nil || !proto.Equal(expected, envelope) {
+		t.Fatalf("Expecting the policy of the policy string")
+	}
+
+	envelope, err = chaincodePolicy(InstantiateCCRequest{Policy: ccPolicy})
+	if err != nil || envelope != ccPolicy {
+		t.Fatalf("Expecting the policy of the request")
+	}
+}
+
+func TestUpgradeCCVersion(t *testing.T) {
+
+	ctx := setupTestContext("Admin", "Org1MSP")
+	ctx.SetConfig(getNetworkConfig(t))
+	rc := setupResMgmtClient(ctx, nil, t)
+	rc.channelProvider.(*fcmocks.MockChannelProvider).SetLedger(setupLedger(ctx, t))
+
+	peer1 := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	peer1.Status = 200
+	peer1.Payload = instantiatedChaincodesPayload(t, &pb.ChaincodeInfo{Name: "name", Version: "v1", Path: "path"})
+
+	ccPolicy := cauthdsl.SignedByMspMember("Org1MSP")
+
+	_, err := rc.UpgradeCC("mychannel", UpgradeCCRequest{Name: "name", Version: "v1", Path: "path", Policy: ccPolicy}, WithTargets(peer1))
+	if err == nil || !strings.Contains(err.Error(), "already instantiated on channel mychannel with version v1") {
+		t.Fatalf("Should have failed since the version is already instantiated: %v", err)
+	}
+	if peer1.ProcessProposalCalls != 1 {
+		t.Fatalf("Expecting the upgrade proposal not to be sent, got %d proposals", peer1.ProcessProposalCalls)
+	}
+
+	_, err = rc.UpgradeCC("mychannel", UpgradeCCRequest{Name: "other", Version: "v2", Path: "path", Policy: ccPolicy}, WithTargets(peer1))
+	if err == nil || !strings.Contains(err.Error(), "chaincode other is not instantiated on channel mychannel") {
+		t.Fatalf("Should have failed since the chaincode isn't instantiated: %v", err)
+	}
+}
+
+func TestQueryChaincodes(t *testing.T) {
+
+	ctx := setupTestContext("Admin", "Org1MSP")
+	ctx.SetConfig(getNetworkConfig(t))
+	rc := setupResMgmtClient(ctx, nil, t)
+	rc.resource = resource.New(ctx)
+	rc.channelProvider.(*fcmocks.MockChannelProvider).SetLedger(setupLedger(ctx, t))
+
+	chaincode := &pb.ChaincodeInfo{Name: "name", Version: "v0", Path: "path", Id: []byte("id")}
+	peer1 := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	peer1.Status = 200
+	peer1.Payload = instantiatedChaincodesPayload(t, chaincode)
+	expected := []ChaincodeInfo{{Name: "name", Version: "v0", Path: "path", ID: []byte("id")}}
+
+	installed, err := rc.QueryInstalledChaincodes(peer1)
+	if err != nil {
+		t.Fatalf("QueryInstalledChaincodes failed: %s", err)
+	}
+	if !reflect.DeepEqual(expected, installed) {
+		t.Fatalf("Expecting installed chaincodes %v, got %v", expected, installed)
+	}
+
+	instantiated, err := rc.QueryInstantiatedChaincodes("mychannel", peer1)
+	if err != nil {
+		t.Fatalf("QueryInstantiatedChaincodes failed: %s", err)
+	}
+	if !reflect.DeepEqual(expected, instantiated) {
+		t.Fatalf("Expecting instantiated chaincodes %v, got %v", expected, instantiated)
+	}
+
+	_, err = rc.QueryInstantiatedChaincodes("", peer1)
+	if err == nil {
+		t.Fatal("Should have failed for empty channel name")
+	}
+
+	// The peer rejects queries of identities that aren't admins
+	peer2 := fcmocks.NewMockPeer("Peer2", "http://peer2.com")
+	peer2.Status = int32(common.Status_FORBIDDEN)
+	peer2.ResponseMessage = "Authorization for GETINSTALLEDCHAINCODES on channel getinstalledchaincodes has been denied with error Failed verifying that proposal's creator satisfies local MSP principal during channelless check policy with policy [Admins]"
+	_, err = rc.QueryInstalledChaincodes(peer2)
+	accessDenied, ok := errors.Cause(err).(*AccessDeniedError)
+	if !ok || accessDenied.Target != peer2.MockURL {
+		t.Fatalf("Expecting AccessDeniedError from %s, got %v", peer2.MockURL, err)
+	}
+
+	peer2.ResponseMessage = "access denied for [getchaincodes][mychannel]: Failed to authorize"
+	_, err = rc.QueryInstantiatedChaincodes("mychannel", peer2)
+	if _, ok := errors.Cause(err).(*AccessDeniedError); !ok {
+		t.Fatalf("Expecting AccessDeniedError, got %v", err)
+	}
+
+	// Other failures aren't reported as access denied, even if their message mentions it
+	peer2.Status = int32(common.Status_INTERNAL_SERVER_ERROR)
+	peer2.ResponseMessage = "chaincode query failed: access denied"
+	_, err = rc.QueryInstantiatedChaincodes("mychannel", peer2)
+	if err == nil || !strings.Contains(err.Error(), "chaincode query failed") {
+		t.Fatalf("Expecting error with the message of the peer, got %v", err)
+	}
+	if _, ok := errors.Cause(err).(*AccessDeniedError); ok {
+		t.Fatal("Should not have returned AccessDeniedError for other errors")
+	}
+}
+
+func TestQueryError(t *testing.T) {
+	peer1 := fcmocks.NewMockPeer("Peer1", "http://peer1.com")
+	tests := []struct {
+		err          error
+		accessDenied bool
+	}{
+		{status.New(status.EndorserServerStatus, int32(common.Status_FORBIDDEN), "forbidden", nil), true},
+		{errors.WithMessage(status.NewFromGRPCStatus(grpcstatus.New(grpccodes.PermissionDenied, "denied")), "query failed"), true},
+		{status.New(status.EndorserServerStatus, int32(common.Status_INTERNAL_SERVER_ERROR), "access denied", nil), false},
+		{status.NewFromGRPCStatus(grpcstatus.New(grpccodes.Unavailable, "unavailable")), false},
+		{errors.New("access denied"), false},
+	}
+	for _, test := range tests {
+		_, accessDenied := queryError(test.err, peer1, "query failed").(*AccessDeniedError)
+		if accessDenied != test.accessDenied {
+			t.Errorf("expected access denied=%t for error [%s]", test.accessDenied, test.err)
+		}
+	}
+}
+
+func setupLedger(ctx context.Context, t *testing.T) fab.ChannelLedger {
+	ledger, err := channel.NewLedger(ctx, "mychannel")
+	if err != nil {
+		t.Fatalf("Failed to create ledger: %s", err)
+	}
+	return ledger
+}
+
+func instantiatedChaincodesPayload(t *testing.T, chaincodes ...*pb.ChaincodeInfo) []byte {
+	payload, err := proto.Marshal(&pb.ChaincodeQueryResponse{Chaincodes: chaincodes})
+	if err != nil {
+		t.Fatalf("Failed to marshal chaincode query response: %s", err)
+	}
+	return payload
+}
+
 func getDefaultTargetFilterOption() ClientOption {
 	targetFilter := &MSPFilter{mspID: "Org1MSP"}
 	return WithDefaultTargetFilter(targetFilter)
@@ -1209,41 +1753,174 @@ func TestSaveChannel(t *testing.T) {
 	cc := setupDefaultResMgmtClient(t)
 
 	// Test empty channel request
-	err := cc.SaveChannel(SaveChannelRequest{})
+	_, err := cc.SaveChannel(SaveChannelRequest{})
 	if err == nil {
 		t.Fatalf("Should have failed for empty channel request")
 	}
 
 	// Test empty channel name
-	err = cc.SaveChannel(SaveChannelRequest{ChannelID: "", ChannelConfig: channelConfig})
+	_, err = cc.SaveChannel(SaveChannelRequest{ChannelID: "", ChannelConfigPath: channelConfig})
 	if err == nil {
 		t.Fatalf("Should have failed for empty channel id")
 	}
 
 	// Test empty channel config
-	err = cc.SaveChannel(SaveChannelRequest{ChannelID: "mychannel", ChannelConfig: ""})
+	_, err = cc.SaveChannel(SaveChannelRequest{ChannelID: "mychannel", ChannelConfigPath: ""})
 	if err == nil {
 		t.Fatalf("Should have failed for empty channel config")
 	}
 
 	// Test extract configuration error
-	err = cc.SaveChannel(SaveChannelRequest{ChannelID: "mychannel", ChannelConfig: "./testdata/extractcherr.tx"})
+	_, err = cc.SaveChannel(SaveChannelRequest{ChannelID: "mychannel", ChannelConfigPath: "./testdata/extractcherr.tx"})
 	if err == nil {
 		t.Fatalf("Should have failed to extract configuration")
 	}
 
+	// Test configuration without config update
+	_, err = cc.SaveChannel(SaveChannelRequest{ChannelID: "mychannel", ChannelConfigPath: "./testdata/test.tx"})
+	if err == nil {
+		t.Fatalf("Should have failed to extract configuration without config update")
+	}
+
 	// Test sign channel error
-	err = cc.SaveChannel(SaveChannelRequest{ChannelID: "mychannel", ChannelConfig: "./testdata/signcherr.tx"})
+	_, err = cc.SaveChannel(SaveChannelRequest{ChannelID: "mychannel", ChannelConfigPath: "./testdata/signcherr.tx"})
 	if err == nil {
 		t.Fatalf("Should have failed to sign configuration")
 	}
 
+	// Test configuration of another channel
+	_, err = cc.SaveChannel(SaveChannelRequest{ChannelID: "orgchannel", ChannelConfigPath: channelConfig})
+	if err == nil || !strings.Contains(err.Error(), "channel config is for channel mychannel, not orgchannel") {
+		t.Fatalf("Should have failed for configuration of another channel: %v", err)
+	}
+
 	// Test valid Save Channel request (success)
-	err = cc.SaveChannel(SaveChannelRequest{ChannelID: "mychannel", ChannelConfig: channelConfig})
+	_, err = cc.SaveChannel(SaveChannelRequest{ChannelID: "mychannel", ChannelConfigPath: channelConfig})
 	if err != nil {
 		t.Fatal(err)
 	}
 
+	// Test valid Save Channel request with configuration reader (success)
+	configTx, err := ioutil.ReadFile(channelConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = cc.SaveChannel(SaveChannelRequest{ChannelID: "mychannel", ChannelConfig: bytes.NewReader(configTx)})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// recordingResource records the create channel requests
+type recordingResource struct {
+	api.Resource
+	requests        []api.CreateChannelRequest
+	installRequests []api.InstallChaincodeRequest
+}
+
+func (r *recordingResource) CreateChannel(request api.CreateChannelRequest) (fab.TransactionID, error) {
+	r.requests = append(r.requests, request)
+	return "1234", nil
+}
+
+func (r *recordingResource) InstallChaincode(request api.InstallChaincodeRequest) ([]*fab.TransactionProposalResponse, fab.TransactionID, error) {
+	r.installRequests = append(r.installRequests, request)
+	return r.Resource.InstallChaincode(request)
+}
+
+func TestSaveChannelSignatures(t *testing.T) {
+
+	cc := setupDefaultResMgmtClient(t)
+	recorder := &recordingResource{Resource: cc.resource}
+	cc.resource = recorder
+
+	org1Admin := setupTestContext("Admin", "Org1MSP")
+	org2Admin := setupTestContext("Admin", "Org2MSP")
+
+	for _, configPath := range []string{channelConfig, channelUpdateConfig} {
+		recorder.requests = nil
+
+		resp, err := cc.SaveChannel(SaveChannelRequest{ChannelID: "mychannel", ChannelConfigPath: configPath, SigningIdentities: []context.IdentityContext{org1Admin, org2Admin}})
+		if err != nil {
+			t.Fatalf("SaveChannel failed: %s", err)
+		}
+		if resp.TransactionID != "1234" {
+			t.Fatalf("Expecting transaction ID 1234, got %s", resp.TransactionID)
+		}
+		if len(recorder.requests) != 1 {
+			t.Fatalf("Expecting one create channel request, got %d", len(recorder.requests))
+		}
+		request := recorder.requests[0]
+
+		configTx, err := ioutil.ReadFile(configPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		configUpdate, err := resource.ExtractChannelConfig(configTx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if request.Name != "mychannel" || !bytes.Equal(request.Config, configUpdate) {
+			t.Fatalf("Expecting the config update of the configuration for mychannel")
+		}
+
+		// The config update is signed by each of the signing identities
+		if len(request.Signatures) != 2 {
+			t.Fatalf("Expecting 2 signatures, got %d", len(request.Signatures))
+		}
+		for i, signer := range []context.IdentityContext{org1Admin, org2Admin} {
+			signatureHeader := &common.SignatureHeader{}
+			if err := proto.Unmarshal(request.Signatures[i].SignatureHeader, signatureHeader); err != nil {
+				t.Fatalf("Failed to unmarshal signature header: %s", err)
+			}
+			creator, err := signer.Identity()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(creator, signatureHeader.Creator) || len(signatureHeader.Nonce) == 0 {
+				t.Fatalf("Expecting signature %d to be created by signing identity %d", i, i)
+			}
+			if len(request.Signatures[i].Signature) == 0 {
+				t.Fatalf("Expecting signature %d to be signed", i)
+			}
+		}
+	}
+
+	// The client's identity signs if there are no signing identities
+	_, err := cc.SaveChannel(SaveChannelRequest{ChannelID: "mychannel", ChannelConfigPath: channelConfig})
+	if err != nil {
+		t.Fatalf("SaveChannel failed: %s", err)
+	}
+	signatures := recorder.requests[len(recorder.requests)-1].Signatures
+	signatureHeader := &common.SignatureHeader{}
+	if len(signatures) != 1 || proto.Unmarshal(signatures[0].SignatureHeader, signatureHeader) != nil {
+		t.Fatalf("Expecting the signature of the client's identity")
+	}
+	creator, err := cc.identity.Identity()
+	if err != nil || !bytes.Equal(creator, signatureHeader.Creator) {
+		t.Fatalf("Expecting the signature of the client's identity")
+	}
+}
+
+func TestIsChannelCreation(t *testing.T) {
+
+	for configPath, expected := range map[string]bool{channelConfig: true, channelUpdateConfig: false} {
+		configTx, err := ioutil.ReadFile(configPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		configUpdate, err := resource.ExtractChannelConfig(configTx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		create, err := isChannelCreation("mychannel", configUpdate)
+		if err != nil {
+			t.Fatalf("isChannelCreation failed: %s", err)
+		}
+		if create != expected {
+			t.Fatalf("Expecting channel creation %t for %s, got %t", expected, configPath, create)
+		}
+	}
 }
 
 func TestSaveChannelFailure(t *testing.T) {
@@ -1276,7 +1953,7 @@ func TestSaveChannelFailure(t *testing.T) {
 	}
 
 	// Test create channel failure
-	err = cc.SaveChannel(SaveChannelRequest{ChannelID: "mychannel", ChannelConfig: channelConfig})
+	_, err = cc.SaveChannel(SaveChannelRequest{ChannelID: "mychannel", ChannelConfigPath: channelConfig})
 	if err == nil {
 		t.Fatal("Should have failed with create channel error")
 	}
@@ -1288,25 +1965,25 @@ func TestSaveChannelWithOpts(t *testing.T) {
 	cc := setupDefaultResMgmtClient(t)
 
 	// Valid request (same for all options)
-	req := SaveChannelRequest{ChannelID: "mychannel", ChannelConfig: channelConfig}
+	req := SaveChannelRequest{ChannelID: "mychannel", ChannelConfigPath: channelConfig}
 
 	// Test empty option (default order is random orderer from config)
 	opts := WithOrdererID("")
-	err := cc.SaveChannel(req, opts)
+	_, err := cc.SaveChannel(req, opts)
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	// Test valid orderer ID
 	opts = WithOrdererID("orderer.example.com")
-	err = cc.SaveChannel(req, opts)
+	_, err = cc.SaveChannel(req, opts)
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	// Test invalid orderer ID
 	opts = WithOrdererID("Invalid")
-	err = cc.SaveChannel(req, opts)
+	_, err = cc.SaveChannel(req, opts)
 	if err == nil {
 		t.Fatal("Should have failed for invalid orderer ID")
 	}
@@ -0,0 +1,170 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package resmgmt
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	config "github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/txn"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultProbeBackoffInitial = 500 * time.Millisecond
+	defaultProbeBackoffMax     = 5 * time.Second
+)
+
+// WarmCCResponse contains the readiness of a chaincode on a target peer
+type WarmCCResponse struct {
+	Target string
+	// Ready is true if a probe succeeded before the deadline
+	Ready bool
+	// Attempts is the number of probes that were sent to the target
+	Attempts int
+	// Latency is the time from the first probe until the target responded successfully
+	// (i.e. the cold-start latency of the chaincode container) or until the target gave up
+	Latency time.Duration
+	// Err is the error from the last probe if the target isn't ready
+	Err error
+}
+
+// WarmChaincode sends a query proposal for the given no-op probe function to each target peer concurrently
+// so that the peers start the chaincode container before taking traffic. Each target is probed until it
+// responds successfully or the deadline (see WithTimeout) is reached, backing off between probes (see
+// WithProbeBackoff). The probe is a query by construction: the proposal responses are discarded and no
+// transaction is ever sent to the orderer. If no targets are provided then the default targets are used.
+// A response is returned for each target and an error is returned if any of the targets isn't ready.
+func (rc *Client) WarmChaincode(channelID, ccID string, targets []fab.Peer, probeFcn string, options ...RequestOption) ([]WarmCCResponse, error) {
+	if channelID == "" {
+		return nil, errors.New("must provide channel ID")
+	}
+	if ccID == "" || probeFcn == "" {
+		return nil, errors.New("chaincode ID and probe function are required")
+	}
+
+	opts, err := rc.prepareResmgmtOpts(options...)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get opts for warm chaincode")
+	}
+
+	if len(targets) == 0 {
+		discovery, err := rc.discoveryProvider.NewDiscoveryService(channelID)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to create channel discovery service")
+		}
+		targets, err = rc.getDefaultTargets(discovery)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to get default targets for warm chaincode")
+		}
+		if len(targets) == 0 {
+			return nil, errors.New("No targets available for warm chaincode")
+		}
+	}
+
+	timeout := rc.provider.Config().Timeout(config.Execute)
+	if opts.Timeout != 0 {
+		timeout = opts.Timeout
+	}
+	deadline := time.Now().Add(timeout)
+
+	request := fab.ChaincodeInvokeRequest{
+		ChaincodeID: ccID,
+		Fcn:         probeFcn,
+	}
+
+	responses := make([]WarmCCResponse, len(targets))
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		go func(i int, target fab.Peer) {
+			defer wg.Done()
+			responses[i] = rc.warmTarget(channelID, request, target, deadline, opts)
+		}(i, target)
+	}
+	wg.Wait()
+
+	var notReady int
+	for _, response := range responses {
+		if !response.Ready {
+			notReady++
+		}
+	}
+	if notReady > 0 {
+		return responses, errors.Errorf("chaincode [%s] is not ready on %d of %d targets", ccID, notReady, len(targets))
+	}
+	return responses, nil
+}
+
+// warmTarget probes the target until it responds successfully or the deadline is reached
+func (rc *Client) warmTarget(channelID string, request fab.ChaincodeInvokeRequest, target fab.Peer, deadline time.Time, opts Opts) WarmCCResponse {
+	delay := opts.ProbeBackoffInitial
+	if delay <= 0 {
+		delay = defaultProbeBackoffInitial
+	}
+	maxDelay := opts.ProbeBackoffMax
+	if maxDelay <= 0 {
+		maxDelay = defaultProbeBackoffMax
+	}
+
+	response := WarmCCResponse{Target: target.URL()}
+	start := time.Now()
+	for {
+		response.Attempts++
+		response.Err = rc.probe(channelID, request, target)
+		response.Latency = time.Since(start)
+		if response.Err == nil {
+			response.Ready = true
+			logger.Debugf("Chaincode [%s] is ready on [%s] after %d probes (%s)", request.ChaincodeID, response.Target, response.Attempts, response.Latency)
+			return response
+		}
+
+		logger.Debugf("Probe %d of chaincode [%s] on [%s] failed: %s", response.Attempts, request.ChaincodeID, response.Target, response.Err)
+
+		if time.Now().Add(delay).After(deadline) {
+			logger.Warnf("Chaincode [%s] is not ready on [%s] after %d probes: %s", request.ChaincodeID, response.Target, response.Attempts, response.Err)
+			return response
+		}
+		time.Sleep(delay)
+
+		delay *= 2
+		if delay > maxDelay {
+			delay = maxDelay
+		}
+	}
+}
+
+// probe sends a query proposal to the target. The proposal response is only checked for success.
+func (rc *Client) probe(channelID string, request fab.ChaincodeInvokeRequest, target fab.Peer) error {
+	probeCtx := fabContext{
+		ProviderContext: rc.provider,
+		IdentityContext: rc.identity,
+	}
+
+	txh, err := txn.NewHeader(&probeCtx, channelID)
+	if err != nil {
+		return errors.WithMessage(err, "create transaction ID failed")
+	}
+
+	tp, err := txn.CreateChaincodeInvokeProposal(txh, request)
+	if err != nil {
+		return errors.WithMessage(err, "creating probe proposal failed")
+	}
+
+	tpr, err := txn.SendProposal(&probeCtx, tp, []fab.ProposalProcessor{target})
+	if err != nil {
+		return errors.WithMessage(err, "sending probe proposal failed")
+	}
+
+	if tpr[0].Status != http.StatusOK {
+		return errors.Errorf("bad status from %s (%d): %s", tpr[0].Endorser, tpr[0].Status, tpr[0].GetResponse().GetMessage())
+	}
+	return nil
+}
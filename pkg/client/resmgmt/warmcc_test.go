@@ -0,0 +1,157 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package resmgmt
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+	"github.com/pkg/errors"
+)
+
+// coldPeer is a mock peer that fails the first N probes, e.g. while the chaincode container is starting
+type coldPeer struct {
+	*fcmocks.MockPeer
+	mutex    sync.Mutex
+	failures int
+	fcns     []string
+}
+
+func newColdPeer(url string, failures int) *coldPeer {
+	return &coldPeer{MockPeer: fcmocks.NewMockPeer(url, url), failures: failures}
+}
+
+func (p *coldPeer) ProcessTransactionProposal(request fab.ProcessProposalRequest) (*fab.TransactionProposalResponse, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	fcn, err := proposalFcn(request.SignedProposal)
+	if err != nil {
+		return nil, err
+	}
+	p.fcns = append(p.fcns, fcn)
+
+	if p.failures > 0 {
+		p.failures--
+		return &fab.TransactionProposalResponse{
+			Endorser:         p.URL(),
+			Status:           http.StatusInternalServerError,
+			ProposalResponse: &pb.ProposalResponse{Response: &pb.Response{Status: http.StatusInternalServerError, Message: "chaincode container starting"}},
+		}, nil
+	}
+	return p.MockPeer.ProcessTransactionProposal(request)
+}
+
+func (p *coldPeer) probes() []string {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.fcns
+}
+
+// proposalFcn returns the chaincode function invoked by the signed proposal
+func proposalFcn(signedProposal *pb.SignedProposal) (string, error) {
+	proposal := &pb.Proposal{}
+	if err := proto.Unmarshal(signedProposal.ProposalBytes, proposal); err != nil {
+		return "", errors.Wrap(err, "unmarshal proposal failed")
+	}
+	payload := &pb.ChaincodeProposalPayload{}
+	if err := proto.Unmarshal(proposal.Payload, payload); err != nil {
+		return "", errors.Wrap(err, "unmarshal proposal payload failed")
+	}
+	spec := &pb.ChaincodeInvocationSpec{}
+	if err := proto.Unmarshal(payload.Input, spec); err != nil {
+		return "", errors.Wrap(err, "unmarshal invocation spec failed")
+	}
+	if len(spec.ChaincodeSpec.Input.Args) == 0 {
+		return "", errors.New("no function in proposal")
+	}
+	return string(spec.ChaincodeSpec.Input.Args[0]), nil
+}
+
+func TestWarmChaincode(t *testing.T) {
+	rc := setupDefaultResMgmtClient(t)
+
+	warm := newColdPeer("http://peer1.com", 0)
+	cold := newColdPeer("http://peer2.com", 3)
+
+	responses, err := rc.WarmChaincode("mychannel", "examplecc", []fab.Peer{warm, cold}, "ping",
+		WithProbeBackoff(10*time.Millisecond, 20*time.Millisecond), WithTimeout(5*time.Second))
+	if err != nil {
+		t.Fatalf("error warming chaincode: %s", err)
+	}
+	if len(responses) != 2 {
+		t.Fatalf("expecting 2 responses but got %d", len(responses))
+	}
+
+	checkWarmResponse(t, responses[0], warm.URL(), true, 1)
+	checkWarmResponse(t, responses[1], cold.URL(), true, 4)
+
+	// Delays of 10ms, 20ms and 20ms between the failed probes
+	if responses[1].Latency < 50*time.Millisecond {
+		t.Fatalf("expecting cold-start latency of at least 50ms but got %s", responses[1].Latency)
+	}
+
+	// Only the probe function is invoked
+	for _, fcn := range cold.probes() {
+		if fcn != "ping" {
+			t.Fatalf("expecting probe function [ping] but got [%s]", fcn)
+		}
+	}
+}
+
+func TestWarmChaincodeNotReady(t *testing.T) {
+	rc := setupDefaultResMgmtClient(t)
+
+	warm := newColdPeer("http://peer1.com", 0)
+	down := newColdPeer("http://peer2.com", 1000)
+
+	responses, err := rc.WarmChaincode("mychannel", "examplecc", []fab.Peer{warm, down}, "ping",
+		WithProbeBackoff(10*time.Millisecond, 10*time.Millisecond), WithTimeout(100*time.Millisecond))
+	if err == nil {
+		t.Fatalf("expecting error since chaincode isn't ready on all targets")
+	}
+	if len(responses) != 2 {
+		t.Fatalf("expecting 2 responses but got %d", len(responses))
+	}
+
+	checkWarmResponse(t, responses[0], warm.URL(), true, 1)
+	if responses[1].Ready || responses[1].Err == nil || responses[1].Attempts < 2 {
+		t.Fatalf("expecting target to be retried and not ready but got %+v", responses[1])
+	}
+}
+
+func TestWarmChaincodeRequiredParameters(t *testing.T) {
+	rc := setupDefaultResMgmtClient(t)
+
+	if _, err := rc.WarmChaincode("", "examplecc", nil, "ping"); err == nil {
+		t.Fatalf("expecting error for missing channel ID")
+	}
+	if _, err := rc.WarmChaincode("mychannel", "", nil, "ping"); err == nil {
+		t.Fatalf("expecting error for missing chaincode ID")
+	}
+	if _, err := rc.WarmChaincode("mychannel", "examplecc", nil, ""); err == nil {
+		t.Fatalf("expecting error for missing probe function")
+	}
+}
+
+func checkWarmResponse(t *testing.T, response WarmCCResponse, expectedTarget string, expectedReady bool, expectedAttempts int) {
+	if response.Target != expectedTarget {
+		t.Fatalf("expecting target [%s] but got [%s]", expectedTarget, response.Target)
+	}
+	if response.Ready != expectedReady {
+		t.Fatalf("expecting ready [%t] for [%s] but got %+v", expectedReady, expectedTarget, response)
+	}
+	if response.Attempts != expectedAttempts {
+		t.Fatalf("expecting %d attempts for [%s] but got %d", expectedAttempts, expectedTarget, response.Attempts)
+	}
+}
@@ -0,0 +1,25 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"context"
+	"crypto/tls"
+
+	"google.golang.org/grpc"
+)
+
+// Connector dials the gRPC connections to the peers and orderers. A connector may share a connection
+// between the clients of the same target, so a connection that's returned by DialContext must not be
+// closed by the client but released with ReleaseConn once the client no longer uses it.
+type Connector interface {
+	// DialContext returns a connection to the target, secured with the given TLS config
+	// (the connection is insecure if the TLS config is nil)
+	DialContext(ctx context.Context, target string, tlsConfig *tls.Config, opts ...grpc.DialOption) (*grpc.ClientConn, error)
+	// ReleaseConn releases a connection that was returned by DialContext
+	ReleaseConn(conn *grpc.ClientConn)
+}
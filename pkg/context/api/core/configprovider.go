@@ -23,6 +23,7 @@ type Config interface {
 	CAClientCertPem(org string) (string, error)
 	CAClientCertPath(org string) (string, error)
 	TimeoutOrDefault(TimeoutType) time.Duration
+	Timeout(TimeoutType) time.Duration
 	MspID(org string) (string, error)
 	PeerMspID(name string) (string, error)
 	OrderersConfig() ([]OrdererConfig, error)
@@ -46,6 +47,7 @@ type Config interface {
 	SecurityProviderLibPath() string
 	SecurityProviderPin() string
 	SecurityProviderLabel() string
+	SecurityProviderSessionPool() SessionPoolConfig
 	KeyStorePath() string
 	CAKeyStorePath() string
 	CryptoConfigPath() string
@@ -56,24 +58,42 @@ type Config interface {
 // ConfigProvider enables creation of a Config instance
 type ConfigProvider func() (Config, error)
 
-// TimeoutType enumerates the different types of outgoing connections
+// ConfigUpdatedEvent is published to the listeners of a configuration when the configuration is reloaded
+type ConfigUpdatedEvent struct {
+	// Config is the configuration that was reloaded (the instance is unchanged)
+	Config Config
+}
+
+// ConfigListener is notified when a configuration is reloaded
+type ConfigListener func(event *ConfigUpdatedEvent)
+
+// TimeoutType enumerates the different types of timeouts. The default of each type is given
+// in brackets; a timeout that isn't configured (or that's configured as zero) has the default value.
 type TimeoutType int
 
 const (
-	// Endorser connection timeout
+	// Endorser connection timeout (5s)
 	Endorser TimeoutType = iota
-	// EventHubConnection connection timeout
+	// EventHubConnection connection timeout (5s)
 	EventHubConnection
-	// EventReg connection timeout
+	// EventReg connection timeout (5s)
 	EventReg
-	// Query timeout
+	// Query timeout (5s)
 	Query
-	// Execute timeout
+	// Execute timeout (5s)
 	Execute
-	// OrdererConnection orderer connection timeout
+	// OrdererConnection orderer connection timeout (5s)
 	OrdererConnection
-	// OrdererResponse orderer response timeout
+	// OrdererResponse orderer response timeout (5s)
 	OrdererResponse
-	// DiscoveryGreylistExpiry discovery Greylist expiration period
+	// DiscoveryGreylistExpiry discovery Greylist expiration period (5s)
 	DiscoveryGreylistExpiry
+	// EventServiceResponse timeout when waiting for a response from the event server (5s)
+	EventServiceResponse
+	// EventReconnectInitialDelay delay before the event client attempts to reconnect (0s)
+	EventReconnectInitialDelay
+	// EventConnectRetryInterval time between the event client's connection attempts (5s)
+	EventConnectRetryInterval
+	// ConnectionIdle time after which a cached connection that isn't used is closed (30s)
+	ConnectionIdle
 )
@@ -441,6 +441,18 @@ func (mr *MockConfigMockRecorder) SecurityProviderPin() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SecurityProviderPin", reflect.TypeOf((*MockConfig)(nil).SecurityProviderPin))
 }
 
+// SecurityProviderSessionPool mocks base method
+func (m *MockConfig) SecurityProviderSessionPool() core.SessionPoolConfig {
+	ret := m.ctrl.Call(m, "SecurityProviderSessionPool")
+	ret0, _ := ret[0].(core.SessionPoolConfig)
+	return ret0
+}
+
+// SecurityProviderSessionPool indicates an expected call of SecurityProviderSessionPool
+func (mr *MockConfigMockRecorder) SecurityProviderSessionPool() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SecurityProviderSessionPool", reflect.TypeOf((*MockConfig)(nil).SecurityProviderSessionPool))
+}
+
 // SetTLSCACertPool mocks base method
 func (m *MockConfig) SetTLSCACertPool(arg0 *x509.CertPool) {
 	m.ctrl.Call(m, "SetTLSCACertPool", arg0)
@@ -493,6 +505,18 @@ func (mr *MockConfigMockRecorder) TLSClientCerts() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TLSClientCerts", reflect.TypeOf((*MockConfig)(nil).TLSClientCerts))
 }
 
+// Timeout mocks base method
+func (m *MockConfig) Timeout(arg0 core.TimeoutType) time.Duration {
+	ret := m.ctrl.Call(m, "Timeout", arg0)
+	ret0, _ := ret[0].(time.Duration)
+	return ret0
+}
+
+// Timeout indicates an expected call of Timeout
+func (mr *MockConfigMockRecorder) Timeout(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Timeout", reflect.TypeOf((*MockConfig)(nil).Timeout), arg0)
+}
+
 // TimeoutOrDefault mocks base method
 func (m *MockConfig) TimeoutOrDefault(arg0 core.TimeoutType) time.Duration {
 	ret := m.ctrl.Call(m, "TimeoutOrDefault", arg0)
@@ -10,6 +10,7 @@ import (
 	"crypto/x509"
 	"encoding/pem"
 	"io/ioutil"
+	"time"
 
 	"github.com/hyperledger/fabric-sdk-go/pkg/errors/status"
 	"github.com/pkg/errors"
@@ -27,6 +28,8 @@ type NetworkConfig struct {
 	Orderers               map[string]OrdererConfig
 	Peers                  map[string]PeerConfig
 	CertificateAuthorities map[string]CAConfig
+	PeerGroups             map[string]PeerGroupConfig
+	EntityMatchers         map[string][]MatchConfig
 }
 
 // ClientConfig provides the definition of the client configuration
@@ -37,6 +40,25 @@ type ClientConfig struct {
 	TLS             TLSType
 	TLSCerts        MutualTLSConfig
 	CredentialStore CredentialStoreType
+	ChannelClient   ChannelClientConfig
+}
+
+// ChannelClientConfig defines the defaults of the channel clients
+type ChannelClientConfig struct {
+	// Retry is the retry policy of Execute and Query calls that don't specify one
+	Retry RetryConfig
+}
+
+// RetryConfig defines a retry policy. Retries are disabled if Attempts is zero.
+type RetryConfig struct {
+	// Attempts is the number of retry attempts
+	Attempts int
+	// InitialBackoff is the backoff interval for the first retry attempt
+	InitialBackoff time.Duration
+	// MaxBackoff is the maximum backoff interval for any retry attempt
+	MaxBackoff time.Duration
+	// BackoffFactor is the factor by which the backoff is incremented for consecutive retry attempts
+	BackoffFactor float64
 }
 
 // LoggingType defines the level of logging
@@ -56,13 +78,34 @@ type TLSType struct {
 
 // CredentialStoreType defines pluggable KV store properties
 type CredentialStoreType struct {
+	// Type of the store of the enrolled users: "file" (the default, the users are stored in the Path
+	// directory) or "sql" (the users are stored in the SQL database)
+	Type        string
 	Path        string
 	CryptoStore struct {
 		Path string
 	}
+	SQL struct {
+		// Driver is the name of the database/sql driver, which must be registered by the application
+		Driver string
+		// DataSource is the driver specific data source name of the database
+		DataSource string
+	}
 	Wallet string
 }
 
+// SessionPoolConfig defines the pool of the sessions of the PKCS11 security provider, the defaults
+// of the pool are used for the zero values
+type SessionPoolConfig struct {
+	// Size is the maximum number of sessions, the operations wait for a session when all the sessions are in use
+	Size int
+	// Timeout is the time to wait for a session when all the sessions are in use
+	Timeout time.Duration
+	// Retries is the number of times that an operation is retried with a new session (and a new login)
+	// when the session is no longer valid, e.g. after a failover of the HSM
+	Retries int
+}
+
 // ChannelConfig provides the definition of channels for the network
 type ChannelConfig struct {
 	// Orderers list of ordering service nodes
@@ -120,6 +163,45 @@ type PeerConfig struct {
 	TLSCACerts  TLSConfig
 }
 
+// PeerGroupUse is the intended use of a peer group
+type PeerGroupUse string
+
+const (
+	// PeerGroupUseQuery indicates that the peer group is used for ledger queries
+	PeerGroupUseQuery PeerGroupUse = "query"
+	// PeerGroupUseEndorse indicates that the peer group is used for endorsements
+	PeerGroupUseEndorse PeerGroupUse = "endorse"
+	// PeerGroupUseEvent indicates that the peer group is used for events
+	PeerGroupUseEvent PeerGroupUse = "event"
+)
+
+// PeerGroupConfig defines a named group of peers among which requests are distributed by weight
+type PeerGroupConfig struct {
+	Use     PeerGroupUse
+	Members []PeerGroupMember
+}
+
+// PeerGroupMember defines a member of a peer group. The weight is relative to the weights
+// of the other members of the group and must be greater than 0.
+type PeerGroupMember struct {
+	Peer   string
+	Weight int
+}
+
+// MatchConfig maps the peers or orderers whose names match the pattern to the addresses at which they
+// can be reached by the client (e.g. when the network runs behind NAT or in docker-compose). Each
+// substitution expression replaces the matched part of the name and may refer to the capture groups
+// of the pattern (e.g. ${1}). Expressions that are empty leave the corresponding setting unchanged.
+type MatchConfig struct {
+	Pattern                             string
+	URLSubstitutionExp                  string
+	EventURLSubstitutionExp             string
+	SSLTargetOverrideURLSubstitutionExp string
+	// MappedHost is the name of the configured peer or orderer whose configuration (e.g. TLS
+	// certificates and gRPC options) is used for a matching entity that isn't in the configuration
+	MappedHost string
+}
+
 // CAConfig defines a CA configuration
 type CAConfig struct {
 	URL         string
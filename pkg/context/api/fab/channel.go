@@ -10,6 +10,7 @@ import (
 	"github.com/hyperledger/fabric-sdk-go/internal/github.com/hyperledger/fabric/msp"
 	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
 	mspCfg "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/msp"
+	ab "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/orderer"
 	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
 )
 
@@ -62,11 +63,20 @@ type ChannelLedger interface {
 	QueryInfo(targets []ProposalProcessor) ([]*common.BlockchainInfo, error)
 	QueryBlock(blockNumber int, targets []ProposalProcessor) ([]*common.Block, error)
 	QueryBlockByHash(blockHash []byte, targets []ProposalProcessor) ([]*common.Block, error)
+	QueryBlockByTxID(transactionID TransactionID, targets []ProposalProcessor) ([]*common.Block, error)
 	QueryTransaction(transactionID TransactionID, targets []ProposalProcessor) ([]*pb.ProcessedTransaction, error)
 	QueryInstantiatedChaincodes(targets []ProposalProcessor) ([]*pb.ChaincodeQueryResponse, error)
+	QueryChaincodePolicy(chaincodeID string, targets []ProposalProcessor) ([]*common.SignaturePolicyEnvelope, error)
+	QueryCollectionsConfig(chaincodeID string, targets []ProposalProcessor) ([]*common.CollectionConfigPackage, error)
 	QueryConfigBlock(targets []ProposalProcessor, minResponses int) (*common.ConfigEnvelope, error) // TODO: generalize minResponses
 }
 
+// BlockchainInfoResponse contains the BlockchainInfo returned by an endorser
+type BlockchainInfoResponse struct {
+	BCI      *common.BlockchainInfo
+	Endorser string
+}
+
 // OrgAnchorPeer contains information about an anchor peer on this channel
 type OrgAnchorPeer struct {
 	Org  string
@@ -81,13 +91,22 @@ type ChannelConfig interface {
 	Query() (ChannelCfg, error)
 }
 
+// ChannelCfgFormatVersion is the version of the format of the parsed channel configuration.
+// Config groups and values that aren't part of this format are skipped when the configuration is parsed.
+const ChannelCfgFormatVersion = 1
+
 // ChannelCfg contains channel configuration
 type ChannelCfg interface {
 	Name() string
 	Msps() []*mspCfg.MSPConfig
+	MSPIDs() []string
 	AnchorPeers() []*OrgAnchorPeer
 	Orderers() []string
+	BatchSize() *ab.BatchSize
+	Consortium() string
 	Versions() *Versions
+	ConfigEnvelope() *common.ConfigEnvelope
+	FormatVersion() int
 }
 
 // Versions ...
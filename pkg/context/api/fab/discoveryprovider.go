@@ -21,3 +21,11 @@ type TargetFilter interface {
 	// Accept returns true if peer should be included in the list of target peers
 	Accept(peer Peer) bool
 }
+
+// TargetFilterFunc is a function that's used as a TargetFilter
+type TargetFilterFunc func(peer Peer) bool
+
+// Accept returns true if the function accepts the peer
+func (f TargetFilterFunc) Accept(peer Peer) bool {
+	return f(peer)
+}
@@ -38,6 +38,15 @@ type EventsClient interface {
 	Stop() error
 }
 
+// TxStatusEventHub is implemented by event hubs that report the block in which a transaction is
+// committed and that notify the transaction registrants when the event hub is disconnected.
+type TxStatusEventHub interface {
+	// RegisterTxStatusEvent registers a callback that's called once: either with the validation code and
+	// block number of the transaction, or with an error if the event hub is disconnected before the
+	// transaction is committed. The registration is removed with UnregisterTxEvent.
+	RegisterTxStatusEvent(txnID TransactionID, callback func(txnID TransactionID, code pb.TxValidationCode, blockNumber uint64, err error))
+}
+
 // The EventHubExt interface allows extensions of the SDK to add functionality to EventHub overloads.
 type EventHubExt interface {
 	SetInterests(block bool)
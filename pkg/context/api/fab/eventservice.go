@@ -7,6 +7,9 @@ SPDX-License-Identifier: Apache-2.0
 package fab
 
 import (
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/options"
 	cb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
 	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
 )
@@ -32,6 +35,18 @@ type CCEvent struct {
 	TxID        string
 	ChaincodeID string
 	EventName   string
+
+	// BlockNumber is the number of the block that contains the transaction
+	BlockNumber uint64
+
+	// TxIndex is the position of the transaction within the block
+	TxIndex int
+
+	// EventIndex is the position of the event within the transaction
+	EventIndex int
+
+	// SourceURL is the URL of the event server (peer) from which the event was received
+	SourceURL string
 }
 
 // Registration is a handle that is returned from a successful RegisterXXXEvent.
@@ -62,10 +77,14 @@ type EventService interface {
 	// RegisterChaincodeEvent registers for chaincode events.
 	// Note that Unregister must be called when the registration is no longer needed.
 	// - ccID is the chaincode ID for which events are to be received
-	// - eventFilter is the chaincode event filter (regular expression) for which events are to be received
+	// - eventFilter is the chaincode event filter (regular expression) for which events are to be received.
+	//   By default the filter is unanchored, i.e. it matches any event name that contains a match.
+	// - opts are optional registration options (e.g. anchored or case-insensitive filter, or a replay of cached events)
 	// - Returns the registration and a channel that is used to receive events. The channel
 	//   is closed when Unregister is called.
-	RegisterChaincodeEvent(ccID, eventFilter string) (Registration, <-chan *CCEvent, error)
+	// The events for a registration are delivered in (BlockNumber, TxIndex, EventIndex) order.
+	// No ordering is guaranteed between events that are delivered to different registrations.
+	RegisterChaincodeEvent(ccID, eventFilter string, opts ...options.Opt) (Registration, <-chan *CCEvent, error)
 
 	// RegisterTxStatusEvent registers for transaction status events.
 	// Note that Unregister must be called when the registration is no longer needed.
@@ -87,6 +106,28 @@ type EventService interface {
 type ConnectionEvent struct {
 	Connected bool
 	Err       error
+	// URL is the URL of the event server
+	URL string
+	// Attempt is the connection attempt (starting at 1) that established the connection.
+	// The attempts are counted from 1 again each time the client tries to reconnect.
+	Attempt uint
+	// Reconnects is the total number of times that the connection was re-established
+	// since the client first connected
+	Reconnects uint
+	// ConnectedTime is the time at which the connection was established
+	ConnectedTime time.Time
+	// DisconnectedTime is the time at which the connection was lost (disconnected events only)
+	DisconnectedTime time.Time
+	// Duration is how long the connection lasted (disconnected events only)
+	Duration time.Duration
+	// Flaps is the number of times that the connection was lost and re-established within the debounce
+	// window of the client, in which case the intermediate connection events were not sent and this
+	// event summarizes them. Flaps is 0 if the client doesn't debounce connection events.
+	Flaps uint
+	// Terminal is true if the client won't reconnect, i.e. it has given up trying to reconnect or it has received
+	// the last block that was requested. Unless the client was created with auto-close disabled, the client
+	// is closing and this is the last connection event that's sent by the client.
+	Terminal bool
 }
 
 // EventClient is a client that connects to a peer and receives channel events
@@ -16,11 +16,28 @@ type IdentityManager interface {
 	contextApi.CredentialManager
 	CAName() string
 	Enroll(enrollmentID string, enrollmentSecret string) (core.Key, []byte, error)
+	EnrollWithRequest(request *EnrollmentRequest) (core.Key, []byte, error)
 	Reenroll(user contextApi.User) (core.Key, []byte, error)
 	Register(request *RegistrationRequest) (string, error)
 	Revoke(request *RevocationRequest) (*RevocationResponse, error)
 }
 
+// EnrollmentRequest defines the attributes required to enroll an identity with the CA
+type EnrollmentRequest struct {
+	// Name is the enrollment ID of the identity
+	Name string
+	// Secret is the enrollment secret of the identity
+	Secret string
+	// Hosts are the host names and IP addresses that are added to the CSR as subject alternative names.
+	// If omitted, the host name of the client is used.
+	Hosts []string
+	// Profile is the name of the signing profile that the CA uses to issue the certificate (e.g. "tls").
+	// If omitted, the default profile of the CA is used.
+	Profile string
+	// AttrReqs are requests for attributes of the identity that are added to the certificate
+	AttrReqs []AttributeRequest
+}
+
 // AttributeRequest is a request for an attribute.
 type AttributeRequest struct {
 	Name     string
@@ -53,6 +70,9 @@ type Attribute struct {
 	Name  string
 	Key   string
 	Value string
+	// ECert is true if the attribute is added to the enrollment certificate by default.
+	// A value with the ":ecert" suffix (e.g. "true:ecert") sets it as well.
+	ECert bool
 }
 
 // RevocationRequest defines the attributes required to revoke credentials with the CA
@@ -70,6 +90,8 @@ type RevocationRequest struct {
 	Reason string
 	// CAName is the name of the CA to connect to
 	CAName string
+	// GenCRL specifies whether the CA generates a CRL, which is returned in the response
+	GenCRL bool
 }
 
 // RevocationResponse represents response from the server for a revocation request
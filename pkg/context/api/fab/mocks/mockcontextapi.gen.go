@@ -98,6 +98,33 @@ func (mr *MockIdentityManagerMockRecorder) Enroll(arg0, arg1 interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Enroll", reflect.TypeOf((*MockIdentityManager)(nil).Enroll), arg0, arg1)
 }
 
+// EnrollWithRequest mocks base method
+func (m *MockIdentityManager) EnrollWithRequest(arg0 *fab.EnrollmentRequest) (core.Key, []byte, error) {
+	ret := m.ctrl.Call(m, "EnrollWithRequest", arg0)
+	ret0, _ := ret[0].(core.Key)
+	ret1, _ := ret[1].([]byte)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// EnrollWithRequest indicates an expected call of EnrollWithRequest
+func (mr *MockIdentityManagerMockRecorder) EnrollWithRequest(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnrollWithRequest", reflect.TypeOf((*MockIdentityManager)(nil).EnrollWithRequest), arg0)
+}
+
+// GetSigningIdentity mocks base method
+func (m *MockIdentityManager) GetSigningIdentity(arg0 string) (*api.SigningIdentity, error) {
+	ret := m.ctrl.Call(m, "GetSigningIdentity", arg0)
+	ret0, _ := ret[0].(*api.SigningIdentity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSigningIdentity indicates an expected call of GetSigningIdentity
+func (mr *MockIdentityManagerMockRecorder) GetSigningIdentity(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSigningIdentity", reflect.TypeOf((*MockIdentityManager)(nil).GetSigningIdentity), arg0)
+}
+
 // Reenroll mocks base method
 func (m *MockIdentityManager) Reenroll(arg0 api.User) (core.Key, []byte, error) {
 	ret := m.ctrl.Call(m, "Reenroll", arg0)
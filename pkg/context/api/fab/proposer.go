@@ -15,6 +15,12 @@ type ProposalProcessor interface {
 	ProcessTransactionProposal(ProcessProposalRequest) (*TransactionProposalResponse, error)
 }
 
+// TLSCertHasher is implemented by targets that connect using a client TLS certificate.
+// TLSCertHash returns the hash of the certificate presented on the connection (nil if none).
+type TLSCertHasher interface {
+	TLSCertHash() []byte
+}
+
 // ProposalSender provides the ability for a transaction proposal to be created and sent.
 type ProposalSender interface {
 	CreateTransactionHeader() (TransactionHeader, error)
@@ -24,6 +24,12 @@ type Sender interface {
 	SendTransaction(tx *Transaction) (*TransactionResponse, error)
 }
 
+// TransactionSigner is implemented by senders that can create the signed envelope of a transaction
+// without sending it to the orderer.
+type TransactionSigner interface {
+	SignTransaction(tx *Transaction) (*SignedEnvelope, error)
+}
+
 // The Transaction object created from an endorsed proposal.
 type Transaction struct {
 	Proposal    *TransactionProposal
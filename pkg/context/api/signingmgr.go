@@ -12,3 +12,10 @@ import "github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
 type SigningManager interface {
 	Sign([]byte, core.Key) ([]byte, error)
 }
+
+// Signer signs messages with the private key identified by keyRef, the hex encoded
+// SKI (subject key identifier) of the key. A signer hashes the message itself, so the
+// private key may be held outside of the application (e.g. by a KMS or a remote signing service).
+type Signer interface {
+	Sign(msg []byte, keyRef string) ([]byte, error)
+}
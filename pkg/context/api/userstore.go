@@ -50,3 +50,23 @@ type UserStore interface {
 	Store(User) error
 	Load(UserKey) (User, error)
 }
+
+// UserData is the persisted data of an enrolled user. The private key isn't part of it; the key stays
+// in the key store of the crypto suite and is referenced by its SKI.
+type UserData struct {
+	MspID                 string
+	Name                  string
+	EnrollmentCertificate []byte
+	// KeyRef is the SKI of the private key in the crypto suite. If empty, the SKI of the public key
+	// of the enrollment certificate is used.
+	KeyRef []byte
+}
+
+// CredentialStore is responsible for the persistence of the data of enrolled users (see UserData).
+// Load returns ErrUserNotFound if the user isn't stored.
+type CredentialStore interface {
+	Store(user UserData) error
+	Load(key UserKey) (UserData, error)
+	Delete(key UserKey) error
+	List() ([]UserKey, error)
+}
@@ -36,4 +36,5 @@ type ProviderContext interface {
 	SigningManager() api.SigningManager
 	Config() core.Config
 	CryptoSuite() core.CryptoSuite
+	Connector() api.Connector
 }
@@ -0,0 +1,54 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package config
+
+import (
+	"strings"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
+)
+
+// defaultChannel is the channel section whose settings apply to the channels that aren't configured
+const defaultChannel = "_default"
+
+// applyDefaultChannel merges the settings of the default channel into the configured channels.
+// The settings of a configured channel override the default settings field-by-field.
+func applyDefaultChannel(networkConfig *core.NetworkConfig) {
+	defaults, ok := networkConfig.Channels[defaultChannel]
+	if !ok {
+		return
+	}
+	for name, ch := range networkConfig.Channels {
+		if name == defaultChannel {
+			continue
+		}
+		if len(ch.Orderers) == 0 {
+			ch.Orderers = defaults.Orderers
+		}
+		if len(ch.Peers) == 0 {
+			ch.Peers = defaults.Peers
+		}
+		if len(ch.Chaincodes) == 0 {
+			ch.Chaincodes = defaults.Chaincodes
+		}
+		networkConfig.Channels[name] = ch
+	}
+}
+
+// channelConfig returns the configuration of the given channel. The default channel configuration
+// is returned if the channel isn't configured. False is returned if neither one is configured.
+func channelConfig(networkConfig *core.NetworkConfig, name string) (core.ChannelConfig, bool) {
+	// viper lowercases all key maps
+	if ch, ok := networkConfig.Channels[strings.ToLower(name)]; ok {
+		return ch, true
+	}
+	ch, ok := networkConfig.Channels[defaultChannel]
+	if ok {
+		logger.Debugf("Channel [%s] isn't configured, using the default channel configuration", name)
+	}
+	return ch, ok
+}
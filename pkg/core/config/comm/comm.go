@@ -24,9 +24,15 @@ func TLSConfig(cert *x509.Certificate, serverName string, config core.Config) (*
 		return nil, err
 	}
 
+	// The client certificate is presented to servers that require mutual TLS, whichever root CAs are used
+	clientCerts, err := config.TLSClientCerts()
+	if err != nil {
+		return nil, errors.Errorf("Error loading cert/key pair for TLS client credentials: %v", err)
+	}
+
 	if cert == nil && (certPool == nil || len(certPool.Subjects()) == 0) {
-		//Return empty tls config if there is no cert provided or if certpool unavailable
-		return &tls.Config{}, nil
+		//Use the host's root CAs if there is no cert provided or if certpool unavailable
+		return &tls.Config{Certificates: clientCerts, ServerName: serverName}, nil
 	}
 
 	tlsCaCertPool, err := config.TLSCACertPool(cert)
@@ -35,18 +41,30 @@ func TLSConfig(cert *x509.Certificate, serverName string, config core.Config) (*
 		return nil, err
 	}
 
-	clientCerts, err := config.TLSClientCerts()
-	if err != nil {
-		return nil, errors.Errorf("Error loading cert/key pair for TLS client credentials: %v", err)
-	}
-
 	return &tls.Config{RootCAs: tlsCaCertPool, Certificates: clientCerts, ServerName: serverName}, nil
 }
 
 // TLSCertHash is a utility method to calculate the SHA256 hash of the configured certificate (for usage in channel headers)
 func TLSCertHash(config core.Config) []byte {
 	certs, err := config.TLSClientCerts()
-	if err != nil || len(certs) == 0 {
+	if err != nil {
+		return nil
+	}
+	return certHash(certs)
+}
+
+// TLSCertHashFromTLSConfig calculates the SHA256 hash of the client certificate contained in the
+// given TLS config, i.e. the certificate that is presented to the server on connections established
+// using this config. Nil is returned if the config contains no client certificate.
+func TLSCertHashFromTLSConfig(tlsConfig *tls.Config) []byte {
+	if tlsConfig == nil {
+		return nil
+	}
+	return certHash(tlsConfig.Certificates)
+}
+
+func certHash(certs []tls.Certificate) []byte {
+	if len(certs) == 0 {
 		return nil
 	}
 
@@ -137,3 +137,29 @@ func TestTlsCertHash(t *testing.T) {
 		t.Fatal("Cert hash calculated incorrectly")
 	}
 }
+
+func TestTlsCertHashFromTLSConfig(t *testing.T) {
+	if len(TLSCertHashFromTLSConfig(nil)) != 0 {
+		t.Fatal("Unexpected non-empty cert hash for nil TLS config")
+	}
+
+	if len(TLSCertHashFromTLSConfig(&tls.Config{})) != 0 {
+		t.Fatal("Unexpected non-empty cert hash for TLS config without client certs")
+	}
+
+	cert, err := tls.LoadX509KeyPair("testdata/server.crt", "testdata/server.key")
+	if err != nil {
+		t.Fatalf("Unexpected error loading cert %v", err)
+	}
+
+	tlsCertHash := TLSCertHashFromTLSConfig(&tls.Config{Certificates: []tls.Certificate{cert}})
+
+	expectedHash, err := hex.DecodeString("0DD590B8A50EA6043EA87516BF77A8FEE7C5622D4CB3CB991274722AD8BAB892")
+	if err != nil {
+		t.Fatalf("Unexpected error decoding cert fingerprint %v", err)
+	}
+
+	if bytes.Compare(tlsCertHash, expectedHash) != 0 {
+		t.Fatal("Cert hash calculated incorrectly")
+	}
+}
@@ -11,12 +11,16 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/pem"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"math/rand"
 	"os"
 	"path"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/spf13/viper"
@@ -27,6 +31,7 @@ import (
 	"github.com/hyperledger/fabric-sdk-go/pkg/logging"
 	"github.com/hyperledger/fabric-sdk-go/pkg/logging/loglevel"
 	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
 
 	cs "github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite"
 )
@@ -34,20 +39,43 @@ import (
 var logger = logging.NewLogger(logModule)
 
 const (
-	cmdRoot        = "FABRIC_SDK"
-	logModule      = "fabric_sdk_go"
-	defaultTimeout = time.Second * 5
+	cmdRoot                = "FABRIC_SDK"
+	logModule              = "fabric_sdk_go"
+	defaultTimeout         = time.Second * 5
+	defaultConnIdleTimeout = time.Second * 30
 )
 
 // Config represents the configuration for the client
 type Config struct {
+	mutex               sync.RWMutex
 	tlsCertPool         *x509.CertPool
+	customCertPool      bool
 	networkConfig       *core.NetworkConfig
 	networkConfigCached bool
+	entityMatchers      *entityMatchers
 	configViper         *viper.Viper
 	opts                options
+
+	// source reloads the configuration (nil if the configuration can't be reloaded)
+	source    loader
+	files     []string
+	layers    []configLayer
+	settings  []setting
+	listeners []core.ConfigListener
+
+	// reloadMutex serializes the updates (see Set and Reload), which replace the loaded configuration
+	reloadMutex sync.Mutex
+}
+
+// configLayer is a configuration that was merged into the loaded configuration (e.g. a config file)
+type configLayer struct {
+	data       []byte
+	configType string
 }
 
+// loader loads the configuration from its source into a new configuration
+type loader func(c *Config) error
+
 type options struct {
 	envPrefix    string
 	templatePath string
@@ -58,60 +86,157 @@ type options struct {
 type Option func(opts *options) error
 
 // FromReader loads configuration from in.
-// configType can be "json" or "yaml".
+// configType can be "json" or "yaml". A JSON profile results in the same configuration as the equivalent YAML profile.
 func FromReader(in io.Reader, configType string, opts ...Option) core.ConfigProvider {
+	// The reader is consumed so the configuration can't be reloaded
+	return fromSource(func(c *Config) error {
+		return c.mergeReader(in, configType)
+	}, false, opts...)
+}
+
+// fromSource returns a provider of the configuration that's loaded from the given source
+func fromSource(source loader, reloadable bool, opts ...Option) core.ConfigProvider {
 	return func() (core.Config, error) {
 		c, err := newConfig(opts...)
 		if err != nil {
 			return nil, err
 		}
 
-		if configType == "" {
-			return nil, errors.New("empty config type")
+		if err := source(c); err != nil {
+			return nil, err
+		}
+		if reloadable {
+			c.source = source
 		}
-
-		// read config from bytes array, but must set ConfigType
-		// for viper to properly unmarshal the bytes array
-		c.configViper.SetConfigType(configType)
-		c.configViper.MergeConfig(in)
 
 		return initConfig(c)
 	}
 }
 
-// FromFile reads from named config file
-func FromFile(name string, opts ...Option) core.ConfigProvider {
-	return func() (core.Config, error) {
-		c, err := newConfig(opts...)
-		if err != nil {
-			return nil, err
+// mergeReader merges the configuration that's read from in into the configuration. Included files
+// are resolved relative to the working directory.
+func (c *Config) mergeReader(in io.Reader, configType string) error {
+	if configType == "" {
+		return errors.New("empty config type")
+	}
+
+	data, err := ioutil.ReadAll(in)
+	if err != nil {
+		return errors.Wrapf(err, "reading %s config failed", configType)
+	}
+	if err := c.mergeData(data, configType, "", nil); err != nil {
+		return errors.WithMessage(err, fmt.Sprintf("loading %s config failed", strings.ToLower(configType)))
+	}
+	return nil
+}
+
+// mergeData merges the given configuration into the configuration. The environment variables that are
+// referenced by the configuration are substituted before it's parsed, and the files that are included
+// by the configuration are resolved (relative to dir) after it's parsed. includes are the files that
+// are being loaded (see resolveIncludes).
+func (c *Config) mergeData(data []byte, configType, dir string, includes []string) error {
+	data, err := substituteEnv(data)
+	if err != nil {
+		return err
+	}
+
+	var settings interface{}
+	switch strings.ToLower(configType) {
+	case "json":
+		if settings, err = parseJSON(data); err != nil {
+			return err
 		}
+	case "yaml", "yml":
+		// Only profiles that include other files need to be parsed before they're merged
+		if !bytes.Contains(data, []byte(includeDirective)) {
+			break
+		}
+		if err := yaml.Unmarshal(data, &settings); err != nil {
+			return errors.Wrap(err, "invalid YAML config")
+		}
+	}
 
-		if name == "" {
-			return nil, errors.New("filename is required")
+	if settings != nil {
+		if settings, err = resolveIncludes(settings, dir, includes); err != nil {
+			return err
 		}
+		if data, err = yaml.Marshal(settings); err != nil {
+			return errors.Wrap(err, "converting config failed")
+		}
+		// Subsequent merges (see Set) are parsed as YAML, which is a superset of JSON
+		configType = "yaml"
+	}
 
-		// create new viper
-		c.configViper.SetConfigFile(name)
+	return c.mergeLayer(configLayer{data: data, configType: configType})
+}
+
+// mergeLayer merges the given configuration into the configuration. The layers are kept so that the
+// configuration may be loaded again without reading its source (see Set).
+func (c *Config) mergeLayer(layer configLayer) error {
+	// the type must be set for viper to properly unmarshal the bytes
+	c.configViper.SetConfigType(layer.configType)
+	if err := c.configViper.MergeConfig(bytes.NewReader(layer.data)); err != nil {
+		return err
+	}
+	c.layers = append(c.layers, layer)
+	return nil
+}
 
-		// If a config file is found, read it in.
-		err = c.configViper.MergeInConfig()
-		if err == nil {
-			logger.Debugf("Using config file: %s", c.configViper.ConfigFileUsed())
-		} else {
-			return nil, errors.Wrap(err, "loading config file failed")
+// FromFile reads from named config file. Files with a .json extension are loaded as JSON profiles.
+func FromFile(name string, opts ...Option) core.ConfigProvider {
+	return FromFiles([]string{name}, opts...)
+}
+
+// FromFiles reads the named config files in order and merges them into a single configuration, e.g. so that
+// credentials and network topology may be kept in separate files. Maps are merged recursively; scalars and lists
+// in a later file replace those in an earlier file. Use Dump to troubleshoot the merged configuration.
+func FromFiles(names []string, opts ...Option) core.ConfigProvider {
+	return fromSource(func(c *Config) error {
+		if len(names) == 0 {
+			return errors.New("filename is required")
 		}
 
-		return initConfig(c)
+		for _, name := range names {
+			if err := c.mergeFile(name); err != nil {
+				return err
+			}
+		}
+		c.files = names
+		return nil
+	}, true, opts...)
+}
+
+// mergeFile merges the named config file into the configuration
+func (c *Config) mergeFile(name string) error {
+	if name == "" {
+		return errors.New("filename is required")
+	}
+
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		return errors.Wrap(err, "loading config file failed")
+	}
+	file, err := filepath.Abs(name)
+	if err != nil {
+		return errors.Wrap(err, "loading config file failed")
+	}
+
+	// the type is set from the extension since a previous file may have set a different type
+	configType := strings.TrimPrefix(path.Ext(name), ".")
+	if err := c.mergeData(data, configType, filepath.Dir(file), []string{file}); err != nil {
+		return errors.WithMessage(err, fmt.Sprintf("loading config file [%s] failed", name))
 	}
+	logger.Debugf("Using config file: %s", name)
+	return nil
 }
 
-// FromRaw will initialize the configs from a byte array
+// FromRaw will initialize the configs from a byte array.
+// configType can be "json" or "yaml". Unlike FromReader, the provider may be invoked more than once.
 func FromRaw(configBytes []byte, configType string, opts ...Option) core.ConfigProvider {
-	buf := bytes.NewBuffer(configBytes)
-	logger.Debugf("config.FromRaw buf Len is %d, Cap is %d: %s", buf.Len(), buf.Cap(), buf)
-
-	return FromReader(buf, configType, opts...)
+	return fromSource(func(c *Config) error {
+		logger.Debugf("config.FromRaw config Len is %d", len(configBytes))
+		return c.mergeReader(bytes.NewReader(configBytes), configType)
+	}, true, opts...)
 }
 
 /*
@@ -198,14 +323,15 @@ func newViper(cmdRootPrefix string) *viper.Viper {
 }
 
 func initConfig(c *Config) (*Config, error) {
-	setLogLevel(c.configViper)
-	tlsCertPool, err := getCertPool(c.configViper)
-	if err != nil {
-		return nil, err
+	setLogLevel(c.viper())
+	tlsCertPool := getCertPool(c.viper())
+	c.mutex.Lock()
+	if !c.customCertPool {
+		c.tlsCertPool = tlsCertPool
 	}
-	c.tlsCertPool = tlsCertPool
+	c.mutex.Unlock()
 
-	if err = c.cacheNetworkConfiguration(); err != nil {
+	if err := c.cacheNetworkConfiguration(); err != nil {
 		return nil, errors.WithMessage(err, "network configuration load failed")
 	}
 
@@ -213,16 +339,24 @@ func initConfig(c *Config) (*Config, error) {
 	return c, nil
 }
 
-func getCertPool(myViper *viper.Viper) (*x509.CertPool, error) {
-	tlsCertPool := x509.NewCertPool()
-	if myViper.GetBool("client.tlsCerts.systemCertPool") == true {
-		var err error
-		if tlsCertPool, err = x509.SystemCertPool(); err != nil {
-			return nil, err
-		}
-		logger.Debugf("Loaded system cert pool of size: %d", len(tlsCertPool.Subjects()))
+// systemCertPool returns the system cert pool (overridden by tests)
+var systemCertPool = x509.SystemCertPool
+
+// getCertPool returns the pool of root TLS CAs that the CAs in the configuration are added to. The pool starts
+// from the system cert pool if "client.tlsCerts.systemCertPool" is enabled, or is empty if the system cert
+// pool isn't enabled or isn't available on this platform.
+func getCertPool(myViper *viper.Viper) *x509.CertPool {
+	if myViper.GetBool("client.tlsCerts.systemCertPool") == false {
+		return x509.NewCertPool()
 	}
-	return tlsCertPool, nil
+
+	tlsCertPool, err := systemCertPool()
+	if err != nil || tlsCertPool == nil {
+		logger.Warnf("The system cert pool is enabled but isn't available, using an empty cert pool: %v", err)
+		return x509.NewCertPool()
+	}
+	logger.Debugf("Loaded system cert pool of size: %d", len(tlsCertPool.Subjects()))
+	return tlsCertPool
 }
 
 // setLogLevel will set the log level of the client
@@ -438,35 +572,57 @@ func (c *Config) CAClientCertPem(org string) (string, error) {
 	return ca.TLSCACerts.Client.Cert.Pem, nil
 }
 
-// TimeoutOrDefault reads connection timeouts for the given connection type
-func (c *Config) TimeoutOrDefault(conn core.TimeoutType) time.Duration {
-	var timeout time.Duration
-	switch conn {
-	case core.Endorser:
-		timeout = c.configViper.GetDuration("client.peer.timeout.connection")
-	case core.Query:
-		timeout = c.configViper.GetDuration("client.peer.timeout.queryResponse")
-	case core.Execute:
-		timeout = c.configViper.GetDuration("client.peer.timeout.executeTxResponse")
-	case core.DiscoveryGreylistExpiry:
-		timeout = c.configViper.GetDuration("client.peer.timeout.discovery.greylistExpiry")
-	case core.EventHubConnection:
-		timeout = c.configViper.GetDuration("client.eventService.timeout.connection")
-	case core.EventReg:
-		timeout = c.configViper.GetDuration("client.eventService.timeout.registrationResponse")
-	case core.OrdererConnection:
-		timeout = c.configViper.GetDuration("client.orderer.timeout.connection")
-	case core.OrdererResponse:
-		timeout = c.configViper.GetDuration("client.orderer.timeout.response")
+// timeoutSetting is the configuration of a timeout type. The timeout is read from the "client.timeouts"
+// section, or from the key of the earlier peer, event service and orderer timeout sections.
+type timeoutSetting struct {
+	key          string
+	legacyKey    string
+	defaultValue time.Duration
+}
+
+// timeoutSettings are the settings of each timeout type (the defaults are documented on core.TimeoutType)
+var timeoutSettings = map[core.TimeoutType]timeoutSetting{
+	core.Endorser:                   {"client.timeouts.endorser", "client.peer.timeout.connection", defaultTimeout},
+	core.Query:                      {"client.timeouts.query", "client.peer.timeout.queryResponse", defaultTimeout},
+	core.Execute:                    {"client.timeouts.execute", "client.peer.timeout.executeTxResponse", defaultTimeout},
+	core.DiscoveryGreylistExpiry:    {"client.timeouts.discoveryGreylistExpiry", "client.peer.timeout.discovery.greylistExpiry", defaultTimeout},
+	core.EventHubConnection:         {"client.timeouts.eventHubConnection", "client.eventService.timeout.connection", defaultTimeout},
+	core.EventReg:                   {"client.timeouts.eventRegistration", "client.eventService.timeout.registrationResponse", defaultTimeout},
+	core.OrdererConnection:          {"client.timeouts.ordererConnection", "client.orderer.timeout.connection", defaultTimeout},
+	core.OrdererResponse:            {"client.timeouts.ordererResponse", "client.orderer.timeout.response", defaultTimeout},
+	core.EventServiceResponse:       {"client.timeouts.eventServiceResponse", "", defaultTimeout},
+	core.EventReconnectInitialDelay: {"client.timeouts.eventReconnectInitialDelay", "", 0},
+	core.EventConnectRetryInterval:  {"client.timeouts.eventConnectRetryInterval", "", defaultTimeout},
+	core.ConnectionIdle:             {"client.timeouts.connectionIdle", "", defaultConnIdleTimeout},
+}
+
+// Timeout returns the timeout of the given type. The timeout is read from the "client.timeouts" section
+// (e.g. "client.timeouts.endorser"), and then from the peer, event service and orderer timeout sections
+// (e.g. "client.peer.timeout.connection"). The default of the type is returned if the timeout isn't
+// configured or is zero (see core.TimeoutType).
+func (c *Config) Timeout(timeoutType core.TimeoutType) time.Duration {
+	setting, ok := timeoutSettings[timeoutType]
+	if !ok {
+		return defaultTimeout
+	}
 
+	v := c.viper()
+	timeout := v.GetDuration(setting.key)
+	if timeout == 0 && setting.legacyKey != "" {
+		timeout = v.GetDuration(setting.legacyKey)
 	}
 	if timeout == 0 {
-		timeout = defaultTimeout
+		timeout = setting.defaultValue
 	}
-
 	return timeout
 }
 
+// TimeoutOrDefault reads connection timeouts for the given connection type.
+// Deprecated: use Timeout.
+func (c *Config) TimeoutOrDefault(conn core.TimeoutType) time.Duration {
+	return c.Timeout(conn)
+}
+
 // MspID returns the MSP ID for the requested organization
 func (c *Config) MspID(org string) (string, error) {
 	config, err := c.NetworkConfig()
@@ -484,47 +640,224 @@ func (c *Config) MspID(org string) (string, error) {
 
 func (c *Config) cacheNetworkConfiguration() error {
 	networkConfig := core.NetworkConfig{}
-	networkConfig.Name = c.configViper.GetString("name")
-	networkConfig.Xtype = c.configViper.GetString("x-type")
-	networkConfig.Description = c.configViper.GetString("description")
-	networkConfig.Version = c.configViper.GetString("version")
+	networkConfig.Name = c.viper().GetString("name")
+	networkConfig.Xtype = c.viper().GetString("x-type")
+	networkConfig.Description = c.viper().GetString("description")
+	networkConfig.Version = c.viper().GetString("version")
 
-	err := c.configViper.UnmarshalKey("client", &networkConfig.Client)
+	err := c.unmarshalKey("client", &networkConfig.Client)
 	logger.Debugf("Client is: %+v", networkConfig.Client)
 	if err != nil {
 		return err
 	}
-	err = c.configViper.UnmarshalKey("channels", &networkConfig.Channels)
+	err = c.unmarshalKey("channels", &networkConfig.Channels)
 	logger.Debugf("channels are: %+v", networkConfig.Channels)
 	if err != nil {
 		return err
 	}
-	err = c.configViper.UnmarshalKey("organizations", &networkConfig.Organizations)
+	err = c.unmarshalKey("organizations", &networkConfig.Organizations)
 	logger.Debugf("organizations are: %+v", networkConfig.Organizations)
 	if err != nil {
 		return err
 	}
-	err = c.configViper.UnmarshalKey("orderers", &networkConfig.Orderers)
+	err = c.unmarshalKey("orderers", &networkConfig.Orderers)
 	logger.Debugf("orderers are: %+v", networkConfig.Orderers)
 	if err != nil {
 		return err
 	}
-	err = c.configViper.UnmarshalKey("peers", &networkConfig.Peers)
+	err = c.unmarshalKey("peers", &networkConfig.Peers)
 	logger.Debugf("peers are: %+v", networkConfig.Peers)
 	if err != nil {
 		return err
 	}
-	err = c.configViper.UnmarshalKey("certificateAuthorities", &networkConfig.CertificateAuthorities)
+	err = c.unmarshalKey("certificateAuthorities", &networkConfig.CertificateAuthorities)
 	logger.Debugf("certificateAuthorities are: %+v", networkConfig.CertificateAuthorities)
 	if err != nil {
 		return err
 	}
+	err = c.unmarshalKey("peerGroups", &networkConfig.PeerGroups)
+	logger.Debugf("peerGroups are: %+v", networkConfig.PeerGroups)
+	if err != nil {
+		return err
+	}
+	err = c.unmarshalKey("entityMatchers", &networkConfig.EntityMatchers)
+	logger.Debugf("entityMatchers are: %+v", networkConfig.EntityMatchers)
+	if err != nil {
+		return err
+	}
+	resolvePems(&networkConfig)
+	matchers, err := compileEntityMatchers(&networkConfig)
+	if err != nil {
+		return err
+	}
+	applyEntityMatchers(&networkConfig, matchers)
+	applyDefaultChannel(&networkConfig)
+	if err := validatePeerGroups(&networkConfig); err != nil {
+		return err
+	}
+	if err := c.validateTimeouts(); err != nil {
+		return err
+	}
+	for name, peer := range networkConfig.Peers {
+		if err := normalizeGRPCOptions(name, peer.GRPCOptions); err != nil {
+			return err
+		}
+	}
+	for name, orderer := range networkConfig.Orderers {
+		if err := normalizeGRPCOptions(name, orderer.GRPCOptions); err != nil {
+			return err
+		}
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
 
 	c.networkConfig = &networkConfig
+	c.entityMatchers = matchers
 	c.networkConfigCached = true
 	return nil
 }
 
+// unmarshalKey unmarshals the given top-level key. The error identifies the key, and the
+// decoding error identifies the invalid field within the key (e.g. '[mychannel].Peers').
+func (c *Config) unmarshalKey(key string, rawVal interface{}) error {
+	if err := c.viper().UnmarshalKey(key, rawVal); err != nil {
+		return errors.Wrapf(err, "failed to parse [%s]", key)
+	}
+	return nil
+}
+
+// validatePeerGroups ensures that the peer groups only reference known peers
+// and that the intended use and weights are valid
+func validatePeerGroups(networkConfig *core.NetworkConfig) error {
+	for name, group := range networkConfig.PeerGroups {
+		switch group.Use {
+		case "", core.PeerGroupUseQuery, core.PeerGroupUseEndorse, core.PeerGroupUseEvent:
+		default:
+			return errors.Errorf("invalid use [%s] for peer group [%s]", group.Use, name)
+		}
+		if len(group.Members) == 0 {
+			return errors.Errorf("peer group [%s] has no members", name)
+		}
+		for _, member := range group.Members {
+			// viper lowercases all key maps
+			if _, ok := networkConfig.Peers[strings.ToLower(member.Peer)]; !ok {
+				return errors.Errorf("peer group [%s] references unknown peer [%s]", name, member.Peer)
+			}
+			if member.Weight <= 0 {
+				return errors.Errorf("invalid weight [%d] for peer [%s] in peer group [%s]", member.Weight, member.Peer, name)
+			}
+		}
+	}
+	return nil
+}
+
+// validateTimeouts ensures that the configured timeouts are durations that aren't negative. Numbers aren't
+// accepted since the unit would be ambiguous (a JSON profile with "connection": 30 would otherwise result in 30ns).
+func (c *Config) validateTimeouts() error {
+	v := c.viper()
+	for _, setting := range timeoutSettings {
+		for _, key := range []string{setting.key, setting.legacyKey} {
+			if key == "" {
+				continue
+			}
+			if err := validateDuration(v.Get(key)); err != nil {
+				return errors.WithMessage(err, fmt.Sprintf("invalid timeout [%s]", key))
+			}
+			if v.GetDuration(key) < 0 {
+				return errors.Errorf("invalid timeout [%s]: negative timeouts aren't allowed", key)
+			}
+		}
+	}
+	return nil
+}
+
+// grpcBoolOptions, grpcDurationOptions and grpcSizeOptions are the gRPC options that are boolean, duration
+// and size (in bytes) values
+var (
+	grpcBoolOptions     = []string{"fail-fast", "allow-insecure", "keep-alive-permit"}
+	grpcDurationOptions = []string{"keep-alive-time", "keep-alive-timeout"}
+	grpcSizeOptions     = []string{"max-recv-msg-size", "max-send-msg-size"}
+)
+
+// normalizeGRPCOptions ensures that the boolean, duration and size gRPC options of the given peer or orderer
+// are valid. Booleans and sizes that are specified as strings (e.g. "true" in a JSON profile) are converted
+// to bools and ints.
+func normalizeGRPCOptions(name string, grpcOptions map[string]interface{}) error {
+	for _, option := range grpcBoolOptions {
+		value, ok := grpcOptions[option]
+		if !ok {
+			continue
+		}
+		switch v := value.(type) {
+		case bool:
+		case string:
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return errors.Errorf("invalid value [%s] for gRPC option [%s] of [%s]: expecting true or false", v, option, name)
+			}
+			grpcOptions[option] = b
+		default:
+			return errors.Errorf("invalid value [%v] for gRPC option [%s] of [%s]: expecting true or false", v, option, name)
+		}
+	}
+	for _, option := range grpcDurationOptions {
+		if err := validateDuration(grpcOptions[option]); err != nil {
+			return errors.WithMessage(err, fmt.Sprintf("invalid value for gRPC option [%s] of [%s]", option, name))
+		}
+	}
+	for _, option := range grpcSizeOptions {
+		value, ok := grpcOptions[option]
+		if !ok {
+			continue
+		}
+		size, err := parseSize(value)
+		if err != nil {
+			return errors.WithMessage(err, fmt.Sprintf("invalid value for gRPC option [%s] of [%s]", option, name))
+		}
+		grpcOptions[option] = size
+	}
+	return nil
+}
+
+// parseSize parses a positive size in bytes, which may be given as a number or as a string
+func parseSize(value interface{}) (int, error) {
+	var size int
+	switch v := value.(type) {
+	case int:
+		size = v
+	case string:
+		var err error
+		if size, err = strconv.Atoi(v); err != nil {
+			return 0, errors.Errorf("[%s] is not a size: expecting a number of bytes", v)
+		}
+	default:
+		return 0, errors.Errorf("[%v] is not a size: expecting a number of bytes", v)
+	}
+	if size <= 0 {
+		return 0, errors.Errorf("[%d] is not a size: expecting a positive number of bytes", size)
+	}
+	return size, nil
+}
+
+// validateDuration ensures that the given setting (if set) is a duration or a duration string such as "30s"
+func validateDuration(value interface{}) error {
+	switch v := value.(type) {
+	case nil, time.Duration:
+		return nil
+	case string:
+		if v == "" {
+			return nil
+		}
+		if _, err := time.ParseDuration(v); err != nil {
+			return errors.Errorf("[%s] is not a duration: expecting a value such as \"30s\"", v)
+		}
+		return nil
+	default:
+		return errors.Errorf("[%v] is not a duration: expecting a string such as \"30s\"", v)
+	}
+}
+
 // OrderersConfig returns a list of defined orderers
 func (c *Config) OrderersConfig() ([]core.OrdererConfig, error) {
 	orderers := []core.OrdererConfig{}
@@ -537,7 +870,7 @@ func (c *Config) OrderersConfig() ([]core.OrdererConfig, error) {
 
 		if orderer.TLSCACerts.Path != "" {
 			orderer.TLSCACerts.Path = substPathVars(orderer.TLSCACerts.Path)
-		} else if len(orderer.TLSCACerts.Pem) == 0 && c.configViper.GetBool("client.tlsCerts.systemCertPool") == false {
+		} else if len(orderer.TLSCACerts.Pem) == 0 && c.viper().GetBool("client.tlsCerts.systemCertPool") == false {
 			errors.Errorf("Orderer has no certs configured. Make sure TLSCACerts.Pem or TLSCACerts.Path is set for %s", orderer.URL)
 		}
 
@@ -567,7 +900,8 @@ func randomOrdererConfig(orderers []core.OrdererConfig) (*core.OrdererConfig, er
 	return &orderers[randomNumber], nil
 }
 
-// OrdererConfig returns the requested orderer
+// OrdererConfig returns the requested orderer. If the name isn't that of a configured orderer then the
+// orderer entity matchers are consulted, so the name may also be an orderer address that's learned at runtime.
 func (c *Config) OrdererConfig(name string) (*core.OrdererConfig, error) {
 	config, err := c.NetworkConfig()
 	if err != nil {
@@ -575,7 +909,12 @@ func (c *Config) OrdererConfig(name string) (*core.OrdererConfig, error) {
 	}
 	orderer, ok := config.Orderers[strings.ToLower(name)]
 	if !ok {
-		return nil, nil
+		// The orderer may be known only at runtime (e.g. from a channel configuration)
+		matched := c.matchOrderer(config, name)
+		if matched == nil {
+			return nil, nil
+		}
+		orderer = *matched
 	}
 
 	if orderer.TLSCACerts.Path != "" {
@@ -641,25 +980,38 @@ func (c *Config) PeerConfig(org string, name string) (*core.PeerConfig, error) {
 
 // NetworkConfig returns the network configuration defined in the config file
 func (c *Config) NetworkConfig() (*core.NetworkConfig, error) {
-	if c.networkConfigCached {
-		return c.networkConfig, nil
+	c.mutex.RLock()
+	networkConfig, cached := c.networkConfig, c.networkConfigCached
+	c.mutex.RUnlock()
+	if cached {
+		return networkConfig, nil
 	}
 
 	if err := c.cacheNetworkConfiguration(); err != nil {
 		return nil, errors.WithMessage(err, "network configuration load failed")
 	}
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
 	return c.networkConfig, nil
 }
 
-// ChannelConfig returns the channel configuration
+// viper returns the viper instance that holds the current configuration (the instance is replaced by Reload)
+func (c *Config) viper() *viper.Viper {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.configViper
+}
+
+// ChannelConfig returns the channel configuration. The default channel configuration (channels._default)
+// is returned for channels that aren't configured.
 func (c *Config) ChannelConfig(name string) (*core.ChannelConfig, error) {
 	config, err := c.NetworkConfig()
 	if err != nil {
 		return nil, err
 	}
 
-	// viper lowercases all key maps
-	ch, ok := config.Channels[strings.ToLower(name)]
+	ch, ok := channelConfig(config, name)
 	if !ok {
 		return nil, nil
 	}
@@ -687,15 +1039,14 @@ func (c *Config) ChannelOrderers(name string) ([]core.OrdererConfig, error) {
 	return orderers, nil
 }
 
-// ChannelPeers returns the channel peers configuration
+// ChannelPeers returns the channel peers configuration (see ChannelConfig)
 func (c *Config) ChannelPeers(name string) ([]core.ChannelPeer, error) {
 	netConfig, err := c.NetworkConfig()
 	if err != nil {
 		return nil, err
 	}
 
-	// viper lowercases all key maps
-	chConfig, ok := netConfig.Channels[strings.ToLower(name)]
+	chConfig, ok := channelConfig(netConfig, name)
 	if !ok {
 		return nil, errors.Errorf("channel config not found for %s", name)
 	}
@@ -796,7 +1147,7 @@ func (c *Config) verifyPeerConfig(p core.PeerConfig, peerName string, tlsEnabled
 	if p.EventURL == "" {
 		return errors.Errorf("event URL does not exist or empty for peer %s", peerName)
 	}
-	if tlsEnabled && len(p.TLSCACerts.Pem) == 0 && p.TLSCACerts.Path == "" && c.configViper.GetBool("client.tlsCerts.systemCertPool") == false {
+	if tlsEnabled && len(p.TLSCACerts.Pem) == 0 && p.TLSCACerts.Path == "" && c.viper().GetBool("client.tlsCerts.systemCertPool") == false {
 		return errors.Errorf("tls.certificate does not exist or empty for peer %s", peerName)
 	}
 	return nil
@@ -808,54 +1159,62 @@ func (c *Config) SetTLSCACertPool(certPool *x509.CertPool) {
 	if certPool == nil {
 		certPool = x509.NewCertPool()
 	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
 	c.tlsCertPool = certPool
+	c.customCertPool = true
 }
 
 // TLSCACertPool returns the configured cert pool. If a certConfig
 // is provided, the certficate is added to the pool
 func (c *Config) TLSCACertPool(certs ...*x509.Certificate) (*x509.CertPool, error) {
+	c.mutex.RLock()
+	tlsCertPool := c.tlsCertPool
+	c.mutex.RUnlock()
+
 	for _, cert := range certs {
 		if cert != nil {
-			c.tlsCertPool.AddCert(cert)
+			tlsCertPool.AddCert(cert)
 		}
 	}
 
-	return c.tlsCertPool, nil
+	return tlsCertPool, nil
 }
 
 // IsSecurityEnabled ...
 func (c *Config) IsSecurityEnabled() bool {
-	return c.configViper.GetBool("client.BCCSP.security.enabled")
+	return c.viper().GetBool("client.BCCSP.security.enabled")
 }
 
 // SecurityAlgorithm ...
 func (c *Config) SecurityAlgorithm() string {
-	return c.configViper.GetString("client.BCCSP.security.hashAlgorithm")
+	return c.viper().GetString("client.BCCSP.security.hashAlgorithm")
 }
 
 // SecurityLevel ...
 func (c *Config) SecurityLevel() int {
-	return c.configViper.GetInt("client.BCCSP.security.level")
+	return c.viper().GetInt("client.BCCSP.security.level")
 }
 
 //SecurityProvider provider SW or PKCS11
 func (c *Config) SecurityProvider() string {
-	return c.configViper.GetString("client.BCCSP.security.default.provider")
+	return c.viper().GetString("client.BCCSP.security.default.provider")
 }
 
 //Ephemeral flag
 func (c *Config) Ephemeral() bool {
-	return c.configViper.GetBool("client.BCCSP.security.ephemeral")
+	return c.viper().GetBool("client.BCCSP.security.ephemeral")
 }
 
 //SoftVerify flag
 func (c *Config) SoftVerify() bool {
-	return c.configViper.GetBool("client.BCCSP.security.softVerify")
+	return c.viper().GetBool("client.BCCSP.security.softVerify")
 }
 
 //SecurityProviderLibPath will be set only if provider is PKCS11
 func (c *Config) SecurityProviderLibPath() string {
-	configuredLibs := c.configViper.GetString("client.BCCSP.security.library")
+	configuredLibs := c.viper().GetString("client.BCCSP.security.library")
 	libPaths := strings.Split(configuredLibs, ",")
 	logger.Debug("Configured BCCSP Lib Paths %v", libPaths)
 	var lib string
@@ -875,22 +1234,31 @@ func (c *Config) SecurityProviderLibPath() string {
 
 //SecurityProviderPin will be set only if provider is PKCS11
 func (c *Config) SecurityProviderPin() string {
-	return c.configViper.GetString("client.BCCSP.security.pin")
+	return c.viper().GetString("client.BCCSP.security.pin")
 }
 
 //SecurityProviderLabel will be set only if provider is PKCS11
 func (c *Config) SecurityProviderLabel() string {
-	return c.configViper.GetString("client.BCCSP.security.label")
+	return c.viper().GetString("client.BCCSP.security.label")
+}
+
+//SecurityProviderSessionPool returns the settings of the PKCS11 session pool, will be set only if provider is PKCS11
+func (c *Config) SecurityProviderSessionPool() core.SessionPoolConfig {
+	return core.SessionPoolConfig{
+		Size:    c.viper().GetInt("client.BCCSP.security.sessionPool.size"),
+		Timeout: c.viper().GetDuration("client.BCCSP.security.sessionPool.timeout"),
+		Retries: c.viper().GetInt("client.BCCSP.security.sessionPool.retries"),
+	}
 }
 
 // CredentialStorePath returns the user store path
 func (c *Config) CredentialStorePath() string {
-	return substPathVars(c.configViper.GetString("client.credentialStore.path"))
+	return substPathVars(c.viper().GetString("client.credentialStore.path"))
 }
 
 // KeyStorePath returns the keystore path used by BCCSP
 func (c *Config) KeyStorePath() string {
-	keystorePath := substPathVars(c.configViper.GetString("client.credentialStore.cryptoStore.path"))
+	keystorePath := substPathVars(c.viper().GetString("client.credentialStore.cryptoStore.path"))
 	return path.Join(keystorePath, "keystore")
 }
 
@@ -898,26 +1266,27 @@ func (c *Config) KeyStorePath() string {
 // 'keystore' directory added. This is done because the fabric-ca-client
 // adds this to the path
 func (c *Config) CAKeyStorePath() string {
-	return substPathVars(c.configViper.GetString("client.credentialStore.cryptoStore.path"))
+	return substPathVars(c.viper().GetString("client.credentialStore.cryptoStore.path"))
 }
 
 // CryptoConfigPath ...
 func (c *Config) CryptoConfigPath() string {
-	return substPathVars(c.configViper.GetString("client.cryptoconfig.path"))
+	return substPathVars(c.viper().GetString("client.cryptoconfig.path"))
 }
 
 // TLSClientCerts loads the client's certs for mutual TLS
-// It checks the config for embedded pem files before looking for cert files
+// It checks the config for embedded pem files before looking for cert files.
+// The private key is looked up in the crypto suite (by the SKI of the certificate's public key) first,
+// in which case the handshake is signed by the crypto suite (e.g. by an HSM) and the key isn't needed
+// in the config. Otherwise the key is loaded from the client.tlsCerts.client.key section.
 func (c *Config) TLSClientCerts() ([]tls.Certificate, error) {
-	config, err := c.NetworkConfig()
+	clientConfig, err := c.Client()
 	if err != nil {
 		return nil, err
 	}
 
-	clientConfig := config.Client
 	var clientCerts tls.Certificate
-	var cb, kb []byte
-	cb, err = clientConfig.TLSCerts.Client.Cert.Bytes()
+	cb, err := clientConfig.TLSCerts.Client.Cert.Bytes()
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to load tls client cert")
 	}
@@ -934,13 +1303,9 @@ func (c *Config) TLSClientCerts() ([]tls.Certificate, error) {
 	// If CryptoSuite fails to load private key from cert then load private key from config
 	if err != nil || pk == nil {
 		logger.Debugf("Reading pk from config, unable to retrieve from cert: %s", err)
-		if clientConfig.TLSCerts.Client.Key.Pem != "" {
-			kb = []byte(clientConfig.TLSCerts.Client.Key.Pem)
-		} else if clientConfig.TLSCerts.Client.Key.Path != "" {
-			kb, err = loadByteKeyOrCertFromFile(&clientConfig, true)
-			if err != nil {
-				return nil, errors.Wrapf(err, "Failed to load key from file path '%s'", clientConfig.TLSCerts.Client.Key.Path)
-			}
+		kb, err := clientConfig.TLSCerts.Client.Key.Bytes()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to load tls client key")
 		}
 
 		// load the key/cert pair from []byte
@@ -962,24 +1327,6 @@ func (c *Config) TLSClientCerts() ([]tls.Certificate, error) {
 	return []tls.Certificate{clientCerts}, nil
 }
 
-func loadByteKeyOrCertFromFile(c *core.ClientConfig, isKey bool) ([]byte, error) {
-	var path string
-	a := "key"
-	if isKey {
-		path = substPathVars(c.TLSCerts.Client.Key.Path)
-		c.TLSCerts.Client.Key.Path = path
-	} else {
-		a = "cert"
-		path = substPathVars(c.TLSCerts.Client.Cert.Path)
-		c.TLSCerts.Client.Cert.Path = path
-	}
-	bts, err := ioutil.ReadFile(path)
-	if err != nil {
-		return nil, errors.Errorf("Error loading %s file from '%s' err: %v", a, path, err)
-	}
-	return bts, nil
-}
-
 // loadCAKey
 func loadCAKey(rawData []byte) (*x509.Certificate, error) {
 	block, _ := pem.Decode(rawData)
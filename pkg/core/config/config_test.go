@@ -9,17 +9,24 @@ package config
 import (
 	"bytes"
 	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
+	"io/ioutil"
+	"net"
 	"os"
 	"path"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"reflect"
 
 	api "github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config/comm"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config/cryptoutil/testutils"
 	"github.com/hyperledger/fabric-sdk-go/pkg/logging"
 	"github.com/pkg/errors"
 	"github.com/spf13/viper"
@@ -34,6 +41,7 @@ const (
 	configEmptyTestFilePath         = "testdata/empty.yaml"
 	configPemTestFilePath           = "testdata/config_test_pem.yaml"
 	configEmbeddedUsersTestFilePath = "../../../test/fixtures/config/config_test_embedded_pems.yaml"
+	configInlinePemsTestFilePath    = "../../../test/fixtures/config/config_test_inline_pems.yaml"
 	configType                      = "yaml"
 	defaultConfigPath               = "testdata/template"
 )
@@ -418,6 +426,77 @@ func TestTimeouts(t *testing.T) {
 
 }
 
+func TestTimeoutsSection(t *testing.T) {
+	raw := []byte(`
+client:
+  organization: Org1
+  peer:
+    timeout:
+      connection: 3s
+      queryResponse: 45s
+  timeouts:
+    endorser: 4s
+    eventServiceResponse: 7s
+    eventReconnectInitialDelay: 1s
+    ordererResponse: 0s
+`)
+	c, err := FromRaw(raw, "yaml")()
+	if err != nil {
+		t.Fatalf("Unexpected error from config: %s", err)
+	}
+
+	expected := map[api.TimeoutType]time.Duration{
+		// The timeouts section takes precedence
+		api.Endorser:                   4 * time.Second,
+		api.EventServiceResponse:       7 * time.Second,
+		api.EventReconnectInitialDelay: time.Second,
+		// The peer timeouts section is used if the timeout isn't in the timeouts section
+		api.Query: 45 * time.Second,
+		// Timeouts that aren't configured or that are zero have their defaults
+		api.OrdererResponse:           defaultTimeout,
+		api.EventConnectRetryInterval: defaultTimeout,
+		api.ConnectionIdle:            defaultConnIdleTimeout,
+	}
+	for timeoutType, timeout := range expected {
+		if actual := c.Timeout(timeoutType); actual != timeout {
+			t.Fatalf("Expected timeout %s for timeout type %d but got %s", timeout, timeoutType, actual)
+		}
+	}
+
+	if _, err := FromRaw([]byte("client:\n  timeouts:\n    endorser: -1s\n"), "yaml")(); err == nil || !strings.Contains(err.Error(), "negative") {
+		t.Fatalf("Expected error for a negative timeout but got %v", err)
+	}
+	if _, err := FromRaw([]byte("client:\n  peer:\n    timeout:\n      connection: -1s\n"), "yaml")(); err == nil {
+		t.Fatalf("Expected error for a negative timeout")
+	}
+}
+
+func TestChannelClientRetryConfig(t *testing.T) {
+	raw := []byte(`
+client:
+  organization: Org1
+  channelClient:
+    retry:
+      attempts: 3
+      initialBackoff: 500ms
+      maxBackoff: 5s
+      backoffFactor: 2.5
+`)
+	c, err := FromRaw(raw, "yaml")()
+	if err != nil {
+		t.Fatalf("Unexpected error from config: %s", err)
+	}
+	client, err := c.Client()
+	if err != nil {
+		t.Fatalf("Unexpected error from client config: %s", err)
+	}
+
+	expected := api.RetryConfig{Attempts: 3, InitialBackoff: 500 * time.Millisecond, MaxBackoff: 5 * time.Second, BackoffFactor: 2.5}
+	if client.ChannelClient.Retry != expected {
+		t.Fatalf("Expected retry config %+v but got %+v", expected, client.ChannelClient.Retry)
+	}
+}
+
 func TestOrdererConfig(t *testing.T) {
 	oConfig, err := configImpl.RandomOrdererConfig()
 
@@ -546,6 +625,978 @@ func TestFromReaderSuccess(t *testing.T) {
 	}
 }
 
+func TestFromRawReusable(t *testing.T) {
+	cBytes, err := loadConfigBytesFromFile(t, configTestFilePath)
+	if err != nil {
+		t.Fatalf("Failed to load sample bytes from File. Error: %s", err)
+	}
+
+	// The bytes aren't consumed by the first invocation of the provider
+	provider := FromRaw(cBytes, configType)
+	for i := 0; i < 2; i++ {
+		c, err := provider()
+		if err != nil {
+			t.Fatalf("Failed to initialize config from bytes array. Error: %s", err)
+		}
+		if peers, err := c.NetworkPeers(); err != nil || len(peers) == 0 {
+			t.Fatalf("Expected network peers on invocation %d but got %d (error: %v)", i+1, len(peers), err)
+		}
+	}
+}
+
+func TestFromRawInvalidKey(t *testing.T) {
+	invalid := `
+channels:
+  mychannel:
+    peers: invalid
+`
+	_, err := FromRaw([]byte(invalid), configType)()
+	if err == nil {
+		t.Fatalf("Expected error for invalid channel peers")
+	}
+	if !strings.Contains(err.Error(), "[channels]") || !strings.Contains(err.Error(), "[mychannel].Peers") {
+		t.Fatalf("Expected error to identify the key path [channels] [mychannel].Peers but got: %s", err)
+	}
+
+	_, err = FromRaw([]byte("channels: [\n"), configType)()
+	if err == nil || !strings.Contains(err.Error(), "line") {
+		t.Fatalf("Expected error to identify the line of the syntax error but got: %v", err)
+	}
+}
+
+func TestSet(t *testing.T) {
+	c, err := FromFile(configTestFilePath)()
+	if err != nil {
+		t.Fatalf("Unexpected error from config: %s", err)
+	}
+	config := c.(*Config)
+
+	org2Peer, err := config.PeerConfig("org2", "peer0.org2.example.com")
+	if err != nil {
+		t.Fatalf("Error getting peer config: %s", err)
+	}
+
+	if err := config.Set("peers.peer0.org1.example.com.url", "grpcs://localhost:17051"); err != nil {
+		t.Fatalf("Error setting peer URL: %s", err)
+	}
+	if err := config.Set("client.peer.timeout.connection", 10*time.Second); err != nil {
+		t.Fatalf("Error setting timeout: %s", err)
+	}
+	if err := config.Set("peers.peer0.org1.example.com.grpcOptions.grpc.http2.keepalive_time", 20); err != nil {
+		t.Fatalf("Error setting peer gRPC option: %s", err)
+	}
+	if err := config.Set("peers.peer0.org1.example.com.grpcOptions.my-option", true); err != nil {
+		t.Fatalf("Error adding peer gRPC option: %s", err)
+	}
+
+	peer, err := config.PeerConfig("org1", "peer0.org1.example.com")
+	if err != nil {
+		t.Fatalf("Error getting peer config: %s", err)
+	}
+	if peer.URL != "grpcs://localhost:17051" {
+		t.Fatalf("Expected overridden peer URL but got %s", peer.URL)
+	}
+	if peer.EventURL != "peer0.org1.example.com:7053" {
+		t.Fatalf("Expected the other peer settings to be unchanged but got event URL %s", peer.EventURL)
+	}
+	if keepalive := peer.GRPCOptions["grpc.http2.keepalive_time"]; fmt.Sprint(keepalive) != "20" {
+		t.Fatalf("Expected overridden peer gRPC option but got %v", keepalive)
+	}
+	if myOption, ok := peer.GRPCOptions["my-option"].(bool); !ok || !myOption {
+		t.Fatalf("Expected new peer gRPC option but got %v", peer.GRPCOptions["my-option"])
+	}
+	if timeout := config.TimeoutOrDefault(api.Endorser); timeout != 10*time.Second {
+		t.Fatalf("Expected overridden timeout but got %s", timeout)
+	}
+
+	// Only the overridden settings are changed
+	if peer, err := config.PeerConfig("org2", "peer0.org2.example.com"); err != nil || !reflect.DeepEqual(peer, org2Peer) {
+		t.Fatalf("Expected the other peers to be unchanged (error: %v)", err)
+	}
+
+	networkPeers, err := config.NetworkPeers()
+	if err != nil {
+		t.Fatalf("Error getting network peers: %s", err)
+	}
+	var found bool
+	for _, p := range networkPeers {
+		found = found || p.URL == "grpcs://localhost:17051"
+	}
+	if !found {
+		t.Fatalf("Expected overridden peer URL in the network peers")
+	}
+
+	if err := config.Set("peers.peer0.org1.example.com", "invalid"); err == nil {
+		t.Fatalf("Expected error setting a value that is not a single value")
+	}
+	if err := config.Set("client..timeout", "invalid"); err == nil {
+		t.Fatalf("Expected error setting an invalid key")
+	}
+}
+
+func TestSetEnvPrecedence(t *testing.T) {
+	os.Setenv("FABRIC_SDK_CLIENT_PEER_TIMEOUT_CONNECTION", "7s")
+	defer os.Unsetenv("FABRIC_SDK_CLIENT_PEER_TIMEOUT_CONNECTION")
+
+	c, err := FromFile(configTestFilePath)()
+	if err != nil {
+		t.Fatalf("Unexpected error from config: %s", err)
+	}
+	if err := c.(*Config).Set("client.peer.timeout.connection", "10s"); err != nil {
+		t.Fatalf("Error setting timeout: %s", err)
+	}
+	if timeout := c.TimeoutOrDefault(api.Endorser); timeout != 7*time.Second {
+		t.Fatalf("Expected timeout from environment variable but got %s", timeout)
+	}
+}
+
+func TestJSONProfile(t *testing.T) {
+	for _, yamlFile := range []string{configTestFilePath, configEmbeddedUsersTestFilePath} {
+		jsonFile := strings.TrimSuffix(yamlFile, ".yaml") + ".json"
+
+		yamlConfig, err := FromFile(yamlFile)()
+		if err != nil {
+			t.Fatalf("Unexpected error from YAML config %s: %s", yamlFile, err)
+		}
+		jsonConfig, err := FromFile(jsonFile)()
+		if err != nil {
+			t.Fatalf("Unexpected error from JSON config %s: %s", jsonFile, err)
+		}
+		jsonBytes, err := loadConfigBytesFromFile(t, jsonFile)
+		if err != nil {
+			t.Fatalf("Failed to load sample bytes from File. Error: %s", err)
+		}
+		rawConfig, err := FromRaw(jsonBytes, "json")()
+		if err != nil {
+			t.Fatalf("Unexpected error from raw JSON config %s: %s", jsonFile, err)
+		}
+
+		for _, c := range []api.Config{jsonConfig, rawConfig} {
+			checkSameConfig(t, yamlConfig, c)
+		}
+	}
+}
+
+func checkSameConfig(t *testing.T, expected api.Config, actual api.Config) {
+	expectedNetwork, err := expected.NetworkConfig()
+	if err != nil {
+		t.Fatalf("Error getting network config: %s", err)
+	}
+	actualNetwork, err := actual.NetworkConfig()
+	if err != nil {
+		t.Fatalf("Error getting network config: %s", err)
+	}
+	if !reflect.DeepEqual(expectedNetwork, actualNetwork) {
+		t.Fatalf("Expected the same network config from JSON as from YAML:\n%#v\n%#v", expectedNetwork, actualNetwork)
+	}
+
+	expectedPeers, err := expected.PeersConfig(org1)
+	if err != nil {
+		t.Fatalf("Error getting peers: %s", err)
+	}
+	actualPeers, err := actual.PeersConfig(org1)
+	if err != nil {
+		t.Fatalf("Error getting peers: %s", err)
+	}
+	if len(actualPeers) == 0 || !reflect.DeepEqual(expectedPeers, actualPeers) {
+		t.Fatalf("Expected the same peers from JSON as from YAML")
+	}
+
+	expectedCA, err := expected.CAConfig(org1)
+	if err != nil {
+		t.Fatalf("Error getting CA config: %s", err)
+	}
+	actualCA, err := actual.CAConfig(org1)
+	if err != nil {
+		t.Fatalf("Error getting CA config: %s", err)
+	}
+	if !reflect.DeepEqual(expectedCA, actualCA) {
+		t.Fatalf("Expected the same CA config from JSON as from YAML")
+	}
+
+	for conn := range timeoutSettings {
+		if expected.TimeoutOrDefault(conn) != actual.TimeoutOrDefault(conn) {
+			t.Fatalf("Expected timeout %s but got %s", expected.TimeoutOrDefault(conn), actual.TimeoutOrDefault(conn))
+		}
+	}
+	if expected.IsSecurityEnabled() != actual.IsSecurityEnabled() || expected.SecurityLevel() != actual.SecurityLevel() ||
+		expected.SecurityAlgorithm() != actual.SecurityAlgorithm() || expected.KeyStorePath() != actual.KeyStorePath() ||
+		expected.CryptoConfigPath() != actual.CryptoConfigPath() {
+		t.Fatalf("Expected the same client settings from JSON as from YAML")
+	}
+}
+
+func TestJSONProfileErrors(t *testing.T) {
+	_, err := FromRaw([]byte("{\n  \"client\": {\n    \"organization\": \"Org1\",\n  }\n}"), "json")()
+	if err == nil || !strings.Contains(err.Error(), "line 4") {
+		t.Fatalf("Expected error to identify the line of the syntax error but got: %v", err)
+	}
+
+	_, err = FromRaw([]byte(`["client"]`), "json")()
+	if err == nil || !strings.Contains(err.Error(), "expecting an object") {
+		t.Fatalf("Expected error for a JSON profile that isn't an object but got: %v", err)
+	}
+
+	_, err = FromRaw([]byte(`{"client": {"peer": {"timeout": {"connection": 30}}}}`), "json")()
+	if err == nil || !strings.Contains(err.Error(), "client.peer.timeout.connection") || !strings.Contains(err.Error(), "30s") {
+		t.Fatalf("Expected error for a timeout that isn't a duration string but got: %v", err)
+	}
+
+	_, err = FromRaw([]byte(`{"client": {"peer": {"timeout": {"connection": "thirty"}}}}`), "json")()
+	if err == nil || !strings.Contains(err.Error(), "thirty") {
+		t.Fatalf("Expected error for an invalid duration but got: %v", err)
+	}
+
+	_, err = FromRaw([]byte(`{"peers": {"peer0": {"url": "localhost:7051", "grpcOptions": {"fail-fast": "maybe"}}}}`), "json")()
+	if err == nil || !strings.Contains(err.Error(), "fail-fast") || !strings.Contains(err.Error(), "peer0") {
+		t.Fatalf("Expected error for an invalid boolean gRPC option but got: %v", err)
+	}
+}
+
+func TestJSONProfileStringBooleans(t *testing.T) {
+	c, err := FromRaw([]byte(`{"peers": {"peer0": {"url": "localhost:7051", "grpcOptions": {"fail-fast": "true", "allow-insecure": false, "keep-alive-time": "10s"}}}}`), "json")()
+	if err != nil {
+		t.Fatalf("Unexpected error from JSON config: %s", err)
+	}
+	network, err := c.NetworkConfig()
+	if err != nil {
+		t.Fatalf("Error getting network config: %s", err)
+	}
+	options := network.Peers["peer0"].GRPCOptions
+	if failFast, ok := options["fail-fast"].(bool); !ok || !failFast {
+		t.Fatalf("Expected the fail-fast option to be converted to a boolean but got %#v", options["fail-fast"])
+	}
+	if allowInsecure, ok := options["allow-insecure"].(bool); !ok || allowInsecure {
+		t.Fatalf("Expected the allow-insecure option to be false but got %#v", options["allow-insecure"])
+	}
+}
+
+func TestFromFiles(t *testing.T) {
+	c, err := FromFiles([]string{"testdata/config_topology.yaml", "testdata/config_credentials.yaml"})()
+	if err != nil {
+		t.Fatalf("Unexpected error from config files: %s", err)
+	}
+	network, err := c.NetworkConfig()
+	if err != nil {
+		t.Fatalf("Error getting network config: %s", err)
+	}
+
+	// Scalars in the later file win
+	if network.Description != "credentials" {
+		t.Fatalf("Expected description from the later file but got %s", network.Description)
+	}
+	if timeout := c.TimeoutOrDefault(api.Endorser); timeout != 10*time.Second {
+		t.Fatalf("Expected timeout from the later file but got %s", timeout)
+	}
+	if timeout := c.TimeoutOrDefault(api.Query); timeout != 45*time.Second {
+		t.Fatalf("Expected timeout from the earlier file but got %s", timeout)
+	}
+
+	// Nested maps are merged
+	org := network.Organizations["org1"]
+	if org.MspID != "Org1MSP" || org.CryptoPath == "" {
+		t.Fatalf("Expected organization to be merged from both files but got %+v", org)
+	}
+
+	// The peer that's defined in the earlier file has the TLS certificate from the later file
+	peer := network.Peers["peer0.org1.example.com"]
+	if peer.URL != "peer0.org1.example.com:7051" {
+		t.Fatalf("Expected peer URL from the earlier file but got %s", peer.URL)
+	}
+	if !strings.Contains(peer.TLSCACerts.Pem, "BEGIN CERTIFICATE") {
+		t.Fatalf("Expected peer TLS certificate from the later file but got %s", peer.TLSCACerts.Pem)
+	}
+	if peer.GRPCOptions["ssl-target-name-override"] != "peer0.org1.example.com" || peer.GRPCOptions["allow-insecure"] != true {
+		t.Fatalf("Expected peer gRPC options to be merged from both files but got %v", peer.GRPCOptions)
+	}
+
+	// Lists are replaced
+	if orderers := network.Channels["mychannel"].Orderers; !reflect.DeepEqual(orderers, []string{"orderer3.example.com"}) {
+		t.Fatalf("Expected channel orderers from the later file but got %v", orderers)
+	}
+	if _, ok := network.Channels["mychannel"].Peers["peer0.org1.example.com"]; !ok {
+		t.Fatalf("Expected channel peers from the earlier file")
+	}
+
+	dump, err := c.(*Config).Dump()
+	if err != nil {
+		t.Fatalf("Error dumping config: %s", err)
+	}
+	for _, expected := range []string{"description: credentials", "orderer3.example.com", "BEGIN CERTIFICATE", "ssl-target-name-override"} {
+		if !strings.Contains(string(dump), expected) {
+			t.Fatalf("Expected dump to contain [%s] but got:\n%s", expected, dump)
+		}
+	}
+	if strings.Contains(string(dump), "orderer1.example.com") {
+		t.Fatalf("Expected dump not to contain the replaced list")
+	}
+
+	if _, err := FromFiles([]string{"testdata/config_topology.yaml", "notarealfile.yaml"})(); err == nil {
+		t.Fatalf("Expected error for a missing config file")
+	}
+	if _, err := FromFiles(nil)(); err == nil {
+		t.Fatalf("Expected error for no config files")
+	}
+}
+
+func TestEntityMatchers(t *testing.T) {
+	c, err := FromFile("testdata/config_entity_matchers.yaml")()
+	if err != nil {
+		t.Fatalf("Unexpected error from config: %s", err)
+	}
+
+	// The capture groups of the pattern are substituted
+	for i, name := range []string{"peer0.org1.example.com", "peer1.org1.example.com"} {
+		peer, err := c.PeerConfig(org1, name)
+		if err != nil {
+			t.Fatalf("Error getting peer config: %s", err)
+		}
+		if peer.URL != fmt.Sprintf("grpcs://localhost:1%d051", i) || peer.EventURL != fmt.Sprintf("grpcs://localhost:1%d053", i) {
+			t.Fatalf("Expected peer URLs to be substituted but got %s and %s", peer.URL, peer.EventURL)
+		}
+		if override := peer.GRPCOptions["ssl-target-name-override"]; override != name {
+			t.Fatalf("Expected SSL target name override %s but got %v", name, override)
+		}
+		if peer.TLSCACerts.Pem != "org1-tls-cert" {
+			t.Fatalf("Expected the other peer settings to be unchanged")
+		}
+	}
+
+	// Peers that don't match are passed through
+	peer, err := c.PeerConfig(org1, "peer0.org2.example.com")
+	if err != nil {
+		t.Fatalf("Error getting peer config: %s", err)
+	}
+	if peer.URL != "grpcs://peer0.org2.example.com:8051" || peer.EventURL != "grpcs://peer0.org2.example.com:8053" || peer.GRPCOptions != nil {
+		t.Fatalf("Expected peer that doesn't match to be unchanged but got %+v", peer)
+	}
+
+	// The matchers apply to all of the peer lookups
+	networkPeers, err := c.NetworkPeers()
+	if err != nil {
+		t.Fatalf("Error getting network peers: %s", err)
+	}
+	if _, ok := peersByURL(networkPeers)["grpcs://localhost:10051"]; !ok {
+		t.Fatalf("Expected substituted URL in the network peers")
+	}
+
+	orderer, err := c.OrdererConfig("orderer.example.com")
+	if err != nil {
+		t.Fatalf("Error getting orderer config: %s", err)
+	}
+	if orderer.URL != "grpcs://localhost:7050" || orderer.GRPCOptions["ssl-target-name-override"] != "orderer.example.com" {
+		t.Fatalf("Expected orderer URL to be substituted but got %+v", orderer)
+	}
+
+	// An orderer address that's learned at runtime uses the configuration of the mapped host
+	orderer, err = c.OrdererConfig("orderer2.example.com:7050")
+	if err != nil {
+		t.Fatalf("Error getting orderer config: %s", err)
+	}
+	if orderer == nil || orderer.URL != "grpcs://localhost:17050" || orderer.TLSCACerts.Pem != "orderer-tls-cert" {
+		t.Fatalf("Expected orderer config from the mapped host but got %+v", orderer)
+	}
+	if override := orderer.GRPCOptions["ssl-target-name-override"]; override != "orderer2.example.com" {
+		t.Fatalf("Expected SSL target name override orderer2.example.com but got %v", override)
+	}
+
+	// The configuration of the mapped host is unchanged
+	orderer, err = c.OrdererConfig("orderer.example.com")
+	if err != nil || orderer.GRPCOptions["ssl-target-name-override"] != "orderer.example.com" {
+		t.Fatalf("Expected the mapped host to be unchanged but got %+v (error: %v)", orderer, err)
+	}
+
+	if orderer, err := c.OrdererConfig("unknown.example.com:7050"); err != nil || orderer != nil {
+		t.Fatalf("Expected no orderer config for an address that doesn't match but got %+v (error: %v)", orderer, err)
+	}
+}
+
+func TestEntityMatchersInvalidPattern(t *testing.T) {
+	invalid := `
+entityMatchers:
+  peer:
+    - pattern: (peer
+      urlSubstitutionExp: localhost:7051
+`
+	_, err := FromRaw([]byte(invalid), configType)()
+	if err == nil || !strings.Contains(err.Error(), "(peer") {
+		t.Fatalf("Expected error for an invalid pattern but got: %v", err)
+	}
+}
+
+func TestDefaultChannel(t *testing.T) {
+	c, err := FromFile("testdata/config_default_channel.yaml")()
+	if err != nil {
+		t.Fatalf("Unexpected error from config: %s", err)
+	}
+
+	// A channel that isn't configured gets the default configuration
+	ch, err := c.ChannelConfig("unknownchannel")
+	if err != nil || ch == nil {
+		t.Fatalf("Expected the default channel config but got %v, %v", ch, err)
+	}
+	if !reflect.DeepEqual(ch.Orderers, []string{"orderer.example.com"}) || !reflect.DeepEqual(ch.Chaincodes, []string{"example02:v1"}) {
+		t.Fatalf("Expected the default orderers and chaincodes but got %v and %v", ch.Orderers, ch.Chaincodes)
+	}
+	peers, err := c.ChannelPeers("unknownchannel")
+	if err != nil {
+		t.Fatalf("Error getting channel peers: %s", err)
+	}
+	if len(peers) != 1 || peers[0].URL != "grpcs://peer0.org1.example.com:7051" || !peers[0].EventSource || peers[0].MspID != "Org1MSP" {
+		t.Fatalf("Expected the default channel peer but got %+v", peers)
+	}
+	orderers, err := c.ChannelOrderers("unknownchannel")
+	if err != nil || len(orderers) != 1 || orderers[0].URL != "grpcs://orderer.example.com:7050" {
+		t.Fatalf("Expected the default channel orderer but got %+v, %v", orderers, err)
+	}
+
+	// The settings of a configured channel override the default settings field-by-field
+	ch, err = c.ChannelConfig("orgchannel")
+	if err != nil || ch == nil {
+		t.Fatalf("Expected channel config but got %v, %v", ch, err)
+	}
+	if !reflect.DeepEqual(ch.Orderers, []string{"orderer.example.com"}) || !reflect.DeepEqual(ch.Chaincodes, []string{"marbles:1.0"}) {
+		t.Fatalf("Expected the default orderers and the configured chaincodes but got %v and %v", ch.Orderers, ch.Chaincodes)
+	}
+	peers, err = c.ChannelPeers("orgchannel")
+	if err != nil {
+		t.Fatalf("Error getting channel peers: %s", err)
+	}
+	if len(peers) != 1 || peers[0].URL != "grpcs://peer1.org1.example.com:7051" || peers[0].EventSource || peers[0].ChaincodeQuery {
+		t.Fatalf("Expected the configured channel peer but got %+v", peers)
+	}
+
+	orderers, err = c.ChannelOrderers("ordererChannel")
+	if err != nil || len(orderers) != 1 || orderers[0].URL != "grpcs://orderer2.example.com:7050" {
+		t.Fatalf("Expected the configured channel orderer but got %+v, %v", orderers, err)
+	}
+	peers, err = c.ChannelPeers("ordererChannel")
+	if err != nil || len(peers) != 1 || peers[0].URL != "grpcs://peer0.org1.example.com:7051" {
+		t.Fatalf("Expected the default channel peer but got %+v, %v", peers, err)
+	}
+}
+
+func TestNoDefaultChannel(t *testing.T) {
+	ch, err := configImpl.ChannelConfig("unknownchannel")
+	if err != nil || ch != nil {
+		t.Fatalf("Expected no channel config but got %v, %v", ch, err)
+	}
+	if _, err := configImpl.ChannelPeers("unknownchannel"); err == nil {
+		t.Fatal("Expected error getting the peers of a channel that isn't configured")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		expected []string
+	}{
+		{name: "Valid", file: configTestFilePath},
+		{name: "ValidInlinePems", file: configInlinePemsTestFilePath},
+		{
+			name: "Channels",
+			file: "testdata/invalid/channels.yaml",
+			expected: []string{
+				"channels.mychannel.peers.peer9.org1.example.com: peer not defined",
+				"channels.mychannel.orderers.orderer9.example.com: orderer not defined",
+				"channels.otherchannel.orderers: no orderers configured",
+			},
+		},
+		{
+			name: "Organizations",
+			file: "testdata/invalid/organizations.yaml",
+			expected: []string{
+				"organizations.org1.cryptoPath: MSP directory [/does/not/exist/crypto-config/peerOrganizations/org1.example.com/users] does not exist",
+				"organizations.org2: neither a cryptoPath, embedded users nor an existing credential store is configured",
+			},
+		},
+		{
+			name: "TLSCertificates",
+			file: "testdata/invalid/tls.yaml",
+			expected: []string{
+				"client.tlsCerts.client.key.path: file [/does/not/exist/client-key.pem] is not readable",
+				"orderers.orderer.example.com.tlsCACerts.path: file [/does/not/exist/orderer-tls-cert.pem] is not readable",
+				"certificateAuthorities.ca.org1.example.com.tlsCACerts.path: file [/does/not/exist/ca-root.pem] is not readable",
+				"certificateAuthorities.ca.org1.example.com.tlsCACerts.client.key.path: file [/does/not/exist/ca-client-key.pem] is not readable",
+			},
+		},
+		{
+			name: "URLs",
+			file: "testdata/invalid/urls.yaml",
+			expected: []string{
+				"peers.peer0.org1.example.com.eventUrl: invalid URL [grpcs://peer0.org1.example.com:%53]",
+				"peers.peer1.org1.example.com.url: URL not configured",
+				"orderers.orderer.example.com.url: invalid URL [grpcs://orderer example.com:7050]",
+				"certificateAuthorities.ca.org1.example.com.url: invalid URL [ca.org1.example.com:7054]: the host is missing",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, err := FromFile(test.file)()
+			if err != nil {
+				t.Fatalf("Unexpected error from config: %s", err)
+			}
+
+			errs := c.(*Config).Validate()
+			if len(errs) != len(test.expected) {
+				t.Fatalf("Expected %d validation errors but got %d: %v", len(test.expected), len(errs), errs)
+			}
+			for i, expected := range test.expected {
+				if !strings.HasPrefix(errs[i].Error(), expected) {
+					t.Fatalf("Expected validation error [%s] but got [%s]", expected, errs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestValidateSQLCredentialStore(t *testing.T) {
+	c, err := FromFile("testdata/invalid/organizations.yaml")()
+	if err != nil {
+		t.Fatalf("Unexpected error from config: %s", err)
+	}
+	if err := c.(*Config).Set("client.credentialStore.type", "sql"); err != nil {
+		t.Fatalf("Unexpected error setting the credential store type: %s", err)
+	}
+
+	// The users of the organizations may be enrolled in the SQL credential store
+	if errs := c.(*Config).Validate(); len(errs) != 0 {
+		t.Fatalf("Expected no validation errors with an SQL credential store but got %v", errs)
+	}
+}
+
+func TestSecurityProviderSessionPool(t *testing.T) {
+	c, err := FromFile("../../../test/fixtures/config/config_pkcs11_test.yaml")()
+	if err != nil {
+		t.Fatalf("Unexpected error from config: %s", err)
+	}
+	expected := api.SessionPoolConfig{Size: 10, Timeout: 10 * time.Second, Retries: 3}
+	if sessionPool := c.SecurityProviderSessionPool(); sessionPool != expected {
+		t.Fatalf("Expected session pool %+v but got %+v", expected, sessionPool)
+	}
+
+	// The defaults of the session pool are used when it isn't configured
+	c, err = FromFile(configTestFilePath)()
+	if err != nil {
+		t.Fatalf("Unexpected error from config: %s", err)
+	}
+	if sessionPool := c.SecurityProviderSessionPool(); sessionPool != (api.SessionPoolConfig{}) {
+		t.Fatalf("Expected no session pool settings but got %+v", sessionPool)
+	}
+}
+
+func TestEnvSubstitution(t *testing.T) {
+	const profile = `
+# The comments aren't substituted: ${TEST_UNSET_IN_COMMENT}
+client:
+  organization: ${TEST_CLIENT_ORG:-Org1}
+  logging:
+    level: ${TEST_LOGGING_LEVEL:-info}
+peers:
+  ${TEST_PEER0_NAME:-peer0}.org1.example.com:
+    url: ${TEST_PEER0_URL}
+    grpcOptions:
+      ssl-target-name-override: ${TEST_PEER0_HOST:-}peer0.org1.example.com
+      fail-fast: ${TEST_FAIL_FAST:-false}
+entityMatchers:
+  peer:
+    - pattern: ^(peer\d+)\.org1\.example\.com$
+      sslTargetOverrideUrlSubstitutionExp: ${1}.org1.example.com
+`
+	defer os.Unsetenv("TEST_PEER0_URL")
+	defer os.Unsetenv("TEST_LOGGING_LEVEL")
+	defer os.Unsetenv("TEST_FAIL_FAST")
+	os.Unsetenv("TEST_UNSET_IN_COMMENT")
+	os.Setenv("TEST_FAIL_FAST", "true")
+
+	// A variable without a default must be set
+	os.Unsetenv("TEST_PEER0_URL")
+	_, err := FromRaw([]byte(profile), configType)()
+	if err == nil || !strings.Contains(err.Error(), "TEST_PEER0_URL") {
+		t.Fatalf("Expected error for an environment variable that isn't set but got: %v", err)
+	}
+
+	os.Setenv("TEST_PEER0_URL", "grpcs://localhost:17051")
+	os.Setenv("TEST_LOGGING_LEVEL", "")
+	c, err := FromRaw([]byte(profile), configType)()
+	if err != nil {
+		t.Fatalf("Unexpected error from config: %s", err)
+	}
+	client, err := c.Client()
+	if err != nil {
+		t.Fatalf("Error getting client config: %s", err)
+	}
+	if client.Organization != "Org1" || client.Logging.Level != "info" {
+		t.Fatalf("Expected default organization and logging level but got %s and %s", client.Organization, client.Logging.Level)
+	}
+	networkConfig, err := c.NetworkConfig()
+	if err != nil {
+		t.Fatalf("Error getting network config: %s", err)
+	}
+	peer0 := networkConfig.Peers["peer0.org1.example.com"]
+	if peer0.URL != "grpcs://localhost:17051" {
+		t.Fatalf("Expected the peer URL from the environment but got %s", peer0.URL)
+	}
+	// The entity matcher expressions aren't environment variables
+	if override := peer0.GRPCOptions["ssl-target-name-override"]; override != "peer0.org1.example.com" {
+		t.Fatalf("Expected SSL target name override peer0.org1.example.com but got %v", override)
+	}
+	// The values are substituted before the profile is parsed, so they're typed as if they were in the profile
+	if failFast := peer0.GRPCOptions["fail-fast"]; failFast != true {
+		t.Fatalf("Expected fail-fast to be the boolean true but got %#v", failFast)
+	}
+}
+
+func TestIncludes(t *testing.T) {
+	defer os.Unsetenv("TEST_PEER0_EVENT_URL")
+
+	os.Unsetenv("TEST_PEER0_EVENT_URL")
+	_, err := FromFile("testdata/config_includes.yaml")()
+	if err == nil || !strings.Contains(err.Error(), "TEST_PEER0_EVENT_URL") || !strings.Contains(err.Error(), "peer0.org1.example.com.json") {
+		t.Fatalf("Expected error for an environment variable that isn't set in an included file but got: %v", err)
+	}
+
+	os.Setenv("TEST_PEER0_EVENT_URL", "grpcs://localhost:7053")
+	c, err := FromFile("testdata/config_includes.yaml")()
+	if err != nil {
+		t.Fatalf("Unexpected error from config: %s", err)
+	}
+
+	// The peer is included from a file that's included by an included file
+	peers, err := c.PeersConfig("org1")
+	if err != nil {
+		t.Fatalf("Error getting peers: %s", err)
+	}
+	if len(peers) != 1 || peers[0].URL != "grpcs://localhost:7051" || peers[0].EventURL != "grpcs://localhost:7053" {
+		t.Fatalf("Expected the included peer but got %+v", peers)
+	}
+	if override := peers[0].GRPCOptions["ssl-target-name-override"]; override != "peer0.org1.example.com" {
+		t.Fatalf("Expected SSL target name override peer0.org1.example.com but got %v", override)
+	}
+	if _, err := peers[0].TLSCACerts.TLSCert(); err != nil {
+		t.Fatalf("Expected the path variables to be substituted in the included file but got: %s", err)
+	}
+
+	// The includes of a raw profile are relative to the working directory
+	_, err = FromRaw([]byte("peers: $include:testdata/includes/cycle-a.yaml"), configType)()
+	if err == nil || !strings.Contains(err.Error(), "include cycle") {
+		t.Fatalf("Expected error for an include cycle but got: %v", err)
+	}
+	_, err = FromRaw([]byte("peers: $include:testdata/includes/notarealfile.yaml"), configType)()
+	if err == nil || !strings.Contains(err.Error(), "notarealfile.yaml") {
+		t.Fatalf("Expected error for an included file that doesn't exist but got: %v", err)
+	}
+}
+
+func TestGRPCSizeOptions(t *testing.T) {
+	c, err := FromRaw([]byte(`{"peers": {"peer0": {"url": "localhost:7051", "grpcOptions": {"max-recv-msg-size": "16777216", "max-send-msg-size": 8388608}}}}`), "json")()
+	if err != nil {
+		t.Fatalf("Unexpected error from JSON config: %s", err)
+	}
+	networkConfig, err := c.NetworkConfig()
+	if err != nil {
+		t.Fatalf("Error getting network config: %s", err)
+	}
+	grpcOptions := networkConfig.Peers["peer0"].GRPCOptions
+	if grpcOptions["max-recv-msg-size"] != 16777216 || grpcOptions["max-send-msg-size"] != 8388608 {
+		t.Fatalf("Expected the message sizes to be ints but got %#v", grpcOptions)
+	}
+
+	for _, size := range []string{`"16MB"`, "0", "-1", "true"} {
+		_, err := FromRaw([]byte(`{"orderers": {"orderer0": {"url": "localhost:7050", "grpcOptions": {"max-recv-msg-size": `+size+`}}}}`), "json")()
+		if err == nil || !strings.Contains(err.Error(), "max-recv-msg-size") {
+			t.Fatalf("Expected error for an invalid message size %s but got: %v", size, err)
+		}
+	}
+}
+
+// peersByURL returns the given peers keyed by URL
+func peersByURL(peers []api.NetworkPeer) map[string]api.NetworkPeer {
+	m := make(map[string]api.NetworkPeer)
+	for _, p := range peers {
+		m[p.URL] = p
+	}
+	return m
+}
+
+func TestReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	topology, err := ioutil.ReadFile("testdata/config_topology.yaml")
+	if err != nil {
+		t.Fatalf("Failed to read config file: %s", err)
+	}
+	file := filepath.Join(dir, "config.yaml")
+	if err := ioutil.WriteFile(file, topology, 0600); err != nil {
+		t.Fatalf("Failed to write config file: %s", err)
+	}
+
+	c, err := FromFile(file)()
+	if err != nil {
+		t.Fatalf("Unexpected error from config file: %s", err)
+	}
+	cfg := c.(*Config)
+	if err := cfg.Set("client.peer.timeout.connection", "7s"); err != nil {
+		t.Fatalf("Unexpected error from Set: %s", err)
+	}
+
+	updated := make(chan *api.ConfigUpdatedEvent, 1)
+	cfg.AddListener(func(event *api.ConfigUpdatedEvent) {
+		updated <- event
+	})
+
+	reloadedURL := "peer0.org1.example.com:8051"
+	changed := strings.Replace(string(topology), "peer0.org1.example.com:7051", reloadedURL, 1)
+	if err := ioutil.WriteFile(file, []byte(changed), 0600); err != nil {
+		t.Fatalf("Failed to write config file: %s", err)
+	}
+	if err := cfg.Reload(); err != nil {
+		t.Fatalf("Unexpected error from Reload: %s", err)
+	}
+
+	select {
+	case event := <-updated:
+		if event.Config != c {
+			t.Fatalf("Expected the reloaded config in the event")
+		}
+	default:
+		t.Fatalf("Expected the listener to be notified")
+	}
+
+	peer, err := c.PeerConfig("org1", "peer0.org1.example.com")
+	if err != nil || peer == nil {
+		t.Fatalf("Error getting peer config: %v", err)
+	}
+	if peer.URL != reloadedURL {
+		t.Fatalf("Expected reloaded peer URL but got %s", peer.URL)
+	}
+	if timeout := c.TimeoutOrDefault(api.Endorser); timeout != 7*time.Second {
+		t.Fatalf("Expected the setting to be applied again but got %s", timeout)
+	}
+
+	// The previous configuration is kept if the file can't be parsed
+	if err := ioutil.WriteFile(file, []byte("peers: [\n"), 0600); err != nil {
+		t.Fatalf("Failed to write config file: %s", err)
+	}
+	if err := cfg.Reload(); err == nil {
+		t.Fatalf("Expected error reloading an invalid config file")
+	}
+	if peer, _ := c.PeerConfig("org1", "peer0.org1.example.com"); peer == nil || peer.URL != reloadedURL {
+		t.Fatalf("Expected the previous configuration to be kept")
+	}
+
+	stop, err := cfg.Watch()
+	if err != nil {
+		t.Fatalf("Unexpected error from Watch: %s", err)
+	}
+	stop()
+
+	reader, err := FromReader(bytes.NewReader(topology), "yaml")()
+	if err != nil {
+		t.Fatalf("Unexpected error from config reader: %s", err)
+	}
+	if err := reader.(*Config).Reload(); err == nil {
+		t.Fatalf("Expected error reloading a config that was loaded from a reader")
+	}
+
+	raw, err := FromRaw(topology, "yaml")()
+	if err != nil {
+		t.Fatalf("Unexpected error from raw config: %s", err)
+	}
+	if err := raw.(*Config).Reload(); err != nil {
+		t.Fatalf("Unexpected error reloading raw config: %s", err)
+	}
+	if _, err := raw.(*Config).Watch(); err == nil {
+		t.Fatalf("Expected error watching a raw config")
+	}
+}
+
+func TestReloadTLSCACert(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// The TLS CA of the peer is in a separate file, which is merged with the topology
+	tlsFile := filepath.Join(dir, "tls.yaml")
+	writeTLSCACert := func(caCert *x509.Certificate) {
+		caPem := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caCert.Raw})
+		tlsConfig := "peers:\n  peer0.org1.example.com:\n    tlsCACerts:\n      pem: |\n        " +
+			strings.Replace(strings.TrimSpace(string(caPem)), "\n", "\n        ", -1) + "\n"
+		if err := ioutil.WriteFile(tlsFile, []byte(tlsConfig), 0600); err != nil {
+			t.Fatalf("Failed to write config file: %s", err)
+		}
+	}
+
+	oldServer := testutils.NewServerCert(t, "localhost")
+	newServer := testutils.NewServerCert(t, "localhost")
+	oldServerCert, oldCACert := oldServer.TLS, oldServer.X509
+	newServerCert, newCACert := newServer.TLS, newServer.X509
+	writeTLSCACert(oldCACert)
+
+	c, err := FromFiles([]string{"testdata/config_topology.yaml", tlsFile})()
+	if err != nil {
+		t.Fatalf("Unexpected error from config files: %s", err)
+	}
+	if err := peerTLSHandshake(c, oldServerCert); err != nil {
+		t.Fatalf("Expected the server certificate to be trusted: %s", err)
+	}
+	if err := peerTLSHandshake(c, newServerCert); err == nil {
+		t.Fatalf("Expected the server certificate of the new CA not to be trusted before the config is reloaded")
+	}
+
+	writeTLSCACert(newCACert)
+	if err := c.(*Config).Reload(); err != nil {
+		t.Fatalf("Unexpected error from Reload: %s", err)
+	}
+
+	// The CA that was added to the previous cert pool isn't trusted anymore
+	if err := peerTLSHandshake(c, newServerCert); err != nil {
+		t.Fatalf("Expected the server certificate of the new CA to be trusted: %s", err)
+	}
+	if err := peerTLSHandshake(c, oldServerCert); err == nil {
+		t.Fatalf("Expected the server certificate of the previous CA not to be trusted after the config is reloaded")
+	}
+}
+
+// peerTLSHandshake performs a TLS handshake with a server that has the given certificate, using the
+// TLS config of peer0.org1.example.com
+func peerTLSHandshake(c api.Config, serverCert tls.Certificate) error {
+	peer, err := c.PeerConfig(org1, "peer0.org1.example.com")
+	if err != nil || peer == nil {
+		return errors.Errorf("failed to get peer config: %v", err)
+	}
+	peerCert, err := peer.TLSCACerts.TLSCert()
+	if err != nil {
+		return errors.WithMessage(err, "failed to load peer TLS CA cert")
+	}
+	tlsConfig, err := comm.TLSConfig(peerCert, "localhost", c)
+	if err != nil {
+		return errors.WithMessage(err, "failed to create TLS config")
+	}
+
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	defer serverConn.Close()
+	go tls.Server(serverConn, &tls.Config{Certificates: []tls.Certificate{serverCert}}).Handshake()
+	return tls.Client(clientConn, tlsConfig).Handshake()
+}
+
+func TestSetConcurrentReload(t *testing.T) {
+	c, err := FromFile("testdata/config_topology.yaml")()
+	if err != nil {
+		t.Fatalf("Unexpected error from config file: %s", err)
+	}
+	cfg := c.(*Config)
+
+	const n = 50
+	var wg sync.WaitGroup
+	errs := make(chan error, 3*n)
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < n; i++ {
+			if err := cfg.Set("client.peer.timeout.connection", fmt.Sprintf("%ds", i+1)); err != nil {
+				errs <- err
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < n; i++ {
+			if err := cfg.Reload(); err != nil {
+				errs <- err
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < n; i++ {
+			if _, err := c.PeerConfig(org1, "peer0.org1.example.com"); err != nil {
+				errs <- err
+			}
+			c.TimeoutOrDefault(api.Endorser)
+		}
+	}()
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	// None of the settings is lost by a concurrent reload
+	if timeout := c.TimeoutOrDefault(api.Endorser); timeout != n*time.Second {
+		t.Fatalf("Expected the last setting to be applied but got %s", timeout)
+	}
+	if len(cfg.settings) != n {
+		t.Fatalf("Expected %d settings but got %d", n, len(cfg.settings))
+	}
+}
+
+func TestWatch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	topology, err := ioutil.ReadFile("testdata/config_topology.yaml")
+	if err != nil {
+		t.Fatalf("Failed to read config file: %s", err)
+	}
+	file := filepath.Join(dir, "config.yaml")
+	if err := ioutil.WriteFile(file, topology, 0600); err != nil {
+		t.Fatalf("Failed to write config file: %s", err)
+	}
+
+	c, err := FromFile(file)()
+	if err != nil {
+		t.Fatalf("Unexpected error from config file: %s", err)
+	}
+	cfg := c.(*Config)
+
+	updated := make(chan struct{}, 10)
+	cfg.AddListener(func(event *api.ConfigUpdatedEvent) {
+		updated <- struct{}{}
+	})
+
+	stop, err := cfg.Watch()
+	if err != nil {
+		t.Fatalf("Unexpected error from Watch: %s", err)
+	}
+	defer stop()
+
+	// Other files in the directory are ignored
+	if err := ioutil.WriteFile(filepath.Join(dir, "other.yaml"), topology, 0600); err != nil {
+		t.Fatalf("Failed to write file: %s", err)
+	}
+
+	changed := strings.Replace(string(topology), "peer0.org1.example.com:7051", "peer0.org1.example.com:8051", 1)
+	if err := ioutil.WriteFile(file, []byte(changed), 0600); err != nil {
+		t.Fatalf("Failed to write config file: %s", err)
+	}
+
+	select {
+	case <-updated:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for the config to be reloaded")
+	}
+	if peer, _ := c.PeerConfig("org1", "peer0.org1.example.com"); peer == nil || peer.URL != "peer0.org1.example.com:8051" {
+		t.Fatalf("Expected the changed config file to be reloaded")
+	}
+
+	stop()
+	stop()
+}
+
 func TestFromFileEmptyFilename(t *testing.T) {
 	_, err := FromFile("")()
 	if err == nil {
@@ -725,6 +1776,58 @@ func TestNetworkConfig(t *testing.T) {
 	}
 }
 
+func TestPeerGroups(t *testing.T) {
+	cBytes, err := loadConfigBytesFromFile(t, configTestFilePath)
+	if err != nil {
+		t.Fatalf("Failed to load sample bytes from File. Error: %s", err)
+	}
+
+	groups := `
+peerGroups:
+  readers:
+    use: query
+    members:
+      - peer: peer0.org1.example.com
+        weight: 80
+      - peer: peer0.org2.example.com
+        weight: 20
+`
+	c, err := FromRaw(append(cBytes, []byte(groups)...), configType)()
+	if err != nil {
+		t.Fatalf("Failed to initialize config from bytes array. Error: %s", err)
+	}
+
+	netConfig, err := c.NetworkConfig()
+	if err != nil {
+		t.Fatalf("Failed to get network config: %s", err)
+	}
+
+	group, ok := netConfig.PeerGroups["readers"]
+	if !ok {
+		t.Fatalf("Expected peer group [readers] to be present in network configuration")
+	}
+	if group.Use != api.PeerGroupUseQuery {
+		t.Fatalf("Expected peer group use [%s] but got [%s]", api.PeerGroupUseQuery, group.Use)
+	}
+	if len(group.Members) != 2 || group.Members[0].Peer != "peer0.org1.example.com" || group.Members[0].Weight != 80 {
+		t.Fatalf("Unexpected peer group members: %+v", group.Members)
+	}
+
+	invalidGroups := []string{
+		"\npeerGroups:\n  readers:\n    members:\n      - peer: unknown.example.com\n",
+		"\npeerGroups:\n  readers:\n    use: unknown\n    members:\n      - peer: peer0.org1.example.com\n",
+		"\npeerGroups:\n  readers:\n    members:\n      - peer: peer0.org1.example.com\n        weight: -1\n",
+		"\npeerGroups:\n  readers:\n    members:\n      - peer: peer0.org1.example.com\n        weight: 0\n",
+		"\npeerGroups:\n  readers:\n    members:\n      - peer: peer0.org1.example.com\n",
+		"\npeerGroups:\n  readers:\n    use: query\n",
+	}
+	for _, invalid := range invalidGroups {
+		if _, err := FromRaw(append(cBytes, []byte(invalid)...), configType)(); err == nil {
+			t.Fatalf("Expected config to fail validation for peer groups: %s", invalid)
+		}
+	}
+}
+
 func TestMain(m *testing.M) {
 	setUp(m)
 	r := m.Run()
@@ -802,6 +1905,68 @@ func TestSystemCertPoolEnabled(t *testing.T) {
 
 }
 
+func TestSystemCertPoolFactory(t *testing.T) {
+	server := testutils.NewServerCert(t, "localhost")
+	serverCert, caCert := server.TLS, server.X509
+	defer func(factory func() (*x509.CertPool, error)) { systemCertPool = factory }(systemCertPool)
+	systemCertPool = func() (*x509.CertPool, error) {
+		pool := x509.NewCertPool()
+		pool.AddCert(caCert)
+		return pool, nil
+	}
+
+	c, err := FromFile(configPemTestFilePath)()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The CAs in the profile are added to the system cert pool
+	peer, err := c.PeerConfig(org1, "peer0.org1.example.com")
+	if err != nil || peer == nil {
+		t.Fatalf("Failed to get peer config: %v", err)
+	}
+	peerCert, err := peer.TLSCACerts.TLSCert()
+	if err != nil {
+		t.Fatalf("Failed to load peer TLS CA cert: %s", err)
+	}
+	tlsConfig, err := comm.TLSConfig(peerCert, "localhost", c)
+	if err != nil {
+		t.Fatalf("Failed to create TLS config: %s", err)
+	}
+	if !containsSubject(tlsConfig.RootCAs, caCert) || !containsSubject(tlsConfig.RootCAs, peerCert) {
+		t.Fatalf("Expected the cert pool to contain the system CA and the profile's CA")
+	}
+
+	// A server with a certificate issued by the system CA is trusted without a CA in the profile
+	tlsConfig, err = comm.TLSConfig(nil, "localhost", c)
+	if err != nil {
+		t.Fatalf("Failed to create TLS config: %s", err)
+	}
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	defer serverConn.Close()
+	go tls.Server(serverConn, &tls.Config{Certificates: []tls.Certificate{serverCert}}).Handshake()
+	if err := tls.Client(clientConn, tlsConfig).Handshake(); err != nil {
+		t.Fatalf("Expected the server certificate to be trusted: %s", err)
+	}
+}
+
+func TestSystemCertPoolUnavailable(t *testing.T) {
+	defer func(factory func() (*x509.CertPool, error)) { systemCertPool = factory }(systemCertPool)
+	systemCertPool = func() (*x509.CertPool, error) {
+		return nil, errors.New("no system cert pool on this platform")
+	}
+
+	configProvider, err := FromFile(configPemTestFilePath)()
+	if err != nil {
+		t.Fatalf("Expected an empty cert pool when the system cert pool isn't available but got: %s", err)
+	}
+	c := configProvider.(*Config)
+	if len(c.tlsCertPool.Subjects()) > 0 {
+		t.Fatal("Expecting empty tls cert pool when the system cert pool isn't available")
+	}
+}
+
 func TestSetTLSCACertPool(t *testing.T) {
 	configImpl.SetTLSCACertPool(nil)
 	t.Log("TLSCACertRoot must be created. Nothing additional to verify..")
@@ -927,6 +2092,123 @@ O94CDp7l2k7hMQI0zQ==
 	}
 }
 
+func TestInlinePemsProfile(t *testing.T) {
+	c, err := FromFile(configInlinePemsTestFilePath)()
+	if err != nil {
+		t.Fatalf("Unexpected error from config: %s", err)
+	}
+
+	clientCerts, err := c.TLSClientCerts()
+	if err != nil {
+		t.Fatalf("Failed to load the embedded client TLS cert: %s", err)
+	}
+	if len(clientCerts) != 1 || len(clientCerts[0].Certificate) == 0 || clientCerts[0].PrivateKey == nil {
+		t.Fatalf("Expected the embedded client TLS cert and key")
+	}
+
+	peer, err := c.PeerConfig(org1, "peer0.org1.example.com")
+	if err != nil || peer == nil {
+		t.Fatalf("Failed to get peer config: %v", err)
+	}
+	orderer, err := c.OrdererConfig("orderer.example.com")
+	if err != nil || orderer == nil {
+		t.Fatalf("Failed to get orderer config: %v", err)
+	}
+	for _, tlsCACerts := range []api.TLSConfig{peer.TLSCACerts, orderer.TLSCACerts} {
+		cert, err := tlsCACerts.TLSCert()
+		if err != nil {
+			t.Fatalf("Failed to load the embedded TLS CA cert: %s", err)
+		}
+		tlsConfig, err := comm.TLSConfig(cert, "", c)
+		if err != nil {
+			t.Fatalf("Failed to create TLS config: %s", err)
+		}
+		if !containsSubject(tlsConfig.RootCAs, cert) {
+			t.Fatalf("Expected the embedded TLS CA cert in the cert pool")
+		}
+	}
+
+	caCerts, err := c.CAServerCertPems(org1)
+	if err != nil || len(caCerts) != 1 {
+		t.Fatalf("Expected the embedded CA server cert but got %v (%v)", caCerts, err)
+	}
+	if pem, err := c.CAClientCertPem(org1); err != nil || pem == "" {
+		t.Fatalf("Expected the embedded CA client cert: %v", err)
+	}
+	if pem, err := c.CAClientKeyPem(org1); err != nil || pem == "" {
+		t.Fatalf("Expected the embedded CA client key: %v", err)
+	}
+
+	// The configuration is the same after a round trip through its YAML dump
+	dump, err := c.(*Config).Dump()
+	if err != nil {
+		t.Fatalf("Failed to dump config: %s", err)
+	}
+	if strings.Contains(string(dump), "path") {
+		t.Fatalf("Expected no paths in the dump of an inline profile:\n%s", dump)
+	}
+	reloaded, err := FromRaw(dump, "yaml")()
+	if err != nil {
+		t.Fatalf("Failed to load dumped config: %s", err)
+	}
+	expected, _ := c.NetworkConfig()
+	actual, err := reloaded.NetworkConfig()
+	if err != nil {
+		t.Fatalf("Failed to get network config: %s", err)
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Expected the same network config after a round trip through YAML")
+	}
+}
+
+func TestPemTakesPrecedenceOverPath(t *testing.T) {
+	c, err := FromFile(configInlinePemsTestFilePath)()
+	if err != nil {
+		t.Fatalf("Unexpected error from config: %s", err)
+	}
+	for _, key := range []string{
+		"peers.peer0.org1.example.com.tlsCACerts.path",
+		"client.tlsCerts.client.cert.path",
+		"certificateAuthorities.ca.org1.example.com.tlsCACerts.path",
+		"certificateAuthorities.ca.org1.example.com.tlsCACerts.client.key.path",
+	} {
+		if err := c.(*Config).Set(key, "/notarealfile.pem"); err != nil {
+			t.Fatalf("Failed to set %s: %s", key, err)
+		}
+	}
+
+	peer, err := c.PeerConfig(org1, "peer0.org1.example.com")
+	if err != nil || peer == nil {
+		t.Fatalf("Failed to get peer config: %v", err)
+	}
+	if peer.TLSCACerts.Path != "" {
+		t.Fatalf("Expected the path to be ignored but got %s", peer.TLSCACerts.Path)
+	}
+	if _, err := peer.TLSCACerts.TLSCert(); err != nil {
+		t.Fatalf("Expected the embedded TLS CA cert to be loaded: %s", err)
+	}
+	if _, err := c.TLSClientCerts(); err != nil {
+		t.Fatalf("Expected the embedded client TLS cert to be loaded: %s", err)
+	}
+
+	ca, err := c.CAConfig(org1)
+	if err != nil {
+		t.Fatalf("Failed to get CA config: %s", err)
+	}
+	if ca.TLSCACerts.Path != "" || ca.TLSCACerts.Client.Key.Path != "" {
+		t.Fatalf("Expected the CA paths to be ignored but got %+v", ca.TLSCACerts)
+	}
+}
+
+func containsSubject(pool *x509.CertPool, cert *x509.Certificate) bool {
+	for _, subject := range pool.Subjects() {
+		if bytes.Equal(subject, cert.RawSubject) {
+			return true
+		}
+	}
+	return false
+}
+
 func TestLoadConfigWithEmbeddedUsersWithPems(t *testing.T) {
 	// get a config file with embedded users
 	c, err := FromFile(configEmbeddedUsersTestFilePath)()
@@ -1002,18 +2284,11 @@ func TestInitConfigFromRawWrongType(t *testing.T) {
 
 	// test init config with wrong type
 	c, err = FromRaw(cBytes, "json")()
-	if err != nil {
-		t.Fatalf("Failed to initialize config from bytes array. Error: %s", err)
-	}
-
-	o, err := c.OrderersConfig()
-	if len(o) > 0 {
-		t.Fatalf("Expected to get an empty list of orderers for wrong config type")
+	if err == nil {
+		t.Fatalf("Expected error when initializing YAML config as JSON but got no error.")
 	}
-
-	np, err := c.NetworkPeers()
-	if len(np) > 0 {
-		t.Fatalf("Expected to get an empty list of peers for wrong config type")
+	if c != nil {
+		t.Fatalf("Expected no config when initializing YAML config as JSON")
 	}
 }
 
@@ -1241,6 +2516,59 @@ func TestTLSClientCertsNoCerts(t *testing.T) {
 	}
 }
 
+func TestTLSClientCertsMutualTLS(t *testing.T) {
+	serverTestCert := testutils.NewServerCert(t, "localhost")
+	serverCert, caCert := serverTestCert.TLS, serverTestCert.X509
+	client := testutils.NewClientCert(t, "sdk_go")
+	clientCert, clientKey := client.CertPEM, client.KeyPEM
+
+	configProvider, err := FromFile(configTestFilePath)()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := configProvider.(*Config)
+	if err := c.Set("client.tlsCerts.client.cert.pem", string(clientCert)); err != nil {
+		t.Fatalf("Failed to set client cert: %s", err)
+	}
+	if err := c.Set("client.tlsCerts.client.key.pem", string(clientKey)); err != nil {
+		t.Fatalf("Failed to set client key: %s", err)
+	}
+
+	tlsConfig, err := comm.TLSConfig(caCert, "localhost", c)
+	if err != nil {
+		t.Fatalf("Failed to create TLS config: %s", err)
+	}
+
+	// The server only accepts clients that present a certificate it trusts
+	clientCAs := x509.NewCertPool()
+	clientCAs.AppendCertsFromPEM(clientCert)
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	defer serverConn.Close()
+	server := tls.Server(serverConn, &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+	})
+	result := make(chan error, 1)
+	go func() { result <- server.Handshake() }()
+
+	if err := tls.Client(clientConn, tlsConfig).Handshake(); err != nil {
+		t.Fatalf("TLS handshake failed: %s", err)
+	}
+	select {
+	case err := <-result:
+		if err != nil {
+			t.Fatalf("Expected the server to accept the client certificate: %s", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the server handshake")
+	}
+	if len(server.ConnectionState().PeerCertificates) == 0 {
+		t.Fatal("Expected the client certificate to be presented")
+	}
+}
+
 func TestNewGoodOpt(t *testing.T) {
 	_, err := FromFile("../../../test/fixtures/config/config_test.yaml", goodOpt())()
 	if err != nil {
@@ -101,14 +101,15 @@ func X509KeyPair(certPEMBlock []byte, pk core.Key, cs core.CryptoSuite) (tls.Cer
 		return fail(err)
 	}
 
+	// The TLS stack selects the signature scheme from the public key (e.g. the curve of an ECDSA key),
+	// so the signer has to return the certificate's public key
 	switch x509Cert.PublicKey.(type) {
-	case *rsa.PublicKey:
-		cert.PrivateKey = &PrivateKey{cs, pk, &rsa.PublicKey{}}
-	case *ecdsa.PublicKey:
-		cert.PrivateKey = &PrivateKey{cs, pk, &ecdsa.PublicKey{}}
+	case *rsa.PublicKey, *ecdsa.PublicKey:
+		cert.PrivateKey = &PrivateKey{cs, pk, x509Cert.PublicKey}
 	default:
 		return fail(errors.New("tls: unknown public key algorithm"))
 	}
+	cert.Leaf = x509Cert
 
 	return cert, nil
 }
@@ -7,9 +7,13 @@ SPDX-License-Identifier: Apache-2.0
 package cryptoutil
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"testing"
+	"time"
 
 	fabricCaUtil "github.com/hyperledger/fabric-sdk-go/internal/github.com/hyperledger/fabric-ca/util"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config/cryptoutil/testutils"
 	"github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite"
 )
 
@@ -119,6 +123,76 @@ func TestPrivateKey(t *testing.T) {
 
 }
 
+func TestX509KeyPairMutualTLS(t *testing.T) {
+	cs := cryptosuite.GetDefault()
+
+	client := testutils.NewClientCert(t, "client")
+	server := testutils.NewServerCert(t, "localhost")
+	clientCert, clientKey := client.CertPEM, client.KeyPEM
+	serverCert, serverKey := server.CertPEM, server.KeyPEM
+
+	// The handshake is signed with the key in the crypto suite, the raw key isn't given to the TLS stack
+	key, err := fabricCaUtil.ImportBCCSPKeyFromPEMBytes(clientKey, cs, true)
+	if err != nil {
+		t.Fatalf("Failed to import private key from pem: %s", err)
+	}
+	keyPair, err := X509KeyPair(clientCert, key, cs)
+	if err != nil {
+		t.Fatalf("Failed to load key pair: %s", err)
+	}
+	if _, ok := keyPair.PrivateKey.(*PrivateKey); !ok {
+		t.Fatalf("Expected the crypto suite signer, got %T", keyPair.PrivateKey)
+	}
+
+	serverKeyPair, err := tls.X509KeyPair(serverCert, serverKey)
+	if err != nil {
+		t.Fatalf("Failed to load server key pair: %s", err)
+	}
+	clientCAs := x509.NewCertPool()
+	clientCAs.AppendCertsFromPEM(clientCert)
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{serverKeyPair},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+	})
+	if err != nil {
+		t.Fatalf("Failed to start TLS server: %s", err)
+	}
+	defer listener.Close()
+
+	result := make(chan error, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			result <- err
+			return
+		}
+		defer conn.Close()
+		result <- conn.(*tls.Conn).Handshake()
+	}()
+
+	rootCAs := x509.NewCertPool()
+	rootCAs.AppendCertsFromPEM(serverCert)
+	conn, err := tls.Dial("tcp", listener.Addr().String(), &tls.Config{
+		RootCAs:      rootCAs,
+		ServerName:   "localhost",
+		Certificates: []tls.Certificate{keyPair},
+	})
+	if err != nil {
+		t.Fatalf("TLS handshake failed: %s", err)
+	}
+	defer conn.Close()
+
+	select {
+	case err := <-result:
+		if err != nil {
+			t.Fatalf("Server rejected the client certificate: %s", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the server handshake")
+	}
+}
+
 // RSA Cert
 const rsaCert = `-----BEGIN CERTIFICATE-----
 MIIFdDCCBFygAwIBAgIQJ2buVutJ846r13Ci/ITeIjANBgkqhkiG9w0BAQwFADBv
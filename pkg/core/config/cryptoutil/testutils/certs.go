@@ -0,0 +1,95 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package testutils
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+)
+
+// Cert is a certificate that's generated for a test, along with its private key
+type Cert struct {
+	// TLS is the certificate and private key, e.g. to serve TLS or to present a client certificate
+	TLS     tls.Certificate
+	X509    *x509.Certificate
+	CertPEM []byte
+	KeyPEM  []byte
+}
+
+// NewServerCert generates a self-signed CA certificate that's also a server certificate for the given host
+// (a DNS name or an IP address), so that it may be added to the root CAs of the clients
+func NewServerCert(t testing.TB, host string) *Cert {
+	template := x509.Certificate{
+		Subject:     pkix.Name{CommonName: host},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:        true,
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		template.IPAddresses = []net.IP{ip}
+	} else {
+		template.DNSNames = []string{host}
+	}
+	return NewCert(t, template)
+}
+
+// NewClientCert generates a self-signed CA certificate that's also a client certificate with the given
+// common name, so that it may be added to the client CAs of the servers
+func NewClientCert(t testing.TB, name string) *Cert {
+	return NewCert(t, x509.Certificate{
+		Subject:     pkix.Name{CommonName: name},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		IsCA:        true,
+	})
+}
+
+// NewCert generates a self-signed certificate with an ECDSA P-256 key from the given template. The serial
+// number, the validity period (an hour before and after now) and the key usage (digital signature, and
+// certificate signing for a CA) of the template are set by NewCert.
+func NewCert(t testing.TB, template x509.Certificate) *Cert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Error generating key: %s", err)
+	}
+
+	template.SerialNumber = big.NewInt(time.Now().UnixNano())
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	if template.IsCA {
+		template.KeyUsage |= x509.KeyUsageCertSign
+		template.BasicConstraintsValid = true
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Error creating certificate: %s", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Error parsing certificate: %s", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Error marshalling key: %s", err)
+	}
+
+	return &Cert{
+		TLS:     tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: cert},
+		X509:    cert,
+		CertPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		KeyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
@@ -0,0 +1,143 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package config
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
+	"github.com/pkg/errors"
+)
+
+const (
+	peerMatchers    = "peer"
+	ordererMatchers = "orderer"
+
+	sslTargetNameOverride = "ssl-target-name-override"
+)
+
+// entityMatcher is a compiled entity matcher (see core.MatchConfig)
+type entityMatcher struct {
+	regex  *regexp.Regexp
+	config core.MatchConfig
+}
+
+// entityMatchers are the compiled peer and orderer matchers, in the order in which they're configured
+type entityMatchers struct {
+	peers    []*entityMatcher
+	orderers []*entityMatcher
+}
+
+func compileEntityMatchers(networkConfig *core.NetworkConfig) (*entityMatchers, error) {
+	peers, err := compileMatchers(peerMatchers, networkConfig.EntityMatchers[peerMatchers])
+	if err != nil {
+		return nil, err
+	}
+	orderers, err := compileMatchers(ordererMatchers, networkConfig.EntityMatchers[ordererMatchers])
+	if err != nil {
+		return nil, err
+	}
+	return &entityMatchers{peers: peers, orderers: orderers}, nil
+}
+
+func compileMatchers(entity string, configs []core.MatchConfig) ([]*entityMatcher, error) {
+	var matchers []*entityMatcher
+	for _, config := range configs {
+		regex, err := regexp.Compile(config.Pattern)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid pattern [%s] in %s entity matchers", config.Pattern, entity)
+		}
+		matchers = append(matchers, &entityMatcher{regex: regex, config: config})
+	}
+	return matchers, nil
+}
+
+// match returns the first matcher whose pattern matches the given name (nil if none match)
+func match(matchers []*entityMatcher, name string) *entityMatcher {
+	for _, m := range matchers {
+		if m.regex.MatchString(name) {
+			return m
+		}
+	}
+	return nil
+}
+
+// expand replaces the matched part of the name with the given expression. The value
+// is returned unchanged if the expression is empty.
+func (m *entityMatcher) expand(name, exp, value string) string {
+	if exp == "" {
+		return value
+	}
+	return m.regex.ReplaceAllString(name, exp)
+}
+
+// expandGRPCOptions returns a copy of the gRPC options with the SSL target name override substituted
+func (m *entityMatcher) expandGRPCOptions(name string, grpcOptions map[string]interface{}) map[string]interface{} {
+	if m.config.SSLTargetOverrideURLSubstitutionExp == "" {
+		return grpcOptions
+	}
+
+	options := make(map[string]interface{}, len(grpcOptions)+1)
+	for k, v := range grpcOptions {
+		options[k] = v
+	}
+	override, _ := options[sslTargetNameOverride].(string)
+	options[sslTargetNameOverride] = m.expand(name, m.config.SSLTargetOverrideURLSubstitutionExp, override)
+	return options
+}
+
+func (m *entityMatcher) matchPeer(name string, peer core.PeerConfig) core.PeerConfig {
+	peer.URL = m.expand(name, m.config.URLSubstitutionExp, peer.URL)
+	peer.EventURL = m.expand(name, m.config.EventURLSubstitutionExp, peer.EventURL)
+	peer.GRPCOptions = m.expandGRPCOptions(name, peer.GRPCOptions)
+	return peer
+}
+
+func (m *entityMatcher) matchOrderer(name string, orderer core.OrdererConfig) core.OrdererConfig {
+	orderer.URL = m.expand(name, m.config.URLSubstitutionExp, orderer.URL)
+	orderer.GRPCOptions = m.expandGRPCOptions(name, orderer.GRPCOptions)
+	return orderer
+}
+
+// applyEntityMatchers applies the matchers to the configured peers and orderers
+func applyEntityMatchers(networkConfig *core.NetworkConfig, matchers *entityMatchers) {
+	for name, peer := range networkConfig.Peers {
+		if m := match(matchers.peers, name); m != nil {
+			networkConfig.Peers[name] = m.matchPeer(name, peer)
+		}
+	}
+	for name, orderer := range networkConfig.Orderers {
+		if m := match(matchers.orderers, name); m != nil {
+			networkConfig.Orderers[name] = m.matchOrderer(name, orderer)
+		}
+	}
+}
+
+// matchOrderer returns the configuration of an orderer that isn't in the configuration (e.g. an orderer
+// address from a channel configuration) using the first orderer matcher that matches the given name or
+// address. The configuration of the matcher's mapped host is used as the base configuration, and the
+// name is used as the URL if the matcher doesn't substitute the URL. Nil is returned if no matcher matches.
+func (c *Config) matchOrderer(networkConfig *core.NetworkConfig, name string) *core.OrdererConfig {
+	c.mutex.RLock()
+	matchers := c.entityMatchers
+	c.mutex.RUnlock()
+
+	name = strings.ToLower(name)
+	m := match(matchers.orderers, name)
+	if m == nil {
+		return nil
+	}
+
+	orderer := core.OrdererConfig{URL: name}
+	if mapped, ok := networkConfig.Orderers[strings.ToLower(m.config.MappedHost)]; ok {
+		orderer = mapped
+	}
+	orderer = m.matchOrderer(name, orderer)
+	logger.Debugf("Orderer [%s] matched by pattern [%s]: %s", name, m.config.Pattern, orderer.URL)
+	return &orderer
+}
@@ -0,0 +1,79 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// parseJSON parses a JSON configuration. The numbers are converted to the equivalent YAML values so
+// that a JSON profile results in exactly the same configuration as the equivalent YAML profile (e.g.
+// whole numbers are ints rather than float64s, and nested sections have the same types).
+func parseJSON(data []byte) (map[string]interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var settings map[string]interface{}
+	if err := decoder.Decode(&settings); err != nil {
+		return nil, jsonError(data, err)
+	}
+	if decoder.More() {
+		return nil, errors.New("invalid JSON config: unexpected data after the top-level object")
+	}
+	return fromJSON(settings).(map[string]interface{}), nil
+}
+
+// fromJSON converts the numbers in the given decoded JSON value to ints (or float64s for
+// numbers that aren't whole), as they would be if the value had been parsed from YAML
+func fromJSON(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			v[key] = fromJSON(child)
+		}
+	case []interface{}:
+		for i, child := range v {
+			v[i] = fromJSON(child)
+		}
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return int(i)
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+		return v.String()
+	}
+	return value
+}
+
+// jsonError returns an error that identifies the location of a JSON syntax error
+func jsonError(data []byte, err error) error {
+	var offset int64
+	switch e := err.(type) {
+	case *json.SyntaxError:
+		offset = e.Offset
+	case *json.UnmarshalTypeError:
+		return errors.Errorf("invalid JSON config: expecting an object at the top level but got %s", e.Value)
+	default:
+		return errors.Wrap(err, "invalid JSON config")
+	}
+
+	line, column := 1, 1
+	for i := int64(0); i < offset-1 && i < int64(len(data)); i++ {
+		if data[i] == '\n' {
+			line++
+			column = 1
+		} else {
+			column++
+		}
+	}
+	return errors.Errorf("invalid JSON config at line %d, column %d: %s", line, column, err)
+}
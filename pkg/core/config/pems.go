@@ -0,0 +1,44 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package config
+
+import (
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
+)
+
+// resolvePems ensures that the embedded PEM of each certificate and key takes precedence over its path:
+// the path is cleared if both are configured, so the certificate or key is never loaded from a file
+// (e.g. a stale file that's left on disk) when the PEM is embedded in the configuration.
+func resolvePems(networkConfig *core.NetworkConfig) {
+	resolveTLSConfig("client TLS key", &networkConfig.Client.TLSCerts.Client.Key)
+	resolveTLSConfig("client TLS cert", &networkConfig.Client.TLSCerts.Client.Cert)
+
+	for name, peer := range networkConfig.Peers {
+		resolveTLSConfig("TLS CA cert of peer "+name, &peer.TLSCACerts)
+		networkConfig.Peers[name] = peer
+	}
+	for name, orderer := range networkConfig.Orderers {
+		resolveTLSConfig("TLS CA cert of orderer "+name, &orderer.TLSCACerts)
+		networkConfig.Orderers[name] = orderer
+	}
+	for name, ca := range networkConfig.CertificateAuthorities {
+		if len(ca.TLSCACerts.Pem) > 0 && ca.TLSCACerts.Path != "" {
+			logger.Warnf("Both the pem and the path of the TLS CA certs of CA %s are configured; using the pem", name)
+			ca.TLSCACerts.Path = ""
+		}
+		resolveTLSConfig("TLS client key of CA "+name, &ca.TLSCACerts.Client.Key)
+		resolveTLSConfig("TLS client cert of CA "+name, &ca.TLSCACerts.Client.Cert)
+		networkConfig.CertificateAuthorities[name] = ca
+	}
+}
+
+func resolveTLSConfig(entity string, tlsConfig *core.TLSConfig) {
+	if tlsConfig.Pem != "" && tlsConfig.Path != "" {
+		logger.Warnf("Both the pem and the path of the %s are configured; using the pem", entity)
+		tlsConfig.Path = ""
+	}
+}
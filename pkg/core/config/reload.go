@@ -0,0 +1,165 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package config
+
+import (
+	"path/filepath"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
+	"github.com/pkg/errors"
+)
+
+// AddListener registers a listener that's notified after the configuration is reloaded (see Reload and Watch)
+func (c *Config) AddListener(listener core.ConfigListener) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.listeners = append(c.listeners, listener)
+}
+
+// Reload re-parses the source of the configuration (the config files or bytes) and replaces the loaded
+// configuration, e.g. after a TLS CA certificate or a peer was changed. Settings that were overridden
+// with Set are applied again, and the TLS CA cert pool is recreated (unless it was set with SetTLSCACertPool).
+// The listeners are notified after the configuration is replaced. Components that were created from the
+// previous configuration (e.g. existing connections) are unaffected; only new ones use the reloaded
+// configuration. The previous configuration is kept if the source can't be parsed.
+func (c *Config) Reload() error {
+	if err := c.reload(); err != nil {
+		return err
+	}
+
+	logger.Infof("Configuration reloaded")
+
+	c.mutex.RLock()
+	listeners := append([]core.ConfigListener(nil), c.listeners...)
+	c.mutex.RUnlock()
+
+	event := &core.ConfigUpdatedEvent{Config: c}
+	for _, listener := range listeners {
+		listener(event)
+	}
+	return nil
+}
+
+func (c *Config) reload() error {
+	c.reloadMutex.Lock()
+	defer c.reloadMutex.Unlock()
+
+	if c.source == nil {
+		return errors.New("the configuration was loaded from a reader so it can't be reloaded")
+	}
+
+	reloaded, err := c.load(c.source, c.settings)
+	if err != nil {
+		return errors.WithMessage(err, "reloading configuration failed")
+	}
+	c.replace(reloaded)
+	return nil
+}
+
+// load loads a new configuration from the given source, with the given settings applied on top of it.
+// The caller must hold the reload mutex.
+func (c *Config) load(source loader, settings []setting) (*Config, error) {
+	loaded := &Config{configViper: newViper(c.opts.envPrefix), opts: c.opts}
+	if err := loaded.loadTemplateConfig(); err != nil {
+		return nil, err
+	}
+	if err := source(loaded); err != nil {
+		return nil, err
+	}
+	for _, s := range settings {
+		if err := loaded.mergeSetting(s.key, s.value); err != nil {
+			return nil, err
+		}
+	}
+	loaded.settings = settings
+	return initConfig(loaded)
+}
+
+// replace replaces the loaded configuration with the given configuration. The TLS CA cert pool is
+// kept if it was set with SetTLSCACertPool. The caller must hold the reload mutex.
+func (c *Config) replace(loaded *Config) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.configViper = loaded.configViper
+	c.networkConfig = loaded.networkConfig
+	c.networkConfigCached = true
+	c.entityMatchers = loaded.entityMatchers
+	if !c.customCertPool {
+		c.tlsCertPool = loaded.tlsCertPool
+	}
+	c.layers = loaded.layers
+	c.settings = loaded.settings
+}
+
+// Watch reloads the configuration (see Reload) whenever one of the config files changes. It's only supported
+// for configurations that are loaded from files; configurations that are loaded from bytes may be
+// reloaded with Reload. Errors that occur while reloading are logged and the previous configuration is kept.
+// The returned function stops watching the files.
+func (c *Config) Watch() (stop func(), err error) {
+	if len(c.files) == 0 {
+		return nil, errors.New("only configurations that are loaded from files can be watched")
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create config file watcher")
+	}
+
+	// The directories are watched since editors (and Kubernetes config maps) replace the files
+	files := make(map[string]bool)
+	dirs := make(map[string]bool)
+	for _, name := range c.files {
+		file := filepath.Clean(name)
+		files[file] = true
+		dir := filepath.Dir(file)
+		if dirs[dir] {
+			continue
+		}
+		if err := watcher.Add(dir); err != nil {
+			watcher.Close()
+			return nil, errors.Wrapf(err, "failed to watch config directory [%s]", dir)
+		}
+		dirs[dir] = true
+	}
+
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if !files[filepath.Clean(event.Name)] || event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) == 0 {
+					continue
+				}
+				logger.Debugf("Config file [%s] changed: %s", event.Name, event.Op)
+				if err := c.Reload(); err != nil {
+					logger.Warnf("Failed to reload configuration after config file [%s] changed: %s", event.Name, err)
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				logger.Warnf("Error watching config files: %s", err)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			watcher.Close()
+		})
+	}, nil
+}
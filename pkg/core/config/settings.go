@@ -0,0 +1,150 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Set overrides the setting with the given key (e.g. "client.peer.timeout.connection") in the loaded
+// configuration. Names that contain dots are matched against the loaded configuration, so the URL of
+// peer0.org1.example.com may be set with the key "peers.peer0.org1.example.com.url". The setting is
+// layered on top of the loaded configuration (environment variable overrides still take precedence)
+// and the network configuration is reloaded so that the new value is returned by all of the accessors.
+func (c *Config) Set(key string, value interface{}) error {
+	c.reloadMutex.Lock()
+	defer c.reloadMutex.Unlock()
+
+	// The setting is merged into a copy of the loaded configuration, which then replaces the configuration,
+	// so that the configuration isn't modified while it's being read. The setting is applied again when
+	// the configuration is reloaded.
+	layers := c.layers
+	settings := append(append([]setting(nil), c.settings...), setting{key: key, value: value})
+	updated, err := c.load(func(updated *Config) error {
+		for _, layer := range layers {
+			if err := updated.mergeLayer(layer); err != nil {
+				return errors.Wrap(err, "failed to merge loaded configuration")
+			}
+		}
+		return nil
+	}, settings)
+	if err != nil {
+		return err
+	}
+
+	c.replace(updated)
+	return nil
+}
+
+// setting is a setting that overrides the loaded configuration (see Set)
+type setting struct {
+	key   string
+	value interface{}
+}
+
+// mergeSetting merges the given setting into the configuration
+func (c *Config) mergeSetting(key string, value interface{}) error {
+	parts := strings.Split(key, ".")
+	for _, part := range parts {
+		if part == "" {
+			return errors.Errorf("invalid setting key [%s]", key)
+		}
+	}
+
+	path, current := resolveSettingPath(parts, c.configViper.Get(parts[0]))
+	switch current.(type) {
+	case map[string]interface{}, map[interface{}]interface{}:
+		return errors.Errorf("setting [%s] is not a single value", key)
+	case string:
+		// Values are merged only if their type matches the loaded value, e.g. a time.Duration
+		// is set as "10s" in order to replace a timeout
+		value = fmt.Sprint(value)
+	}
+
+	var nested interface{} = value
+	for i := len(path) - 1; i >= 0; i-- {
+		nested = map[string]interface{}{path[i]: nested}
+	}
+	settingBytes, err := json.Marshal(nested)
+	if err != nil {
+		return errors.Wrapf(err, "invalid value for setting [%s]", key)
+	}
+
+	// JSON is valid YAML so the setting may be merged into either type of configuration
+	if err := c.configViper.MergeConfig(bytes.NewReader(settingBytes)); err != nil {
+		return errors.Wrapf(err, "failed to merge setting [%s]", key)
+	}
+
+	logger.Debugf("Setting %s: %v", key, value)
+	return nil
+}
+
+// resolveSettingPath splits the parts of a setting key into the names of the nested settings that
+// they refer to, given the loaded value of the first part. A name may span several parts if the loaded
+// configuration contains a setting with that name (e.g. "peer0.org1.example.com"). The loaded value of
+// the setting is also returned (nil if the setting isn't in the loaded configuration).
+func resolveSettingPath(parts []string, value interface{}) ([]string, interface{}) {
+	path := []string{strings.ToLower(parts[0])}
+	for i := 1; i < len(parts); {
+		n := 1
+		var child interface{}
+		for j := len(parts); j > i; j-- {
+			if v, ok := lookupSetting(value, strings.Join(parts[i:j], ".")); ok {
+				n, child = j-i, v
+				break
+			}
+		}
+		path = append(path, strings.ToLower(strings.Join(parts[i:i+n], ".")))
+		value = child
+		i += n
+	}
+	return path, value
+}
+
+func lookupSetting(value interface{}, name string) (interface{}, bool) {
+	switch m := value.(type) {
+	case map[string]interface{}:
+		for k, v := range m {
+			if strings.EqualFold(k, name) {
+				return v, true
+			}
+		}
+	case map[interface{}]interface{}:
+		for k, v := range m {
+			if strings.EqualFold(fmt.Sprint(k), name) {
+				return v, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// Dump returns the merged configuration as YAML, e.g. to troubleshoot a configuration that's loaded from
+// several files (see FromFiles) or that has overridden settings (see Set). Environment variable overrides
+// aren't included. Note that the dump contains any credentials (e.g. private keys) that are in the configuration.
+func (c *Config) Dump() ([]byte, error) {
+	v := c.viper()
+	settings := make(map[string]interface{})
+	for _, key := range v.AllKeys() {
+		top := strings.SplitN(key, ".", 2)[0]
+		if _, ok := settings[top]; !ok {
+			settings[top] = v.Get(top)
+		}
+	}
+
+	dump, err := yaml.Marshal(settings)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal configuration")
+	}
+	return dump, nil
+}
@@ -0,0 +1,147 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// includeDirective prefixes a value that's replaced with the content of the named file (e.g. "$include:./peers.yaml")
+const includeDirective = "$include:"
+
+// envVarPattern matches the ${VAR} and ${VAR:-default} placeholders
+var envVarPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-[^}]*)?\}`)
+
+// substituteEnv replaces the ${VAR} placeholders in a configuration with the values of the environment
+// variables, before the configuration is parsed. The default given with ${VAR:-default} is used if the
+// variable is unset or empty, and an error is returned if a variable without a default is unset. The path
+// variables (e.g. ${GOPATH}) are left for substPathVars. The lines that are comments (their first non-blank
+// character is #) aren't substituted.
+func substituteEnv(data []byte) ([]byte, error) {
+	var missing []string
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	for i, line := range lines {
+		if bytes.HasPrefix(bytes.TrimSpace(line), []byte("#")) {
+			continue
+		}
+		lines[i] = envVarPattern.ReplaceAllFunc(line, func(placeholder []byte) []byte {
+			match := envVarPattern.FindSubmatch(placeholder)
+			name := string(match[1])
+			if _, ok := substVar(name); ok && len(match[2]) == 0 {
+				return placeholder
+			}
+
+			value, ok := os.LookupEnv(name)
+			if len(match[2]) > 0 {
+				if value == "" {
+					return match[2][len(":-"):]
+				}
+				return []byte(value)
+			}
+			if !ok {
+				missing = append(missing, name)
+			}
+			return []byte(value)
+		})
+	}
+
+	if len(missing) > 0 {
+		return nil, errors.Errorf("environment variables referenced by the config aren't set: %s", strings.Join(missing, ", "))
+	}
+	return bytes.Join(lines, nil), nil
+}
+
+// resolveIncludes replaces the include directives in the given parsed configuration with the content of
+// the included files. Relative paths are resolved against dir. includes are the files that are being
+// loaded, which is used to detect files that include themselves (directly or through other files).
+func resolveIncludes(value interface{}, dir string, includes []string) (interface{}, error) {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		for key, child := range v {
+			resolved, err := resolveIncludes(child, dir, includes)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = resolved
+		}
+	case map[string]interface{}:
+		for key, child := range v {
+			resolved, err := resolveIncludes(child, dir, includes)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = resolved
+		}
+	case []interface{}:
+		for i, child := range v {
+			resolved, err := resolveIncludes(child, dir, includes)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = resolved
+		}
+	case string:
+		if strings.HasPrefix(v, includeDirective) {
+			return loadInclude(strings.TrimSpace(strings.TrimPrefix(v, includeDirective)), dir, includes)
+		}
+	}
+	return value, nil
+}
+
+// loadInclude loads an included file. Files with a .json extension are parsed as JSON, other files as YAML.
+func loadInclude(name, dir string, includes []string) (interface{}, error) {
+	if name == "" {
+		return nil, errors.New("the include directive doesn't name a file")
+	}
+
+	file := substPathVars(name)
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(dir, file)
+	}
+	file, err := filepath.Abs(file)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to resolve included file [%s]", name)
+	}
+	for i, include := range includes {
+		if include == file {
+			cycle := append(append([]string(nil), includes[i:]...), file)
+			return nil, errors.Errorf("include cycle: %s", strings.Join(cycle, " -> "))
+		}
+	}
+
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read included file [%s]", name)
+	}
+	data, err = substituteEnv(data)
+	if err != nil {
+		return nil, errors.WithMessage(err, fmt.Sprintf("failed to load included file [%s]", name))
+	}
+
+	var value interface{}
+	if strings.EqualFold(path.Ext(file), ".json") {
+		value, err = parseJSON(data)
+	} else {
+		err = yaml.Unmarshal(data, &value)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse included file [%s]", name)
+	}
+
+	logger.Debugf("Including config file: %s", file)
+	return resolveIncludes(value, filepath.Dir(file), append(includes, file))
+}
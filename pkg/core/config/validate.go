@@ -0,0 +1,222 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config/urlutil"
+	"github.com/pkg/errors"
+)
+
+// Validate checks the network configuration for references and files that would otherwise only cause
+// failures when the clients use them: the channel peers and orderers must be defined, the URLs must be
+// valid, the TLS certificate files must be readable and the MSP directory (or the credential store) of
+// each organization must exist. An error is returned for each problem found, prefixed with the path of
+// the setting in the configuration (e.g. "channels.mychannel.peers.peer9: peer not defined").
+func (c *Config) Validate() []error {
+	networkConfig, err := c.NetworkConfig()
+	if err != nil {
+		return []error{err}
+	}
+
+	v := &validator{config: c, networkConfig: networkConfig}
+	v.validateChannels()
+	v.validateOrganizations()
+	v.validateClient()
+	v.validatePeers()
+	v.validateOrderers()
+	v.validateCAs()
+	return v.errs
+}
+
+// validator collects the problems found in a network configuration
+type validator struct {
+	config        *Config
+	networkConfig *core.NetworkConfig
+	errs          []error
+}
+
+func (v *validator) addError(path, format string, args ...interface{}) {
+	v.errs = append(v.errs, errors.Errorf("%s: %s", path, fmt.Sprintf(format, args...)))
+}
+
+func (v *validator) validateChannels() {
+	for _, name := range sortedKeys(v.networkConfig.Channels) {
+		ch := v.networkConfig.Channels[name]
+		path := "channels." + name
+
+		for _, peer := range sortedKeys(ch.Peers) {
+			if _, ok := v.networkConfig.Peers[strings.ToLower(peer)]; !ok {
+				v.addError(path+".peers."+peer, "peer not defined")
+			}
+		}
+
+		if len(ch.Orderers) == 0 {
+			v.addError(path+".orderers", "no orderers configured")
+		}
+		for _, orderer := range ch.Orderers {
+			if _, ok := v.networkConfig.Orderers[strings.ToLower(orderer)]; ok {
+				continue
+			}
+			// The orderer may be mapped by an entity matcher
+			if v.config.matchOrderer(v.networkConfig, orderer) == nil {
+				v.addError(path+".orderers."+orderer, "orderer not defined")
+			}
+		}
+	}
+}
+
+func (v *validator) validateOrganizations() {
+	for _, name := range sortedKeys(v.networkConfig.Organizations) {
+		org := v.networkConfig.Organizations[name]
+		path := "organizations." + name
+
+		// The users' credentials are embedded in the configuration
+		if len(org.Users) > 0 {
+			continue
+		}
+
+		if org.CryptoPath != "" {
+			mspDir := mspDirectory(v.config.CryptoConfigPath(), org.CryptoPath)
+			if isDir(mspDir) {
+				continue
+			}
+			if !v.hasCredentialStore() {
+				v.addError(path+".cryptoPath", "MSP directory [%s] does not exist", mspDir)
+			}
+			continue
+		}
+
+		if !v.hasCredentialStore() {
+			v.addError(path, "neither a cryptoPath, embedded users nor an existing credential store is configured")
+		}
+	}
+}
+
+// hasCredentialStore returns true if an SQL credential store or an existing credential store directory is configured
+func (v *validator) hasCredentialStore() bool {
+	if v.networkConfig.Client.CredentialStore.Type == "sql" {
+		return true
+	}
+	store := v.config.CredentialStorePath()
+	return store != "" && isDir(store)
+}
+
+func (v *validator) validateClient() {
+	client := v.networkConfig.Client.TLSCerts.Client
+	v.validateFile("client.tlsCerts.client.key.path", client.Key.Path)
+	v.validateFile("client.tlsCerts.client.cert.path", client.Cert.Path)
+}
+
+func (v *validator) validatePeers() {
+	for _, name := range sortedKeys(v.networkConfig.Peers) {
+		peer := v.networkConfig.Peers[name]
+		path := "peers." + name
+		v.validateGRPCURL(path+".url", peer.URL)
+		if peer.EventURL != "" {
+			v.validateGRPCURL(path+".eventUrl", peer.EventURL)
+		}
+		v.validateFile(path+".tlsCACerts.path", peer.TLSCACerts.Path)
+	}
+}
+
+func (v *validator) validateOrderers() {
+	for _, name := range sortedKeys(v.networkConfig.Orderers) {
+		orderer := v.networkConfig.Orderers[name]
+		path := "orderers." + name
+		v.validateGRPCURL(path+".url", orderer.URL)
+		v.validateFile(path+".tlsCACerts.path", orderer.TLSCACerts.Path)
+	}
+}
+
+func (v *validator) validateCAs() {
+	for _, name := range sortedKeys(v.networkConfig.CertificateAuthorities) {
+		ca := v.networkConfig.CertificateAuthorities[name]
+		path := "certificateAuthorities." + name
+		v.validateURL(path+".url", ca.URL)
+		if ca.TLSCACerts.Path != "" {
+			for _, file := range strings.Split(ca.TLSCACerts.Path, ",") {
+				v.validateFile(path+".tlsCACerts.path", file)
+			}
+		}
+		v.validateFile(path+".tlsCACerts.client.key.path", ca.TLSCACerts.Client.Key.Path)
+		v.validateFile(path+".tlsCACerts.client.cert.path", ca.TLSCACerts.Client.Cert.Path)
+	}
+}
+
+// validateGRPCURL validates the URL of a peer or orderer, which may omit the protocol
+func (v *validator) validateGRPCURL(path, address string) {
+	if address != "" && !urlutil.HasProtocol(address) {
+		address = "grpc://" + address
+	}
+	v.validateURL(path, address)
+}
+
+func (v *validator) validateURL(path, address string) {
+	if address == "" {
+		v.addError(path, "URL not configured")
+		return
+	}
+	u, err := url.Parse(address)
+	if err != nil {
+		v.addError(path, "invalid URL [%s]: %s", address, err)
+		return
+	}
+	if u.Host == "" {
+		v.addError(path, "invalid URL [%s]: the host is missing", address)
+	}
+}
+
+// validateFile ensures that the given file (if any) is readable
+func (v *validator) validateFile(path, file string) {
+	if file == "" {
+		return
+	}
+	file = substPathVars(file)
+	f, err := os.Open(file)
+	if err != nil {
+		v.addError(path, "file [%s] is not readable: %s", file, err)
+		return
+	}
+	f.Close()
+}
+
+// mspDirectory returns the directory of the MSP stores of an organization, i.e. the part of the
+// crypto path that precedes the first placeholder (e.g. "{userName}")
+func mspDirectory(cryptoConfigPath, cryptoPath string) string {
+	dir := substPathVars(cryptoPath)
+	if i := strings.Index(dir, "{"); i >= 0 {
+		dir = filepath.Dir(dir[:i])
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(cryptoConfigPath, dir)
+	}
+	return dir
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// sortedKeys returns the keys of a map with string keys in order, so that the problems are reported in a consistent order
+func sortedKeys(m interface{}) []string {
+	var keys []string
+	for _, k := range reflect.ValueOf(m).MapKeys() {
+		keys = append(keys, k.String())
+	}
+	sort.Strings(keys)
+	return keys
+}
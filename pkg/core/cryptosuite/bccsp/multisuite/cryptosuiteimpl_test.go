@@ -23,7 +23,6 @@ func TestBadConfig(t *testing.T) {
 
 	mockConfig := mock_core.NewMockConfig(mockCtrl)
 	mockConfig.EXPECT().SecurityProvider().Return("UNKNOWN")
-	mockConfig.EXPECT().SecurityProvider().Return("UNKNOWN")
 
 	//Get cryptosuite using config
 	_, err := GetSuiteByConfig(mockConfig)
@@ -72,6 +71,7 @@ func TestCryptoSuiteByConfigPKCS11(t *testing.T) {
 	mockConfig.EXPECT().SecurityProviderLabel().Return(softHSMTokenLabel)
 	mockConfig.EXPECT().SecurityProviderPin().Return(softHSMPin)
 	mockConfig.EXPECT().SoftVerify().Return(true)
+	mockConfig.EXPECT().SecurityProviderSessionPool().Return(core.SessionPoolConfig{})
 
 	//Get cryptosuite using config
 	c, err := GetSuiteByConfig(mockConfig)
@@ -11,13 +11,20 @@ import (
 	bccspPkcs11 "github.com/hyperledger/fabric-sdk-go/internal/github.com/hyperledger/fabric/bccsp/factory/pkcs11"
 	"github.com/hyperledger/fabric-sdk-go/internal/github.com/hyperledger/fabric/bccsp/pkcs11"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite"
 	"github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite/bccsp/wrapper"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite/common"
+	sessionpool "github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite/common/pkcs11"
 	"github.com/hyperledger/fabric-sdk-go/pkg/logging"
 	"github.com/pkg/errors"
 )
 
 var logger = logging.NewLogger("fabric_sdk_go")
 
+func init() {
+	cryptosuite.Register("PKCS11", GetSuiteByConfig)
+}
+
 //GetSuiteByConfig returns cryptosuite adaptor for bccsp loaded according to given config
 func GetSuiteByConfig(config core.Config) (core.CryptoSuite, error) {
 	// TODO: delete this check?
@@ -26,6 +33,10 @@ func GetSuiteByConfig(config core.Config) (core.CryptoSuite, error) {
 	}
 
 	opts := getOptsByConfig(config)
+	// the security level selects both the hash function and the curve of the keys
+	if _, err := common.HashOpts(opts.HashFamily, opts.SecLevel); err != nil {
+		return nil, errors.WithMessage(err, "invalid security config")
+	}
 	bccsp, err := getBCCSPFromOpts(opts)
 
 	if err != nil {
@@ -34,6 +45,25 @@ func GetSuiteByConfig(config core.Config) (core.CryptoSuite, error) {
 	return &wrapper.CryptoSuite{BCCSP: bccsp}, nil
 }
 
+// sessionMetricsProvider is implemented by the PKCS11 BCCSP
+type sessionMetricsProvider interface {
+	SessionMetrics() sessionpool.Metrics
+}
+
+//SessionMetrics returns the session churn counters of the PKCS11 sessions of a crypto suite returned by
+//GetSuiteByConfig, or false if the crypto suite isn't a PKCS11 crypto suite
+func SessionMetrics(suite core.CryptoSuite) (sessionpool.Metrics, bool) {
+	cs, ok := suite.(*wrapper.CryptoSuite)
+	if !ok {
+		return sessionpool.Metrics{}, false
+	}
+	provider, ok := cs.BCCSP.(sessionMetricsProvider)
+	if !ok {
+		return sessionpool.Metrics{}, false
+	}
+	return provider.SessionMetrics(), true
+}
+
 func getBCCSPFromOpts(config *pkcs11.PKCS11Opts) (bccsp.BCCSP, error) {
 	f := &bccspPkcs11.PKCS11Factory{}
 
@@ -47,15 +77,19 @@ func getBCCSPFromOpts(config *pkcs11.PKCS11Opts) (bccsp.BCCSP, error) {
 //getOptsByConfig Returns Factory opts for given SDK config
 func getOptsByConfig(c core.Config) *pkcs11.PKCS11Opts {
 	pkks := pkcs11.FileKeystoreOpts{KeyStorePath: c.KeyStorePath()}
+	sessionPool := c.SecurityProviderSessionPool()
 	opts := &pkcs11.PKCS11Opts{
-		SecLevel:     c.SecurityLevel(),
-		HashFamily:   c.SecurityAlgorithm(),
-		Ephemeral:    c.Ephemeral(),
-		FileKeystore: &pkks,
-		Library:      c.SecurityProviderLibPath(),
-		Pin:          c.SecurityProviderPin(),
-		Label:        c.SecurityProviderLabel(),
-		SoftVerify:   c.SoftVerify(),
+		SecLevel:         c.SecurityLevel(),
+		HashFamily:       c.SecurityAlgorithm(),
+		Ephemeral:        c.Ephemeral(),
+		FileKeystore:     &pkks,
+		Library:          c.SecurityProviderLibPath(),
+		Pin:              c.SecurityProviderPin(),
+		Label:            c.SecurityProviderLabel(),
+		SoftVerify:       c.SoftVerify(),
+		SessionCacheSize: sessionPool.Size,
+		SessionTimeout:   sessionPool.Timeout,
+		SessionRetries:   sessionPool.Retries,
 	}
 	logger.Debug("Initialized PKCS11 cryptosuite")
 
@@ -9,8 +9,13 @@ package pkcs11
 import (
 	"bytes"
 	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 
@@ -20,6 +25,8 @@ import (
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
 	api "github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core/mocks"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite/bccsp/wrapper"
 	"github.com/hyperledger/fabric-sdk-go/pkg/logging/testutils"
 )
 
@@ -62,6 +69,7 @@ func TestCryptoSuiteByConfigPKCS11(t *testing.T) {
 	mockConfig.EXPECT().SecurityProviderLabel().Return(softHSMTokenLabel)
 	mockConfig.EXPECT().SecurityProviderPin().Return(softHSMPin)
 	mockConfig.EXPECT().SoftVerify().Return(true)
+	mockConfig.EXPECT().SecurityProviderSessionPool().Return(core.SessionPoolConfig{})
 
 	//Get cryptosuite using config
 	c, err := GetSuiteByConfig(mockConfig)
@@ -88,6 +96,7 @@ func TestCryptoSuiteByConfigPKCS11Failure(t *testing.T) {
 	mockConfig.EXPECT().SecurityProviderLabel().Return("")
 	mockConfig.EXPECT().SecurityProviderPin().Return("")
 	mockConfig.EXPECT().SoftVerify().Return(true)
+	mockConfig.EXPECT().SecurityProviderSessionPool().Return(core.SessionPoolConfig{})
 
 	//Get cryptosuite using config
 	samplecryptoSuite, err := GetSuiteByConfig(mockConfig)
@@ -95,6 +104,138 @@ func TestCryptoSuiteByConfigPKCS11Failure(t *testing.T) {
 	testutils.VerifyEmpty(t, samplecryptoSuite, "Not supposed to get valid cryptosuite")
 }
 
+func TestCryptoSuiteByConfigPKCS11SessionPool(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	//Prepare Config
+	providerLib, softHSMPin, softHSMTokenLabel := pkcs11.FindPKCS11Lib()
+
+	mockConfig := mock_core.NewMockConfig(mockCtrl)
+	mockConfig.EXPECT().SecurityProvider().Return("PKCS11")
+	mockConfig.EXPECT().SecurityAlgorithm().Return("SHA2")
+	mockConfig.EXPECT().SecurityLevel().Return(256)
+	mockConfig.EXPECT().KeyStorePath().Return("/tmp/msp")
+	mockConfig.EXPECT().Ephemeral().Return(true)
+	mockConfig.EXPECT().SecurityProviderLibPath().Return(providerLib)
+	mockConfig.EXPECT().SecurityProviderLabel().Return(softHSMTokenLabel)
+	mockConfig.EXPECT().SecurityProviderPin().Return(softHSMPin)
+	mockConfig.EXPECT().SoftVerify().Return(false)
+	mockConfig.EXPECT().SecurityProviderSessionPool().Return(core.SessionPoolConfig{Size: 2, Timeout: 10 * time.Second, Retries: 1})
+
+	c, err := GetSuiteByConfig(mockConfig)
+	if err != nil {
+		t.Fatalf("Not supposed to get error, but got: %v", err)
+	}
+
+	key, err := c.KeyGen(&bccsp.ECDSAP256KeyGenOpts{Temporary: true})
+	if err != nil {
+		t.Fatalf("KeyGen failed: %v", err)
+	}
+	digest := sha256.Sum256([]byte("Hello"))
+
+	// The operations borrow the sessions of the pool concurrently
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			signature, err := c.Sign(key, digest[:], nil)
+			if err != nil {
+				errs <- err
+				return
+			}
+			valid, err := c.Verify(key, signature, digest[:], nil)
+			if err != nil || !valid {
+				errs <- fmt.Errorf("invalid signature: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("Sign/Verify failed: %v", err)
+	}
+
+	metrics, ok := SessionMetrics(c)
+	if !ok {
+		t.Fatalf("Expected the session metrics of the PKCS11 crypto suite")
+	}
+	if metrics.Size != 2 || metrics.Opened > 2 || metrics.InUse != 0 {
+		t.Fatalf("Expected at most 2 sessions, got %+v", metrics)
+	}
+
+	if _, ok := SessionMetrics(nil); ok {
+		t.Fatalf("Expected no session metrics for another crypto suite")
+	}
+}
+
+func TestPKCS11ImportPrivateKey(t *testing.T) {
+	f := &pkcsFactory.PKCS11Factory{}
+	csp, err := f.Get(configurePKCS11Options("SHA2", securityLevel))
+	if err != nil {
+		t.Fatalf("Failed to get PKCS11 BCCSP: %v", err)
+	}
+	ki := cryptosuite.NewKeyImporter(wrapper.NewCryptoSuite(csp))
+
+	for _, curve := range []string{"p256", "p384"} {
+		privateKey, err := ki.PrivateKeyPEM(readKeyFixture(t, curve+"-sec1-key.pem"), false)
+		if err != nil {
+			t.Fatalf("Failed to import %s private key: %v", curve, err)
+		}
+
+		// the key is stored on the token, a new crypto suite finds it by its SKI
+		csp, err := f.Get(configurePKCS11Options("SHA2", securityLevel))
+		if err != nil {
+			t.Fatalf("Failed to get PKCS11 BCCSP: %v", err)
+		}
+		c := wrapper.NewCryptoSuite(csp)
+		storedKey, err := c.GetKey(privateKey.SKI())
+		if err != nil || !storedKey.Private() {
+			t.Fatalf("Failed to get imported %s private key from token: %v", curve, err)
+		}
+
+		publicKey, err := cryptosuite.NewKeyImporter(c).CertPEM(readKeyFixture(t, curve+"-cert.pem"))
+		if err != nil {
+			t.Fatalf("Failed to import %s certificate: %v", curve, err)
+		}
+		digest := sha256.Sum256([]byte("Hello"))
+		signature, err := c.Sign(storedKey, digest[:], nil)
+		if err != nil {
+			t.Fatalf("Failed to sign with imported %s key: %v", curve, err)
+		}
+		valid, err := c.Verify(publicKey, signature, digest[:], nil)
+		if err != nil || !valid {
+			t.Fatalf("Failed to verify signature of imported %s key: %v", curve, err)
+		}
+	}
+}
+
+func TestPKCS11ImportPrivateKeyNotSupported(t *testing.T) {
+	opts := configurePKCS11Options("SHA2", securityLevel)
+	opts.Sensitive = true
+
+	f := &pkcsFactory.PKCS11Factory{}
+	csp, err := f.Get(opts)
+	if err != nil {
+		t.Fatalf("Failed to get PKCS11 BCCSP: %v", err)
+	}
+
+	_, err = cryptosuite.NewKeyImporter(wrapper.NewCryptoSuite(csp)).PrivateKeyPEM(readKeyFixture(t, "p256-sec1-key.pem"), false)
+	if err == nil {
+		t.Fatalf("Expected error importing a private key into a token with sensitive keys")
+	}
+}
+
+func readKeyFixture(t *testing.T, name string) []byte {
+	raw, err := ioutil.ReadFile(filepath.Join("../../testdata", name))
+	if err != nil {
+		t.Fatalf("Failed to read fixture %s: %v", name, err)
+	}
+	return raw
+}
+
 func TestPKCS11CSPConfigWithValidOptions(t *testing.T) {
 	opts := configurePKCS11Options("SHA2", securityLevel)
 	f := &pkcsFactory.PKCS11Factory{}
@@ -11,6 +11,7 @@ import (
 	bccspSw "github.com/hyperledger/fabric-sdk-go/internal/github.com/hyperledger/fabric/bccsp/factory/sw"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
 	"github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite/bccsp/wrapper"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite/common"
 	"github.com/hyperledger/fabric-sdk-go/pkg/logging"
 	"github.com/pkg/errors"
 )
@@ -25,6 +26,10 @@ func GetSuiteByConfig(config core.Config) (core.CryptoSuite, error) {
 	}
 
 	opts := getOptsByConfig(config)
+	// the security level selects both the hash function and the curve of the keys
+	if _, err := common.HashOpts(opts.HashFamily, opts.SecLevel); err != nil {
+		return nil, errors.WithMessage(err, "invalid security config")
+	}
 	bccsp, err := getBCCSPFromOpts(opts)
 	if err != nil {
 		return nil, err
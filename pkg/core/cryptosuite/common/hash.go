@@ -0,0 +1,45 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package common holds the helpers that are shared by the crypto suite implementations.
+package common
+
+import (
+	"github.com/hyperledger/fabric-sdk-go/internal/github.com/hyperledger/fabric/bccsp"
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
+	"github.com/pkg/errors"
+)
+
+// The hash families of the security config (client.BCCSP.security.hashAlgorithm)
+const (
+	SHA2 = "SHA2"
+	SHA3 = "SHA3"
+)
+
+// HashOpts returns the options of the hash function of the given hash family (SHA2 or SHA3) and
+// security level (256 or 384). The security level also selects the curve of the ECDSA keys of the
+// crypto suites (P-256 or P-384), so the hash function always matches the size of the keys.
+func HashOpts(family string, level int) (core.HashOpts, error) {
+	switch family {
+	case SHA2:
+		switch level {
+		case 256:
+			return &bccsp.SHA256Opts{}, nil
+		case 384:
+			return &bccsp.SHA384Opts{}, nil
+		}
+	case SHA3:
+		switch level {
+		case 256:
+			return &bccsp.SHA3_256Opts{}, nil
+		case 384:
+			return &bccsp.SHA3_384Opts{}, nil
+		}
+	default:
+		return nil, errors.Errorf("unsupported hash family [%s], supported families are %s and %s", family, SHA2, SHA3)
+	}
+	return nil, errors.Errorf("unsupported security level [%d] for hash family [%s], supported levels are 256 and 384", level, family)
+}
@@ -0,0 +1,381 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package pkcs11 manages the sessions of the PKCS11 crypto suite. The sessions are kept in a bounded
+// pool: an operation borrows a session and returns it when it's done, a session is health checked
+// before it's borrowed, and the operation is retried with a new session (and a new login) when the
+// HSM reports that its session is no longer valid, e.g. after a failover of the HSM.
+package pkcs11
+
+import (
+	"sync"
+	"time"
+
+	"github.com/miekg/pkcs11"
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/logging"
+)
+
+var logger = logging.NewLogger("fabric_sdk_go")
+
+const (
+	// DefaultSize is the default maximum number of sessions of a pool
+	DefaultSize = 10
+	// DefaultTimeout is the default time to wait for a session when all the sessions of a pool are in use
+	DefaultTimeout = 10 * time.Second
+	// DefaultRetries is the default number of times that an operation is retried with a new session
+	DefaultRetries = 3
+)
+
+// The session states of CK_STATE in which the user is logged in
+const (
+	stateROUserFunctions = 1
+	stateRWUserFunctions = 3
+)
+
+// ErrTimeout is returned when no session was returned to an exhausted pool before the timeout
+var ErrTimeout = errors.New("timed out waiting for a PKCS11 session")
+
+// ErrClosed is returned when a session is requested from a closed pool
+var ErrClosed = errors.New("PKCS11 session pool is closed")
+
+// Lib is the part of the PKCS11 library that is used to manage sessions, it's implemented by *pkcs11.Ctx
+type Lib interface {
+	OpenSession(slotID uint, flags uint) (pkcs11.SessionHandle, error)
+	CloseSession(sh pkcs11.SessionHandle) error
+	GetSessionInfo(sh pkcs11.SessionHandle) (pkcs11.SessionInfo, error)
+	Login(sh pkcs11.SessionHandle, userType uint, pin string) error
+}
+
+// Metrics contains the session churn counters of a pool
+type Metrics struct {
+	// Size is the maximum number of sessions of the pool
+	Size int
+
+	// InUse is the number of sessions that are currently borrowed
+	InUse int
+
+	// Idle is the number of open sessions that are waiting to be borrowed
+	Idle int
+
+	// Opened is the number of sessions that were opened
+	Opened uint64
+
+	// Closed is the number of sessions that were closed (including the invalidated sessions)
+	Closed uint64
+
+	// Invalidated is the number of sessions that failed the health check or an operation with a
+	// session error, and were closed
+	Invalidated uint64
+
+	// Logins is the number of times that the user logged in (including re-logins)
+	Logins uint64
+
+	// Retries is the number of times that an operation was retried with a new session
+	Retries uint64
+
+	// Timeouts is the number of times that no session became available before the timeout
+	Timeouts uint64
+}
+
+// SessionPool is a bounded pool of logged in sessions of a PKCS11 slot
+type SessionPool struct {
+	lib     Lib
+	slot    uint
+	pin     string
+	size    int
+	timeout time.Duration
+	retries int
+
+	// a token is held for every borrowed session, so that at most size sessions are in use
+	tokens chan struct{}
+	idle   chan pkcs11.SessionHandle
+
+	mutex   sync.Mutex
+	closed  bool
+	metrics Metrics
+}
+
+// Option configures a session pool
+type Option func(p *SessionPool) error
+
+// WithSize sets the maximum number of sessions of the pool
+func WithSize(size int) Option {
+	return func(p *SessionPool) error {
+		if size <= 0 {
+			return errors.New("session pool size must be positive")
+		}
+		p.size = size
+		return nil
+	}
+}
+
+// WithTimeout sets the time to wait for a session when all the sessions of the pool are in use
+func WithTimeout(timeout time.Duration) Option {
+	return func(p *SessionPool) error {
+		if timeout <= 0 {
+			return errors.New("session timeout must be positive")
+		}
+		p.timeout = timeout
+		return nil
+	}
+}
+
+// WithRetries sets the number of times that an operation is retried with a new session after a
+// session error, and that opening a session is retried
+func WithRetries(retries int) Option {
+	return func(p *SessionPool) error {
+		if retries < 0 {
+			return errors.New("session retries must not be negative")
+		}
+		p.retries = retries
+		return nil
+	}
+}
+
+// NewSessionPool creates a pool of the sessions of the slot, in which the user logs in with the pin.
+// A first session is opened to check that the user can log in.
+func NewSessionPool(lib Lib, slot uint, pin string, opts ...Option) (*SessionPool, error) {
+	if lib == nil {
+		return nil, errors.New("PKCS11 library is required")
+	}
+	if pin == "" {
+		return nil, errors.New("PIN is required")
+	}
+
+	p := &SessionPool{
+		lib:     lib,
+		slot:    slot,
+		pin:     pin,
+		size:    DefaultSize,
+		timeout: DefaultTimeout,
+		retries: DefaultRetries,
+	}
+	for _, opt := range opts {
+		if err := opt(p); err != nil {
+			return nil, err
+		}
+	}
+	p.tokens = make(chan struct{}, p.size)
+	p.idle = make(chan pkcs11.SessionHandle, p.size)
+	p.metrics.Size = p.size
+
+	session, err := p.open()
+	if err != nil {
+		return nil, err
+	}
+	p.idle <- session
+	return p, nil
+}
+
+// Get borrows a logged in session from the pool, it must be given back with Return or Discard.
+// If all the sessions are in use, Get waits for a session to be given back and returns ErrTimeout
+// if none is given back before the timeout of the pool.
+func (p *SessionPool) Get() (pkcs11.SessionHandle, error) {
+	if p.isClosed() {
+		return 0, ErrClosed
+	}
+
+	timer := time.NewTimer(p.timeout)
+	defer timer.Stop()
+	select {
+	case p.tokens <- struct{}{}:
+	case <-timer.C:
+		p.record(func(m *Metrics) { m.Timeouts++ })
+		return 0, ErrTimeout
+	}
+
+	session, err := p.session()
+	if err != nil {
+		<-p.tokens
+		return 0, err
+	}
+	return session, nil
+}
+
+// Return gives a healthy session back to the pool
+func (p *SessionPool) Return(session pkcs11.SessionHandle) {
+	// the session is added under the lock so that it is either closed here or by Close
+	p.mutex.Lock()
+	closed := p.closed
+	if !closed {
+		p.idle <- session
+	}
+	p.mutex.Unlock()
+
+	if closed {
+		p.close(session, false)
+	}
+	<-p.tokens
+}
+
+// Discard closes a session that is no longer valid instead of giving it back to the pool
+func (p *SessionPool) Discard(session pkcs11.SessionHandle) {
+	p.close(session, true)
+	<-p.tokens
+}
+
+// Do runs the operation with a session borrowed from the pool. If the operation fails with a session
+// error (see IsSessionError), the session is discarded and the operation is run again with another
+// session, at most the number of retries of the pool.
+func (p *SessionPool) Do(operation func(session pkcs11.SessionHandle) error) error {
+	var err error
+	for attempt := 0; attempt <= p.retries; attempt++ {
+		if attempt > 0 {
+			logger.Warnf("Retrying PKCS11 operation with a new session after session error: %s", err)
+			p.record(func(m *Metrics) { m.Retries++ })
+		}
+
+		session, getErr := p.Get()
+		if getErr != nil {
+			return getErr
+		}
+
+		err = operation(session)
+		if !IsSessionError(err) {
+			p.Return(session)
+			return err
+		}
+		p.Discard(session)
+	}
+	return errors.WithMessage(err, "PKCS11 operation failed with a session error after retries")
+}
+
+// Metrics returns a snapshot of the session churn counters of the pool
+func (p *SessionPool) Metrics() Metrics {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	m := p.metrics
+	m.InUse = len(p.tokens)
+	m.Idle = len(p.idle)
+	return m
+}
+
+// Close closes the idle sessions of the pool. The borrowed sessions are closed when they're given back.
+func (p *SessionPool) Close() {
+	p.mutex.Lock()
+	p.closed = true
+	p.mutex.Unlock()
+
+	for {
+		select {
+		case session := <-p.idle:
+			p.close(session, false)
+		default:
+			return
+		}
+	}
+}
+
+// IsSessionError returns true if the (cause of the) error means that the session or its login are
+// no longer valid, so that the operation may succeed with another session
+func IsSessionError(err error) bool {
+	p11Err, ok := errors.Cause(err).(pkcs11.Error)
+	if !ok {
+		return false
+	}
+	switch p11Err {
+	case pkcs11.CKR_SESSION_HANDLE_INVALID, pkcs11.CKR_SESSION_CLOSED, pkcs11.CKR_USER_NOT_LOGGED_IN,
+		pkcs11.CKR_DEVICE_REMOVED, pkcs11.CKR_TOKEN_NOT_PRESENT:
+		return true
+	default:
+		return false
+	}
+}
+
+// session returns a healthy idle session or opens a new session if there's none
+func (p *SessionPool) session() (pkcs11.SessionHandle, error) {
+	for {
+		select {
+		case session := <-p.idle:
+			if err := p.check(session); err != nil {
+				logger.Debugf("Closing invalid PKCS11 session %d: %s", session, err)
+				p.close(session, true)
+				continue
+			}
+			return session, nil
+		default:
+			return p.open()
+		}
+	}
+}
+
+// check checks that the session is valid and that the user is logged in, the user logs in again if
+// the session is valid but the user was logged out
+func (p *SessionPool) check(session pkcs11.SessionHandle) error {
+	info, err := p.lib.GetSessionInfo(session)
+	if err != nil {
+		return errors.Wrap(err, "getting session info failed")
+	}
+	if info.State != stateROUserFunctions && info.State != stateRWUserFunctions {
+		return p.login(session)
+	}
+	return nil
+}
+
+// open opens a new session and logs in the user
+func (p *SessionPool) open() (pkcs11.SessionHandle, error) {
+	var session pkcs11.SessionHandle
+	var err error
+	for attempt := 0; attempt <= p.retries; attempt++ {
+		session, err = p.lib.OpenSession(p.slot, pkcs11.CKF_SERIAL_SESSION|pkcs11.CKF_RW_SESSION)
+		if err == nil {
+			break
+		}
+		logger.Warnf("OpenSession failed, retrying [%s]", err)
+	}
+	if err != nil {
+		return 0, errors.Wrapf(err, "opening session on slot %d failed", p.slot)
+	}
+	p.record(func(m *Metrics) { m.Opened++ })
+	logger.Debugf("Created new pkcs11 session %d on slot %d", session, p.slot)
+
+	if err := p.login(session); err != nil {
+		p.close(session, true)
+		return 0, err
+	}
+	return session, nil
+}
+
+// login logs in the user, the user may already be logged in by another session
+func (p *SessionPool) login(session pkcs11.SessionHandle) error {
+	err := p.lib.Login(session, pkcs11.CKU_USER, p.pin)
+	if err == pkcs11.Error(pkcs11.CKR_USER_ALREADY_LOGGED_IN) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "login failed")
+	}
+	p.record(func(m *Metrics) { m.Logins++ })
+	return nil
+}
+
+// close closes the session, invalid tells whether the session is closed because it's no longer valid
+func (p *SessionPool) close(session pkcs11.SessionHandle, invalid bool) {
+	if err := p.lib.CloseSession(session); err != nil {
+		logger.Debugf("Closing PKCS11 session %d failed: %s", session, err)
+	}
+	p.record(func(m *Metrics) {
+		m.Closed++
+		if invalid {
+			m.Invalidated++
+		}
+	})
+}
+
+func (p *SessionPool) record(update func(m *Metrics)) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	update(&p.metrics)
+}
+
+func (p *SessionPool) isClosed() bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.closed
+}
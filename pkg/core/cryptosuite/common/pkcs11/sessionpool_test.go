@@ -0,0 +1,378 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package pkcs11
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/miekg/pkcs11"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testSlot = 1
+	testPin  = "98765432"
+)
+
+func TestNewSessionPool(t *testing.T) {
+	lib := newMockLib()
+	pool, err := NewSessionPool(lib, testSlot, testPin)
+	if err != nil {
+		t.Fatalf("NewSessionPool failed: %s", err)
+	}
+	assert.Equal(t, Metrics{Size: DefaultSize, Idle: 1, Opened: 1, Logins: 1}, pool.Metrics())
+
+	_, err = NewSessionPool(nil, testSlot, testPin)
+	assert.NotNil(t, err, "expected an error without library")
+	_, err = NewSessionPool(lib, testSlot, "")
+	assert.NotNil(t, err, "expected an error without PIN")
+	_, err = NewSessionPool(lib, testSlot, testPin, WithSize(0))
+	assert.NotNil(t, err, "expected an error for an invalid size")
+	_, err = NewSessionPool(lib, testSlot, testPin, WithTimeout(0))
+	assert.NotNil(t, err, "expected an error for an invalid timeout")
+	_, err = NewSessionPool(lib, testSlot, testPin, WithRetries(-1))
+	assert.NotNil(t, err, "expected an error for invalid retries")
+
+	// The session is closed if the user can't log in
+	lib = newMockLib()
+	_, err = NewSessionPool(lib, testSlot, "invalid")
+	assert.NotNil(t, err, "expected an error for an invalid PIN")
+	assert.Equal(t, 0, lib.openSessions())
+
+	lib = newMockLib()
+	lib.failOpen(DefaultRetries + 1)
+	_, err = NewSessionPool(lib, testSlot, testPin)
+	assert.NotNil(t, err, "expected an error when the sessions can't be opened")
+
+	// Opening a session is retried
+	lib = newMockLib()
+	lib.failOpen(DefaultRetries)
+	_, err = NewSessionPool(lib, testSlot, testPin)
+	assert.Nil(t, err, "expected the session to be opened after retries")
+}
+
+func TestSessionPoolReuse(t *testing.T) {
+	lib := newMockLib()
+	pool := newTestPool(t, lib)
+
+	session, err := pool.Get()
+	if err != nil {
+		t.Fatalf("Get failed: %s", err)
+	}
+	assert.Equal(t, 1, pool.Metrics().InUse)
+	pool.Return(session)
+
+	reused, err := pool.Get()
+	if err != nil {
+		t.Fatalf("Get failed: %s", err)
+	}
+	assert.Equal(t, session, reused, "expected the idle session to be reused")
+	pool.Return(reused)
+
+	m := pool.Metrics()
+	assert.Equal(t, uint64(1), m.Opened)
+	assert.Equal(t, 0, m.InUse)
+	assert.Equal(t, 1, m.Idle)
+}
+
+func TestSessionPoolExhausted(t *testing.T) {
+	lib := newMockLib()
+	pool := newTestPool(t, lib, WithSize(2), WithTimeout(50*time.Millisecond))
+
+	first, err := pool.Get()
+	assert.Nil(t, err)
+	second, err := pool.Get()
+	assert.Nil(t, err)
+	assert.NotEqual(t, first, second)
+
+	start := time.Now()
+	_, err = pool.Get()
+	assert.Equal(t, ErrTimeout, err)
+	assert.True(t, time.Since(start) >= 50*time.Millisecond, "expected Get to block until the timeout")
+	assert.Equal(t, uint64(1), pool.Metrics().Timeouts)
+
+	// A blocked Get gets the session that is given back
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		pool.Return(first)
+	}()
+	session, err := pool.Get()
+	assert.Nil(t, err, "expected the returned session")
+	assert.Equal(t, first, session)
+
+	pool.Return(session)
+	pool.Return(second)
+	assert.Equal(t, uint64(2), pool.Metrics().Opened)
+}
+
+func TestSessionPoolHealthCheck(t *testing.T) {
+	lib := newMockLib()
+	pool := newTestPool(t, lib)
+
+	// The idle session is invalidated by a failover: it's replaced by a new session and the user logs in again
+	lib.failover()
+	err := pool.Do(lib.operation)
+	assert.Nil(t, err, "operation failed")
+
+	m := pool.Metrics()
+	assert.Equal(t, uint64(2), m.Opened)
+	assert.Equal(t, uint64(1), m.Invalidated)
+	assert.Equal(t, uint64(2), m.Logins)
+	assert.Equal(t, uint64(0), m.Retries)
+
+	// The session is still valid but the user was logged out: the user logs in again
+	lib.logout()
+	err = pool.Do(lib.operation)
+	assert.Nil(t, err, "operation failed")
+
+	m = pool.Metrics()
+	assert.Equal(t, uint64(2), m.Opened)
+	assert.Equal(t, uint64(3), m.Logins)
+}
+
+func TestSessionPoolRetry(t *testing.T) {
+	lib := newMockLib()
+	pool := newTestPool(t, lib)
+
+	// The failover happens during the operation
+	attempts := 0
+	err := pool.Do(func(session pkcs11.SessionHandle) error {
+		attempts++
+		if attempts == 1 {
+			lib.failover()
+		}
+		return lib.operation(session)
+	})
+	assert.Nil(t, err, "expected the operation to succeed with a new session")
+	assert.Equal(t, 2, attempts)
+
+	m := pool.Metrics()
+	assert.Equal(t, uint64(1), m.Retries)
+	assert.Equal(t, uint64(1), m.Invalidated)
+	assert.Equal(t, uint64(2), m.Logins)
+	assert.Equal(t, 1, lib.openSessions())
+
+	// Other errors aren't retried
+	attempts = 0
+	err = pool.Do(func(session pkcs11.SessionHandle) error {
+		attempts++
+		return errors.Wrap(pkcs11.Error(pkcs11.CKR_KEY_HANDLE_INVALID), "sign failed")
+	})
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, attempts)
+	assert.Equal(t, 1, pool.Metrics().Idle, "expected the session to be returned to the pool")
+}
+
+func TestSessionPoolRetriesExhausted(t *testing.T) {
+	lib := newMockLib()
+	pool := newTestPool(t, lib, WithRetries(2))
+
+	attempts := 0
+	err := pool.Do(func(session pkcs11.SessionHandle) error {
+		attempts++
+		return errors.Wrap(pkcs11.Error(pkcs11.CKR_DEVICE_REMOVED), "sign failed")
+	})
+	assert.NotNil(t, err, "expected an error after the retries")
+	assert.True(t, IsSessionError(err))
+	assert.Equal(t, 3, attempts)
+
+	m := pool.Metrics()
+	assert.Equal(t, uint64(2), m.Retries)
+	assert.Equal(t, uint64(3), m.Invalidated)
+	assert.Equal(t, 0, m.InUse)
+	assert.Equal(t, 0, lib.openSessions())
+}
+
+func TestSessionPoolConcurrency(t *testing.T) {
+	lib := newMockLib()
+	pool := newTestPool(t, lib, WithSize(3), WithTimeout(5*time.Second))
+
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	inUse, maxInUse := 0, 0
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				err := pool.Do(func(session pkcs11.SessionHandle) error {
+					mutex.Lock()
+					inUse++
+					if inUse > maxInUse {
+						maxInUse = inUse
+					}
+					mutex.Unlock()
+
+					time.Sleep(time.Millisecond)
+
+					mutex.Lock()
+					inUse--
+					mutex.Unlock()
+					return lib.operation(session)
+				})
+				assert.Nil(t, err, "operation failed")
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.True(t, maxInUse <= 3, "expected at most 3 sessions in use, got %d", maxInUse)
+	m := pool.Metrics()
+	assert.True(t, m.Opened <= 3, "expected at most 3 sessions to be opened, got %d", m.Opened)
+	assert.Equal(t, 0, m.InUse)
+}
+
+func TestSessionPoolClose(t *testing.T) {
+	lib := newMockLib()
+	pool := newTestPool(t, lib)
+
+	session, err := pool.Get()
+	assert.Nil(t, err)
+	other, err := pool.Get()
+	assert.Nil(t, err)
+	pool.Return(other)
+
+	pool.Close()
+	assert.Equal(t, 1, lib.openSessions(), "expected the idle session to be closed")
+	_, err = pool.Get()
+	assert.Equal(t, ErrClosed, err)
+
+	// The borrowed session is closed when it's given back
+	pool.Return(session)
+	assert.Equal(t, 0, lib.openSessions())
+}
+
+func TestIsSessionError(t *testing.T) {
+	assert.True(t, IsSessionError(pkcs11.Error(pkcs11.CKR_SESSION_HANDLE_INVALID)))
+	assert.True(t, IsSessionError(errors.Wrap(pkcs11.Error(pkcs11.CKR_USER_NOT_LOGGED_IN), "sign failed")))
+	assert.False(t, IsSessionError(pkcs11.Error(pkcs11.CKR_SIGNATURE_INVALID)))
+	assert.False(t, IsSessionError(errors.New("other error")))
+	assert.False(t, IsSessionError(nil))
+}
+
+func newTestPool(t *testing.T, lib Lib, opts ...Option) *SessionPool {
+	pool, err := NewSessionPool(lib, testSlot, testPin, opts...)
+	if err != nil {
+		t.Fatalf("NewSessionPool failed: %s", err)
+	}
+	return pool
+}
+
+// mockLib is a PKCS11 library in which faults can be injected: the HSM can fail over (all the
+// sessions become invalid and the user is logged out) and opening sessions can fail
+type mockLib struct {
+	mutex            sync.Mutex
+	sessions         map[pkcs11.SessionHandle]bool
+	next             pkcs11.SessionHandle
+	loggedIn         bool
+	openFailuresLeft int
+}
+
+func newMockLib() *mockLib {
+	return &mockLib{sessions: make(map[pkcs11.SessionHandle]bool)}
+}
+
+func (l *mockLib) OpenSession(slotID uint, flags uint) (pkcs11.SessionHandle, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.openFailuresLeft > 0 {
+		l.openFailuresLeft--
+		return 0, pkcs11.Error(pkcs11.CKR_DEVICE_ERROR)
+	}
+	if slotID != testSlot {
+		return 0, pkcs11.Error(pkcs11.CKR_SLOT_ID_INVALID)
+	}
+	l.next++
+	l.sessions[l.next] = true
+	return l.next, nil
+}
+
+func (l *mockLib) CloseSession(sh pkcs11.SessionHandle) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if !l.sessions[sh] {
+		return pkcs11.Error(pkcs11.CKR_SESSION_HANDLE_INVALID)
+	}
+	delete(l.sessions, sh)
+	return nil
+}
+
+func (l *mockLib) GetSessionInfo(sh pkcs11.SessionHandle) (pkcs11.SessionInfo, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if !l.sessions[sh] {
+		return pkcs11.SessionInfo{}, pkcs11.Error(pkcs11.CKR_SESSION_HANDLE_INVALID)
+	}
+	info := pkcs11.SessionInfo{SlotID: testSlot, State: 2} // CKS_RW_PUBLIC_SESSION
+	if l.loggedIn {
+		info.State = stateRWUserFunctions
+	}
+	return info, nil
+}
+
+func (l *mockLib) Login(sh pkcs11.SessionHandle, userType uint, pin string) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if !l.sessions[sh] {
+		return pkcs11.Error(pkcs11.CKR_SESSION_HANDLE_INVALID)
+	}
+	if pin != testPin {
+		return pkcs11.Error(pkcs11.CKR_PIN_INCORRECT)
+	}
+	if l.loggedIn {
+		return pkcs11.Error(pkcs11.CKR_USER_ALREADY_LOGGED_IN)
+	}
+	l.loggedIn = true
+	return nil
+}
+
+// operation is an operation that requires a valid session in which the user is logged in
+func (l *mockLib) operation(sh pkcs11.SessionHandle) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if !l.sessions[sh] {
+		return errors.Wrap(pkcs11.Error(pkcs11.CKR_SESSION_HANDLE_INVALID), "operation failed")
+	}
+	if !l.loggedIn {
+		return errors.Wrap(pkcs11.Error(pkcs11.CKR_USER_NOT_LOGGED_IN), "operation failed")
+	}
+	return nil
+}
+
+func (l *mockLib) failover() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.sessions = make(map[pkcs11.SessionHandle]bool)
+	l.loggedIn = false
+}
+
+func (l *mockLib) logout() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.loggedIn = false
+}
+
+func (l *mockLib) failOpen(n int) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.openFailuresLeft = n
+}
+
+func (l *mockLib) openSessions() int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return len(l.sessions)
+}
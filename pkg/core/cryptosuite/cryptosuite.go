@@ -7,16 +7,17 @@ SPDX-License-Identifier: Apache-2.0
 package cryptosuite
 
 import (
+	"hash"
 	"sync/atomic"
 
-	"errors"
-
 	"sync"
 
 	"github.com/hyperledger/fabric-sdk-go/internal/github.com/hyperledger/fabric/bccsp"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
 	"github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite/bccsp/sw"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite/common"
 	"github.com/hyperledger/fabric-sdk-go/pkg/logging"
+	"github.com/pkg/errors"
 )
 
 var logger = logging.NewLogger("fabric_sdk_go")
@@ -77,6 +78,46 @@ func GetSHAOpts() core.HashOpts {
 	return &bccsp.SHAOpts{}
 }
 
+//GetHashOpts returns options for computing the hash of the given hash family (SHA2 or SHA3)
+//and security level (256 or 384).
+func GetHashOpts(family string, level int) (core.HashOpts, error) {
+	return common.HashOpts(family, level)
+}
+
+//GetHashOptsByConfig returns options for computing the hash of the hash family and security level of
+//the security config (client.BCCSP.security.hashAlgorithm and client.BCCSP.security.level).
+func GetHashOptsByConfig(config core.Config) (core.HashOpts, error) {
+	return common.HashOpts(config.SecurityAlgorithm(), config.SecurityLevel())
+}
+
+//GetHashByConfig returns the hash function of the crypto suite for the hash family and security level
+//of the security config.
+func GetHashByConfig(cs core.CryptoSuite, config core.Config) (hash.Hash, error) {
+	opts, err := GetHashOptsByConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	h, err := cs.GetHash(opts)
+	if err != nil {
+		return nil, errors.WithMessage(err, "hash function creation failed")
+	}
+	return h, nil
+}
+
+//HashByConfig computes the hash of msg with the hash function of the crypto suite for the hash family
+//and security level of the security config.
+func HashByConfig(cs core.CryptoSuite, config core.Config, msg []byte) ([]byte, error) {
+	opts, err := GetHashOptsByConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	digest, err := cs.Hash(msg, opts)
+	if err != nil {
+		return nil, errors.WithMessage(err, "hash computation failed")
+	}
+	return digest, nil
+}
+
 //GetECDSAP256KeyGenOpts returns options for ECDSA key generation with curve P-256.
 func GetECDSAP256KeyGenOpts(ephemeral bool) core.KeyGenOpts {
 	return &bccsp.ECDSAP256KeyGenOpts{Temporary: ephemeral}
@@ -7,10 +7,14 @@ SPDX-License-Identifier: Apache-2.0
 package cryptosuite
 
 import (
+	"encoding/hex"
+	"strings"
 	"testing"
 
 	"sync/atomic"
 
+	"github.com/golang/mock/gomock"
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core/mocks"
 	"github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite/bccsp/sw"
 	"github.com/hyperledger/fabric-sdk-go/pkg/logging/testutils"
 )
@@ -99,3 +103,86 @@ func TestKeyGenOpts(t *testing.T) {
 	testutils.VerifyTrue(t, keygenOpts.Algorithm() == ecdsap256KeyGenOpts, "Unexpected SHA hash opts, expected [%v], got [%v]", ecdsap256KeyGenOpts, keygenOpts.Algorithm())
 
 }
+
+// hashVectors are the known answers for the message "abc" of each supported hash family and security level
+var hashVectors = []struct {
+	family string
+	level  int
+	digest string
+}{
+	{"SHA2", 256, "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+	{"SHA2", 384, "cb00753f45a35e8bb5a03d699ac65007272c32ab0eded1631a8b605a43ff5bed8086072ba1e7cc2358baeca134c825a7"},
+	{"SHA3", 256, "3a985da74fe225b2045c172d6bd390bd855f086e3e9d525b46bfe24511431532"},
+	{"SHA3", 384, "ec01498288516fc926459f58e2c6ad8df9b473cb0fc08c2596da7cf0e49be4b298d88cea927ac7f539f1edf228376d25"},
+}
+
+func TestHashByConfig(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	for _, v := range hashVectors {
+		mockConfig := mock_core.NewMockConfig(mockCtrl)
+		mockConfig.EXPECT().SecurityProvider().Return("SW")
+		mockConfig.EXPECT().SecurityAlgorithm().Return(v.family).AnyTimes()
+		mockConfig.EXPECT().SecurityLevel().Return(v.level).AnyTimes()
+		mockConfig.EXPECT().KeyStorePath().Return("")
+		mockConfig.EXPECT().Ephemeral().Return(true)
+
+		cs, err := sw.GetSuiteByConfig(mockConfig)
+		if err != nil {
+			t.Fatalf("%s-%d: failed to get crypto suite: %v", v.family, v.level, err)
+		}
+
+		digest, err := HashByConfig(cs, mockConfig, []byte("abc"))
+		if err != nil {
+			t.Fatalf("%s-%d: failed to hash: %v", v.family, v.level, err)
+		}
+		if hex.EncodeToString(digest) != v.digest {
+			t.Fatalf("%s-%d: unexpected digest %x", v.family, v.level, digest)
+		}
+
+		h, err := GetHashByConfig(cs, mockConfig)
+		if err != nil {
+			t.Fatalf("%s-%d: failed to get hash function: %v", v.family, v.level, err)
+		}
+		h.Write([]byte("abc"))
+		if hex.EncodeToString(h.Sum(nil)) != v.digest {
+			t.Fatalf("%s-%d: unexpected digest of hash function", v.family, v.level)
+		}
+
+		// the default hash function of the crypto suite is the configured hash function
+		digest, err = cs.Hash([]byte("abc"), GetSHAOpts())
+		if err != nil || hex.EncodeToString(digest) != v.digest {
+			t.Fatalf("%s-%d: unexpected default digest of crypto suite %x: %v", v.family, v.level, digest, err)
+		}
+	}
+}
+
+func TestGetHashOptsInvalid(t *testing.T) {
+	if _, err := GetHashOpts("SHA2", 512); err == nil {
+		t.Fatalf("Expected error for unsupported security level")
+	}
+	if _, err := GetHashOpts("SHA3", 224); err == nil {
+		t.Fatalf("Expected error for unsupported security level")
+	}
+	if _, err := GetHashOpts("MD5", 256); err == nil {
+		t.Fatalf("Expected error for unsupported hash family")
+	}
+}
+
+func TestInvalidSecurityConfig(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockConfig := mock_core.NewMockConfig(mockCtrl)
+	mockConfig.EXPECT().SecurityProvider().Return("SW")
+	mockConfig.EXPECT().SecurityAlgorithm().Return("SHA2")
+	mockConfig.EXPECT().SecurityLevel().Return(512)
+	mockConfig.EXPECT().KeyStorePath().Return("")
+	mockConfig.EXPECT().Ephemeral().Return(true)
+
+	_, err := sw.GetSuiteByConfig(mockConfig)
+	if err == nil || !strings.Contains(err.Error(), "unsupported security level") {
+		t.Fatalf("Expected error for unsupported security level, got %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package cryptosuite
+
+import (
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/pem"
+
+	"github.com/hyperledger/fabric-sdk-go/internal/github.com/hyperledger/fabric/bccsp"
+	"github.com/hyperledger/fabric-sdk-go/internal/github.com/hyperledger/fabric/bccsp/utils"
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
+	"github.com/pkg/errors"
+)
+
+//ImportPrivateKeyPEM imports a PEM encoded ECDSA private key (SEC 1 or PKCS#8) into the default crypto suite.
+//Unless ephemeral is set the key is stored by the crypto suite and can be retrieved by its SKI with GetKey.
+func ImportPrivateKeyPEM(raw []byte, ephemeral bool) (core.Key, error) {
+	return NewKeyImporter(GetDefault()).PrivateKeyPEM(raw, ephemeral)
+}
+
+//ImportPrivateKeyDER imports a DER encoded ECDSA private key (SEC 1 or PKCS#8) into the default crypto suite.
+//Unless ephemeral is set the key is stored by the crypto suite and can be retrieved by its SKI with GetKey.
+func ImportPrivateKeyDER(der []byte, ephemeral bool) (core.Key, error) {
+	return NewKeyImporter(GetDefault()).PrivateKeyDER(der, ephemeral)
+}
+
+//ImportCertPEM imports the public key of a PEM encoded X.509 certificate into the default crypto suite
+func ImportCertPEM(raw []byte) (core.Key, error) {
+	return NewKeyImporter(GetDefault()).CertPEM(raw)
+}
+
+//ImportCertDER imports the public key of a DER encoded X.509 certificate into the default crypto suite
+func ImportCertDER(der []byte) (core.Key, error) {
+	return NewKeyImporter(GetDefault()).CertDER(der)
+}
+
+//KeyImporter imports existing keys into a crypto suite
+type KeyImporter struct {
+	cryptoSuite core.CryptoSuite
+}
+
+//NewKeyImporter returns a key importer for the given crypto suite
+func NewKeyImporter(cs core.CryptoSuite) *KeyImporter {
+	return &KeyImporter{cryptoSuite: cs}
+}
+
+//PrivateKeyPEM imports a PEM encoded ECDSA private key (SEC 1 or PKCS#8).
+//For the SW crypto suite a non ephemeral key is stored in the key store, for the PKCS11 crypto suite
+//it is stored on the token. Tokens that don't allow the import of private keys return an error.
+func (ki *KeyImporter) PrivateKeyPEM(raw []byte, ephemeral bool) (core.Key, error) {
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, errors.New("failed to decode PEM encoded private key")
+	}
+	if x509.IsEncryptedPEMBlock(block) {
+		return nil, errors.New("encrypted private keys are not supported")
+	}
+	return ki.PrivateKeyDER(block.Bytes, ephemeral)
+}
+
+//PrivateKeyDER imports a DER encoded ECDSA private key (SEC 1 or PKCS#8)
+func (ki *KeyImporter) PrivateKeyDER(der []byte, ephemeral bool) (core.Key, error) {
+	if len(der) == 0 {
+		return nil, errors.New("private key is required")
+	}
+
+	// the crypto suites only import ECDSA private keys
+	privateKey, err := utils.DERToPrivateKey(der)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to parse private key")
+	}
+	if _, ok := privateKey.(*ecdsa.PrivateKey); !ok {
+		return nil, errors.Errorf("unsupported private key type %T, only ECDSA private keys can be imported", privateKey)
+	}
+
+	key, err := ki.cryptoSuite.KeyImport(der, &bccsp.ECDSAPrivateKeyImportOpts{Temporary: ephemeral})
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to import private key")
+	}
+	return key, nil
+}
+
+//CertPEM imports the public key of a PEM encoded X.509 certificate
+func (ki *KeyImporter) CertPEM(raw []byte) (core.Key, error) {
+	block, _ := pem.Decode(raw)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errors.New("failed to decode PEM encoded certificate")
+	}
+	return ki.CertDER(block.Bytes)
+}
+
+//CertDER imports the public key of a DER encoded X.509 certificate
+func (ki *KeyImporter) CertDER(der []byte) (core.Key, error) {
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse certificate")
+	}
+
+	// public keys are always ephemeral, the private key is looked up by the SKI of the public key
+	key, err := ki.cryptoSuite.KeyImport(cert, &bccsp.X509PublicKeyImportOpts{Temporary: true})
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to import certificate's public key")
+	}
+	return key, nil
+}
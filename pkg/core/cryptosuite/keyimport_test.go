@@ -0,0 +1,198 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package cryptosuite
+
+import (
+	"bytes"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core/mocks"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite/bccsp/sw"
+)
+
+var keyImportFixtures = []struct {
+	name string
+	key  string
+	cert string
+}{
+	{"P-256 SEC 1", "p256-sec1-key.pem", "p256-cert.pem"},
+	{"P-256 PKCS#8", "p256-pkcs8-key.pem", "p256-cert.pem"},
+	{"P-384 SEC 1", "p384-sec1-key.pem", "p384-cert.pem"},
+	{"P-384 PKCS#8", "p384-pkcs8-key.pem", "p384-cert.pem"},
+}
+
+func TestImportPrivateKeyPEM(t *testing.T) {
+	cs, err := sw.GetSuiteWithDefaultEphemeral()
+	if err != nil {
+		t.Fatalf("Failed to get crypto suite: %v", err)
+	}
+	ki := NewKeyImporter(cs)
+
+	for _, f := range keyImportFixtures {
+		privateKey, err := ki.PrivateKeyPEM(readFixture(t, f.key), true)
+		if err != nil {
+			t.Fatalf("%s: failed to import private key: %v", f.name, err)
+		}
+		if !privateKey.Private() {
+			t.Fatalf("%s: expected a private key", f.name)
+		}
+		publicKey, err := ki.CertPEM(readFixture(t, f.cert))
+		if err != nil {
+			t.Fatalf("%s: failed to import certificate: %v", f.name, err)
+		}
+		if !bytes.Equal(privateKey.SKI(), publicKey.SKI()) {
+			t.Fatalf("%s: SKI of private key doesn't match SKI of certificate", f.name)
+		}
+		verifySignRoundTrip(t, cs, privateKey, publicKey)
+	}
+}
+
+func TestImportPrivateKeyDER(t *testing.T) {
+	cs, err := sw.GetSuiteWithDefaultEphemeral()
+	if err != nil {
+		t.Fatalf("Failed to get crypto suite: %v", err)
+	}
+	ki := NewKeyImporter(cs)
+
+	for _, f := range keyImportFixtures {
+		keyBlock, _ := pem.Decode(readFixture(t, f.key))
+		certBlock, _ := pem.Decode(readFixture(t, f.cert))
+
+		privateKey, err := ki.PrivateKeyDER(keyBlock.Bytes, true)
+		if err != nil {
+			t.Fatalf("%s: failed to import private key: %v", f.name, err)
+		}
+		publicKey, err := ki.CertDER(certBlock.Bytes)
+		if err != nil {
+			t.Fatalf("%s: failed to import certificate: %v", f.name, err)
+		}
+		verifySignRoundTrip(t, cs, privateKey, publicKey)
+	}
+}
+
+func TestImportPrivateKeyPersisted(t *testing.T) {
+	keyStorePath, err := ioutil.TempDir("", "keyimport")
+	if err != nil {
+		t.Fatalf("Failed to create key store directory: %v", err)
+	}
+	defer os.RemoveAll(keyStorePath)
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	cs := newFileKeyStoreSuite(t, mockCtrl, keyStorePath)
+	privateKey, err := NewKeyImporter(cs).PrivateKeyPEM(readFixture(t, "p384-sec1-key.pem"), false)
+	if err != nil {
+		t.Fatalf("Failed to import private key: %v", err)
+	}
+
+	// a new crypto suite on the same key store acts as a restarted process
+	cs = newFileKeyStoreSuite(t, mockCtrl, keyStorePath)
+	storedKey, err := cs.GetKey(privateKey.SKI())
+	if err != nil {
+		t.Fatalf("Failed to get imported key by SKI: %v", err)
+	}
+	if !storedKey.Private() {
+		t.Fatalf("Expected the stored key to be private")
+	}
+
+	publicKey, err := NewKeyImporter(cs).CertPEM(readFixture(t, "p384-cert.pem"))
+	if err != nil {
+		t.Fatalf("Failed to import certificate: %v", err)
+	}
+	verifySignRoundTrip(t, cs, storedKey, publicKey)
+}
+
+func TestImportPrivateKeyEphemeralNotPersisted(t *testing.T) {
+	keyStorePath, err := ioutil.TempDir("", "keyimport")
+	if err != nil {
+		t.Fatalf("Failed to create key store directory: %v", err)
+	}
+	defer os.RemoveAll(keyStorePath)
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	cs := newFileKeyStoreSuite(t, mockCtrl, keyStorePath)
+	privateKey, err := NewKeyImporter(cs).PrivateKeyPEM(readFixture(t, "p256-sec1-key.pem"), true)
+	if err != nil {
+		t.Fatalf("Failed to import private key: %v", err)
+	}
+
+	cs = newFileKeyStoreSuite(t, mockCtrl, keyStorePath)
+	if _, err := cs.GetKey(privateKey.SKI()); err == nil {
+		t.Fatalf("Ephemeral key isn't supposed to be stored")
+	}
+}
+
+func TestImportInvalidKeys(t *testing.T) {
+	cs, err := sw.GetSuiteWithDefaultEphemeral()
+	if err != nil {
+		t.Fatalf("Failed to get crypto suite: %v", err)
+	}
+	ki := NewKeyImporter(cs)
+
+	if _, err := ki.PrivateKeyPEM([]byte("not a PEM"), true); err == nil {
+		t.Fatalf("Expected error importing invalid PEM")
+	}
+	if _, err := ki.PrivateKeyDER(nil, true); err == nil {
+		t.Fatalf("Expected error importing empty DER")
+	}
+	if _, err := ki.PrivateKeyPEM(readFixture(t, "rsa-key.pem"), true); err == nil {
+		t.Fatalf("Expected error importing RSA private key")
+	}
+	if _, err := ki.CertPEM(readFixture(t, "p256-sec1-key.pem")); err == nil {
+		t.Fatalf("Expected error importing private key as certificate")
+	}
+	if _, err := ki.CertDER([]byte("invalid")); err == nil {
+		t.Fatalf("Expected error importing invalid certificate")
+	}
+}
+
+func verifySignRoundTrip(t *testing.T, cs core.CryptoSuite, privateKey core.Key, publicKey core.Key) {
+	digest, err := cs.Hash([]byte("Sample message"), GetSHAOpts())
+	if err != nil {
+		t.Fatalf("Failed to hash message: %v", err)
+	}
+	signature, err := cs.Sign(privateKey, digest, nil)
+	if err != nil {
+		t.Fatalf("Failed to sign with imported key: %v", err)
+	}
+	valid, err := cs.Verify(publicKey, signature, digest, nil)
+	if err != nil || !valid {
+		t.Fatalf("Failed to verify signature with certificate's public key: %v", err)
+	}
+}
+
+func newFileKeyStoreSuite(t *testing.T, mockCtrl *gomock.Controller, keyStorePath string) core.CryptoSuite {
+	mockConfig := mock_core.NewMockConfig(mockCtrl)
+	mockConfig.EXPECT().SecurityProvider().Return("SW")
+	mockConfig.EXPECT().SecurityAlgorithm().Return("SHA2")
+	mockConfig.EXPECT().SecurityLevel().Return(256)
+	mockConfig.EXPECT().KeyStorePath().Return(keyStorePath)
+	mockConfig.EXPECT().Ephemeral().Return(false)
+
+	cs, err := sw.GetSuiteByConfig(mockConfig)
+	if err != nil {
+		t.Fatalf("Failed to get crypto suite: %v", err)
+	}
+	return cs
+}
+
+func readFixture(t *testing.T, name string) []byte {
+	raw, err := ioutil.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("Failed to read fixture %s: %v", name, err)
+	}
+	return raw
+}
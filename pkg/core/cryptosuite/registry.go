@@ -0,0 +1,81 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package cryptosuite
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite/bccsp/sw"
+	"github.com/pkg/errors"
+)
+
+//SuiteFactory creates a crypto suite according to the given config
+type SuiteFactory func(config core.Config) (core.CryptoSuite, error)
+
+var (
+	factoriesMutex sync.RWMutex
+	factories      = make(map[string]SuiteFactory)
+)
+
+func init() {
+	Register("SW", sw.GetSuiteByConfig)
+}
+
+//Register makes a crypto suite factory available by the provider name of the security config
+//(client.BCCSP.security.default.provider). The built-in "SW" provider is always registered, the
+//"PKCS11" provider is registered by importing the bccsp/pkcs11 package.
+//Register panics if the name is empty, the factory is nil or a factory is already registered by the name.
+func Register(name string, factory SuiteFactory) {
+	factoriesMutex.Lock()
+	defer factoriesMutex.Unlock()
+
+	if name == "" {
+		panic("cryptosuite: provider name is required")
+	}
+	if factory == nil {
+		panic("cryptosuite: factory of provider " + name + " is nil")
+	}
+	if _, exists := factories[name]; exists {
+		panic("cryptosuite: Register called twice for provider " + name)
+	}
+	factories[name] = factory
+}
+
+//Providers returns the sorted names of the registered crypto suite providers
+func Providers() []string {
+	factoriesMutex.RLock()
+	defer factoriesMutex.RUnlock()
+
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+//GetSuiteByConfig returns the crypto suite created by the factory registered for the provider of the config
+func GetSuiteByConfig(config core.Config) (core.CryptoSuite, error) {
+	name := config.SecurityProvider()
+
+	factoriesMutex.RLock()
+	factory, ok := factories[name]
+	factoriesMutex.RUnlock()
+
+	if !ok {
+		return nil, errors.Errorf("Unsupported security provider requested: %s (registered providers: %s)", name, strings.Join(Providers(), ", "))
+	}
+
+	suite, err := factory(config)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to create crypto suite "+name)
+	}
+	return suite, nil
+}
@@ -0,0 +1,100 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package cryptosuite
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core/mocks"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite/bccsp/sw"
+	"github.com/pkg/errors"
+)
+
+type stubCryptoSuite struct {
+	core.CryptoSuite
+}
+
+func TestRegister(t *testing.T) {
+	Register("STUB", func(config core.Config) (core.CryptoSuite, error) {
+		suite, err := sw.GetSuiteWithDefaultEphemeral()
+		return &stubCryptoSuite{suite}, err
+	})
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockConfig := mock_core.NewMockConfig(mockCtrl)
+	mockConfig.EXPECT().SecurityProvider().Return("STUB")
+
+	suite, err := GetSuiteByConfig(mockConfig)
+	if err != nil {
+		t.Fatalf("Unexpected error creating registered crypto suite: %v", err)
+	}
+	if _, ok := suite.(*stubCryptoSuite); !ok {
+		t.Fatalf("Expected the crypto suite of the registered factory")
+	}
+
+	providers := strings.Join(Providers(), ",")
+	if !strings.Contains(providers, "STUB") || !strings.Contains(providers, "SW") {
+		t.Fatalf("Expected registered providers SW and STUB, got %s", providers)
+	}
+}
+
+func TestRegisterFactoryError(t *testing.T) {
+	Register("FAILING", func(config core.Config) (core.CryptoSuite, error) {
+		return nil, errors.New("factory failed")
+	})
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockConfig := mock_core.NewMockConfig(mockCtrl)
+	mockConfig.EXPECT().SecurityProvider().Return("FAILING")
+
+	if _, err := GetSuiteByConfig(mockConfig); err == nil || !strings.Contains(err.Error(), "factory failed") {
+		t.Fatalf("Expected error of the factory, got %v", err)
+	}
+}
+
+func TestRegisterDuplicate(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatalf("Expected panic registering a provider twice")
+		}
+	}()
+	Register("SW", sw.GetSuiteByConfig)
+}
+
+func TestRegisterInvalid(t *testing.T) {
+	verifyRegisterPanics(t, "", sw.GetSuiteByConfig)
+	verifyRegisterPanics(t, "NIL", nil)
+}
+
+func TestGetSuiteByConfigUnknownProvider(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockConfig := mock_core.NewMockConfig(mockCtrl)
+	mockConfig.EXPECT().SecurityProvider().Return("UNKNOWN")
+
+	_, err := GetSuiteByConfig(mockConfig)
+	if err == nil {
+		t.Fatalf("Unknown security provider should return error")
+	}
+	if !strings.Contains(err.Error(), "UNKNOWN") || !strings.Contains(err.Error(), "SW") {
+		t.Fatalf("Expected error listing the registered providers, got %v", err)
+	}
+}
+
+func verifyRegisterPanics(t *testing.T, name string, factory SuiteFactory) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatalf("Expected panic registering provider [%s]", name)
+		}
+	}()
+	Register(name, factory)
+}
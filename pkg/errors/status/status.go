@@ -108,6 +108,9 @@ func FromError(err error) (s *Status, ok bool) {
 	if s, ok := unwrappedErr.(*Status); ok {
 		return s, true
 	}
+	if p, ok := unwrappedErr.(statusProvider); ok {
+		return p.Status(), true
+	}
 	if m, ok := unwrappedErr.(multi.Errors); ok {
 		return New(ClientStatus, MultipleErrors.ToInt32(), m.Error(), nil), true
 	}
@@ -115,6 +118,11 @@ func FromError(err error) (s *Status, ok bool) {
 	return nil, false
 }
 
+// statusProvider is implemented by errors that have a status
+type statusProvider interface {
+	Status() *Status
+}
+
 func (s *Status) Error() string {
 	return fmt.Sprintf("%s Code: (%d) %s. Description: %s", s.Group.String(), s.Code, s.codeString(), s.Message)
 }
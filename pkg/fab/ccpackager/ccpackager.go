@@ -0,0 +1,177 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package ccpackager creates chaincode deployment packages, which can be signed by the owners of the
+// chaincode and installed on the peers with the same bytes (see resmgmt.InstallCCRequest).
+package ccpackager
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+
+	fcutils "github.com/hyperledger/fabric-sdk-go/internal/github.com/hyperledger/fabric/common/util"
+	"github.com/hyperledger/fabric-sdk-go/pkg/context"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/ccpackager/gopackager"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/policy"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/txn"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+)
+
+// NewCCPackage creates the chaincode deployment spec (CDS) of the golang chaincode with the given name
+// and version at the given path (relative to goPath/src, the GOPATH is used if goPath is empty).
+// The package is reproducible: packaging the same sources twice returns the same bytes.
+func NewCCPackage(name, version, path, goPath string) ([]byte, error) {
+	if name == "" || version == "" {
+		return nil, errors.New("chaincode name and version are required")
+	}
+
+	ccPkg, err := gopackager.NewCCPackage(path, goPath)
+	if err != nil {
+		return nil, errors.WithMessage(err, "packaging of chaincode sources failed")
+	}
+
+	// The CDS has no effective date so that it only depends on the sources
+	cds := &pb.ChaincodeDeploymentSpec{
+		ChaincodeSpec: &pb.ChaincodeSpec{
+			Type:        ccPkg.Type,
+			ChaincodeId: &pb.ChaincodeID{Name: name, Path: path, Version: version},
+		},
+		CodePackage: ccPkg.Code,
+	}
+	cdsBytes, err := proto.Marshal(cds)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal of chaincode deployment spec failed")
+	}
+	return cdsBytes, nil
+}
+
+// SignPackage signs the chaincode deployment spec created by NewCCPackage as the owner of the chaincode
+// (the identity of the context) and returns the signed package, a SignedChaincodeDeploymentSpec envelope.
+// The instantiation policy must be satisfied by the identity that instantiates the chaincode; if it is nil
+// then an admin of the MSP of the owner is required.
+func SignPackage(ctx context.Context, cds []byte, instantiationPolicy *common.SignaturePolicyEnvelope) ([]byte, error) {
+	if _, err := unmarshalCDS(cds); err != nil {
+		return nil, err
+	}
+
+	if instantiationPolicy == nil {
+		instantiationPolicy = policy.SignedBy(ctx.MspID(), policy.Admin)
+	}
+	policyBytes, err := proto.Marshal(instantiationPolicy)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal of instantiation policy failed")
+	}
+
+	owner, err := ctx.Identity()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get user context's identity")
+	}
+
+	// the owner endorses the CDS and the instantiation policy
+	signingMgr := ctx.SigningManager()
+	signature, err := signingMgr.Sign(fcutils.ConcatenateBytes(cds, policyBytes, owner), ctx.PrivateKey())
+	if err != nil {
+		return nil, errors.WithMessage(err, "signing of chaincode deployment spec failed")
+	}
+
+	signedCDS := &pb.SignedChaincodeDeploymentSpec{
+		ChaincodeDeploymentSpec: cds,
+		InstantiationPolicy:     policyBytes,
+		OwnerEndorsements:       []*pb.Endorsement{{Endorser: owner, Signature: signature}},
+	}
+	signedCDSBytes, err := proto.Marshal(signedCDS)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal of signed chaincode deployment spec failed")
+	}
+
+	txh, err := txn.NewHeader(ctx, "")
+	if err != nil {
+		return nil, errors.WithMessage(err, "create transaction header failed")
+	}
+	channelHeader, err := txn.CreateChannelHeader(common.HeaderType_CHAINCODE_PACKAGE, txn.ChannelHeaderOpts{TxnHeader: txh})
+	if err != nil {
+		return nil, errors.WithMessage(err, "create channel header failed")
+	}
+	payload, err := txn.CreatePayload(txh, channelHeader, signedCDSBytes)
+	if err != nil {
+		return nil, errors.WithMessage(err, "create payload failed")
+	}
+	payloadBytes, err := proto.Marshal(payload)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal of payload failed")
+	}
+
+	envelopeSignature, err := signingMgr.Sign(payloadBytes, ctx.PrivateKey())
+	if err != nil {
+		return nil, errors.WithMessage(err, "signing of package failed")
+	}
+	envelopeBytes, err := proto.Marshal(&common.Envelope{Payload: payloadBytes, Signature: envelopeSignature})
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal of package envelope failed")
+	}
+	return envelopeBytes, nil
+}
+
+// ChaincodeID returns the ID (name, path and version) of the chaincode of a package created by
+// NewCCPackage or SignPackage.
+func ChaincodeID(pkg []byte) (*pb.ChaincodeID, error) {
+	cdsBytes := pkg
+	if signedCDS, err := unmarshalSignedCDS(pkg); err == nil {
+		cdsBytes = signedCDS.ChaincodeDeploymentSpec
+	}
+
+	cds, err := unmarshalCDS(cdsBytes)
+	if err != nil {
+		return nil, err
+	}
+	return cds.ChaincodeSpec.ChaincodeId, nil
+}
+
+// unmarshalSignedCDS returns the signed chaincode deployment spec of a package created by SignPackage
+func unmarshalSignedCDS(pkg []byte) (*pb.SignedChaincodeDeploymentSpec, error) {
+	envelope := &common.Envelope{}
+	if err := proto.Unmarshal(pkg, envelope); err != nil {
+		return nil, errors.Wrap(err, "unmarshal of package envelope failed")
+	}
+	payload := &common.Payload{}
+	if err := proto.Unmarshal(envelope.Payload, payload); err != nil {
+		return nil, errors.Wrap(err, "unmarshal of package payload failed")
+	}
+	if payload.Header == nil {
+		return nil, errors.New("package payload header is missing")
+	}
+	channelHeader := &common.ChannelHeader{}
+	if err := proto.Unmarshal(payload.Header.ChannelHeader, channelHeader); err != nil {
+		return nil, errors.Wrap(err, "unmarshal of package channel header failed")
+	}
+	if channelHeader.Type != int32(common.HeaderType_CHAINCODE_PACKAGE) {
+		return nil, errors.Errorf("package has header type %d instead of %d", channelHeader.Type, common.HeaderType_CHAINCODE_PACKAGE)
+	}
+
+	signedCDS := &pb.SignedChaincodeDeploymentSpec{}
+	if err := proto.Unmarshal(payload.Data, signedCDS); err != nil {
+		return nil, errors.Wrap(err, "unmarshal of signed chaincode deployment spec failed")
+	}
+	return signedCDS, nil
+}
+
+// unmarshalCDS returns the chaincode deployment spec and checks that it identifies its chaincode and
+// contains the code
+func unmarshalCDS(cdsBytes []byte) (*pb.ChaincodeDeploymentSpec, error) {
+	cds := &pb.ChaincodeDeploymentSpec{}
+	if err := proto.Unmarshal(cdsBytes, cds); err != nil {
+		return nil, errors.Wrap(err, "unmarshal of chaincode deployment spec failed")
+	}
+	ccID := cds.GetChaincodeSpec().GetChaincodeId()
+	if ccID.GetName() == "" || ccID.GetVersion() == "" {
+		return nil, errors.New("chaincode deployment spec must contain the chaincode name and version")
+	}
+	if len(cds.CodePackage) == 0 {
+		return nil, errors.New("chaincode deployment spec must contain the code package")
+	}
+	return cds, nil
+}
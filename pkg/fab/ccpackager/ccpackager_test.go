@@ -0,0 +1,136 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ccpackager
+
+import (
+	"crypto/sha256"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/policy"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+)
+
+const (
+	ccName    = "examplecc"
+	ccVersion = "v1"
+	ccPath    = "github.com/example_cc"
+)
+
+func TestNewCCPackage(t *testing.T) {
+	cds := newTestPackage(t)
+
+	// Packaging the same sources twice returns the same package
+	repackaged := newTestPackage(t)
+	assert.Equal(t, sha256.Sum256(cds), sha256.Sum256(repackaged), "expected the same package hash")
+
+	spec := &pb.ChaincodeDeploymentSpec{}
+	if err := proto.Unmarshal(cds, spec); err != nil {
+		t.Fatalf("Failed to unmarshal chaincode deployment spec: %s", err)
+	}
+	assert.Equal(t, pb.ChaincodeSpec_GOLANG, spec.ChaincodeSpec.Type)
+	assert.NotEmpty(t, spec.CodePackage, "expected the code package")
+
+	ccID, err := ChaincodeID(cds)
+	assert.Nil(t, err, "ChaincodeID failed")
+	assert.True(t, proto.Equal(&pb.ChaincodeID{Name: ccName, Path: ccPath, Version: ccVersion}, ccID))
+
+	_, err = NewCCPackage("", ccVersion, ccPath, testGoPath(t))
+	assert.NotNil(t, err, "expected an error without a chaincode name")
+	_, err = NewCCPackage(ccName, ccVersion, "", testGoPath(t))
+	assert.NotNil(t, err, "expected an error without a chaincode path")
+}
+
+func TestSignPackage(t *testing.T) {
+	cds := newTestPackage(t)
+	ctx := mocks.NewMockContext(mocks.NewMockUserWithMSPID("test", "Org1MSP"))
+
+	pkg, err := SignPackage(ctx, cds, nil)
+	if err != nil {
+		t.Fatalf("SignPackage failed: %s", err)
+	}
+
+	envelope := &common.Envelope{}
+	if err := proto.Unmarshal(pkg, envelope); err != nil {
+		t.Fatalf("Failed to unmarshal package envelope: %s", err)
+	}
+	assert.Equal(t, envelope.Payload, envelope.Signature, "expected the mock signature of the payload")
+
+	signedCDS, err := unmarshalSignedCDS(pkg)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal signed package: %s", err)
+	}
+	assert.Equal(t, cds, signedCDS.ChaincodeDeploymentSpec, "expected the chaincode deployment spec as is")
+
+	// The default instantiation policy requires an admin of the MSP of the owner
+	instantiationPolicy := &common.SignaturePolicyEnvelope{}
+	if err := proto.Unmarshal(signedCDS.InstantiationPolicy, instantiationPolicy); err != nil {
+		t.Fatalf("Failed to unmarshal instantiation policy: %s", err)
+	}
+	assert.True(t, proto.Equal(policy.SignedBy("Org1MSP", policy.Admin), instantiationPolicy), "expected the admin policy of the owner")
+
+	owner, err := ctx.Identity()
+	assert.Nil(t, err)
+	if assert.Len(t, signedCDS.OwnerEndorsements, 1) {
+		endorsement := signedCDS.OwnerEndorsements[0]
+		assert.Equal(t, owner, endorsement.Endorser)
+		expected := append(append(append([]byte{}, cds...), signedCDS.InstantiationPolicy...), owner...)
+		assert.Equal(t, expected, endorsement.Signature, "expected the signature of the CDS, the policy and the owner")
+	}
+
+	ccID, err := ChaincodeID(pkg)
+	assert.Nil(t, err, "ChaincodeID failed for signed package")
+	assert.Equal(t, ccName, ccID.Name)
+	assert.Equal(t, ccVersion, ccID.Version)
+
+	// A given instantiation policy is used
+	orPolicy := policy.Or(policy.SignedBy("Org1MSP", policy.Admin), policy.SignedBy("Org2MSP", policy.Admin))
+	pkg, err = SignPackage(ctx, cds, orPolicy)
+	assert.Nil(t, err, "SignPackage failed")
+	signedCDS, err = unmarshalSignedCDS(pkg)
+	assert.Nil(t, err)
+	policyBytes, err := proto.Marshal(orPolicy)
+	assert.Nil(t, err)
+	assert.Equal(t, policyBytes, signedCDS.InstantiationPolicy)
+}
+
+func TestSignPackageInvalid(t *testing.T) {
+	ctx := mocks.NewMockContext(mocks.NewMockUser("test"))
+
+	_, err := SignPackage(ctx, []byte("invalid"), nil)
+	assert.NotNil(t, err, "expected an error for an invalid chaincode deployment spec")
+
+	cds, err := proto.Marshal(&pb.ChaincodeDeploymentSpec{ChaincodeSpec: &pb.ChaincodeSpec{ChaincodeId: &pb.ChaincodeID{Name: ccName}}, CodePackage: []byte("code")})
+	assert.Nil(t, err)
+	_, err = SignPackage(ctx, cds, nil)
+	assert.NotNil(t, err, "expected an error for a chaincode deployment spec without a version")
+
+	_, err = ChaincodeID([]byte("invalid"))
+	assert.NotNil(t, err, "expected an error for an invalid package")
+}
+
+func newTestPackage(t *testing.T) []byte {
+	cds, err := NewCCPackage(ccName, ccVersion, ccPath, testGoPath(t))
+	if err != nil {
+		t.Fatalf("NewCCPackage failed: %s", err)
+	}
+	return cds
+}
+
+func testGoPath(t *testing.T) string {
+	pwd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("error from os.Getwd %v", err)
+	}
+	return path.Join(pwd, "../../../test/fixtures/testdata")
+}
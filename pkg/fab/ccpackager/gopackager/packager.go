@@ -15,6 +15,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
 	"time"
 
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/resource/api"
@@ -37,7 +38,9 @@ var keep = []string{".go", ".c", ".h"}
 
 var logger = logging.NewLogger("fabric_sdk_go")
 
-// NewCCPackage creates new go lang chaincode package
+// NewCCPackage creates new go lang chaincode package. The package is reproducible: the files are
+// packed in the order of their names and with the same mode and timestamps, so packaging the same
+// sources always returns the same bytes.
 func NewCCPackage(chaincodePath string, goPath string) (*api.CCPackage, error) {
 
 	if chaincodePath == "" {
@@ -82,7 +85,8 @@ func NewCCPackage(chaincodePath string, goPath string) (*api.CCPackage, error) {
 // Given an input 'filePath', recursively parse the filesystem for any files
 // that fit the criteria for being valid golang source (ISREG + (*.(go|c|h)))
 // As a convenience, we also formulate a tar-friendly "name" for each file
-// based on relative position to 'goPath'.
+// based on relative position to 'goPath'. The descriptors are sorted by
+// name so that the files are always packed in the same order.
 // -------------------------------------------------------------------------
 func findSource(goPath string, filePath string) ([]*Descriptor, error) {
 	var descriptors []*Descriptor
@@ -96,7 +100,7 @@ func findSource(goPath string, filePath string) ([]*Descriptor, error) {
 				if err != nil {
 					return err
 				}
-				descriptors = append(descriptors, &Descriptor{name: relPath, fqp: path})
+				descriptors = append(descriptors, &Descriptor{name: filepath.ToSlash(relPath), fqp: path})
 			}
 			return nil
 
@@ -104,6 +108,9 @@ func findSource(goPath string, filePath string) ([]*Descriptor, error) {
 	if err != nil {
 		return descriptors, err
 	}
+	sort.Slice(descriptors, func(i, j int) bool {
+		return descriptors[i].name < descriptors[j].name
+	})
 	return descriptors, nil
 }
 
@@ -163,8 +170,10 @@ func packEntry(tw *tar.Writer, gw *gzip.Writer, descriptor *Descriptor) error {
 		// now lets create the header as needed for this file within the tarball
 		header := new(tar.Header)
 		header.Name = descriptor.name
+		header.Typeflag = tar.TypeReg
 		header.Size = stat.Size()
-		header.Mode = int64(stat.Mode())
+		// Use the same mode for all files, regardless of the permissions of the checkout
+		header.Mode = 0100644
 		// Use a deterministic "zero-time" for all date fields
 		header.ModTime = time.Time{}
 		header.AccessTime = time.Time{}
@@ -10,10 +10,14 @@ import (
 	"archive/tar"
 	"bytes"
 	"compress/gzip"
+	"crypto/sha256"
 	"io"
+	"io/ioutil"
 	"os"
 	"path"
+	"path/filepath"
 	"testing"
+	"time"
 )
 
 // Test golang ChainCode packaging
@@ -59,6 +63,78 @@ func TestNewCCPackage(t *testing.T) {
 
 }
 
+// Test that packaging the same sources twice returns the same package
+func TestNewCCPackageDeterministic(t *testing.T) {
+	goPath, err := ioutil.TempDir("", "gopackager")
+	if err != nil {
+		t.Fatalf("error from ioutil.TempDir %v", err)
+	}
+	defer os.RemoveAll(goPath)
+
+	ccPath := "example.com/cc"
+	for _, name := range []string{"main.go", "b/b.go", "a.go", "a/a.go", "README.md"} {
+		file := filepath.Join(goPath, "src", ccPath, name)
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatalf("error from os.MkdirAll %v", err)
+		}
+		if err := ioutil.WriteFile(file, []byte("package "+filepath.Base(filepath.Dir(file))), 0644); err != nil {
+			t.Fatalf("error from ioutil.WriteFile %v", err)
+		}
+	}
+
+	ccPackage, err := NewCCPackage(ccPath, goPath)
+	if err != nil {
+		t.Fatalf("error from Create %v", err)
+	}
+
+	// Neither the timestamps nor the permissions of the files change the package
+	mainFile := filepath.Join(goPath, "src", ccPath, "main.go")
+	if err := os.Chtimes(mainFile, time.Now().Add(time.Hour), time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("error from os.Chtimes %v", err)
+	}
+	if err := os.Chmod(mainFile, 0755); err != nil {
+		t.Fatalf("error from os.Chmod %v", err)
+	}
+
+	repackaged, err := NewCCPackage(ccPath, goPath)
+	if err != nil {
+		t.Fatalf("error from Create %v", err)
+	}
+	if sha256.Sum256(ccPackage.Code) != sha256.Sum256(repackaged.Code) {
+		t.Fatalf("packaging the same sources twice must return the same package")
+	}
+
+	gzf, err := gzip.NewReader(bytes.NewReader(ccPackage.Code))
+	if err != nil {
+		t.Fatalf("error from gzip.NewReader %v", err)
+	}
+	tarReader := tar.NewReader(gzf)
+	var names []string
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("error from tarReader.Next() %v", err)
+		}
+		if header.Mode != 0100644 || !header.ModTime.Equal(time.Unix(0, 0)) {
+			t.Fatalf("unexpected mode %o or timestamp %v of %s", header.Mode, header.ModTime, header.Name)
+		}
+		names = append(names, header.Name)
+	}
+
+	expected := []string{"src/example.com/cc/a.go", "src/example.com/cc/a/a.go", "src/example.com/cc/b/b.go", "src/example.com/cc/main.go"}
+	if len(names) != len(expected) {
+		t.Fatalf("expected files %v, got %v", expected, names)
+	}
+	for i := range expected {
+		if names[i] != expected[i] {
+			t.Fatalf("expected files %v, got %v", expected, names)
+		}
+	}
+}
+
 // Test Package Go ChainCode
 func TestEmptyCreate(t *testing.T) {
 
@@ -12,7 +12,6 @@ import (
 	ab "github.com/hyperledger/fabric-sdk-go/internal/github.com/hyperledger/fabric/protos/orderer"
 	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
 
-	ccomm "github.com/hyperledger/fabric-sdk-go/pkg/core/config/comm"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/txn"
 	"github.com/pkg/errors"
 )
@@ -25,9 +24,15 @@ func (c *Channel) block(pos *ab.SeekPosition) (*common.Block, error) {
 		return nil, errors.Wrap(err, "generating TX ID failed")
 	}
 
+	orderers := c.Orderers()
+	tlsCertHash, err := txn.OrderersTLSCertHash(c.clientContext.Config(), orderers)
+	if err != nil {
+		return nil, err
+	}
+
 	channelHeaderOpts := txn.ChannelHeaderOpts{
 		TxnHeader:   th,
-		TLSCertHash: ccomm.TLSCertHash(c.clientContext.Config()),
+		TLSCertHash: tlsCertHash,
 	}
 	seekInfoHeader, err := txn.CreateChannelHeader(common.HeaderType_DELIVER_SEEK_INFO, channelHeaderOpts)
 	if err != nil {
@@ -70,7 +75,7 @@ func (c *Channel) block(pos *ab.SeekPosition) (*common.Block, error) {
 		Data:   seekInfoBytes,
 	}
 
-	return txn.SendPayload(c.clientContext, &payload, c.Orderers())
+	return txn.SendPayload(c.clientContext, &payload, orderers)
 }
 
 // newNewestSeekPosition returns a SeekPosition that requests the newest block
@@ -100,9 +100,9 @@ func New(ctx context.Context, cfg fab.ChannelCfg) (*Channel, error) {
 
 		var o *orderer.Orderer
 		if oCfg == nil {
-			o, err = orderer.New(ctx.Config(), orderer.WithURL(name), orderer.WithServerName(resolveOrdererAddress(name)))
+			o, err = orderer.New(ctx.Config(), orderer.WithURL(name), orderer.WithServerName(resolveOrdererAddress(name)), orderer.WithConnector(ctx.Connector()))
 		} else {
-			o, err = orderer.New(ctx.Config(), orderer.FromOrdererConfig(oCfg))
+			o, err = orderer.New(ctx.Config(), orderer.FromOrdererConfig(oCfg), orderer.WithConnector(ctx.Connector()))
 		}
 
 		if err != nil {
@@ -15,6 +15,7 @@ import (
 	"github.com/hyperledger/fabric-sdk-go/pkg/context"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
 	"github.com/hyperledger/fabric-sdk-go/pkg/errors/multi"
+	"github.com/hyperledger/fabric-sdk-go/pkg/errors/status"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/txn"
 	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
 	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
@@ -113,6 +114,30 @@ func (c *Ledger) QueryBlock(blockNumber int, targets []fab.ProposalProcessor) ([
 	return responses, errs
 }
 
+// QueryBlockByTxID queries the ledger for the Block that contains the given transaction.
+// This query will be made to specified targets.
+// Returns the block.
+func (c *Ledger) QueryBlockByTxID(transactionID fab.TransactionID, targets []fab.ProposalProcessor) ([]*common.Block, error) {
+
+	if transactionID == "" {
+		return nil, errors.New("transactionID is required")
+	}
+
+	cir := createBlockByTxIDInvokeRequest(c.chName, transactionID)
+	tprs, errs := queryChaincode(c.ctx, fab.SystemChannel, cir, targets)
+
+	responses := []*common.Block{}
+	for _, tpr := range tprs {
+		r, err := createCommonBlock(tpr)
+		if err != nil {
+			errs = multi.Append(errs, errors.WithMessage(err, "From target: "+tpr.Endorser))
+		} else {
+			responses = append(responses, r)
+		}
+	}
+	return responses, errs
+}
+
 func createCommonBlock(tpr *fab.TransactionProposalResponse) (*common.Block, error) {
 	response := common.Block{}
 	err := proto.Unmarshal(tpr.ProposalResponse.GetResponse().Payload, &response)
@@ -179,6 +204,59 @@ func createChaincodeQueryResponse(tpr *fab.TransactionProposalResponse) (*pb.Cha
 	return &response, nil
 }
 
+// QueryChaincodePolicy queries the endorsement policy of the given chaincode on this channel.
+// This query will be made to specified targets.
+func (c *Ledger) QueryChaincodePolicy(chaincodeID string, targets []fab.ProposalProcessor) ([]*common.SignaturePolicyEnvelope, error) {
+	cir := ChaincodeDataInvokeRequest(c.chName, chaincodeID)
+	tprs, errs := queryChaincode(c.ctx, c.chName, cir, targets)
+
+	responses := []*common.SignaturePolicyEnvelope{}
+	for _, tpr := range tprs {
+		r, err := createChaincodePolicy(tpr)
+		if err != nil {
+			errs = multi.Append(errs, errors.WithMessage(err, "From target: "+tpr.Endorser))
+		} else {
+			responses = append(responses, r)
+		}
+	}
+	return responses, errs
+}
+
+func createChaincodePolicy(tpr *fab.TransactionProposalResponse) (*common.SignaturePolicyEnvelope, error) {
+	ccData, err := UnmarshalChaincodeData(tpr.ProposalResponse.GetResponse().Payload)
+	if err != nil {
+		return nil, err
+	}
+	return UnmarshalChaincodePolicy(ccData)
+}
+
+// QueryCollectionsConfig queries the private data collections configuration of the given
+// chaincode on this channel. This query will be made to specified targets.
+func (c *Ledger) QueryCollectionsConfig(chaincodeID string, targets []fab.ProposalProcessor) ([]*common.CollectionConfigPackage, error) {
+	cir := createCollectionsConfigInvokeRequest(chaincodeID)
+	tprs, errs := queryChaincode(c.ctx, c.chName, cir, targets)
+
+	responses := []*common.CollectionConfigPackage{}
+	for _, tpr := range tprs {
+		r, err := createCollectionsConfig(tpr)
+		if err != nil {
+			errs = multi.Append(errs, errors.WithMessage(err, "From target: "+tpr.Endorser))
+		} else {
+			responses = append(responses, r)
+		}
+	}
+	return responses, errs
+}
+
+func createCollectionsConfig(tpr *fab.TransactionProposalResponse) (*common.CollectionConfigPackage, error) {
+	response := common.CollectionConfigPackage{}
+	err := proto.Unmarshal(tpr.ProposalResponse.GetResponse().Payload, &response)
+	if err != nil {
+		return nil, errors.Wrap(err, "unmarshal of collections config failed")
+	}
+	return &response, nil
+}
+
 // QueryConfigBlock returns the current configuration block for the specified channel. If the
 // peer doesn't belong to the channel, return error
 func (c *Ledger) QueryConfigBlock(targets []fab.ProposalProcessor, minResponses int) (*common.ConfigEnvelope, error) {
@@ -260,7 +338,8 @@ func filterResponses(responses []*fab.TransactionProposalResponse, errs error) (
 		if response.Status == http.StatusOK {
 			filteredResponses = append(filteredResponses, response)
 		} else {
-			errs = multi.Append(errs, errors.Errorf("bad status from %s (%d)", response.Endorser, response.Status))
+			s := status.New(status.EndorserServerStatus, response.Status, response.ProposalResponse.GetResponse().GetMessage(), []interface{}{response.Endorser})
+			errs = multi.Append(errs, errors.WithMessage(s, "bad status from "+response.Endorser))
 		}
 	}
 
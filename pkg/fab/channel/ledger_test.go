@@ -13,6 +13,7 @@ import (
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
 	"github.com/hyperledger/fabric-sdk-go/pkg/errors/multi"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/core/common/ccprovider"
 	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
 	"github.com/stretchr/testify/assert"
 )
@@ -51,6 +52,26 @@ func TestChannelQueryBlock(t *testing.T) {
 
 }
 
+func TestQueryBlockByTxID(t *testing.T) {
+	channel, _ := setupTestLedger()
+
+	block := &common.Block{Header: &common.BlockHeader{Number: 7, DataHash: []byte("hash")}}
+	payload, err := proto.Marshal(block)
+	if err != nil {
+		t.Fatalf("Failed to marshal block: %s", err)
+	}
+	peer := mocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", Status: 200, Payload: payload}
+
+	_, err = channel.QueryBlockByTxID("", []fab.ProposalProcessor{&peer})
+	assert.NotNil(t, err, "transaction ID is required")
+
+	res, err := channel.QueryBlockByTxID("txid", []fab.ProposalProcessor{&peer})
+	if err != nil || len(res) != 1 {
+		t.Fatalf("Test QueryBlockByTxID failed: %v", err)
+	}
+	assert.True(t, proto.Equal(block, res[0]), "Expecting the block of the transaction to be returned")
+}
+
 func TestQueryInstantiatedChaincodes(t *testing.T) {
 	channel, _ := setupTestLedger()
 	peer := mocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockRoles: []string{}, MockCert: nil, Status: 200}
@@ -63,6 +84,52 @@ func TestQueryInstantiatedChaincodes(t *testing.T) {
 
 }
 
+func TestQueryChaincodePolicy(t *testing.T) {
+	channel, _ := setupTestLedger()
+
+	policy := &common.SignaturePolicyEnvelope{Version: 1}
+	policyBytes, err := proto.Marshal(policy)
+	if err != nil {
+		t.Fatalf("Failed to marshal policy: %s", err)
+	}
+	ccData, err := proto.Marshal(&ccprovider.ChaincodeData{Name: "mycc", Policy: policyBytes})
+	if err != nil {
+		t.Fatalf("Failed to marshal chaincode data: %s", err)
+	}
+	peer := mocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", Status: 200, Payload: ccData}
+
+	res, err := channel.QueryChaincodePolicy("mycc", []fab.ProposalProcessor{&peer})
+	if err != nil || len(res) != 1 {
+		t.Fatalf("Test QueryChaincodePolicy failed: %v", err)
+	}
+	assert.True(t, proto.Equal(policy, res[0]), "Expecting the chaincode policy to be returned")
+
+	peer.Payload = []byte("invalid")
+	_, err = channel.QueryChaincodePolicy("mycc", []fab.ProposalProcessor{&peer})
+	assert.NotNil(t, err, "Expecting error for invalid chaincode data")
+}
+
+func TestQueryCollectionsConfig(t *testing.T) {
+	channel, _ := setupTestLedger()
+
+	collConfig := &common.CollectionConfigPackage{
+		Config: []*common.CollectionConfig{
+			{Payload: &common.CollectionConfig_StaticCollectionConfig{StaticCollectionConfig: &common.StaticCollectionConfig{Name: "coll1"}}},
+		},
+	}
+	payload, err := proto.Marshal(collConfig)
+	if err != nil {
+		t.Fatalf("Failed to marshal collections config: %s", err)
+	}
+	peer := mocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", Status: 200, Payload: payload}
+
+	res, err := channel.QueryCollectionsConfig("mycc", []fab.ProposalProcessor{&peer})
+	if err != nil || len(res) != 1 {
+		t.Fatalf("Test QueryCollectionsConfig failed: %v", err)
+	}
+	assert.True(t, proto.Equal(collConfig, res[0]), "Expecting the collections config to be returned")
+}
+
 func TestQueryTransaction(t *testing.T) {
 	channel, _ := setupTestLedger()
 	peer := mocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockRoles: []string{}, MockCert: nil, Status: 200}
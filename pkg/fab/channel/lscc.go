@@ -12,6 +12,7 @@ import (
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/txn"
 	"github.com/pkg/errors"
 
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/core/common/ccprovider"
 	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
 	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
 	protos_utils "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/utils"
@@ -22,6 +23,8 @@ const (
 	lsccDeploy     = "deploy"
 	lsccUpgrade    = "upgrade"
 	lsccChaincodes = "getchaincodes"
+	lsccCCData     = "getccdata"
+	lsccCollConfig = "GetCollectionsConfig"
 	escc           = "escc"
 	vscc           = "vscc"
 )
@@ -87,7 +90,7 @@ func CreateChaincodeDeployProposal(txh fab.TransactionHeader, deploy ChaincodePr
 	case UpgradeChaincode:
 		fcn = lsccUpgrade
 	default:
-		return nil, errors.WithMessage(err, "chaincode deployment type unknown")
+		return nil, errors.New("chaincode deployment type unknown")
 	}
 
 	cir := fab.ChaincodeInvokeRequest{
@@ -106,3 +109,42 @@ func createChaincodesInvokeRequest() fab.ChaincodeInvokeRequest {
 	}
 	return cir
 }
+
+// ChaincodeDataInvokeRequest returns the LSCC request that retrieves the chaincode data
+// (which includes the endorsement policy) of the given chaincode on the given channel.
+// The response payload may be unmarshalled with UnmarshalChaincodeData.
+func ChaincodeDataInvokeRequest(channelID string, chaincodeID string) fab.ChaincodeInvokeRequest {
+	cir := fab.ChaincodeInvokeRequest{
+		ChaincodeID: lscc,
+		Fcn:         lsccCCData,
+		Args:        [][]byte{[]byte(channelID), []byte(chaincodeID)},
+	}
+	return cir
+}
+
+// UnmarshalChaincodeData unmarshals the chaincode data returned by the LSCC getccdata function
+func UnmarshalChaincodeData(payload []byte) (*ccprovider.ChaincodeData, error) {
+	ccData := &ccprovider.ChaincodeData{}
+	if err := proto.Unmarshal(payload, ccData); err != nil {
+		return nil, errors.Wrap(err, "unmarshal of chaincode data failed")
+	}
+	return ccData, nil
+}
+
+// UnmarshalChaincodePolicy unmarshals the endorsement policy contained in the given chaincode data
+func UnmarshalChaincodePolicy(ccData *ccprovider.ChaincodeData) (*common.SignaturePolicyEnvelope, error) {
+	policy := &common.SignaturePolicyEnvelope{}
+	if err := proto.Unmarshal(ccData.Policy, policy); err != nil {
+		return nil, errors.Wrap(err, "unmarshal of chaincode policy failed")
+	}
+	return policy, nil
+}
+
+func createCollectionsConfigInvokeRequest(chaincodeID string) fab.ChaincodeInvokeRequest {
+	cir := fab.ChaincodeInvokeRequest{
+		ChaincodeID: lscc,
+		Fcn:         lsccCollConfig,
+		Args:        [][]byte{[]byte(chaincodeID)},
+	}
+	return cir
+}
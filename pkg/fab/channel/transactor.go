@@ -22,7 +22,7 @@ import (
 type Transactor struct {
 	ctx       context.Context
 	ChannelID string
-	orderers  []fab.Orderer
+	orderers  *orderer.Selection
 }
 
 // NewTransactor returns a Transactor for the current context and channel config.
@@ -39,7 +39,7 @@ func NewTransactor(ctx context.Context, cfg fab.ChannelCfg) (*Transactor, error)
 	t := Transactor{
 		ctx:       ctx,
 		ChannelID: cfg.Name(),
-		orderers:  orderers,
+		orderers:  orderer.NewSelection(orderers),
 	}
 	return &t, nil
 }
@@ -56,10 +56,20 @@ func orderersFromChannelCfg(ctx context.Context, cfg fab.ChannelCfg) ([]fab.Orde
 
 		// Figure out orderer configuration
 		oCfg, ok := ordererDict[target]
+		if !ok {
+			// The orderer entity matchers (if any) map the target to a configuration
+			matched, err := ctx.Config().OrdererConfig(target)
+			if err != nil {
+				return nil, errors.WithMessage(err, "loading orderer config failed")
+			}
+			if matched != nil {
+				oCfg, ok = *matched, true
+			}
+		}
 
 		if !ok {
 			// TODO: need default options
-			o, err := orderer.New(ctx.Config(), orderer.WithURL(target))
+			o, err := orderer.New(ctx.Config(), orderer.WithURL(target), orderer.WithConnector(ctx.Connector()))
 			// TODO: should we fail hard if we cannot configure a default orderer?
 			//if err != nil {
 			//	return nil, errors.WithMessage(err, "failed to create orderer from defaults")
@@ -68,7 +78,7 @@ func orderersFromChannelCfg(ctx context.Context, cfg fab.ChannelCfg) ([]fab.Orde
 				orderers = append(orderers, o)
 			}
 		} else {
-			o, err := orderer.New(ctx.Config(), orderer.FromOrdererConfig(&oCfg))
+			o, err := orderer.New(ctx.Config(), orderer.FromOrdererConfig(&oCfg), orderer.WithConnector(ctx.Connector()))
 			if err != nil {
 				return nil, errors.WithMessage(err, "failed to create orderer from config")
 			}
@@ -115,6 +125,20 @@ func (t *Transactor) CreateTransaction(request fab.TransactionRequest) (*fab.Tra
 }
 
 // SendTransaction send a transaction to the chain’s orderer service (one or more orderer endpoints) for consensus and committing to the ledger.
+// The orderers of the channel are tried in turn, failing over to the next orderer if an orderer is unavailable.
 func (t *Transactor) SendTransaction(tx *fab.Transaction) (*fab.TransactionResponse, error) {
-	return txn.Send(t.ctx, tx, t.orderers)
+	if len(t.orderers.Orderers()) == 0 {
+		return nil, errors.New("orderers is nil")
+	}
+
+	envelope, err := txn.SignTransaction(t.ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	return t.orderers.Broadcast(envelope)
+}
+
+// SignTransaction creates the signed envelope of a transaction without sending it to the orderer.
+func (t *Transactor) SignTransaction(tx *fab.Transaction) (*fab.SignedEnvelope, error) {
+	return txn.SignTransaction(t.ctx, tx)
 }
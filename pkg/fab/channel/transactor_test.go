@@ -11,6 +11,7 @@ import (
 
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/orderer"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/txn"
 	"github.com/stretchr/testify/assert"
 )
@@ -58,11 +59,11 @@ func TestTransactionBadStatus(t *testing.T) {
 func createTransactor(t *testing.T) *Transactor {
 	user := mocks.NewMockUser("test")
 	ctx := mocks.NewMockContext(user)
-	orderer := mocks.NewMockOrderer("", nil)
+	o := mocks.NewMockOrderer("", nil)
 	chConfig := mocks.NewMockChannelCfg("testChannel")
 
 	transactor, err := NewTransactor(ctx, chConfig)
-	transactor.orderers = []fab.Orderer{orderer}
+	transactor.orderers = orderer.NewSelection([]fab.Orderer{o})
 	assert.Nil(t, err)
 
 	return transactor
@@ -7,6 +7,8 @@ SPDX-License-Identifier: Apache-2.0
 package chconfig
 
 import (
+	"sort"
+
 	"github.com/golang/protobuf/proto"
 
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
@@ -31,6 +33,13 @@ const (
 	defaultMinResponses = 1
 )
 
+// knownChannelGroups are the groups of the channel group that are parsed. Other groups are skipped.
+var knownChannelGroups = map[string]bool{
+	channelConfig.OrdererGroupKey: true,
+	"Application":                 true,
+	"Consortiums":                 true,
+}
+
 // Opts contains options for retrieving channel configuration
 type Opts struct {
 	Orderer      string     // if configured, channel config will be retrieved from this orderer
@@ -56,11 +65,16 @@ type ChannelConfig struct {
 
 // ChannelCfg contains channel configuration
 type ChannelCfg struct {
-	name        string
-	msps        []*msp.MSPConfig
-	anchorPeers []*fab.OrgAnchorPeer
-	orderers    []string
-	versions    *fab.Versions
+	name           string
+	msps           []*msp.MSPConfig
+	mspIDs         []string
+	anchorPeers    []*fab.OrgAnchorPeer
+	orderers       []string
+	batchSize      *ab.BatchSize
+	consortium     string
+	versions       *fab.Versions
+	sequence       uint64
+	configEnvelope *common.ConfigEnvelope
 }
 
 // NewChannelCfg creates channel cfg
@@ -79,6 +93,11 @@ func (cfg *ChannelCfg) Msps() []*msp.MSPConfig {
 	return cfg.msps
 }
 
+// MSPIDs returns the IDs of the msps
+func (cfg *ChannelCfg) MSPIDs() []string {
+	return cfg.mspIDs
+}
+
 // AnchorPeers returns anchor peers
 func (cfg *ChannelCfg) AnchorPeers() []*fab.OrgAnchorPeer {
 	return cfg.anchorPeers
@@ -89,11 +108,36 @@ func (cfg *ChannelCfg) Orderers() []string {
 	return cfg.orderers
 }
 
+// BatchSize returns the batch size of the orderer
+func (cfg *ChannelCfg) BatchSize() *ab.BatchSize {
+	return cfg.batchSize
+}
+
+// Consortium returns the name of the consortium of the channel
+func (cfg *ChannelCfg) Consortium() string {
+	return cfg.consortium
+}
+
 // Versions returns versions
 func (cfg *ChannelCfg) Versions() *fab.Versions {
 	return cfg.versions
 }
 
+// ConfigEnvelope returns the config envelope that the configuration was parsed from
+func (cfg *ChannelCfg) ConfigEnvelope() *common.ConfigEnvelope {
+	return cfg.configEnvelope
+}
+
+// FormatVersion returns the version of the format of the parsed configuration
+func (cfg *ChannelCfg) FormatVersion() int {
+	return fab.ChannelCfgFormatVersion
+}
+
+// Sequence returns the sequence number of the channel configuration
+func (cfg *ChannelCfg) Sequence() uint64 {
+	return cfg.sequence
+}
+
 // New channel config implementation
 func New(ctx context.Context, channelID string, options ...Option) (*ChannelConfig, error) {
 	opts, err := prepareOpts(options...)
@@ -131,7 +175,7 @@ func (c *ChannelConfig) queryPeers() (*ChannelCfg, error) {
 		}
 
 		for _, p := range chPeers {
-			newPeer, err := peer.New(c.ctx.Config(), peer.FromPeerConfig(&p.NetworkPeer))
+			newPeer, err := peer.New(c.ctx.Config(), peer.FromPeerConfig(&p.NetworkPeer), peer.WithConnector(c.ctx.Connector()))
 			if err != nil || newPeer == nil {
 				return nil, errors.WithMessage(err, "NewPeer failed")
 			}
@@ -207,8 +251,21 @@ func prepareOpts(options ...Option) (Opts, error) {
 	return opts, nil
 }
 
+// FromConfigEnvelope parses the channel configuration of the given config envelope
+func FromConfigEnvelope(channelID string, configEnvelope *common.ConfigEnvelope) (fab.ChannelCfg, error) {
+	config, err := extractConfig(channelID, configEnvelope)
+	if err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
 func extractConfig(channel string, configEnvelope *common.ConfigEnvelope) (*ChannelCfg, error) {
 
+	if configEnvelope == nil || configEnvelope.Config == nil {
+		return nil, errors.New("config envelope doesn't contain a config")
+	}
+
 	group := configEnvelope.Config.ChannelGroup
 
 	versions := &fab.Versions{
@@ -216,17 +273,21 @@ func extractConfig(channel string, configEnvelope *common.ConfigEnvelope) (*Chan
 	}
 
 	config := &ChannelCfg{
-		name:        channel,
-		msps:        []*msp.MSPConfig{},
-		anchorPeers: []*fab.OrgAnchorPeer{},
-		orderers:    []string{},
-		versions:    versions,
+		name:           channel,
+		msps:           []*msp.MSPConfig{},
+		mspIDs:         []string{},
+		anchorPeers:    []*fab.OrgAnchorPeer{},
+		orderers:       []string{},
+		versions:       versions,
+		sequence:       configEnvelope.Config.Sequence,
+		configEnvelope: configEnvelope,
 	}
 
 	err := loadConfig(config, config.versions.Channel, group, "base", "", true)
 	if err != nil {
 		return nil, errors.WithMessage(err, "load config items from config group failed")
 	}
+	sort.Strings(config.mspIDs)
 
 	logger.Debugf("channel config: %v", config)
 
@@ -248,10 +309,17 @@ func loadConfig(configItems *ChannelCfg, versionsGroup *common.ConfigGroup, grou
 	if groups != nil {
 		versionsGroup.Groups = make(map[string]*common.ConfigGroup)
 		for key, configGroup := range groups {
+			if top && !knownChannelGroups[key] {
+				logger.Debugf("loadConfigGroup - %s - skipping unknown config group ==> %s", name, key)
+				continue
+			}
 			logger.Debugf("loadConfigGroup - %s - found config group ==> %s", name, key)
 			// The Application group is where config settings are that we want to find
 			versionsGroup.Groups[key] = &common.ConfigGroup{}
-			loadConfig(configItems, versionsGroup.Groups[key], configGroup, name+"."+key, key, false)
+			err := loadConfig(configItems, versionsGroup.Groups[key], configGroup, name+"."+key, key, false)
+			if err != nil {
+				return err
+			}
 		}
 	} else {
 		logger.Debugf("loadConfigGroup - %s - no groups", name)
@@ -264,7 +332,10 @@ func loadConfig(configItems *ChannelCfg, versionsGroup *common.ConfigGroup, grou
 		versionsGroup.Values = make(map[string]*common.ConfigValue)
 		for key, configValue := range values {
 			versionsGroup.Values[key] = &common.ConfigValue{}
-			loadConfigValue(configItems, key, versionsGroup.Values[key], configValue, name, org)
+			err := loadConfigValue(configItems, key, versionsGroup.Values[key], configValue, name, org)
+			if err != nil {
+				return err
+			}
 		}
 	} else {
 		logger.Debugf("loadConfigGroup - %s - no values", name)
@@ -277,7 +348,10 @@ func loadConfig(configItems *ChannelCfg, versionsGroup *common.ConfigGroup, grou
 		versionsGroup.Policies = make(map[string]*common.ConfigPolicy)
 		for key, configPolicy := range policies {
 			versionsGroup.Policies[key] = &common.ConfigPolicy{}
-			loadConfigPolicy(configItems, key, versionsGroup.Policies[key], configPolicy, name, org)
+			err := loadConfigPolicy(configItems, key, versionsGroup.Policies[key], configPolicy, name, org)
+			if err != nil {
+				return err
+			}
 		}
 	} else {
 		logger.Debugf("loadConfigGroup - %s - no policies", name)
@@ -327,7 +401,7 @@ func loadPolicy(configItems *ChannelCfg, versionsPolicy *common.ConfigPolicy, ke
 		break
 
 	default:
-		return errors.Errorf("unknown policy type %v", policyType)
+		logger.Debugf("loadConfigPolicy - %s - skipping policy of unknown type %v", groupName, policyType)
 	}
 	return nil
 }
@@ -369,10 +443,18 @@ func loadConfigValue(configItems *ChannelCfg, key string, versionsValue *common.
 
 		mspType := imsp.ProviderType(mspConfig.Type)
 		if mspType != imsp.FABRIC {
-			return errors.Errorf("unsupported MSP type (%v)", mspType)
+			logger.Debugf("loadConfigValue - %s   - skipping MSP of unsupported type (%v)", groupName, mspType)
+			break
+		}
+
+		fabricMSPConfig := &msp.FabricMSPConfig{}
+		err = proto.Unmarshal(mspConfig.Config, fabricMSPConfig)
+		if err != nil {
+			return errors.Wrap(err, "unmarshal FabricMSPConfig from config failed")
 		}
 
 		configItems.msps = append(configItems.msps, mspConfig)
+		configItems.mspIDs = append(configItems.mspIDs, fabricMSPConfig.Name)
 		break
 
 	case channelConfig.ConsensusTypeKey:
@@ -396,7 +478,9 @@ func loadConfigValue(configItems *ChannelCfg, key string, versionsValue *common.
 		logger.Debugf("loadConfigValue - %s   - BatchSize  maxMessageCount :: %d", groupName, batchSize.MaxMessageCount)
 		logger.Debugf("loadConfigValue - %s   - BatchSize  absoluteMaxBytes :: %d", groupName, batchSize.AbsoluteMaxBytes)
 		logger.Debugf("loadConfigValue - %s   - BatchSize  preferredMaxBytes :: %d", groupName, batchSize.PreferredMaxBytes)
-		// TODO: Do something with this value
+		if org == channelConfig.OrdererGroupKey {
+			configItems.batchSize = batchSize
+		}
 		break
 
 	case channelConfig.BatchTimeoutKey:
@@ -436,7 +520,7 @@ func loadConfigValue(configItems *ChannelCfg, key string, versionsValue *common.
 			return errors.Wrap(err, "unmarshal consortium from config failed")
 		}
 		logger.Debugf("loadConfigValue - %s   - Consortium names value :: %s", groupName, consortium.Name)
-		// TODO: Do something with this value
+		configItems.consortium = consortium.Name
 		break
 
 	case channelConfig.BlockDataHashingStructureKey:
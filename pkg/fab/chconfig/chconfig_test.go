@@ -6,17 +6,24 @@ SPDX-License-Identifier: Apache-2.0
 package chconfig
 
 import (
+	"io/ioutil"
 	"testing"
 
 	"github.com/golang/protobuf/proto"
+	channelConfig "github.com/hyperledger/fabric-sdk-go/internal/github.com/hyperledger/fabric/common/channelconfig"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/channel"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+	ab "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/orderer"
+	"github.com/stretchr/testify/assert"
 )
 
 const (
-	channelID = "testChannel"
+	channelID          = "testChannel"
+	systemChannelID    = "testchainid"
+	fixtureConfigBlock = "../../../test/fixtures/fabric/v1.1.0-alpha/channel/twoorgs.genesis.block"
 )
 
 func TestChannelConfigWithPeer(t *testing.T) {
@@ -37,6 +44,12 @@ func TestChannelConfigWithPeer(t *testing.T) {
 	if cfg.Name() != channelID {
 		t.Fatalf("Channel name error. Expecting %s, got %s ", channelID, cfg.Name())
 	}
+
+	if cfg.(*ChannelCfg).Sequence() != 3 {
+		t.Fatalf("Channel config sequence error. Expecting 3, got %d", cfg.(*ChannelCfg).Sequence())
+	}
+
+	assert.Equal(t, []string{"OrdererMSP", "Org1MSP", "Org2MSP"}, cfg.MSPIDs())
 }
 
 func TestChannelConfigWithPeerError(t *testing.T) {
@@ -98,6 +111,7 @@ func getPeerWithConfigBlockPayload(t *testing.T) fab.Peer {
 		},
 		Index:           0,
 		LastConfigIndex: 0,
+		Sequence:        3,
 	}
 
 	payload, err := proto.Marshal(builder.Build())
@@ -127,3 +141,97 @@ EDAKBggqhkjOPQQDAgNHADBEAiAHp5Rbp9Em1G/UmKn8WsCbqDfWecVbZPQj3RK4
 oG5kQQIgQAe4OOKYhJdh3f7URaKfGTf492/nmRmtK+ySKjpHSrU=
 -----END CERTIFICATE-----
 `
+
+func TestChannelConfigFromFixture(t *testing.T) {
+
+	ctx := setupTestContext()
+	peer := getPeerWithFixtureConfigBlock(t)
+
+	channelConfig, err := New(ctx, systemChannelID, WithPeers([]fab.Peer{peer}))
+	if err != nil {
+		t.Fatalf("Failed to create new channel client: %s", err)
+	}
+
+	cfg, err := channelConfig.Query()
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	assert.Equal(t, systemChannelID, cfg.Name())
+	assert.Equal(t, []string{"OrdererMSP", "Org1MSP", "Org2MSP"}, cfg.MSPIDs())
+	assert.Len(t, cfg.Msps(), 3)
+	assert.Equal(t, []string{"orderer.example.com:7050"}, cfg.Orderers())
+	assert.Equal(t, &ab.BatchSize{MaxMessageCount: 10, AbsoluteMaxBytes: 102760448, PreferredMaxBytes: 524288}, cfg.BatchSize())
+	assert.Empty(t, cfg.Consortium(), "expected no consortium for the orderer system channel")
+	assert.Equal(t, fab.ChannelCfgFormatVersion, cfg.FormatVersion())
+	assert.NotNil(t, cfg.ConfigEnvelope().Config.ChannelGroup.Groups["Consortiums"], "expected the raw config envelope")
+}
+
+func TestChannelConfigUnknownGroup(t *testing.T) {
+
+	configEnvelope := fixtureConfigEnvelope(t)
+	group := configEnvelope.Config.ChannelGroup
+	group.Groups["Unknown"] = &common.ConfigGroup{
+		Values: map[string]*common.ConfigValue{
+			channelConfig.BatchSizeKey: {Value: []byte("not a batch size")},
+		},
+	}
+	group.Values[channelConfig.ConsortiumKey] = &common.ConfigValue{Value: marshal(t, &common.Consortium{Name: "SampleConsortium"})}
+
+	cfg, err := FromConfigEnvelope(channelID, configEnvelope)
+	if err != nil {
+		t.Fatalf("Expected unknown config group to be skipped: %s", err)
+	}
+	assert.Equal(t, channelID, cfg.Name())
+	assert.Equal(t, "SampleConsortium", cfg.Consortium())
+	assert.Equal(t, uint32(10), cfg.BatchSize().MaxMessageCount)
+	_, ok := cfg.Versions().Channel.Groups["Unknown"]
+	assert.False(t, ok, "expected unknown config group to be skipped")
+
+	// Known values must be valid
+	group.Groups[channelConfig.OrdererGroupKey].Values[channelConfig.BatchSizeKey] = &common.ConfigValue{Value: []byte("not a batch size")}
+	_, err = FromConfigEnvelope(channelID, configEnvelope)
+	assert.NotNil(t, err, "expected an error for an invalid batch size")
+
+	_, err = FromConfigEnvelope(channelID, &common.ConfigEnvelope{})
+	assert.NotNil(t, err, "expected an error for a config envelope without a config")
+}
+
+func getPeerWithFixtureConfigBlock(t *testing.T) fab.Peer {
+	payload, err := ioutil.ReadFile(fixtureConfigBlock)
+	if err != nil {
+		t.Fatalf("Failed to read fixture config block: %s", err)
+	}
+	return &mocks.MockPeer{MockName: "Peer1", MockURL: "http://peer1.com", MockRoles: []string{}, MockCert: nil, Payload: payload, Status: 200}
+}
+
+func fixtureConfigEnvelope(t *testing.T) *common.ConfigEnvelope {
+	blockBytes, err := ioutil.ReadFile(fixtureConfigBlock)
+	if err != nil {
+		t.Fatalf("Failed to read fixture config block: %s", err)
+	}
+
+	block := &common.Block{}
+	unmarshal(t, blockBytes, block)
+	envelope := &common.Envelope{}
+	unmarshal(t, block.Data.Data[0], envelope)
+	payload := &common.Payload{}
+	unmarshal(t, envelope.Payload, payload)
+	configEnvelope := &common.ConfigEnvelope{}
+	unmarshal(t, payload.Data, configEnvelope)
+	return configEnvelope
+}
+
+func unmarshal(t *testing.T, data []byte, message proto.Message) {
+	if err := proto.Unmarshal(data, message); err != nil {
+		t.Fatalf("Failed to unmarshal %T: %s", message, err)
+	}
+}
+
+func marshal(t *testing.T, message proto.Message) []byte {
+	data, err := proto.Marshal(message)
+	if err != nil {
+		t.Fatalf("Failed to marshal %T: %s", message, err)
+	}
+	return data
+}
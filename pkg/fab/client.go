@@ -19,6 +19,7 @@ import (
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
 	channel "github.com/hyperledger/fabric-sdk-go/pkg/fab/channel"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/chconfig"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/comm"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/resource"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/resource/api"
 	"github.com/hyperledger/fabric-sdk-go/pkg/logging"
@@ -133,6 +134,11 @@ func (c *Client) SetSigningManager(signingMgr contextApi.SigningManager) {
 	c.signingManager = signingMgr
 }
 
+// Connector returns a connector that dials a new connection for each client
+func (c *Client) Connector() contextApi.Connector {
+	return &comm.DirectConnector{}
+}
+
 // SaveUserToStateStore ...
 /*
  * Sets an instance of the User class as the security context of this client instance. This user’s credentials (ECert) will be
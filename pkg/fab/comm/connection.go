@@ -8,18 +8,20 @@ package comm
 
 import (
 	"context"
+	"crypto/tls"
 	"sync/atomic"
 
 	"github.com/pkg/errors"
 
 	fabcontext "github.com/hyperledger/fabric-sdk-go/pkg/context"
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
 	"github.com/hyperledger/fabric-sdk-go/pkg/core/config/comm"
 	"github.com/hyperledger/fabric-sdk-go/pkg/core/config/urlutil"
 	"github.com/hyperledger/fabric-sdk-go/pkg/logging"
 	"github.com/hyperledger/fabric-sdk-go/pkg/options"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/connectivity"
 )
 
 var logger = logging.NewLogger("fabric_sdk_go")
@@ -31,6 +33,7 @@ type StreamProvider func(conn *grpc.ClientConn) (grpc.ClientStream, error)
 type GRPCConnection struct {
 	channelID   string
 	conn        *grpc.ClientConn
+	connector   api.Connector
 	stream      grpc.ClientStream
 	context     fabcontext.Context
 	tlsCertHash []byte
@@ -46,38 +49,43 @@ func NewConnection(ctx fabcontext.Context, channelID string, streamProvider Stre
 	params := defaultParams()
 	options.Apply(params, opts)
 
-	dialOpts, err := newDialOpts(ctx.Config(), url, params)
+	dialOpts, tlsConfig, tlsCertHash, err := newDialOpts(ctx.Config(), url, params)
 	if err != nil {
 		return nil, err
 	}
 
-	grpcctx := context.Background()
+	connector := ctx.Connector()
+	if connector == nil {
+		connector = &DirectConnector{}
+	}
+
+	grpcctx := WithDialKey(context.Background(), params.dialSettings().Key())
 	grpcctx, cancel := context.WithTimeout(grpcctx, params.connectTimeout)
 	defer cancel()
 
-	grpcconn, err := grpc.DialContext(grpcctx, urlutil.ToAddress(url), dialOpts...)
+	grpcconn, err := connector.DialContext(grpcctx, urlutil.ToAddress(url), tlsConfig, dialOpts...)
 	if err != nil {
 		return nil, errors.Wrapf(err, "could not connect to %s", url)
 	}
 
 	stream, err := streamProvider(grpcconn)
 	if err != nil {
-		if err := grpcconn.Close(); err != nil {
-			logger.Warnf("error closing GRPC connection: %s", err)
-		}
+		connector.ReleaseConn(grpcconn)
 		return nil, errors.Wrapf(err, "could not create stream to %s", url)
 	}
 
 	if stream == nil {
+		connector.ReleaseConn(grpcconn)
 		return nil, errors.New("unexpected nil stream received from provider")
 	}
 
 	return &GRPCConnection{
 		channelID:   channelID,
 		conn:        grpcconn,
+		connector:   connector,
 		stream:      stream,
 		context:     ctx,
-		tlsCertHash: comm.TLSCertHash(ctx.Config()),
+		tlsCertHash: tlsCertHash,
 	}, nil
 }
 
@@ -98,10 +106,8 @@ func (c *GRPCConnection) Close() {
 		logger.Warnf("error closing GRPC stream: %s", err)
 	}
 
-	logger.Debugf("Closing connection....")
-	if err := c.conn.Close(); err != nil {
-		logger.Warnf("error closing GRPC connection: %s", err)
-	}
+	logger.Debugf("Releasing connection....")
+	c.connector.ReleaseConn(c.conn)
 }
 
 // Closed returns true if the connection has been closed
@@ -113,12 +119,31 @@ func (c *GRPCConnection) setClosed() bool {
 	return atomic.CompareAndSwapInt32(&c.done, 0, 1)
 }
 
+// CheckHealth returns an error if the connection has been closed, the stream has terminated
+// or the underlying GRPC connection is failing or shut down
+func (c *GRPCConnection) CheckHealth() error {
+	if c.Closed() {
+		return errors.New("connection is closed")
+	}
+
+	if err := c.stream.Context().Err(); err != nil {
+		return errors.Wrap(err, "stream has terminated")
+	}
+
+	if state := c.conn.GetState(); state == connectivity.TransientFailure || state == connectivity.Shutdown {
+		return errors.Errorf("GRPC connection is in state [%s]", state)
+	}
+
+	return nil
+}
+
 // Stream returns the GRPC stream
 func (c *GRPCConnection) Stream() grpc.Stream {
 	return c.stream
 }
 
-// TLSCertHash returns the hash of the TLS cert
+// TLSCertHash returns the hash of the client TLS cert presented on this connection
+// (nil if the connection is insecure or no client cert is used)
 func (c *GRPCConnection) TLSCertHash() []byte {
 	return c.tlsCertHash
 }
@@ -128,26 +153,26 @@ func (c *GRPCConnection) Context() fabcontext.Context {
 	return c.context
 }
 
-func newDialOpts(config core.Config, url string, params *params) ([]grpc.DialOption, error) {
-	var dialOpts []grpc.DialOption
+// newDialOpts returns the dial options and the TLS config of the connection (nil if the connection is insecure)
+func newDialOpts(config core.Config, url string, params *params) ([]grpc.DialOption, *tls.Config, []byte, error) {
+	var tlsConfig *tls.Config
+	var tlsCertHash []byte
 
-	if params.keepAliveParams.Time > 0 || params.keepAliveParams.Timeout > 0 {
-		dialOpts = append(dialOpts, grpc.WithKeepaliveParams(params.keepAliveParams))
+	dialOpts, err := params.dialSettings().DialOptions(url)
+	if err != nil {
+		return nil, nil, nil, err
 	}
 
-	dialOpts = append(dialOpts, grpc.WithDefaultCallOptions(grpc.FailFast(params.failFast)))
-
 	if urlutil.IsTLSEnabled(url) {
-		tlsConfig, err := comm.TLSConfig(params.certificate, params.hostOverride, config)
+		tlsConfig, err = comm.TLSConfig(params.certificate, params.hostOverride, config)
 		if err != nil {
-			return nil, err
+			return nil, nil, nil, err
 		}
-		dialOpts = append(dialOpts, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+		tlsCertHash = comm.TLSCertHashFromTLSConfig(tlsConfig)
 		logger.Debugf("Creating a secure connection to [%s] with TLS HostOverride [%s]", url, params.hostOverride)
 	} else {
 		logger.Debugf("Creating an insecure connection [%s]", url)
-		dialOpts = append(dialOpts, grpc.WithInsecure())
 	}
 
-	return dialOpts, nil
+	return dialOpts, tlsConfig, tlsCertHash, nil
 }
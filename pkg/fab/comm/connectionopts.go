@@ -20,6 +20,9 @@ type params struct {
 	keepAliveParams keepalive.ClientParameters
 	failFast        bool
 	connectTimeout  time.Duration
+	maxRecvMsgSize  int
+	maxSendMsgSize  int
+	proxyURL        string
 }
 
 func defaultParams() *params {
@@ -29,6 +32,17 @@ func defaultParams() *params {
 	}
 }
 
+// dialSettings returns the settings of the dial options of the connection
+func (p *params) dialSettings() DialSettings {
+	return DialSettings{
+		KeepAlive:      p.keepAliveParams,
+		FailFast:       p.failFast,
+		MaxRecvMsgSize: p.maxRecvMsgSize,
+		MaxSendMsgSize: p.maxSendMsgSize,
+		ProxyURL:       p.proxyURL,
+	}
+}
+
 // WithHostOverride sets the host name that will be used to resolve the TLS certificate
 func WithHostOverride(value string) options.Opt {
 	return func(p options.Params) {
@@ -74,6 +88,36 @@ func WithConnectTimeout(value time.Duration) options.Opt {
 	}
 }
 
+// WithMaxRecvMsgSize sets the maximum size (in bytes) of the messages that may be received
+// over the connection (the gRPC default applies if not set)
+func WithMaxRecvMsgSize(value int) options.Opt {
+	return func(p options.Params) {
+		if setter, ok := p.(maxRecvMsgSizeSetter); ok {
+			setter.SetMaxRecvMsgSize(value)
+		}
+	}
+}
+
+// WithMaxSendMsgSize sets the maximum size (in bytes) of the messages that may be sent
+// over the connection (the gRPC default applies if not set)
+func WithMaxSendMsgSize(value int) options.Opt {
+	return func(p options.Params) {
+		if setter, ok := p.(maxSendMsgSizeSetter); ok {
+			setter.SetMaxSendMsgSize(value)
+		}
+	}
+}
+
+// WithProxyURL sets the URL of the proxy through which the server is dialed
+// (the proxy of the environment is used if not set)
+func WithProxyURL(value string) options.Opt {
+	return func(p options.Params) {
+		if setter, ok := p.(proxyURLSetter); ok {
+			setter.SetProxyURL(value)
+		}
+	}
+}
+
 func (p *params) SetHostOverride(value string) {
 	logger.Debugf("HostOverride: %s", value)
 	p.hostOverride = value
@@ -99,6 +143,21 @@ func (p *params) SetConnectTimeout(value time.Duration) {
 	p.connectTimeout = value
 }
 
+func (p *params) SetMaxRecvMsgSize(value int) {
+	logger.Debugf("MaxRecvMsgSize: %d", value)
+	p.maxRecvMsgSize = value
+}
+
+func (p *params) SetMaxSendMsgSize(value int) {
+	logger.Debugf("MaxSendMsgSize: %d", value)
+	p.maxSendMsgSize = value
+}
+
+func (p *params) SetProxyURL(value string) {
+	logger.Debugf("ProxyURL: %s", value)
+	p.proxyURL = value
+}
+
 type hostOverrideSetter interface {
 	SetHostOverride(value string)
 }
@@ -118,3 +177,15 @@ type failFastSetter interface {
 type connectTimeoutSetter interface {
 	SetConnectTimeout(value time.Duration)
 }
+
+type maxRecvMsgSizeSetter interface {
+	SetMaxRecvMsgSize(value int)
+}
+
+type maxSendMsgSizeSetter interface {
+	SetMaxSendMsgSize(value int)
+}
+
+type proxyURLSetter interface {
+	SetProxyURL(value string)
+}
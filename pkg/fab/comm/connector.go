@@ -0,0 +1,354 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package comm
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config/urlutil"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
+)
+
+// DialSettings are the settings of the dial options of a connection, other than its TLS config
+type DialSettings struct {
+	KeepAlive      keepalive.ClientParameters
+	FailFast       bool
+	MaxRecvMsgSize int
+	MaxSendMsgSize int
+	ProxyURL       string
+	Block          bool
+}
+
+// Key returns a key that identifies the settings (see WithDialKey)
+func (s DialSettings) Key() string {
+	return fmt.Sprintf("%+v", s)
+}
+
+// DialOptions returns the gRPC dial options of a connection to the given target, other than its transport
+// credentials (see Connector.DialContext)
+func (s DialSettings) DialOptions(target string) ([]grpc.DialOption, error) {
+	var opts []grpc.DialOption
+	if s.KeepAlive.Time > 0 || s.KeepAlive.Timeout > 0 {
+		opts = append(opts, grpc.WithKeepaliveParams(s.KeepAlive))
+	}
+	opts = append(opts, grpc.WithDefaultCallOptions(s.CallOptions()...))
+	if s.Block {
+		opts = append(opts, grpc.WithBlock())
+	}
+
+	proxyOpt, err := ProxyDialOption(s.ProxyURL, urlutil.ToAddress(target))
+	if err != nil {
+		return nil, err
+	}
+	if proxyOpt != nil {
+		opts = append(opts, proxyOpt)
+	}
+	return opts, nil
+}
+
+// CallOptions returns the default gRPC call options of a connection. The gRPC default message sizes apply
+// unless the sizes are set.
+func (s DialSettings) CallOptions() []grpc.CallOption {
+	callOpts := []grpc.CallOption{grpc.FailFast(s.FailFast)}
+	if s.MaxRecvMsgSize > 0 {
+		callOpts = append(callOpts, grpc.MaxCallRecvMsgSize(s.MaxRecvMsgSize))
+	}
+	if s.MaxSendMsgSize > 0 {
+		callOpts = append(callOpts, grpc.MaxCallSendMsgSize(s.MaxSendMsgSize))
+	}
+	return callOpts
+}
+
+// dialKey is the context key of the key of the dial options of a connection
+type dialKey struct{}
+
+// WithDialKey returns a context for Connector.DialContext that carries the key of the dial options (e.g.
+// DialSettings.Key). A connection is dialed with the options of the client that dialed it first, so the
+// CachingConnector only shares a connection between the clients that dial the target with the same TLS
+// config and the same dial key. The key is appended to the key that's carried by the context, if any.
+func WithDialKey(ctx context.Context, key string) context.Context {
+	if parent := dialKeyFromContext(ctx); parent != "" {
+		key = parent + "|" + key
+	}
+	return context.WithValue(ctx, dialKey{}, key)
+}
+
+func dialKeyFromContext(ctx context.Context) string {
+	key, _ := ctx.Value(dialKey{}).(string)
+	return key
+}
+
+// DirectConnector dials a new connection for each client and closes the connection when it's released
+type DirectConnector struct {
+}
+
+// DialContext dials a new connection to the target
+func (c *DirectConnector) DialContext(ctx context.Context, target string, tlsConfig *tls.Config, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	return grpc.DialContext(ctx, target, append(opts, transportOption(tlsConfig))...)
+}
+
+// ReleaseConn closes the connection
+func (c *DirectConnector) ReleaseConn(conn *grpc.ClientConn) {
+	if err := conn.Close(); err != nil {
+		logger.Warnf("error closing GRPC connection: %s", err)
+	}
+}
+
+// CachingConnector shares the connections to the same target, secured with the same TLS config and dialed
+// with the same options (see WithDialKey), between the clients of the SDK (e.g. the endorsements, the broadcasts to the orderer and the event streams).
+// The connections are reference counted; a connection that hasn't been used for the idle timeout after
+// it was last released is closed.
+type CachingConnector struct {
+	idleTimeout time.Duration
+
+	mutex  sync.Mutex
+	conns  map[string]*cachedConn
+	refs   map[*grpc.ClientConn]*cachedConn
+	closed bool
+	done   chan struct{}
+}
+
+type cachedConn struct {
+	key          string
+	conn         *grpc.ClientConn
+	refs         int
+	lastReleased time.Time
+	evicted      bool
+}
+
+// NewCachingConnector returns a connector that closes the connections that are idle for the given timeout.
+// Connections are closed as soon as they're released if the timeout isn't positive.
+func NewCachingConnector(idleTimeout time.Duration) *CachingConnector {
+	c := &CachingConnector{
+		idleTimeout: idleTimeout,
+		conns:       make(map[string]*cachedConn),
+		refs:        make(map[*grpc.ClientConn]*cachedConn),
+		done:        make(chan struct{}),
+	}
+	if idleTimeout > 0 {
+		go c.sweep(idleTimeout / 2)
+	}
+	return c
+}
+
+// DialContext returns the cached connection to the target, or dials a new connection if there's no cached
+// connection or the cached connection has failed
+func (c *CachingConnector) DialContext(ctx context.Context, target string, tlsConfig *tls.Config, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	key := target + "#" + TLSConfigFingerprint(tlsConfig) + "#" + dialKeyFromContext(ctx)
+
+	c.mutex.Lock()
+	if c.closed {
+		c.mutex.Unlock()
+		return nil, errors.New("connector is closed")
+	}
+	if cc, ok := c.conns[key]; ok {
+		if healthy(cc.conn) {
+			cc.refs++
+			c.mutex.Unlock()
+			logger.Debugf("Reusing connection to [%s]", target)
+			return cc.conn, nil
+		}
+		c.evict(cc)
+	}
+	c.mutex.Unlock()
+
+	// the connection is dialed without holding the lock since a blocking dial may take until the timeout
+	conn, err := grpc.DialContext(ctx, target, append(opts, transportOption(tlsConfig))...)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.closed {
+		closeConn(conn)
+		return nil, errors.New("connector is closed")
+	}
+	if cc, ok := c.conns[key]; ok && healthy(cc.conn) {
+		// the connection was dialed concurrently by another client
+		closeConn(conn)
+		cc.refs++
+		return cc.conn, nil
+	}
+
+	logger.Debugf("Caching connection to [%s]", target)
+	cc := &cachedConn{key: key, conn: conn, refs: 1}
+	c.conns[key] = cc
+	c.refs[conn] = cc
+	return conn, nil
+}
+
+// ReleaseConn releases a connection that was returned by DialContext
+func (c *CachingConnector) ReleaseConn(conn *grpc.ClientConn) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	cc, ok := c.refs[conn]
+	if !ok {
+		// the connection was closed by Close
+		return
+	}
+	cc.refs--
+	cc.lastReleased = time.Now()
+	if cc.refs > 0 {
+		return
+	}
+	if cc.evicted || c.idleTimeout <= 0 {
+		c.remove(cc)
+	}
+}
+
+// Close closes all the connections, including the connections that are in use
+func (c *CachingConnector) Close() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	close(c.done)
+
+	for _, cc := range c.refs {
+		closeConn(cc.conn)
+	}
+	c.conns = make(map[string]*cachedConn)
+	c.refs = make(map[*grpc.ClientConn]*cachedConn)
+	return nil
+}
+
+// HandleConfigUpdate evicts the cached connections when the SDK configuration is reloaded, so that the
+// clients dial new connections with the reloaded TLS CAs and client certificates. The connections that
+// are in use are closed once they're released.
+func (c *CachingConnector) HandleConfigUpdate(event *core.ConfigUpdatedEvent) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, cc := range c.conns {
+		c.evict(cc)
+	}
+}
+
+// NumConns returns the number of open connections
+func (c *CachingConnector) NumConns() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return len(c.refs)
+}
+
+func (c *CachingConnector) sweep(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case now := <-ticker.C:
+			c.closeIdle(now)
+		}
+	}
+}
+
+func (c *CachingConnector) closeIdle(now time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, cc := range c.refs {
+		if cc.refs == 0 && now.Sub(cc.lastReleased) >= c.idleTimeout {
+			logger.Debugf("Closing idle connection [%s]", cc.key)
+			c.remove(cc)
+		}
+	}
+}
+
+// evict removes the connection from the cache, the connection is closed once it's no longer used.
+// The mutex must be held.
+func (c *CachingConnector) evict(cc *cachedConn) {
+	delete(c.conns, cc.key)
+	cc.evicted = true
+	if cc.refs == 0 {
+		c.remove(cc)
+	}
+}
+
+// remove closes the connection. The mutex must be held.
+func (c *CachingConnector) remove(cc *cachedConn) {
+	if cached, ok := c.conns[cc.key]; ok && cached == cc {
+		delete(c.conns, cc.key)
+	}
+	delete(c.refs, cc.conn)
+	closeConn(cc.conn)
+}
+
+func healthy(conn *grpc.ClientConn) bool {
+	state := conn.GetState()
+	return state != connectivity.TransientFailure && state != connectivity.Shutdown
+}
+
+func closeConn(conn *grpc.ClientConn) {
+	if err := conn.Close(); err != nil {
+		logger.Warnf("error closing GRPC connection: %s", err)
+	}
+}
+
+func transportOption(tlsConfig *tls.Config) grpc.DialOption {
+	if tlsConfig == nil {
+		return grpc.WithInsecure()
+	}
+	return grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig))
+}
+
+// TLSConfigFingerprint returns a fingerprint of the settings of a TLS config that identify the server
+// and the client: the server name, the trusted root CAs and the client certificates. The root CAs are
+// identified by the cert pool and its subjects, since the certificates of a pool can't be listed.
+func TLSConfigFingerprint(tlsConfig *tls.Config) string {
+	if tlsConfig == nil {
+		return "insecure"
+	}
+
+	h := sha256.New()
+	writeField(h, []byte(tlsConfig.ServerName))
+	if tlsConfig.InsecureSkipVerify {
+		writeField(h, []byte("insecure-skip-verify"))
+	}
+	if tlsConfig.RootCAs != nil {
+		writeField(h, []byte(fmt.Sprintf("root-cas:%p", tlsConfig.RootCAs)))
+		for _, subject := range tlsConfig.RootCAs.Subjects() {
+			writeField(h, subject)
+		}
+	}
+	for i, cert := range tlsConfig.Certificates {
+		writeField(h, []byte(fmt.Sprintf("client-cert:%d", i)))
+		for _, der := range cert.Certificate {
+			writeField(h, der)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// writeField writes a length-prefixed field, so that the fields written in sequence can't collide
+func writeField(h hash.Hash, field []byte) {
+	var length [8]byte
+	binary.BigEndian.PutUint64(length[:], uint64(len(field)))
+	h.Write(length[:])
+	h.Write(field)
+}
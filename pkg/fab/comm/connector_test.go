@@ -0,0 +1,282 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package comm
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/keepalive"
+)
+
+func TestCachingConnectorReuse(t *testing.T) {
+	connector := NewCachingConnector(time.Minute)
+	defer connector.Close()
+
+	conn1 := dialConnector(t, connector, nil)
+	connector.ReleaseConn(conn1)
+
+	conn2 := dialConnector(t, connector, nil)
+	defer connector.ReleaseConn(conn2)
+
+	if conn1 != conn2 {
+		t.Fatalf("expected the connection to be reused")
+	}
+	if connector.NumConns() != 1 {
+		t.Fatalf("expected 1 connection but got %d", connector.NumConns())
+	}
+	if conn1.GetState() == connectivity.Shutdown {
+		t.Fatalf("expected the released connection to be kept open")
+	}
+}
+
+func TestCachingConnectorTLSConfig(t *testing.T) {
+	connector := NewCachingConnector(time.Minute)
+	defer connector.Close()
+
+	conn1 := dialConnector(t, connector, nil)
+	defer connector.ReleaseConn(conn1)
+	conn2 := dialConnector(t, connector, &tls.Config{ServerName: "peer0"})
+	defer connector.ReleaseConn(conn2)
+
+	if conn1 == conn2 {
+		t.Fatalf("expected a new connection for a different TLS config")
+	}
+	if connector.NumConns() != 2 {
+		t.Fatalf("expected 2 connections but got %d", connector.NumConns())
+	}
+}
+
+func TestCachingConnectorDialKey(t *testing.T) {
+	connector := NewCachingConnector(time.Minute)
+	defer connector.Close()
+
+	keepAlive := DialSettings{KeepAlive: keepalive.ClientParameters{Time: time.Minute}}
+	conn1 := dialConnectorWithKey(t, connector, keepAlive.Key())
+	defer connector.ReleaseConn(conn1)
+	conn2 := dialConnectorWithKey(t, connector, keepAlive.Key())
+	defer connector.ReleaseConn(conn2)
+	conn3 := dialConnectorWithKey(t, connector, DialSettings{ProxyURL: "http://proxy:3128"}.Key())
+	defer connector.ReleaseConn(conn3)
+
+	if conn1 != conn2 {
+		t.Fatalf("expected the connection to be reused for the same dial key")
+	}
+	if conn1 == conn3 {
+		t.Fatalf("expected a new connection for a different dial key")
+	}
+
+	// The connections that are dialed with interceptors aren't shared with the other clients
+	intercepting := NewInterceptingConnector(connector, nil, nil)
+	ctx, cancel := context.WithTimeout(WithDialKey(context.Background(), keepAlive.Key()), 3*time.Second)
+	defer cancel()
+	conn4, err := intercepting.DialContext(ctx, peerAddress, nil)
+	if err != nil {
+		t.Fatalf("error dialing %s: %s", peerAddress, err)
+	}
+	defer intercepting.ReleaseConn(conn4)
+
+	if conn4 == conn1 {
+		t.Fatalf("expected a new connection for the intercepting connector")
+	}
+	if connector.NumConns() != 3 {
+		t.Fatalf("expected 3 connections but got %d", connector.NumConns())
+	}
+}
+
+func TestCachingConnectorIdleTimeout(t *testing.T) {
+	connector := NewCachingConnector(100 * time.Millisecond)
+	defer connector.Close()
+
+	conn := dialConnector(t, connector, nil)
+
+	// connections aren't closed while they're in use
+	time.Sleep(300 * time.Millisecond)
+	if connector.NumConns() != 1 {
+		t.Fatalf("expected the connection that's in use to be kept open")
+	}
+
+	connector.ReleaseConn(conn)
+	waitForConnState(t, conn, connectivity.Shutdown)
+	if connector.NumConns() != 0 {
+		t.Fatalf("expected the idle connection to be removed but got %d connections", connector.NumConns())
+	}
+
+	conn2 := dialConnector(t, connector, nil)
+	defer connector.ReleaseConn(conn2)
+	if conn2 == conn {
+		t.Fatalf("expected a new connection once the idle connection is closed")
+	}
+}
+
+func TestCachingConnectorNoIdleTimeout(t *testing.T) {
+	connector := NewCachingConnector(0)
+	defer connector.Close()
+
+	conn := dialConnector(t, connector, nil)
+	connector.ReleaseConn(conn)
+
+	if conn.GetState() != connectivity.Shutdown {
+		t.Fatalf("expected the connection to be closed when it's released")
+	}
+	if connector.NumConns() != 0 {
+		t.Fatalf("expected no connections but got %d", connector.NumConns())
+	}
+}
+
+func TestCachingConnectorClose(t *testing.T) {
+	connector := NewCachingConnector(time.Minute)
+
+	conn := dialConnector(t, connector, nil)
+	if err := connector.Close(); err != nil {
+		t.Fatalf("error closing connector: %s", err)
+	}
+	if conn.GetState() != connectivity.Shutdown {
+		t.Fatalf("expected the connection to be closed when the connector is closed")
+	}
+
+	// releasing a connection of a closed connector has no effect
+	connector.ReleaseConn(conn)
+
+	if _, err := connector.DialContext(context.Background(), peerAddress, nil); err == nil {
+		t.Fatalf("expected error dialing with a closed connector")
+	}
+	if err := connector.Close(); err != nil {
+		t.Fatalf("expected no error closing the connector twice but got %s", err)
+	}
+}
+
+func TestCachingConnectorHandleConfigUpdate(t *testing.T) {
+	connector := NewCachingConnector(time.Minute)
+	defer connector.Close()
+
+	inUse := dialConnector(t, connector, nil)
+	idle := dialConnector(t, connector, &tls.Config{ServerName: "peer0"})
+	connector.ReleaseConn(idle)
+
+	NewInterceptingConnector(connector, nil, nil).HandleConfigUpdate(&core.ConfigUpdatedEvent{})
+
+	// The idle connection is closed and the connection that's in use is closed once it's released
+	waitForConnState(t, idle, connectivity.Shutdown)
+	if inUse.GetState() == connectivity.Shutdown {
+		t.Fatalf("expected the connection that's in use to be kept open")
+	}
+
+	conn := dialConnector(t, connector, nil)
+	defer connector.ReleaseConn(conn)
+	if conn == inUse {
+		t.Fatalf("expected a new connection after the configuration is updated")
+	}
+
+	connector.ReleaseConn(inUse)
+	waitForConnState(t, inUse, connectivity.Shutdown)
+	if connector.NumConns() != 1 {
+		t.Fatalf("expected 1 connection but got %d", connector.NumConns())
+	}
+}
+
+func TestDialSettingsOptions(t *testing.T) {
+	settings := DialSettings{
+		KeepAlive:      keepalive.ClientParameters{Time: time.Minute},
+		MaxRecvMsgSize: 1024,
+		MaxSendMsgSize: 1024,
+		ProxyURL:       "http://proxy:3128",
+		Block:          true,
+	}
+	opts, err := settings.DialOptions("grpcs://peer0:7051")
+	if err != nil {
+		t.Fatalf("error creating dial options: %s", err)
+	}
+	// keep-alive, default call options, blocking dial and proxy
+	if len(opts) != 4 {
+		t.Fatalf("expected 4 dial options but got %d", len(opts))
+	}
+	// fail-fast and message sizes
+	if len(settings.CallOptions()) != 3 {
+		t.Fatalf("expected 3 call options but got %d", len(settings.CallOptions()))
+	}
+
+	// The gRPC defaults apply if the settings aren't set
+	opts, err = DialSettings{}.DialOptions("peer0:7051")
+	if err != nil {
+		t.Fatalf("error creating dial options: %s", err)
+	}
+	if len(opts) != 1 || len(DialSettings{}.CallOptions()) != 1 {
+		t.Fatalf("expected only the fail-fast call option")
+	}
+
+	if _, err := (DialSettings{ProxyURL: "://invalid"}).DialOptions("peer0:7051"); err == nil {
+		t.Fatalf("expected error for an invalid proxy URL")
+	}
+}
+
+func TestTLSConfigFingerprint(t *testing.T) {
+	if TLSConfigFingerprint(nil) == TLSConfigFingerprint(&tls.Config{}) {
+		t.Fatalf("expected different fingerprints for insecure and TLS connections")
+	}
+	if TLSConfigFingerprint(&tls.Config{ServerName: "peer0"}) != TLSConfigFingerprint(&tls.Config{ServerName: "peer0"}) {
+		t.Fatalf("expected the same fingerprint for equivalent TLS configs")
+	}
+	if TLSConfigFingerprint(&tls.Config{ServerName: "peer0"}) == TLSConfigFingerprint(&tls.Config{ServerName: "peer1"}) {
+		t.Fatalf("expected different fingerprints for different server names")
+	}
+	clientCert := tls.Certificate{Certificate: [][]byte{[]byte("client")}}
+	if TLSConfigFingerprint(&tls.Config{}) == TLSConfigFingerprint(&tls.Config{Certificates: []tls.Certificate{clientCert}}) {
+		t.Fatalf("expected different fingerprints for different client certificates")
+	}
+	otherClientCert := tls.Certificate{Certificate: [][]byte{[]byte("other client")}}
+	if TLSConfigFingerprint(&tls.Config{Certificates: []tls.Certificate{clientCert}}) == TLSConfigFingerprint(&tls.Config{Certificates: []tls.Certificate{otherClientCert}}) {
+		t.Fatalf("expected different fingerprints for different client certificates")
+	}
+
+	// The root CAs are identified by their cert pool (e.g. the cert pool of a config that was reloaded)
+	rootCAs := x509.NewCertPool()
+	if TLSConfigFingerprint(&tls.Config{RootCAs: rootCAs}) != TLSConfigFingerprint(&tls.Config{RootCAs: rootCAs}) {
+		t.Fatalf("expected the same fingerprint for the same root CAs")
+	}
+	if TLSConfigFingerprint(&tls.Config{RootCAs: rootCAs}) == TLSConfigFingerprint(&tls.Config{RootCAs: x509.NewCertPool()}) {
+		t.Fatalf("expected different fingerprints for different root CAs")
+	}
+}
+
+func dialConnector(t *testing.T, connector *CachingConnector, tlsConfig *tls.Config) *grpc.ClientConn {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	conn, err := connector.DialContext(ctx, peerAddress, tlsConfig)
+	if err != nil {
+		t.Fatalf("error dialing %s: %s", peerAddress, err)
+	}
+	return conn
+}
+
+func dialConnectorWithKey(t *testing.T, connector *CachingConnector, dialKey string) *grpc.ClientConn {
+	ctx, cancel := context.WithTimeout(WithDialKey(context.Background(), dialKey), 3*time.Second)
+	defer cancel()
+
+	conn, err := connector.DialContext(ctx, peerAddress, nil)
+	if err != nil {
+		t.Fatalf("error dialing %s: %s", peerAddress, err)
+	}
+	return conn
+}
+
+func waitForConnState(t *testing.T, conn *grpc.ClientConn, state connectivity.State) {
+	deadline := time.Now().Add(3 * time.Second)
+	for conn.GetState() != state {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected connection state %s but got %s", state, conn.GetState())
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
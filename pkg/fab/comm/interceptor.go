@@ -0,0 +1,141 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package comm
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api"
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
+	"google.golang.org/grpc"
+)
+
+// targetKey is the context key of the target of an intercepted call
+type targetKey struct{}
+
+// TargetFromContext returns the target (the address of the peer or the orderer) of the call that's
+// intercepted by an interceptor of an InterceptingConnector
+func TargetFromContext(ctx context.Context) (string, bool) {
+	target, ok := ctx.Value(targetKey{}).(string)
+	return target, ok
+}
+
+// InterceptingConnector applies client interceptors to the calls and the streams of the connections
+// that are dialed by another connector. The interceptors are invoked in order, the first interceptor
+// being the outermost one; the target of the connection is available to the interceptors from the
+// context of the call (see TargetFromContext).
+type InterceptingConnector struct {
+	api.Connector
+	unary  []grpc.UnaryClientInterceptor
+	stream []grpc.StreamClientInterceptor
+}
+
+// NewInterceptingConnector returns a connector that applies the given interceptors to the connections
+// that are dialed by the given connector
+func NewInterceptingConnector(connector api.Connector, unary []grpc.UnaryClientInterceptor, stream []grpc.StreamClientInterceptor) *InterceptingConnector {
+	return &InterceptingConnector{Connector: connector, unary: unary, stream: stream}
+}
+
+// DialContext dials the target with the dial options of the interceptors. The connections that are dialed
+// with the interceptors aren't shared with the clients of other connectors (see WithDialKey).
+func (c *InterceptingConnector) DialContext(ctx context.Context, target string, tlsConfig *tls.Config, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	ctx = WithDialKey(ctx, fmt.Sprintf("interceptors:%p", c))
+	opts = append(opts,
+		grpc.WithUnaryInterceptor(chainUnaryInterceptors(target, c.unary)),
+		grpc.WithStreamInterceptor(chainStreamInterceptors(target, c.stream)))
+	return c.Connector.DialContext(ctx, target, tlsConfig, opts...)
+}
+
+// Close closes the connector that dials the connections (if it implements io.Closer)
+func (c *InterceptingConnector) Close() error {
+	if closer, ok := c.Connector.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// HandleConfigUpdate forwards the update of the configuration to the connector that dials the connections
+// (see CachingConnector.HandleConfigUpdate)
+func (c *InterceptingConnector) HandleConfigUpdate(event *core.ConfigUpdatedEvent) {
+	if handler, ok := c.Connector.(configUpdateHandler); ok {
+		handler.HandleConfigUpdate(event)
+	}
+}
+
+// configUpdateHandler is implemented by connectors that handle the update of the configuration
+type configUpdateHandler interface {
+	HandleConfigUpdate(event *core.ConfigUpdatedEvent)
+}
+
+// chainUnaryInterceptors returns an interceptor that adds the target to the context of the call
+// and then invokes the interceptors in order
+func chainUnaryInterceptors(target string, interceptors []grpc.UnaryClientInterceptor) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx = context.WithValue(ctx, targetKey{}, target)
+		return chainedInvoker(interceptors, invoker)(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+func chainedInvoker(interceptors []grpc.UnaryClientInterceptor, invoker grpc.UnaryInvoker) grpc.UnaryInvoker {
+	if len(interceptors) == 0 {
+		return invoker
+	}
+	next := chainedInvoker(interceptors[1:], invoker)
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		return interceptors[0](ctx, method, req, reply, cc, next, opts...)
+	}
+}
+
+// chainStreamInterceptors returns an interceptor that adds the target to the context of the stream
+// and then invokes the interceptors in order
+func chainStreamInterceptors(target string, interceptors []grpc.StreamClientInterceptor) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		ctx = context.WithValue(ctx, targetKey{}, target)
+		return chainedStreamer(interceptors, streamer)(ctx, desc, cc, method, opts...)
+	}
+}
+
+func chainedStreamer(interceptors []grpc.StreamClientInterceptor, streamer grpc.Streamer) grpc.Streamer {
+	if len(interceptors) == 0 {
+		return streamer
+	}
+	next := chainedStreamer(interceptors[1:], streamer)
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return interceptors[0](ctx, desc, cc, method, next, opts...)
+	}
+}
+
+// LoggingUnaryInterceptor is a client interceptor that logs the method, the target, the duration and
+// the error (if any) of each call at debug level
+func LoggingUnaryInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	start := time.Now()
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	target, _ := TargetFromContext(ctx)
+	if err != nil {
+		logger.Debugf("Call of %s on [%s] failed after %s: %s", method, target, time.Since(start), err)
+	} else {
+		logger.Debugf("Call of %s on [%s] completed in %s", method, target, time.Since(start))
+	}
+	return err
+}
+
+// LoggingStreamInterceptor is a client interceptor that logs the method, the target and the error
+// (if any) of each stream that's opened at debug level
+func LoggingStreamInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	stream, err := streamer(ctx, desc, cc, method, opts...)
+	target, _ := TargetFromContext(ctx)
+	if err != nil {
+		logger.Debugf("Opening stream %s on [%s] failed: %s", method, target, err)
+	} else {
+		logger.Debugf("Opened stream %s on [%s]", method, target)
+	}
+	return stream, err
+}
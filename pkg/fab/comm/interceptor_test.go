@@ -0,0 +1,77 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package comm
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	fabmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+	"google.golang.org/grpc"
+)
+
+func TestChainUnaryInterceptors(t *testing.T) {
+	var calls []string
+	interceptor := func(name string) grpc.UnaryClientInterceptor {
+		return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			target, ok := TargetFromContext(ctx)
+			if !ok || target != peerAddress {
+				t.Fatalf("expected target [%s] in the context of interceptor %s but got [%s]", peerAddress, name, target)
+			}
+			calls = append(calls, name)
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+	}
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		calls = append(calls, "invoker")
+		return nil
+	}
+
+	chain := chainUnaryInterceptors(peerAddress, []grpc.UnaryClientInterceptor{interceptor("first"), interceptor("second"), LoggingUnaryInterceptor})
+	if err := chain(context.Background(), "/protos.Endorser/ProcessProposal", nil, nil, nil, invoker); err != nil {
+		t.Fatalf("unexpected error from interceptor chain: %s", err)
+	}
+
+	expected := []string{"first", "second", "invoker"}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Fatalf("expected calls %v but got %v", expected, calls)
+	}
+}
+
+func TestInterceptingConnectorStream(t *testing.T) {
+	var methods []string
+	var targets []string
+	interceptor := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		target, _ := TargetFromContext(ctx)
+		methods = append(methods, method)
+		targets = append(targets, target)
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+
+	connector := NewInterceptingConnector(NewCachingConnector(0), nil, []grpc.StreamClientInterceptor{interceptor, LoggingStreamInterceptor})
+	defer connector.Close()
+
+	ctx := fabmocks.NewMockContext(fabmocks.NewMockUser("test"))
+	ctx.SetConnector(connector)
+
+	conn, err := NewConnection(ctx, "testchannel", func(grpcconn *grpc.ClientConn) (grpc.ClientStream, error) {
+		return pb.NewEventsClient(grpcconn).Chat(context.Background())
+	}, peerURL)
+	if err != nil {
+		t.Fatalf("error creating new connection: %s", err)
+	}
+	conn.Close()
+
+	if len(methods) != 1 || methods[0] != "/protos.Events/Chat" {
+		t.Fatalf("expected the events stream to be intercepted once but got %v", methods)
+	}
+	if targets[0] != peerAddress {
+		t.Fatalf("expected target [%s] but got [%s]", peerAddress, targets[0])
+	}
+}
@@ -0,0 +1,245 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package comm
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	fabmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+	"google.golang.org/grpc"
+)
+
+func TestConnectionThroughProxy(t *testing.T) {
+	proxy := newConnectProxy(t, "user:pass", nil)
+	defer proxy.Close()
+
+	ctx := fabmocks.NewMockContext(fabmocks.NewMockUser("test"))
+	conn, err := NewConnection(ctx, "testchannel", func(grpcconn *grpc.ClientConn) (grpc.ClientStream, error) {
+		return pb.NewEventsClient(grpcconn).Chat(context.Background())
+	}, peerURL, WithProxyURL("http://user:pass@"+proxy.Addr()))
+	if err != nil {
+		t.Fatalf("error creating new connection through the proxy: %s", err)
+	}
+	conn.Close()
+
+	if targets := proxy.Targets(); len(targets) != 1 || targets[0] != peerAddress {
+		t.Fatalf("expected one tunnel to [%s] but got %v", peerAddress, targets)
+	}
+}
+
+func TestProxyAuthenticationFailure(t *testing.T) {
+	proxy := newConnectProxy(t, "user:pass", nil)
+	defer proxy.Close()
+
+	proxyURL, err := parseProxyURL("http://user:wrong@" + proxy.Addr())
+	if err != nil {
+		t.Fatalf("error parsing proxy URL: %s", err)
+	}
+	dial, err := proxyDialer(proxyURL)
+	if err != nil {
+		t.Fatalf("error creating proxy dialer: %s", err)
+	}
+	if _, err := dial(peerAddress, time.Second); err == nil {
+		t.Fatalf("expected error dialing through the proxy with invalid credentials")
+	}
+	if targets := proxy.Targets(); len(targets) != 0 {
+		t.Fatalf("expected no tunnel but got %v", targets)
+	}
+}
+
+func TestProxyFromEnvironment(t *testing.T) {
+	// the host name of the peer is only known to the proxy, so the peer can't be dialed directly
+	const target = "peer0.example.com:9999"
+	proxy := newConnectProxy(t, "", map[string]string{target: peerAddress})
+	defer proxy.Close()
+
+	defer setEnv(t, "HTTPS_PROXY", proxy.Addr())()
+	defer setEnv(t, "NO_PROXY", "")()
+
+	opt, err := ProxyDialOption("", target)
+	if err != nil {
+		t.Fatalf("error creating proxy dial option: %s", err)
+	}
+	if opt == nil {
+		t.Fatalf("expected the target to be dialed through the proxy of the environment")
+	}
+	conn, err := dialBlocking(target, opt)
+	if err != nil {
+		t.Fatalf("error dialing through the proxy of the environment: %s", err)
+	}
+	conn.Close()
+
+	if targets := proxy.Targets(); len(targets) != 1 || targets[0] != target {
+		t.Fatalf("expected one tunnel to [%s] but got %v", target, targets)
+	}
+
+	// the peer is excluded from the proxy
+	os.Setenv("NO_PROXY", "localhost, .example.com")
+	if opt, err := ProxyDialOption("", target); err != nil || opt != nil {
+		t.Fatalf("expected the target to bypass the proxy but got option %v and error %v", opt, err)
+	}
+
+	// an explicit proxy isn't subject to NO_PROXY
+	if opt, err := ProxyDialOption("http://"+proxy.Addr(), target); err != nil || opt == nil {
+		t.Fatalf("expected the target to be dialed through the explicit proxy but got error %v", err)
+	}
+}
+
+func TestNoProxy(t *testing.T) {
+	tests := []struct {
+		target  string
+		noProxy string
+		proxied bool
+	}{
+		{"peer0.example.com:7051", "", true},
+		{"localhost:7051", "", false},
+		{"127.0.0.1:7051", "", false},
+		{"[::1]:7051", "", false},
+		{"peer0.example.com:7051", "*", false},
+		{"peer0.example.com:7051", "peer0.example.com", false},
+		{"peer0.example.com:7051", "PEER0.example.com", false},
+		{"peer0.example.com:7051", "example.com", false},
+		{"peer0.example.com:7051", ".example.com", false},
+		{"peer0.example.com:7051", "other.com, .example.com", false},
+		{"peer0.example.com:7051", "ample.com", true},
+		{"peer0.example.com:7051", "peer0.example.com:7051", false},
+		{"peer0.example.com:7051", "peer0.example.com:7053", true},
+		{"10.1.2.3:7050", "10.1.2.3", false},
+		{"10.1.2.3:7050", "10.0.0.0/8", false},
+		{"192.168.1.1:7050", "10.0.0.0/8", true},
+	}
+	for _, test := range tests {
+		if proxied := useProxy(test.target, test.noProxy); proxied != test.proxied {
+			t.Errorf("expected proxied=%t for target [%s] and NO_PROXY [%s]", test.proxied, test.target, test.noProxy)
+		}
+	}
+}
+
+func TestProxyURL(t *testing.T) {
+	if url := ProxyURL(map[string]interface{}{"proxyurl": "http://proxy:3128"}); url != "http://proxy:3128" {
+		t.Fatalf("expected the proxy URL of the lower-cased option but got [%s]", url)
+	}
+	if url := ProxyURL(map[string]interface{}{"ssl-target-name-override": "peer0"}); url != "" {
+		t.Fatalf("expected no proxy URL but got [%s]", url)
+	}
+	if _, err := ProxyDialOption("ftp://proxy:21", peerAddress); err == nil {
+		t.Fatalf("expected error for an unsupported proxy scheme")
+	}
+}
+
+func dialBlocking(target string, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	return grpc.DialContext(ctx, target, append(opts, grpc.WithInsecure(), grpc.WithBlock())...)
+}
+
+func setEnv(t *testing.T, name, value string) func() {
+	previous, ok := os.LookupEnv(name)
+	if err := os.Setenv(name, value); err != nil {
+		t.Fatalf("error setting %s: %s", name, err)
+	}
+	return func() {
+		if ok {
+			os.Setenv(name, previous)
+		} else {
+			os.Unsetenv(name)
+		}
+	}
+}
+
+// connectProxy is an HTTP proxy that tunnels the connections with the CONNECT method
+type connectProxy struct {
+	listener    net.Listener
+	credentials string
+	hosts       map[string]string
+
+	mutex   sync.Mutex
+	targets []string
+}
+
+// newConnectProxy starts a proxy that requires the given credentials (if not empty) and resolves
+// the targets with the given hosts (if any)
+func newConnectProxy(t *testing.T, credentials string, hosts map[string]string) *connectProxy {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error starting proxy: %s", err)
+	}
+	p := &connectProxy{listener: listener, credentials: credentials, hosts: hosts}
+	go p.serve()
+	return p
+}
+
+func (p *connectProxy) Addr() string {
+	return p.listener.Addr().String()
+}
+
+func (p *connectProxy) Close() {
+	p.listener.Close()
+}
+
+// Targets returns the targets of the tunnels that were opened
+func (p *connectProxy) Targets() []string {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return append([]string(nil), p.targets...)
+}
+
+func (p *connectProxy) serve() {
+	for {
+		conn, err := p.listener.Accept()
+		if err != nil {
+			return
+		}
+		go p.tunnel(conn)
+	}
+}
+
+func (p *connectProxy) tunnel(conn net.Conn) {
+	defer conn.Close()
+
+	req, err := http.ReadRequest(bufio.NewReader(conn))
+	if err != nil {
+		return
+	}
+	if req.Method != http.MethodConnect {
+		io.WriteString(conn, "HTTP/1.1 405 Method Not Allowed\r\n\r\n")
+		return
+	}
+	if p.credentials != "" && req.Header.Get("Proxy-Authorization") != "Basic "+base64.StdEncoding.EncodeToString([]byte(p.credentials)) {
+		io.WriteString(conn, "HTTP/1.1 407 Proxy Authentication Required\r\n\r\n")
+		return
+	}
+
+	addr := req.Host
+	if resolved, ok := p.hosts[addr]; ok {
+		addr = resolved
+	}
+	target, err := net.Dial("tcp", addr)
+	if err != nil {
+		io.WriteString(conn, "HTTP/1.1 502 Bad Gateway\r\n\r\n")
+		return
+	}
+	defer target.Close()
+
+	p.mutex.Lock()
+	p.targets = append(p.targets, req.Host)
+	p.mutex.Unlock()
+
+	io.WriteString(conn, "HTTP/1.1 200 Connection Established\r\n\r\n")
+	go io.Copy(target, conn)
+	io.Copy(conn, target)
+}
@@ -21,5 +21,26 @@ type Connection interface {
 	Closed() bool
 }
 
+// LedgerInfoProvider is optionally implemented by a Connection that obtains the
+// ledger info of the peer when the connection is established
+type LedgerInfoProvider interface {
+	// LedgerHeight returns the block height that was reported by the peer when the
+	// connection was established. False is returned if the height is not known.
+	LedgerHeight() (uint64, bool)
+}
+
+// HealthChecker is optionally implemented by a Connection that can verify
+// that the underlying stream is healthy
+type HealthChecker interface {
+	// CheckHealth returns an error if the connection is not healthy
+	CheckHealth() error
+}
+
 // ConnectionProvider creates a Connection.
 type ConnectionProvider func(channelID string, context context.Context, peer fab.Peer) (Connection, error)
+
+// Connect invokes the provider. This allows a ConnectionProvider to be used where a
+// connection provider interface is expected (see client.ConnectionProvider).
+func (p ConnectionProvider) Connect(channelID string, context context.Context, peer fab.Peer) (Connection, error) {
+	return p(channelID, context, peer)
+}
@@ -8,6 +8,7 @@ package api
 
 import (
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/options"
 )
 
 // EventEndpoint extends a Peer endpoint and provides the
@@ -18,3 +19,18 @@ type EventEndpoint interface {
 	// EventURL returns the event URL
 	EventURL() string
 }
+
+// ConnectionOptsProvider is implemented by an event endpoint that
+// provides options for the connection to its event server
+type ConnectionOptsProvider interface {
+	// ConnectionOpts returns the connection options
+	ConnectionOpts() []options.Opt
+}
+
+// ConnectionOpts returns the connection options provided by the given peer (if any)
+func ConnectionOpts(peer fab.Peer) []options.Opt {
+	if p, ok := peer.(ConnectionOptsProvider); ok {
+		return p.ConnectionOpts()
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package checkpointer records the number of the last block that a named consumer of events has fully
+// handled so that, after a restart, the event client resumes from the block following the checkpoint.
+//
+// The checkpoint is saved after the events for a block have been published to all registrations and
+// before the next block is handled. If the process exits after the events were published but before
+// the checkpoint was saved then the block is delivered again after the restart, i.e. delivery is
+// at-least-once and consumers must be prepared to receive the last block more than once.
+//
+// If an event for a block can't be delivered (the registration's channel is full or the consumer timed
+// out, see dispatcher.WithEventConsumerTimeout) then the checkpoint isn't advanced past the previous block
+// until the channel's dispatcher is reset, so that the block is delivered again after a restart.
+package checkpointer
+
+import (
+	"sync"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/logging"
+)
+
+var logger = logging.NewLogger("fabric_sdk_go")
+
+// Checkpointer saves and loads the last block number that was handled by each named consumer
+type Checkpointer interface {
+	// Save records the given block number as the last block handled by the named consumer
+	Save(name string, blockNum uint64) error
+
+	// Load returns the last block number that was saved for the named consumer.
+	// False is returned if no block number was saved.
+	Load(name string) (uint64, bool, error)
+
+	// Clear removes the checkpoint of the named consumer (e.g. when the channel was re-created
+	// and the saved block number is no longer meaningful)
+	Clear(name string) error
+}
+
+// Memory is a Checkpointer that keeps the checkpoints in memory, i.e. they don't survive a restart
+type Memory struct {
+	mutex       sync.RWMutex
+	checkpoints map[string]uint64
+}
+
+// NewMemory returns a new in-memory Checkpointer
+func NewMemory() *Memory {
+	return &Memory{checkpoints: make(map[string]uint64)}
+}
+
+// Save records the given block number as the last block handled by the named consumer
+func (m *Memory) Save(name string, blockNum uint64) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.checkpoints[name] = blockNum
+	return nil
+}
+
+// Load returns the last block number that was saved for the named consumer
+func (m *Memory) Load(name string) (uint64, bool, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	blockNum, ok := m.checkpoints[name]
+	return blockNum, ok, nil
+}
+
+// Clear removes the checkpoint of the named consumer
+func (m *Memory) Clear(name string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	delete(m.checkpoints, name)
+	return nil
+}
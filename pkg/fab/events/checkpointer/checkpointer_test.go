@@ -0,0 +1,102 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package checkpointer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMemory(t *testing.T) {
+	testCheckpointer(t, NewMemory())
+}
+
+func TestFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkpointer")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "checkpoints")
+
+	cp, err := NewFile(path)
+	if err != nil {
+		t.Fatalf("error creating file checkpointer: %s", err)
+	}
+	testCheckpointer(t, cp)
+
+	// The checkpoints survive a restart
+	cp, err = NewFile(path)
+	if err != nil {
+		t.Fatalf("error creating file checkpointer: %s", err)
+	}
+	checkCheckpoint(t, cp, "consumer1", 7, true)
+	checkCheckpoint(t, cp, "consumer2", 3, true)
+}
+
+func TestFileSaveError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkpointer")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	parent := filepath.Join(dir, "parent")
+	cp, err := NewFile(filepath.Join(parent, "checkpoints"))
+	if err != nil {
+		t.Fatalf("error creating file checkpointer: %s", err)
+	}
+
+	// The parent of the checkpoint file is a regular file so the checkpoint file can't be written
+	if err := ioutil.WriteFile(parent, []byte("x"), 0644); err != nil {
+		t.Fatalf("error writing file: %s", err)
+	}
+	if err := cp.Save("consumer1", 1); err == nil {
+		t.Fatalf("expecting error saving checkpoint")
+	}
+	checkCheckpoint(t, cp, "consumer1", 0, false)
+}
+
+func testCheckpointer(t *testing.T, cp Checkpointer) {
+	checkCheckpoint(t, cp, "consumer1", 0, false)
+
+	for _, blockNum := range []uint64{5, 6, 7} {
+		if err := cp.Save("consumer1", blockNum); err != nil {
+			t.Fatalf("error saving checkpoint: %s", err)
+		}
+	}
+	if err := cp.Save("consumer2", 3); err != nil {
+		t.Fatalf("error saving checkpoint: %s", err)
+	}
+
+	checkCheckpoint(t, cp, "consumer1", 7, true)
+	checkCheckpoint(t, cp, "consumer2", 3, true)
+
+	if err := cp.Save("consumer3", 9); err != nil {
+		t.Fatalf("error saving checkpoint: %s", err)
+	}
+	if err := cp.Clear("consumer3"); err != nil {
+		t.Fatalf("error clearing checkpoint: %s", err)
+	}
+	checkCheckpoint(t, cp, "consumer3", 0, false)
+	if err := cp.Clear("consumer3"); err != nil {
+		t.Fatalf("error clearing checkpoint that doesn't exist: %s", err)
+	}
+}
+
+func checkCheckpoint(t *testing.T, cp Checkpointer, name string, expectedBlockNum uint64, expectedOK bool) {
+	blockNum, ok, err := cp.Load(name)
+	if err != nil {
+		t.Fatalf("error loading checkpoint for [%s]: %s", name, err)
+	}
+	if ok != expectedOK || blockNum != expectedBlockNum {
+		t.Fatalf("expecting checkpoint %d (found: %t) for [%s] but got %d (found: %t)", expectedBlockNum, expectedOK, name, blockNum, ok)
+	}
+}
@@ -0,0 +1,87 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package checkpointer
+
+import (
+	"sync"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/filestore"
+	"github.com/pkg/errors"
+)
+
+// fileFormat is the version of the format of the checkpoint file
+var fileFormat = filestore.Format{Major: 1}
+
+// File is a Checkpointer that persists the checkpoints of all consumers to a single file. The file
+// is replaced atomically on each save. The file must not be shared by more than one process.
+type File struct {
+	mutex       sync.Mutex
+	store       *filestore.Store
+	checkpoints map[string]uint64
+}
+
+// NewFile returns a new Checkpointer that persists the checkpoints to the file at the given path.
+// The checkpoints that were previously saved to the file are loaded.
+func NewFile(path string) (*File, error) {
+	store, err := filestore.New(path, fileFormat)
+	if err != nil {
+		return nil, errors.WithMessage(err, "error creating checkpoint file store")
+	}
+
+	checkpoints := make(map[string]uint64)
+	if _, err := store.Read(&checkpoints); err != nil {
+		return nil, errors.WithMessage(err, "error reading checkpoint file")
+	}
+
+	logger.Debugf("Loaded %d checkpoint(s) from [%s]", len(checkpoints), path)
+	return &File{store: store, checkpoints: checkpoints}, nil
+}
+
+// Save records the given block number as the last block handled by the named consumer and writes the
+// checkpoints to the file. The checkpoint isn't recorded if the file couldn't be written.
+func (f *File) Save(name string, blockNum uint64) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	previous, existed := f.checkpoints[name]
+	f.checkpoints[name] = blockNum
+	if err := f.store.Write(f.checkpoints); err != nil {
+		if existed {
+			f.checkpoints[name] = previous
+		} else {
+			delete(f.checkpoints, name)
+		}
+		return errors.WithMessage(err, "error saving checkpoint")
+	}
+	return nil
+}
+
+// Load returns the last block number that was saved for the named consumer
+func (f *File) Load(name string) (uint64, bool, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	blockNum, ok := f.checkpoints[name]
+	return blockNum, ok, nil
+}
+
+// Clear removes the checkpoint of the named consumer and writes the checkpoints to the file.
+// The checkpoint isn't removed if the file couldn't be written.
+func (f *File) Clear(name string) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	previous, existed := f.checkpoints[name]
+	if !existed {
+		return nil
+	}
+	delete(f.checkpoints, name)
+	if err := f.store.Write(f.checkpoints); err != nil {
+		f.checkpoints[name] = previous
+		return errors.WithMessage(err, "error clearing checkpoint")
+	}
+	return nil
+}
@@ -7,13 +7,20 @@ SPDX-License-Identifier: Apache-2.0
 package client
 
 import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	fabcontext "github.com/hyperledger/fabric-sdk-go/pkg/context"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/api"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/client/dispatcher"
 	eventservice "github.com/hyperledger/fabric-sdk-go/pkg/fab/events/service"
+	esdispatcher "github.com/hyperledger/fabric-sdk-go/pkg/fab/events/service/dispatcher"
 	"github.com/hyperledger/fabric-sdk-go/pkg/logging"
 	"github.com/hyperledger/fabric-sdk-go/pkg/options"
 	"github.com/pkg/errors"
@@ -21,6 +28,9 @@ import (
 
 var logger = logging.NewLogger("fabric_sdk_go")
 
+// drainPollInterval is the interval at which outstanding registrations are checked while draining (see CloseGracefully)
+const drainPollInterval = 50 * time.Millisecond
+
 // ConnectionState is the state of the client connection
 type ConnectionState int32
 
@@ -33,6 +43,28 @@ const (
 	Connected
 )
 
+// ConnectionProvider creates connections to the event server. Any api.ConnectionProvider implements this
+// interface. A provider may be supplied with the WithConnectionProvider option in order to unit test code
+// that uses the client without a peer (see the mocks/scripted package).
+type ConnectionProvider interface {
+	// Connect returns a connection to the given peer for the given channel
+	Connect(channelID string, context fabcontext.Context, peer fab.Peer) (api.Connection, error)
+}
+
+// EventType is a type of event for which a registration may be made (see WithPermittedEventTypes)
+type EventType int
+
+const (
+	// BlockEventType is the type of block events
+	BlockEventType EventType = iota
+	// FilteredBlockEventType is the type of filtered block events
+	FilteredBlockEventType
+	// TxStatusEventType is the type of transaction status events
+	TxStatusEventType
+	// ChaincodeEventType is the type of chaincode events
+	ChaincodeEventType
+)
+
 // Client connects to an event server and receives events, such as block, filtered block,
 // chaincode, and transaction status events. Client also monitors the connection to the
 // event server and attempts to reconnect if the connection is closed.
@@ -43,106 +75,624 @@ type Client struct {
 	connEvent         chan *fab.ConnectionEvent
 	connectionState   int32
 	stopped           int32
+	droppedConnEvents uint64
 	registerOnce      sync.Once
-	permitBlockEvents bool
-	afterConnect      handler
-	beforeReconnect   handler
+	monitorMutex      sync.Mutex
+	monitorStarted    bool
+	monitorDone       chan struct{}
+	stopch            chan struct{}
+	permitBlockEvents int32
+	afterConnect      []handler
+	beforeReconnect   []reconnectHandler
+	afterClose        []func()
+	connectedPeer     fab.Peer
+	resolvedPeer      fab.Peer
+	failedPeer        fab.Peer
+	lastDisconnect    *disconnectInfo
+	stateListener     ConnectionStateListener
+	stateMutex        sync.Mutex
+	stateChanges      []stateChange
+	notifyingState    bool
+	stateWaiters      []*stateWaiter
+	metrics           metrics
+	debounce          debounceState
 }
 
 type handler func() error
 
+type disconnectInfo struct {
+	err  error
+	time time.Time
+}
+
+// EndpointProvider returns the URL of the event server to connect to, along with options for the
+// connection, and is invoked on each connection attempt. The context is cancelled after the
+// response timeout (see WithResponseTimeout).
+type EndpointProvider func(ctx context.Context) (url string, opts []options.Opt, err error)
+
+// StaticEndpointProvider returns an endpoint provider that always returns the given URL and options
+func StaticEndpointProvider(url string, opts ...options.Opt) EndpointProvider {
+	return func(ctx context.Context) (string, []options.Opt, error) {
+		return url, opts, nil
+	}
+}
+
+// reconnectHandler is invoked with the candidate peer before each reconnection attempt.
+// The peer is nil if no peer resolver was provided (see WithPeerResolver), in which case the
+// dispatcher chooses the peer. If the handler returns an error then the attempt is abandoned.
+type reconnectHandler func(peer fab.Peer) error
+
+// ConnectionStateListener is invoked when the connection state of the client changes.
+// The error (which may be nil) is the reason for the state change, if known.
+type ConnectionStateListener func(oldState, newState ConnectionState, err error)
+
+type stateChange struct {
+	oldState ConnectionState
+	newState ConnectionState
+	err      error
+	listener ConnectionStateListener
+	observer MetricsObserver
+	metrics  Metrics
+}
+
+type stateWaiter struct {
+	state ConnectionState
+	done  chan struct{}
+}
+
 // New returns a new event client
 func New(permitBlockEvents bool, dispatcher eventservice.Dispatcher, opts ...options.Opt) *Client {
 	params := defaultParams()
 	options.Apply(params, opts)
 
-	return &Client{
-		Service:           *eventservice.New(dispatcher, opts...),
-		params:            *params,
-		connEvent:         make(chan *fab.ConnectionEvent),
-		connectionState:   int32(Disconnected),
-		permitBlockEvents: permitBlockEvents,
+	client := &Client{
+		Service:         *eventservice.New(dispatcher, opts...),
+		params:          *params,
+		connEvent:       make(chan *fab.ConnectionEvent),
+		connectionState: int32(Disconnected),
+		monitorDone:     make(chan struct{}),
+		stopch:          make(chan struct{}),
+		metrics:         metrics{stateSince: params.now()},
 	}
+	client.SetPermitBlockEvents(permitBlockEvents)
+	return client
 }
 
 // SetAfterConnectHandler registers a handler that is called
 // after the client connects to the event server. This allows for
 // custom code to be executed for a particular
-// event client implementation.
+// event client implementation. Any handlers that were previously
+// registered are replaced (or removed if the handler is nil).
 func (c *Client) SetAfterConnectHandler(h handler) {
 	c.Lock()
 	defer c.Unlock()
-	c.afterConnect = h
+	c.afterConnect = nil
+	if h != nil {
+		c.afterConnect = append(c.afterConnect, h)
+	}
+}
+
+// AddAfterConnectHandler registers a handler that is called after the client connects to the
+// event server, after the handlers that were previously registered. The handlers are invoked in
+// the order in which they were registered until one of them returns an error.
+func (c *Client) AddAfterConnectHandler(h handler) {
+	c.Lock()
+	defer c.Unlock()
+	c.afterConnect = append(c.afterConnect, h)
 }
 
-func (c *Client) afterConnectHandler() handler {
+func (c *Client) afterConnectHandlers() []handler {
 	c.RLock()
 	defer c.RUnlock()
-	return c.afterConnect
+	return append([]handler(nil), c.afterConnect...)
 }
 
 // SetBeforeReconnectHandler registers a handler that will be called
-// before retrying to reconnect to the event server. This allows for
+// before each attempt to reconnect to the event server. This allows for
 // custom code to be executed for a particular event client implementation.
-func (c *Client) SetBeforeReconnectHandler(h handler) {
+// The handler receives the candidate peer and may veto it by returning an
+// error, in which case the attempt counts as a failed attempt. Any handlers that
+// were previously registered are replaced (or removed if the handler is nil).
+func (c *Client) SetBeforeReconnectHandler(h reconnectHandler) {
+	c.Lock()
+	defer c.Unlock()
+	c.beforeReconnect = nil
+	if h != nil {
+		c.beforeReconnect = append(c.beforeReconnect, h)
+	}
+}
+
+// AddBeforeReconnectHandler registers a handler that is called before each attempt to reconnect to
+// the event server, after the handlers that were previously registered. The handlers are invoked in
+// the order in which they were registered until one of them returns an error (i.e. vetoes the attempt).
+func (c *Client) AddBeforeReconnectHandler(h reconnectHandler) {
+	c.Lock()
+	defer c.Unlock()
+	c.beforeReconnect = append(c.beforeReconnect, h)
+}
+
+func (c *Client) beforeReconnectHandlers() []reconnectHandler {
+	c.RLock()
+	defer c.RUnlock()
+	return append([]reconnectHandler(nil), c.beforeReconnect...)
+}
+
+// AddAfterCloseHandler registers a handler that is called once the client has been closed, whether
+// it was closed by the application or because the connection was terminated.
+func (c *Client) AddAfterCloseHandler(h func()) {
+	c.Lock()
+	defer c.Unlock()
+	c.afterClose = append(c.afterClose, h)
+}
+
+func (c *Client) afterCloseHandlers() []func() {
+	c.RLock()
+	defer c.RUnlock()
+	return append(([]func())(nil), c.afterClose...)
+}
+
+// invokeAfterConnectHandlers invokes the afterConnect handlers in order and
+// returns the error of the first handler that fails
+func (c *Client) invokeAfterConnectHandlers() error {
+	for i, h := range c.afterConnectHandlers() {
+		if err := h(); err != nil {
+			return errors.WithMessage(err, fmt.Sprintf("afterConnect handler #%d failed", i))
+		}
+	}
+	return nil
+}
+
+// invokeBeforeReconnectHandlers invokes the beforeReconnect handlers in order and
+// returns the error of the first handler that fails
+func (c *Client) invokeBeforeReconnectHandlers(peer fab.Peer) error {
+	for i, h := range c.beforeReconnectHandlers() {
+		if err := h(peer); err != nil {
+			return errors.WithMessage(err, fmt.Sprintf("beforeReconnect handler #%d failed", i))
+		}
+	}
+	return nil
+}
+
+// SetConnectionStateListener registers a listener that is notified whenever the
+// connection state of the client changes (including transitions to Connecting).
+// The listener is invoked on a separate Go routine and notifications are delivered
+// in the order in which the state changes occurred. The listener may be set before
+// Connect is called and may be replaced (or removed by passing nil) at any time.
+func (c *Client) SetConnectionStateListener(l ConnectionStateListener) {
 	c.Lock()
 	defer c.Unlock()
-	c.beforeReconnect = h
+	c.stateListener = l
 }
 
-func (c *Client) beforeReconnectHandler() handler {
+func (c *Client) connectionStateListener() ConnectionStateListener {
 	c.RLock()
 	defer c.RUnlock()
-	return c.beforeReconnect
+	return c.stateListener
 }
 
 // Connect connects to the peer and registers for events on a particular channel.
 func (c *Client) Connect() error {
 	if c.maxConnAttempts == 1 {
-		return c.connect()
+		return c.connectAttempt(1, false)
 	}
-	return c.connectWithRetry(c.maxConnAttempts, c.timeBetweenConnAttempts)
+	return c.connectWithRetry(c.maxConnAttempts, 0, c.timeBetweenConnAttempts, false)
+}
+
+// ConnectedPeer returns the peer that the client is connected to or nil if the client isn't connected
+func (c *Client) ConnectedPeer() fab.Peer {
+	if c.ConnectionState() != Connected {
+		return nil
+	}
+
+	c.RLock()
+	defer c.RUnlock()
+	return c.connectedPeer
+}
+
+// LastBlockReceived returns the number of the last block for which an event was received along with the time at
+// which it was received, so that callers may determine how far behind the ledger the client is. The time is zero
+// if the dispatcher doesn't record it. False is returned if no block has been received (or the dispatcher
+// was reset since the last block was received).
+func (c *Client) LastBlockReceived() (blockNum uint64, receivedAt time.Time, ok bool) {
+	blockNum = c.Dispatcher().LastBlockNum()
+	if blockNum == math.MaxUint64 {
+		return 0, time.Time{}, false
+	}
+	if p, ok := c.Dispatcher().(lastBlockTimeProvider); ok {
+		receivedAt = p.LastBlockTime()
+	}
+	return blockNum, receivedAt, true
 }
 
 // Close closes the connection to the event server and deallocates all resources.
-// Once this function is invoked the client may no longer be used.
+// Once this function is invoked the client may no longer be used. Close waits at most
+// the response timeout for the client to shut down (see CloseContext).
 func (c *Client) Close() {
+	c.close(esdispatcher.ServiceStopped)
+}
+
+// terminate closes the client since the connection was terminated and won't be re-established. The
+// registrations are notified that they were closed due to ConnectionTerminated (see esdispatcher.WithCloseNotification).
+func (c *Client) terminate() {
+	c.close(esdispatcher.ConnectionTerminated)
+}
+
+func (c *Client) close(reason esdispatcher.CloseReason) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.respTimeout)
+	defer cancel()
+
+	if err := c.closeContext(ctx, reason); err != nil {
+		logger.Warnf("Error closing event client: %s", err)
+	}
+}
+
+// CloseContext closes the connection to the event server and deallocates all resources.
+// If the context is done before the dispatcher has processed the disconnect and stop
+// requests then the wait is abandoned, the dispatcher is forcibly stopped and ctx.Err()
+// is returned. Once this function is invoked the client may no longer be used.
+func (c *Client) CloseContext(ctx context.Context) error {
+	return c.closeContext(ctx, esdispatcher.ServiceStopped)
+}
+
+func (c *Client) closeContext(ctx context.Context, reason esdispatcher.CloseReason) error {
 	logger.Debugf("Attempting to close event client...")
 
 	if !c.setStoppped() {
 		// Already stopped
 		logger.Debugf("Client already stopped")
-		return
+		return nil
 	}
 
 	logger.Debugf("Stopping client...")
 
-	if c.connEventCh != nil {
-		close(c.connEventCh)
-	}
+	c.stopMonitor()
 
 	logger.Debugf("Sending disconnect request...")
 
-	errch := make(chan error)
-	c.Submit(dispatcher.NewDisconnectEvent(errch))
-	err := <-errch
-
-	if err != nil {
-		logger.Warnf("Received error from disconnect request: %s", err)
+	// The response channels are buffered so that the dispatcher
+	// doesn't block if we've stopped waiting for the response
+	errch := make(chan error, 1)
+	if err := c.request(ctx, dispatcher.NewDisconnectEvent(errch), errch); err != nil {
+		logger.Warnf("Error from disconnect request: %s", err)
 	} else {
 		logger.Debugf("Received success from disconnect request")
 	}
 
-	logger.Debugf("Stopping dispatcher...")
+	var ctxErr error
+	if ctx.Err() == nil {
+		logger.Debugf("Stopping dispatcher...")
+		stoperrch := make(chan error, 1)
+		stopEvent := esdispatcher.NewStopEvent(stoperrch)
+		stopEvent.Reason = reason
+		if err := c.request(ctx, stopEvent, stoperrch); err != nil {
+			logger.Warnf("Error from stop request: %s", err)
+		}
+	}
+	if ctx.Err() != nil {
+		logger.Warnf("Abandoned waiting for event client to close: %s. Forcibly stopping dispatcher.", ctx.Err())
+		ctxErr = ctx.Err()
+		c.ForceStop()
+	}
+
+	select {
+	case <-c.monitorDone:
+	case <-ctx.Done():
+		logger.Warnf("Abandoned waiting for connection monitor to exit: %s", ctx.Err())
+		ctxErr = ctx.Err()
+	}
 
-	c.Stop()
+	c.mustSetConnectionState(Disconnected, nil)
 
-	c.mustSetConnectionState(Disconnected)
+	for _, h := range c.afterCloseHandlers() {
+		h()
+	}
 
 	logger.Debugf("... event client is stopped")
+
+	return ctxErr
+}
+
+// CloseGracefully stops accepting new registrations and waits until every transaction status registration has
+// been sent its event (or maxWait elapses) before closing the client. The connection remains open while waiting
+// so that transactions that are about to be committed are still reported. The IDs of the transactions whose
+// status wasn't reported before the client was closed are returned. Once this function is invoked the client
+// may no longer be used.
+func (c *Client) CloseGracefully(maxWait time.Duration) []string {
+	unresolved := c.drain(maxWait)
+	if len(unresolved) > 0 {
+		logger.Warnf("Closing event client with %d unresolved transaction status registrations: %v", len(unresolved), unresolved)
+	}
+	c.Close()
+	return unresolved
+}
+
+// drain waits until there are no pending transaction status registrations or maxWait elapses,
+// returning the IDs of the transactions that are still pending
+func (c *Client) drain(maxWait time.Duration) []string {
+	if c.Stopped() {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), maxWait)
+	defer cancel()
+
+	if err := c.RejectRegistrations(ctx); err != nil {
+		logger.Warnf("Error rejecting registrations while draining event client: %s", err)
+	}
+
+	var pending []string
+	for {
+		counts, err := c.RegistrationCounts(ctx)
+		if err != nil {
+			logger.Warnf("Error getting registration counts while draining event client: %s", err)
+			return pending
+		}
+		pending = counts.PendingTxIDs
+		if len(pending) == 0 {
+			logger.Debugf("All transaction status registrations have been resolved")
+			return nil
+		}
+
+		logger.Debugf("Waiting for %d transaction status registrations to be resolved...", len(pending))
+
+		select {
+		case <-time.After(drainPollInterval):
+		case <-ctx.Done():
+			return pending
+		case <-c.stopch:
+			return pending
+		}
+	}
+}
+
+// Ping verifies that the connection to the event server is healthy, i.e. the client is connected and
+// the underlying stream hasn't terminated. An error is returned if the connection isn't healthy or if
+// the dispatcher doesn't respond within the given timeout. If the WithPingTriggersReconnect option was
+// provided and the connection isn't healthy then the client disconnects (and reconnects if reconnect is
+// enabled) in the same way as if the server had closed the connection.
+func (c *Client) Ping(timeout time.Duration) error {
+	if c.Stopped() {
+		return errors.New("event client is closed")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	errch := make(chan error, 1)
+	err := c.request(ctx, dispatcher.NewPingEvent(errch), errch)
+	if err == nil {
+		return nil
+	}
+
+	if err != ctx.Err() && c.pingReconnect && c.ConnectionState() == Connected {
+		logger.Warnf("Ping failed: %s. Disconnecting...", err)
+		if submitErr := c.Submit(dispatcher.NewDisconnectedEvent(err)); submitErr != nil {
+			logger.Warnf("Error submitting disconnected event: %s", submitErr)
+		}
+	}
+
+	return errors.WithMessage(err, "ping failed")
+}
+
+// Reconnect closes the connection to the event server and connects again straight away, for example, in
+// order to use a different type of connection. The beforeReconnect and afterConnect handlers are invoked
+// as for any other reconnect and registrations are retained. An error is returned if the client isn't
+// connected or if the connection attempt fails. If the attempt fails then the client behaves as if the
+// connection had been lost, i.e. it keeps trying to reconnect in the background if reconnect is enabled
+// and otherwise it's closed.
+func (c *Client) Reconnect() error {
+	if c.Stopped() {
+		return errors.New("event client is closed")
+	}
+
+	if !c.changeConnectionState(Connected, Disconnected, nil) {
+		return errors.Errorf("unable to reconnect event client since client is [%s]. Expecting client to be in state [%s]", c.ConnectionState(), Connected)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.respTimeout)
+	defer cancel()
+
+	errch := make(chan error, 1)
+	if err := c.request(ctx, dispatcher.NewDisconnectEvent(errch), errch); err != nil {
+		logger.Warnf("Error from disconnect request: %s", err)
+	}
+
+	err := c.connectAttempt(1, true)
+	if err == nil {
+		return nil
+	}
+
+	if c.reconn {
+		logger.Warnf("Reconnect failed: %s. Attempting to reconnect in the background...", err)
+		go c.reconnect()
+	} else if c.autoClose {
+		logger.Warnf("Reconnect failed: %s. Closing.", err)
+		go c.terminate()
+	} else {
+		logger.Warnf("Reconnect failed: %s. Remaining disconnected.", err)
+	}
+	return err
+}
+
+// WaitForState waits until the client is in the given connection state. If the client is already in the
+// given state then nil is returned immediately. Otherwise nil is returned as soon as the client enters the
+// state during the wait, even if the state subsequently changes (e.g. from Connected to Disconnected).
+// An error is returned if the state isn't reached within the given timeout or if the client is closed
+// while waiting for a state other than Disconnected.
+func (c *Client) WaitForState(state ConnectionState, timeout time.Duration) error {
+	c.stateMutex.Lock()
+	if c.ConnectionState() == state {
+		c.stateMutex.Unlock()
+		return nil
+	}
+	waiter := &stateWaiter{state: state, done: make(chan struct{})}
+	c.stateWaiters = append(c.stateWaiters, waiter)
+	c.stateMutex.Unlock()
+
+	var stopch <-chan struct{}
+	if state != Disconnected {
+		stopch = c.stopch
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-waiter.done:
+		return nil
+	case <-stopch:
+		c.removeStateWaiter(waiter)
+		return errors.Errorf("event client was closed while waiting for state [%s]", state)
+	case <-timer.C:
+		c.removeStateWaiter(waiter)
+		return errors.Errorf("timed out after %s waiting for event client to be [%s] - current state is [%s]", timeout, state, c.ConnectionState())
+	}
+}
+
+func (c *Client) removeStateWaiter(waiter *stateWaiter) {
+	c.stateMutex.Lock()
+	defer c.stateMutex.Unlock()
+
+	for i, w := range c.stateWaiters {
+		if w == waiter {
+			c.stateWaiters = append(c.stateWaiters[:i], c.stateWaiters[i+1:]...)
+			return
+		}
+	}
+}
+
+// notifyStateWaiters releases the callers of WaitForState that are waiting for the given state.
+// The state mutex must be held.
+func (c *Client) notifyStateWaiters(newState ConnectionState) {
+	waiters := c.stateWaiters[:0]
+	for _, w := range c.stateWaiters {
+		if w.state == newState {
+			close(w.done)
+		} else {
+			waiters = append(waiters, w)
+		}
+	}
+	c.stateWaiters = waiters
+}
+
+// connectAttempt resolves the peer to connect to (if a peer resolver was provided), invokes
+// the beforeReconnect handlers when reconnecting and then attempts to connect to the peer.
+// If the attempt fails then the peer is passed to the resolver as the failed peer on the next attempt.
+func (c *Client) connectAttempt(attempt uint, reconnecting bool) error {
+	peer, err := c.resolvePeer()
+	if err != nil {
+		return errors.WithMessage(err, "error resolving peer")
+	}
+
+	if reconnecting {
+		c.recordReconnectAttempt()
+		if err := c.invokeBeforeReconnectHandlers(peer); err != nil {
+			c.setFailedPeer(peer)
+			return errors.WithMessage(err, "reconnect rejected by beforeReconnect handler")
+		}
+	}
+
+	if err := c.connect(attempt, peer); err != nil {
+		c.setFailedPeer(peer)
+		return err
+	}
+	return nil
+}
+
+// resolvePeer returns the peer to connect to or nil if no peer resolver was provided
+func (c *Client) resolvePeer() (fab.Peer, error) {
+	if c.peerResolver == nil {
+		return nil, nil
+	}
+
+	c.RLock()
+	failed := c.failedPeer
+	c.RUnlock()
+
+	peer, err := c.peerResolver.Resolve(failed)
+	if err != nil {
+		return nil, err
+	}
+	if peer == nil {
+		return nil, errors.New("peer resolver returned no peer")
+	}
+
+	logger.Debugf("Resolved peer [%s]", peer.URL())
+	return peer, nil
+}
+
+// LastDisconnect returns the reason (which may be nil) for the last time that the connection was lost along
+// with the time at which it was lost. False is returned if the client hasn't been disconnected. Note that
+// failed connection attempts and the client giving up reconnecting are not recorded as disconnects.
+func (c *Client) LastDisconnect() (error, time.Time, bool) {
+	c.RLock()
+	defer c.RUnlock()
+
+	if c.lastDisconnect == nil {
+		return nil, time.Time{}, false
+	}
+	return c.lastDisconnect.err, c.lastDisconnect.time, true
+}
+
+func (c *Client) setLastDisconnect(err error, disconnectedAt time.Time) {
+	c.Lock()
+	defer c.Unlock()
+	c.lastDisconnect = &disconnectInfo{err: err, time: disconnectedAt}
+}
+
+func (c *Client) lastDisconnectErr() error {
+	err, _, _ := c.LastDisconnect()
+	return err
+}
+
+// resolveEndpoint returns the URL and options for the connection from the endpoint provider. An
+// empty URL (and no options) are returned if no endpoint provider was provided (see WithEndpointProvider).
+func (c *Client) resolveEndpoint() (string, []options.Opt, error) {
+	if c.endpointProvider == nil {
+		return "", nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.respTimeout)
+	defer cancel()
+
+	url, opts, err := c.endpointProvider(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	if url == "" {
+		return "", nil, errors.New("endpoint provider returned no URL")
+	}
+
+	logger.Debugf("Resolved event endpoint [%s]", url)
+	return url, opts, nil
+}
+
+func (c *Client) setFailedPeer(peer fab.Peer) {
+	c.Lock()
+	defer c.Unlock()
+	c.failedPeer = peer
+}
+
+// setConnectedPeer records the peer that the client is connected to along with the peer that was
+// returned by the peer resolver (which differs from the connected peer if an endpoint provider
+// overrode the URL of the resolved peer)
+func (c *Client) setConnectedPeer(peer, resolved fab.Peer) {
+	c.Lock()
+	defer c.Unlock()
+	c.connectedPeer = peer
+	c.resolvedPeer = resolved
+	c.failedPeer = nil
+}
+
+// connectionLost records the resolved peer of the connection as the failed peer
+// so that the peer resolver fails over to another peer when reconnecting
+func (c *Client) connectionLost() {
+	c.Lock()
+	defer c.Unlock()
+	c.failedPeer = c.resolvedPeer
 }
 
-func (c *Client) connect() error {
+func (c *Client) connect(attempt uint, peer fab.Peer) error {
 	if c.Stopped() {
 		return errors.New("event client is closed")
 	}
@@ -151,19 +701,38 @@ func (c *Client) connect() error {
 		return errors.Errorf("unable to connect event client since client is [%s]. Expecting client to be in state [%s]", c.ConnectionState(), Disconnected)
 	}
 
+	url, connOpts, err := c.resolveEndpoint()
+	if err != nil {
+		err = errors.WithMessage(err, "error resolving event endpoint")
+		c.connectFailed(err)
+		return err
+	}
+
 	logger.Debugf("Submitting connection request...")
 
-	errch := make(chan error)
-	c.Submit(dispatcher.NewConnectEvent(errch))
+	// The channel is buffered so that the dispatcher doesn't block if the connect timeout expires
+	errch := make(chan error, 1)
+	connectEvent := dispatcher.NewConnectEvent(errch)
+	connectEvent.Peer = peer
+	connectEvent.URL = url
+	connectEvent.Opts = connOpts
+	if err := c.Submit(connectEvent); err != nil {
+		err = errors.WithMessage(err, "error submitting connection request")
+		c.connectFailed(err)
+		return err
+	}
 
-	err := <-errch
+	err = c.waitForConnectResponse(errch)
 
 	if err != nil {
-		c.mustSetConnectionState(Disconnected)
+		c.connectFailed(err)
 		logger.Debugf("... got error in connection response: %s", err)
 		return err
 	}
 
+	// The dispatcher sets the connected peer before responding
+	c.setConnectedPeer(connectEvent.ConnectedPeer, peer)
+
 	c.registerOnce.Do(func() {
 		logger.Debugf("Submitting connection event registration...")
 		_, eventch, err := c.RegisterConnectionEvent()
@@ -172,16 +741,15 @@ func (c *Client) connect() error {
 			c.Close()
 		}
 		c.connEvent = eventch
-		go c.monitorConnection()
+		c.startMonitor()
 	})
 
-	handler := c.afterConnectHandler()
-	if handler != nil {
-		if err := handler(); err != nil {
-			logger.Warnf("Error invoking afterConnect handler: %s. Disconnecting...", err)
-
-			c.Submit(dispatcher.NewDisconnectEvent(errch))
+	if err := c.invokeAfterConnectHandlers(); err != nil {
+		logger.Warnf("Error invoking afterConnect handler: %s. Disconnecting...", err)
 
+		if err := c.Submit(dispatcher.NewDisconnectEvent(errch)); err != nil {
+			logger.Warnf("Error submitting disconnect request: %s", err)
+		} else {
 			select {
 			case disconnErr := <-errch:
 				if disconnErr != nil {
@@ -192,22 +760,73 @@ func (c *Client) connect() error {
 			case <-time.After(c.respTimeout):
 				logger.Warnf("Timed out waiting for disconnect response")
 			}
+		}
 
-			c.setConnectionState(Connecting, Disconnected)
+		c.connectFailed(err)
 
-			return errors.WithMessage(err, "error invoking afterConnect handler")
-		}
+		return errors.WithMessage(err, "error invoking afterConnect handler")
 	}
 
 	c.setConnectionState(Connecting, Connected)
 
 	logger.Debugf("Submitting connected event")
-	c.Submit(dispatcher.NewConnectedEvent())
+	connectedEvent := dispatcher.NewConnectedEvent()
+	connectedEvent.Attempt = attempt
+	c.Submit(connectedEvent)
 
 	return err
 }
 
-func (c *Client) connectWithRetry(maxAttempts uint, timeBetweenAttempts time.Duration) error {
+// waitForConnectResponse waits for the response to a connect request. If the connect timeout
+// (see WithConnectTimeout) expires first then a DisconnectEvent is submitted so that the dispatcher
+// abandons the pending connection attempt (its connection is closed when the attempt completes), and
+// a timeout error is returned.
+func (c *Client) waitForConnectResponse(errch <-chan error) error {
+	if c.connectTimeout <= 0 {
+		return <-errch
+	}
+
+	timer := time.NewTimer(c.connectTimeout)
+	defer timer.Stop()
+
+	select {
+	case err := <-errch:
+		return err
+	case <-timer.C:
+		logger.Warnf("Timed out after %s waiting for connection response. Abandoning connection attempt.", c.connectTimeout)
+		if err := c.Submit(dispatcher.NewDisconnectEvent(make(chan error, 1))); err != nil {
+			logger.Warnf("Error submitting disconnect request for abandoned connection attempt: %s", err)
+		}
+		return errors.Errorf("timed out after %s waiting for connection response", c.connectTimeout)
+	}
+}
+
+// connectFailed restores the Disconnected state after a failed connection attempt. The state is set
+// regardless of the current state since the state may have been changed concurrently (for example, by the
+// connection monitor) and the client would otherwise be left in an inconsistent state, in which case
+// subsequent connection attempts would fail. If the client was stopped then the state is left to Close.
+func (c *Client) connectFailed(err error) {
+	if c.Stopped() {
+		return
+	}
+	c.mustSetConnectionState(Disconnected, err)
+}
+
+// ResetConnectionState sets the connection state to Disconnected so that Connect may be called again.
+// It's an escape hatch for recovering a client whose connection state is inconsistent, e.g. if the client
+// is in the Connecting state even though no connection attempt is in progress. It should not be invoked
+// while a connection attempt is in progress. Nothing is done if the client is closed.
+func (c *Client) ResetConnectionState() {
+	if c.Stopped() {
+		return
+	}
+	logger.Warnf("Resetting connection state from [%s] to [%s]", c.ConnectionState(), Disconnected)
+	c.mustSetConnectionState(Disconnected, nil)
+}
+
+// connectWithRetry attempts to connect until either maxAttempts or maxDuration (if non-zero) is reached.
+// The client gives up on reaching maxDuration if the next attempt wouldn't start within the duration.
+func (c *Client) connectWithRetry(maxAttempts uint, maxDuration, timeBetweenAttempts time.Duration, reconnecting bool) error {
 	if c.Stopped() {
 		return errors.New("event client is closed")
 	}
@@ -215,17 +834,27 @@ func (c *Client) connectWithRetry(maxAttempts uint, timeBetweenAttempts time.Dur
 		timeBetweenAttempts = time.Second
 	}
 
+	start := c.now()
 	var attempts uint
 	for {
 		attempts++
 		logger.Debugf("Attempt #%d to connect...", attempts)
-		if err := c.connect(); err != nil {
+		if err := c.connectAttempt(attempts, reconnecting); err != nil {
 			logger.Warnf("... connection attempt failed: %s", err)
 			if maxAttempts > 0 && attempts >= maxAttempts {
 				logger.Warnf("maximum connect attempts exceeded")
-				return errors.New("maximum connect attempts exceeded")
+				return &ConnectAttemptsExceededError{Attempts: attempts, LastDisconnect: c.lastDisconnectErr()}
+			}
+			delay := c.retryDelay(attempts, timeBetweenAttempts)
+			if elapsed := c.now().Sub(start); maxDuration > 0 && elapsed+delay >= maxDuration {
+				logger.Warnf("maximum reconnect duration exceeded")
+				return &ConnectDurationExceededError{Attempts: attempts, Duration: elapsed, LastDisconnect: c.lastDisconnectErr()}
+			}
+			logger.Debugf("Waiting %s before next connection attempt...", delay)
+			if !c.pause(delay) {
+				logger.Debugf("Event client was closed while waiting to connect")
+				return errors.New("event client is closed")
 			}
-			time.Sleep(timeBetweenAttempts)
 		} else {
 			logger.Debugf("... connect succeeded.")
 			return nil
@@ -233,34 +862,129 @@ func (c *Client) connectWithRetry(maxAttempts uint, timeBetweenAttempts time.Dur
 	}
 }
 
+// pause waits for the given duration. False is returned if the client
+// is closed before the duration elapses.
+func (c *Client) pause(d time.Duration) bool {
+	select {
+	case <-c.after(d):
+		return true
+	case <-c.stopch:
+		return false
+	}
+}
+
+// retryDelay returns the time to wait after the given number of failed connection attempts.
+// If backoff is not configured then the fixed time between attempts is returned.
+func (c *Client) retryDelay(failedAttempts uint, timeBetweenAttempts time.Duration) time.Duration {
+	if c.backoffInitial <= 0 {
+		return timeBetweenAttempts
+	}
+
+	// Without a maximum the delay may overflow (or even be +Inf), so
+	// it's capped at the largest possible duration
+	maxDelay := float64(math.MaxInt64)
+	if c.backoffMax > 0 {
+		maxDelay = float64(c.backoffMax)
+	}
+
+	// The cap is applied before the jitter so that clients that have reached
+	// the maximum delay are still spread out
+	delay := math.Min(float64(c.backoffInitial)*math.Pow(c.backoffMultiplier, float64(failedAttempts-1)), maxDelay)
+	if c.backoffJitter > 0 {
+		delay += delay * c.backoffJitter * (2*rand.Float64() - 1)
+	}
+	if delay >= float64(math.MaxInt64) {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(delay)
+}
+
 // RegisterBlockEvent registers for block events. If the client is not authorized to receive
-// block events then an error is returned.
+// block events, or block events are not permitted (see WithPermittedEventTypes), then an error is returned.
 func (c *Client) RegisterBlockEvent(filter ...fab.BlockFilter) (fab.Registration, <-chan *fab.BlockEvent, error) {
-	if !c.permitBlockEvents {
+	if !c.BlockEventsPermitted() {
 		return nil, nil, errors.New("block events are not permitted")
 	}
+	if err := c.checkEventTypePermitted(BlockEventType); err != nil {
+		return nil, nil, err
+	}
 	return c.Service.RegisterBlockEvent(filter...)
 }
 
+// RegisterFilteredBlockEvent registers for filtered block events. If filtered block
+// events are not permitted (see WithPermittedEventTypes) then an error is returned.
+func (c *Client) RegisterFilteredBlockEvent() (fab.Registration, <-chan *fab.FilteredBlockEvent, error) {
+	if err := c.checkEventTypePermitted(FilteredBlockEventType); err != nil {
+		return nil, nil, err
+	}
+	return c.Service.RegisterFilteredBlockEvent()
+}
+
+// RegisterChaincodeEvent registers for chaincode events. If chaincode events
+// are not permitted (see WithPermittedEventTypes) then an error is returned.
+func (c *Client) RegisterChaincodeEvent(ccID, eventFilter string, opts ...options.Opt) (fab.Registration, <-chan *fab.CCEvent, error) {
+	if err := c.checkEventTypePermitted(ChaincodeEventType); err != nil {
+		return nil, nil, err
+	}
+	return c.Service.RegisterChaincodeEvent(ccID, eventFilter, opts...)
+}
+
+// RegisterTxStatusEvent registers for transaction status events. If transaction status
+// events are not permitted (see WithPermittedEventTypes) then an error is returned.
+func (c *Client) RegisterTxStatusEvent(txID string) (fab.Registration, <-chan *fab.TxStatusEvent, error) {
+	if err := c.checkEventTypePermitted(TxStatusEventType); err != nil {
+		return nil, nil, err
+	}
+	return c.Service.RegisterTxStatusEvent(txID)
+}
+
+// EventTypePermitted returns true if registrations for the given type of event are permitted (see
+// WithPermittedEventTypes). All types of event are permitted if the permitted event types weren't provided.
+func (c *Client) EventTypePermitted(eventType EventType) bool {
+	return c.permittedEventTypes == nil || c.permittedEventTypes[eventType]
+}
+
+func (c *Client) checkEventTypePermitted(eventType EventType) error {
+	if !c.EventTypePermitted(eventType) {
+		return errors.Errorf("%s events are not permitted", eventType)
+	}
+	return nil
+}
+
+// SetPermitBlockEvents sets whether the client is authorized to receive block events. This allows
+// an event client implementation to change the type of connection, for example, from filtered blocks
+// to full blocks. Registrations that already exist are not affected.
+func (c *Client) SetPermitBlockEvents(value bool) {
+	var permit int32
+	if value {
+		permit = 1
+	}
+	atomic.StoreInt32(&c.permitBlockEvents, permit)
+}
+
+// BlockEventsPermitted returns true if the client is authorized to receive block events
+func (c *Client) BlockEventsPermitted() bool {
+	return atomic.LoadInt32(&c.permitBlockEvents) == 1
+}
+
 // RegisterConnectionEvent registers a connection event. The returned
 // ConnectionEvent channel will be called whenever the client clients or disconnects
-// from the event server
+// from the event server. Any number of registrations may be made. The registration
+// is removed (and the channel is closed) with Unregister.
 func (c *Client) RegisterConnectionEvent() (fab.Registration, chan *fab.ConnectionEvent, error) {
 	if c.Stopped() {
 		return nil, nil, errors.New("event client is closed")
 	}
 
 	eventch := make(chan *fab.ConnectionEvent, c.eventConsumerBufferSize)
-	errch := make(chan error)
-	regch := make(chan fab.Registration)
-	c.Submit(dispatcher.NewRegisterConnectionEvent(eventch, regch, errch))
+	errch := make(chan error, 1)
+	regch := make(chan fab.Registration, 1)
 
-	select {
-	case reg := <-regch:
-		return reg, eventch, nil
-	case err := <-errch:
+	reg, err := c.SubmitRegistration("registering for connection events", dispatcher.NewRegisterConnectionEvent(eventch, regch, errch), regch, errch)
+	if err != nil {
 		return nil, nil, err
 	}
+	return reg, eventch, nil
 }
 
 // Stopped returns true if the client has been stopped (disconnected)
@@ -281,17 +1005,194 @@ func (c *Client) ConnectionState() ConnectionState {
 // setConnectionState sets the connection state only if the given currentState
 // matches the actual state. True is returned if the connection state was successfully set.
 func (c *Client) setConnectionState(currentState, newState ConnectionState) bool {
-	return atomic.CompareAndSwapInt32(&c.connectionState, int32(currentState), int32(newState))
+	return c.changeConnectionState(currentState, newState, nil)
+}
+
+// changeConnectionState is the same as setConnectionState except that the reason
+// for the state change is passed to the connection state listener.
+func (c *Client) changeConnectionState(currentState, newState ConnectionState, err error) bool {
+	c.stateMutex.Lock()
+	defer c.stateMutex.Unlock()
+
+	if !atomic.CompareAndSwapInt32(&c.connectionState, int32(currentState), int32(newState)) {
+		return false
+	}
+	c.notifyStateChange(currentState, newState, err)
+	return true
+}
+
+func (c *Client) mustSetConnectionState(newState ConnectionState, err error) {
+	c.stateMutex.Lock()
+	defer c.stateMutex.Unlock()
+
+	oldState := ConnectionState(atomic.SwapInt32(&c.connectionState, int32(newState)))
+	c.notifyStateChange(oldState, newState, err)
+}
+
+// notifyStateChange queues the state change for the connection state listener. The
+// queue is drained by a single Go routine so that the listener never blocks the
+// caller and notifications are serialized. The state mutex must be held so that
+// state changes are queued in the order in which they occurred.
+func (c *Client) notifyStateChange(oldState, newState ConnectionState, err error) {
+	if oldState == newState {
+		return
+	}
+
+	c.recordStateChange(oldState, newState)
+	c.notifyStateWaiters(newState)
+
+	listener := c.connectionStateListener()
+	if listener == nil && c.metricsObserver == nil {
+		return
+	}
+
+	change := stateChange{oldState: oldState, newState: newState, err: err, listener: listener}
+	if c.metricsObserver != nil {
+		change.observer = c.metricsObserver
+		change.metrics = c.snapshotMetrics()
+	}
+
+	c.stateChanges = append(c.stateChanges, change)
+	if !c.notifyingState {
+		c.notifyingState = true
+		go c.dispatchStateChanges()
+	}
 }
 
-func (c *Client) mustSetConnectionState(newState ConnectionState) {
-	atomic.StoreInt32(&c.connectionState, int32(newState))
+func (c *Client) dispatchStateChanges() {
+	for {
+		c.stateMutex.Lock()
+		if len(c.stateChanges) == 0 {
+			c.notifyingState = false
+			c.stateMutex.Unlock()
+			return
+		}
+		change := c.stateChanges[0]
+		c.stateChanges = c.stateChanges[1:]
+		c.stateMutex.Unlock()
+
+		if change.listener != nil {
+			logger.Debugf("Notifying listener of connection state change from [%s] to [%s]", change.oldState, change.newState)
+			change.listener(change.oldState, change.newState, change.err)
+		}
+		if change.observer != nil {
+			change.observer(change.metrics)
+		}
+	}
+}
+
+// request submits the given request event and waits for the response on the given error
+// channel. If the context is done first then ctx.Err() is returned.
+func (c *Client) request(ctx context.Context, event interface{}, errch <-chan error) error {
+	if err := c.SubmitContext(ctx, event); err != nil {
+		return err
+	}
+
+	select {
+	case err := <-errch:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// startMonitor starts the connection monitor unless the client has been stopped
+func (c *Client) startMonitor() {
+	c.monitorMutex.Lock()
+	defer c.monitorMutex.Unlock()
+
+	if c.Stopped() {
+		logger.Debugf("Not starting connection monitor since the client is stopped")
+		return
+	}
+
+	c.monitorStarted = true
+	go c.monitorConnection()
+}
+
+// stopMonitor tells the connection monitor to exit. The monitor is the only sender on the
+// connection event channel so it closes the channel when it exits. If the monitor was never
+// started then the channel is closed here. The client must already be marked as stopped
+// so that the monitor isn't started afterward.
+func (c *Client) stopMonitor() {
+	c.monitorMutex.Lock()
+	defer c.monitorMutex.Unlock()
+
+	close(c.stopch)
+
+	if !c.monitorStarted {
+		c.closeConnEventCh()
+		close(c.monitorDone)
+	}
+}
+
+func (c *Client) closeConnEventCh() {
+	if c.connEventCh != nil {
+		close(c.connEventCh)
+	}
+}
+
+// DroppedConnectionEvents returns the number of connection events that couldn't be sent to the
+// connection event channel (see WithConnectionEvent) within the event consumer timeout
+func (c *Client) DroppedConnectionEvents() uint64 {
+	return atomic.LoadUint64(&c.droppedConnEvents)
+}
+
+// sendConnectionEvent sends the event to the subscriber's connection event channel according to the
+// event consumer timeout. False is returned if the client was stopped while waiting to send the event.
+func (c *Client) sendConnectionEvent(event *fab.ConnectionEvent) bool {
+	logger.Debugln("Sending connection event to subscriber.")
+
+	if c.eventConsumerTimeout < 0 {
+		select {
+		case c.connEventCh <- event:
+		default:
+			logger.Warnf("Unable to send to connection event channel. Dropping event.")
+			atomic.AddUint64(&c.droppedConnEvents, 1)
+		}
+		return true
+	}
+
+	var timeout <-chan time.Time
+	if c.eventConsumerTimeout > 0 {
+		timer := time.NewTimer(c.eventConsumerTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case c.connEventCh <- event:
+	case <-timeout:
+		logger.Warnf("Timed out sending connection event. Dropping event.")
+		atomic.AddUint64(&c.droppedConnEvents, 1)
+	case <-c.stopch:
+		return false
+	}
+	return true
 }
 
 func (c *Client) monitorConnection() {
 	logger.Debugf("Monitoring connection")
+	defer func() {
+		c.closeConnEventCh()
+		close(c.monitorDone)
+	}()
+
 	for {
-		event, ok := <-c.connEvent
+		var event *fab.ConnectionEvent
+		var ok bool
+		select {
+		case event, ok = <-c.connEvent:
+		case <-c.debounce.windowch:
+			if !c.sendConnectionEvents(c.closeDebounceWindow()) {
+				logger.Debugln("Event client has been stopped.")
+				return
+			}
+			continue
+		case <-c.stopch:
+			logger.Debugln("Event client has been stopped.")
+			return
+		}
 		if !ok {
 			logger.Debugln("Connection has closed.")
 			break
@@ -302,25 +1203,42 @@ func (c *Client) monitorConnection() {
 			break
 		}
 
-		if c.connEventCh != nil {
-			logger.Debugln("Sending connection event to subscriber.")
-			c.connEventCh <- event
+		if !c.sendConnectionEvents(c.debounceConnectionEvent(event)) {
+			logger.Debugln("Event client has been stopped.")
+			return
+		}
+
+		if !event.Connected && !event.Terminal {
+			c.setLastDisconnect(event.Err, event.DisconnectedTime)
 		}
 
 		if event.Connected {
 			logger.Debugf("Event client has connected")
+		} else if event.Terminal {
+			if c.autoClose {
+				logger.Warnf("Event client won't reconnect. Terminating: %s", event.Err)
+				go c.terminate()
+				break
+			}
+			logger.Warnf("Event client won't reconnect. Remaining disconnected: %s", event.Err)
+			c.changeConnectionState(Connected, Disconnected, event.Err)
 		} else if c.reconn {
 			logger.Warnf("Event client has disconnected. Details: %s", event.Err)
-			if c.setConnectionState(Connected, Disconnected) {
+			if c.changeConnectionState(Connected, Disconnected, event.Err) {
+				c.connectionLost()
 				logger.Warnf("Attempting to reconnect...")
 				go c.reconnect()
-			} else if c.setConnectionState(Connecting, Disconnected) {
+			} else if c.changeConnectionState(Connecting, Disconnected, event.Err) {
 				logger.Warnf("Reconnect already in progress. Setting state to disconnected")
 			}
 		} else {
-			logger.Debugf("Event client has disconnected. Terminating: %s", event.Err)
-			go c.Close()
-			break
+			c.changeConnectionState(Connected, Disconnected, event.Err)
+			if c.autoClose {
+				logger.Debugf("Event client has disconnected. Terminating: %s", event.Err)
+				go c.terminate()
+				break
+			}
+			logger.Debugf("Event client has disconnected. Remaining disconnected: %s", event.Err)
 		}
 	}
 	logger.Debugf("Exiting connection monitor")
@@ -328,21 +1246,44 @@ func (c *Client) monitorConnection() {
 
 func (c *Client) reconnect() {
 	logger.Debugf("Waiting %s before attempting to reconnect event client...", c.reconnInitialDelay)
-	time.Sleep(c.reconnInitialDelay)
+	if !c.pause(c.reconnInitialDelay) {
+		logger.Debugf("Event client was closed while waiting to reconnect")
+		return
+	}
 
 	logger.Debugf("Attempting to reconnect event client...")
 
-	handler := c.beforeReconnectHandler()
-	if handler != nil {
-		if err := handler(); err != nil {
-			logger.Errorf("Error invoking beforeReconnect handler: %s", err)
+	if err := c.connectWithRetry(c.maxReconnAttempts, c.maxReconnDuration, c.timeBetweenConnAttempts, true); err != nil {
+		if c.Stopped() {
+			logger.Debugf("Event client was closed while reconnecting")
 			return
 		}
+
+		logger.Warnf("Could not reconnect event client: %s", err)
+
+		// The connection monitor sends the terminal connection event to the subscriber and then
+		// closes the client (unless the client was created with WithAutoCloseOnDisconnect(false))
+		disconnected := dispatcher.NewDisconnectedEvent(err)
+		disconnected.Terminal = true
+		if err := c.Submit(disconnected); err != nil {
+			logger.Warnf("Error submitting terminal disconnected event: %s", err)
+			c.terminate()
+		}
 	}
+}
 
-	if err := c.connectWithRetry(c.maxReconnAttempts, c.timeBetweenConnAttempts); err != nil {
-		logger.Warnf("Could not reconnect event client: %s. Closing.", err)
-		c.Close()
+func (t EventType) String() string {
+	switch t {
+	case BlockEventType:
+		return "block"
+	case FilteredBlockEventType:
+		return "filtered block"
+	case TxStatusEventType:
+		return "transaction status"
+	case ChaincodeEventType:
+		return "chaincode"
+	default:
+		return "undefined"
 	}
 }
 
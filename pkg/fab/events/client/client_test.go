@@ -1,3 +1,4 @@
+//go:build testing
 // +build testing
 
 /*
@@ -9,13 +10,17 @@ SPDX-License-Identifier: Apache-2.0
 package client
 
 import (
+	stdcontext "context"
 	"fmt"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/hyperledger/fabric-sdk-go/pkg/context"
 
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
 	"github.com/hyperledger/fabric-sdk-go/pkg/options"
 
@@ -23,6 +28,9 @@ import (
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/client/dispatcher"
 	clientmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/events/client/mocks"
 	mockconn "github.com/hyperledger/fabric-sdk-go/pkg/fab/events/client/mocks"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/client/mocks/scripted"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/client/peerresolver"
+	eventservice "github.com/hyperledger/fabric-sdk-go/pkg/fab/events/service"
 	esdispatcher "github.com/hyperledger/fabric-sdk-go/pkg/fab/events/service/dispatcher"
 	servicemocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/events/service/mocks"
 	fabmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
@@ -94,46 +102,1732 @@ func TestFailConnect(t *testing.T) {
 	}
 }
 
+func TestConnectBackoff(t *testing.T) {
+	cp := mockconn.NewProviderFactory()
+	connectch := make(chan *fab.ConnectionEvent, 1)
+
+	eventClient, _, err := newClientWithMockConnAndOpts(
+		"mychannel", newMockContext(),
+		cp.FlakeyProvider(
+			mockconn.NewConnectResults(
+				mockconn.NewConnectResult(mockconn.FourthAttempt, mockconn.SucceedResult),
+			),
+			mockconn.WithLedger(servicemocks.NewMockLedger(servicemocks.BlockEventFactory)),
+		),
+		clientProvider,
+		clientmocks.NewDiscoveryService(peer1, peer2),
+		[]options.Opt{
+			WithMaxConnectAttempts(5),
+			WithReconnectBackoff(100*time.Millisecond, 300*time.Millisecond, 2, 0),
+			WithConnectionEvent(connectch),
+		},
+	)
+	if err != nil {
+		t.Fatalf("error creating client: %s", err)
+	}
+
+	var delays []time.Duration
+	eventClient.after = func(d time.Duration) <-chan time.Time {
+		delays = append(delays, d)
+		return elapsed()
+	}
+
+	if err := eventClient.Connect(); err != nil {
+		t.Fatalf("error connecting client: %s", err)
+	}
+	defer eventClient.Close()
+
+	expected := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond}
+	if len(delays) != len(expected) {
+		t.Fatalf("expecting %d delays but got %d: %v", len(expected), len(delays), delays)
+	}
+	for i, d := range expected {
+		if delays[i] != d {
+			t.Fatalf("expecting delay #%d to be %s but got %s", i+1, d, delays[i])
+		}
+	}
+
+	select {
+	case event := <-connectch:
+		if !event.Connected || event.Attempt != 4 {
+			t.Fatalf("expecting connected event for attempt 4 but got connected: %t, attempt: %d", event.Connected, event.Attempt)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for connected event")
+	}
+}
+
+// elapsed returns a channel on which the current time is ready to be received
+func elapsed() <-chan time.Time {
+	ch := make(chan time.Time, 1)
+	ch <- time.Now()
+	return ch
+}
+
+// fakeClock is a clock that's advanced by waiting
+type fakeClock struct {
+	mutex sync.Mutex
+	now   time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = c.now.Add(d)
+	return elapsed()
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestConnectAttemptsExceeded(t *testing.T) {
+	eventClient, _, err := newClientWithMockConnAndOpts(
+		"mychannel", newMockContext(),
+		mockconn.NewProviderFactory().FlakeyProvider(mockconn.NewConnectResults()),
+		clientProvider,
+		clientmocks.NewDiscoveryService(peer1, peer2),
+		[]options.Opt{
+			WithMaxConnectAttempts(3),
+		},
+	)
+	if err != nil {
+		t.Fatalf("error creating client: %s", err)
+	}
+	defer eventClient.Close()
+
+	clock := &fakeClock{now: time.Now()}
+	eventClient.now = clock.Now
+	eventClient.after = clock.After
+
+	err = eventClient.Connect()
+	attemptsErr, ok := err.(*ConnectAttemptsExceededError)
+	if !ok {
+		t.Fatalf("expecting ConnectAttemptsExceededError but got: %v", err)
+	}
+	if attemptsErr.Attempts != 3 {
+		t.Fatalf("expecting 3 attempts but got %d", attemptsErr.Attempts)
+	}
+}
+
+func TestMaxReconnectDuration(t *testing.T) {
+	connectch := make(chan *fab.ConnectionEvent, 10)
+
+	eventClient, _, err := newClientWithMockConnAndOpts(
+		"mychannel", newMockContext(),
+		mockconn.NewProviderFactory().FlakeyProvider(
+			mockconn.NewConnectResults(
+				mockconn.NewConnectResult(mockconn.FirstAttempt, mockconn.SucceedResult),
+			),
+			mockconn.WithLedger(servicemocks.NewMockLedger(servicemocks.BlockEventFactory)),
+		),
+		clientProvider,
+		clientmocks.NewDiscoveryService(peer1, peer2),
+		[]options.Opt{
+			WithReconnect(true),
+			WithReconnectInitialDelay(0),
+			WithMaxReconnectAttempts(100),
+			WithMaxReconnectDuration(time.Minute),
+			WithTimeBetweenConnectAttempts(5 * time.Second),
+			WithConnectionEvent(connectch),
+		},
+	)
+	if err != nil {
+		t.Fatalf("error creating client: %s", err)
+	}
+	defer eventClient.Close()
+
+	clock := &fakeClock{now: time.Now()}
+	eventClient.now = clock.Now
+	eventClient.after = clock.After
+
+	if err := eventClient.Connect(); err != nil {
+		t.Fatalf("error connecting client: %s", err)
+	}
+
+	select {
+	case event := <-connectch:
+		if !event.Connected {
+			t.Fatalf("expecting connected event")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for connected event")
+	}
+
+	// All reconnect attempts fail
+	if err := eventClient.Submit(dispatcher.NewDisconnectedEvent(errors.New("simulated disconnect"))); err != nil {
+		t.Fatalf("error submitting disconnected event: %s", err)
+	}
+
+	var terminal *fab.ConnectionEvent
+	for terminal == nil {
+		select {
+		case event, ok := <-connectch:
+			if !ok {
+				t.Fatalf("connection event channel closed before the terminal event was received")
+			}
+			if event.Terminal {
+				terminal = event
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for terminal connection event")
+		}
+	}
+
+	durationErr, ok := terminal.Err.(*ConnectDurationExceededError)
+	if !ok {
+		t.Fatalf("expecting ConnectDurationExceededError but got: %v", terminal.Err)
+	}
+
+	// The attempts are 5s apart, so a maximum duration of one minute allows for 12 attempts
+	if durationErr.Attempts != 12 {
+		t.Fatalf("expecting the client to give up after 12 attempts but got %d", durationErr.Attempts)
+	}
+	if durationErr.Duration != 55*time.Second {
+		t.Fatalf("expecting the client to give up after 55s but got %s", durationErr.Duration)
+	}
+
+	// The client closes itself after the terminal event
+	for i := 0; i < 50 && !eventClient.Stopped(); i++ {
+		time.Sleep(100 * time.Millisecond)
+	}
+	if !eventClient.Stopped() {
+		t.Fatalf("expecting client to be stopped after giving up reconnecting")
+	}
+}
+
+// peerProvider is a connection provider that fails to connect to the given down peers
+// and records the peers to which a connection was attempted
+type peerProvider struct {
+	mutex     sync.Mutex
+	down      map[string]bool
+	attempted []string
+}
+
+func newPeerProvider(down ...fab.Peer) *peerProvider {
+	p := &peerProvider{down: make(map[string]bool)}
+	for _, peer := range down {
+		p.down[peer.URL()] = true
+	}
+	return p
+}
+
+func (p *peerProvider) provider() api.ConnectionProvider {
+	return func(channelID string, ctx context.Context, peer fab.Peer) (api.Connection, error) {
+		p.mutex.Lock()
+		defer p.mutex.Unlock()
+
+		p.attempted = append(p.attempted, peer.URL())
+		if p.down[peer.URL()] {
+			return nil, errors.Errorf("simulating peer [%s] down", peer.URL())
+		}
+		return mockconn.NewMockConnection(mockconn.WithLedger(servicemocks.NewMockLedger(servicemocks.BlockEventFactory))), nil
+	}
+}
+
+func (p *peerProvider) attempts() []string {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return append([]string{}, p.attempted...)
+}
+
+func TestPeerResolverFailover(t *testing.T) {
+	t.Run("RoundRobin", func(t *testing.T) {
+		testPeerResolverFailover(t, peerresolver.NewRoundRobin(peer1, peer2))
+	})
+	t.Run("Priority", func(t *testing.T) {
+		testPeerResolverFailover(t, peerresolver.NewPriority(peer1, peer2))
+	})
+}
+
+func testPeerResolverFailover(t *testing.T, resolver peerresolver.PeerResolver) {
+	// The first peer is permanently down
+	connProvider := newPeerProvider(peer1)
+
+	eventClient, _, err := newClientWithMockConnAndOpts(
+		"mychannel", newMockContext(),
+		connProvider.provider(),
+		clientProvider,
+		clientmocks.NewDiscoveryService(peer1, peer2),
+		[]options.Opt{
+			WithMaxConnectAttempts(3),
+			WithPeerResolver(resolver),
+		},
+	)
+	if err != nil {
+		t.Fatalf("error creating client: %s", err)
+	}
+	defer eventClient.Close()
+
+	clock := &fakeClock{now: time.Now()}
+	eventClient.now = clock.Now
+	eventClient.after = clock.After
+
+	if eventClient.ConnectedPeer() != nil {
+		t.Fatalf("expecting no connected peer before connecting")
+	}
+
+	if err := eventClient.Connect(); err != nil {
+		t.Fatalf("error connecting client: %s", err)
+	}
+
+	if peer := eventClient.ConnectedPeer(); peer == nil || peer.URL() != peer2.URL() {
+		t.Fatalf("expecting client to fail over to [%s] but connected peer is %v", peer2.URL(), peer)
+	}
+
+	expected := []string{peer1.URL(), peer2.URL()}
+	if attempts := connProvider.attempts(); fmt.Sprint(attempts) != fmt.Sprint(expected) {
+		t.Fatalf("expecting connection attempts %v but got %v", expected, attempts)
+	}
+}
+
+func TestEndpointProvider(t *testing.T) {
+	const bestURL = "grpcs://best.example.com:7051"
+
+	// The first call to the endpoint provider fails
+	var calls int32
+	endpointProvider := func(ctx stdcontext.Context) (string, []options.Opt, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			return "", nil, errors.New("simulated endpoint provider failure")
+		}
+		return StaticEndpointProvider(bestURL, WithResponseTimeout(time.Second))(ctx)
+	}
+
+	connProvider := newPeerProvider()
+
+	eventClient, _, err := newClientWithMockConnAndOpts(
+		"mychannel", newMockContext(),
+		connProvider.provider(),
+		clientProvider,
+		clientmocks.NewDiscoveryService(peer1),
+		[]options.Opt{
+			WithMaxConnectAttempts(2),
+			WithEndpointProvider(endpointProvider),
+		},
+	)
+	if err != nil {
+		t.Fatalf("error creating client: %s", err)
+	}
+	defer eventClient.Close()
+
+	clock := &fakeClock{now: time.Now()}
+	eventClient.now = clock.Now
+	eventClient.after = clock.After
+
+	if err := eventClient.Connect(); err != nil {
+		t.Fatalf("error connecting client: %s", err)
+	}
+
+	if n := atomic.LoadInt32(&calls); n != 2 {
+		t.Fatalf("expecting the endpoint provider to be called twice but it was called %d times", n)
+	}
+
+	expected := []string{bestURL}
+	if attempts := connProvider.attempts(); fmt.Sprint(attempts) != fmt.Sprint(expected) {
+		t.Fatalf("expecting connection attempts %v but got %v", expected, attempts)
+	}
+
+	peer := eventClient.ConnectedPeer()
+	if peer == nil || peer.URL() != bestURL {
+		t.Fatalf("expecting connected peer URL [%s] but got %v", bestURL, peer)
+	}
+	if opts := api.ConnectionOpts(peer); len(opts) != 1 {
+		t.Fatalf("expecting the connection options of the endpoint provider but got %d options", len(opts))
+	}
+}
+
+func TestPeerResolverReconnect(t *testing.T) {
+	peer3 := fabmocks.NewMockPeer("peer3", "grpcs://peer3.example.com:7051")
+
+	// The first peer is permanently down
+	connProvider := newPeerProvider(peer1)
+	connectch := make(chan *fab.ConnectionEvent, 10)
+
+	// The beforeReconnect handler vetoes the third peer
+	var candidatesMutex sync.Mutex
+	var candidates []string
+	beforeReconnect := func(peer fab.Peer) error {
+		candidatesMutex.Lock()
+		defer candidatesMutex.Unlock()
+		candidates = append(candidates, peer.URL())
+		if peer.URL() == peer3.URL() {
+			return errors.New("peer vetoed")
+		}
+		return nil
+	}
+
+	eventClient, err := newClient(
+		"mychannel", newMockContext(),
+		connProvider.provider(),
+		clientmocks.NewDiscoveryService(peer1, peer2, peer3),
+		[]options.Opt{
+			WithMaxConnectAttempts(3),
+			WithReconnect(true),
+			WithMaxReconnectAttempts(5),
+			WithConnectionEvent(connectch),
+			WithPeerResolver(peerresolver.NewPriority(peer1, peer2, peer3)),
+		},
+		true, nil, beforeReconnect,
+	)
+	if err != nil {
+		t.Fatalf("error creating client: %s", err)
+	}
+	defer eventClient.Close()
+
+	clock := &fakeClock{now: time.Now()}
+	eventClient.now = clock.Now
+	eventClient.after = clock.After
+
+	if err := eventClient.Connect(); err != nil {
+		t.Fatalf("error connecting client: %s", err)
+	}
+	checkConnectionEvent(t, connectch, true)
+
+	if err := eventClient.Submit(dispatcher.NewDisconnectedEvent(errors.New("simulated disconnect"))); err != nil {
+		t.Fatalf("error submitting disconnected event: %s", err)
+	}
+	checkConnectionEvent(t, connectch, false)
+	checkConnectionEvent(t, connectch, true)
+
+	if peer := eventClient.ConnectedPeer(); peer == nil || peer.URL() != peer2.URL() {
+		t.Fatalf("expecting client to reconnect to [%s] but connected peer is %v", peer2.URL(), peer)
+	}
+
+	// After losing peer2 the resolver offers peer3 (vetoed), then peer1 (down) and then peer2
+	expectedCandidates := []string{peer3.URL(), peer1.URL(), peer2.URL()}
+	candidatesMutex.Lock()
+	if fmt.Sprint(candidates) != fmt.Sprint(expectedCandidates) {
+		t.Fatalf("expecting reconnect candidates %v but got %v", expectedCandidates, candidates)
+	}
+	candidatesMutex.Unlock()
+
+	// The vetoed peer is never dialed
+	expectedAttempts := []string{peer1.URL(), peer2.URL(), peer1.URL(), peer2.URL()}
+	if attempts := connProvider.attempts(); fmt.Sprint(attempts) != fmt.Sprint(expectedAttempts) {
+		t.Fatalf("expecting connection attempts %v but got %v", expectedAttempts, attempts)
+	}
+}
+
+func checkConnectionEvent(t *testing.T, connectch chan *fab.ConnectionEvent, expectConnected bool) *fab.ConnectionEvent {
+	select {
+	case event := <-connectch:
+		if event.Connected != expectConnected {
+			t.Fatalf("expecting connection event with connected [%t] but got %+v", expectConnected, event)
+		}
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for connection event")
+	}
+	return nil
+}
+
+func TestWithTimeouts(t *testing.T) {
+	config := fabmocks.NewMockConfigWithTimeouts(map[core.TimeoutType]time.Duration{
+		core.EventServiceResponse:       7 * time.Second,
+		core.EventReconnectInitialDelay: 2 * time.Second,
+		core.EventConnectRetryInterval:  3 * time.Second,
+	})
+
+	params := defaultParams()
+	options.Apply(params, []options.Opt{WithTimeouts(config)})
+	if params.respTimeout != 7*time.Second {
+		t.Fatalf("expecting the configured response timeout but got %s", params.respTimeout)
+	}
+	if params.reconnInitialDelay != 2*time.Second {
+		t.Fatalf("expecting the configured reconnect initial delay but got %s", params.reconnInitialDelay)
+	}
+	if params.timeBetweenConnAttempts != 3*time.Second {
+		t.Fatalf("expecting the configured time between connection attempts but got %s", params.timeBetweenConnAttempts)
+	}
+
+	// Zero timeouts are ignored
+	params = defaultParams()
+	options.Apply(params, []options.Opt{WithTimeouts(fabmocks.NewMockConfigWithTimeouts(map[core.TimeoutType]time.Duration{
+		core.EventServiceResponse: 0,
+	}))})
+	if params.respTimeout != 5*time.Second {
+		t.Fatalf("expecting the default response timeout but got %s", params.respTimeout)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	c := &Client{params: *defaultParams()}
+	c.SetTimeBetweenConnectAttempts(2 * time.Second)
+
+	for attempt := uint(1); attempt <= 3; attempt++ {
+		if d := c.retryDelay(attempt, c.timeBetweenConnAttempts); d != 2*time.Second {
+			t.Fatalf("expecting fixed delay of 2s without backoff but got %s", d)
+		}
+	}
+
+	c.SetReconnectBackoff(time.Second, 10*time.Second, 3, 0.5)
+	for i := 0; i < 100; i++ {
+		if d := c.retryDelay(1, c.timeBetweenConnAttempts); d < 500*time.Millisecond || d > 1500*time.Millisecond {
+			t.Fatalf("expecting delay for first attempt to be within 500ms and 1.5s but got %s", d)
+		}
+		if d := c.retryDelay(2, c.timeBetweenConnAttempts); d < 1500*time.Millisecond || d > 4500*time.Millisecond {
+			t.Fatalf("expecting delay for second attempt to be within 1.5s and 4.5s but got %s", d)
+		}
+		if d := c.retryDelay(5, c.timeBetweenConnAttempts); d < 5*time.Second || d > 15*time.Second {
+			t.Fatalf("expecting delay to be capped at 10s (+/- jitter) but got %s", d)
+		}
+	}
+
+	// Without a maximum the delay must not overflow
+	c.SetReconnectBackoff(time.Second, 0, 10, 0.5)
+	for _, attempt := range []uint{20, 100, 1000} {
+		if d := c.retryDelay(attempt, c.timeBetweenConnAttempts); d <= 0 {
+			t.Fatalf("expecting positive delay for attempt %d but got %s", attempt, d)
+		}
+	}
+}
+
 func TestCallsOnClosedClient(t *testing.T) {
 	eventClient, _, err := newClientWithMockConn(
 		"mychannel", newMockContext(),
-		filteredClientProvider,
+		filteredClientProvider,
+		clientmocks.NewDiscoveryService(peer1, peer2),
+		mockconn.WithLedger(servicemocks.NewMockLedger(servicemocks.FilteredBlockEventFactory)),
+	)
+	if err != nil {
+		t.Fatalf("error creating channel event client: %s", err)
+	}
+	if err := eventClient.Connect(); err != nil {
+		t.Fatalf("error connecting channel event client: %s", err)
+	}
+	eventClient.Close()
+
+	// Make sure you can call Close again with no issues
+	eventClient.Close()
+
+	if err := eventClient.Connect(); err == nil {
+		t.Fatalf("expecting error connecting to closed channel event client but got none")
+	}
+
+	if _, _, err := eventClient.RegisterConnectionEvent(); err == nil {
+		t.Fatalf("expecting error registering for connection events on closed channel event client but got none")
+	}
+
+	if _, _, err := eventClient.RegisterFilteredBlockEvent(); err == nil {
+		t.Fatalf("expecting error registering for block events on closed channel event client but got none")
+	}
+
+	if _, _, err := eventClient.RegisterChaincodeEvent("ccid", "event"); err == nil {
+		t.Fatalf("expecting error registering for chaincode events on closed channel event client but got none")
+	}
+
+	if _, _, err := eventClient.RegisterTxStatusEvent("txid"); err == nil {
+		t.Fatalf("expecting error registering for TX events on closed channel event client but got none")
+	}
+
+	// Make sure the client doesn't panic when calling unregister on disconnected client
+	eventClient.Unregister(nil)
+}
+
+func TestCallsOnStoppedDispatcher(t *testing.T) {
+	eventClient, _, err := newClientWithMockConn(
+		"mychannel", newMockContext(),
+		filteredClientProvider,
+		clientmocks.NewDiscoveryService(peer1, peer2),
+		mockconn.WithLedger(servicemocks.NewMockLedger(servicemocks.FilteredBlockEventFactory)),
+	)
+	if err != nil {
+		t.Fatalf("error creating channel event client: %s", err)
+	}
+
+	// The dispatcher is stopped without closing the client
+	eventClient.Service.Stop()
+
+	done := make(chan error, 2)
+	go func() {
+		_, _, err := eventClient.RegisterConnectionEvent()
+		done <- err
+	}()
+	go func() {
+		done <- eventClient.Connect()
+	}()
+
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-done:
+			if err == nil {
+				t.Fatalf("expecting error with a stopped dispatcher but got none")
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("expecting calls on a stopped dispatcher to fail immediately")
+		}
+	}
+
+	_, _, err = eventClient.RegisterConnectionEvent()
+	if _, ok := errors.Cause(err).(*eventservice.ServiceStoppedError); !ok {
+		t.Fatalf("expecting ServiceStoppedError registering for connection events but got: %v", err)
+	}
+}
+
+type wedgedDispatcher struct {
+	eventch      chan interface{}
+	forceStopped int32
+}
+
+func (d *wedgedDispatcher) ForceStop() { atomic.StoreInt32(&d.forceStopped, 1) }
+
+func (d *wedgedDispatcher) Start() error                         { return nil }
+func (d *wedgedDispatcher) EventCh() (chan<- interface{}, error) { return d.eventch, nil }
+func (d *wedgedDispatcher) LastBlockNum() uint64                 { return 0 }
+
+func TestCloseDuringConnectRetry(t *testing.T) {
+	eventClient, _, err := newClientWithMockConnAndOpts(
+		"mychannel", newMockContext(),
+		mockconn.NewProviderFactory().FlakeyProvider(mockconn.NewConnectResults()),
+		clientProvider,
+		clientmocks.NewDiscoveryService(peer1, peer2),
+		[]options.Opt{
+			WithMaxConnectAttempts(0),
+			WithTimeBetweenConnectAttempts(30 * time.Second),
+		},
+	)
+	if err != nil {
+		t.Fatalf("error creating client: %s", err)
+	}
+
+	errch := make(chan error, 1)
+	go func() {
+		errch <- eventClient.Connect()
+	}()
+
+	// Wait for the first attempt to fail so that the client is waiting for the next attempt
+	if err := eventClient.WaitForState(Connecting, 2*time.Second); err != nil {
+		t.Fatalf("error waiting for connection attempt: %s", err)
+	}
+	if err := eventClient.WaitForState(Disconnected, 2*time.Second); err != nil {
+		t.Fatalf("error waiting for connection attempt to fail: %s", err)
+	}
+
+	start := time.Now()
+	eventClient.Close()
+
+	select {
+	case err := <-errch:
+		if err == nil {
+			t.Fatalf("expecting error from Connect after Close")
+		}
+		if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+			t.Fatalf("expecting Connect to return immediately after Close but it took %s", elapsed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for Connect to return after Close")
+	}
+}
+
+// TestConnectTimeout tests that a connection attempt is abandoned if the connection provider doesn't respond
+func TestConnectTimeout(t *testing.T) {
+	ledger := servicemocks.NewMockLedger(servicemocks.BlockEventFactory)
+
+	// The first two connection attempts hang until they're released
+	release := make(chan struct{})
+	defer close(release)
+	var attempts int32
+	hungConnections := make(chan api.Connection, 2)
+	connectionProvider := func(string, context.Context, fab.Peer) (api.Connection, error) {
+		conn := mockconn.NewMockConnection(mockconn.WithLedger(ledger))
+		if atomic.AddInt32(&attempts, 1) <= 2 {
+			<-release
+			hungConnections <- conn
+		}
+		return conn, nil
+	}
+
+	eventClient, _, err := newClientWithMockConnAndOpts(
+		"mychannel", newMockContext(),
+		connectionProvider,
+		clientProvider,
+		clientmocks.NewDiscoveryService(peer1, peer2),
+		[]options.Opt{
+			WithMaxConnectAttempts(2),
+			WithConnectTimeout(200 * time.Millisecond),
+			WithResponseTimeout(5 * time.Second),
+		},
+	)
+	if err != nil {
+		t.Fatalf("error creating client: %s", err)
+	}
+	defer eventClient.Close()
+
+	eventClient.after = (&fakeClock{now: time.Now()}).After
+
+	errch := make(chan error, 1)
+	go func() {
+		errch <- eventClient.Connect()
+	}()
+
+	select {
+	case err := <-errch:
+		exceededErr, ok := errors.Cause(err).(*ConnectAttemptsExceededError)
+		if !ok {
+			t.Fatalf("expecting ConnectAttemptsExceededError but got: %v", err)
+		}
+		if exceededErr.Attempts != 2 {
+			t.Fatalf("expecting 2 connection attempts but got %d", exceededErr.Attempts)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for Connect to return")
+	}
+
+	if state := eventClient.ConnectionState(); state != Disconnected {
+		t.Fatalf("expecting connection state [%s] after timed out attempts but got [%s]", Disconnected, state)
+	}
+
+	// The dispatcher isn't blocked by the hung attempts so the client is able to connect
+	if err := eventClient.Connect(); err != nil {
+		t.Fatalf("error connecting client: %s", err)
+	}
+	if state := eventClient.ConnectionState(); state != Connected {
+		t.Fatalf("expecting connection state [%s] but got [%s]", Connected, state)
+	}
+
+	// The connections of the abandoned attempts are closed once they complete
+	release <- struct{}{}
+	release <- struct{}{}
+	for i := 0; i < 2; i++ {
+		conn := <-hungConnections
+		deadline := time.Now().Add(5 * time.Second)
+		for !conn.Closed() {
+			if time.Now().After(deadline) {
+				t.Fatalf("expecting the connection of the abandoned attempt to be closed")
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	if state := eventClient.ConnectionState(); state != Connected {
+		t.Fatalf("expecting connection state [%s] after the abandoned attempts completed but got [%s]", Connected, state)
+	}
+}
+
+// TestScriptedConnectionProvider tests that the connection provider may be overridden
+// with a scripted connection provider that produces events on demand
+func TestScriptedConnectionProvider(t *testing.T) {
+	channelID := "mychannel"
+	conn1 := scripted.NewConnection()
+	conn2 := scripted.NewConnection()
+	provider := scripted.NewProvider(conn1, nil, conn2)
+
+	eventClient, err := newClient(
+		channelID, newMockContext(),
+		nil,
+		clientmocks.NewDiscoveryService(peer1, peer2),
+		[]options.Opt{
+			WithConnectionProvider(provider),
+			WithReconnect(true),
+			WithMaxReconnectAttempts(2),
+		},
+		true, nil, nil,
+	)
+	if err != nil {
+		t.Fatalf("error creating client: %s", err)
+	}
+	defer eventClient.Close()
+
+	eventClient.after = (&fakeClock{now: time.Now()}).After
+
+	_, blockch, err := eventClient.RegisterBlockEvent()
+	if err != nil {
+		t.Fatalf("error registering for block events: %s", err)
+	}
+	_, fblockch, err := eventClient.RegisterFilteredBlockEvent()
+	if err != nil {
+		t.Fatalf("error registering for filtered block events: %s", err)
+	}
+
+	if err := eventClient.Connect(); err != nil {
+		t.Fatalf("error connecting client: %s", err)
+	}
+
+	producer := servicemocks.NewBlockProducer()
+	conn1.SendBlock(producer.NewBlock(channelID,
+		servicemocks.NewTransaction("txid1", pb.TxValidationCode_VALID, cb.HeaderType_ENDORSER_TRANSACTION),
+	))
+
+	select {
+	case event := <-blockch:
+		if event.Block.Header.Number != 0 {
+			t.Fatalf("expecting block 0 but got %d", event.Block.Header.Number)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for block event")
+	}
+	select {
+	case <-fblockch:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for filtered block event")
+	}
+
+	// The first reconnect attempt fails and the second attempt gets the second connection
+	conn1.Disconnect(errors.New("simulated disconnect"))
+	if err := eventClient.WaitForState(Disconnected, 2*time.Second); err != nil {
+		t.Fatalf("error waiting for client to disconnect: %s", err)
+	}
+	if err := eventClient.WaitForState(Connected, 2*time.Second); err != nil {
+		t.Fatalf("error waiting for client to reconnect: %s", err)
+	}
+	if attempts := provider.Attempts(); attempts != 3 {
+		t.Fatalf("expecting 3 connection attempts but got %d", attempts)
+	}
+	if !conn1.Closed() {
+		t.Fatalf("expecting the first connection to be closed")
+	}
+
+	conn2.SendFilteredBlock(producer.NewFilteredBlock(channelID,
+		servicemocks.NewFilteredTx("txid2", pb.TxValidationCode_VALID),
+	))
+
+	select {
+	case event := <-fblockch:
+		if event.FilteredBlock.Number != 1 {
+			t.Fatalf("expecting filtered block 1 but got %d", event.FilteredBlock.Number)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for filtered block event")
+	}
+}
+
+// TestAutoCloseOnDisconnect tests each combination of the reconnect and auto-close options
+// after the connection is lost (and, if reconnecting, the client gives up)
+func TestAutoCloseOnDisconnect(t *testing.T) {
+	tests := []struct {
+		reconnect bool
+		autoClose bool
+	}{
+		{reconnect: true, autoClose: true},
+		{reconnect: true, autoClose: false},
+		{reconnect: false, autoClose: true},
+		{reconnect: false, autoClose: false},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("reconnect=%t,autoClose=%t", test.reconnect, test.autoClose), func(t *testing.T) {
+			testAutoCloseOnDisconnect(t, test.reconnect, test.autoClose)
+		})
+	}
+}
+
+func testAutoCloseOnDisconnect(t *testing.T, reconnect, autoClose bool) {
+	conn1 := scripted.NewConnection()
+	conn2 := scripted.NewConnection()
+
+	// When reconnecting, the single reconnect attempt fails
+	provider := scripted.NewProvider(conn1, conn2)
+	if reconnect {
+		provider = scripted.NewProvider(conn1, nil, conn2)
+	}
+
+	connectch := make(chan *fab.ConnectionEvent, 10)
+	eventClient, err := newClient(
+		"mychannel", newMockContext(),
+		nil,
+		clientmocks.NewDiscoveryService(peer1),
+		[]options.Opt{
+			WithConnectionProvider(provider),
+			WithReconnect(reconnect),
+			WithMaxReconnectAttempts(1),
+			WithAutoCloseOnDisconnect(autoClose),
+			WithConnectionEvent(connectch),
+		},
+		true, nil, nil,
+	)
+	if err != nil {
+		t.Fatalf("error creating client: %s", err)
+	}
+	defer eventClient.Close()
+
+	eventClient.after = (&fakeClock{now: time.Now()}).After
+
+	if err := eventClient.Connect(); err != nil {
+		t.Fatalf("error connecting client: %s", err)
+	}
+	checkConnectionEvent(t, connectch, true)
+
+	conn1.Disconnect(errors.New("simulated disconnect"))
+
+	// The last connection event is terminal if the client gave up reconnecting
+	event := checkConnectionEvent(t, connectch, false)
+	if reconnect {
+		if event.Terminal {
+			t.Fatalf("expecting the first disconnected event not to be terminal")
+		}
+		event = checkConnectionEvent(t, connectch, false)
+		if !event.Terminal {
+			t.Fatalf("expecting a terminal disconnected event after giving up reconnecting")
+		}
+	}
+
+	if autoClose {
+		for i := 0; i < 50 && !eventClient.Stopped(); i++ {
+			time.Sleep(100 * time.Millisecond)
+		}
+		if !eventClient.Stopped() {
+			t.Fatalf("expecting client to close itself")
+		}
+		return
+	}
+
+	// The client remains disconnected and may be connected again by the caller
+	time.Sleep(200 * time.Millisecond)
+	if eventClient.Stopped() {
+		t.Fatalf("expecting client not to close itself")
+	}
+	if state := eventClient.ConnectionState(); state != Disconnected {
+		t.Fatalf("expecting client to be disconnected but state is %s", state)
+	}
+	if err := eventClient.Connect(); err != nil {
+		t.Fatalf("error connecting client again: %s", err)
+	}
+	checkConnectionEvent(t, connectch, true)
+	if state := eventClient.ConnectionState(); state != Connected {
+		t.Fatalf("expecting client to be connected but state is %s", state)
+	}
+}
+
+func TestCloseGracefully(t *testing.T) {
+	channelID := "mychannel"
+	eventClient, conn, err := newClientWithMockConn(
+		channelID, newMockContext(),
+		clientProvider,
+		clientmocks.NewDiscoveryService(peer1, peer2),
+		mockconn.WithLedger(servicemocks.NewMockLedger(servicemocks.BlockEventFactory)),
+	)
+	if err != nil {
+		t.Fatalf("error creating channel event client: %s", err)
+	}
+	if err := eventClient.Connect(); err != nil {
+		t.Fatalf("error connecting channel event client: %s", err)
+	}
+	defer eventClient.Close()
+
+	_, eventch1, err := eventClient.RegisterTxStatusEvent("txid1")
+	if err != nil {
+		t.Fatalf("error registering for TxStatus events: %s", err)
+	}
+	if _, _, err := eventClient.RegisterTxStatusEvent("txid2"); err != nil {
+		t.Fatalf("error registering for TxStatus events: %s", err)
+	}
+
+	unresolvedch := make(chan []string, 1)
+	go func() {
+		unresolvedch <- eventClient.CloseGracefully(2 * time.Second)
+	}()
+
+	// Wait for the drain to start
+	time.Sleep(200 * time.Millisecond)
+
+	if _, _, err := eventClient.RegisterTxStatusEvent("txid3"); err == nil {
+		t.Fatalf("expecting registration to be rejected while draining")
+	}
+	if state := eventClient.ConnectionState(); state != Connected {
+		t.Fatalf("expecting client to remain connected while draining but state is [%s]", state)
+	}
+
+	// The first transaction is committed during the drain
+	conn.Ledger().NewBlock(channelID,
+		servicemocks.NewTransaction("txid1", pb.TxValidationCode_VALID, cb.HeaderType_ENDORSER_TRANSACTION),
+	)
+
+	select {
+	case event := <-eventch1:
+		checkTxStatusEvent(t, event, "txid1", pb.TxValidationCode_VALID)
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for TxStatus event")
+	}
+
+	select {
+	case unresolved := <-unresolvedch:
+		if len(unresolved) != 1 || unresolved[0] != "txid2" {
+			t.Fatalf("expecting [txid2] to be unresolved but got %v", unresolved)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for CloseGracefully to return")
+	}
+
+	if !eventClient.Stopped() {
+		t.Fatalf("expecting client to be closed")
+	}
+}
+
+// TestLastDisconnect tests that the reason for the last disconnect is recorded and included
+// in the error when the client gives up reconnecting and when a subsequent Connect fails
+func TestLastDisconnect(t *testing.T) {
+	const cause = "simulated disconnect: peer went away"
+
+	conn1 := scripted.NewConnection()
+
+	connectch := make(chan *fab.ConnectionEvent, 10)
+	eventClient, err := newClient(
+		"mychannel", newMockContext(),
+		nil,
+		clientmocks.NewDiscoveryService(peer1),
+		[]options.Opt{
+			WithConnectionProvider(scripted.NewProvider(conn1)),
+			WithReconnect(true),
+			WithMaxConnectAttempts(2),
+			WithMaxReconnectAttempts(1),
+			WithAutoCloseOnDisconnect(false),
+			WithConnectionEvent(connectch),
+		},
+		true, nil, nil,
+	)
+	if err != nil {
+		t.Fatalf("error creating client: %s", err)
+	}
+	defer eventClient.Close()
+
+	eventClient.after = (&fakeClock{now: time.Now()}).After
+
+	if _, _, ok := eventClient.LastDisconnect(); ok {
+		t.Fatalf("expecting no last disconnect before connecting")
+	}
+
+	if err := eventClient.Connect(); err != nil {
+		t.Fatalf("error connecting client: %s", err)
+	}
+	checkConnectionEvent(t, connectch, true)
+
+	conn1.Disconnect(errors.New(cause))
+	checkConnectionEvent(t, connectch, false)
+
+	// The reconnect attempt fails
+	terminal := checkConnectionEvent(t, connectch, false)
+	if !terminal.Terminal {
+		t.Fatalf("expecting a terminal connection event")
+	}
+
+	lastErr, disconnectedAt, ok := eventClient.LastDisconnect()
+	if !ok || lastErr == nil || lastErr.Error() != cause {
+		t.Fatalf("expecting last disconnect error [%s] but got [%v]", cause, lastErr)
+	}
+	if disconnectedAt.IsZero() {
+		t.Fatalf("expecting the time of the last disconnect")
+	}
+
+	attemptsErr, ok := terminal.Err.(*ConnectAttemptsExceededError)
+	if !ok {
+		t.Fatalf("expecting ConnectAttemptsExceededError but got: %v", terminal.Err)
+	}
+	if attemptsErr.LastDisconnect != lastErr || !strings.Contains(attemptsErr.Error(), cause) {
+		t.Fatalf("expecting the error to include the last disconnect but got: %s", attemptsErr)
+	}
+
+	err = eventClient.Connect()
+	if err == nil {
+		t.Fatalf("expecting error connecting client")
+	}
+	if !strings.Contains(err.Error(), cause) {
+		t.Fatalf("expecting the connect error to include the last disconnect but got: %s", err)
+	}
+}
+
+// TestMultipleConnectionEventRegistrations tests that each connection event registration receives
+// the connection events (independently of the client's own registration) until it's unregistered
+func TestMultipleConnectionEventRegistrations(t *testing.T) {
+	const reconnectDelay = time.Minute
+
+	conn1 := scripted.NewConnection()
+	conn2 := scripted.NewConnection()
+
+	eventClient, err := newClient(
+		"mychannel", newMockContext(),
+		nil,
+		clientmocks.NewDiscoveryService(peer1),
+		[]options.Opt{
+			WithConnectionProvider(scripted.NewProvider(conn1, conn2)),
+			WithReconnect(true),
+			WithReconnectInitialDelay(reconnectDelay),
+		},
+		true, nil, nil,
+	)
+	if err != nil {
+		t.Fatalf("error creating client: %s", err)
+	}
+	defer eventClient.Close()
+
+	// The reconnect is held back until the test releases it
+	clock := &fakeClock{now: time.Now()}
+	reconnectch := make(chan time.Time)
+	eventClient.after = func(d time.Duration) <-chan time.Time {
+		if d == reconnectDelay {
+			return reconnectch
+		}
+		return clock.After(d)
+	}
+
+	if err := eventClient.Connect(); err != nil {
+		t.Fatalf("error connecting client: %s", err)
+	}
+
+	reg1, connectch1, err := eventClient.RegisterConnectionEvent()
+	if err != nil {
+		t.Fatalf("error registering for connection events: %s", err)
+	}
+	_, connectch2, err := eventClient.RegisterConnectionEvent()
+	if err != nil {
+		t.Fatalf("error registering for connection events: %s", err)
+	}
+
+	conn1.Disconnect(errors.New("simulated disconnect"))
+	checkConnectionEvent(t, connectch1, false)
+	checkConnectionEvent(t, connectch2, false)
+
+	eventClient.Unregister(reg1)
+	reconnectch <- time.Now()
+
+	checkConnectionEvent(t, connectch2, true)
+
+	select {
+	case event, ok := <-connectch1:
+		if ok {
+			t.Fatalf("unexpected connection event after unregistering: %+v", event)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("expecting the event channel to be closed after unregistering")
+	}
+}
+
+// TestConnectionEventDebounce tests that the connection events of three flaps within the
+// debounce window are coalesced into a single summary event
+func TestConnectionEventDebounce(t *testing.T) {
+	const window = time.Minute
+
+	conns := []*scripted.Connection{
+		scripted.NewConnection(), scripted.NewConnection(), scripted.NewConnection(), scripted.NewConnection(),
+	}
+	provider := scripted.NewProvider(conns...)
+
+	connectch := make(chan *fab.ConnectionEvent, 10)
+	eventClient, err := newClient(
+		"mychannel", newMockContext(),
+		nil,
+		clientmocks.NewDiscoveryService(peer1),
+		[]options.Opt{
+			WithConnectionProvider(provider),
+			WithReconnect(true),
+			WithConnectionEvent(connectch),
+			WithConnectionEventDebounce(window),
+		},
+		true, nil, nil,
+	)
+	if err != nil {
+		t.Fatalf("error creating client: %s", err)
+	}
+	defer eventClient.Close()
+
+	clock := &fakeClock{now: time.Now()}
+	windowch := make(chan time.Time)
+	eventClient.after = func(d time.Duration) <-chan time.Time {
+		if d == window {
+			return windowch
+		}
+		return clock.After(d)
+	}
+
+	if err := eventClient.Connect(); err != nil {
+		t.Fatalf("error connecting client: %s", err)
+	}
+	checkConnectionEvent(t, connectch, true)
+
+	for i := 0; i < 3; i++ {
+		conns[i].Disconnect(errors.New("simulated flap"))
+		for j := 0; j < 50 && (provider.Attempts() < i+2 || eventClient.ConnectionState() != Connected); j++ {
+			time.Sleep(50 * time.Millisecond)
+		}
+		if provider.Attempts() != i+2 || eventClient.ConnectionState() != Connected {
+			t.Fatalf("expecting client to reconnect after flap %d", i+1)
+		}
+	}
+
+	select {
+	case event := <-connectch:
+		t.Fatalf("unexpected connection event during the debounce window: %+v", event)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	windowch <- time.Now()
+
+	event := checkConnectionEvent(t, connectch, true)
+	if event.Flaps != 3 {
+		t.Fatalf("expecting 3 flaps in the summary event but got %d", event.Flaps)
+	}
+
+	select {
+	case event := <-connectch:
+		t.Fatalf("unexpected connection event after the summary event: %+v", event)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+func TestCloseContext(t *testing.T) {
+	d := &wedgedDispatcher{eventch: make(chan interface{})}
+	eventClient := New(true, d)
+
+	ctx, cancel := stdcontext.WithTimeout(stdcontext.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := eventClient.CloseContext(ctx)
+	if err != stdcontext.DeadlineExceeded {
+		t.Fatalf("expecting deadline exceeded error from CloseContext but got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expecting CloseContext to return within the deadline but it took %s", elapsed)
+	}
+	if !eventClient.Stopped() {
+		t.Fatalf("expecting client to be stopped")
+	}
+	if eventClient.ConnectionState() != Disconnected {
+		t.Fatalf("expecting client to be disconnected but state is %s", eventClient.ConnectionState())
+	}
+	if atomic.LoadInt32(&d.forceStopped) != 1 {
+		t.Fatalf("expecting dispatcher to be forcibly stopped")
+	}
+
+	if err := eventClient.CloseContext(stdcontext.Background()); err != nil {
+		t.Fatalf("expecting no error closing a closed client but got: %s", err)
+	}
+}
+
+func TestConcurrentClose(t *testing.T) {
+	eventClient := New(
+		true, &wedgedDispatcher{eventch: make(chan interface{})},
+		WithConnectionEvent(make(chan *fab.ConnectionEvent)),
+		WithResponseTimeout(100*time.Millisecond),
+	)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			eventClient.Close()
+		}()
+	}
+	wg.Wait()
+
+	if !eventClient.Stopped() {
+		t.Fatalf("expecting client to be stopped")
+	}
+}
+
+type stateTransition struct {
+	oldState ConnectionState
+	newState ConnectionState
+	err      error
+}
+
+// TestAfterConnectFailureWithConcurrentStateChange tests that the client may be connected again after the
+// afterConnect handler fails while the connection monitor concurrently changes the connection state
+func TestAfterConnectFailureWithConcurrentStateChange(t *testing.T) {
+	var eventClient *Client
+	var attempts int32
+	afterConnect := func() error {
+		if atomic.AddInt32(&attempts, 1) > 1 {
+			return nil
+		}
+
+		// The connection is lost while the handler is running
+		if err := eventClient.Submit(dispatcher.NewDisconnectedEvent(errors.New("simulated disconnect"))); err != nil {
+			return err
+		}
+		if err := eventClient.WaitForState(Disconnected, 5*time.Second); err != nil {
+			return err
+		}
+		return errors.New("simulated afterConnect failure")
+	}
+
+	var err error
+	eventClient, err = newClient(
+		"mychannel", newMockContext(),
+		mockconn.NewProviderFactory().FlakeyProvider(
+			mockconn.NewConnectResults(
+				mockconn.NewConnectResult(mockconn.FirstAttempt, mockconn.SucceedResult),
+				mockconn.NewConnectResult(mockconn.SecondAttempt, mockconn.SucceedResult),
+			),
+			mockconn.WithLedger(servicemocks.NewMockLedger(servicemocks.BlockEventFactory)),
+		),
+		clientmocks.NewDiscoveryService(peer1, peer2),
+		[]options.Opt{
+			WithReconnect(true),
+			WithResponseTimeout(2 * time.Second),
+		},
+		true, afterConnect, nil,
+	)
+	if err != nil {
+		t.Fatalf("error creating client: %s", err)
+	}
+	defer eventClient.Close()
+
+	if err := eventClient.Connect(); err == nil {
+		t.Fatalf("expecting error connecting since the afterConnect handler failed")
+	}
+	if state := eventClient.ConnectionState(); state != Disconnected {
+		t.Fatalf("expecting connection state [%s] after failed connect but got [%s]", Disconnected, state)
+	}
+
+	if err := eventClient.Connect(); err != nil {
+		t.Fatalf("error connecting client after failed attempt: %s", err)
+	}
+	if state := eventClient.ConnectionState(); state != Connected {
+		t.Fatalf("expecting connection state [%s] but got [%s]", Connected, state)
+	}
+}
+
+func TestResetConnectionState(t *testing.T) {
+	eventClient, _, err := newClientWithMockConn(
+		"mychannel", newMockContext(),
+		clientProvider,
+		clientmocks.NewDiscoveryService(peer1, peer2),
+		mockconn.WithLedger(servicemocks.NewMockLedger(servicemocks.BlockEventFactory)),
+	)
+	if err != nil {
+		t.Fatalf("error creating channel event client: %s", err)
+	}
+	defer eventClient.Close()
+
+	// Simulate a client that's stuck in the Connecting state
+	eventClient.setConnectionState(Disconnected, Connecting)
+
+	if err := eventClient.Connect(); err == nil {
+		t.Fatalf("expecting error connecting client in state [%s]", Connecting)
+	}
+
+	eventClient.ResetConnectionState()
+
+	if err := eventClient.Connect(); err != nil {
+		t.Fatalf("error connecting client after resetting connection state: %s", err)
+	}
+
+	eventClient.Close()
+	eventClient.ResetConnectionState()
+	if state := eventClient.ConnectionState(); state != Disconnected {
+		t.Fatalf("expecting connection state [%s] but got [%s]", Disconnected, state)
+	}
+}
+
+// handlerRecorder records the order in which handlers are invoked
+type handlerRecorder struct {
+	mutex sync.Mutex
+	calls []string
+}
+
+func (r *handlerRecorder) record(name string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.calls = append(r.calls, name)
+}
+
+func (r *handlerRecorder) reset() []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	calls := r.calls
+	r.calls = nil
+	return calls
+}
+
+func TestAfterConnectHandlers(t *testing.T) {
+	eventClient, err := newClient(
+		"mychannel", newMockContext(),
+		mockconn.NewProviderFactory().FlakeyProvider(
+			mockconn.NewConnectResults(
+				mockconn.NewConnectResult(mockconn.FirstAttempt, mockconn.SucceedResult),
+				mockconn.NewConnectResult(mockconn.SecondAttempt, mockconn.SucceedResult),
+			),
+			mockconn.WithLedger(servicemocks.NewMockLedger(servicemocks.BlockEventFactory)),
+		),
+		clientmocks.NewDiscoveryService(peer1, peer2),
+		[]options.Opt{WithResponseTimeout(2 * time.Second)},
+		true, nil, nil,
+	)
+	if err != nil {
+		t.Fatalf("error creating client: %s", err)
+	}
+	defer eventClient.Close()
+
+	recorder := &handlerRecorder{}
+	var fail int32 = 1
+
+	eventClient.AddAfterConnectHandler(func() error {
+		recorder.record("h0")
+		return nil
+	})
+	eventClient.AddAfterConnectHandler(func() error {
+		recorder.record("h1")
+		if atomic.CompareAndSwapInt32(&fail, 1, 0) {
+			return errors.New("simulated afterConnect failure")
+		}
+		return nil
+	})
+	eventClient.AddAfterConnectHandler(func() error {
+		recorder.record("h2")
+		return nil
+	})
+
+	// The second handler fails so the third handler isn't invoked
+	err = eventClient.Connect()
+	if err == nil {
+		t.Fatalf("expecting error connecting since an afterConnect handler failed")
+	}
+	if !strings.Contains(err.Error(), "afterConnect handler #1 failed") {
+		t.Fatalf("expecting error to include the index of the failed handler but got: %s", err)
+	}
+	if calls := recorder.reset(); fmt.Sprint(calls) != fmt.Sprint([]string{"h0", "h1"}) {
+		t.Fatalf("unexpected handler invocations: %v", calls)
+	}
+
+	if err := eventClient.Connect(); err != nil {
+		t.Fatalf("error connecting client: %s", err)
+	}
+	if calls := recorder.reset(); fmt.Sprint(calls) != fmt.Sprint([]string{"h0", "h1", "h2"}) {
+		t.Fatalf("unexpected handler invocations: %v", calls)
+	}
+	eventClient.Close()
+
+	// Set replaces all of the handlers
+	eventClient.SetAfterConnectHandler(func() error { return nil })
+	if n := len(eventClient.afterConnectHandlers()); n != 1 {
+		t.Fatalf("expecting one afterConnect handler but got %d", n)
+	}
+	eventClient.SetAfterConnectHandler(nil)
+	if n := len(eventClient.afterConnectHandlers()); n != 0 {
+		t.Fatalf("expecting no afterConnect handlers but got %d", n)
+	}
+}
+
+func TestBeforeReconnectHandlers(t *testing.T) {
+	connectch := make(chan *fab.ConnectionEvent, 10)
+
+	eventClient, err := newClient(
+		"mychannel", newMockContext(),
+		mockconn.NewProviderFactory().FlakeyProvider(
+			mockconn.NewConnectResults(
+				mockconn.NewConnectResult(mockconn.FirstAttempt, mockconn.SucceedResult),
+				mockconn.NewConnectResult(mockconn.SecondAttempt, mockconn.SucceedResult),
+			),
+			mockconn.WithLedger(servicemocks.NewMockLedger(servicemocks.BlockEventFactory)),
+		),
+		clientmocks.NewDiscoveryService(peer1, peer2),
+		[]options.Opt{
+			WithReconnect(true),
+			WithMaxReconnectAttempts(3),
+			WithConnectionEvent(connectch),
+		},
+		true, nil, nil,
+	)
+	if err != nil {
+		t.Fatalf("error creating client: %s", err)
+	}
+	defer eventClient.Close()
+
+	clock := &fakeClock{now: time.Now()}
+	eventClient.now = clock.Now
+	eventClient.after = clock.After
+
+	recorder := &handlerRecorder{}
+	var fail int32 = 1
+
+	eventClient.AddBeforeReconnectHandler(func(fab.Peer) error {
+		recorder.record("h0")
+		return nil
+	})
+	eventClient.AddBeforeReconnectHandler(func(fab.Peer) error {
+		recorder.record("h1")
+		if atomic.CompareAndSwapInt32(&fail, 1, 0) {
+			return errors.New("simulated veto")
+		}
+		return nil
+	})
+	eventClient.AddBeforeReconnectHandler(func(fab.Peer) error {
+		recorder.record("h2")
+		return nil
+	})
+
+	if err := eventClient.Connect(); err != nil {
+		t.Fatalf("error connecting client: %s", err)
+	}
+	checkConnectionEvent(t, connectch, true)
+	if calls := recorder.reset(); len(calls) != 0 {
+		t.Fatalf("expecting beforeReconnect handlers not to be invoked on connect but got %v", calls)
+	}
+
+	if err := eventClient.Submit(dispatcher.NewDisconnectedEvent(errors.New("simulated disconnect"))); err != nil {
+		t.Fatalf("error submitting disconnected event: %s", err)
+	}
+	checkConnectionEvent(t, connectch, false)
+	checkConnectionEvent(t, connectch, true)
+
+	// The first reconnect attempt is vetoed by the second handler
+	expectedCalls := []string{"h0", "h1", "h0", "h1", "h2"}
+	if calls := recorder.reset(); fmt.Sprint(calls) != fmt.Sprint(expectedCalls) {
+		t.Fatalf("expecting handler invocations %v but got %v", expectedCalls, calls)
+	}
+}
+
+func TestConnectionStateListener(t *testing.T) {
+	cp := mockconn.NewProviderFactory()
+
+	eventClient, _, err := newClientWithMockConnAndOpts(
+		"mychannel", newMockContext(),
+		cp.FlakeyProvider(
+			mockconn.NewConnectResults(
+				mockconn.NewConnectResult(mockconn.FirstAttempt, mockconn.SucceedResult),
+				mockconn.NewConnectResult(mockconn.SecondAttempt, mockconn.SucceedResult),
+			),
+			mockconn.WithLedger(servicemocks.NewMockLedger(servicemocks.BlockEventFactory)),
+		),
+		clientProvider,
+		clientmocks.NewDiscoveryService(peer1, peer2),
+		[]options.Opt{
+			WithMaxConnectAttempts(1),
+			WithReconnect(true),
+			WithReconnectInitialDelay(0),
+			WithMaxReconnectAttempts(1),
+			WithConnectionEvent(make(chan *fab.ConnectionEvent, 10)),
+			WithResponseTimeout(2 * time.Second),
+		},
+	)
+	if err != nil {
+		t.Fatalf("error creating channel event client: %s", err)
+	}
+
+	transitions := make(chan stateTransition, 10)
+	eventClient.SetConnectionStateListener(func(oldState, newState ConnectionState, err error) {
+		transitions <- stateTransition{oldState: oldState, newState: newState, err: err}
+	})
+
+	if err := eventClient.Connect(); err != nil {
+		t.Fatalf("error connecting channel event client: %s", err)
+	}
+	checkStateTransition(t, transitions, Disconnected, Connecting)
+	checkStateTransition(t, transitions, Connecting, Connected)
+
+	// Simulate a dropped connection
+	connErr := errors.New("testing connection drop")
+	cp.Connection().ProduceEvent(dispatcher.NewDisconnectedEvent(connErr))
+
+	if transition := checkStateTransition(t, transitions, Connected, Disconnected); transition.err != connErr {
+		t.Fatalf("expecting disconnect error [%s] to be passed to listener but got [%v]", connErr, transition.err)
+	}
+	checkStateTransition(t, transitions, Disconnected, Connecting)
+	checkStateTransition(t, transitions, Connecting, Connected)
+
+	// Replace the listener
+	replaced := make(chan stateTransition, 10)
+	eventClient.SetConnectionStateListener(func(oldState, newState ConnectionState, err error) {
+		replaced <- stateTransition{oldState: oldState, newState: newState, err: err}
+	})
+
+	eventClient.Close()
+
+	checkStateTransition(t, replaced, Connected, Disconnected)
+	select {
+	case transition := <-transitions:
+		t.Fatalf("expecting replaced listener not to be notified but got transition from [%s] to [%s]", transition.oldState, transition.newState)
+	default:
+	}
+}
+
+func checkStateTransition(t *testing.T, transitions chan stateTransition, expectedOld, expectedNew ConnectionState) stateTransition {
+	select {
+	case transition := <-transitions:
+		if transition.oldState != expectedOld || transition.newState != expectedNew {
+			t.Fatalf("expecting transition from [%s] to [%s] but got transition from [%s] to [%s]", expectedOld, expectedNew, transition.oldState, transition.newState)
+		}
+		return transition
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for transition from [%s] to [%s]", expectedOld, expectedNew)
+	}
+	return stateTransition{}
+}
+
+func TestWaitForState(t *testing.T) {
+	eventClient, _, err := newClientWithMockConnAndOpts(
+		"mychannel", newMockContext(),
+		mockconn.NewProviderFactory().FlakeyProvider(
+			mockconn.NewConnectResults(
+				mockconn.NewConnectResult(mockconn.ThirdAttempt, mockconn.SucceedResult),
+			),
+			mockconn.WithLedger(servicemocks.NewMockLedger(servicemocks.BlockEventFactory)),
+		),
+		clientProvider,
 		clientmocks.NewDiscoveryService(peer1, peer2),
-		mockconn.WithLedger(servicemocks.NewMockLedger(servicemocks.FilteredBlockEventFactory)),
+		[]options.Opt{
+			WithMaxConnectAttempts(3),
+		},
+	)
+	if err != nil {
+		t.Fatalf("error creating client: %s", err)
+	}
+	defer eventClient.Close()
+
+	eventClient.after = func(time.Duration) <-chan time.Time { return time.After(100 * time.Millisecond) }
+
+	// Already in the requested state
+	if err := eventClient.WaitForState(Disconnected, 0); err != nil {
+		t.Fatalf("expecting no error waiting for the current state but got: %s", err)
+	}
+
+	// Never connects
+	if err := eventClient.WaitForState(Connected, 200*time.Millisecond); err == nil {
+		t.Fatalf("expecting timeout error waiting for client to connect")
+	}
+
+	// Eventually connects. The wait for Connecting should also succeed even though the
+	// state has moved past Connecting by the time the waiter is released.
+	connectingErr := make(chan error, 1)
+	connectedErr := make(chan error, 1)
+	go func() { connectingErr <- eventClient.WaitForState(Connecting, 5*time.Second) }()
+	go func() { connectedErr <- eventClient.WaitForState(Connected, 5*time.Second) }()
+
+	waitForStateWaiters(t, eventClient, 2)
+
+	go func() {
+		if err := eventClient.Connect(); err != nil {
+			t.Errorf("error connecting client: %s", err)
+		}
+	}()
+
+	if err := <-connectedErr; err != nil {
+		t.Fatalf("error waiting for client to connect: %s", err)
+	}
+	if err := <-connectingErr; err != nil {
+		t.Fatalf("error waiting for client to be connecting: %s", err)
+	}
+
+	// Already connected
+	if err := eventClient.WaitForState(Connected, 0); err != nil {
+		t.Fatalf("expecting no error waiting for connected client but got: %s", err)
+	}
+
+	// The waiters were removed
+	waitForStateWaiters(t, eventClient, 0)
+}
+
+func waitForStateWaiters(t *testing.T, eventClient *Client, expected int) {
+	for i := 0; i < 50; i++ {
+		eventClient.stateMutex.Lock()
+		n := len(eventClient.stateWaiters)
+		eventClient.stateMutex.Unlock()
+		if n == expected {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %d state waiters", expected)
+}
+
+func TestPing(t *testing.T) {
+	eventClient, _, err := newClientWithMockConnAndOpts(
+		"mychannel", newMockContext(),
+		mockconn.NewProviderFactory().Provider(
+			mockconn.NewMockConnection(
+				mockconn.WithLedger(servicemocks.NewMockLedger(servicemocks.BlockEventFactory)),
+			),
+		),
+		clientProvider,
+		clientmocks.NewDiscoveryService(peer1, peer2),
+		[]options.Opt{},
 	)
 	if err != nil {
 		t.Fatalf("error creating channel event client: %s", err)
 	}
+
+	if err := eventClient.Ping(time.Second); err == nil {
+		t.Fatalf("expecting ping to fail since the client isn't connected")
+	}
+
 	if err := eventClient.Connect(); err != nil {
 		t.Fatalf("error connecting channel event client: %s", err)
 	}
-	eventClient.Close()
 
-	// Make sure you can call Close again with no issues
+	if err := eventClient.Ping(time.Second); err != nil {
+		t.Fatalf("error from ping: %s", err)
+	}
+
 	eventClient.Close()
 
-	if err := eventClient.Connect(); err == nil {
-		t.Fatalf("expecting error connecting to closed channel event client but got none")
+	if err := eventClient.Ping(time.Second); err == nil {
+		t.Fatalf("expecting ping to fail since the client is closed")
 	}
+}
 
-	if _, _, err := eventClient.RegisterConnectionEvent(); err == nil {
-		t.Fatalf("expecting error registering for connection events on closed channel event client but got none")
+func TestPingTriggersReconnect(t *testing.T) {
+	testPingFailure(t, false)
+	testPingFailure(t, true)
+}
+
+func testPingFailure(t *testing.T, triggerReconnect bool) {
+	cp := mockconn.NewProviderFactory()
+
+	opts := []options.Opt{
+		WithMaxConnectAttempts(1),
+		WithReconnect(true),
+		WithReconnectInitialDelay(0),
+		WithMaxReconnectAttempts(1),
+		WithResponseTimeout(2 * time.Second),
+	}
+	if triggerReconnect {
+		opts = append(opts, WithPingTriggersReconnect())
 	}
 
-	if _, _, err := eventClient.RegisterFilteredBlockEvent(); err == nil {
-		t.Fatalf("expecting error registering for block events on closed channel event client but got none")
+	eventClient, _, err := newClientWithMockConnAndOpts(
+		"mychannel", newMockContext(),
+		cp.FlakeyProvider(
+			mockconn.NewConnectResults(
+				mockconn.NewConnectResult(mockconn.FirstAttempt, mockconn.SucceedResult),
+				mockconn.NewConnectResult(mockconn.SecondAttempt, mockconn.SucceedResult),
+			),
+			mockconn.WithLedger(servicemocks.NewMockLedger(servicemocks.BlockEventFactory)),
+			mockconn.WithResults(mockconn.NewResult(mockconn.Ping, mockconn.FailResult, "stream terminated")),
+		),
+		clientProvider,
+		clientmocks.NewDiscoveryService(peer1, peer2),
+		opts,
+	)
+	if err != nil {
+		t.Fatalf("error creating channel event client: %s", err)
 	}
+	defer eventClient.Close()
 
-	if _, _, err := eventClient.RegisterChaincodeEvent("ccid", "event"); err == nil {
-		t.Fatalf("expecting error registering for chaincode events on closed channel event client but got none")
+	transitions := make(chan stateTransition, 10)
+	eventClient.SetConnectionStateListener(func(oldState, newState ConnectionState, err error) {
+		transitions <- stateTransition{oldState: oldState, newState: newState, err: err}
+	})
+
+	if err := eventClient.Connect(); err != nil {
+		t.Fatalf("error connecting channel event client: %s", err)
 	}
+	checkStateTransition(t, transitions, Disconnected, Connecting)
+	checkStateTransition(t, transitions, Connecting, Connected)
 
-	if _, _, err := eventClient.RegisterTxStatusEvent("txid"); err == nil {
-		t.Fatalf("expecting error registering for TX events on closed channel event client but got none")
+	err = eventClient.Ping(time.Second)
+	if err == nil || !strings.Contains(err.Error(), "stream terminated") {
+		t.Fatalf("expecting ping to fail with the health check error but got: %v", err)
 	}
 
-	// Make sure the client doesn't panic when calling unregister on disconnected client
-	eventClient.Unregister(nil)
+	if !triggerReconnect {
+		select {
+		case transition := <-transitions:
+			t.Fatalf("expecting no state change after a failed ping but got transition from [%s] to [%s]", transition.oldState, transition.newState)
+		case <-time.After(500 * time.Millisecond):
+		}
+		return
+	}
+
+	checkStateTransition(t, transitions, Connected, Disconnected)
+	checkStateTransition(t, transitions, Disconnected, Connecting)
+	checkStateTransition(t, transitions, Connecting, Connected)
 }
 
 func TestInvalidUnregister(t *testing.T) {
@@ -177,6 +1871,89 @@ func TestUnauthorizedBlockEvents(t *testing.T) {
 	}
 }
 
+func TestPermittedEventTypes(t *testing.T) {
+	t.Run("Default", func(t *testing.T) {
+		eventClient := newClientWithPermittedEventTypes(t, filteredClientProvider)
+		defer eventClient.Close()
+
+		checkRegistrations(t, eventClient, false, true, true, true)
+	})
+
+	t.Run("Block only", func(t *testing.T) {
+		eventClient := newClientWithPermittedEventTypes(t, clientProvider, BlockEventType)
+		defer eventClient.Close()
+
+		checkRegistrations(t, eventClient, true, false, false, false)
+	})
+
+	t.Run("Filtered only", func(t *testing.T) {
+		// Block events are rejected even though the connection permits them
+		eventClient := newClientWithPermittedEventTypes(t, clientProvider, FilteredBlockEventType, TxStatusEventType)
+		defer eventClient.Close()
+
+		checkRegistrations(t, eventClient, false, true, true, false)
+	})
+
+	t.Run("Block not permitted by connection", func(t *testing.T) {
+		eventClient := newClientWithPermittedEventTypes(t, filteredClientProvider, BlockEventType, ChaincodeEventType)
+		defer eventClient.Close()
+
+		checkRegistrations(t, eventClient, false, false, false, true)
+	})
+}
+
+func newClientWithPermittedEventTypes(t *testing.T, clientProvider ClientProvider, types ...EventType) *Client {
+	var opts []options.Opt
+	if len(types) > 0 {
+		opts = append(opts, WithPermittedEventTypes(types...))
+	}
+
+	eventClient, _, err := newClientWithMockConnAndOpts(
+		"mychannel", newMockContext(),
+		nil, clientProvider,
+		clientmocks.NewDiscoveryService(peer1, peer2),
+		opts,
+		mockconn.WithLedger(servicemocks.NewMockLedger(servicemocks.BlockEventFactory)),
+	)
+	if err != nil {
+		t.Fatalf("error creating channel event client: %s", err)
+	}
+	return eventClient
+}
+
+// checkRegistrations checks that registrations for each type of event are either accepted or rejected
+func checkRegistrations(t *testing.T, eventClient *Client, block, filteredBlock, txStatus, chaincode bool) {
+	check := func(eventType EventType, expectPermitted bool, reg fab.Registration, err error) {
+		if expectPermitted {
+			if err != nil {
+				t.Fatalf("error registering for %s events: %s", eventType, err)
+			}
+			eventClient.Unregister(reg)
+			return
+		}
+		if err == nil {
+			t.Fatalf("expecting error registering for %s events", eventType)
+		}
+		if eventType != BlockEventType || eventClient.BlockEventsPermitted() {
+			if expected := fmt.Sprintf("%s events are not permitted", eventType); err.Error() != expected {
+				t.Fatalf("expecting error [%s] but got [%s]", expected, err)
+			}
+		}
+	}
+
+	reg, _, err := eventClient.RegisterBlockEvent()
+	check(BlockEventType, block, reg, err)
+
+	reg, _, err = eventClient.RegisterFilteredBlockEvent()
+	check(FilteredBlockEventType, filteredBlock, reg, err)
+
+	reg, _, err = eventClient.RegisterTxStatusEvent("txid")
+	check(TxStatusEventType, txStatus, reg, err)
+
+	reg, _, err = eventClient.RegisterChaincodeEvent("mycc", ".*")
+	check(ChaincodeEventType, chaincode, reg, err)
+}
+
 func TestBlockEvents(t *testing.T) {
 	channelID := "mychannel"
 	eventClient, conn, err := newClientWithMockConn(
@@ -232,6 +2009,57 @@ func TestBlockEvents(t *testing.T) {
 	}
 }
 
+func TestLastBlockReceived(t *testing.T) {
+	channelID := "mychannel"
+	eventClient, conn, err := newClientWithMockConn(
+		channelID, newMockContext(),
+		clientProvider,
+		clientmocks.NewDiscoveryService(peer1, peer2),
+		mockconn.WithLedger(servicemocks.NewMockLedger(servicemocks.BlockEventFactory)),
+	)
+	if err != nil {
+		t.Fatalf("error creating channel event client: %s", err)
+	}
+	if err := eventClient.Connect(); err != nil {
+		t.Fatalf("error connecting channel event client: %s", err)
+	}
+	defer eventClient.Close()
+
+	// No blocks yet
+	if blockNum, receivedAt, ok := eventClient.LastBlockReceived(); ok || blockNum != 0 || !receivedAt.IsZero() {
+		t.Fatalf("expecting no last block received but got block %d received at %s", blockNum, receivedAt)
+	}
+
+	_, eventch, err := eventClient.RegisterBlockEvent()
+	if err != nil {
+		t.Fatalf("error registering for block events: %s", err)
+	}
+
+	start := time.Now()
+	for i := uint64(0); i < 2; i++ {
+		conn.Ledger().NewBlock(channelID,
+			servicemocks.NewTransaction("txID", pb.TxValidationCode_VALID, cb.HeaderType_ENDORSER_TRANSACTION),
+		)
+
+		select {
+		case <-eventch:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for block event")
+		}
+
+		blockNum, receivedAt, ok := eventClient.LastBlockReceived()
+		if !ok {
+			t.Fatalf("expecting last block received to be available")
+		}
+		if blockNum != i {
+			t.Fatalf("expecting last block received to be %d but got %d", i, blockNum)
+		}
+		if receivedAt.Before(start) {
+			t.Fatalf("expecting last block to be received after %s but got %s", start, receivedAt)
+		}
+	}
+}
+
 func TestFilteredBlockEvents(t *testing.T) {
 	channelID := "mychannel"
 	eventClient, conn, err := newClientWithMockConn(
@@ -619,6 +2447,66 @@ func TestReconnect(t *testing.T) {
 
 // TestReconnectRegistration tests the ability of the Channel Event Client to
 // re-establish the existing registrations after reconnecting.
+func TestBlockedConnectionEventSubscriber(t *testing.T) {
+	t.Run("NonBlocking", func(t *testing.T) {
+		testBlockedConnectionEventSubscriber(t, -1)
+	})
+	t.Run("Timeout", func(t *testing.T) {
+		testBlockedConnectionEventSubscriber(t, 10*time.Millisecond)
+	})
+}
+
+func testBlockedConnectionEventSubscriber(t *testing.T, consumerTimeout time.Duration) {
+	// The subscriber never reads from the channel
+	connectch := make(chan *fab.ConnectionEvent)
+
+	eventClient, _, err := newClientWithMockConnAndOpts(
+		"mychannel", newMockContext(),
+		mockconn.NewProviderFactory().FlakeyProvider(
+			mockconn.NewConnectResults(
+				mockconn.NewConnectResult(mockconn.FirstAttempt, mockconn.SucceedResult),
+				mockconn.NewConnectResult(mockconn.SecondAttempt, mockconn.SucceedResult),
+			),
+			mockconn.WithLedger(servicemocks.NewMockLedger(servicemocks.BlockEventFactory)),
+		),
+		clientProvider,
+		clientmocks.NewDiscoveryService(peer1, peer2),
+		[]options.Opt{
+			WithReconnect(true),
+			WithMaxReconnectAttempts(1),
+			WithConnectionEvent(connectch),
+			esdispatcher.WithEventConsumerTimeout(consumerTimeout),
+		},
+	)
+	if err != nil {
+		t.Fatalf("error creating client: %s", err)
+	}
+	defer eventClient.Close()
+
+	if err := eventClient.Connect(); err != nil {
+		t.Fatalf("error connecting client: %s", err)
+	}
+
+	if err := eventClient.Submit(dispatcher.NewDisconnectedEvent(errors.New("simulated disconnect"))); err != nil {
+		t.Fatalf("error submitting disconnected event: %s", err)
+	}
+
+	if err := eventClient.WaitForState(Disconnected, 2*time.Second); err != nil {
+		t.Fatalf("expecting client to process the disconnect: %s", err)
+	}
+	if err := eventClient.WaitForState(Connected, 2*time.Second); err != nil {
+		t.Fatalf("expecting client to reconnect: %s", err)
+	}
+
+	// The connected, disconnected and reconnected events are dropped
+	for i := 0; i < 50 && eventClient.DroppedConnectionEvents() < 3; i++ {
+		time.Sleep(20 * time.Millisecond)
+	}
+	if dropped := eventClient.DroppedConnectionEvents(); dropped != 3 {
+		t.Fatalf("expecting 3 dropped connection events but got %d", dropped)
+	}
+}
+
 func TestReconnectRegistration(t *testing.T) {
 	// (1) Connect
 	// (2) Register for block events
@@ -1145,7 +3033,7 @@ var clientProvider = func(channelID string, context context.Context, connectionP
 			fmt.Printf("AfterConnect called")
 			return nil
 		},
-		func() error {
+		func(fab.Peer) error {
 			fmt.Printf("BeforeReconnect called")
 			return nil
 		})
@@ -1165,13 +3053,13 @@ var filteredClientProvider = func(channelID string, context context.Context, con
 			fmt.Printf("AfterConnect called")
 			return nil
 		},
-		func() error {
+		func(fab.Peer) error {
 			fmt.Printf("BeforeReconnect called")
 			return nil
 		})
 }
 
-func newClient(channelID string, context context.Context, connectionProvider api.ConnectionProvider, discoveryService fab.DiscoveryService, opts []options.Opt, permitBlockEvents bool, afterConnect handler, beforeReconnect handler) (*Client, error) {
+func newClient(channelID string, context context.Context, connectionProvider api.ConnectionProvider, discoveryService fab.DiscoveryService, opts []options.Opt, permitBlockEvents bool, afterConnect handler, beforeReconnect reconnectHandler) (*Client, error) {
 	client := New(
 		permitBlockEvents,
 		dispatcher.New(
@@ -1263,3 +3151,65 @@ func checkCCEvent(t *testing.T, event *fab.CCEvent, expectedCCID string, expecte
 func newMockContext() context.Context {
 	return fabmocks.NewMockContext(fabmocks.NewMockUser("user1"))
 }
+
+func TestCloseNotificationReason(t *testing.T) {
+	t.Run("Closed", func(t *testing.T) {
+		testCloseNotificationReason(t, false, esdispatcher.ServiceStopped)
+	})
+	t.Run("Terminated", func(t *testing.T) {
+		testCloseNotificationReason(t, true, esdispatcher.ConnectionTerminated)
+	})
+}
+
+func testCloseNotificationReason(t *testing.T, disconnect bool, expectedReason esdispatcher.CloseReason) {
+	conn := scripted.NewConnection()
+
+	eventClient, err := newClient(
+		"mychannel", newMockContext(),
+		nil,
+		clientmocks.NewDiscoveryService(peer1),
+		[]options.Opt{
+			WithConnectionProvider(scripted.NewProvider(conn)),
+			WithReconnect(false),
+		},
+		true, nil, nil,
+	)
+	if err != nil {
+		t.Fatalf("error creating client: %s", err)
+	}
+	defer eventClient.Close()
+
+	if err := eventClient.Connect(); err != nil {
+		t.Fatalf("error connecting client: %s", err)
+	}
+
+	closech := make(chan *esdispatcher.RegistrationClosedEvent, 1)
+	_, eventch, err := eventClient.RegisterChaincodeEvent("mycc", "event", esdispatcher.WithCloseNotification(closech))
+	if err != nil {
+		t.Fatalf("error registering for chaincode events: %s", err)
+	}
+
+	if disconnect {
+		conn.Disconnect(errors.New("simulated disconnect"))
+	} else {
+		eventClient.Close()
+	}
+
+	select {
+	case _, ok := <-eventch:
+		if ok {
+			t.Fatalf("expecting chaincode event channel to be closed")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for chaincode event channel to close")
+	}
+
+	select {
+	case event := <-closech:
+		if event.Reason != expectedReason {
+			t.Fatalf("expecting close reason [%s] but got [%s]", expectedReason, event.Reason)
+		}
+	default:
+		t.Fatalf("expecting close notification with reason [%s]", expectedReason)
+	}
+}
@@ -0,0 +1,78 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package client
+
+import (
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+)
+
+// debounceState holds the connection event that's held back during the debounce window
+// (see WithConnectionEventDebounce). It's only accessed by the connection monitor.
+type debounceState struct {
+	windowch <-chan time.Time
+	pending  *fab.ConnectionEvent
+	flaps    uint
+}
+
+// debounceConnectionEvent returns the connection events that are to be sent to the
+// subscriber now that the given event has been received
+func (c *Client) debounceConnectionEvent(event *fab.ConnectionEvent) []*fab.ConnectionEvent {
+	if c.connEventDebounce <= 0 {
+		return []*fab.ConnectionEvent{event}
+	}
+
+	if event.Terminal {
+		return append(c.closeDebounceWindow(), event)
+	}
+
+	if c.debounce.windowch == nil {
+		if event.Connected {
+			return []*fab.ConnectionEvent{event}
+		}
+		logger.Debugf("Holding back disconnected event for %s", c.connEventDebounce)
+		c.debounce.windowch = c.after(c.connEventDebounce)
+	} else if event.Connected && c.debounce.pending != nil && !c.debounce.pending.Connected {
+		c.debounce.flaps++
+		logger.Debugf("Connection flapped %d time(s) within the debounce window", c.debounce.flaps)
+	}
+
+	c.debounce.pending = event
+	return nil
+}
+
+// closeDebounceWindow returns the event that was held back (if any), with the number of flaps
+// that occurred during the window, and resets the debounce state
+func (c *Client) closeDebounceWindow() []*fab.ConnectionEvent {
+	pending := c.debounce.pending
+	flaps := c.debounce.flaps
+	c.debounce = debounceState{}
+
+	if pending == nil {
+		return nil
+	}
+
+	summary := *pending
+	summary.Flaps = flaps
+	return []*fab.ConnectionEvent{&summary}
+}
+
+// sendConnectionEvents sends the given events to the subscriber (if any).
+// False is returned if the client was stopped.
+func (c *Client) sendConnectionEvents(events []*fab.ConnectionEvent) bool {
+	if c.connEventCh == nil {
+		return true
+	}
+
+	for _, event := range events {
+		if !c.sendConnectionEvent(event) {
+			return false
+		}
+	}
+	return true
+}
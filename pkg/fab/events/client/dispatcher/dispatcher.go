@@ -8,6 +8,7 @@ package dispatcher
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/hyperledger/fabric-sdk-go/pkg/context"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
@@ -29,13 +30,20 @@ var logger = logging.NewLogger("fabric_sdk_go")
 type Dispatcher struct {
 	esdispatcher.Dispatcher
 	params
-	channelID              string
-	context                context.Context
-	discoveryService       fab.DiscoveryService
-	signingMgr             contextapi.SigningManager
-	connection             api.Connection
-	connectionRegistration *ConnectionReg
-	connectionProvider     api.ConnectionProvider
+	channelID               string
+	context                 context.Context
+	discoveryService        fab.DiscoveryService
+	signingMgr              contextapi.SigningManager
+	connection              api.Connection
+	connectionRegistrations []*ConnectionReg
+	connectionProvider      api.ConnectionProvider
+	pendingConnect          *ConnectEvent
+	idleConnection          api.Connection
+	peer                    fab.Peer
+	connectionURL           string
+	connectionAttempt       uint
+	numConnections          uint
+	connectedTime           time.Time
 }
 
 type handler func(esdispatcher.Event)
@@ -45,7 +53,12 @@ func New(context context.Context, channelID string, connectionProvider api.Conne
 	params := defaultParams()
 	options.Apply(params, opts)
 
-	return &Dispatcher{
+	if params.connectionProvider != nil {
+		// The connection provider was overridden (see client.WithConnectionProvider)
+		connectionProvider = params.connectionProvider
+	}
+
+	dispatcher := &Dispatcher{
 		Dispatcher:         *esdispatcher.New(opts...),
 		params:             *params,
 		context:            context,
@@ -53,6 +66,9 @@ func New(context context.Context, channelID string, connectionProvider api.Conne
 		channelID:          channelID,
 		connectionProvider: connectionProvider,
 	}
+	dispatcher.SetChannelID(channelID)
+
+	return dispatcher
 }
 
 // Start starts the dispatcher
@@ -62,6 +78,9 @@ func (ed *Dispatcher) Start() error {
 	if err := ed.Dispatcher.Start(); err != nil {
 		return errors.WithMessage(err, "error starting client event dispatcher")
 	}
+
+	ed.startIdleMonitor()
+
 	return nil
 }
 
@@ -80,62 +99,160 @@ func (ed *Dispatcher) Connection() api.Connection {
 func (ed *Dispatcher) HandleStopEvent(e esdispatcher.Event) {
 	// Remove all registrations and close the associated event channels
 	// so that the client is notified that the registration has been removed
-	ed.clearConnectionRegistration()
+	ed.clearConnectionRegistrations()
 
 	ed.Dispatcher.HandleStopEvent(e)
 }
 
-// HandleConnectEvent initiates a connection to the event server
+// HandleConnectEvent initiates a connection to the event server. The connection is established outside of
+// the event loop so that the dispatcher keeps handling events while it connects; the result is handled once
+// it's submitted back to the dispatcher (see handleConnectResultEvent).
 func (ed *Dispatcher) HandleConnectEvent(e esdispatcher.Event) {
 	evt := e.(*ConnectEvent)
 
 	if ed.connection != nil {
 		// Already connected. No error.
+		evt.ConnectedPeer = ed.peer
 		evt.ErrCh <- nil
 		return
 	}
 
-	eventch, err := ed.EventCh()
-	if err != nil {
+	if _, err := ed.EventCh(); err != nil {
 		evt.ErrCh <- err
 		return
 	}
 
-	peers, err := ed.discoveryService.GetPeers()
+	peer := evt.Peer
+	if peer == nil {
+		var err error
+		peer, err = ed.choosePeer()
+		if err != nil {
+			evt.ErrCh <- err
+			return
+		}
+	}
+
+	if evt.URL != "" || len(evt.Opts) > 0 {
+		peer = newEndpointOverride(peer, evt.URL, evt.Opts)
+	}
+
+	if ed.pendingConnect != nil {
+		logger.Debugf("Abandoning the pending connection attempt")
+	}
+	ed.pendingConnect = evt
+
+	// The ledger height may require a round trip to the peer so it's only obtained if it's checked
+	checkHeight := ed.BlockHeightResetMargin() > 0
+
+	go func() {
+		result := &connectResultEvent{connectEvent: evt, peer: peer}
+		result.conn, result.err = ed.connectionProvider(ed.channelID, ed.context, peer)
+		if result.err == nil && checkHeight {
+			if ledgerInfo, ok := result.conn.(api.LedgerInfoProvider); ok {
+				result.height, result.heightOK = ledgerInfo.LedgerHeight()
+			}
+		}
+		ed.submitConnectResult(result)
+	}()
+}
+
+// submitConnectResult submits the result of a connection attempt to the dispatcher. The connection is
+// closed if the dispatcher is no longer accepting events.
+func (ed *Dispatcher) submitConnectResult(result *connectResultEvent) {
+	eventch, err := ed.EventCh()
 	if err != nil {
-		evt.ErrCh <- err
+		logger.Debugf("Unable to submit the result of the connection attempt: %s", err)
+		if result.conn != nil {
+			result.conn.Close()
+		}
 		return
 	}
+	eventch <- result
+}
 
-	if len(peers) == 0 {
-		evt.ErrCh <- errors.New("no peers to connect to")
+// handleConnectResultEvent completes a connection attempt. The connection of an attempt that was abandoned,
+// i.e. the attempt was superseded by another attempt or a disconnect was requested while it was pending,
+// is closed.
+func (ed *Dispatcher) handleConnectResultEvent(e esdispatcher.Event) {
+	result := e.(*connectResultEvent)
+	evt := result.connectEvent
+
+	if evt != ed.pendingConnect {
+		logger.Debugf("Received the result of an abandoned connection attempt")
+		if result.conn != nil {
+			result.conn.Close()
+		}
 		return
 	}
+	ed.pendingConnect = nil
 
-	peer, err := ed.loadBalancePolicy.Choose(peers)
-	if err != nil {
-		evt.ErrCh <- err
+	if result.err != nil {
+		logger.Warnf("error creating connection: %s", result.err)
+		evt.ErrCh <- errors.WithMessage(result.err, fmt.Sprintf("could not create client conn"))
 		return
 	}
 
-	conn, err := ed.connectionProvider(ed.channelID, ed.context, peer)
+	eventch, err := ed.EventCh()
 	if err != nil {
-		logger.Warnf("error creating connection: %s", err)
-		evt.ErrCh <- errors.WithMessage(err, fmt.Sprintf("could not create client conn"))
+		result.conn.Close()
+		evt.ErrCh <- err
 		return
 	}
 
-	ed.connection = conn
+	ed.connection = result.conn
+	ed.peer = result.peer
+	ed.connectionURL = eventURL(result.peer)
+	ed.SetSourceURL(ed.connectionURL)
 
-	go ed.connection.Receive(eventch)
+	if result.heightOK {
+		ed.CheckLedgerHeight(result.height)
+	}
+
+	go ed.receive(result.conn, eventch)
 
+	evt.ConnectedPeer = result.peer
 	evt.ErrCh <- nil
 }
 
+// choosePeer chooses one of the discovered peers using the load-balance policy
+func (ed *Dispatcher) choosePeer() (fab.Peer, error) {
+	peers, err := ed.discoveryService.GetPeers()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(peers) == 0 {
+		return nil, errors.New("no peers to connect to")
+	}
+
+	return ed.loadBalancePolicy.Choose(peers)
+}
+
+// receive receives events from the given connection and forwards them to the event channel
+// so that the connection's events are included in the dispatcher's ingest statistics
+func (ed *Dispatcher) receive(conn api.Connection, eventch chan<- interface{}) {
+	connch := make(chan interface{})
+	go func() {
+		conn.Receive(connch)
+		close(connch)
+	}()
+
+	for event := range connch {
+		ed.Enqueue(eventch, event)
+	}
+}
+
 // HandleDisconnectEvent disconnects from the event server
 func (ed *Dispatcher) HandleDisconnectEvent(e esdispatcher.Event) {
 	evt := e.(*DisconnectEvent)
 
+	if ed.connection == nil && ed.pendingConnect != nil {
+		logger.Debugf("Abandoning the pending connection attempt")
+		ed.pendingConnect = nil
+		evt.Errch <- nil
+		return
+	}
+
 	if ed.connection == nil {
 		evt.Errch <- errors.New("connection already closed")
 		return
@@ -145,36 +262,165 @@ func (ed *Dispatcher) HandleDisconnectEvent(e esdispatcher.Event) {
 
 	ed.connection.Close()
 	ed.connection = nil
+	ed.peer = nil
 
 	evt.Errch <- nil
 }
 
+// HandlePingEvent verifies that the connection to the event server is healthy. If the connection
+// doesn't provide a health check then the connection is assumed to be healthy if it isn't closed.
+func (ed *Dispatcher) HandlePingEvent(e esdispatcher.Event) {
+	evt := e.(*PingEvent)
+
+	if ed.connection == nil {
+		evt.ErrCh <- errors.New("not connected")
+		return
+	}
+
+	if hc, ok := ed.connection.(api.HealthChecker); ok {
+		evt.ErrCh <- hc.CheckHealth()
+		return
+	}
+
+	if ed.connection.Closed() {
+		evt.ErrCh <- errors.New("connection is closed")
+		return
+	}
+
+	evt.ErrCh <- nil
+}
+
+// HandleIdleCheckEvent disconnects from the event server if no block has been received for longer than
+// the maximum idle time. The idle time is measured from the later of the time of the last block and the
+// time that the connection was established. The disconnect is submitted as a DisconnectedEvent so that
+// it's handled by the handler that's registered for the event (which may be overridden).
+func (ed *Dispatcher) HandleIdleCheckEvent(e esdispatcher.Event) {
+	if ed.maxIdleTime <= 0 || ed.connection == nil || ed.connection == ed.idleConnection {
+		return
+	}
+
+	lastActivity := ed.LastBlockTime()
+	if ed.connectedTime.After(lastActivity) {
+		lastActivity = ed.connectedTime
+	}
+	if lastActivity.IsZero() {
+		return
+	}
+
+	idleTime := time.Since(lastActivity)
+	if idleTime <= ed.maxIdleTime {
+		return
+	}
+
+	err := errors.Errorf("no blocks received from event server [%s] for %s which exceeds the maximum idle time of %s", ed.connectionURL, idleTime, ed.maxIdleTime)
+	logger.Warnf("%s. Disconnecting...", err)
+
+	eventch, chErr := ed.EventCh()
+	if chErr != nil {
+		logger.Warnf("Unable to submit disconnected event: %s", chErr)
+		return
+	}
+
+	// The connection is recorded so that it's not reported again before the event is handled
+	ed.idleConnection = ed.connection
+	go func() {
+		eventch <- NewDisconnectedEvent(err)
+	}()
+}
+
+// startIdleMonitor periodically posts an IdleCheckEvent if a maximum idle time was specified.
+// The monitor stops once the dispatcher is no longer accepting events.
+func (ed *Dispatcher) startIdleMonitor() {
+	if ed.maxIdleTime <= 0 {
+		return
+	}
+
+	go func() {
+		// Check twice per idle period so that an idle connection is detected within 1.5 times the maximum idle time
+		ticker := time.NewTicker(ed.maxIdleTime / 2)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			eventch, err := ed.EventCh()
+			if err != nil {
+				logger.Debugf("Stopping idle connection monitor: %s", err)
+				return
+			}
+
+			select {
+			case eventch <- NewIdleCheckEvent():
+			default:
+				// The event buffer is full so the connection isn't idle
+			}
+		}
+	}()
+}
+
 // HandleRegisterConnectionEvent registers a connection listener
 func (ed *Dispatcher) HandleRegisterConnectionEvent(e esdispatcher.Event) {
 	evt := e.(*RegisterConnectionEvent)
 
-	if ed.connectionRegistration != nil {
-		evt.ErrCh <- errors.New("registration already exists for connection event")
+	ed.connectionRegistrations = append(ed.connectionRegistrations, evt.Reg)
+	evt.RegCh <- evt.Reg
+}
+
+// HandleUnregisterEvent unregisters a connection listener. Other types
+// of registration are unregistered by the event service dispatcher.
+func (ed *Dispatcher) HandleUnregisterEvent(e esdispatcher.Event) {
+	evt := e.(*esdispatcher.UnregisterEvent)
+
+	reg, ok := evt.Reg.(*ConnectionReg)
+	if !ok {
+		ed.Dispatcher.HandleUnregisterEvent(e)
 		return
 	}
 
-	ed.connectionRegistration = evt.Reg
-	evt.RegCh <- evt.Reg
+	if err := ed.unregisterConnectionEvents(reg); err != nil {
+		logger.Warnf("Error in unregister: %s", err)
+	}
 }
 
-// HandleConnectedEvent sends a 'connected' event to any registered listener
-func (ed *Dispatcher) HandleConnectedEvent(e esdispatcher.Event) {
-	evt := e.(*ConnectedEvent)
-
-	logger.Debugf("Handling connected event: %v", evt)
+func (ed *Dispatcher) unregisterConnectionEvents(registration *ConnectionReg) error {
+	for i, reg := range ed.connectionRegistrations {
+		if reg == registration {
+			ed.connectionRegistrations = append(ed.connectionRegistrations[:i], ed.connectionRegistrations[i+1:]...)
+			close(reg.Eventch)
+			return nil
+		}
+	}
+	return errors.New("the provided registration is invalid")
+}
 
-	if ed.connectionRegistration != nil && ed.connectionRegistration.Eventch != nil {
+// publishConnectionEvent sends the given event to the connection listeners. An event
+// is dropped for a listener whose event channel is full.
+func (ed *Dispatcher) publishConnectionEvent(event *fab.ConnectionEvent) bool {
+	for _, reg := range ed.connectionRegistrations {
 		select {
-		case ed.connectionRegistration.Eventch <- &fab.ConnectionEvent{Connected: true}:
+		case reg.Eventch <- event:
 		default:
 			logger.Warnf("Unable to send to connection event channel.")
 		}
 	}
+	return len(ed.connectionRegistrations) > 0
+}
+
+// HandleConnectedEvent sends a 'connected' event to any registered listener
+func (ed *Dispatcher) HandleConnectedEvent(e esdispatcher.Event) {
+	evt := e.(*ConnectedEvent)
+
+	logger.Debugf("Handling connected event: %v", evt)
+
+	ed.connectionAttempt = evt.Attempt
+	ed.connectedTime = time.Now()
+	ed.numConnections++
+
+	ed.publishConnectionEvent(&fab.ConnectionEvent{
+		Connected:     true,
+		URL:           ed.connectionURL,
+		Attempt:       ed.connectionAttempt,
+		Reconnects:    ed.reconnects(),
+		ConnectedTime: ed.connectedTime,
+	})
 }
 
 // HandleDisconnectedEvent sends a 'disconnected' event to any registered listener
@@ -183,18 +429,30 @@ func (ed *Dispatcher) HandleDisconnectedEvent(e esdispatcher.Event) {
 
 	logger.Debugf("Disconnecting from event server: %s", evt.Err)
 
+	ed.idleConnection = nil
 	if ed.connection != nil {
 		ed.connection.Close()
 		ed.connection = nil
+		ed.peer = nil
 	}
 
-	if ed.connectionRegistration != nil {
+	event := &fab.ConnectionEvent{
+		Connected:        false,
+		Err:              evt.Err,
+		URL:              ed.connectionURL,
+		Attempt:          ed.connectionAttempt,
+		Reconnects:       ed.reconnects(),
+		ConnectedTime:    ed.connectedTime,
+		DisconnectedTime: time.Now(),
+		Terminal:         evt.Terminal,
+	}
+	if !ed.connectedTime.IsZero() {
+		event.Duration = event.DisconnectedTime.Sub(ed.connectedTime)
+	}
+	ed.connectedTime = time.Time{}
+
+	if ed.publishConnectionEvent(event) {
 		logger.Debugf("Disconnected from event server: %s", evt.Err)
-		select {
-		case ed.connectionRegistration.Eventch <- &fab.ConnectionEvent{Connected: false, Err: evt.Err}:
-		default:
-			logger.Warnf("Unable to send to connection event channel.")
-		}
 	} else {
 		logger.Warnf("Disconnected from event server: %s", evt.Err)
 	}
@@ -203,19 +461,46 @@ func (ed *Dispatcher) HandleDisconnectedEvent(e esdispatcher.Event) {
 func (ed *Dispatcher) registerHandlers() {
 	// Override existing handlers
 	ed.RegisterHandler(&esdispatcher.StopEvent{}, ed.HandleStopEvent)
+	ed.RegisterHandler(&esdispatcher.UnregisterEvent{}, ed.HandleUnregisterEvent)
 
 	// Register new handlers
 	ed.RegisterHandler(&ConnectEvent{}, ed.HandleConnectEvent)
+	ed.RegisterHandler(&connectResultEvent{}, ed.handleConnectResultEvent)
 	ed.RegisterHandler(&DisconnectEvent{}, ed.HandleDisconnectEvent)
 	ed.RegisterHandler(&ConnectedEvent{}, ed.HandleConnectedEvent)
 	ed.RegisterHandler(&DisconnectedEvent{}, ed.HandleDisconnectedEvent)
+	ed.RegisterHandler(&PingEvent{}, ed.HandlePingEvent)
+	ed.RegisterHandler(&IdleCheckEvent{}, ed.HandleIdleCheckEvent)
 	ed.RegisterHandler(&RegisterConnectionEvent{}, ed.HandleRegisterConnectionEvent)
 }
 
-func (ed *Dispatcher) clearConnectionRegistration() {
-	if ed.connectionRegistration != nil {
+func (ed *Dispatcher) clearConnectionRegistrations() {
+	for _, reg := range ed.connectionRegistrations {
 		logger.Debugf("Closing connection registration event channel.")
-		close(ed.connectionRegistration.Eventch)
-		ed.connectionRegistration = nil
+		close(reg.Eventch)
+	}
+	ed.connectionRegistrations = nil
+}
+
+// reconnects returns the number of times that the connection was re-established
+// after the first connection
+func (ed *Dispatcher) reconnects() uint {
+	if ed.numConnections == 0 {
+		return 0
+	}
+	return ed.numConnections - 1
+}
+
+type eventURLProvider interface {
+	EventURL() string
+}
+
+// eventURL returns the URL of the event server. If the peer provides an event URL
+// (which may be different from the peer URL, for example, for the event hub)
+// then the event URL is returned.
+func eventURL(peer fab.Peer) string {
+	if p, ok := peer.(eventURLProvider); ok {
+		return p.EventURL()
 	}
+	return peer.URL()
 }
@@ -7,11 +7,15 @@ SPDX-License-Identifier: Apache-2.0
 package dispatcher
 
 import (
+	"math"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/hyperledger/fabric-sdk-go/pkg/context"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/api"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/client/lbp"
 
 	clientmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/events/client/mocks"
@@ -183,6 +187,18 @@ func TestConnectionEvent(t *testing.T) {
 					}
 					return
 				}
+				if event.URL != peer1.URL() && event.URL != peer2.URL() {
+					errch <- errors.Errorf("unexpected event server URL [%s]", event.URL)
+					return
+				}
+				if event.Attempt != 2 {
+					errch <- errors.Errorf("expecting connection attempt 2 but got %d", event.Attempt)
+					return
+				}
+				if event.ConnectedTime.IsZero() {
+					errch <- errors.New("expecting connected time to be set")
+					return
+				}
 				if event.Connected {
 					if state != "" {
 						errch <- errors.New("unexpected connected event")
@@ -198,6 +214,10 @@ func TestConnectionEvent(t *testing.T) {
 						errch <- errors.Errorf("unexpected disconnect error [%s] but got [%s]", expectedDisconnectErr, event.Err.Error())
 						return
 					}
+					if event.Duration <= 0 || !event.DisconnectedTime.After(event.ConnectedTime) {
+						errch <- errors.Errorf("unexpected connection duration [%s] from [%s] to [%s]", event.Duration, event.ConnectedTime, event.DisconnectedTime)
+						return
+					}
 					state = "disconnected"
 				}
 			case <-time.After(5 * time.Second):
@@ -221,7 +241,15 @@ func TestConnectionEvent(t *testing.T) {
 	}
 
 	// Connect
-	dispatcherEventch <- NewConnectedEvent()
+	connerrch := make(chan error)
+	dispatcherEventch <- NewConnectEvent(connerrch)
+	if err := <-connerrch; err != nil {
+		t.Fatalf("Error connecting: %s", err)
+	}
+
+	connectedEvent := NewConnectedEvent()
+	connectedEvent.Attempt = 2
+	dispatcherEventch <- connectedEvent
 	time.Sleep(500 * time.Millisecond)
 
 	// Disconnect
@@ -241,6 +269,340 @@ func TestConnectionEvent(t *testing.T) {
 	}
 }
 
+func TestIdleCheck(t *testing.T) {
+	testIdleCheck(t, 0)
+	testIdleCheck(t, 300*time.Millisecond)
+}
+
+func testIdleCheck(t *testing.T, maxIdleTime time.Duration) {
+	channelID := "testchannel"
+
+	dispatcher := New(
+		newMockContext(), channelID,
+		clientmocks.NewProviderFactory().Provider(
+			clientmocks.NewMockConnection(
+				clientmocks.WithLedger(
+					servicemocks.NewMockLedger(servicemocks.BlockEventFactory),
+				),
+			),
+		),
+		clientmocks.NewDiscoveryService(peer1, peer2),
+		WithMaxIdleTime(maxIdleTime),
+	)
+
+	// Override the disconnected handler (as the deliver dispatcher does) to check that it's invoked
+	var numDisconnected int32
+	dispatcher.RegisterHandler(&DisconnectedEvent{}, func(e esdispatcher.Event) {
+		atomic.AddInt32(&numDisconnected, 1)
+		dispatcher.HandleDisconnectedEvent(e)
+	})
+
+	if err := dispatcher.Start(); err != nil {
+		t.Fatalf("Error starting dispatcher: %s", err)
+	}
+
+	dispatcherEventch, err := dispatcher.EventCh()
+	if err != nil {
+		t.Fatalf("Error getting event channel from dispatcher: %s", err)
+	}
+
+	connch := make(chan *fab.ConnectionEvent, 10)
+	regch := make(chan fab.Registration)
+	regerrch := make(chan error)
+	dispatcherEventch <- NewRegisterConnectionEvent(connch, regch, regerrch)
+	select {
+	case <-regch:
+	case err := <-regerrch:
+		t.Fatalf("Error registering for connection events: %s", err)
+	}
+
+	connerrch := make(chan error)
+	dispatcherEventch <- NewConnectEvent(connerrch)
+	if err := <-connerrch; err != nil {
+		t.Fatalf("Error connecting: %s", err)
+	}
+	dispatcherEventch <- NewConnectedEvent()
+	if event := <-connch; !event.Connected {
+		t.Fatalf("Expecting connected event")
+	}
+
+	// The connection was just established so it isn't idle
+	dispatcherEventch <- NewIdleCheckEvent()
+
+	// Blocks are received regularly so the connection isn't idle
+	blockProducer := servicemocks.NewBlockProducer()
+	for i := 0; i < 6; i++ {
+		dispatcherEventch <- blockProducer.NewBlock(channelID)
+		time.Sleep(100 * time.Millisecond)
+		dispatcherEventch <- NewIdleCheckEvent()
+	}
+
+	select {
+	case event := <-connch:
+		t.Fatalf("Expecting no connection event while blocks are being received but got: %+v", event)
+	default:
+	}
+
+	// No blocks are received. The connection is only reported once while the disconnect is pending.
+	time.Sleep(500 * time.Millisecond)
+	dispatcherEventch <- NewIdleCheckEvent()
+	dispatcherEventch <- NewIdleCheckEvent()
+
+	if maxIdleTime == 0 {
+		select {
+		case event := <-connch:
+			t.Fatalf("Expecting no connection event since idle detection is disabled but got: %+v", event)
+		case <-time.After(200 * time.Millisecond):
+		}
+		if dispatcher.Connection() == nil {
+			t.Fatalf("Expecting connection to remain open since idle detection is disabled")
+		}
+	} else {
+		select {
+		case event := <-connch:
+			if event.Connected || event.Err == nil {
+				t.Fatalf("Expecting disconnected event with an error but got: %+v", event)
+			}
+			if !strings.Contains(event.Err.Error(), "maximum idle time") {
+				t.Fatalf("Expecting idle error but got: %s", event.Err)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Timed out waiting for disconnected event")
+		}
+		select {
+		case event := <-connch:
+			t.Fatalf("Expecting a single disconnected event but got: %+v", event)
+		case <-time.After(200 * time.Millisecond):
+		}
+		if n := atomic.LoadInt32(&numDisconnected); n != 1 {
+			t.Fatalf("Expecting the registered disconnected handler to be invoked once but it was invoked %d times", n)
+		}
+	}
+
+	stopResp := make(chan error)
+	dispatcherEventch <- esdispatcher.NewStopEvent(stopResp)
+	if err := <-stopResp; err != nil {
+		t.Fatalf("Error stopping dispatcher: %s", err)
+	}
+}
+
 func newMockContext() context.Context {
 	return fabmocks.NewMockContext(fabmocks.NewMockUser("user1"))
 }
+
+func TestResetOnConnect(t *testing.T) {
+	channelID := "testchannel"
+	ledger := servicemocks.NewMockLedger(servicemocks.FilteredBlockEventFactory)
+
+	// The first connection doesn't report a height, the second reports a height within
+	// the margin and the third a height that's lower than the margin (i.e. the channel was re-created)
+	connections := []api.Connection{
+		clientmocks.NewMockConnection(clientmocks.WithLedger(ledger)),
+		clientmocks.NewMockConnection(clientmocks.WithLedger(ledger), clientmocks.WithLedgerHeight(3)),
+		clientmocks.NewMockConnection(clientmocks.WithLedger(ledger), clientmocks.WithLedgerHeight(1)),
+	}
+	var numConnections int
+	connectionProvider := func(channelID string, context context.Context, peer fab.Peer) (api.Connection, error) {
+		conn := connections[numConnections]
+		numConnections++
+		return conn, nil
+	}
+
+	dispatcher := New(
+		newMockContext(), channelID, connectionProvider,
+		clientmocks.NewDiscoveryService(peer1),
+		esdispatcher.WithBlockHeightResetMargin(2),
+	)
+	if err := dispatcher.Start(); err != nil {
+		t.Fatalf("Error starting dispatcher: %s", err)
+	}
+
+	dispatcherEventch, err := dispatcher.EventCh()
+	if err != nil {
+		t.Fatalf("Error getting event channel from dispatcher: %s", err)
+	}
+
+	errch := make(chan error)
+	connect := func() {
+		dispatcherEventch <- NewConnectEvent(errch)
+		if err := <-errch; err != nil {
+			t.Fatalf("Error connecting: %s", err)
+		}
+	}
+	disconnect := func() {
+		dispatcherEventch <- NewDisconnectEvent(errch)
+		if err := <-errch; err != nil {
+			t.Fatalf("Error disconnecting: %s", err)
+		}
+	}
+
+	connect()
+
+	producer := servicemocks.NewBlockProducer()
+	for i := 0; i < 5; i++ {
+		dispatcherEventch <- producer.NewFilteredBlock(channelID)
+	}
+	waitForLastBlockNum(t, dispatcher, 4)
+
+	disconnect()
+	connect()
+	waitForLastBlockNum(t, dispatcher, 4)
+
+	disconnect()
+	connect()
+	waitForLastBlockNum(t, dispatcher, math.MaxUint64)
+
+	disconnect()
+
+	stopResp := make(chan error)
+	dispatcherEventch <- esdispatcher.NewStopEvent(stopResp)
+	if err := <-stopResp; err != nil {
+		t.Fatalf("Error stopping dispatcher: %s", err)
+	}
+}
+
+func waitForLastBlockNum(t *testing.T, dispatcher *Dispatcher, expected uint64) {
+	deadline := time.Now().Add(2 * time.Second)
+	for dispatcher.LastBlockNum() != expected {
+		if time.Now().After(deadline) {
+			t.Fatalf("expecting last block number %d but got %d", expected, dispatcher.LastBlockNum())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestConnectionIngestStats(t *testing.T) {
+	channelID := "testchannel"
+	ledger := servicemocks.NewMockLedger(servicemocks.FilteredBlockEventFactory)
+	conn := clientmocks.NewMockConnection(clientmocks.WithLedger(ledger))
+	connectionProvider := func(channelID string, context context.Context, peer fab.Peer) (api.Connection, error) {
+		return conn, nil
+	}
+
+	dispatcher := New(
+		newMockContext(), channelID, connectionProvider,
+		clientmocks.NewDiscoveryService(peer1),
+	)
+	if err := dispatcher.Start(); err != nil {
+		t.Fatalf("Error starting dispatcher: %s", err)
+	}
+
+	dispatcherEventch, err := dispatcher.EventCh()
+	if err != nil {
+		t.Fatalf("Error getting event channel from dispatcher: %s", err)
+	}
+
+	errch := make(chan error)
+	dispatcherEventch <- NewConnectEvent(errch)
+	if err := <-errch; err != nil {
+		t.Fatalf("Error connecting: %s", err)
+	}
+
+	producer := servicemocks.NewBlockProducer()
+	for i := 0; i < 3; i++ {
+		conn.ProduceEvent(producer.NewFilteredBlock(channelID))
+		waitForLastBlockNum(t, dispatcher, uint64(i))
+	}
+
+	if stats := dispatcher.IngestStats(); stats.Submitted != 3 {
+		t.Fatalf("expecting 3 events from the connection to be recorded but got %d", stats.Submitted)
+	}
+
+	dispatcherEventch <- NewDisconnectEvent(errch)
+	if err := <-errch; err != nil {
+		t.Fatalf("Error disconnecting: %s", err)
+	}
+
+	stopResp := make(chan error)
+	dispatcherEventch <- esdispatcher.NewStopEvent(stopResp)
+	if err := <-stopResp; err != nil {
+		t.Fatalf("Error stopping dispatcher: %s", err)
+	}
+}
+
+func TestReconnects(t *testing.T) {
+	channelID := "testchannel"
+
+	dispatcher := New(
+		newMockContext(), channelID,
+		clientmocks.NewProviderFactory().Provider(
+			clientmocks.NewMockConnection(
+				clientmocks.WithLedger(
+					servicemocks.NewMockLedger(servicemocks.BlockEventFactory),
+				),
+			),
+		),
+		clientmocks.NewDiscoveryService(peer1),
+	)
+	if err := dispatcher.Start(); err != nil {
+		t.Fatalf("Error starting dispatcher: %s", err)
+	}
+
+	dispatcherEventch, err := dispatcher.EventCh()
+	if err != nil {
+		t.Fatalf("Error getting event channel from dispatcher: %s", err)
+	}
+
+	connch := make(chan *fab.ConnectionEvent, 10)
+	regch := make(chan fab.Registration)
+	errch := make(chan error)
+	dispatcherEventch <- NewRegisterConnectionEvent(connch, regch, errch)
+	select {
+	case <-regch:
+	case err := <-errch:
+		t.Fatalf("Error registering for connection events: %s", err)
+	}
+
+	checkEvent := func(connected bool, reconnects uint) {
+		select {
+		case event := <-connch:
+			if event.Connected != connected || event.Reconnects != reconnects {
+				t.Fatalf("expecting connected: %t, reconnects: %d but got connected: %t, reconnects: %d", connected, reconnects, event.Connected, event.Reconnects)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for connection event")
+		}
+	}
+
+	for i := uint(0); i < 3; i++ {
+		dispatcherEventch <- NewConnectEvent(errch)
+		if err := <-errch; err != nil {
+			t.Fatalf("Error connecting: %s", err)
+		}
+
+		// The attempt is reset for each reconnect but the number of reconnects is cumulative
+		connectedEvent := NewConnectedEvent()
+		connectedEvent.Attempt = 1
+		dispatcherEventch <- connectedEvent
+		checkEvent(true, i)
+
+		dispatcherEventch <- NewDisconnectedEvent(errors.New("simulated disconnect error"))
+		checkEvent(false, i)
+	}
+
+	stopResp := make(chan error)
+	dispatcherEventch <- esdispatcher.NewStopEvent(stopResp)
+	if err := <-stopResp; err != nil {
+		t.Fatalf("Error stopping dispatcher: %s", err)
+	}
+}
+
+type eventEndpoint struct {
+	fab.Peer
+	url string
+}
+
+func (e *eventEndpoint) EventURL() string {
+	return e.url
+}
+
+func TestEventURL(t *testing.T) {
+	if url := eventURL(peer1); url != peer1.URL() {
+		t.Fatalf("expecting peer URL [%s] but got [%s]", peer1.URL(), url)
+	}
+
+	// The embedded peer must not be dereferenced
+	if url := eventURL(&eventEndpoint{url: "grpc://event.example.com:7053"}); url != "grpc://event.example.com:7053" {
+		t.Fatalf("expecting event URL [grpc://event.example.com:7053] but got [%s]", url)
+	}
+}
@@ -0,0 +1,42 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package dispatcher
+
+import (
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/options"
+)
+
+// endpointOverride is a peer whose (event) URL and connection options
+// are overridden by the ones provided with the connect request
+type endpointOverride struct {
+	fab.Peer
+	url  string
+	opts []options.Opt
+}
+
+func newEndpointOverride(peer fab.Peer, url string, opts []options.Opt) *endpointOverride {
+	if url == "" {
+		url = eventURL(peer)
+	}
+	return &endpointOverride{Peer: peer, url: url, opts: opts}
+}
+
+// URL returns the overridden URL
+func (p *endpointOverride) URL() string {
+	return p.url
+}
+
+// EventURL returns the overridden URL
+func (p *endpointOverride) EventURL() string {
+	return p.url
+}
+
+// ConnectionOpts returns the options for the connection to the event server
+func (p *endpointOverride) ConnectionOpts() []options.Opt {
+	return p.opts
+}
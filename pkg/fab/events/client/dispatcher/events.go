@@ -8,7 +8,9 @@ package dispatcher
 
 import (
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/api"
 	esdispatcher "github.com/hyperledger/fabric-sdk-go/pkg/fab/events/service/dispatcher"
+	"github.com/hyperledger/fabric-sdk-go/pkg/options"
 )
 
 // RegisterConnectionEvent is a request to register for connection events
@@ -27,6 +29,8 @@ func NewRegisterConnectionEvent(eventch chan<- *fab.ConnectionEvent, regch chan<
 
 // ConnectedEvent indicates that the client has connected to the server
 type ConnectedEvent struct {
+	// Attempt is the connection attempt that established the connection
+	Attempt uint
 }
 
 // NewConnectedEvent creates a new ConnectedEvent
@@ -37,6 +41,8 @@ func NewConnectedEvent() *ConnectedEvent {
 // DisconnectedEvent indicates that the client has disconnected from the server
 type DisconnectedEvent struct {
 	Err error
+	// Terminal indicates that the client won't attempt to reconnect
+	Terminal bool
 }
 
 // NewDisconnectedEvent creates a new DisconnectedEvent
@@ -48,6 +54,16 @@ func NewDisconnectedEvent(err error) *DisconnectedEvent {
 type ConnectEvent struct {
 	ErrCh        chan<- error
 	FromBlockNum uint64
+	// Peer is the peer to connect to. If nil then a peer is chosen
+	// from the discovered peers using the load-balance policy.
+	Peer fab.Peer
+	// URL overrides the URL of the peer to connect to (if not empty)
+	URL string
+	// Opts are the options for the connection to the event server
+	Opts []options.Opt
+	// ConnectedPeer is set by the dispatcher to the peer that it's
+	// connected to before a nil error is sent on ErrCh
+	ConnectedPeer fab.Peer
 }
 
 // NewConnectEvent creates a new ConnectEvent
@@ -55,6 +71,16 @@ func NewConnectEvent(errch chan<- error) *ConnectEvent {
 	return &ConnectEvent{ErrCh: errch}
 }
 
+// connectResultEvent is the result of a connection attempt that's made outside of the event loop
+type connectResultEvent struct {
+	connectEvent *ConnectEvent
+	peer         fab.Peer
+	conn         api.Connection
+	err          error
+	height       uint64
+	heightOK     bool
+}
+
 // DisconnectEvent is a request to disconnect to the server
 type DisconnectEvent struct {
 	Errch chan<- error
@@ -64,3 +90,23 @@ type DisconnectEvent struct {
 func NewDisconnectEvent(errch chan<- error) *DisconnectEvent {
 	return &DisconnectEvent{Errch: errch}
 }
+
+// PingEvent is a request to verify that the connection to the server is healthy
+type PingEvent struct {
+	ErrCh chan<- error
+}
+
+// NewPingEvent creates a new PingEvent
+func NewPingEvent(errch chan<- error) *PingEvent {
+	return &PingEvent{ErrCh: errch}
+}
+
+// IdleCheckEvent is posted periodically to check whether the connection has
+// been idle for longer than the maximum idle time (see WithMaxIdleTime)
+type IdleCheckEvent struct {
+}
+
+// NewIdleCheckEvent creates a new IdleCheckEvent
+func NewIdleCheckEvent() *IdleCheckEvent {
+	return &IdleCheckEvent{}
+}
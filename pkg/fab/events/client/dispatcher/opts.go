@@ -7,12 +7,17 @@ SPDX-License-Identifier: Apache-2.0
 package dispatcher
 
 import (
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/api"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/client/lbp"
 	"github.com/hyperledger/fabric-sdk-go/pkg/options"
 )
 
 type params struct {
-	loadBalancePolicy lbp.LoadBalancePolicy
+	loadBalancePolicy  lbp.LoadBalancePolicy
+	maxIdleTime        time.Duration
+	connectionProvider api.ConnectionProvider
 }
 
 func defaultParams() *params {
@@ -39,3 +44,30 @@ func (p *params) SetLoadBalancePolicy(value lbp.LoadBalancePolicy) {
 	logger.Debugf("LoadBalancePolicy: %#v", value)
 	p.loadBalancePolicy = value
 }
+
+// WithMaxIdleTime sets the maximum time that the connection may remain idle, i.e. without a
+// block being received. If the connection is idle for longer than this time then it's assumed
+// to be dead and the client is disconnected (and possibly reconnected, depending on the client's
+// reconnect options). If zero (the default) then idle connections are not detected, which
+// should be used for channels that legitimately produce no blocks for long periods.
+func WithMaxIdleTime(value time.Duration) options.Opt {
+	return func(p options.Params) {
+		if setter, ok := p.(maxIdleTimeSetter); ok {
+			setter.SetMaxIdleTime(value)
+		}
+	}
+}
+
+type maxIdleTimeSetter interface {
+	SetMaxIdleTime(value time.Duration)
+}
+
+func (p *params) SetMaxIdleTime(value time.Duration) {
+	logger.Debugf("MaxIdleTime: %s", value)
+	p.maxIdleTime = value
+}
+
+func (p *params) SetConnectionProvider(value api.ConnectionProvider) {
+	logger.Debugf("ConnectionProvider: %#v", value)
+	p.connectionProvider = value
+}
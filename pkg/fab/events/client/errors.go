@@ -0,0 +1,47 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package client
+
+import (
+	"fmt"
+	"time"
+)
+
+// ConnectAttemptsExceededError is returned when the client gives up connecting
+// because the maximum number of connection attempts was reached
+type ConnectAttemptsExceededError struct {
+	// Attempts is the number of connection attempts that were made
+	Attempts uint
+	// LastDisconnect is the reason for the last disconnect (if any) before the client tried to connect
+	LastDisconnect error
+}
+
+func (e *ConnectAttemptsExceededError) Error() string {
+	return withLastDisconnect(fmt.Sprintf("maximum connect attempts exceeded after %d attempts", e.Attempts), e.LastDisconnect)
+}
+
+// ConnectDurationExceededError is returned when the client gives up reconnecting
+// because the maximum reconnect duration was reached (see WithMaxReconnectDuration)
+type ConnectDurationExceededError struct {
+	// Attempts is the number of connection attempts that were made
+	Attempts uint
+	// Duration is the time that was spent trying to connect
+	Duration time.Duration
+	// LastDisconnect is the reason for the last disconnect (if any) before the client tried to reconnect
+	LastDisconnect error
+}
+
+func (e *ConnectDurationExceededError) Error() string {
+	return withLastDisconnect(fmt.Sprintf("maximum reconnect duration exceeded after %d attempts in %s", e.Attempts, e.Duration), e.LastDisconnect)
+}
+
+func withLastDisconnect(msg string, lastDisconnect error) string {
+	if lastDisconnect == nil {
+		return msg
+	}
+	return fmt.Sprintf("%s (last disconnect: %s)", msg, lastDisconnect)
+}
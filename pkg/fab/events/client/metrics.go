@@ -0,0 +1,112 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package client
+
+import (
+	"time"
+)
+
+// Metrics contains operational counters of the event client
+type Metrics struct {
+	// State is the connection state of the client
+	State ConnectionState
+
+	// Connects is the number of times that the client successfully connected (including reconnects)
+	Connects uint64
+
+	// ReconnectAttempts is the number of attempts that the client made to reconnect after the connection was lost
+	ReconnectAttempts uint64
+
+	// ConnectedDuration is the total time for which the client has been connected (including the current connection)
+	ConnectedDuration time.Duration
+
+	// DisconnectedDuration is the total time for which the client has not been connected, i.e. the client
+	// was either disconnected or connecting, since the client was created
+	DisconnectedDuration time.Duration
+
+	// ConnectionAge is the time since the current connection was established (or 0 if the client is not connected)
+	ConnectionAge time.Duration
+
+	// TimeSinceLastBlock is the time since the last block event was received (or 0 if no block
+	// event has been received or the dispatcher doesn't record the time of the last block)
+	TimeSinceLastBlock time.Duration
+}
+
+// MetricsObserver is invoked with a snapshot of the client's metrics on every connection state change
+type MetricsObserver func(Metrics)
+
+// metrics holds the counters from which a Metrics snapshot is taken. The
+// state mutex of the client must be held when accessing these values.
+type metrics struct {
+	connects             uint64
+	reconnectAttempts    uint64
+	connectedDuration    time.Duration
+	disconnectedDuration time.Duration
+	stateSince           time.Time
+}
+
+type lastBlockTimeProvider interface {
+	LastBlockTime() time.Time
+}
+
+// Metrics returns a snapshot of the client's operational counters
+func (c *Client) Metrics() Metrics {
+	c.stateMutex.Lock()
+	defer c.stateMutex.Unlock()
+	return c.snapshotMetrics()
+}
+
+// recordReconnectAttempt increments the number of reconnect attempts
+func (c *Client) recordReconnectAttempt() {
+	c.stateMutex.Lock()
+	defer c.stateMutex.Unlock()
+	c.metrics.reconnectAttempts++
+}
+
+// recordStateChange accumulates the time spent in the old state.
+// The state mutex must be held.
+func (c *Client) recordStateChange(oldState, newState ConnectionState) {
+	now := c.now()
+	if oldState == Connected {
+		c.metrics.connectedDuration += now.Sub(c.metrics.stateSince)
+	} else {
+		c.metrics.disconnectedDuration += now.Sub(c.metrics.stateSince)
+	}
+	c.metrics.stateSince = now
+
+	if newState == Connected {
+		c.metrics.connects++
+	}
+}
+
+// snapshotMetrics returns the current metrics. The state mutex must be held.
+func (c *Client) snapshotMetrics() Metrics {
+	now := c.now()
+	state := c.ConnectionState()
+
+	m := Metrics{
+		State:                state,
+		Connects:             c.metrics.connects,
+		ReconnectAttempts:    c.metrics.reconnectAttempts,
+		ConnectedDuration:    c.metrics.connectedDuration,
+		DisconnectedDuration: c.metrics.disconnectedDuration,
+	}
+
+	inState := now.Sub(c.metrics.stateSince)
+	if state == Connected {
+		m.ConnectedDuration += inState
+		m.ConnectionAge = inState
+	} else {
+		m.DisconnectedDuration += inState
+	}
+
+	if _, receivedAt, ok := c.LastBlockReceived(); ok && !receivedAt.IsZero() {
+		m.TimeSinceLastBlock = now.Sub(receivedAt)
+	}
+
+	return m
+}
@@ -0,0 +1,133 @@
+//go:build testing
+// +build testing
+
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/client/dispatcher"
+	clientmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/events/client/mocks"
+	mockconn "github.com/hyperledger/fabric-sdk-go/pkg/fab/events/client/mocks"
+	servicemocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/events/service/mocks"
+	"github.com/hyperledger/fabric-sdk-go/pkg/options"
+	cb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+	"github.com/pkg/errors"
+)
+
+func TestMetrics(t *testing.T) {
+	channelID := "mychannel"
+	clock := &fakeClock{now: time.Now()}
+	ledger := servicemocks.NewMockLedger(servicemocks.BlockEventFactory)
+	observed := make(chan Metrics, 20)
+
+	// Each reconnect takes two seconds
+	beforeReconnect := func(peer fab.Peer) error {
+		clock.advance(2 * time.Second)
+		return nil
+	}
+
+	eventClient, err := newClient(
+		channelID, newMockContext(),
+		mockconn.NewProviderFactory().FlakeyProvider(
+			mockconn.NewConnectResults(
+				mockconn.NewConnectResult(mockconn.FirstAttempt, mockconn.SucceedResult),
+				mockconn.NewConnectResult(mockconn.SecondAttempt, mockconn.SucceedResult),
+				mockconn.NewConnectResult(mockconn.ThirdAttempt, mockconn.SucceedResult),
+			),
+			mockconn.WithLedger(ledger),
+		),
+		clientmocks.NewDiscoveryService(peer1, peer2),
+		[]options.Opt{
+			WithReconnect(true),
+			WithMaxReconnectAttempts(1),
+			WithMetricsObserver(func(m Metrics) { observed <- m }),
+		},
+		true, nil, beforeReconnect,
+	)
+	if err != nil {
+		t.Fatalf("error creating client: %s", err)
+	}
+	defer eventClient.Close()
+
+	eventClient.now = clock.Now
+	eventClient.metrics.stateSince = clock.Now()
+
+	clock.advance(time.Second)
+
+	if err := eventClient.Connect(); err != nil {
+		t.Fatalf("error connecting client: %s", err)
+	}
+
+	m := checkMetrics(t, observed, Connected, 1)
+	if m.ConnectionAge != 0 || m.DisconnectedDuration != time.Second {
+		t.Fatalf("unexpected metrics after connecting: %+v", m)
+	}
+	if m.TimeSinceLastBlock != 0 {
+		t.Fatalf("expecting no time since last block before a block was received but got %s", m.TimeSinceLastBlock)
+	}
+
+	// Two disconnect/reconnect cycles with ten seconds of connected time each
+	for i := 0; i < 2; i++ {
+		clock.advance(10 * time.Second)
+		if err := eventClient.Submit(dispatcher.NewDisconnectedEvent(errors.New("simulated disconnect"))); err != nil {
+			t.Fatalf("error submitting disconnected event: %s", err)
+		}
+		checkMetrics(t, observed, Connected, uint64(i+2))
+	}
+
+	clock.advance(5 * time.Second)
+
+	m = eventClient.Metrics()
+	expected := Metrics{
+		State:                Connected,
+		Connects:             3,
+		ReconnectAttempts:    2,
+		ConnectedDuration:    25 * time.Second,
+		DisconnectedDuration: 5 * time.Second,
+		ConnectionAge:        5 * time.Second,
+	}
+	if m != expected {
+		t.Fatalf("expecting metrics %+v but got %+v", expected, m)
+	}
+
+	_, blockch, err := eventClient.RegisterBlockEvent()
+	if err != nil {
+		t.Fatalf("error registering for block events: %s", err)
+	}
+	ledger.NewBlock(channelID,
+		servicemocks.NewTransaction("txID", pb.TxValidationCode_VALID, cb.HeaderType_ENDORSER_TRANSACTION),
+	)
+	select {
+	case <-blockch:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for block event")
+	}
+
+	if m := eventClient.Metrics(); m.TimeSinceLastBlock == 0 {
+		t.Fatalf("expecting time since last block to be set after a block was received")
+	}
+}
+
+// checkMetrics waits for the observer to be notified of the given state and number of connects
+func checkMetrics(t *testing.T, observed chan Metrics, state ConnectionState, connects uint64) Metrics {
+	for {
+		select {
+		case m := <-observed:
+			if m.State == state && m.Connects == connects {
+				return m
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for metrics with state [%s] and %d connects", state, connects)
+		}
+	}
+}
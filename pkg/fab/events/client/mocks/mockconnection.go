@@ -37,6 +37,9 @@ const (
 	NoOpResult Result = "no-op"
 )
 
+// Ping is the health check operation (used in the OperationMap)
+const Ping Operation = "ping"
+
 // Attempt specifies the number of connection attempts
 type Attempt uint
 
@@ -90,6 +93,7 @@ type MockConnection struct {
 	producerch <-chan interface{}
 	rcvch      chan interface{}
 	closed     int32
+	height     *uint64
 }
 
 // Opts contains mock connection options
@@ -97,6 +101,7 @@ type Opts struct {
 	Ledger     servicemocks.Ledger
 	Operations OperationMap
 	Factory    ConnectionFactory
+	Height     *uint64
 }
 
 // NewMockConnection returns a new MockConnection using the given options
@@ -122,10 +127,30 @@ func NewMockConnection(opts ...Opt) *MockConnection {
 		producerch: producer.Register(),
 		rcvch:      make(chan interface{}),
 		operations: operations,
+		height:     copts.Height,
 	}
 	return c
 }
 
+// LedgerHeight returns the ledger height provided with the WithLedgerHeight option
+func (c *MockConnection) LedgerHeight() (uint64, bool) {
+	if c.height == nil {
+		return 0, false
+	}
+	return *c.height, true
+}
+
+// CheckHealth returns an error if the connection is closed or if a FailResult was specified for the Ping operation
+func (c *MockConnection) CheckHealth() error {
+	if c.Closed() {
+		return errors.New("mock connection is closed")
+	}
+	if result, ok := c.Result(Ping); ok && result.Result == FailResult {
+		return errors.New(result.ErrMsg)
+	}
+	return nil
+}
+
 // Close implements the MockConnection interface
 func (c *MockConnection) Close() {
 	if !atomic.CompareAndSwapInt32(&c.closed, 0, 1) {
@@ -296,6 +321,13 @@ func WithLedger(ledger servicemocks.Ledger) Opt {
 	}
 }
 
+// WithLedgerHeight specifies the ledger height that the connection reports when it's established
+func WithLedgerHeight(height uint64) Opt {
+	return func(opts *Opts) {
+		opts.Height = &height
+	}
+}
+
 // WithResults specifies the results for one or more operations
 func WithResults(funcResults ...*OperationResult) Opt {
 	return func(opts *Opts) {
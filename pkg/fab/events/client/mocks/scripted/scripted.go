@@ -0,0 +1,158 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package scripted provides a fake event server connection whose events are produced on demand, so that code
+// which uses the event client (including its reconnect logic) may be unit tested without a peer. A Provider may
+// be passed to the event client with the client.WithConnectionProvider option.
+package scripted
+
+import (
+	"sync"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/context"
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/api"
+	clientdisp "github.com/hyperledger/fabric-sdk-go/pkg/fab/events/client/dispatcher"
+	cb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+	"github.com/pkg/errors"
+)
+
+// Connection is a fake connection to the event server. Events are sent to the event
+// client only when they're produced by the test (see Send, SendBlock, SendFilteredBlock
+// and Disconnect). Events that are produced before the client receives from the
+// connection are queued.
+type Connection struct {
+	mutex    sync.Mutex
+	queue    []interface{}
+	signalch chan struct{}
+	closech  chan struct{}
+	closed   bool
+}
+
+// NewConnection returns a new scripted connection
+func NewConnection() *Connection {
+	return &Connection{
+		signalch: make(chan struct{}, 1),
+		closech:  make(chan struct{}),
+	}
+}
+
+// Send sends the given event to the event client
+func (c *Connection) Send(event interface{}) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.closed {
+		return
+	}
+
+	c.queue = append(c.queue, event)
+	select {
+	case c.signalch <- struct{}{}:
+	default:
+	}
+}
+
+// SendBlock sends a block event to the event client
+func (c *Connection) SendBlock(block *cb.Block) {
+	c.Send(block)
+}
+
+// SendFilteredBlock sends a filtered block event to the event client
+func (c *Connection) SendFilteredBlock(fblock *pb.FilteredBlock) {
+	c.Send(fblock)
+}
+
+// Disconnect simulates the loss of the connection with the given error. The
+// event client reconnects if it was created with the reconnect option.
+func (c *Connection) Disconnect(err error) {
+	c.Send(clientdisp.NewDisconnectedEvent(err))
+}
+
+// Receive sends the produced events to the given channel until the connection is closed
+func (c *Connection) Receive(eventch chan<- interface{}) {
+	for {
+		for _, event := range c.dequeue() {
+			select {
+			case eventch <- event:
+			case <-c.closech:
+				return
+			}
+		}
+
+		select {
+		case <-c.signalch:
+		case <-c.closech:
+			return
+		}
+	}
+}
+
+func (c *Connection) dequeue() []interface{} {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	events := c.queue
+	c.queue = nil
+	return events
+}
+
+// Close closes the connection
+func (c *Connection) Close() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.closed {
+		return
+	}
+	c.closed = true
+	close(c.closech)
+}
+
+// Closed returns true if the connection is closed
+func (c *Connection) Closed() bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.closed
+}
+
+// Provider returns the given connections in order, one for each connection attempt. A connection
+// attempt fails if the next connection is nil or if all of the connections have been returned.
+type Provider struct {
+	mutex    sync.Mutex
+	conns    []*Connection
+	attempts int
+}
+
+// NewProvider returns a new scripted connection provider
+func NewProvider(conns ...*Connection) *Provider {
+	return &Provider{conns: conns}
+}
+
+// Connect returns the next connection
+func (p *Provider) Connect(channelID string, context context.Context, peer fab.Peer) (api.Connection, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.attempts++
+	if p.attempts > len(p.conns) {
+		return nil, errors.Errorf("no connection for attempt %d", p.attempts)
+	}
+
+	conn := p.conns[p.attempts-1]
+	if conn == nil {
+		return nil, errors.Errorf("simulated failure of connection attempt %d", p.attempts)
+	}
+	return conn, nil
+}
+
+// Attempts returns the number of connection attempts
+func (p *Provider) Attempts() int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.attempts
+}
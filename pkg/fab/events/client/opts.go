@@ -9,30 +9,53 @@ package client
 import (
 	"time"
 
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/api"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/client/peerresolver"
 	"github.com/hyperledger/fabric-sdk-go/pkg/options"
 )
 
 type params struct {
 	eventConsumerBufferSize uint
+	eventConsumerTimeout    time.Duration
 	reconn                  bool
+	autoClose               bool
 	maxConnAttempts         uint
 	maxReconnAttempts       uint
+	maxReconnDuration       time.Duration
 	reconnInitialDelay      time.Duration
 	timeBetweenConnAttempts time.Duration
 	connEventCh             chan *fab.ConnectionEvent
+	connEventDebounce       time.Duration
 	respTimeout             time.Duration
+	connectTimeout          time.Duration
+	backoffInitial          time.Duration
+	backoffMax              time.Duration
+	backoffMultiplier       float64
+	backoffJitter           float64
+	after                   func(time.Duration) <-chan time.Time
+	now                     func() time.Time
+	pingReconnect           bool
+	peerResolver            peerresolver.PeerResolver
+	endpointProvider        EndpointProvider
+	metricsObserver         MetricsObserver
+	permittedEventTypes     map[EventType]bool
 }
 
 func defaultParams() *params {
 	return &params{
 		eventConsumerBufferSize: 100,
+		eventConsumerTimeout:    500 * time.Millisecond,
 		reconn:                  true,
+		autoClose:               true,
 		maxConnAttempts:         1,
 		maxReconnAttempts:       0, // Try forever
 		reconnInitialDelay:      0,
 		timeBetweenConnAttempts: 5 * time.Second,
 		respTimeout:             5 * time.Second,
+		after:                   time.After,
+		now:                     time.Now,
 	}
 }
 
@@ -46,6 +69,25 @@ func WithReconnect(value bool) options.Opt {
 	}
 }
 
+// WithAutoCloseOnDisconnect indicates whether the client should close itself when the connection is lost
+// and the client won't (or can no longer) reconnect. If set to false then the client sends the connection
+// event (see WithConnectionEvent) and remains in the Disconnected state so that the caller may decide
+// whether to Connect again or Close the client. The default is true.
+//
+// The interaction with WithReconnect is as follows:
+//   - reconnect=true, autoClose=true (default): the client reconnects and closes itself if it gives up reconnecting
+//   - reconnect=true, autoClose=false: the client reconnects and, if it gives up, sends the terminal connection
+//     event and remains Disconnected
+//   - reconnect=false, autoClose=true: the client closes itself as soon as the connection is lost
+//   - reconnect=false, autoClose=false: the client sends the connection event and remains Disconnected
+func WithAutoCloseOnDisconnect(value bool) options.Opt {
+	return func(p options.Params) {
+		if setter, ok := p.(autoCloseSetter); ok {
+			setter.SetAutoCloseOnDisconnect(value)
+		}
+	}
+}
+
 // WithMaxConnectAttempts sets the maximum number of times that the client will attempt
 // to connect to the server. If set to 0 then the client will try until it is stopped.
 func WithMaxConnectAttempts(value uint) options.Opt {
@@ -67,6 +109,18 @@ func WithMaxReconnectAttempts(value uint) options.Opt {
 	}
 }
 
+// WithMaxReconnectDuration sets the maximum total time that the client will spend trying to reconnect
+// to the server after a connection has been lost. The client gives up when either the maximum number of
+// reconnect attempts (see WithMaxReconnectAttempts) or the maximum duration is reached, whichever comes
+// first. If set to 0 (the default) then the duration isn't limited.
+func WithMaxReconnectDuration(value time.Duration) options.Opt {
+	return func(p options.Params) {
+		if setter, ok := p.(maxReconnectDurationSetter); ok {
+			setter.SetMaxReconnectDuration(value)
+		}
+	}
+}
+
 // WithReconnectInitialDelay sets the initial delay before attempting to reconnect.
 func WithReconnectInitialDelay(value time.Duration) options.Opt {
 	return func(p options.Params) {
@@ -77,7 +131,10 @@ func WithReconnectInitialDelay(value time.Duration) options.Opt {
 }
 
 // WithConnectionEvent sets the channel that is to receive connection events, i.e. when the client connects and/or
-// disconnects from the channel event service.
+// disconnects from the channel event service. Events are sent to the channel according to the event consumer
+// timeout (see dispatcher.WithEventConsumerTimeout) so that a subscriber that doesn't read from the channel
+// doesn't prevent the client from reconnecting. Events that can't be sent are dropped and counted
+// (see Client.DroppedConnectionEvents).
 func WithConnectionEvent(value chan *fab.ConnectionEvent) options.Opt {
 	return func(p options.Params) {
 		if setter, ok := p.(connectEventChSetter); ok {
@@ -86,6 +143,21 @@ func WithConnectionEvent(value chan *fab.ConnectionEvent) options.Opt {
 	}
 }
 
+// WithConnectionEventDebounce coalesces the connection events that are sent to the connection event channel
+// (see WithConnectionEvent) when the connection flaps. When the connection is lost, the disconnected event is
+// held back for the given window. If the client reconnects within the window then neither the disconnected nor
+// the connected event is sent. When the window closes, the latest event is sent with the number of times that
+// the connection was lost and re-established during the window (see fab.ConnectionEvent.Flaps). Terminal events
+// are sent immediately (after any held back event). Connection state changes and reconnect behavior are not
+// affected. If set to 0 (the default) then every connection event is sent immediately.
+func WithConnectionEventDebounce(value time.Duration) options.Opt {
+	return func(p options.Params) {
+		if setter, ok := p.(connectionEventDebounceSetter); ok {
+			setter.SetConnectionEventDebounce(value)
+		}
+	}
+}
+
 // WithTimeBetweenConnectAttempts sets the time between connection attempts.
 func WithTimeBetweenConnectAttempts(value time.Duration) options.Opt {
 	return func(p options.Params) {
@@ -104,15 +176,147 @@ func WithResponseTimeout(value time.Duration) options.Opt {
 	}
 }
 
+// WithTimeouts sets the response timeout, the initial reconnect delay and the time between connection
+// attempts to the timeouts in the given configuration (see core.EventServiceResponse,
+// core.EventReconnectInitialDelay and core.EventConnectRetryInterval). Timeouts that are zero are ignored.
+// Options that are supplied after this option take precedence, e.g. WithResponseTimeout.
+func WithTimeouts(config core.Config) options.Opt {
+	respTimeout := config.Timeout(core.EventServiceResponse)
+	reconnInitialDelay := config.Timeout(core.EventReconnectInitialDelay)
+	timeBetweenConnAttempts := config.Timeout(core.EventConnectRetryInterval)
+
+	return func(p options.Params) {
+		if respTimeout > 0 {
+			WithResponseTimeout(respTimeout)(p)
+		}
+		if reconnInitialDelay > 0 {
+			WithReconnectInitialDelay(reconnInitialDelay)(p)
+		}
+		if timeBetweenConnAttempts > 0 {
+			WithTimeBetweenConnectAttempts(timeBetweenConnAttempts)(p)
+		}
+	}
+}
+
+// WithConnectTimeout sets the maximum time to wait for a single connection attempt to complete. If the
+// attempt doesn't complete in time then it's abandoned (the connection is closed if it's established later)
+// and it counts as a failed attempt, so the client proceeds to the next attempt (see WithMaxConnectAttempts).
+// The timeout is independent of the response timeout (see WithResponseTimeout) since establishing a connection
+// may legitimately take longer than other requests. If set to 0 (the default) then the client waits
+// indefinitely for the connection attempt to complete.
+func WithConnectTimeout(value time.Duration) options.Opt {
+	return func(p options.Params) {
+		if setter, ok := p.(connectTimeoutSetter); ok {
+			setter.SetConnectTimeout(value)
+		}
+	}
+}
+
+// WithConnectionProvider overrides the provider that the event client implementation uses to connect to the event
+// server, for example, so that a client may be tested without a peer (see the mocks/scripted package).
+func WithConnectionProvider(value ConnectionProvider) options.Opt {
+	return func(p options.Params) {
+		if setter, ok := p.(connectionProviderSetter); ok {
+			setter.SetConnectionProvider(value.Connect)
+		}
+	}
+}
+
+// WithReconnectBackoff enables exponential backoff between connection attempts. The first
+// delay is 'initial' and each subsequent delay is multiplied by 'multiplier', up to 'max'
+// (0 means no maximum). Each delay is then randomized by up to +/- 'jitter' (a fraction
+// between 0 and 1) of its value so that many clients don't reconnect in lockstep.
+// If this option is not supplied then the time between connection attempts is fixed
+// (see WithTimeBetweenConnectAttempts).
+func WithReconnectBackoff(initial, max time.Duration, multiplier float64, jitter float64) options.Opt {
+	return func(p options.Params) {
+		if setter, ok := p.(reconnectBackoffSetter); ok {
+			setter.SetReconnectBackoff(initial, max, multiplier, jitter)
+		}
+	}
+}
+
+// WithPingTriggersReconnect indicates that, if a ping fails because the connection isn't healthy,
+// the client disconnects and (if reconnect is enabled) reconnects as if the server had closed the connection
+func WithPingTriggersReconnect() options.Opt {
+	return func(p options.Params) {
+		if setter, ok := p.(pingReconnectSetter); ok {
+			setter.SetPingTriggersReconnect(true)
+		}
+	}
+}
+
+// WithPeerResolver sets the resolver that is consulted on each connection attempt for the peer to connect to.
+// The peer of a failed attempt (or of a lost connection) is passed to the resolver so that the client fails
+// over to another peer rather than retrying a peer that's down. If this option is not supplied then the peer
+// is chosen from the discovered peers using the load-balance policy.
+func WithPeerResolver(value peerresolver.PeerResolver) options.Opt {
+	return func(p options.Params) {
+		if setter, ok := p.(peerResolverSetter); ok {
+			setter.SetPeerResolver(value)
+		}
+	}
+}
+
+// WithEndpointProvider sets the provider that is consulted on each connection attempt for the URL of the event
+// server, for example, in order to connect to the peer with the greatest block height. The URL overrides the
+// URL of the peer that's chosen by the client (see WithPeerResolver) and the options are passed to the
+// connection. If the provider returns an error then the connection attempt fails (and is retried according
+// to the retry options). If this option is not supplied then the client connects to the URL of the peer.
+// A provider that returns a fixed URL may be created with StaticEndpointProvider.
+func WithEndpointProvider(value EndpointProvider) options.Opt {
+	return func(p options.Params) {
+		if setter, ok := p.(endpointProviderSetter); ok {
+			setter.SetEndpointProvider(value)
+		}
+	}
+}
+
+// WithMetricsObserver sets an observer that is invoked with a snapshot of the client's metrics (see Client.Metrics)
+// on every connection state change. The observer is invoked on the same Go routine as the connection state
+// listener (see Client.SetConnectionStateListener), so it doesn't block the client, and snapshots are
+// delivered in the order in which the state changes occurred.
+func WithMetricsObserver(value MetricsObserver) options.Opt {
+	return func(p options.Params) {
+		if setter, ok := p.(metricsObserverSetter); ok {
+			setter.SetMetricsObserver(value)
+		}
+	}
+}
+
+// WithPermittedEventTypes restricts the types of event for which registrations may be made. A registration
+// for any other type of event is rejected with an error. For example, a client that only processes full
+// blocks may reject filtered block registrations (which cause each block to be converted to a filtered
+// block). Note that block events must also be permitted by the connection (see Client.BlockEventsPermitted).
+// If this option is not supplied then every type of event is permitted, subject to the connection permitting
+// block events.
+func WithPermittedEventTypes(types ...EventType) options.Opt {
+	return func(p options.Params) {
+		if setter, ok := p.(permittedEventTypesSetter); ok {
+			setter.SetPermittedEventTypes(types...)
+		}
+	}
+}
+
 func (p *params) SetEventConsumerBufferSize(value uint) {
 	p.eventConsumerBufferSize = value
 }
 
+func (p *params) SetEventConsumerTimeout(value time.Duration) {
+	logger.Debugf("EventConsumerTimeout: %s", value)
+	p.eventConsumerTimeout = value
+}
+
 func (p *params) SetReconnect(value bool) {
 	logger.Debugf("Reconnect: %t", value)
 	p.reconn = value
 }
 
+func (p *params) SetAutoCloseOnDisconnect(value bool) {
+	logger.Debugf("AutoCloseOnDisconnect: %t", value)
+	p.autoClose = value
+}
+
 func (p *params) SetMaxConnectAttempts(value uint) {
 	logger.Debugf("MaxConnectAttempts: %d", value)
 	p.maxConnAttempts = value
@@ -123,6 +327,11 @@ func (p *params) SetMaxReconnectAttempts(value uint) {
 	p.maxReconnAttempts = value
 }
 
+func (p *params) SetMaxReconnectDuration(value time.Duration) {
+	logger.Debugf("MaxReconnectDuration: %s", value)
+	p.maxReconnDuration = value
+}
+
 func (p *params) SetReconnectInitialDelay(value time.Duration) {
 	logger.Debugf("ReconnectInitialDelay: %s", value)
 	p.reconnInitialDelay = value
@@ -138,15 +347,73 @@ func (p *params) SetConnectEventCh(value chan *fab.ConnectionEvent) {
 	p.connEventCh = value
 }
 
+func (p *params) SetConnectionEventDebounce(value time.Duration) {
+	logger.Debugf("ConnectionEventDebounce: %s", value)
+	p.connEventDebounce = value
+}
+
 func (p *params) SetResponseTimeout(value time.Duration) {
 	logger.Debugf("ResponseTimeout: %s", value)
 	p.respTimeout = value
 }
 
+func (p *params) SetConnectTimeout(value time.Duration) {
+	logger.Debugf("ConnectTimeout: %s", value)
+	p.connectTimeout = value
+}
+
+func (p *params) SetReconnectBackoff(initial, max time.Duration, multiplier float64, jitter float64) {
+	logger.Debugf("ReconnectBackoff: initial: %s, max: %s, multiplier: %f, jitter: %f", initial, max, multiplier, jitter)
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	if jitter < 0 {
+		jitter = 0
+	} else if jitter > 1 {
+		jitter = 1
+	}
+	p.backoffInitial = initial
+	p.backoffMax = max
+	p.backoffMultiplier = multiplier
+	p.backoffJitter = jitter
+}
+
+func (p *params) SetPingTriggersReconnect(value bool) {
+	logger.Debugf("PingTriggersReconnect: %t", value)
+	p.pingReconnect = value
+}
+
+func (p *params) SetPeerResolver(value peerresolver.PeerResolver) {
+	logger.Debugf("PeerResolver: %#v", value)
+	p.peerResolver = value
+}
+
+func (p *params) SetEndpointProvider(value EndpointProvider) {
+	logger.Debugf("EndpointProvider: %#v", value)
+	p.endpointProvider = value
+}
+
+func (p *params) SetMetricsObserver(value MetricsObserver) {
+	logger.Debugf("MetricsObserver: %#v", value)
+	p.metricsObserver = value
+}
+
+func (p *params) SetPermittedEventTypes(types ...EventType) {
+	logger.Debugf("PermittedEventTypes: %v", types)
+	p.permittedEventTypes = make(map[EventType]bool)
+	for _, t := range types {
+		p.permittedEventTypes[t] = true
+	}
+}
+
 type reconnectSetter interface {
 	SetReconnect(value bool)
 }
 
+type autoCloseSetter interface {
+	SetAutoCloseOnDisconnect(value bool)
+}
+
 type maxConnectAttemptsSetter interface {
 	SetMaxConnectAttempts(value uint)
 }
@@ -155,6 +422,10 @@ type maxReconnectAttemptsSetter interface {
 	SetMaxReconnectAttempts(value uint)
 }
 
+type maxReconnectDurationSetter interface {
+	SetMaxReconnectDuration(value time.Duration)
+}
+
 type reconnectInitialDelaySetter interface {
 	SetReconnectInitialDelay(value time.Duration)
 }
@@ -163,6 +434,10 @@ type connectEventChSetter interface {
 	SetConnectEventCh(value chan *fab.ConnectionEvent)
 }
 
+type connectionEventDebounceSetter interface {
+	SetConnectionEventDebounce(value time.Duration)
+}
+
 type timeBetweenConnectAttemptsSetter interface {
 	SetTimeBetweenConnectAttempts(value time.Duration)
 }
@@ -170,3 +445,36 @@ type timeBetweenConnectAttemptsSetter interface {
 type responseTimeoutSetter interface {
 	SetResponseTimeout(value time.Duration)
 }
+
+type connectTimeoutSetter interface {
+	SetConnectTimeout(value time.Duration)
+}
+
+type reconnectBackoffSetter interface {
+	SetReconnectBackoff(initial, max time.Duration, multiplier float64, jitter float64)
+}
+
+type pingReconnectSetter interface {
+	SetPingTriggersReconnect(value bool)
+}
+
+type peerResolverSetter interface {
+	SetPeerResolver(value peerresolver.PeerResolver)
+}
+
+type endpointProviderSetter interface {
+	SetEndpointProvider(value EndpointProvider)
+}
+
+type metricsObserverSetter interface {
+	SetMetricsObserver(value MetricsObserver)
+}
+
+type permittedEventTypesSetter interface {
+	SetPermittedEventTypes(types ...EventType)
+}
+
+// connectionProviderSetter is implemented by the client dispatcher's params
+type connectionProviderSetter interface {
+	SetConnectionProvider(value api.ConnectionProvider)
+}
@@ -0,0 +1,33 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package peerresolver
+
+import (
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/logging"
+)
+
+var logger = logging.NewLogger("fabric_sdk_go")
+
+// PeerResolver resolves the peer that the event client connects to on each connection attempt
+type PeerResolver interface {
+	// Resolve returns the peer to try on the next connection attempt. The failed peer is the
+	// peer whose connection attempt failed or whose connection was lost (nil if there is none).
+	Resolve(failed fab.Peer) (fab.Peer, error)
+}
+
+func indexOf(peers []fab.Peer, peer fab.Peer) int {
+	if peer == nil {
+		return -1
+	}
+	for i, p := range peers {
+		if p.URL() == peer.URL() {
+			return i
+		}
+	}
+	return -1
+}
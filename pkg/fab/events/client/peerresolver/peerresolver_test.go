@@ -0,0 +1,60 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package peerresolver
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+	fabmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+)
+
+var (
+	p1 = fabmocks.NewMockPeer("p1", "grpcs://p1.example.com:7051")
+	p2 = fabmocks.NewMockPeer("p2", "grpcs://p2.example.com:7051")
+	p3 = fabmocks.NewMockPeer("p3", "grpcs://p3.example.com:7051")
+)
+
+func TestRoundRobin(t *testing.T) {
+	if _, err := NewRoundRobin().Resolve(nil); err == nil {
+		t.Fatalf("expecting error resolving from an empty set of peers")
+	}
+
+	resolver := NewRoundRobin(p1, p2, p3)
+	checkResolve(t, resolver, nil, p1)
+	checkResolve(t, resolver, p1, p2)
+	checkResolve(t, resolver, nil, p3)
+	checkResolve(t, resolver, p3, p1)
+}
+
+func TestPriority(t *testing.T) {
+	if _, err := NewPriority().Resolve(nil); err == nil {
+		t.Fatalf("expecting error resolving from an empty set of peers")
+	}
+
+	resolver := NewPriority(p1, p2, p3)
+	checkResolve(t, resolver, nil, p1)
+	checkResolve(t, resolver, p1, p2)
+	checkResolve(t, resolver, p2, p3)
+	checkResolve(t, resolver, p3, p1)
+
+	// Back to the highest priority once there's no failure
+	checkResolve(t, resolver, nil, p1)
+
+	// An unknown peer is treated as no failure
+	checkResolve(t, resolver, fabmocks.NewMockPeer("p4", "grpcs://p4.example.com:7051"), p1)
+}
+
+func checkResolve(t *testing.T, resolver PeerResolver, failed fab.Peer, expected fab.Peer) {
+	peer, err := resolver.Resolve(failed)
+	if err != nil {
+		t.Fatalf("error resolving peer: %s", err)
+	}
+	if peer.URL() != expected.URL() {
+		t.Fatalf("expecting peer [%s] to be resolved but got [%s]", expected.URL(), peer.URL())
+	}
+}
@@ -0,0 +1,37 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package peerresolver
+
+import (
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+	"github.com/pkg/errors"
+)
+
+// Priority resolves the given peers in order of priority, i.e. the first peer has the highest priority
+type Priority struct {
+	peers []fab.Peer
+}
+
+// NewPriority returns a new Priority peer resolver. The peers are ordered from highest to lowest priority.
+func NewPriority(peers ...fab.Peer) *Priority {
+	return &Priority{peers: peers}
+}
+
+// Resolve returns the highest priority peer if no peer failed. Otherwise the peer following the
+// failed peer is returned, wrapping around to the highest priority peer after the lowest priority peer.
+func (r *Priority) Resolve(failed fab.Peer) (fab.Peer, error) {
+	if len(r.peers) == 0 {
+		return nil, errors.New("no peers to resolve")
+	}
+
+	// indexOf returns -1 if there's no failed peer (or it's unknown) so we start with the highest priority
+	index := (indexOf(r.peers, failed) + 1) % len(r.peers)
+
+	logger.Debugf("Resolved peer at priority %d", index)
+
+	return r.peers[index], nil
+}
@@ -0,0 +1,45 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package peerresolver
+
+import (
+	"sync"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+	"github.com/pkg/errors"
+)
+
+// RoundRobin resolves the given peers in round-robin fashion, starting with the first peer
+type RoundRobin struct {
+	sync.Mutex
+	peers []fab.Peer
+	index int
+}
+
+// NewRoundRobin returns a new RoundRobin peer resolver
+func NewRoundRobin(peers ...fab.Peer) *RoundRobin {
+	return &RoundRobin{
+		peers: peers,
+		index: -1,
+	}
+}
+
+// Resolve returns the next peer in the list, independent of which peer failed
+func (r *RoundRobin) Resolve(failed fab.Peer) (fab.Peer, error) {
+	if len(r.peers) == 0 {
+		return nil, errors.New("no peers to resolve")
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	r.index = (r.index + 1) % len(r.peers)
+
+	logger.Debugf("Resolved peer at index %d", r.index)
+
+	return r.peers[r.index], nil
+}
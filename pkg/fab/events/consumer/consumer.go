@@ -8,6 +8,7 @@ package consumer
 
 import (
 	grpcContext "context"
+	"crypto/tls"
 	"crypto/x509"
 	"io"
 	"sync"
@@ -17,16 +18,17 @@ import (
 	"github.com/golang/protobuf/ptypes"
 	"github.com/pkg/errors"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/keepalive"
 
 	consumer "github.com/hyperledger/fabric-sdk-go/internal/github.com/hyperledger/fabric/events/consumer"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context"
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
 	"github.com/hyperledger/fabric-sdk-go/pkg/core/config/comm"
 	ccomm "github.com/hyperledger/fabric-sdk-go/pkg/core/config/comm"
 	"github.com/hyperledger/fabric-sdk-go/pkg/core/config/urlutil"
+	fabcomm "github.com/hyperledger/fabric-sdk-go/pkg/fab/comm"
 	"github.com/hyperledger/fabric-sdk-go/pkg/logging"
 	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
 	ehpb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
@@ -46,6 +48,7 @@ type eventsClient struct {
 	TLSServerHostOverride  string
 	tlsCertHash            []byte
 	clientConn             *grpc.ClientConn
+	connector              api.Connector
 	provider               context.ProviderContext
 	identity               context.IdentityContext
 	processEventsCompleted chan struct{}
@@ -53,12 +56,13 @@ type eventsClient struct {
 	failFast               bool
 	secured                bool
 	allowInsecure          bool
+	proxyURL               string
 }
 
 //NewEventsClient Returns a new grpc.ClientConn to the configured local PEER.
 func NewEventsClient(provider context.ProviderContext, identity context.IdentityContext, peerAddress string, certificate *x509.Certificate,
 	serverhostoverride string, regTimeout time.Duration, adapter consumer.EventAdapter,
-	kap keepalive.ClientParameters, failFast bool, allowInsecure bool) (fab.EventsClient, error) {
+	kap keepalive.ClientParameters, failFast bool, allowInsecure bool, proxyURL string) (fab.EventsClient, error) {
 
 	var err error
 	if regTimeout < 100*time.Millisecond {
@@ -79,39 +83,51 @@ func NewEventsClient(provider context.ProviderContext, identity context.Identity
 		provider:              provider,
 		identity:              identity,
 		tlsCertHash:           ccomm.TLSCertHash(provider.Config()),
+		connector:             connector(provider),
 		kap:                   kap,
 		failFast:              failFast,
 		secured:               urlutil.AttemptSecured(peerAddress),
 		allowInsecure:         allowInsecure,
+		proxyURL:              proxyURL,
 	}, err
 }
 
+// connector returns the connector of the provider, or a connector that dials a new connection
+// if the provider doesn't have a connector
+func connector(provider context.ProviderContext) api.Connector {
+	if c := provider.Connector(); c != nil {
+		return c
+	}
+	return &fabcomm.DirectConnector{}
+}
+
 //newEventsClientConnectionWithAddress Returns a new grpc.ClientConn to the configured local PEER.
-func newEventsClientConnectionWithAddress(peerAddress string, cert *x509.Certificate, serverHostOverride string,
-	config core.Config, kap keepalive.ClientParameters, failFast bool, secured bool) (*grpc.ClientConn, error) {
+func newEventsClientConnectionWithAddress(connector api.Connector, peerAddress string, cert *x509.Certificate, serverHostOverride string,
+	config core.Config, kap keepalive.ClientParameters, failFast bool, secured bool, proxyURL string) (*grpc.ClientConn, error) {
 	var opts []grpc.DialOption
-	opts = append(opts, grpc.WithTimeout(config.TimeoutOrDefault(core.EventHubConnection)))
+	opts = append(opts, grpc.WithTimeout(config.Timeout(core.EventHubConnection)))
+
+	var tlsConfig *tls.Config
 	if secured {
-		tlsConfig, err := comm.TLSConfig(cert, serverHostOverride, config)
+		var err error
+		tlsConfig, err = comm.TLSConfig(cert, serverHostOverride, config)
 		if err != nil {
 			return nil, err
 		}
-
-		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
-	} else {
-		opts = append(opts, grpc.WithInsecure())
 	}
 
-	if kap.Time > 0 {
-		opts = append(opts, grpc.WithKeepaliveParams(kap))
+	settings := fabcomm.DialSettings{KeepAlive: kap, FailFast: failFast, ProxyURL: proxyURL}
+	dialOpts, err := settings.DialOptions(peerAddress)
+	if err != nil {
+		return nil, err
 	}
-	opts = append(opts, grpc.WithDefaultCallOptions(grpc.FailFast(failFast)))
+	opts = append(opts, dialOpts...)
 
-	ctx := grpcContext.Background()
-	ctx, cancel := grpcContext.WithTimeout(ctx, config.TimeoutOrDefault(core.EventHubConnection))
+	ctx := fabcomm.WithDialKey(grpcContext.Background(), settings.Key())
+	ctx, cancel := grpcContext.WithTimeout(ctx, config.Timeout(core.EventHubConnection))
 	defer cancel()
 
-	conn, err := grpc.DialContext(ctx, urlutil.ToAddress(peerAddress), opts...)
+	conn, err := connector.DialContext(ctx, urlutil.ToAddress(peerAddress), tlsConfig, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -311,8 +327,8 @@ func (ec *eventsClient) Start() error {
 }
 
 func (ec *eventsClient) establishConnectionAndRegister(secured bool) error {
-	conn, err := newEventsClientConnectionWithAddress(ec.peerAddress, ec.TLSCertificate, ec.TLSServerHostOverride,
-		ec.provider.Config(), ec.kap, ec.failFast, secured)
+	conn, err := newEventsClientConnectionWithAddress(ec.connector, ec.peerAddress, ec.TLSCertificate, ec.TLSServerHostOverride,
+		ec.provider.Config(), ec.kap, ec.failFast, secured, ec.proxyURL)
 
 	if err != nil {
 		return errors.WithMessage(err, "events connection failed")
@@ -321,16 +337,19 @@ func (ec *eventsClient) establishConnectionAndRegister(secured bool) error {
 
 	ies, err := ec.adapter.GetInterestedEvents()
 	if err != nil {
+		ec.releaseConn()
 		return errors.Wrap(err, "interested events retrieval failed")
 	}
 
 	if len(ies) == 0 {
+		ec.releaseConn()
 		return errors.New("interested events is required")
 	}
 
 	serverClient := ehpb.NewEventsClient(conn)
 	ec.stream, err = serverClient.Chat(grpcContext.Background())
 	if err != nil {
+		ec.releaseConn()
 		logger.Error("events connection failed, cause: ", err)
 		if secured && ec.allowInsecure {
 			//If secured mode failed and allow insecure is enabled then retry in insecure mode
@@ -350,6 +369,14 @@ func (ec *eventsClient) establishConnectionAndRegister(secured bool) error {
 	return nil
 }
 
+// releaseConn releases the client connection to the connector
+func (ec *eventsClient) releaseConn() {
+	if ec.clientConn != nil {
+		ec.connector.ReleaseConn(ec.clientConn)
+		ec.clientConn = nil
+	}
+}
+
 //Stop terminates connection with event hub
 func (ec *eventsClient) Stop() error {
 	var timeoutErr error
@@ -369,17 +396,12 @@ func (ec *eventsClient) Stop() error {
 	// Server ended its send stream in response to CloseSend()
 	case <-ec.processEventsCompleted:
 		// Timeout waiting for server to end stream
-	case <-time.After(ec.provider.Config().TimeoutOrDefault(core.EventHubConnection)):
+	case <-time.After(ec.provider.Config().Timeout(core.EventHubConnection)):
 		timeoutErr = errors.New("close event stream timeout")
 	}
 
-	//close  client connection
-	if ec.clientConn != nil {
-		err := ec.clientConn.Close()
-		if err != nil {
-			return err
-		}
-	}
+	//release client connection
+	ec.releaseConn()
 
 	if timeoutErr != nil {
 		return timeoutErr
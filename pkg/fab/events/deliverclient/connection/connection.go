@@ -10,13 +10,16 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"sync"
 
 	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/utils"
 
 	"github.com/golang/protobuf/proto"
 	fabcontext "github.com/hyperledger/fabric-sdk-go/pkg/context"
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/comm"
 	clientdisp "github.com/hyperledger/fabric-sdk-go/pkg/fab/events/client/dispatcher"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/deliverclient/seek"
 	"github.com/hyperledger/fabric-sdk-go/pkg/logging"
 	"github.com/hyperledger/fabric-sdk-go/pkg/options"
 	"github.com/pkg/errors"
@@ -39,6 +42,12 @@ type deliverStream interface {
 // DeliverConnection manages the connection to the deliver server
 type DeliverConnection struct {
 	comm.GRPCConnection
+	client         pb.DeliverClient
+	streamProvider StreamProvider
+
+	heightOnce sync.Once
+	height     uint64
+	heightOK   bool
 }
 
 // StreamProvider creates a deliver stream
@@ -62,10 +71,12 @@ func New(ctx fabcontext.Context, channelID string, streamProvider StreamProvider
 		return nil, errors.New("channel ID not provided")
 	}
 
+	var client pb.DeliverClient
 	connect, err := comm.NewConnection(
 		ctx, channelID,
 		func(grpcconn *grpc.ClientConn) (grpc.ClientStream, error) {
-			return streamProvider(pb.NewDeliverClient(grpcconn))
+			client = pb.NewDeliverClient(grpcconn)
+			return streamProvider(client)
 		},
 		url, opts...,
 	)
@@ -75,9 +86,85 @@ func New(ctx fabcontext.Context, channelID string, streamProvider StreamProvider
 
 	return &DeliverConnection{
 		GRPCConnection: *connect,
+		client:         client,
+		streamProvider: streamProvider,
 	}, nil
 }
 
+// LedgerHeight returns the block height of the peer's ledger. The height is queried once (with a seek
+// for the newest block on a separate stream) and the result is cached for the life of the connection.
+// False is returned if the height couldn't be obtained.
+func (c *DeliverConnection) LedgerHeight() (uint64, bool) {
+	c.heightOnce.Do(func() {
+		height, err := c.queryLedgerHeight()
+		if err != nil {
+			logger.Warnf("Unable to obtain the ledger height for channel [%s]: %s", c.ChannelID(), err)
+			return
+		}
+		logger.Debugf("Ledger height for channel [%s]: %d", c.ChannelID(), height)
+		c.height = height
+		c.heightOK = true
+	})
+	return c.height, c.heightOK
+}
+
+func (c *DeliverConnection) queryLedgerHeight() (uint64, error) {
+	if c.Closed() {
+		return 0, errors.New("connection is closed")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.Context().Config().Timeout(core.EventServiceResponse))
+	defer cancel()
+
+	stream, err := c.streamProvider(&contextDeliverClient{DeliverClient: c.client, ctx: ctx})
+	if err != nil {
+		return 0, errors.Wrap(err, "error creating deliver stream")
+	}
+
+	env, err := c.createSignedEnvelope(seek.InfoNewestOnly())
+	if err != nil {
+		return 0, err
+	}
+	if err := stream.Send(env); err != nil {
+		return 0, errors.Wrap(err, "error sending seek request")
+	}
+
+	for {
+		in, err := stream.Recv()
+		if err != nil {
+			return 0, errors.Wrap(err, "error receiving newest block")
+		}
+
+		switch evt := in.Type.(type) {
+		case *pb.DeliverResponse_Block:
+			return evt.Block.GetHeader().GetNumber() + 1, nil
+		case *pb.DeliverResponse_FilteredBlock:
+			return evt.FilteredBlock.Number + 1, nil
+		case *pb.DeliverResponse_Status:
+			if evt.Status != cb.Status_SUCCESS {
+				return 0, errors.Errorf("received status [%s] while seeking the newest block", evt.Status)
+			}
+		default:
+			return 0, errors.Errorf("unsupported response type: %T", in.Type)
+		}
+	}
+}
+
+// contextDeliverClient creates the deliver streams with the given context (instead of the context
+// of the stream provider) so that the stream is closed when the context is done
+type contextDeliverClient struct {
+	pb.DeliverClient
+	ctx context.Context
+}
+
+func (c *contextDeliverClient) Deliver(ctx context.Context, opts ...grpc.CallOption) (pb.Deliver_DeliverClient, error) {
+	return c.DeliverClient.Deliver(c.ctx, opts...)
+}
+
+func (c *contextDeliverClient) DeliverFiltered(ctx context.Context, opts ...grpc.CallOption) (pb.Deliver_DeliverFilteredClient, error) {
+	return c.DeliverClient.DeliverFiltered(c.ctx, opts...)
+}
+
 func (c *DeliverConnection) deliverStream() deliverStream {
 	if c.Stream() == nil {
 		return nil
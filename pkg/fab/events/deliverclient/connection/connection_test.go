@@ -8,6 +8,7 @@ package connection
 
 import (
 	"fmt"
+	"math"
 	"net"
 	"os"
 	"testing"
@@ -16,10 +17,16 @@ import (
 	"google.golang.org/grpc/keepalive"
 
 	fabcontext "github.com/hyperledger/fabric-sdk-go/pkg/context"
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/comm"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/api"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/checkpointer"
 	clientdisp "github.com/hyperledger/fabric-sdk-go/pkg/fab/events/client/dispatcher"
+	clientmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/events/client/mocks"
+	deliverdisp "github.com/hyperledger/fabric-sdk-go/pkg/fab/events/deliverclient/dispatcher"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/deliverclient/seek"
 	eventmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/events/mocks"
+	esdispatcher "github.com/hyperledger/fabric-sdk-go/pkg/fab/events/service/dispatcher"
 	fabmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
 	cb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
 	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
@@ -103,6 +110,195 @@ func TestSend(t *testing.T) {
 	})
 }
 
+func TestLedgerHeight(t *testing.T) {
+	deliverServer.SetLedgerHeight(10)
+	defer deliverServer.SetLedgerHeight(0)
+
+	for _, streamType := range []streamType{streamTypeDeliver, streamTypeDeliverFiltered} {
+		conn, err := New(newMockContext(), "mychannel", getStreamProvider(streamType), peerURL)
+		if err != nil {
+			t.Fatalf("error creating new connection: %s", err)
+		}
+
+		var ledgerInfo api.LedgerInfoProvider = conn
+		height, ok := ledgerInfo.LedgerHeight()
+		if !ok || height != 10 {
+			t.Fatalf("expecting ledger height 10 for stream %s but got %d (ok: %t)", streamType, height, ok)
+		}
+
+		// The height is obtained once per connection
+		deliverServer.SetLedgerHeight(20)
+		if height, _ := conn.LedgerHeight(); height != 10 {
+			t.Fatalf("expecting cached ledger height 10 for stream %s but got %d", streamType, height)
+		}
+		deliverServer.SetLedgerHeight(10)
+
+		conn.Close()
+	}
+
+	conn, err := New(newMockContext(), "mychannel", Deliver, peerURL)
+	if err != nil {
+		t.Fatalf("error creating new connection: %s", err)
+	}
+	conn.Close()
+	if _, ok := conn.LedgerHeight(); ok {
+		t.Fatalf("expecting unknown ledger height for closed connection")
+	}
+}
+
+func TestResetOnConnect(t *testing.T) {
+	channelID := "mychannel"
+	consumer := "consumer1"
+	cp := checkpointer.NewMemory()
+
+	connectionProvider := func(channelID string, context fabcontext.Context, peer fab.Peer) (api.Connection, error) {
+		return New(context, channelID, DeliverFiltered, peerURL)
+	}
+
+	dispatcher := deliverdisp.New(
+		newMockContext(), channelID, connectionProvider,
+		clientmocks.NewDiscoveryService(peer),
+		esdispatcher.WithBlockHeightResetMargin(2),
+		esdispatcher.WithCheckpointer(consumer, cp),
+	)
+	if err := dispatcher.Start(); err != nil {
+		t.Fatalf("Error starting dispatcher: %s", err)
+	}
+
+	dispatcherEventch, err := dispatcher.EventCh()
+	if err != nil {
+		t.Fatalf("Error getting event channel from dispatcher: %s", err)
+	}
+	defer func() {
+		stopch := make(chan error)
+		dispatcherEventch <- esdispatcher.NewStopEvent(stopch)
+		<-stopch
+	}()
+
+	errch := make(chan error, 1)
+	connect := func() {
+		dispatcherEventch <- clientdisp.NewConnectEvent(errch)
+		if err := <-errch; err != nil {
+			t.Fatalf("Error connecting: %s", err)
+		}
+	}
+	disconnect := func() {
+		dispatcherEventch <- clientdisp.NewDisconnectEvent(errch)
+		if err := <-errch; err != nil {
+			t.Fatalf("Error disconnecting: %s", err)
+		}
+	}
+
+	// Receive block 10 from the peer
+	deliverServer.SetLedgerHeight(11)
+	defer deliverServer.SetLedgerHeight(0)
+
+	connect()
+	dispatcherEventch <- deliverdisp.NewSeekEvent(seek.InfoNewest(), nil)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for dispatcher.LastBlockNum() != 10 {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for block 10 but the last block number is %d", dispatcher.LastBlockNum())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	checkCheckpoint(t, cp, consumer, 10, true)
+	disconnect()
+
+	// The channel was re-created: the peer reports a height that's lower than the last block by more than the margin
+	deliverServer.SetLedgerHeight(3)
+	connect()
+
+	if blockNum := dispatcher.LastBlockNum(); blockNum != math.MaxUint64 {
+		t.Fatalf("expecting the last block number to be reset but got %d", blockNum)
+	}
+	checkCheckpoint(t, cp, consumer, 0, false)
+	disconnect()
+}
+
+func TestBlockGap(t *testing.T) {
+	connectionProvider := func(channelID string, context fabcontext.Context, peer fab.Peer) (api.Connection, error) {
+		return New(context, channelID, Deliver, peerURL)
+	}
+
+	dispatcher := deliverdisp.New(newMockContext(), "mychannel", connectionProvider, clientmocks.NewDiscoveryService(peer))
+	if err := dispatcher.Start(); err != nil {
+		t.Fatalf("Error starting dispatcher: %s", err)
+	}
+
+	dispatcherEventch, err := dispatcher.EventCh()
+	if err != nil {
+		t.Fatalf("Error getting event channel from dispatcher: %s", err)
+	}
+	defer func() {
+		stopch := make(chan error)
+		dispatcherEventch <- esdispatcher.NewStopEvent(stopch)
+		<-stopch
+	}()
+
+	regch := make(chan fab.Registration)
+	errch := make(chan error, 1)
+	gapch := make(chan *deliverdisp.BlockGapEvent, 10)
+	dispatcherEventch <- deliverdisp.NewRegisterBlockGapEvent(gapch, regch, errch)
+	select {
+	case <-regch:
+	case err := <-errch:
+		t.Fatalf("Error registering for block gap events: %s", err)
+	}
+
+	deliverServer.SetLedgerHeight(20)
+	defer deliverServer.SetLedgerHeight(0)
+
+	dispatcherEventch <- clientdisp.NewConnectEvent(errch)
+	if err := <-errch; err != nil {
+		t.Fatalf("Error connecting: %s", err)
+	}
+
+	seekFrom := func(fromBlock uint64) {
+		seekEvent := deliverdisp.NewSeekEvent(seek.InfoFrom(fromBlock), nil)
+		seekEvent.MaxReplay = 5
+		dispatcherEventch <- seekEvent
+	}
+
+	// 3 blocks to replay (17-19) is within the maximum
+	seekFrom(17)
+	select {
+	case gap := <-gapch:
+		t.Fatalf("unexpected block gap event: %+v", gap)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// 15 blocks to replay (5-19) exceeds the maximum so the client seeks from the newest block (19)
+	seekFrom(5)
+	select {
+	case gap := <-gapch:
+		if gap.FromBlock != 5 || gap.ToBlock != 18 {
+			t.Fatalf("expecting block gap 5-18 but got %d-%d", gap.FromBlock, gap.ToBlock)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for block gap event")
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for dispatcher.LastBlockNum() != 19 {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for block 19 but the last block number is %d", dispatcher.LastBlockNum())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func checkCheckpoint(t *testing.T, cp checkpointer.Checkpointer, name string, expectedBlockNum uint64, expectedOK bool) {
+	blockNum, ok, err := cp.Load(name)
+	if err != nil {
+		t.Fatalf("error loading checkpoint: %s", err)
+	}
+	if ok != expectedOK || blockNum != expectedBlockNum {
+		t.Fatalf("expecting checkpoint %d (found: %t) but got %d (found: %t)", expectedBlockNum, expectedOK, blockNum, ok)
+	}
+}
+
 func TestDisconnected(t *testing.T) {
 	channelID := "mychannel"
 	conn, err := New(newMockContext(), channelID, Deliver, peerURL)
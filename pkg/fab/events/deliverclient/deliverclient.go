@@ -9,6 +9,7 @@ package deliverclient
 import (
 	"math"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	ab "github.com/hyperledger/fabric-sdk-go/internal/github.com/hyperledger/fabric/protos/orderer"
@@ -28,14 +29,25 @@ var logger = logging.NewLogger("fabric_sdk_go")
 
 // deliverProvider is the connection provider used for connecting to the Deliver service
 var deliverProvider = func(channelID string, context fabcontext.Context, peer fab.Peer) (api.Connection, error) {
-	return deliverconn.New(context, channelID, deliverconn.Deliver, peer.URL())
+	return deliverconn.New(context, channelID, deliverconn.Deliver, peer.URL(), api.ConnectionOpts(peer)...)
 }
 
 // deliverFilteredProvider is the connection provider used for connecting to the DeliverFiltered service
 var deliverFilteredProvider = func(channelID string, context fabcontext.Context, peer fab.Peer) (api.Connection, error) {
-	return deliverconn.New(context, channelID, deliverconn.DeliverFiltered, peer.URL())
+	return deliverconn.New(context, channelID, deliverconn.DeliverFiltered, peer.URL(), api.ConnectionOpts(peer)...)
 }
 
+type upgradeState int32
+
+const (
+	// notUpgraded indicates that the client connects with the configured connection provider
+	notUpgraded upgradeState = iota
+	// upgrading indicates that the client is reconnecting for block events
+	upgrading
+	// upgraded indicates that the client connects for block events
+	upgraded
+)
+
 // Client connects to a peer and receives channel events, such as bock, filtered block, chaincode, and transaction status events.
 type Client struct {
 	sync.RWMutex
@@ -46,6 +58,9 @@ type Client struct {
 	stopped              int32
 	registerOnce         sync.Once
 	blockEventsPermitted bool
+	resumed              bool
+	upgradeMutex         sync.Mutex
+	upgradeState         int32
 }
 
 // New returns a new deliver event client
@@ -54,25 +69,96 @@ func New(context fabcontext.Context, channelID string, discoveryService fab.Disc
 		return nil, errors.New("expecting channel ID")
 	}
 
+	// The timeouts in the configuration are overridden by the options
+	opts = append([]options.Opt{client.WithTimeouts(context.Config())}, opts...)
+
 	params := defaultParams()
 	options.Apply(params, opts)
 
-	client := &Client{
-		Client: *client.New(
-			params.permitBlockEvents,
-			dispatcher.New(context, channelID, params.connProvider, discoveryService, opts...),
-			opts...,
-		),
-		params: *params,
+	if _, err := params.setSeekFromCheckpoint(); err != nil {
+		return nil, err
 	}
-	client.SetAfterConnectHandler(client.seek)
-	client.SetBeforeReconnectHandler(client.setSeekFromLastBlockReceived)
 
-	if err := client.Start(); err != nil {
+	c := &Client{params: *params}
+	c.Client = *client.New(
+		params.permitBlockEvents,
+		dispatcher.New(context, channelID, c.connect, discoveryService, opts...),
+		opts...,
+	)
+	c.SetAfterConnectHandler(c.seek)
+	c.SetBeforeReconnectHandler(c.setSeekFromLastBlockReceived)
+
+	if err := c.Start(); err != nil {
 		return nil, err
 	}
 
-	return client, nil
+	return c, nil
+}
+
+// RegisterBlockEvent registers for block events. If the client is connected for filtered blocks and the
+// WithAutoUpgradeToBlockEvents option was provided then the client is first reconnected for full blocks.
+func (c *Client) RegisterBlockEvent(filter ...fab.BlockFilter) (fab.Registration, <-chan *fab.BlockEvent, error) {
+	if err := c.upgradeToBlockEvents(); err != nil {
+		return nil, nil, errors.WithMessage(err, "unable to upgrade connection to block events")
+	}
+	return c.Client.RegisterBlockEvent(filter...)
+}
+
+// connect is the connection provider of the dispatcher. Once an upgrade to block events
+// has been initiated, the connection is created by the block connection provider.
+func (c *Client) connect(channelID string, context fabcontext.Context, peer fab.Peer) (api.Connection, error) {
+	if c.getUpgradeState() == notUpgraded {
+		return c.connProvider(channelID, context, peer)
+	}
+
+	conn, err := c.blockConnProvider(channelID, context, peer)
+	if err != nil {
+		c.revertUpgrade(err)
+	}
+	return conn, err
+}
+
+func (c *Client) upgradeToBlockEvents() error {
+	if !c.autoUpgrade || c.BlockEventsPermitted() || !c.EventTypePermitted(client.BlockEventType) || c.ConnectionState() != client.Connected {
+		return nil
+	}
+
+	c.upgradeMutex.Lock()
+	defer c.upgradeMutex.Unlock()
+
+	if c.BlockEventsPermitted() {
+		return nil
+	}
+
+	logger.Infof("Upgrading event client connection to block events...")
+
+	c.setUpgradeState(upgrading)
+	c.SetPermitBlockEvents(true)
+
+	if err := c.Reconnect(); err != nil {
+		c.revertUpgrade(err)
+		return err
+	}
+
+	logger.Infof("... event client connection upgraded to block events")
+	return nil
+}
+
+// revertUpgrade reverts to the configured connection provider if the upgrade
+// to block events is in progress, i.e. the upgrade failed
+func (c *Client) revertUpgrade(cause error) {
+	if atomic.CompareAndSwapInt32(&c.upgradeState, int32(upgrading), int32(notUpgraded)) {
+		logger.Warnf("Unable to upgrade event client connection to block events: %s. Reverting to filtered blocks.", cause)
+		c.SetPermitBlockEvents(c.permitBlockEvents)
+	}
+}
+
+func (c *Client) getUpgradeState() upgradeState {
+	return upgradeState(atomic.LoadInt32(&c.upgradeState))
+}
+
+func (c *Client) setUpgradeState(state upgradeState) {
+	atomic.StoreInt32(&c.upgradeState, int32(state))
 }
 
 func (c *Client) seek() error {
@@ -84,7 +170,9 @@ func (c *Client) seek() error {
 	}
 
 	errch := make(chan error)
-	c.Submit(dispatcher.NewSeekEvent(seekInfo, errch))
+	seekEvent := dispatcher.NewSeekEvent(seekInfo, errch)
+	seekEvent.MaxReplay = c.replayLimit()
+	c.Submit(seekEvent)
 
 	select {
 	case err = <-errch:
@@ -94,14 +182,16 @@ func (c *Client) seek() error {
 
 	if err != nil {
 		logger.Errorf("unable to send seek request: %s\n", err)
+		c.revertUpgrade(err)
 		return err
 	}
 
 	logger.Debugf("successfully sent seek\n")
+	atomic.CompareAndSwapInt32(&c.upgradeState, int32(upgrading), int32(upgraded))
 	return nil
 }
 
-func (c *Client) setSeekFromLastBlockReceived() error {
+func (c *Client) setSeekFromLastBlockReceived(peer fab.Peer) error {
 	c.Lock()
 	defer c.Unlock()
 
@@ -110,25 +200,95 @@ func (c *Client) setSeekFromLastBlockReceived() error {
 	if lastBlockNum < math.MaxUint64 {
 		c.seekType = seek.FromBlock
 		c.fromBlock = c.Dispatcher().LastBlockNum() + 1
+		c.resumed = true
 	} else {
-		// We haven't received any blocks yet. Just ask for the newest
-		c.seekType = seek.Newest
+		// We haven't received any blocks yet. Resume from the checkpoint (if any) or just ask for the newest
+		c.resumed = false
+		resumed, err := c.setSeekFromCheckpoint()
+		if err != nil {
+			return err
+		}
+		if !resumed {
+			c.seekType = seek.Newest
+		}
 	}
 	return nil
 }
 
+// setSeekFromCheckpoint sets the seek to the block after the checkpoint that was saved for the consumer (see
+// esdispatcher.WithCheckpointer). The saved checkpoint takes precedence over the seek options. False is returned
+// if there's no checkpointer or no checkpoint was saved.
+func (p *params) setSeekFromCheckpoint() (bool, error) {
+	if p.checkpointer == nil {
+		return false, nil
+	}
+
+	blockNum, ok, err := p.checkpointer.Load(p.checkpointName)
+	if err != nil {
+		return false, errors.WithMessage(err, "error loading checkpoint")
+	}
+	if !ok {
+		return false, nil
+	}
+
+	logger.Debugf("Resuming from block %d after the checkpoint of consumer [%s]", blockNum+1, p.checkpointName)
+	p.seekType = seek.FromBlock
+	p.fromBlock = blockNum + 1
+	return true, nil
+}
+
+// replayLimit returns the maximum number of blocks to replay. The limit only applies when
+// resuming from the last block received after a reconnect - not to the seek options
+// that were provided by the caller.
+func (c *Client) replayLimit() uint64 {
+	c.RLock()
+	defer c.RUnlock()
+
+	if !c.resumed {
+		return 0
+	}
+	return c.maxReplay
+}
+
+// RegisterBlockGapEvent registers for block gap events. A BlockGapEvent is published when blocks
+// are skipped after a reconnect since the maximum replay was exceeded (see WithMaxReplay).
+// Only one registration is allowed.
+func (c *Client) RegisterBlockGapEvent() (fab.Registration, <-chan *dispatcher.BlockGapEvent, error) {
+	if c.Stopped() {
+		return nil, nil, errors.New("event client is closed")
+	}
+
+	eventch := make(chan *dispatcher.BlockGapEvent, c.eventConsumerBufferSize)
+	errch := make(chan error)
+	regch := make(chan fab.Registration)
+	c.Submit(dispatcher.NewRegisterBlockGapEvent(eventch, regch, errch))
+
+	select {
+	case reg := <-regch:
+		return reg, eventch, nil
+	case err := <-errch:
+		return nil, nil, err
+	}
+}
+
 func (c *Client) seekInfo() (*ab.SeekInfo, error) {
 	c.RLock()
 	defer c.RUnlock()
 
+	var seekInfo *ab.SeekInfo
 	switch c.seekType {
 	case seek.Newest:
-		return seek.InfoNewest(), nil
+		seekInfo = seek.InfoNewest()
 	case seek.Oldest:
-		return seek.InfoOldest(), nil
+		seekInfo = seek.InfoOldest()
 	case seek.FromBlock:
-		return seek.InfoFrom(c.fromBlock), nil
+		seekInfo = seek.InfoFrom(c.fromBlock)
 	default:
 		return nil, errors.Errorf("unsupported seek type:[%s]", c.seekType)
 	}
+
+	if c.stopAtBlock {
+		return seek.StopAt(seekInfo, c.stopBlock), nil
+	}
+	return seekInfo, nil
 }
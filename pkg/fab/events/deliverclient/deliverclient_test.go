@@ -7,11 +7,14 @@ SPDX-License-Identifier: Apache-2.0
 package deliverclient
 
 import (
+	"sync"
 	"testing"
 	"time"
 
 	fabcontext "github.com/hyperledger/fabric-sdk-go/pkg/context"
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/checkpointer"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/client"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/client/dispatcher"
 	clientmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/events/client/mocks"
@@ -51,6 +54,61 @@ func TestOptionsInNewClient(t *testing.T) {
 	client.Close()
 }
 
+func TestConfiguredTimeouts(t *testing.T) {
+	ctx := fabclientmocks.NewMockContext(fabclientmocks.NewMockUser("user1"))
+	ctx.SetConfig(fabclientmocks.NewMockConfigWithTimeouts(map[core.TimeoutType]time.Duration{
+		core.EventServiceResponse: 7 * time.Second,
+	}))
+
+	eventClient, err := New(ctx, "mychannel", clientmocks.NewDiscoveryService(peer1, peer2))
+	if err != nil {
+		t.Fatalf("error creating deliver client: %s", err)
+	}
+	eventClient.Close()
+	if eventClient.respTimeout != 7*time.Second {
+		t.Fatalf("expecting the configured response timeout but got %s", eventClient.respTimeout)
+	}
+
+	eventClient, err = New(ctx, "mychannel", clientmocks.NewDiscoveryService(peer1, peer2), client.WithResponseTimeout(time.Second))
+	if err != nil {
+		t.Fatalf("error creating deliver client: %s", err)
+	}
+	eventClient.Close()
+	if eventClient.respTimeout != time.Second {
+		t.Fatalf("expecting the response timeout option to take precedence but got %s", eventClient.respTimeout)
+	}
+}
+
+func TestReplayLimit(t *testing.T) {
+	params := defaultParams()
+	WithMaxReplay(5)(params)
+
+	c := &Client{
+		Client: *client.New(false, esdispatcher.New()),
+		params: *params,
+	}
+
+	// The limit doesn't apply before reconnecting
+	if limit := c.replayLimit(); limit != 0 {
+		t.Fatalf("expecting no replay limit before reconnecting but got %d", limit)
+	}
+
+	// No blocks received yet so the client seeks from the newest block
+	c.setSeekFromLastBlockReceived(nil)
+	if limit := c.replayLimit(); limit != 0 {
+		t.Fatalf("expecting no replay limit when no blocks were received but got %d", limit)
+	}
+
+	c.Dispatcher().(*esdispatcher.Dispatcher).HandleBlock(servicemocks.NewBlockProducer().NewBlock("mychannel"))
+	c.setSeekFromLastBlockReceived(nil)
+	if limit := c.replayLimit(); limit != 5 {
+		t.Fatalf("expecting replay limit of 5 after reconnecting but got %d", limit)
+	}
+	if c.seekType != seek.FromBlock || c.fromBlock != 1 {
+		t.Fatalf("expecting to seek from block 1 but got seek type [%s] from block %d", c.seekType, c.fromBlock)
+	}
+}
+
 func TestClientConnect(t *testing.T) {
 	eventClient, err := New(
 		newMockContext(), "mychannel",
@@ -382,6 +440,329 @@ func testReconnectRegistration(t *testing.T, connectResults clientmocks.ConnectA
 	}
 }
 
+// TestAutoUpgradeToBlockEvents tests that a block event registration on a client that is connected for
+// filtered blocks causes the client to reconnect for full blocks and that no events are missed across the switch.
+func TestAutoUpgradeToBlockEvents(t *testing.T) {
+	channelID := "mychannel"
+
+	fledger := servicemocks.NewMockLedger(servicemocks.FilteredBlockEventFactory)
+	bledger := servicemocks.NewMockLedger(servicemocks.BlockEventFactory)
+
+	newBlock := func() {
+		fledger.NewFilteredBlock(channelID, servicemocks.NewFilteredTx("txID", pb.TxValidationCode_VALID))
+		bledger.NewBlock(channelID, servicemocks.NewTransaction("txID", pb.TxValidationCode_VALID, cb.HeaderType_ENDORSER_TRANSACTION))
+	}
+
+	eventClient, err := New(
+		newMockContext(), channelID,
+		clientmocks.NewDiscoveryService(peer1),
+		withConnectionProvider(
+			clientmocks.NewProviderFactory().Provider(
+				delivermocks.NewConnection(clientmocks.WithLedger(fledger)),
+			),
+			false,
+		),
+		withBlockConnectionProvider(
+			clientmocks.NewProviderFactory().Provider(
+				delivermocks.NewConnection(clientmocks.WithLedger(bledger)),
+			),
+		),
+		WithAutoUpgradeToBlockEvents(),
+		WithSeekType(seek.Oldest),
+		client.WithResponseTimeout(3*time.Second),
+	)
+	if err != nil {
+		t.Fatalf("error creating channel event client: %s", err)
+	}
+
+	_, fblockch, err := eventClient.RegisterFilteredBlockEvent()
+	if err != nil {
+		t.Fatalf("error registering for filtered block events: %s", err)
+	}
+
+	if err := eventClient.Connect(); err != nil {
+		t.Fatalf("error connecting: %s", err)
+	}
+	defer eventClient.Close()
+
+	for i := 0; i < 3; i++ {
+		newBlock()
+	}
+
+	for i := uint64(0); i < 3; i++ {
+		checkFilteredBlockEvent(t, fblockch, i)
+	}
+
+	_, blockch, err := eventClient.RegisterBlockEvent()
+	if err != nil {
+		t.Fatalf("error registering for block events: %s", err)
+	}
+	if !eventClient.BlockEventsPermitted() {
+		t.Fatalf("expecting block events to be permitted after the upgrade")
+	}
+
+	for i := 0; i < 3; i++ {
+		newBlock()
+	}
+
+	for i := uint64(3); i < 6; i++ {
+		checkFilteredBlockEvent(t, fblockch, i)
+		checkBlockEvent(t, blockch, i)
+	}
+
+	select {
+	case fbevent := <-fblockch:
+		t.Fatalf("unexpected filtered block event: %d", fbevent.FilteredBlock.Number)
+	case bevent := <-blockch:
+		t.Fatalf("unexpected block event: %d", bevent.Block.Header.Number)
+	case <-time.After(500 * time.Millisecond):
+	}
+}
+
+// TestAutoUpgradeToBlockEventsFailed tests that the client reverts to filtered blocks
+// if it's unable to connect for full blocks.
+func TestAutoUpgradeToBlockEventsFailed(t *testing.T) {
+	channelID := "mychannel"
+
+	fledger := servicemocks.NewMockLedger(servicemocks.FilteredBlockEventFactory)
+
+	eventClient, err := New(
+		newMockContext(), channelID,
+		clientmocks.NewDiscoveryService(peer1),
+		withConnectionProvider(
+			clientmocks.NewProviderFactory().Provider(
+				delivermocks.NewConnection(clientmocks.WithLedger(fledger)),
+			),
+			false,
+		),
+		withBlockConnectionProvider(
+			clientmocks.NewProviderFactory().FlakeyProvider(clientmocks.NewConnectResults()),
+		),
+		WithAutoUpgradeToBlockEvents(),
+		WithSeekType(seek.Oldest),
+		client.WithMaxConnectAttempts(1),
+		client.WithResponseTimeout(3*time.Second),
+	)
+	if err != nil {
+		t.Fatalf("error creating channel event client: %s", err)
+	}
+
+	_, fblockch, err := eventClient.RegisterFilteredBlockEvent()
+	if err != nil {
+		t.Fatalf("error registering for filtered block events: %s", err)
+	}
+
+	if err := eventClient.Connect(); err != nil {
+		t.Fatalf("error connecting: %s", err)
+	}
+	defer eventClient.Close()
+
+	fledger.NewFilteredBlock(channelID, servicemocks.NewFilteredTx("txID", pb.TxValidationCode_VALID))
+	checkFilteredBlockEvent(t, fblockch, 0)
+
+	if _, _, err := eventClient.RegisterBlockEvent(); err == nil {
+		t.Fatalf("expecting error registering for block events")
+	}
+	if eventClient.BlockEventsPermitted() {
+		t.Fatalf("expecting block events not to be permitted after a failed upgrade")
+	}
+	if eventClient.getUpgradeState() != notUpgraded {
+		t.Fatalf("expecting upgrade state to be reverted but got %d", eventClient.getUpgradeState())
+	}
+}
+
+// TestBoundedReplay tests that the client receives the blocks in the requested
+// range and then closes itself after sending a terminal connection event
+func TestBoundedReplay(t *testing.T) {
+	channelID := "mychannel"
+
+	ledger := servicemocks.NewMockLedger(servicemocks.BlockEventFactory)
+	for i := 0; i < 10; i++ {
+		ledger.NewBlock(channelID, servicemocks.NewTransaction("txID", pb.TxValidationCode_VALID, cb.HeaderType_ENDORSER_TRANSACTION))
+	}
+
+	connectch := make(chan *fab.ConnectionEvent, 10)
+	eventClient, err := New(
+		newMockContext(), channelID,
+		clientmocks.NewDiscoveryService(peer1),
+		withConnectionProvider(
+			clientmocks.NewProviderFactory().Provider(
+				delivermocks.NewConnection(clientmocks.WithLedger(ledger)),
+			),
+			true,
+		),
+		WithSeekFromBlock(3),
+		WithStopAtBlock(6),
+		client.WithConnectionEvent(connectch),
+		client.WithResponseTimeout(3*time.Second),
+	)
+	if err != nil {
+		t.Fatalf("error creating channel event client: %s", err)
+	}
+	defer eventClient.Close()
+
+	_, blockch, err := eventClient.RegisterBlockEvent()
+	if err != nil {
+		t.Fatalf("error registering for block events: %s", err)
+	}
+
+	if err := eventClient.Connect(); err != nil {
+		t.Fatalf("error connecting: %s", err)
+	}
+
+	for i := uint64(3); i <= 6; i++ {
+		checkBlockEvent(t, blockch, i)
+	}
+
+	select {
+	case event, ok := <-blockch:
+		if ok {
+			t.Fatalf("unexpected block event after the stop block: %d", event.Block.Header.Number)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for the block event channel to close")
+	}
+
+	var terminal *fab.ConnectionEvent
+	for event := range connectch {
+		if event.Terminal {
+			terminal = event
+		}
+	}
+	if terminal == nil {
+		t.Fatalf("expecting a terminal connection event")
+	}
+	if terminal.Err != nil {
+		t.Fatalf("expecting no error in the terminal connection event but got: %s", terminal.Err)
+	}
+	if !eventClient.Stopped() {
+		t.Fatalf("expecting client to be closed after the stop block")
+	}
+}
+func TestCheckpointer(t *testing.T) {
+	channelID := "mychannel"
+	consumer := "consumer1"
+
+	ledger := servicemocks.NewMockLedger(servicemocks.BlockEventFactory)
+	for i := 0; i < 5; i++ {
+		ledger.NewBlock(channelID, servicemocks.NewTransaction("txID", pb.TxValidationCode_VALID, cb.HeaderType_ENDORSER_TRANSACTION))
+	}
+
+	// Simulate a crash after the events for block 3 were published but before its checkpoint was saved
+	cp := &crashingCheckpointer{Checkpointer: checkpointer.NewMemory(), crashAt: 3}
+
+	connectAndReceive := func(expected ...uint64) {
+		eventClient, err := New(
+			newMockContext(), channelID,
+			clientmocks.NewDiscoveryService(peer1),
+			withConnectionProvider(
+				clientmocks.NewProviderFactory().Provider(
+					delivermocks.NewConnection(clientmocks.WithLedger(ledger)),
+				),
+				true,
+			),
+			WithSeekOldest(),
+			esdispatcher.WithCheckpointer(consumer, cp),
+			client.WithResponseTimeout(3*time.Second),
+		)
+		if err != nil {
+			t.Fatalf("error creating channel event client: %s", err)
+		}
+		defer eventClient.Close()
+
+		_, blockch, err := eventClient.RegisterBlockEvent()
+		if err != nil {
+			t.Fatalf("error registering for block events: %s", err)
+		}
+		if err := eventClient.Connect(); err != nil {
+			t.Fatalf("error connecting: %s", err)
+		}
+		for _, blockNum := range expected {
+			checkBlockEvent(t, blockch, blockNum)
+		}
+	}
+
+	connectAndReceive(0, 1, 2, 3)
+
+	var blockNum uint64
+	for i := 0; i < 50; i++ {
+		var ok bool
+		if blockNum, ok, _ = cp.Load(consumer); ok && blockNum == 2 {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if blockNum != 2 {
+		t.Fatalf("expecting checkpoint at block 2 but got %d", blockNum)
+	}
+
+	// After the restart, the block whose checkpoint wasn't saved is delivered again (at-least-once)
+	cp.crashed()
+	connectAndReceive(3, 4)
+
+	params := defaultParams()
+	esdispatcher.WithCheckpointer(consumer, cp)(params)
+	if _, err := params.setSeekFromCheckpoint(); err != nil {
+		t.Fatalf("error seeking from checkpoint: %s", err)
+	}
+	if params.seekType != seek.FromBlock || params.fromBlock != 5 {
+		t.Fatalf("expecting to seek from block 5 but got seek type [%s] from block %d", params.seekType, params.fromBlock)
+	}
+}
+
+// crashingCheckpointer drops the checkpoints from the given block onwards (until crashed is
+// invoked) in order to simulate a crash between the publishing of a block and its checkpoint
+type crashingCheckpointer struct {
+	checkpointer.Checkpointer
+	mutex   sync.Mutex
+	crashAt uint64
+	crash   bool
+}
+
+func (c *crashingCheckpointer) Save(name string, blockNum uint64) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if !c.crash && blockNum >= c.crashAt {
+		return nil
+	}
+	return c.Checkpointer.Save(name, blockNum)
+}
+
+func (c *crashingCheckpointer) crashed() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.crash = true
+}
+
+func checkFilteredBlockEvent(t *testing.T, eventch <-chan *fab.FilteredBlockEvent, expectedBlockNum uint64) {
+	select {
+	case event, ok := <-eventch:
+		if !ok {
+			t.Fatalf("unexpected closed channel")
+		}
+		if event.FilteredBlock.Number != expectedBlockNum {
+			t.Fatalf("expecting filtered block %d but got %d", expectedBlockNum, event.FilteredBlock.Number)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for filtered block %d", expectedBlockNum)
+	}
+}
+
+func checkBlockEvent(t *testing.T, eventch <-chan *fab.BlockEvent, expectedBlockNum uint64) {
+	select {
+	case event, ok := <-eventch:
+		if !ok {
+			t.Fatalf("unexpected closed channel")
+		}
+		if event.Block.Header.Number != expectedBlockNum {
+			t.Fatalf("expecting block %d but got %d", expectedBlockNum, event.Block.Header.Number)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for block %d", expectedBlockNum)
+	}
+}
+
 func listenConnection(eventch chan *fab.ConnectionEvent, outcome chan clientmocks.Outcome) {
 	state := initialState
 
@@ -7,11 +7,14 @@ SPDX-License-Identifier: Apache-2.0
 package dispatcher
 
 import (
+	"math"
+
 	ab "github.com/hyperledger/fabric-sdk-go/internal/github.com/hyperledger/fabric/protos/orderer"
 	fabcontext "github.com/hyperledger/fabric-sdk-go/pkg/context"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/api"
 	clientdisp "github.com/hyperledger/fabric-sdk-go/pkg/fab/events/client/dispatcher"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/deliverclient/seek"
 	esdispatcher "github.com/hyperledger/fabric-sdk-go/pkg/fab/events/service/dispatcher"
 	"github.com/hyperledger/fabric-sdk-go/pkg/logging"
 	"github.com/hyperledger/fabric-sdk-go/pkg/options"
@@ -33,13 +36,17 @@ type dsConnection interface {
 // This also avoids the need for synchronization.
 type Dispatcher struct {
 	clientdisp.Dispatcher
-	seekRequest *SeekEvent
+	seekRequest          *SeekEvent
+	blockGapRegistration *BlockGapReg
+	stopBlock            uint64
+	stopBlockReached     bool
 }
 
 // New returns a new deliver dispatcher
 func New(context fabcontext.Context, channelID string, connectionProvider api.ConnectionProvider, discoveryService fab.DiscoveryService, opts ...options.Opt) *Dispatcher {
 	return &Dispatcher{
 		Dispatcher: *clientdisp.New(context, channelID, connectionProvider, discoveryService, opts...),
+		stopBlock:  math.MaxUint64,
 	}
 }
 
@@ -65,13 +72,101 @@ func (ed *Dispatcher) handleSeekEvent(e esdispatcher.Event) {
 	}
 
 	ed.seekRequest = evt
+	ed.stopBlock = stopBlock(evt.SeekInfo)
+	ed.stopBlockReached = false
+
+	seekInfo := evt.SeekInfo
+	if gap, ok := ed.blockGap(evt); ok {
+		logger.Warnf("Not replaying blocks %d to %d for channel [%s] since the maximum replay of %d blocks was exceeded. Seeking from the newest block.", gap.FromBlock, gap.ToBlock, ed.ChannelID(), evt.MaxReplay)
+		ed.publishBlockGapEvent(gap)
+		seekInfo = seek.InfoNewest()
+		if ed.stopBlock < math.MaxUint64 {
+			seekInfo = seek.StopAt(seekInfo, ed.stopBlock)
+		}
+	}
 
-	if err := ed.connection().Send(evt.SeekInfo); err != nil {
+	if err := ed.connection().Send(seekInfo); err != nil {
 		evt.ErrCh <- errors.Wrapf(err, "error sending seek info for channel [%s]", ed.ChannelID())
 		ed.seekRequest = nil
 	}
 }
 
+// blockGap returns the blocks that would be skipped if the given seek request exceeds its maximum replay.
+// The ledger height is obtained from the connection (the deliver connection seeks the newest block); all
+// blocks are replayed if the height is unknown.
+func (ed *Dispatcher) blockGap(evt *SeekEvent) (*BlockGapEvent, bool) {
+	if evt.MaxReplay == 0 {
+		return nil, false
+	}
+
+	specified := evt.SeekInfo.GetStart().GetSpecified()
+	if specified == nil {
+		return nil, false
+	}
+
+	ledgerInfo, ok := ed.Connection().(api.LedgerInfoProvider)
+	if !ok {
+		return nil, false
+	}
+
+	height, ok := ledgerInfo.LedgerHeight()
+	if !ok || height <= specified.Number || height-specified.Number <= evt.MaxReplay {
+		return nil, false
+	}
+
+	// The newest block (height-1) is delivered when seeking from the newest block
+	return &BlockGapEvent{FromBlock: specified.Number, ToBlock: height - 2}, true
+}
+
+func (ed *Dispatcher) publishBlockGapEvent(gap *BlockGapEvent) {
+	if ed.blockGapRegistration == nil {
+		return
+	}
+
+	select {
+	case ed.blockGapRegistration.Eventch <- gap:
+	default:
+		logger.Warnf("Unable to send to block gap event channel.")
+	}
+}
+
+func (ed *Dispatcher) handleRegisterBlockGapEvent(e esdispatcher.Event) {
+	evt := e.(*RegisterBlockGapEvent)
+
+	if ed.blockGapRegistration != nil {
+		evt.ErrCh <- errors.New("registration already exists for block gap event")
+		return
+	}
+
+	ed.blockGapRegistration = evt.Reg
+	evt.RegCh <- evt.Reg
+}
+
+func (ed *Dispatcher) handleStopEvent(e esdispatcher.Event) {
+	if ed.blockGapRegistration != nil {
+		close(ed.blockGapRegistration.Eventch)
+		ed.blockGapRegistration = nil
+	}
+
+	ed.Dispatcher.HandleStopEvent(e)
+}
+
+// handleDeliverResponse dispatches the content of a response that's received from the deliver connection
+func (ed *Dispatcher) handleDeliverResponse(e esdispatcher.Event) {
+	evt := e.(*pb.DeliverResponse)
+
+	switch t := evt.Type.(type) {
+	case *pb.DeliverResponse_Status:
+		ed.handleDeliverResponseStatus(t)
+	case *pb.DeliverResponse_Block:
+		ed.handleDeliverResponseBlock(t)
+	case *pb.DeliverResponse_FilteredBlock:
+		ed.handleDeliverResponseFilteredBlock(t)
+	default:
+		logger.Warnf("Unsupported deliver response type: %T", evt.Type)
+	}
+}
+
 func (ed *Dispatcher) handleDeliverResponseStatus(e esdispatcher.Event) {
 	evt := e.(*pb.DeliverResponse_Status)
 
@@ -91,11 +186,62 @@ func (ed *Dispatcher) handleDeliverResponseStatus(e esdispatcher.Event) {
 }
 
 func (ed *Dispatcher) handleDeliverResponseBlock(e esdispatcher.Event) {
-	ed.HandleBlock(e.(*pb.DeliverResponse_Block).Block)
+	ed.handleBlock(e.(*pb.DeliverResponse_Block).Block)
 }
 
 func (ed *Dispatcher) handleDeliverResponseFilteredBlock(e esdispatcher.Event) {
-	ed.HandleFilteredBlock(e.(*pb.DeliverResponse_FilteredBlock).FilteredBlock)
+	ed.handleFilteredBlock(e.(*pb.DeliverResponse_FilteredBlock).FilteredBlock)
+}
+
+func (ed *Dispatcher) handleBlockEvent(e esdispatcher.Event) {
+	ed.handleBlock(e.(*cb.Block))
+}
+
+func (ed *Dispatcher) handleFilteredBlockEvent(e esdispatcher.Event) {
+	ed.handleFilteredBlock(e.(*pb.FilteredBlock))
+}
+
+func (ed *Dispatcher) handleBlock(block *cb.Block) {
+	if ed.stopBlockReached {
+		logger.Debugf("Ignoring block after the stop block %d", ed.stopBlock)
+		return
+	}
+	ed.HandleBlock(block)
+	ed.checkStopBlock()
+}
+
+func (ed *Dispatcher) handleFilteredBlock(fblock *pb.FilteredBlock) {
+	if ed.stopBlockReached {
+		logger.Debugf("Ignoring filtered block after the stop block %d", ed.stopBlock)
+		return
+	}
+	ed.HandleFilteredBlock(fblock)
+	ed.checkStopBlock()
+}
+
+// checkStopBlock disconnects from the event server with a terminal disconnected event
+// (i.e. the client doesn't reconnect) once the stop block of the seek request is dispatched
+func (ed *Dispatcher) checkStopBlock() {
+	blockNum := ed.LastBlockNum()
+	if ed.stopBlock == math.MaxUint64 || blockNum == math.MaxUint64 || blockNum < ed.stopBlock {
+		return
+	}
+
+	logger.Debugf("Stop block %d was received for channel [%s]. Disconnecting.", ed.stopBlock, ed.ChannelID())
+	ed.stopBlockReached = true
+
+	disconnected := clientdisp.NewDisconnectedEvent(nil)
+	disconnected.Terminal = true
+	ed.handleDisconnectedEvent(disconnected)
+}
+
+// stopBlock returns the block number at which the given seek request stops or
+// math.MaxUint64 if blocks are to be delivered indefinitely
+func stopBlock(seekInfo *ab.SeekInfo) uint64 {
+	if specified := seekInfo.GetStop().GetSpecified(); specified != nil {
+		return specified.Number
+	}
+	return math.MaxUint64
 }
 
 func (ed *Dispatcher) handleDisconnectedEvent(e esdispatcher.Event) {
@@ -113,9 +259,14 @@ func (ed *Dispatcher) handleDisconnectedEvent(e esdispatcher.Event) {
 func (ed *Dispatcher) registerHandlers() {
 	// Override Handlers
 	ed.RegisterHandler(&clientdisp.DisconnectedEvent{}, ed.handleDisconnectedEvent)
+	ed.RegisterHandler(&esdispatcher.StopEvent{}, ed.handleStopEvent)
+	ed.RegisterHandler(&cb.Block{}, ed.handleBlockEvent)
+	ed.RegisterHandler(&pb.FilteredBlock{}, ed.handleFilteredBlockEvent)
 
 	// Register handlers
 	ed.RegisterHandler(&SeekEvent{}, ed.handleSeekEvent)
+	ed.RegisterHandler(&RegisterBlockGapEvent{}, ed.handleRegisterBlockGapEvent)
+	ed.RegisterHandler(&pb.DeliverResponse{}, ed.handleDeliverResponse)
 	ed.RegisterHandler(&pb.DeliverResponse_Status{}, ed.handleDeliverResponseStatus)
 	ed.RegisterHandler(&pb.DeliverResponse_Block{}, ed.handleDeliverResponseBlock)
 	ed.RegisterHandler(&pb.DeliverResponse_FilteredBlock{}, ed.handleDeliverResponseFilteredBlock)
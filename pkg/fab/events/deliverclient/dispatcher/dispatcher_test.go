@@ -86,6 +86,99 @@ func TestSeek(t *testing.T) {
 	}
 }
 
+func TestSeekMaxReplay(t *testing.T) {
+	channelID := "testchannel"
+
+	dispatcher := New(
+		newMockContext(), channelID,
+		clientmocks.NewProviderFactory().Provider(
+			delivermocks.NewConnection(
+				clientmocks.WithLedger(servicemocks.NewMockLedger(servicemocks.BlockEventFactory)),
+				clientmocks.WithLedgerHeight(20),
+			),
+		),
+		clientmocks.NewDiscoveryService(peer1, peer2),
+	)
+	if err := dispatcher.Start(); err != nil {
+		t.Fatalf("Error starting dispatcher: %s", err)
+	}
+
+	dispatcherEventch, err := dispatcher.EventCh()
+	if err != nil {
+		t.Fatalf("Error getting event channel from dispatcher: %s", err)
+	}
+
+	regch := make(chan fab.Registration)
+	errch := make(chan error)
+	gapch := make(chan *BlockGapEvent, 10)
+	dispatcherEventch <- NewRegisterBlockGapEvent(gapch, regch, errch)
+	select {
+	case <-regch:
+	case err := <-errch:
+		t.Fatalf("Error registering for block gap events: %s", err)
+	}
+
+	// Only one registration is allowed
+	dispatcherEventch <- NewRegisterBlockGapEvent(make(chan *BlockGapEvent), regch, errch)
+	select {
+	case <-regch:
+		t.Fatalf("expecting error registering for block gap events twice")
+	case <-errch:
+	}
+
+	// Connect
+	dispatcherEventch <- clientdisp.NewConnectEvent(errch)
+	if err := <-errch; err != nil {
+		t.Fatalf("Error connecting: %s", err)
+	}
+
+	// 5 blocks to replay (15-19) is within the maximum
+	seekWithMaxReplay(t, dispatcherEventch, 15, 5)
+	select {
+	case gap := <-gapch:
+		t.Fatalf("unexpected block gap event: %+v", gap)
+	default:
+	}
+
+	// 10 blocks to replay (10-19) exceeds the maximum so blocks 10-18 are skipped
+	seekWithMaxReplay(t, dispatcherEventch, 10, 5)
+	select {
+	case gap := <-gapch:
+		if gap.FromBlock != 10 || gap.ToBlock != 18 {
+			t.Fatalf("expecting block gap from 10 to 18 but got %d to %d", gap.FromBlock, gap.ToBlock)
+		}
+	default:
+		t.Fatalf("expecting block gap event")
+	}
+
+	// Stop
+	stopResp := make(chan error)
+	dispatcherEventch <- esdispatcher.NewStopEvent(stopResp)
+	if err := <-stopResp; err != nil {
+		t.Fatalf("Error stopping dispatcher: %s", err)
+	}
+
+	if _, ok := <-gapch; ok {
+		t.Fatalf("expecting block gap event channel to be closed")
+	}
+}
+
+func seekWithMaxReplay(t *testing.T, dispatcherEventch chan<- interface{}, fromBlock, maxReplay uint64) {
+	errch := make(chan error)
+	seekEvent := NewSeekEvent(seek.InfoFrom(fromBlock), errch)
+	seekEvent.MaxReplay = maxReplay
+	dispatcherEventch <- seekEvent
+
+	select {
+	case err := <-errch:
+		if err != nil {
+			t.Fatalf("error from seek request: %s", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timeout waiting for deliver status response")
+	}
+}
+
 func TestTimedOutSeek(t *testing.T) {
 	channelID := "testchannel"
 
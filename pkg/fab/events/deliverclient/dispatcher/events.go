@@ -8,12 +8,19 @@ package dispatcher
 
 import (
 	ab "github.com/hyperledger/fabric-sdk-go/internal/github.com/hyperledger/fabric/protos/orderer"
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+	esdispatcher "github.com/hyperledger/fabric-sdk-go/pkg/fab/events/service/dispatcher"
 )
 
 // SeekEvent is a SeekInfo request to the deliver server
 type SeekEvent struct {
 	SeekInfo *ab.SeekInfo
 	ErrCh    chan<- error
+
+	// MaxReplay is the maximum number of blocks that may be replayed when seeking from a specific block.
+	// If the ledger height reported by the peer indicates that more blocks would be replayed then a
+	// BlockGapEvent is published and the seek is from the newest block instead. If 0 then there's no maximum.
+	MaxReplay uint64
 }
 
 // NewSeekEvent returns a new SeekRequestEvent
@@ -23,3 +30,30 @@ func NewSeekEvent(seekInfo *ab.SeekInfo, errch chan<- error) *SeekEvent {
 		ErrCh:    errch,
 	}
 }
+
+// BlockGapEvent indicates that, after reconnecting, the blocks from FromBlock to ToBlock (inclusive) were
+// not delivered since the number of blocks to replay exceeded the maximum (see SeekEvent.MaxReplay).
+// Instead, delivery resumed from the newest block.
+type BlockGapEvent struct {
+	FromBlock uint64
+	ToBlock   uint64
+}
+
+// BlockGapReg is a block gap registration
+type BlockGapReg struct {
+	Eventch chan<- *BlockGapEvent
+}
+
+// RegisterBlockGapEvent is a request to register for block gap events
+type RegisterBlockGapEvent struct {
+	esdispatcher.RegisterEvent
+	Reg *BlockGapReg
+}
+
+// NewRegisterBlockGapEvent creates a new RegisterBlockGapEvent
+func NewRegisterBlockGapEvent(eventch chan<- *BlockGapEvent, regch chan<- fab.Registration, errch chan<- error) *RegisterBlockGapEvent {
+	return &RegisterBlockGapEvent{
+		Reg:           &BlockGapReg{Eventch: eventch},
+		RegisterEvent: esdispatcher.NewRegisterEvent(regch, errch),
+	}
+}
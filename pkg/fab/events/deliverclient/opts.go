@@ -10,23 +10,35 @@ import (
 	"time"
 
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/api"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/checkpointer"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/deliverclient/seek"
 	"github.com/hyperledger/fabric-sdk-go/pkg/options"
 )
 
 type params struct {
 	connProvider      api.ConnectionProvider
+	blockConnProvider api.ConnectionProvider
 	permitBlockEvents bool
+	autoUpgrade       bool
 	seekType          seek.Type
 	fromBlock         uint64
+	stopAtBlock       bool
+	stopBlock         uint64
 	respTimeout       time.Duration
+	maxReplay         uint64
+	checkpointName    string
+	checkpointer      checkpointer.Checkpointer
+
+	eventConsumerBufferSize uint
 }
 
 func defaultParams() *params {
 	return &params{
-		connProvider: deliverFilteredProvider,
-		seekType:     seek.Newest,
-		respTimeout:  5 * time.Second,
+		connProvider:            deliverFilteredProvider,
+		blockConnProvider:       deliverProvider,
+		seekType:                seek.Newest,
+		respTimeout:             5 * time.Second,
+		eventConsumerBufferSize: 100,
 	}
 }
 
@@ -59,6 +71,73 @@ func WithBlockNum(value uint64) options.Opt {
 	}
 }
 
+// WithSeekOldest specifies that block events are to be received from the oldest block in the ledger
+func WithSeekOldest() options.Opt {
+	return WithSeekType(seek.Oldest)
+}
+
+// WithSeekNewest specifies that block events are to be received from the newest block in the ledger (default)
+func WithSeekNewest() options.Opt {
+	return WithSeekType(seek.Newest)
+}
+
+// WithSeekFromBlock specifies that block events are to be received from the given block number
+func WithSeekFromBlock(value uint64) options.Opt {
+	return func(p options.Params) {
+		WithSeekType(seek.FromBlock)(p)
+		WithBlockNum(value)(p)
+	}
+}
+
+// WithStopAtBlock specifies the last block for which events are to be received, for example, in order to replay a
+// range of blocks (see WithSeekFromBlock). Once the event for the given block has been dispatched, the client
+// disconnects and sends a terminal connection event (with no error) to the connection event channel
+// (see client.WithConnectionEvent). The client then closes itself unless it was created with
+// client.WithAutoCloseOnDisconnect(false).
+func WithStopAtBlock(value uint64) options.Opt {
+	return func(p options.Params) {
+		if setter, ok := p.(stopAtBlockSetter); ok {
+			setter.SetStopAtBlock(value)
+		}
+	}
+}
+
+// WithMaxReplay specifies the maximum number of blocks that are replayed after a reconnect. When the client
+// reconnects, it seeks from the block after the last block that was received so that no blocks are missed.
+// If the ledger height reported by the peer indicates that more than the given number of blocks would be
+// replayed then a BlockGapEvent is published (see RegisterBlockGapEvent) and the client seeks from the
+// newest block instead. If 0 (default) then all missed blocks are replayed.
+func WithMaxReplay(value uint64) options.Opt {
+	return func(p options.Params) {
+		if setter, ok := p.(maxReplaySetter); ok {
+			setter.SetMaxReplay(value)
+		}
+	}
+}
+
+// WithAutoUpgradeToBlockEvents indicates that, if a block event registration is made while the client is connected
+// for filtered blocks, the client reconnects for full blocks (seeking from the block after the last block received
+// so that no events are missed) rather than rejecting the registration. If the peer doesn't deliver full blocks
+// to the client then the client reverts to filtered blocks and the registration fails. The client never
+// reverts to filtered blocks once it has been upgraded. Note that the caller must have sufficient
+// privileges to receive full blocks.
+func WithAutoUpgradeToBlockEvents() options.Opt {
+	return func(p options.Params) {
+		if setter, ok := p.(autoUpgradeSetter); ok {
+			setter.SetAutoUpgradeToBlockEvents(true)
+		}
+	}
+}
+
+// withBlockConnectionProvider is used only for testing
+func withBlockConnectionProvider(connProvider api.ConnectionProvider) options.Opt {
+	return func(p options.Params) {
+		if setter, ok := p.(blockConnectionProviderSetter); ok {
+			setter.SetBlockConnectionProvider(connProvider)
+		}
+	}
+}
+
 // withConnectionProvider is used only for testing
 func withConnectionProvider(connProvider api.ConnectionProvider, permitBlockEvents bool) options.Opt {
 	return func(p options.Params) {
@@ -72,6 +151,14 @@ type connectionProviderSetter interface {
 	SetConnectionProvider(value api.ConnectionProvider, permitBlockEvents bool)
 }
 
+type blockConnectionProviderSetter interface {
+	SetBlockConnectionProvider(value api.ConnectionProvider)
+}
+
+type autoUpgradeSetter interface {
+	SetAutoUpgradeToBlockEvents(value bool)
+}
+
 type seekTypeSetter interface {
 	SetSeekType(value seek.Type)
 }
@@ -80,12 +167,30 @@ type fromBlockSetter interface {
 	SetFromBlock(value uint64)
 }
 
+type stopAtBlockSetter interface {
+	SetStopAtBlock(value uint64)
+}
+
+type maxReplaySetter interface {
+	SetMaxReplay(value uint64)
+}
+
 func (p *params) SetConnectionProvider(connProvider api.ConnectionProvider, permitBlockEvents bool) {
 	logger.Debugf("ConnectionProvider: %#v, PermitBlockEvents: %t", connProvider, permitBlockEvents)
 	p.connProvider = connProvider
 	p.permitBlockEvents = permitBlockEvents
 }
 
+func (p *params) SetBlockConnectionProvider(value api.ConnectionProvider) {
+	logger.Debugf("BlockConnectionProvider: %#v", value)
+	p.blockConnProvider = value
+}
+
+func (p *params) SetAutoUpgradeToBlockEvents(value bool) {
+	logger.Debugf("AutoUpgradeToBlockEvents: %t", value)
+	p.autoUpgrade = value
+}
+
 func (p *params) SetFromBlock(value uint64) {
 	logger.Debugf("FromBlock: %d", value)
 	p.fromBlock = value
@@ -96,7 +201,29 @@ func (p *params) SetSeekType(value seek.Type) {
 	p.seekType = value
 }
 
+func (p *params) SetStopAtBlock(value uint64) {
+	logger.Debugf("StopAtBlock: %d", value)
+	p.stopAtBlock = true
+	p.stopBlock = value
+}
+
 func (p *params) SetResponseTimeout(value time.Duration) {
 	logger.Debugf("ResponseTimeout: %s", value)
 	p.respTimeout = value
 }
+
+func (p *params) SetMaxReplay(value uint64) {
+	logger.Debugf("MaxReplay: %d", value)
+	p.maxReplay = value
+}
+
+func (p *params) SetEventConsumerBufferSize(value uint) {
+	logger.Debugf("EventConsumerBufferSize: %d", value)
+	p.eventConsumerBufferSize = value
+}
+
+func (p *params) SetCheckpointer(name string, cp checkpointer.Checkpointer) {
+	logger.Debugf("Checkpointer: name: %s, checkpointer: %#v", name, cp)
+	p.checkpointName = name
+	p.checkpointer = cp
+}
@@ -42,12 +42,24 @@ func InfoNewest() *ab.SeekInfo {
 	return newSeekInfo(newestPos, maxPos)
 }
 
+// InfoNewestOnly returns a SeekInfo struct that indicates to the deliver server
+// that we just want the newest block (and no blocks after it)
+func InfoNewestOnly() *ab.SeekInfo {
+	return newSeekInfo(newestPos, newestPos)
+}
+
 // InfoFrom returns a SeekInfo struct that indicates to the deliver server
 // that we want all blocks starting from the given block number
 func InfoFrom(fromBlock uint64) *ab.SeekInfo {
 	return newSeekInfo(seekFromPos(fromBlock), maxPos)
 }
 
+// StopAt returns a SeekInfo struct with the start position of the given SeekInfo that indicates
+// to the deliver server that no blocks are to be delivered after the given block number
+func StopAt(info *ab.SeekInfo, toBlock uint64) *ab.SeekInfo {
+	return newSeekInfo(info.Start, seekFromPos(toBlock))
+}
+
 func seekFromPos(fromBlock uint64) *ab.SeekPosition {
 	return &ab.SeekPosition{
 		Type: &ab.SeekPosition_Specified{
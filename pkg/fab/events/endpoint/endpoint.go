@@ -10,10 +10,13 @@ import (
 	"crypto/x509"
 	"time"
 
+	"github.com/hyperledger/fabric-sdk-go/pkg/context"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
 	"github.com/hyperledger/fabric-sdk-go/pkg/errors/status"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/comm"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/peer"
+	"github.com/hyperledger/fabric-sdk-go/pkg/options"
 	"github.com/spf13/cast"
 	"google.golang.org/grpc/keepalive"
 )
@@ -29,6 +32,9 @@ type EventEndpoint struct {
 	KeepAliveParams keepalive.ClientParameters
 	FailFast        bool
 	ConnectTimeout  time.Duration
+	MaxRecvMsgSize  int
+	MaxSendMsgSize  int
+	ProxyURL        string
 }
 
 // EventURL returns the event URL
@@ -36,9 +42,30 @@ func (e *EventEndpoint) EventURL() string {
 	return e.EvtURL
 }
 
-// FromPeerConfig creates a new EventEndpoint from the given config
-func FromPeerConfig(config core.Config, peerCfg core.NetworkPeer) (*EventEndpoint, error) {
-	p, err := peer.New(config, peer.FromPeerConfig(&peerCfg))
+// ConnectionOpts returns the options for the connection to the event server
+func (e *EventEndpoint) ConnectionOpts() []options.Opt {
+	opts := []options.Opt{
+		comm.WithHostOverride(e.HostOverride),
+		comm.WithCertificate(e.Certificate),
+		comm.WithKeepAliveParams(e.KeepAliveParams),
+		comm.WithFailFast(e.FailFast),
+		comm.WithConnectTimeout(e.ConnectTimeout),
+		comm.WithProxyURL(e.ProxyURL),
+	}
+	if e.MaxRecvMsgSize > 0 {
+		opts = append(opts, comm.WithMaxRecvMsgSize(e.MaxRecvMsgSize))
+	}
+	if e.MaxSendMsgSize > 0 {
+		opts = append(opts, comm.WithMaxSendMsgSize(e.MaxSendMsgSize))
+	}
+	return opts
+}
+
+// FromPeerConfig creates a new EventEndpoint from the given config. The connections to the peer are
+// dialed by the connector of the context.
+func FromPeerConfig(ctx context.ProviderContext, peerCfg core.NetworkPeer) (*EventEndpoint, error) {
+	config := ctx.Config()
+	p, err := peer.New(config, peer.FromPeerConfig(&peerCfg), peer.WithConnector(ctx.Connector()))
 	if err != nil {
 		return nil, err
 	}
@@ -59,7 +86,10 @@ func FromPeerConfig(config core.Config, peerCfg core.NetworkPeer) (*EventEndpoin
 		Certificate:     certificate,
 		KeepAliveParams: getKeepAliveOptions(peerCfg),
 		FailFast:        getFailFast(peerCfg),
-		ConnectTimeout:  config.TimeoutOrDefault(core.EventHubConnection),
+		ConnectTimeout:  config.Timeout(core.EventHubConnection),
+		MaxRecvMsgSize:  cast.ToInt(peerCfg.GRPCOptions["max-recv-msg-size"]),
+		MaxSendMsgSize:  cast.ToInt(peerCfg.GRPCOptions["max-send-msg-size"]),
+		ProxyURL:        comm.ProxyURL(peerCfg.GRPCOptions),
 	}, nil
 }
 
@@ -27,6 +27,7 @@ import (
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
 	"github.com/hyperledger/fabric-sdk-go/pkg/core/config/urlutil"
 	"github.com/hyperledger/fabric-sdk-go/pkg/errors/status"
+	fabcomm "github.com/hyperledger/fabric-sdk-go/pkg/fab/comm"
 	consumer "github.com/hyperledger/fabric-sdk-go/pkg/fab/events/consumer"
 	"github.com/hyperledger/fabric-sdk-go/pkg/logging"
 	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/core/ledger/util"
@@ -66,19 +67,20 @@ type EventHub struct {
 	kap           keepalive.ClientParameters
 	failFast      bool
 	allowInsecure bool
+	proxyURL      string
 }
 
 // eventClientFactory creates an EventsClient instance
 type eventClientFactory interface {
-	newEventsClient(provider context.ProviderContext, identity context.IdentityContext, peerAddress string, certificate *x509.Certificate, serverHostOverride string, regTimeout time.Duration, adapter cnsmr.EventAdapter, kap keepalive.ClientParameters, failFast bool, allowInsecure bool) (fab.EventsClient, error)
+	newEventsClient(provider context.ProviderContext, identity context.IdentityContext, peerAddress string, certificate *x509.Certificate, serverHostOverride string, regTimeout time.Duration, adapter cnsmr.EventAdapter, kap keepalive.ClientParameters, failFast bool, allowInsecure bool, proxyURL string) (fab.EventsClient, error)
 }
 
 // consumerClientFactory is the default implementation oif the eventClientFactory
 type consumerClientFactory struct{}
 
 func (ccf *consumerClientFactory) newEventsClient(provider context.ProviderContext, identity context.IdentityContext, peerAddress string, certificate *x509.Certificate, serverHostOverride string,
-	regTimeout time.Duration, adapter cnsmr.EventAdapter, kap keepalive.ClientParameters, failFast bool, allowInsecure bool) (fab.EventsClient, error) {
-	return consumer.NewEventsClient(provider, identity, peerAddress, certificate, serverHostOverride, regTimeout, adapter, kap, failFast, allowInsecure)
+	regTimeout time.Duration, adapter cnsmr.EventAdapter, kap keepalive.ClientParameters, failFast bool, allowInsecure bool, proxyURL string) (fab.EventsClient, error) {
+	return consumer.NewEventsClient(provider, identity, peerAddress, certificate, serverHostOverride, regTimeout, adapter, kap, failFast, allowInsecure, proxyURL)
 }
 
 // Context holds the providers and services needed to create an EventHub.
@@ -129,6 +131,7 @@ func FromConfig(ctx Context, peerCfg *core.PeerConfig) (*EventHub, error) {
 	eventHub.kap = getKeepAliveOptions(peerCfg)
 	eventHub.failFast = getFailFast(peerCfg)
 	eventHub.allowInsecure = isInsecureConnectionAllowed(peerCfg)
+	eventHub.proxyURL = fabcomm.ProxyURL(peerCfg.GRPCOptions)
 
 	return eventHub, nil
 }
@@ -191,6 +194,7 @@ func (eventHub *EventHub) Disconnect() error {
 	}
 
 	eventHub.connected = false
+	eventHub.notifyDisconnected(nil)
 	return nil
 }
 
@@ -318,7 +322,7 @@ func (eventHub *EventHub) Connect() error {
 	if eventHub.grpcClient == nil {
 		eventsClient, _ := eventHub.eventsClientFactory.newEventsClient(eventHub.provider, eventHub.identity,
 			eventHub.peerAddr, eventHub.peerTLSCertificate, eventHub.peerTLSServerHostOverride,
-			eventHub.provider.Config().TimeoutOrDefault(core.EventReg), eventHub, eventHub.kap, eventHub.failFast, eventHub.allowInsecure)
+			eventHub.provider.Config().Timeout(core.EventReg), eventHub, eventHub.kap, eventHub.failFast, eventHub.allowInsecure, eventHub.proxyURL)
 		eventHub.grpcClient = eventsClient
 	}
 
@@ -380,6 +384,22 @@ func (eventHub *EventHub) Disconnected(err error) {
 	if err != nil {
 		logger.Warnf("EventHub was disconnected unexpectedly: %s", err)
 	}
+	eventHub.notifyDisconnected(err)
+}
+
+// notifyDisconnected notifies the transaction status registrants that the event hub was disconnected
+func (eventHub *EventHub) notifyDisconnected(cause error) {
+	err := errors.New("event hub was disconnected before the transaction was committed")
+	if cause != nil {
+		err = errors.WithMessage(cause, err.Error())
+	}
+	eventHub.txRegistrants.Range(func(key, value interface{}) bool {
+		if r := value.(*txRegistrant); r.notifyDisconnect {
+			eventHub.txRegistrants.Delete(key)
+			r.notify(key.(fab.TransactionID), pb.TxValidationCode_INVALID_OTHER_REASON, 0, err)
+		}
+		return true
+	})
 }
 
 // RegisterChaincodeEvent registers a callback function to receive chaincode events.
@@ -446,7 +466,19 @@ func (eventHub *EventHub) UnregisterChaincodeEvent(cbe *fab.ChainCodeCBE) {
 // is a json object representation of type "message Transaction"
 func (eventHub *EventHub) RegisterTxEvent(txnID fab.TransactionID, callback func(fab.TransactionID, pb.TxValidationCode, error)) {
 	logger.Debugf("reg txid %s\n", txnID)
-	eventHub.txRegistrants.Store(txnID, callback)
+	eventHub.txRegistrants.Store(txnID, &txRegistrant{
+		callback: func(txnID fab.TransactionID, code pb.TxValidationCode, blockNumber uint64, err error) {
+			callback(txnID, code, err)
+		},
+	})
+}
+
+// RegisterTxStatusEvent registers a callback function to receive the status of a transaction and the
+// number of the block that contains it. The callback is called once; if the event hub is disconnected
+// before the transaction is committed then it's called with an error.
+func (eventHub *EventHub) RegisterTxStatusEvent(txnID fab.TransactionID, callback func(fab.TransactionID, pb.TxValidationCode, uint64, error)) {
+	logger.Debugf("reg tx status txid %s\n", txnID)
+	eventHub.txRegistrants.Store(txnID, &txRegistrant{callback: callback, notifyDisconnect: true})
 }
 
 // UnregisterTxEvent unregister transactional event registration.
@@ -485,13 +517,13 @@ func (eventHub *EventHub) txCallback(block *common.Block) {
 			}
 
 			txnID := fab.TransactionID(channelHeader.TxId)
-			callback := eventHub.getTXRegistrant(txnID)
-			if callback != nil {
+			registrant := eventHub.getTXRegistrant(txnID)
+			if registrant != nil {
 				if txFilter.IsInvalid(i) {
-					callback(fab.TransactionID(txnID), txFilter.Flag(i),
+					registrant.notify(fab.TransactionID(txnID), txFilter.Flag(i), block.GetHeader().GetNumber(),
 						status.New(status.EventServerStatus, int32(txFilter.Flag(i)), "received invalid transaction", nil))
 				} else {
-					callback(fab.TransactionID(txnID), txFilter.Flag(i), nil)
+					registrant.notify(fab.TransactionID(txnID), txFilter.Flag(i), block.GetHeader().GetNumber(), nil)
 				}
 			} else {
 				logger.Debugf("No callback registered for TxID: %s\n", txnID)
@@ -524,12 +556,31 @@ func (eventHub *EventHub) getChaincodeRegistrants(chaincodeID string) []*fab.Cha
 	return clone
 }
 
-func (eventHub *EventHub) getTXRegistrant(txID fab.TransactionID) func(fab.TransactionID, pb.TxValidationCode, error) {
+func (eventHub *EventHub) getTXRegistrant(txID fab.TransactionID) *txRegistrant {
 	v, ok := eventHub.txRegistrants.Load(txID)
 	if !ok {
 		return nil
 	}
-	return v.(func(fab.TransactionID, pb.TxValidationCode, error))
+	return v.(*txRegistrant)
+}
+
+// txRegistrant holds a transaction event registration
+type txRegistrant struct {
+	callback func(fab.TransactionID, pb.TxValidationCode, uint64, error)
+	// notifyDisconnect is set for transaction status registrations, which are notified once
+	// (and also when the event hub is disconnected)
+	notifyDisconnect bool
+	once             sync.Once
+}
+
+func (r *txRegistrant) notify(txnID fab.TransactionID, code pb.TxValidationCode, blockNumber uint64, err error) {
+	if !r.notifyDisconnect {
+		r.callback(txnID, code, blockNumber, err)
+		return
+	}
+	r.once.Do(func() {
+		r.callback(txnID, code, blockNumber, err)
+	})
 }
 
 // getChainCodeEvents parses block events for chaincode events associated with individual transactions
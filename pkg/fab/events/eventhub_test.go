@@ -8,6 +8,7 @@ package events
 
 import (
 	"os"
+	"strings"
 	"sync/atomic"
 	"testing"
 
@@ -20,6 +21,7 @@ import (
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
 	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
 	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -498,6 +500,95 @@ func TestRegisterChaincodeEvent(t *testing.T) {
 
 }
 
+func TestTxStatusEvent(t *testing.T) {
+	channelID := "somechannelid"
+	txh, err := mocks.NewMockTransactionHeader(channelID)
+	if err != nil {
+		t.Fatalf("mock txn header failed: %s", err)
+	}
+
+	eventHub, clientFactory, err := createMockedEventHub()
+	if err != nil {
+		t.Fatalf("Failed to create mocked event hub: %s", err)
+	}
+	client := clientFactory.clients[0]
+
+	type txStatus struct {
+		code        pb.TxValidationCode
+		blockNumber uint64
+		err         error
+	}
+	txReceived := make(chan txStatus, 2)
+	eventHub.RegisterTxStatusEvent(txh.TransactionID(), func(txID fab.TransactionID, code pb.TxValidationCode, blockNumber uint64, err error) {
+		txReceived <- txStatus{code: code, blockNumber: blockNumber, err: err}
+	})
+
+	go client.MockEvent(&pb.Event{
+		Event: (&MockTxEventBuilder{
+			ChannelID:   channelID,
+			TxID:        string(txh.TransactionID()),
+			BlockNumber: 42,
+		}).Build(),
+	})
+
+	select {
+	case s := <-txReceived:
+		assert.Nil(t, s.err, "Expected valid transaction")
+		assert.Equal(t, pb.TxValidationCode_VALID, s.code)
+		assert.EqualValues(t, 42, s.blockNumber, "Expected the number of the block that contains the transaction")
+	case <-time.After(time.Second * 5):
+		t.Fatal("Timeout waiting for the transaction status")
+	}
+
+	// The registrant isn't notified again when the event hub is disconnected
+	eventHub.Disconnect()
+	select {
+	case s := <-txReceived:
+		t.Fatalf("Expected the transaction status to be notified once, got %+v", s)
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+func TestTxStatusEventDisconnected(t *testing.T) {
+	eventHub, _, err := createMockedEventHub()
+	if err != nil {
+		t.Fatalf("Failed to create mocked event hub: %s", err)
+	}
+
+	txReceived := make(chan error, 1)
+	eventHub.RegisterTxStatusEvent("txid1", func(txID fab.TransactionID, code pb.TxValidationCode, blockNumber uint64, err error) {
+		txReceived <- err
+	})
+	legacyReceived := make(chan error, 1)
+	eventHub.RegisterTxEvent("txid2", func(txID fab.TransactionID, code pb.TxValidationCode, err error) {
+		legacyReceived <- err
+	})
+
+	eventHub.Disconnected(errors.New("connection lost"))
+
+	select {
+	case err := <-txReceived:
+		if err == nil || !strings.Contains(err.Error(), "connection lost") {
+			t.Fatalf("Expected disconnection error, got %v", err)
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatal("Timeout waiting for the disconnection to be notified")
+	}
+
+	// Transaction event registrations aren't notified of the disconnection
+	select {
+	case err := <-legacyReceived:
+		t.Fatalf("Expected the transaction event registrant not to be notified, got %v", err)
+	default:
+	}
+	if eventHub.getTXRegistrant("txid1") != nil {
+		t.Fatal("Expected the transaction status registration to be removed")
+	}
+	if eventHub.getTXRegistrant("txid2") == nil {
+		t.Fatal("Expected the transaction event registration to be kept")
+	}
+}
+
 // private test callback to be executed on chaincode event
 func (w *callbackWrapper) testChaincodeCallback(ce *fab.ChaincodeEvent) {
 	w.t.Logf("Received CC event: %v", ce)
@@ -606,4 +697,7 @@ func TestInterfaces(t *testing.T) {
 	if apiEventHub == nil {
 		t.Fatalf("this shouldn't happen.")
 	}
+	if _, ok := apiEventHub.(fab.TxStatusEventHub); !ok {
+		t.Fatalf("Expected the event hub to report transaction status events")
+	}
 }
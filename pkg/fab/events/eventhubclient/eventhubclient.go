@@ -31,7 +31,7 @@ var ehConnProvider = func(channelID string, context context.Context, peer fab.Pe
 	}
 
 	return connection.New(
-		context, channelID, eventEndpoint.EventURL(),
+		context, channelID, eventEndpoint.EventURL(), api.ConnectionOpts(peer)...,
 	)
 }
 
@@ -47,6 +47,9 @@ func New(context context.Context, channelID string, discoveryService fab.Discove
 		return nil, errors.New("expecting channel ID")
 	}
 
+	// The timeouts in the configuration are overridden by the options
+	opts = append([]options.Opt{client.WithTimeouts(context.Config())}, opts...)
+
 	params := defaultParams()
 	options.Apply(params, opts)
 
@@ -45,7 +45,7 @@ type mockEventClientFactory struct {
 }
 
 func (mecf *mockEventClientFactory) newEventsClient(provider context.ProviderContext, identity context.IdentityContext, peerAddress string, certificate *x509.Certificate, serverHostOverride string, regTimeout time.Duration,
-	adapter fcConsumer.EventAdapter, kap keepalive.ClientParameters, failFast bool, allowInsecure bool) (fab.EventsClient, error) {
+	adapter fcConsumer.EventAdapter, kap keepalive.ClientParameters, failFast bool, allowInsecure bool, proxyURL string) (fab.EventsClient, error) {
 	mec := &mockEventClient{
 		PeerAddress: peerAddress,
 		RegTimeout:  regTimeout,
@@ -134,8 +134,9 @@ func createMockedEventHub() (*EventHub, *mockEventClientFactory, error) {
 
 // MockTxEventBuilder builds a mock TX event block
 type MockTxEventBuilder struct {
-	ChannelID string
-	TxID      string
+	ChannelID   string
+	TxID        string
+	BlockNumber uint64
 }
 
 // MockCCEventBuilder builds a mock chaincode event
@@ -158,7 +159,7 @@ type MockCCBlockEventBuilder struct {
 func (b *MockTxEventBuilder) Build() *pb.Event_Block {
 	return &pb.Event_Block{
 		Block: &common.Block{
-			Header:   &common.BlockHeader{},
+			Header:   &common.BlockHeader{Number: b.BlockNumber},
 			Metadata: b.buildBlockMetadata(pb.TxValidationCode_VALID),
 			Data: &common.BlockData{
 				Data: [][]byte{protos_utils.MarshalOrPanic(b.buildEnvelope())},
@@ -171,7 +172,7 @@ func (b *MockTxEventBuilder) Build() *pb.Event_Block {
 func (b *MockTxEventBuilder) BuildWithTxValidationCode(c pb.TxValidationCode) *pb.Event_Block {
 	return &pb.Event_Block{
 		Block: &common.Block{
-			Header:   &common.BlockHeader{},
+			Header:   &common.BlockHeader{Number: b.BlockNumber},
 			Metadata: b.buildBlockMetadata(c),
 			Data: &common.BlockData{
 				Data: [][]byte{protos_utils.MarshalOrPanic(b.buildEnvelope())},
@@ -0,0 +1,261 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+/*
+Package filestore persists a single value to a file using a versioned envelope so that
+the format of the value may evolve across SDK releases.
+
+The envelope consists of a fixed-size header followed by the JSON-encoded payload:
+
+	magic   [4]byte  "FSEV"
+	major   uint16   major version of the payload format
+	minor   uint16   minor version of the payload format
+	length  uint32   length of the payload
+	crc     uint32   CRC-32 (IEEE) of the payload
+	payload [length]byte
+
+All integers are big-endian. A minor version only adds fields to the payload, so a reader
+ignores the fields it doesn't know about and accepts any minor version of its major version.
+A major version change requires a migration from the previous major version. A file that
+doesn't start with the magic is treated as version 0, i.e. the unversioned format that was
+written before the envelope was introduced.
+*/
+package filestore
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/logging"
+	"github.com/pkg/errors"
+)
+
+var logger = logging.NewLogger("fabric_sdk_go")
+
+// Magic identifies a file written in the envelope format
+const Magic = "FSEV"
+
+const headerSize = len(Magic) + 2 + 2 + 4 + 4
+
+// Migration migrates a payload from one major version to the next
+type Migration func(payload []byte) ([]byte, error)
+
+// Format describes the current version of the payload format and how to migrate
+// payloads that were written in older versions
+type Format struct {
+	// Major is the current major version (must be greater than 0)
+	Major uint16
+	// Minor is the current minor version
+	Minor uint16
+	// Migrations maps a major version to the migration from that version to the next
+	// major version. The migration from version 0 converts the unversioned format.
+	Migrations map[uint16]Migration
+}
+
+// UnsupportedVersionError is returned when a file was written in a major version that is
+// newer than the current version, i.e. by a newer release of the SDK. The file is left as-is.
+type UnsupportedVersionError struct {
+	// Path is the path of the file
+	Path string
+	// Major is the major version of the file
+	Major uint16
+	// Supported is the current major version
+	Supported uint16
+}
+
+func (e *UnsupportedVersionError) Error() string {
+	return fmt.Sprintf("file [%s] has format version %d which is newer than the supported version %d", e.Path, e.Major, e.Supported)
+}
+
+// Store reads and writes a value at the given path
+type Store struct {
+	mutex  sync.Mutex
+	path   string
+	format Format
+	now    func() time.Time
+}
+
+// New returns a new file store for the given path and format
+func New(path string, format Format) (*Store, error) {
+	if path == "" {
+		return nil, errors.New("expecting file path")
+	}
+	if format.Major == 0 {
+		return nil, errors.New("format major version must be greater than 0")
+	}
+	return &Store{
+		path:   path,
+		format: format,
+		now:    time.Now,
+	}, nil
+}
+
+// Path returns the path of the file
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Write encodes the value as JSON and atomically replaces the file with an
+// envelope containing the value in the current format version
+func (s *Store) Write(value interface{}) error {
+	payload, err := json.Marshal(value)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal value")
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.write(payload)
+}
+
+// Read decodes the value in the file into the given value. False is returned if the file doesn't exist.
+// A file written in an older major version is migrated to the current version (and rewritten). If the
+// file is corrupted (or can't be migrated) then it's renamed aside and false is returned so that the
+// caller starts fresh. An UnsupportedVersionError is returned if the file was written in a newer major version.
+func (s *Store) Read(value interface{}) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	data, err := ioutil.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, errors.Wrapf(err, "failed to read file [%s]", s.path)
+	}
+
+	major, minor, payload, err := decode(data)
+	if err != nil {
+		return false, s.quarantine(err)
+	}
+
+	if major > s.format.Major {
+		return false, &UnsupportedVersionError{Path: s.path, Major: major, Supported: s.format.Major}
+	}
+
+	migrated := major < s.format.Major
+	if migrated {
+		payload, err = s.migrate(major, payload)
+		if err != nil {
+			return false, s.quarantine(err)
+		}
+	} else if minor > s.format.Minor {
+		logger.Debugf("File [%s] has minor version %d which is newer than %d. Unknown fields are ignored.", s.path, minor, s.format.Minor)
+	}
+
+	if err := json.Unmarshal(payload, value); err != nil {
+		return false, s.quarantine(errors.Wrap(err, "failed to unmarshal payload"))
+	}
+
+	if migrated {
+		logger.Infof("Migrated file [%s] from format version %d to %d", s.path, major, s.format.Major)
+		if err := s.write(payload); err != nil {
+			logger.Warnf("Error rewriting migrated file [%s]: %s", s.path, err)
+		}
+	}
+
+	return true, nil
+}
+
+func (s *Store) migrate(major uint16, payload []byte) ([]byte, error) {
+	for v := major; v < s.format.Major; v++ {
+		migration, ok := s.format.Migrations[v]
+		if !ok {
+			return nil, errors.Errorf("no migration from format version %d", v)
+		}
+		var err error
+		payload, err = migration(payload)
+		if err != nil {
+			return nil, errors.WithMessage(err, fmt.Sprintf("migration from format version %d failed", v))
+		}
+	}
+	return payload, nil
+}
+
+// quarantine renames the corrupted file aside so that the caller can start fresh. An error
+// is returned only if the file couldn't be renamed, since the file would otherwise be overwritten.
+func (s *Store) quarantine(cause error) error {
+	quarantinePath := fmt.Sprintf("%s.corrupt-%d", s.path, s.now().UnixNano())
+	if err := os.Rename(s.path, quarantinePath); err != nil {
+		return errors.Wrapf(err, "file [%s] is corrupted (%s) and could not be quarantined", s.path, cause)
+	}
+	logger.Errorf("File [%s] is corrupted: %s. Moved it to [%s] and starting fresh.", s.path, cause, quarantinePath)
+	return nil
+}
+
+func (s *Store) write(payload []byte) error {
+	dir := filepath.Dir(s.path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return errors.Wrapf(err, "failed to create directory [%s]", dir)
+	}
+
+	tmp, err := ioutil.TempFile(dir, filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return errors.Wrap(err, "failed to create temporary file")
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(encode(s.format.Major, s.format.Minor, payload))
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to write temporary file [%s]", tmp.Name())
+	}
+
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return errors.Wrapf(err, "failed to replace file [%s]", s.path)
+	}
+	return nil
+}
+
+func encode(major, minor uint16, payload []byte) []byte {
+	data := make([]byte, headerSize+len(payload))
+	copy(data, Magic)
+	binary.BigEndian.PutUint16(data[4:], major)
+	binary.BigEndian.PutUint16(data[6:], minor)
+	binary.BigEndian.PutUint32(data[8:], uint32(len(payload)))
+	binary.BigEndian.PutUint32(data[12:], crc32.ChecksumIEEE(payload))
+	copy(data[headerSize:], payload)
+	return data
+}
+
+// decode returns the version and payload of the given file contents. Contents
+// without the magic are returned as version 0.
+func decode(data []byte) (major, minor uint16, payload []byte, err error) {
+	if !bytes.HasPrefix(data, []byte(Magic)) {
+		return 0, 0, data, nil
+	}
+	if len(data) < headerSize {
+		return 0, 0, nil, errors.New("truncated header")
+	}
+
+	major = binary.BigEndian.Uint16(data[4:])
+	minor = binary.BigEndian.Uint16(data[6:])
+	length := binary.BigEndian.Uint32(data[8:])
+	checksum := binary.BigEndian.Uint32(data[12:])
+
+	payload = data[headerSize:]
+	if uint64(len(payload)) != uint64(length) {
+		return 0, 0, nil, errors.Errorf("expecting payload of %d bytes but got %d bytes", length, len(payload))
+	}
+	if crc32.ChecksumIEEE(payload) != checksum {
+		return 0, 0, nil, errors.New("checksum mismatch")
+	}
+	return major, minor, payload, nil
+}
@@ -0,0 +1,294 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package filestore
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+// The test record evolves as follows:
+// - version 0 (unversioned): the block number as plain text
+// - version 1: {"blockNum": <num>}
+// - version 2: {"blockNumber": <num>, "name": <name>} (version 2.1 adds "txIndex")
+
+type recordV1 struct {
+	BlockNum uint64 `json:"blockNum"`
+}
+
+type recordV2 struct {
+	BlockNumber uint64 `json:"blockNumber"`
+	Name        string `json:"name"`
+}
+
+type recordV21 struct {
+	BlockNumber uint64 `json:"blockNumber"`
+	Name        string `json:"name"`
+	TxIndex     int    `json:"txIndex"`
+}
+
+func migrateV0(payload []byte) ([]byte, error) {
+	blockNum, err := strconv.ParseUint(strings.TrimSpace(string(payload)), 10, 64)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid block number")
+	}
+	return json.Marshal(&recordV1{BlockNum: blockNum})
+}
+
+func migrateV1(payload []byte) ([]byte, error) {
+	var r recordV1
+	if err := json.Unmarshal(payload, &r); err != nil {
+		return nil, err
+	}
+	return json.Marshal(&recordV2{BlockNumber: r.BlockNum, Name: "default"})
+}
+
+var (
+	formatV1  = Format{Major: 1, Migrations: map[uint16]Migration{0: migrateV0}}
+	formatV2  = Format{Major: 2, Migrations: map[uint16]Migration{0: migrateV0, 1: migrateV1}}
+	formatV21 = Format{Major: 2, Minor: 1, Migrations: formatV2.Migrations}
+)
+
+func TestNew(t *testing.T) {
+	if _, err := New("", formatV1); err == nil {
+		t.Fatalf("expecting error for empty path")
+	}
+	if _, err := New("file", Format{}); err == nil {
+		t.Fatalf("expecting error for major version 0")
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	s1 := newStore(t, filepath.Join(dir, "v1"), formatV1)
+	if err := s1.Write(&recordV1{BlockNum: 10}); err != nil {
+		t.Fatalf("error writing: %s", err)
+	}
+	var r1 recordV1
+	mustRead(t, s1, &r1)
+	if r1.BlockNum != 10 {
+		t.Fatalf("expecting block 10 but got %d", r1.BlockNum)
+	}
+
+	s2 := newStore(t, filepath.Join(dir, "v2"), formatV2)
+	if err := s2.Write(&recordV2{BlockNumber: 20, Name: "consumer"}); err != nil {
+		t.Fatalf("error writing: %s", err)
+	}
+	var r2 recordV2
+	mustRead(t, s2, &r2)
+	if r2.BlockNumber != 20 || r2.Name != "consumer" {
+		t.Fatalf("unexpected record: %+v", r2)
+	}
+	checkVersion(t, s2.Path(), 2, 0)
+}
+
+func TestNotFound(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	var r recordV2
+	found, err := newStore(t, filepath.Join(dir, "missing"), formatV2).Read(&r)
+	if err != nil {
+		t.Fatalf("error reading: %s", err)
+	}
+	if found {
+		t.Fatalf("expecting missing file not to be found")
+	}
+}
+
+func TestMigrateUnversioned(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "checkpoint")
+	if err := ioutil.WriteFile(path, []byte("42\n"), 0644); err != nil {
+		t.Fatalf("error writing file: %s", err)
+	}
+
+	s := newStore(t, path, formatV2)
+	var r recordV2
+	mustRead(t, s, &r)
+	if r.BlockNumber != 42 || r.Name != "default" {
+		t.Fatalf("unexpected migrated record: %+v", r)
+	}
+
+	// The file is rewritten in the current version
+	checkVersion(t, path, 2, 0)
+	r = recordV2{}
+	mustRead(t, s, &r)
+	if r.BlockNumber != 42 {
+		t.Fatalf("expecting block 42 after rewrite but got %d", r.BlockNumber)
+	}
+}
+
+func TestMigrateV1(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "checkpoint")
+	if err := newStore(t, path, formatV1).Write(&recordV1{BlockNum: 7}); err != nil {
+		t.Fatalf("error writing: %s", err)
+	}
+
+	var r recordV2
+	mustRead(t, newStore(t, path, formatV2), &r)
+	if r.BlockNumber != 7 || r.Name != "default" {
+		t.Fatalf("unexpected migrated record: %+v", r)
+	}
+	checkVersion(t, path, 2, 0)
+}
+
+func TestNewerMinorVersion(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "checkpoint")
+	if err := newStore(t, path, formatV21).Write(&recordV21{BlockNumber: 5, Name: "consumer", TxIndex: 3}); err != nil {
+		t.Fatalf("error writing: %s", err)
+	}
+
+	// A 2.0 reader ignores the fields added in 2.1 and leaves the file alone
+	var r recordV2
+	mustRead(t, newStore(t, path, formatV2), &r)
+	if r.BlockNumber != 5 || r.Name != "consumer" {
+		t.Fatalf("unexpected record: %+v", r)
+	}
+	checkVersion(t, path, 2, 1)
+}
+
+func TestNewerMajorVersion(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "checkpoint")
+	if err := newStore(t, path, formatV2).Write(&recordV2{BlockNumber: 5}); err != nil {
+		t.Fatalf("error writing: %s", err)
+	}
+
+	var r recordV1
+	_, err := newStore(t, path, formatV1).Read(&r)
+	versionErr, ok := err.(*UnsupportedVersionError)
+	if !ok {
+		t.Fatalf("expecting UnsupportedVersionError but got: %v", err)
+	}
+	if versionErr.Major != 2 || versionErr.Supported != 1 {
+		t.Fatalf("unexpected error: %s", versionErr)
+	}
+
+	// The file written by the newer version is left as-is
+	checkVersion(t, path, 2, 0)
+}
+
+func TestCorruptedFile(t *testing.T) {
+	valid := encode(2, 0, []byte(`{"blockNumber":1}`))
+
+	badChecksum := append([]byte{}, valid...)
+	badChecksum[len(badChecksum)-2] = 'x'
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "TruncatedHeader", data: valid[:headerSize-1]},
+		{name: "TruncatedPayload", data: valid[:len(valid)-1]},
+		{name: "ChecksumMismatch", data: badChecksum},
+		{name: "InvalidPayload", data: encode(2, 0, []byte("{"))},
+		{name: "FailedMigration", data: []byte("not a block number")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := tempDir(t)
+			defer os.RemoveAll(dir)
+
+			path := filepath.Join(dir, "checkpoint")
+			if err := ioutil.WriteFile(path, test.data, 0644); err != nil {
+				t.Fatalf("error writing file: %s", err)
+			}
+
+			s := newStore(t, path, formatV2)
+			var r recordV2
+			found, err := s.Read(&r)
+			if err != nil {
+				t.Fatalf("expecting corrupted file to be quarantined without error but got: %s", err)
+			}
+			if found {
+				t.Fatalf("expecting corrupted file not to be found")
+			}
+
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				t.Fatalf("expecting corrupted file to be moved aside")
+			}
+			quarantined, err := filepath.Glob(path + ".corrupt-*")
+			if err != nil || len(quarantined) != 1 {
+				t.Fatalf("expecting one quarantined file but got %v (%v)", quarantined, err)
+			}
+			data, err := ioutil.ReadFile(quarantined[0])
+			if err != nil || string(data) != string(test.data) {
+				t.Fatalf("expecting quarantined file to have the original contents")
+			}
+
+			// Start fresh
+			if err := s.Write(&recordV2{BlockNumber: 3}); err != nil {
+				t.Fatalf("error writing: %s", err)
+			}
+			mustRead(t, s, &r)
+			if r.BlockNumber != 3 {
+				t.Fatalf("expecting block 3 but got %d", r.BlockNumber)
+			}
+		})
+	}
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "filestore")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %s", err)
+	}
+	return dir
+}
+
+func newStore(t *testing.T, path string, format Format) *Store {
+	s, err := New(path, format)
+	if err != nil {
+		t.Fatalf("error creating store: %s", err)
+	}
+	return s
+}
+
+func mustRead(t *testing.T, s *Store, value interface{}) {
+	found, err := s.Read(value)
+	if err != nil {
+		t.Fatalf("error reading: %s", err)
+	}
+	if !found {
+		t.Fatalf("expecting file [%s] to be found", s.Path())
+	}
+}
+
+func checkVersion(t *testing.T, path string, expectedMajor, expectedMinor uint16) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("error reading file: %s", err)
+	}
+	major, minor, _, err := decode(data)
+	if err != nil {
+		t.Fatalf("error decoding file: %s", err)
+	}
+	if major != expectedMajor || minor != expectedMinor {
+		t.Fatalf("expecting version %d.%d but got %d.%d", expectedMajor, expectedMinor, major, minor)
+	}
+}
@@ -10,6 +10,8 @@ import (
 	"io"
 	"sync"
 
+	"github.com/golang/protobuf/proto"
+	ab "github.com/hyperledger/fabric-sdk-go/internal/github.com/hyperledger/fabric/protos/orderer"
 	cb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
 	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
 )
@@ -18,6 +20,7 @@ import (
 type MockDeliverServer struct {
 	sync.RWMutex
 	disconnErr error
+	height     uint64
 }
 
 // NewMockDeliverServer returns a new MockDeliverServer
@@ -38,6 +41,36 @@ func (s *MockDeliverServer) disconnectErr() error {
 	return s.disconnErr
 }
 
+// SetLedgerHeight sets the height of the ledger. A seek for the newest block is answered
+// with block number height-1 (block 0 is delivered for all seeks by default).
+func (s *MockDeliverServer) SetLedgerHeight(height uint64) {
+	s.Lock()
+	defer s.Unlock()
+	s.height = height
+}
+
+// blockNum returns the number of the block that's delivered for the given seek request
+func (s *MockDeliverServer) blockNum(envelope *cb.Envelope) uint64 {
+	s.RLock()
+	defer s.RUnlock()
+	if s.height == 0 {
+		return 0
+	}
+
+	payload := &cb.Payload{}
+	if err := proto.Unmarshal(envelope.Payload, payload); err != nil {
+		return 0
+	}
+	seekInfo := &ab.SeekInfo{}
+	if err := proto.Unmarshal(payload.Data, seekInfo); err != nil {
+		return 0
+	}
+	if seekInfo.GetStart().GetNewest() == nil {
+		return 0
+	}
+	return s.height - 1
+}
+
 // Deliver delivers a stream of blocks
 func (s *MockDeliverServer) Deliver(srv pb.Deliver_DeliverServer) error {
 	srv.Send(&pb.DeliverResponse{
@@ -59,7 +92,7 @@ func (s *MockDeliverServer) Deliver(srv pb.Deliver_DeliverServer) error {
 
 		srv.Send(&pb.DeliverResponse{
 			Type: &pb.DeliverResponse_Block{
-				Block: &cb.Block{},
+				Block: &cb.Block{Header: &cb.BlockHeader{Number: s.blockNum(envelope)}},
 			},
 		})
 	}
@@ -86,7 +119,7 @@ func (s *MockDeliverServer) DeliverFiltered(srv pb.Deliver_DeliverFilteredServer
 
 		srv.Send(&pb.DeliverResponse{
 			Type: &pb.DeliverResponse_FilteredBlock{
-				FilteredBlock: &pb.FilteredBlock{},
+				FilteredBlock: &pb.FilteredBlock{Number: s.blockNum(envelope)},
 			},
 		})
 	}
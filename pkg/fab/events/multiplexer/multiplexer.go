@@ -0,0 +1,214 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package multiplexer
+
+import (
+	"sync"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/logging"
+)
+
+var logger = logging.NewLogger("fabric_sdk_go")
+
+// Kind is the kind of an event emitted by the Multiplexer
+type Kind int
+
+const (
+	// BlockKind indicates that the event contains a block event
+	BlockKind Kind = iota
+	// FilteredBlockKind indicates that the event contains a filtered block event
+	FilteredBlockKind
+	// ChaincodeKind indicates that the event contains a chaincode event
+	ChaincodeKind
+	// TxStatusKind indicates that the event contains a transaction status event
+	TxStatusKind
+	// ClosedKind indicates that the event channel of the registration has been closed
+	ClosedKind
+)
+
+// String returns the string representation of the kind
+func (k Kind) String() string {
+	switch k {
+	case BlockKind:
+		return "Block"
+	case FilteredBlockKind:
+		return "FilteredBlock"
+	case ChaincodeKind:
+		return "Chaincode"
+	case TxStatusKind:
+		return "TxStatus"
+	case ClosedKind:
+		return "Closed"
+	default:
+		return "Unknown"
+	}
+}
+
+// Event is emitted by the Multiplexer. Only the field corresponding to Kind is set.
+type Event struct {
+	Kind          Kind
+	Registration  fab.Registration
+	BlockEvent    *fab.BlockEvent
+	FilteredBlock *fab.FilteredBlockEvent
+	CCEvent       *fab.CCEvent
+	TxStatus      *fab.TxStatusEvent
+}
+
+// receiveFunc receives the next event from a source. It returns the event, or nil and closed=true
+// if the source channel has been closed, or nil and closed=false if done has been signalled.
+type receiveFunc func(done <-chan struct{}) (event *Event, closed bool)
+
+// Source is a registration and its event channel
+type Source struct {
+	reg     fab.Registration
+	receive receiveFunc
+}
+
+// BlockSource returns a source for a block event registration
+func BlockSource(reg fab.Registration, eventch <-chan *fab.BlockEvent) Source {
+	return Source{
+		reg: reg,
+		receive: func(done <-chan struct{}) (*Event, bool) {
+			select {
+			case e, ok := <-eventch:
+				if !ok {
+					return nil, true
+				}
+				return &Event{Kind: BlockKind, Registration: reg, BlockEvent: e}, false
+			case <-done:
+				return nil, false
+			}
+		},
+	}
+}
+
+// FilteredBlockSource returns a source for a filtered block event registration
+func FilteredBlockSource(reg fab.Registration, eventch <-chan *fab.FilteredBlockEvent) Source {
+	return Source{
+		reg: reg,
+		receive: func(done <-chan struct{}) (*Event, bool) {
+			select {
+			case e, ok := <-eventch:
+				if !ok {
+					return nil, true
+				}
+				return &Event{Kind: FilteredBlockKind, Registration: reg, FilteredBlock: e}, false
+			case <-done:
+				return nil, false
+			}
+		},
+	}
+}
+
+// ChaincodeSource returns a source for a chaincode event registration
+func ChaincodeSource(reg fab.Registration, eventch <-chan *fab.CCEvent) Source {
+	return Source{
+		reg: reg,
+		receive: func(done <-chan struct{}) (*Event, bool) {
+			select {
+			case e, ok := <-eventch:
+				if !ok {
+					return nil, true
+				}
+				return &Event{Kind: ChaincodeKind, Registration: reg, CCEvent: e}, false
+			case <-done:
+				return nil, false
+			}
+		},
+	}
+}
+
+// TxStatusSource returns a source for a transaction status event registration
+func TxStatusSource(reg fab.Registration, eventch <-chan *fab.TxStatusEvent) Source {
+	return Source{
+		reg: reg,
+		receive: func(done <-chan struct{}) (*Event, bool) {
+			select {
+			case e, ok := <-eventch:
+				if !ok {
+					return nil, true
+				}
+				return &Event{Kind: TxStatusKind, Registration: reg, TxStatus: e}, false
+			case <-done:
+				return nil, false
+			}
+		},
+	}
+}
+
+// Multiplexer merges the event channels of a set of registrations into a single channel.
+// Events from the same source are emitted in the order in which they were received. When the
+// channel of a source is closed, an event of kind ClosedKind is emitted for its registration.
+// The output channel is closed when all sources have been closed or when Stop is called.
+type Multiplexer struct {
+	eventch  chan *Event
+	done     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// New creates a new Multiplexer for the given sources and starts
+// forwarding events to the channel returned by Events.
+func New(sources ...Source) *Multiplexer {
+	m := &Multiplexer{
+		eventch: make(chan *Event),
+		done:    make(chan struct{}),
+	}
+
+	m.wg.Add(len(sources))
+	for _, source := range sources {
+		go m.forward(source)
+	}
+
+	go func() {
+		m.wg.Wait()
+		logger.Debugf("All sources are done - closing multiplexer event channel")
+		close(m.eventch)
+	}()
+
+	return m
+}
+
+// Events returns the channel to which events are emitted
+func (m *Multiplexer) Events() <-chan *Event {
+	return m.eventch
+}
+
+// Stop stops the Multiplexer. Events that have not yet been emitted are dropped and
+// the output channel is closed. Stop may be called more than once.
+func (m *Multiplexer) Stop() {
+	m.stopOnce.Do(func() {
+		close(m.done)
+	})
+	m.wg.Wait()
+}
+
+func (m *Multiplexer) forward(source Source) {
+	defer m.wg.Done()
+
+	for {
+		event, closed := source.receive(m.done)
+		if event == nil {
+			if !closed {
+				return
+			}
+			logger.Debugf("Source channel closed for registration %v", source.reg)
+			event = &Event{Kind: ClosedKind, Registration: source.reg}
+		}
+
+		select {
+		case m.eventch <- event:
+		case <-m.done:
+			return
+		}
+
+		if event.Kind == ClosedKind {
+			return
+		}
+	}
+}
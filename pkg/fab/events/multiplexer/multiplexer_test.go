@@ -0,0 +1,172 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package multiplexer
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+	cb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+)
+
+type mockReg struct {
+	name string
+}
+
+func TestOrdering(t *testing.T) {
+	numEvents := 100
+
+	breg := &mockReg{name: "block"}
+	beventch := make(chan *fab.BlockEvent)
+	fbreg := &mockReg{name: "filteredblock"}
+	fbeventch := make(chan *fab.FilteredBlockEvent)
+	ccreg := &mockReg{name: "cc"}
+	cceventch := make(chan *fab.CCEvent)
+	txreg := &mockReg{name: "tx"}
+	txeventch := make(chan *fab.TxStatusEvent)
+
+	m := New(
+		BlockSource(breg, beventch),
+		FilteredBlockSource(fbreg, fbeventch),
+		ChaincodeSource(ccreg, cceventch),
+		TxStatusSource(txreg, txeventch),
+	)
+	defer m.Stop()
+
+	go func() {
+		for i := 0; i < numEvents; i++ {
+			beventch <- &fab.BlockEvent{Block: &cb.Block{Header: &cb.BlockHeader{Number: uint64(i)}}}
+		}
+		close(beventch)
+	}()
+	go func() {
+		for i := 0; i < numEvents; i++ {
+			fbeventch <- &fab.FilteredBlockEvent{FilteredBlock: &pb.FilteredBlock{Number: uint64(i)}}
+		}
+		close(fbeventch)
+	}()
+	go func() {
+		for i := 0; i < numEvents; i++ {
+			cceventch <- &fab.CCEvent{TxID: fmt.Sprintf("%d", i)}
+		}
+		close(cceventch)
+	}()
+	go func() {
+		for i := 0; i < numEvents; i++ {
+			txeventch <- &fab.TxStatusEvent{TxID: fmt.Sprintf("%d", i)}
+		}
+		close(txeventch)
+	}()
+
+	next := make(map[Kind]int)
+	closed := make(map[fab.Registration]bool)
+
+	for {
+		select {
+		case event, ok := <-m.Events():
+			if !ok {
+				for _, reg := range []fab.Registration{breg, fbreg, ccreg, txreg} {
+					if !closed[reg] {
+						t.Fatalf("expecting closed event for registration %v", reg)
+					}
+				}
+				for _, kind := range []Kind{BlockKind, FilteredBlockKind, ChaincodeKind, TxStatusKind} {
+					if next[kind] != numEvents {
+						t.Fatalf("expecting %d events of kind %s but got %d", numEvents, kind, next[kind])
+					}
+				}
+				return
+			}
+			if event.Kind == ClosedKind {
+				if closed[event.Registration] {
+					t.Fatalf("received more than one closed event for registration %v", event.Registration)
+				}
+				closed[event.Registration] = true
+				continue
+			}
+			if closed[event.Registration] {
+				t.Fatalf("received %s event after the closed event for registration %v", event.Kind, event.Registration)
+			}
+			checkEvent(t, event, next[event.Kind])
+			next[event.Kind]++
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for events")
+		}
+	}
+}
+
+func checkEvent(t *testing.T, event *Event, expected int) {
+	var actual string
+	switch event.Kind {
+	case BlockKind:
+		actual = fmt.Sprintf("%d", event.BlockEvent.Block.Header.Number)
+	case FilteredBlockKind:
+		actual = fmt.Sprintf("%d", event.FilteredBlock.FilteredBlock.Number)
+	case ChaincodeKind:
+		actual = event.CCEvent.TxID
+	case TxStatusKind:
+		actual = event.TxStatus.TxID
+	default:
+		t.Fatalf("unexpected event kind: %s", event.Kind)
+	}
+	if actual != fmt.Sprintf("%d", expected) {
+		t.Fatalf("expecting %s event %d but got %s", event.Kind, expected, actual)
+	}
+}
+
+func TestStop(t *testing.T) {
+	beventch := make(chan *fab.BlockEvent, 1)
+	txeventch := make(chan *fab.TxStatusEvent)
+
+	m := New(
+		BlockSource(&mockReg{name: "block"}, beventch),
+		TxStatusSource(&mockReg{name: "tx"}, txeventch),
+	)
+
+	// Nobody reads this event so the forwarder is blocked on the output channel
+	beventch <- &fab.BlockEvent{}
+
+	stopped := make(chan struct{})
+	go func() {
+		m.Stop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for multiplexer to stop")
+	}
+
+	// Calling Stop again should not block or panic
+	m.Stop()
+
+	select {
+	case _, ok := <-m.Events():
+		if ok {
+			t.Fatalf("expecting no events after Stop")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for the event channel to be closed")
+	}
+}
+
+func TestNoSources(t *testing.T) {
+	m := New()
+	select {
+	case _, ok := <-m.Events():
+		if ok {
+			t.Fatalf("expecting no events")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for the event channel to be closed")
+	}
+	m.Stop()
+}
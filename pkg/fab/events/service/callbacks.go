@@ -0,0 +1,139 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package service
+
+import (
+	"reflect"
+	"runtime/debug"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/options"
+)
+
+// RegisterBlockEventFunc registers for block events which are delivered to the given callback rather than to
+// a channel. The callback is invoked serially on a Go routine that's owned by the service until the
+// registration is unregistered (see Unregister) or the service is stopped. A panic in the callback is
+// recovered and logged and doesn't prevent subsequent events from being delivered.
+func (s *Service) RegisterBlockEventFunc(callback func(*fab.BlockEvent), filter ...fab.BlockFilter) (fab.Registration, error) {
+	reg, eventch, err := s.RegisterBlockEvent(filter...)
+	if err != nil {
+		return nil, err
+	}
+
+	s.deliverToCallback(reg, "block event", eventch, callback)
+	return reg, nil
+}
+
+// RegisterFilteredBlockEventFunc registers for filtered block events which are delivered to the given callback
+// rather than to a channel (see RegisterBlockEventFunc).
+func (s *Service) RegisterFilteredBlockEventFunc(callback func(*fab.FilteredBlockEvent)) (fab.Registration, error) {
+	reg, eventch, err := s.RegisterFilteredBlockEvent()
+	if err != nil {
+		return nil, err
+	}
+
+	s.deliverToCallback(reg, "filtered block event", eventch, callback)
+	return reg, nil
+}
+
+// RegisterChaincodeEventFunc registers for chaincode events which are delivered to the given callback
+// rather than to a channel (see RegisterBlockEventFunc).
+func (s *Service) RegisterChaincodeEventFunc(ccID, eventFilter string, callback func(*fab.CCEvent), opts ...options.Opt) (fab.Registration, error) {
+	reg, eventch, err := s.RegisterChaincodeEvent(ccID, eventFilter, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	s.deliverToCallback(reg, "chaincode event", eventch, callback)
+	return reg, nil
+}
+
+// RegisterTxStatusEventFunc registers for the transaction status event of the given transaction which is
+// delivered to the given callback rather than to a channel (see RegisterBlockEventFunc).
+func (s *Service) RegisterTxStatusEventFunc(txID string, callback func(*fab.TxStatusEvent)) (fab.Registration, error) {
+	reg, eventch, err := s.RegisterTxStatusEvent(txID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.deliverToCallback(reg, "TxStatus event", eventch, callback)
+	return reg, nil
+}
+
+// deliverToCallback invokes the callback with each of the events that are received from the event channel
+// of the given registration. The callback must be a function whose single argument has the type of the
+// events of the channel (e.g. func(*fab.BlockEvent) for a chan *fab.BlockEvent).
+func (s *Service) deliverToCallback(reg fab.Registration, eventType string, eventch interface{}, callback interface{}) {
+	stopch := s.addCallback(reg)
+	cases := []reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(eventch)},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(stopch)},
+	}
+	fn := reflect.ValueOf(callback)
+
+	go func() {
+		defer s.removeCallback(reg)
+		for {
+			chosen, event, ok := reflect.Select(cases)
+			if chosen != 0 || !ok || stopped(stopch) {
+				return
+			}
+			invokeCallback(eventType, func() { fn.Call([]reflect.Value{event}) })
+		}
+	}()
+}
+
+// addCallback returns the channel that's closed when the given callback registration is unregistered
+func (s *Service) addCallback(reg fab.Registration) <-chan struct{} {
+	s.callbackMutex.Lock()
+	defer s.callbackMutex.Unlock()
+
+	if s.callbacks == nil {
+		s.callbacks = make(map[fab.Registration]chan struct{})
+	}
+	stopch := make(chan struct{})
+	s.callbacks[reg] = stopch
+	return stopch
+}
+
+func (s *Service) removeCallback(reg fab.Registration) {
+	s.callbackMutex.Lock()
+	defer s.callbackMutex.Unlock()
+	delete(s.callbacks, reg)
+}
+
+// stopCallback stops the delivery of events to the callback of the given registration
+// (if any) so that events which are already buffered aren't delivered after Unregister
+func (s *Service) stopCallback(reg fab.Registration) {
+	s.callbackMutex.Lock()
+	defer s.callbackMutex.Unlock()
+
+	if stopch, ok := s.callbacks[reg]; ok {
+		close(stopch)
+		delete(s.callbacks, reg)
+	}
+}
+
+func stopped(stopch <-chan struct{}) bool {
+	select {
+	case <-stopch:
+		return true
+	default:
+		return false
+	}
+}
+
+// invokeCallback invokes the given callback, recovering from (and logging) any panic
+func invokeCallback(eventType string, callback func()) {
+	defer func() {
+		if p := recover(); p != nil {
+			logger.Warnf("panic in %s callback: %s", eventType, p)
+			debug.PrintStack()
+		}
+	}()
+	callback()
+}
@@ -0,0 +1,150 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+	servicemocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/events/service/mocks"
+	cb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+)
+
+func TestBlockEventFunc(t *testing.T) {
+	channelID := "mychannel"
+	eventService, eventProducer, err := newServiceWithMockProducer(defaultOpts, withBlockLedger())
+	if err != nil {
+		t.Fatalf("error creating event service: %s", err)
+	}
+	defer eventProducer.Close()
+	defer eventService.Stop()
+
+	// The callback panics on the first event, which mustn't prevent the second event from being delivered
+	blocknumch := make(chan uint64, 10)
+	reg, err := eventService.RegisterBlockEventFunc(func(event *fab.BlockEvent) {
+		blocknumch <- event.Block.Header.Number
+		if event.Block.Header.Number == 0 {
+			panic("simulated callback panic")
+		}
+	})
+	if err != nil {
+		t.Fatalf("error registering for block events: %s", err)
+	}
+
+	newBlock := func() {
+		eventProducer.Ledger().NewBlock(channelID,
+			servicemocks.NewTransaction("txid", pb.TxValidationCode_VALID, cb.HeaderType_ENDORSER_TRANSACTION),
+		)
+	}
+
+	newBlock()
+	newBlock()
+
+	for i := uint64(0); i < 2; i++ {
+		select {
+		case blockNum := <-blocknumch:
+			if blockNum != i {
+				t.Fatalf("expecting block %d but got %d", i, blockNum)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for block %d", i)
+		}
+	}
+
+	eventService.Unregister(reg)
+	newBlock()
+
+	select {
+	case blockNum := <-blocknumch:
+		t.Fatalf("unexpected block %d after unregistering", blockNum)
+	case <-time.After(500 * time.Millisecond):
+	}
+}
+
+func TestTxStatusEventFunc(t *testing.T) {
+	channelID := "mychannel"
+	eventService, eventProducer, err := newServiceWithMockProducer(defaultOpts, withFilteredBlockLedger())
+	if err != nil {
+		t.Fatalf("error creating event service: %s", err)
+	}
+	defer eventProducer.Close()
+
+	eventch := make(chan *fab.TxStatusEvent, 1)
+	if _, err := eventService.RegisterTxStatusEventFunc("txid1", func(event *fab.TxStatusEvent) {
+		eventch <- event
+	}); err != nil {
+		t.Fatalf("error registering for TxStatus events: %s", err)
+	}
+
+	eventProducer.Ledger().NewFilteredBlock(channelID, servicemocks.NewFilteredTx("txid1", pb.TxValidationCode_VALID))
+
+	select {
+	case event := <-eventch:
+		checkTxStatusEvent(t, event, "txid1", pb.TxValidationCode_VALID)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for TxStatus event")
+	}
+
+	// The adapter exits when the service is stopped
+	eventService.Stop()
+	for i := 0; i < 50 && callbackCount(eventService) > 0; i++ {
+		time.Sleep(20 * time.Millisecond)
+	}
+	if n := callbackCount(eventService); n != 0 {
+		t.Fatalf("expecting no callbacks after the service is stopped but got %d", n)
+	}
+}
+
+func TestFilteredBlockAndChaincodeEventFuncs(t *testing.T) {
+	channelID := "mychannel"
+	eventService, eventProducer, err := newServiceWithMockProducer(defaultOpts, withFilteredBlockLedger())
+	if err != nil {
+		t.Fatalf("error creating event service: %s", err)
+	}
+	defer eventProducer.Close()
+	defer eventService.Stop()
+
+	fblockch := make(chan *fab.FilteredBlockEvent, 1)
+	if _, err := eventService.RegisterFilteredBlockEventFunc(func(event *fab.FilteredBlockEvent) {
+		fblockch <- event
+	}); err != nil {
+		t.Fatalf("error registering for filtered block events: %s", err)
+	}
+	ccch := make(chan *fab.CCEvent, 1)
+	if _, err := eventService.RegisterChaincodeEventFunc("mycc", "event1", func(event *fab.CCEvent) {
+		ccch <- event
+	}); err != nil {
+		t.Fatalf("error registering for chaincode events: %s", err)
+	}
+
+	eventProducer.Ledger().NewFilteredBlock(channelID, servicemocks.NewFilteredTxWithCCEvent("txid1", "mycc", "event1"))
+
+	select {
+	case event := <-fblockch:
+		if event.FilteredBlock == nil {
+			t.Fatalf("expecting filtered block in the filtered block event")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for filtered block event")
+	}
+	select {
+	case event := <-ccch:
+		if event.ChaincodeID != "mycc" || event.EventName != "event1" {
+			t.Fatalf("unexpected chaincode event: %+v", event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for chaincode event")
+	}
+}
+
+func callbackCount(eventService *Service) int {
+	eventService.callbackMutex.Lock()
+	defer eventService.callbackMutex.Unlock()
+	return len(eventService.callbacks)
+}
@@ -0,0 +1,36 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package dispatcher
+
+import (
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+	"github.com/pkg/errors"
+)
+
+// RegistrationAuthorizer decides whether the dispatcher honors a registration request,
+// for example, to prevent one tenant from registering for another tenant's chaincode events.
+type RegistrationAuthorizer interface {
+	// Authorize returns an error if the registration isn't permitted for the given scope.
+	// - scope identifies the requester (see RegisterEvent.Scope). It's empty if the request wasn't scoped.
+	// - reg is the requested registration, i.e. *BlockReg, *FilteredBlockReg, *ChaincodeReg or *TxStatusReg
+	Authorize(scope string, reg fab.Registration) error
+}
+
+// authorize consults the registration authorizer (if any). The error returned by the authorizer is
+// sent to the requester on the registration's error channel and false is returned.
+func (ed *Dispatcher) authorize(event *RegisterEvent, reg fab.Registration) bool {
+	if ed.authorizer == nil {
+		return true
+	}
+
+	if err := ed.authorizer.Authorize(event.Scope, reg); err != nil {
+		logger.Debugf("Registration %T for scope [%s] was not authorized: %s", reg, event.Scope, err)
+		event.ErrCh <- errors.WithMessage(err, "registration not authorized")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,162 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package dispatcher
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/options"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+	"github.com/pkg/errors"
+)
+
+// ReplayOutOfRangeError is sent on the ErrCh of a registration that requested a replay from a block
+// that is older than the oldest block in the event cache (see WithEventCache and WithReplayFromCache).
+type ReplayOutOfRangeError struct {
+	// FromBlock is the block from which the replay was requested
+	FromBlock uint64
+	// OldestBlock is the number of the oldest block in the event cache
+	OldestBlock uint64
+}
+
+func (e *ReplayOutOfRangeError) Error() string {
+	return fmt.Sprintf("cannot replay events from block %d since the oldest cached block is %d", e.FromBlock, e.OldestBlock)
+}
+
+// WithReplayFromCache specifies that the cached events (see WithEventCache) from the given block onwards that
+// match the registration are delivered to the registrant when it registers, in order and before any live events.
+// If the block is older than the oldest cached block then the registration fails with a ReplayOutOfRangeError.
+func WithReplayFromCache(fromBlock uint64) options.Opt {
+	return func(p options.Params) {
+		if setter, ok := p.(replayFromCacheSetter); ok {
+			setter.SetReplayFromCache(fromBlock)
+		}
+	}
+}
+
+type replayFromCacheSetter interface {
+	SetReplayFromCache(fromBlock uint64)
+}
+
+// cachedBlock is a filtered block in the event cache along with the data that's needed
+// to reproduce the events that were published for the block
+type cachedBlock struct {
+	fblock    *pb.FilteredBlock
+	txIndexes []int
+	sourceURL string
+}
+
+// eventCache is a ring buffer that holds the most recently dispatched filtered blocks.
+// It must only be accessed from a dispatcher handler.
+type eventCache struct {
+	blocks []*cachedBlock
+	start  int
+	count  int
+}
+
+func newEventCache(size int) *eventCache {
+	if size <= 0 {
+		return nil
+	}
+	return &eventCache{blocks: make([]*cachedBlock, size)}
+}
+
+// add adds the given block to the cache, evicting the oldest block if the cache is full
+func (c *eventCache) add(block *cachedBlock) {
+	if c.count < len(c.blocks) {
+		c.blocks[(c.start+c.count)%len(c.blocks)] = block
+		c.count++
+		return
+	}
+	c.blocks[c.start] = block
+	c.start = (c.start + 1) % len(c.blocks)
+}
+
+// clear removes all of the blocks from the cache
+func (c *eventCache) clear() {
+	for i := range c.blocks {
+		c.blocks[i] = nil
+	}
+	c.start = 0
+	c.count = 0
+}
+
+// from returns the cached blocks, in order, whose block number is greater than or equal to the given block number.
+// A ReplayOutOfRangeError is returned if the given block number is older than the oldest cached block.
+func (c *eventCache) from(blockNum uint64) ([]*cachedBlock, error) {
+	if c.count == 0 {
+		return nil, nil
+	}
+
+	if oldest := c.blocks[c.start].fblock.Number; blockNum < oldest {
+		return nil, &ReplayOutOfRangeError{FromBlock: blockNum, OldestBlock: oldest}
+	}
+
+	var blocks []*cachedBlock
+	for i := 0; i < c.count; i++ {
+		block := c.blocks[(c.start+i)%len(c.blocks)]
+		if block.fblock.Number >= blockNum {
+			blocks = append(blocks, block)
+		}
+	}
+	return blocks, nil
+}
+
+// cacheBlock adds the given filtered block to the event cache (if enabled)
+func (ed *Dispatcher) cacheBlock(fblock *pb.FilteredBlock, txIndexes []int) {
+	if ed.cache == nil || fblock == nil {
+		return
+	}
+	ed.cache.add(&cachedBlock{fblock: fblock, txIndexes: txIndexes, sourceURL: ed.sourceURL})
+}
+
+// clearCache removes all of the blocks from the event cache (if enabled)
+func (ed *Dispatcher) clearCache() {
+	if ed.cache != nil {
+		ed.cache.clear()
+	}
+}
+
+// cachedBlocks returns the cached blocks from which events are to be replayed to the registrant of the given
+// registration event. Nil is returned if a replay wasn't requested. An error is returned if a replay was requested
+// but the event cache isn't enabled or if the requested block is older than the oldest cached block.
+func (ed *Dispatcher) cachedBlocks(event *RegisterEvent) ([]*cachedBlock, error) {
+	if !event.Replay {
+		return nil, nil
+	}
+	if ed.cache == nil {
+		return nil, errors.New("cannot replay events since the event cache is not enabled")
+	}
+	return ed.cache.from(event.ReplayFromBlock)
+}
+
+func (ed *Dispatcher) replayFilteredBlockEvents(reg *FilteredBlockReg, blocks []*cachedBlock) {
+	for _, block := range blocks {
+		ed.sendFilteredBlockEvent(reg, &fab.FilteredBlockEvent{FilteredBlock: block.fblock})
+	}
+}
+
+func (ed *Dispatcher) replayTxStatusEvents(reg *TxStatusReg, blocks []*cachedBlock) {
+	for _, block := range blocks {
+		for _, tx := range block.fblock.FilteredTx {
+			if tx.Txid == reg.TxID {
+				ed.sendTxStatusEvent(reg, NewTxStatusEvent(tx.Txid, tx.TxValidationCode))
+			}
+		}
+	}
+}
+
+func (ed *Dispatcher) replayCCEvents(reg *ChaincodeReg, blocks []*cachedBlock) {
+	for _, block := range blocks {
+		forEachCCEvent(block.fblock, block.txIndexes, func(ccEvent *pb.ChaincodeEvent, txIndex, eventIndex int) {
+			if reg.matches(ccEvent) {
+				ed.sendCCEvent(reg, newCCEvent(ccEvent, block.fblock.Number, txIndex, eventIndex, block.sourceURL))
+			}
+		})
+	}
+}
@@ -0,0 +1,197 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package dispatcher
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+	servicemocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/events/service/mocks"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+)
+
+func TestEventCacheEviction(t *testing.T) {
+	if cache := newEventCache(0); cache != nil {
+		t.Fatalf("expecting the cache to be disabled for a size of 0")
+	}
+
+	cache := newEventCache(3)
+	for i := uint64(0); i < 5; i++ {
+		cache.add(&cachedBlock{fblock: &pb.FilteredBlock{Number: i}})
+	}
+
+	// The cache never holds more than its size and the oldest blocks are evicted first
+	if len(cache.blocks) != 3 || cache.count != 3 {
+		t.Fatalf("expecting 3 cached blocks but got %d (capacity %d)", cache.count, len(cache.blocks))
+	}
+	checkCachedBlocks(t, cache, 2, 2, 3, 4)
+	checkCachedBlocks(t, cache, 4, 4)
+	checkCachedBlocks(t, cache, 5)
+
+	_, err := cache.from(1)
+	rerr, ok := err.(*ReplayOutOfRangeError)
+	if !ok {
+		t.Fatalf("expecting ReplayOutOfRangeError but got %v", err)
+	}
+	if rerr.FromBlock != 1 || rerr.OldestBlock != 2 {
+		t.Fatalf("expecting replay from 1 with oldest block 2 but got %d and %d", rerr.FromBlock, rerr.OldestBlock)
+	}
+
+	cache.clear()
+	checkCachedBlocks(t, cache, 0)
+
+	// Blocks that are added after the cache is cleared are kept in order
+	cache.add(&cachedBlock{fblock: &pb.FilteredBlock{Number: 0}})
+	cache.add(&cachedBlock{fblock: &pb.FilteredBlock{Number: 1}})
+	checkCachedBlocks(t, cache, 0, 0, 1)
+}
+
+func checkCachedBlocks(t *testing.T, cache *eventCache, fromBlock uint64, expected ...uint64) {
+	blocks, err := cache.from(fromBlock)
+	if err != nil {
+		t.Fatalf("error getting cached blocks from %d: %s", fromBlock, err)
+	}
+
+	var blockNums []uint64
+	for _, block := range blocks {
+		blockNums = append(blockNums, block.fblock.Number)
+	}
+	if fmt.Sprint(blockNums) != fmt.Sprint(expected) {
+		t.Fatalf("expecting cached blocks %v from %d but got %v", expected, fromBlock, blockNums)
+	}
+}
+
+func TestReplayFromCache(t *testing.T) {
+	channelID := "testchannel"
+	ccID := "mycc"
+
+	dispatcher := New(WithEventCache(3))
+	if err := dispatcher.Start(); err != nil {
+		t.Fatalf("Error starting dispatcher: %s", err)
+	}
+
+	dispatcherEventch, err := dispatcher.EventCh()
+	if err != nil {
+		t.Fatalf("Error getting event channel from dispatcher: %s", err)
+	}
+
+	eventProducer := servicemocks.NewBlockProducer()
+	newBlock := func(i int) {
+		dispatcherEventch <- eventProducer.NewFilteredBlock(channelID,
+			servicemocks.NewFilteredTxWithCCEvent(fmt.Sprintf("txid%d", i), ccID, "event"),
+		)
+	}
+
+	// Blocks 0-4 are dispatched before anybody registers, so only blocks 2-4 remain in the cache
+	for i := 0; i < 5; i++ {
+		newBlock(i)
+	}
+
+	regch := make(chan fab.Registration, 1)
+	errch := make(chan error, 1)
+
+	// A replay older than the cache window is rejected
+	dispatcherEventch <- NewRegisterChaincodeEvent(ccID, "event", make(chan *fab.CCEvent, 10), regch, errch, WithReplayFromCache(1))
+	select {
+	case <-regch:
+		t.Fatalf("expecting registration to fail for a replay older than the cache")
+	case err := <-errch:
+		if rerr, ok := err.(*ReplayOutOfRangeError); !ok || rerr.OldestBlock != 2 {
+			t.Fatalf("expecting ReplayOutOfRangeError with oldest block 2 but got %v", err)
+		}
+	}
+
+	cceventch := make(chan *fab.CCEvent, 10)
+	dispatcherEventch <- NewRegisterChaincodeEvent(ccID, "event", cceventch, regch, errch, WithReplayFromCache(3))
+	getRegistration(t, regch, errch)
+
+	fbeventch := make(chan *fab.FilteredBlockEvent, 10)
+	fbregEvent := NewRegisterFilteredBlockEvent(fbeventch, regch, errch)
+	fbregEvent.Replay = true
+	fbregEvent.ReplayFromBlock = 4
+	dispatcherEventch <- fbregEvent
+	getRegistration(t, regch, errch)
+
+	txeventch := make(chan *fab.TxStatusEvent, 10)
+	txregEvent := NewRegisterTxStatusEvent("txid2", txeventch, regch, errch)
+	txregEvent.Replay = true
+	txregEvent.ReplayFromBlock = 2
+	dispatcherEventch <- txregEvent
+	getRegistration(t, regch, errch)
+
+	newBlock(5)
+
+	// The cached events are delivered in order and before the live events
+	for _, expected := range []uint64{3, 4, 5} {
+		select {
+		case event := <-cceventch:
+			if event.BlockNumber != expected {
+				t.Fatalf("expecting chaincode event for block %d but got block %d", expected, event.BlockNumber)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for chaincode event for block %d", expected)
+		}
+	}
+	for _, expected := range []uint64{4, 5} {
+		select {
+		case event := <-fbeventch:
+			if event.FilteredBlock.Number != expected {
+				t.Fatalf("expecting filtered block %d but got %d", expected, event.FilteredBlock.Number)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for filtered block %d", expected)
+		}
+	}
+	select {
+	case event := <-txeventch:
+		checkTxStatusEvent(t, event, "txid2", pb.TxValidationCode_VALID)
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for TxStatus event")
+	}
+
+	stopResp := make(chan error)
+	dispatcherEventch <- NewStopEvent(stopResp)
+	if err := <-stopResp; err != nil {
+		t.Fatalf("Error stopping dispatcher: %s", err)
+	}
+}
+
+func TestReplayWithoutCache(t *testing.T) {
+	dispatcher := New()
+	if err := dispatcher.Start(); err != nil {
+		t.Fatalf("Error starting dispatcher: %s", err)
+	}
+
+	dispatcherEventch, err := dispatcher.EventCh()
+	if err != nil {
+		t.Fatalf("Error getting event channel from dispatcher: %s", err)
+	}
+
+	regch := make(chan fab.Registration, 1)
+	errch := make(chan error, 1)
+	dispatcherEventch <- NewRegisterChaincodeEvent("mycc", "event", make(chan *fab.CCEvent, 10), regch, errch, WithReplayFromCache(0))
+	select {
+	case <-regch:
+		t.Fatalf("expecting registration to fail since the event cache is not enabled")
+	case err := <-errch:
+		if err == nil {
+			t.Fatalf("expecting error since the event cache is not enabled")
+		}
+	}
+
+	// A registration without a replay is unaffected
+	dispatcherEventch <- NewRegisterChaincodeEvent("mycc", "event", make(chan *fab.CCEvent, 10), regch, errch)
+	getRegistration(t, regch, errch)
+
+	stopResp := make(chan error)
+	dispatcherEventch <- NewStopEvent(stopResp)
+	if err := <-stopResp; err != nil {
+		t.Fatalf("Error stopping dispatcher: %s", err)
+	}
+}
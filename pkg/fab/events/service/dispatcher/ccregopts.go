@@ -0,0 +1,65 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package dispatcher
+
+import (
+	"github.com/hyperledger/fabric-sdk-go/pkg/options"
+)
+
+// ccRegParams contains the options for a chaincode registration
+type ccRegParams struct {
+	anchored        bool
+	caseInsensitive bool
+	replay          bool
+	replayFromBlock uint64
+	closeCh         chan<- *RegistrationClosedEvent
+}
+
+// WithAnchoredFilter specifies that the chaincode event filter must match the
+// entire event name, i.e. the filter is compiled as ^(?:filter)$.
+// By default the filter is unanchored, so a filter of "pay" also matches "payment_failed".
+func WithAnchoredFilter() options.Opt {
+	return func(p options.Params) {
+		if setter, ok := p.(anchoredFilterSetter); ok {
+			setter.SetAnchoredFilter(true)
+		}
+	}
+}
+
+// WithCaseInsensitiveFilter specifies that the chaincode event filter
+// is matched against the event name without regard to case.
+func WithCaseInsensitiveFilter() options.Opt {
+	return func(p options.Params) {
+		if setter, ok := p.(caseInsensitiveFilterSetter); ok {
+			setter.SetCaseInsensitiveFilter(true)
+		}
+	}
+}
+
+type anchoredFilterSetter interface {
+	SetAnchoredFilter(value bool)
+}
+
+type caseInsensitiveFilterSetter interface {
+	SetCaseInsensitiveFilter(value bool)
+}
+
+func (p *ccRegParams) SetAnchoredFilter(value bool) {
+	logger.Debugf("AnchoredFilter: %t", value)
+	p.anchored = value
+}
+
+func (p *ccRegParams) SetCaseInsensitiveFilter(value bool) {
+	logger.Debugf("CaseInsensitiveFilter: %t", value)
+	p.caseInsensitive = value
+}
+
+func (p *ccRegParams) SetReplayFromCache(fromBlock uint64) {
+	logger.Debugf("ReplayFromCache: %d", fromBlock)
+	p.replay = true
+	p.replayFromBlock = fromBlock
+}
@@ -0,0 +1,82 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package dispatcher
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/options"
+)
+
+// CloseReason is the reason that a registration's event channel was closed
+type CloseReason int
+
+const (
+	// ServiceStopped indicates that the event service was stopped, e.g. the event client was closed
+	ServiceStopped CloseReason = iota
+	// Unregistered indicates that the registration was removed with Unregister
+	Unregistered
+	// ConnectionTerminated indicates that the event client closed itself since the connection was
+	// terminated and won't be re-established (see client.WithAutoCloseOnDisconnect)
+	ConnectionTerminated
+)
+
+func (r CloseReason) String() string {
+	switch r {
+	case ServiceStopped:
+		return "service stopped"
+	case Unregistered:
+		return "unregistered"
+	case ConnectionTerminated:
+		return "connection terminated"
+	default:
+		return fmt.Sprintf("unknown(%d)", r)
+	}
+}
+
+// RegistrationClosedEvent is sent to the close notification channel of a registration (see
+// WithCloseNotification) just before the registration's event channel is closed
+type RegistrationClosedEvent struct {
+	Reg    fab.Registration
+	Reason CloseReason
+}
+
+// WithCloseNotification specifies a channel to which a RegistrationClosedEvent is sent just before the
+// registration's event channel is closed, so that the registrant can tell why the channel was closed.
+// The event is sent without blocking (it's dropped if the channel is full), so the channel should be buffered.
+// The option applies to chaincode registrations; for other registrations, set the CloseCh of the registration.
+func WithCloseNotification(value chan<- *RegistrationClosedEvent) options.Opt {
+	return func(p options.Params) {
+		if setter, ok := p.(closeNotificationSetter); ok {
+			setter.SetCloseNotification(value)
+		}
+	}
+}
+
+type closeNotificationSetter interface {
+	SetCloseNotification(value chan<- *RegistrationClosedEvent)
+}
+
+func (p *ccRegParams) SetCloseNotification(value chan<- *RegistrationClosedEvent) {
+	logger.Debugf("CloseNotification: %#v", value)
+	p.closeCh = value
+}
+
+// notifyClosed sends a RegistrationClosedEvent to the given close notification
+// channel (if any) without blocking
+func notifyClosed(closech chan<- *RegistrationClosedEvent, reg fab.Registration, reason CloseReason) {
+	if closech == nil {
+		return
+	}
+
+	select {
+	case closech <- &RegistrationClosedEvent{Reg: reg, Reason: reason}:
+	default:
+		logger.Warnf("Unable to send to close notification channel. Reason: %s", reason)
+	}
+}
@@ -0,0 +1,124 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package dispatcher
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+	cb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+)
+
+func TestCloseNotification(t *testing.T) {
+	testCloseNotification(t, func(eventch chan<- interface{}, reg fab.Registration) {
+		eventch <- NewUnregisterEvent(reg)
+	}, Unregistered)
+
+	testCloseNotification(t, func(eventch chan<- interface{}, reg fab.Registration) {
+		stopResp := make(chan error)
+		eventch <- NewStopEvent(stopResp)
+		<-stopResp
+	}, ServiceStopped)
+
+	testCloseNotification(t, func(eventch chan<- interface{}, reg fab.Registration) {
+		stopResp := make(chan error)
+		stopEvent := NewStopEvent(stopResp)
+		stopEvent.Reason = ConnectionTerminated
+		eventch <- stopEvent
+		<-stopResp
+	}, ConnectionTerminated)
+}
+
+func testCloseNotification(t *testing.T, closeReg func(eventch chan<- interface{}, reg fab.Registration), expectedReason CloseReason) {
+	dispatcher := New()
+	if err := dispatcher.Start(); err != nil {
+		t.Fatalf("Error starting dispatcher: %s", err)
+	}
+	defer dispatcher.ForceStop()
+
+	dispatcherEventch, err := dispatcher.EventCh()
+	if err != nil {
+		t.Fatalf("Error getting event channel from dispatcher: %s", err)
+	}
+
+	regch := make(chan fab.Registration, 1)
+	errch := make(chan error, 1)
+
+	closech := make(chan *RegistrationClosedEvent, 1)
+	cceventch := make(chan *fab.CCEvent, 1)
+	dispatcherEventch <- NewRegisterChaincodeEvent("mycc", "event", cceventch, regch, errch, WithCloseNotification(closech))
+	reg := getRegistration(t, regch, errch)
+
+	// A registration without a close notification channel is unaffected
+	beventch := make(chan *fab.BlockEvent, 1)
+	dispatcherEventch <- NewRegisterBlockEvent(func(block *cb.Block) bool { return true }, beventch, regch, errch)
+	getRegistration(t, regch, errch)
+
+	closeReg(dispatcherEventch, reg)
+
+	select {
+	case _, ok := <-cceventch:
+		if ok {
+			t.Fatalf("expecting chaincode event channel to be closed")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for chaincode event channel to close")
+	}
+
+	// The notification is sent before the event channel is closed
+	select {
+	case event := <-closech:
+		if event.Reason != expectedReason {
+			t.Fatalf("expecting close reason [%s] but got [%s]", expectedReason, event.Reason)
+		}
+		if event.Reg != reg {
+			t.Fatalf("expecting close notification for the chaincode registration")
+		}
+	default:
+		t.Fatalf("expecting close notification with reason [%s]", expectedReason)
+	}
+}
+
+func TestCloseNotificationNonBlocking(t *testing.T) {
+	dispatcher := New()
+	if err := dispatcher.Start(); err != nil {
+		t.Fatalf("Error starting dispatcher: %s", err)
+	}
+
+	dispatcherEventch, err := dispatcher.EventCh()
+	if err != nil {
+		t.Fatalf("Error getting event channel from dispatcher: %s", err)
+	}
+
+	regch := make(chan fab.Registration, 1)
+	errch := make(chan error, 1)
+
+	// Nobody receives from the close notification channel so the notification is dropped
+	fbeventch := make(chan *fab.FilteredBlockEvent, 1)
+	request := NewRegisterFilteredBlockEvent(fbeventch, regch, errch)
+	request.Reg.CloseCh = make(chan *RegistrationClosedEvent)
+	dispatcherEventch <- request
+	reg := getRegistration(t, regch, errch)
+
+	dispatcherEventch <- NewUnregisterEvent(reg)
+
+	select {
+	case _, ok := <-fbeventch:
+		if ok {
+			t.Fatalf("expecting filtered block event channel to be closed")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for filtered block event channel to close")
+	}
+
+	stopResp := make(chan error)
+	dispatcherEventch <- NewStopEvent(stopResp)
+	if err := <-stopResp; err != nil {
+		t.Fatalf("Error stopping dispatcher: %s", err)
+	}
+}
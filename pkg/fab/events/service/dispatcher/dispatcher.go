@@ -7,6 +7,7 @@ SPDX-License-Identifier: Apache-2.0
 package dispatcher
 
 import (
+	"fmt"
 	"math"
 	"reflect"
 	"regexp"
@@ -26,10 +27,35 @@ import (
 
 var logger = logging.NewLogger("fabric_sdk_go")
 
+// State is the state of a Dispatcher
+type State int32
+
+const (
+	// StateInitial indicates that the dispatcher hasn't been started
+	StateInitial State = iota
+	// StateStarted indicates that the dispatcher is processing events
+	StateStarted
+	// StateStopped indicates that the dispatcher has been stopped and is no longer usable
+	StateStopped
+)
+
+func (s State) String() string {
+	switch s {
+	case StateInitial:
+		return "initial"
+	case StateStarted:
+		return "started"
+	case StateStopped:
+		return "stopped"
+	default:
+		return fmt.Sprintf("unknown(%d)", s)
+	}
+}
+
 const (
-	dispatcherStateInitial = iota
-	dispatcherStateStarted
-	dispatcherStateStopped
+	dispatcherStateInitial = int32(StateInitial)
+	dispatcherStateStarted = int32(StateStarted)
+	dispatcherStateStopped = int32(StateStopped)
 )
 
 // Handler is the handler for a given event type.
@@ -46,12 +72,23 @@ type Dispatcher struct {
 	params
 	handlers                   map[reflect.Type]Handler
 	eventch                    chan interface{}
+	stopch                     chan struct{}
 	blockRegistrations         []*BlockReg
 	filteredBlockRegistrations []*FilteredBlockReg
 	txRegistrations            map[string]*TxStatusReg
 	ccRegistrations            map[string]*ChaincodeReg
+	panicRegistrations         []*HandlerPanicReg
 	state                      int32
 	lastBlockNum               uint64
+	lastBlockTime              int64
+	ingest                     *ingestMonitor
+	undelivered                FlushReport
+	checkpointHeld             bool
+	sourceURL                  string
+	channelID                  string
+	warnedEmptyChannelID       bool
+	rejectRegistrations        bool
+	cache                      *eventCache
 }
 
 // New creates a new Dispatcher.
@@ -65,10 +102,13 @@ func New(opts ...options.Opt) *Dispatcher {
 		params:          *params,
 		handlers:        make(map[reflect.Type]Handler),
 		eventch:         make(chan interface{}, params.eventConsumerBufferSize),
+		stopch:          make(chan struct{}),
 		txRegistrations: make(map[string]*TxStatusReg),
 		ccRegistrations: make(map[string]*ChaincodeReg),
 		state:           dispatcherStateInitial,
 		lastBlockNum:    math.MaxUint64,
+		ingest:          newIngestMonitor(params.ingestWindowSize),
+		cache:           newEventCache(params.eventCacheSize),
 	}
 }
 
@@ -78,8 +118,13 @@ func (ed *Dispatcher) RegisterHandlers() {
 	ed.RegisterHandler(&RegisterTxStatusEvent{}, ed.handleRegisterTxStatusEvent)
 	ed.RegisterHandler(&RegisterBlockEvent{}, ed.handleRegisterBlockEvent)
 	ed.RegisterHandler(&RegisterFilteredBlockEvent{}, ed.handleRegisterFilteredBlockEvent)
-	ed.RegisterHandler(&UnregisterEvent{}, ed.handleUnregisterEvent)
+	ed.RegisterHandler(&RegisterHandlerPanicEvent{}, ed.handleRegisterHandlerPanicEvent)
+	ed.RegisterHandler(&UnregisterEvent{}, ed.HandleUnregisterEvent)
 	ed.RegisterHandler(&StopEvent{}, ed.HandleStopEvent)
+	ed.RegisterHandler(&ResetEvent{}, ed.handleResetEvent)
+	ed.RegisterHandler(&FlushEvent{}, ed.handleFlushEvent)
+	ed.RegisterHandler(&RejectRegistrationsEvent{}, ed.handleRejectRegistrationsEvent)
+	ed.RegisterHandler(&RegistrationCountsEvent{}, ed.handleRegistrationCountsEvent)
 	ed.RegisterHandler(&cb.Block{}, ed.handleBlockEvent)
 	ed.RegisterHandler(&pb.FilteredBlock{}, ed.handleFilteredBlockEvent)
 }
@@ -101,6 +146,7 @@ func (ed *Dispatcher) Start() error {
 	}
 
 	ed.RegisterHandlers()
+	ed.startIngestMonitor()
 
 	go func() {
 		for {
@@ -109,47 +155,174 @@ func (ed *Dispatcher) Start() error {
 			}
 
 			logger.Debug("Listening for events...")
-			e, ok := <-ed.eventch
-			if !ok {
-				break
+			var e interface{}
+			select {
+			case e = <-ed.eventch:
+			case <-ed.stopch:
+				logger.Debug("Dispatcher was forcibly stopped")
+				continue
 			}
 
 			logger.Debugf("Received event: %v", reflect.TypeOf(e))
 
 			if handler, ok := ed.handlers[reflect.TypeOf(e)]; ok {
 				logger.Debugf("Dispatching event: %v", reflect.TypeOf(e))
-				handler(e)
+				ed.invoke(handler, e)
 			} else {
 				logger.Errorf("Handler not found for: %s", reflect.TypeOf(e))
 			}
 		}
+		// The registrations have already been cleared if the dispatcher was stopped with a stop event
+		// but not if it was forcibly stopped
+		ed.clearRegistrations(ServiceStopped)
 		logger.Debug("Exiting event dispatcher")
 	}()
 	return nil
 }
 
+// ForceStop stops the dispatcher without waiting for a stop event to be processed, for example,
+// when a handler is blocked and the dispatcher isn't responding. Events may no longer be submitted
+// and the registrations are removed (and their event channels closed) once the current handler,
+// if any, returns. The Dispatcher is no longer usable.
+func (ed *Dispatcher) ForceStop() {
+	if !ed.setState(dispatcherStateStarted, dispatcherStateStopped) {
+		logger.Debugf("Cannot force stop event dispatcher since it's not started.")
+		return
+	}
+	logger.Warnf("Forcibly stopping event dispatcher")
+	close(ed.stopch)
+	ed.stopIngestMonitor()
+}
+
+// State returns the current state of the dispatcher
+func (ed *Dispatcher) State() State {
+	return State(ed.getState())
+}
+
+// QueueDepth returns the number of events that are waiting to be processed
+func (ed *Dispatcher) QueueDepth() int {
+	return len(ed.eventch)
+}
+
 // LastBlockNum returns the block number of the last block for which an event was received.
 func (ed *Dispatcher) LastBlockNum() uint64 {
 	return atomic.LoadUint64(&ed.lastBlockNum)
 }
 
-// updateLastBlockNum updates the value of lastBlockNum and
-// returns the updated value.
+// LastBlockTime returns the time at which the last block was received (or the zero
+// time if no block has been received).
+func (ed *Dispatcher) LastBlockTime() time.Time {
+	t := atomic.LoadInt64(&ed.lastBlockTime)
+	if t == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, t)
+}
+
+// updateLastBlockNum updates the value of lastBlockNum (along with the time
+// at which the block was received) and returns the updated value.
 func (ed *Dispatcher) updateLastBlockNum(blockNum uint64) error {
 	// The Deliver Service shouldn't be sending blocks out of order.
 	// Log an error if we detect this happening.
 	lastBlockNum := atomic.LoadUint64(&ed.lastBlockNum)
 	if lastBlockNum == math.MaxUint64 || blockNum > lastBlockNum {
 		atomic.StoreUint64(&ed.lastBlockNum, blockNum)
+		atomic.StoreInt64(&ed.lastBlockTime, time.Now().UnixNano())
 		return nil
 	}
-	return errors.Errorf("Expecting a block number greater than %d but received block number %d", lastBlockNum, lastBlockNum)
+	return errors.Errorf("Expecting a block number greater than %d but received block number %d", lastBlockNum, blockNum)
+}
+
+// BlockHeightResetMargin returns the block height reset margin (see WithBlockHeightResetMargin).
+// Zero is returned if the ledger height isn't checked.
+func (ed *Dispatcher) BlockHeightResetMargin() uint64 {
+	return ed.blockHeightResetMargin
+}
+
+// CheckLedgerHeight is invoked with the ledger height that's reported by the peer when a connection
+// is established. If the last block on the peer's ledger is lower than the last block number by more
+// than the block height reset margin (see WithBlockHeightResetMargin) then the channel is assumed to
+// have been re-created and the last block number is reset. This function must be invoked from a
+// dispatcher handler.
+func (ed *Dispatcher) CheckLedgerHeight(height uint64) {
+	lastBlockNum := ed.LastBlockNum()
+	if ed.blockHeightResetMargin == 0 || lastBlockNum == math.MaxUint64 || height > lastBlockNum {
+		return
+	}
+
+	// The block height is the number of blocks in the ledger, so the last block on the peer is height-1
+	if lastBlockNum+1-height <= ed.blockHeightResetMargin {
+		return
+	}
+
+	logger.Warnf("*** The ledger height reported by the peer (%d) is lower than the last block number (%d) by more than %d. Assuming that the channel was re-created. Resetting last block number. ***", height, lastBlockNum, ed.blockHeightResetMargin)
+	ed.resetLastBlockNum()
+}
+
+// SetSourceURL sets the URL of the event server from which events are currently being received.
+// The URL is included in the chaincode events that are published. This function must be invoked
+// from a dispatcher handler.
+func (ed *Dispatcher) SetSourceURL(url string) {
+	ed.sourceURL = url
+}
+
+// SetChannelID sets the ID of the channel for which events are received. The configured channel ID is
+// authoritative: filtered blocks that arrive without a channel ID are stamped with it and blocks with
+// a different channel ID are logged. This function must be invoked before the dispatcher is started.
+func (ed *Dispatcher) SetChannelID(channelID string) {
+	ed.channelID = channelID
+}
+
+// checkChannelID returns a copy of the filtered block that's stamped with the configured channel ID if the
+// block's channel ID is empty (the received block isn't modified since it may be shared). A warning is logged
+// if the block's channel ID doesn't match the configured channel ID.
+func (ed *Dispatcher) checkChannelID(fblock *pb.FilteredBlock) *pb.FilteredBlock {
+	if ed.channelID == "" {
+		return fblock
+	}
+
+	if fblock.ChannelId == "" {
+		if !ed.warnedEmptyChannelID {
+			logger.Warnf("Received filtered block #%d without a channel ID. Using the configured channel ID [%s] for this and subsequent blocks.", fblock.Number, ed.channelID)
+			ed.warnedEmptyChannelID = true
+		}
+		return &pb.FilteredBlock{
+			ChannelId:  ed.channelID,
+			Number:     fblock.Number,
+			FilteredTx: fblock.FilteredTx,
+		}
+	}
+
+	if fblock.ChannelId != ed.channelID {
+		logger.Warnf("Received block #%d for channel [%s] but the configured channel is [%s]", fblock.Number, fblock.ChannelId, ed.channelID)
+	}
+	return fblock
+}
+
+// resetLastBlockNum clears the last block number so that the next block is accepted regardless of its number.
+// The event cache and the checkpoint (if any) are also cleared since the saved block numbers may no longer be
+// meaningful.
+func (ed *Dispatcher) resetLastBlockNum() {
+	atomic.StoreUint64(&ed.lastBlockNum, math.MaxUint64)
+	ed.clearCache()
+	ed.clearCheckpoint()
+}
+
+// clearRegistrations removes all registrations and closes the corresponding event channels. The given
+// reason is sent to the close notification channels of the registrations (see WithCloseNotification).
+func (ed *Dispatcher) clearRegistrations(reason CloseReason) {
+	ed.clearBlockRegistrations(reason)
+	ed.clearFilteredBlockRegistrations(reason)
+	ed.clearTxRegistrations(reason)
+	ed.clearChaincodeRegistrations(reason)
+	ed.clearHandlerPanicRegistrations()
 }
 
 // clearBlockRegistrations removes all block registrations and closes the corresponding event channels.
 // The listener will receive a 'closed' event to indicate that the channel has been closed.
-func (ed *Dispatcher) clearBlockRegistrations() {
+func (ed *Dispatcher) clearBlockRegistrations(reason CloseReason) {
 	for _, reg := range ed.blockRegistrations {
+		notifyClosed(reg.CloseCh, reg, reason)
 		close(reg.Eventch)
 	}
 	ed.blockRegistrations = nil
@@ -157,8 +330,9 @@ func (ed *Dispatcher) clearBlockRegistrations() {
 
 // clearFilteredBlockRegistrations removes all filtered block registrations and closes the corresponding event channels.
 // The listener will receive a 'closed' event to indicate that the channel has been closed.
-func (ed *Dispatcher) clearFilteredBlockRegistrations() {
+func (ed *Dispatcher) clearFilteredBlockRegistrations(reason CloseReason) {
 	for _, reg := range ed.filteredBlockRegistrations {
+		notifyClosed(reg.CloseCh, reg, reason)
 		close(reg.Eventch)
 	}
 	ed.filteredBlockRegistrations = nil
@@ -166,9 +340,10 @@ func (ed *Dispatcher) clearFilteredBlockRegistrations() {
 
 // clearTxRegistrations removes all transaction registrations and closes the corresponding event channels.
 // The listener will receive a 'closed' event to indicate that the channel has been closed.
-func (ed *Dispatcher) clearTxRegistrations() {
+func (ed *Dispatcher) clearTxRegistrations(reason CloseReason) {
 	for _, reg := range ed.txRegistrations {
 		logger.Debugf("Closing TX registration event channel for TxID [%s].", reg.TxID)
+		notifyClosed(reg.CloseCh, reg, reason)
 		close(reg.Eventch)
 	}
 	ed.txRegistrations = make(map[string]*TxStatusReg)
@@ -176,9 +351,10 @@ func (ed *Dispatcher) clearTxRegistrations() {
 
 // clearChaincodeRegistrations removes all chaincode registrations and closes the corresponding event channels.
 // The listener will receive a 'closed' event to indicate that the channel has been closed.
-func (ed *Dispatcher) clearChaincodeRegistrations() {
+func (ed *Dispatcher) clearChaincodeRegistrations(reason CloseReason) {
 	for _, reg := range ed.ccRegistrations {
 		logger.Debugf("Closing chaincode registration event channel for CC ID [%s] and event filter [%s].", reg.ChaincodeID, reg.EventFilter)
+		notifyClosed(reg.CloseCh, reg, reason)
 		close(reg.Eventch)
 	}
 	ed.ccRegistrations = make(map[string]*ChaincodeReg)
@@ -197,10 +373,17 @@ func (ed *Dispatcher) HandleStopEvent(e Event) {
 
 	// Remove all registrations and close the associated event channels
 	// so that the client is notified that the registration has been removed
-	ed.clearBlockRegistrations()
-	ed.clearFilteredBlockRegistrations()
-	ed.clearTxRegistrations()
-	ed.clearChaincodeRegistrations()
+	ed.clearRegistrations(event.Reason)
+	ed.stopIngestMonitor()
+
+	event.ErrCh <- nil
+}
+
+func (ed *Dispatcher) handleResetEvent(e Event) {
+	event := e.(*ResetEvent)
+
+	logger.Infof("Resetting dispatcher. Last block number was %d.", ed.LastBlockNum())
+	ed.resetLastBlockNum()
 
 	event.ErrCh <- nil
 }
@@ -208,46 +391,88 @@ func (ed *Dispatcher) HandleStopEvent(e Event) {
 func (ed *Dispatcher) handleRegisterBlockEvent(e Event) {
 	event := e.(*RegisterBlockEvent)
 
+	if !ed.acceptRegistration(&event.RegisterEvent, event.Reg) {
+		return
+	}
+
 	ed.blockRegistrations = append(ed.blockRegistrations, event.Reg)
 	event.RegCh <- event.Reg
 }
 
 func (ed *Dispatcher) handleRegisterFilteredBlockEvent(e Event) {
 	event := e.(*RegisterFilteredBlockEvent)
+
+	if !ed.acceptRegistration(&event.RegisterEvent, event.Reg) {
+		return
+	}
+
+	blocks, err := ed.cachedBlocks(&event.RegisterEvent)
+	if err != nil {
+		event.ErrCh <- err
+		return
+	}
+
 	ed.filteredBlockRegistrations = append(ed.filteredBlockRegistrations, event.Reg)
 	event.RegCh <- event.Reg
+	ed.replayFilteredBlockEvents(event.Reg, blocks)
 }
 
 func (ed *Dispatcher) handleRegisterCCEvent(e Event) {
 	event := e.(*RegisterChaincodeEvent)
 
-	key := getCCKey(event.Reg.ChaincodeID, event.Reg.EventFilter)
+	if !ed.acceptRegistration(&event.RegisterEvent, event.Reg) {
+		return
+	}
+
+	key := getCCKey(event.Reg)
 	if _, exists := ed.ccRegistrations[key]; exists {
 		event.ErrCh <- errors.Errorf("registration already exists for chaincode [%s] and event [%s]", event.Reg.ChaincodeID, event.Reg.EventFilter)
-	} else {
-		regExp, err := regexp.Compile(event.Reg.EventFilter)
-		if err != nil {
-			event.ErrCh <- errors.Wrapf(err, "error compiling regular expression for event filter [%s]", event.Reg.EventFilter)
-		} else {
-			event.Reg.EventRegExp = regExp
-			ed.ccRegistrations[key] = event.Reg
-			event.RegCh <- event.Reg
-		}
+		return
+	}
+
+	regExp, err := regexp.Compile(event.Reg.pattern())
+	if err != nil {
+		event.ErrCh <- errors.Wrapf(err, "error compiling regular expression for event filter [%s]", event.Reg.EventFilter)
+		return
 	}
+
+	blocks, err := ed.cachedBlocks(&event.RegisterEvent)
+	if err != nil {
+		event.ErrCh <- err
+		return
+	}
+
+	event.Reg.EventRegExp = regExp
+	ed.ccRegistrations[key] = event.Reg
+	event.RegCh <- event.Reg
+	ed.replayCCEvents(event.Reg, blocks)
 }
 
 func (ed *Dispatcher) handleRegisterTxStatusEvent(e Event) {
 	event := e.(*RegisterTxStatusEvent)
 
+	if !ed.acceptRegistration(&event.RegisterEvent, event.Reg) {
+		return
+	}
+
 	if _, exists := ed.txRegistrations[event.Reg.TxID]; exists {
 		event.ErrCh <- errors.Errorf("registration already exists for TX ID [%s]", event.Reg.TxID)
-	} else {
-		ed.txRegistrations[event.Reg.TxID] = event.Reg
-		event.RegCh <- event.Reg
+		return
+	}
+
+	blocks, err := ed.cachedBlocks(&event.RegisterEvent)
+	if err != nil {
+		event.ErrCh <- err
+		return
 	}
+
+	ed.txRegistrations[event.Reg.TxID] = event.Reg
+	event.RegCh <- event.Reg
+	ed.replayTxStatusEvents(event.Reg, blocks)
 }
 
-func (ed *Dispatcher) handleUnregisterEvent(e Event) {
+// HandleUnregisterEvent unregisters the registration of the given UnregisterEvent
+func (ed *Dispatcher) HandleUnregisterEvent(e Event) {
 	event := e.(*UnregisterEvent)
 
 	var err error
@@ -260,6 +485,8 @@ func (ed *Dispatcher) handleUnregisterEvent(e Event) {
 		err = ed.unregisterCCEvents(registration)
 	case *TxStatusReg:
 		err = ed.unregisterTXEvents(registration)
+	case *HandlerPanicReg:
+		err = ed.unregisterHandlerPanicEvents(registration)
 	default:
 		err = errors.Errorf("Unsupported registration type: %v", reflect.TypeOf(registration))
 	}
@@ -285,8 +512,14 @@ func (ed *Dispatcher) HandleBlock(block *cb.Block) {
 		return
 	}
 
+	undelivered := ed.undelivered.Undelivered
 	ed.publishBlockEvents(block)
-	ed.publishFilteredBlockEvents(toFilteredBlock(block))
+
+	fblock, txIndexes := toFilteredBlock(block, ed.channelID)
+	fblock = ed.checkChannelID(fblock)
+	ed.cacheBlock(fblock, txIndexes)
+	ed.publishFilteredBlockEvents(fblock, txIndexes)
+	ed.saveCheckpoint(block.Header.Number, ed.undelivered.Undelivered == undelivered)
 }
 
 // HandleFilteredBlock handles a filtered block event
@@ -298,8 +531,43 @@ func (ed *Dispatcher) HandleFilteredBlock(fblock *pb.FilteredBlock) {
 		return
 	}
 
+	fblock = ed.checkChannelID(fblock)
+	ed.cacheBlock(fblock, nil)
+
 	logger.Debugf("Publishing filtered block event...")
-	ed.publishFilteredBlockEvents(fblock)
+	undelivered := ed.undelivered.Undelivered
+	ed.publishFilteredBlockEvents(fblock, nil)
+	ed.saveCheckpoint(fblock.Number, ed.undelivered.Undelivered == undelivered)
+}
+
+// saveCheckpoint records the given block number in the checkpointer (if any). It must be invoked
+// once the events for the block have been published to all registrations; delivered tells whether
+// all of the events were delivered. If an event was dropped (the registration's channel was full or
+// the consumer timed out) then the checkpoint is held at the previous block until the dispatcher is
+// reset, so that the block is delivered again after a restart.
+func (ed *Dispatcher) saveCheckpoint(blockNum uint64, delivered bool) {
+	if ed.checkpointer == nil || ed.checkpointHeld {
+		return
+	}
+	if !delivered {
+		logger.Warnf("Events for block #%d couldn't be delivered. Holding the checkpoint of consumer [%s] at the previous block.", blockNum, ed.checkpointName)
+		ed.checkpointHeld = true
+		return
+	}
+	if err := ed.checkpointer.Save(ed.checkpointName, blockNum); err != nil {
+		logger.Warnf("Error saving checkpoint for block #%d of consumer [%s]: %s", blockNum, ed.checkpointName, err)
+	}
+}
+
+// clearCheckpoint removes the checkpoint from the checkpointer (if any)
+func (ed *Dispatcher) clearCheckpoint() {
+	ed.checkpointHeld = false
+	if ed.checkpointer == nil {
+		return
+	}
+	if err := ed.checkpointer.Clear(ed.checkpointName); err != nil {
+		logger.Warnf("Error clearing checkpoint of consumer [%s]: %s", ed.checkpointName, err)
+	}
 }
 
 func (ed *Dispatcher) unregisterBlockEvents(registration *BlockReg) error {
@@ -308,6 +576,7 @@ func (ed *Dispatcher) unregisterBlockEvents(registration *BlockReg) error {
 			// Move the 0'th item to i and then delete the 0'th item
 			ed.blockRegistrations[i] = ed.blockRegistrations[0]
 			ed.blockRegistrations = ed.blockRegistrations[1:]
+			notifyClosed(reg.CloseCh, reg, Unregistered)
 			close(reg.Eventch)
 			return nil
 		}
@@ -321,6 +590,7 @@ func (ed *Dispatcher) unregisterFilteredBlockEvents(registration *FilteredBlockR
 			// Move the 0'th item to i and then delete the 0'th item
 			ed.filteredBlockRegistrations[i] = ed.filteredBlockRegistrations[0]
 			ed.filteredBlockRegistrations = ed.filteredBlockRegistrations[1:]
+			notifyClosed(reg.CloseCh, reg, Unregistered)
 			close(reg.Eventch)
 			return nil
 		}
@@ -329,13 +599,14 @@ func (ed *Dispatcher) unregisterFilteredBlockEvents(registration *FilteredBlockR
 }
 
 func (ed *Dispatcher) unregisterCCEvents(registration *ChaincodeReg) error {
-	key := getCCKey(registration.ChaincodeID, registration.EventFilter)
+	key := getCCKey(registration)
 	reg, ok := ed.ccRegistrations[key]
 	if !ok {
 		return errors.New("the provided registration is invalid")
 	}
 
 	logger.Debugf("Unregistering CC event for CC ID [%s] and event filter [%s]...", registration.ChaincodeID, registration.EventFilter)
+	notifyClosed(reg.CloseCh, reg, Unregistered)
 	close(reg.Eventch)
 	delete(ed.ccRegistrations, key)
 	return nil
@@ -348,6 +619,7 @@ func (ed *Dispatcher) unregisterTXEvents(registration *TxStatusReg) error {
 	}
 
 	logger.Debugf("Unregistering Tx Status event for TxID [%s]...", registration.TxID)
+	notifyClosed(reg.CloseCh, reg, Unregistered)
 	close(reg.Eventch)
 	delete(ed.txRegistrations, registration.TxID)
 	return nil
@@ -355,30 +627,36 @@ func (ed *Dispatcher) unregisterTXEvents(registration *TxStatusReg) error {
 
 func (ed *Dispatcher) publishBlockEvents(block *cb.Block) {
 	for _, reg := range ed.blockRegistrations {
-		if !reg.Filter(block) {
+		if !ed.filterBlock(reg, block) {
 			logger.Debugf("Not sending block event for block #%d since it was filtered out.", block.Header.Number)
 			continue
 		}
 
+		event := &fab.BlockEvent{Block: block}
 		if ed.eventConsumerTimeout < 0 {
 			select {
-			case reg.Eventch <- &fab.BlockEvent{Block: block}:
+			case reg.Eventch <- event:
 			default:
 				logger.Warnf("Unable to send to block event channel.")
+				ed.recordUndelivered(reg, event)
 			}
 		} else if ed.eventConsumerTimeout == 0 {
-			reg.Eventch <- &fab.BlockEvent{Block: block}
+			reg.Eventch <- event
 		} else {
 			select {
-			case reg.Eventch <- &fab.BlockEvent{Block: block}:
+			case reg.Eventch <- event:
 			case <-time.After(ed.eventConsumerTimeout):
 				logger.Warnf("Timed out sending block event.")
+				ed.recordUndelivered(reg, event)
 			}
 		}
 	}
 }
 
-func (ed *Dispatcher) publishFilteredBlockEvents(fblock *pb.FilteredBlock) {
+// publishFilteredBlockEvents publishes the given filtered block along with its transaction status and chaincode events.
+// txIndexes contains the position within the block of each of the filtered transactions. If nil then the
+// filtered block contains all of the transactions in the block.
+func (ed *Dispatcher) publishFilteredBlockEvents(fblock *pb.FilteredBlock, txIndexes []int) {
 	if fblock == nil {
 		logger.Warnf("Filtered block is nil. Event will not be published")
 		return
@@ -387,107 +665,165 @@ func (ed *Dispatcher) publishFilteredBlockEvents(fblock *pb.FilteredBlock) {
 	logger.Debugf("Publishing filtered block event: %#v", fblock)
 
 	for _, reg := range ed.filteredBlockRegistrations {
-		if ed.eventConsumerTimeout < 0 {
-			select {
-			case reg.Eventch <- &fab.FilteredBlockEvent{FilteredBlock: fblock}:
-			default:
-				logger.Warnf("Unable to send to filtered block event channel.")
-			}
-		} else if ed.eventConsumerTimeout == 0 {
-			reg.Eventch <- &fab.FilteredBlockEvent{FilteredBlock: fblock}
-		} else {
-			select {
-			case reg.Eventch <- &fab.FilteredBlockEvent{FilteredBlock: fblock}:
-			case <-time.After(ed.eventConsumerTimeout):
-				logger.Warnf("Timed out sending filtered block event.")
-			}
-		}
+		ed.sendFilteredBlockEvent(reg, &fab.FilteredBlockEvent{FilteredBlock: fblock})
 	}
 
+	// Transactions are published in block order so that the events for each registration
+	// are delivered in (BlockNumber, TxIndex) order
 	for _, tx := range fblock.FilteredTx {
 		ed.publishTxStatusEvents(tx)
+	}
+	forEachCCEvent(fblock, txIndexes, func(ccEvent *pb.ChaincodeEvent, txIndex, eventIndex int) {
+		ed.publishCCEvents(ccEvent, fblock.Number, txIndex, eventIndex)
+	})
+}
 
+// forEachCCEvent invokes the given function, in block order, for each of the chaincode events of the committed
+// transactions in the given filtered block. txIndexes contains the position within the block of each of the
+// filtered transactions. If nil then the filtered block contains all of the transactions in the block.
+func forEachCCEvent(fblock *pb.FilteredBlock, txIndexes []int, f func(ccEvent *pb.ChaincodeEvent, txIndex, eventIndex int)) {
+	for i, tx := range fblock.FilteredTx {
 		// Only send a chaincode event if the transaction has committed
-		if tx.TxValidationCode == pb.TxValidationCode_VALID {
-			txActions := tx.GetTransactionActions()
-			if txActions == nil {
-				continue
-			}
-			for _, action := range txActions.ChaincodeActions {
-				if action.CcEvent != nil {
-					ed.publishCCEvents(action.CcEvent)
-				}
+		if tx.TxValidationCode != pb.TxValidationCode_VALID {
+			continue
+		}
+		txActions := tx.GetTransactionActions()
+		if txActions == nil {
+			continue
+		}
+
+		txIndex := i
+		if txIndexes != nil {
+			txIndex = txIndexes[i]
+		}
+
+		eventIndex := 0
+		for _, action := range txActions.ChaincodeActions {
+			if action.CcEvent != nil {
+				f(action.CcEvent, txIndex, eventIndex)
+				eventIndex++
 			}
 		}
 	}
 }
 
+func (ed *Dispatcher) sendFilteredBlockEvent(reg *FilteredBlockReg, event *fab.FilteredBlockEvent) {
+	if ed.eventConsumerTimeout < 0 {
+		select {
+		case reg.Eventch <- event:
+		default:
+			logger.Warnf("Unable to send to filtered block event channel.")
+			ed.recordUndelivered(reg, event)
+		}
+	} else if ed.eventConsumerTimeout == 0 {
+		reg.Eventch <- event
+	} else {
+		select {
+		case reg.Eventch <- event:
+		case <-time.After(ed.eventConsumerTimeout):
+			logger.Warnf("Timed out sending filtered block event.")
+			ed.recordUndelivered(reg, event)
+		}
+	}
+}
+
 func (ed *Dispatcher) publishTxStatusEvents(tx *pb.FilteredTransaction) {
 	logger.Debugf("Publishing Tx Status event for TxID [%s]...", tx.Txid)
 	if reg, ok := ed.txRegistrations[tx.Txid]; ok {
 		logger.Debugf("Sending Tx Status event for TxID [%s] to registrant...", tx.Txid)
+		ed.sendTxStatusEvent(reg, NewTxStatusEvent(tx.Txid, tx.TxValidationCode))
+	}
+}
 
-		if ed.eventConsumerTimeout < 0 {
-			select {
-			case reg.Eventch <- NewTxStatusEvent(tx.Txid, tx.TxValidationCode):
-			default:
-				logger.Warnf("Unable to send to Tx Status event channel.")
-			}
-		} else if ed.eventConsumerTimeout == 0 {
-			reg.Eventch <- NewTxStatusEvent(tx.Txid, tx.TxValidationCode)
-		} else {
-			select {
-			case reg.Eventch <- NewTxStatusEvent(tx.Txid, tx.TxValidationCode):
-			case <-time.After(ed.eventConsumerTimeout):
-				logger.Warnf("Timed out sending Tx Status event.")
-			}
+func (ed *Dispatcher) sendTxStatusEvent(reg *TxStatusReg, event *fab.TxStatusEvent) {
+	if ed.eventConsumerTimeout < 0 {
+		select {
+		case reg.Eventch <- event:
+			reg.fired = true
+		default:
+			logger.Warnf("Unable to send to Tx Status event channel.")
+			ed.recordUndelivered(reg, event)
+		}
+	} else if ed.eventConsumerTimeout == 0 {
+		reg.Eventch <- event
+		reg.fired = true
+	} else {
+		select {
+		case reg.Eventch <- event:
+			reg.fired = true
+		case <-time.After(ed.eventConsumerTimeout):
+			logger.Warnf("Timed out sending Tx Status event.")
+			ed.recordUndelivered(reg, event)
 		}
 	}
 }
 
-func (ed *Dispatcher) publishCCEvents(ccEvent *pb.ChaincodeEvent) {
+func (ed *Dispatcher) publishCCEvents(ccEvent *pb.ChaincodeEvent, blockNum uint64, txIndex, eventIndex int) {
 	for _, reg := range ed.ccRegistrations {
 		logger.Debugf("Matching CCEvent[%s,%s] against Reg[%s,%s] ...", ccEvent.ChaincodeId, ccEvent.EventName, reg.ChaincodeID, reg.EventFilter)
-		if reg.ChaincodeID == ccEvent.ChaincodeId && reg.EventRegExp.MatchString(ccEvent.EventName) {
+		if reg.matches(ccEvent) {
 			logger.Debugf("... matched CCEvent[%s,%s] against Reg[%s,%s]", ccEvent.ChaincodeId, ccEvent.EventName, reg.ChaincodeID, reg.EventFilter)
+			ed.sendCCEvent(reg, newCCEvent(ccEvent, blockNum, txIndex, eventIndex, ed.sourceURL))
+		}
+	}
+}
 
-			if ed.eventConsumerTimeout < 0 {
-				select {
-				case reg.Eventch <- NewChaincodeEvent(ccEvent.ChaincodeId, ccEvent.EventName, ccEvent.TxId):
-				default:
-					logger.Warnf("Unable to send to CC event channel.")
-				}
-			} else if ed.eventConsumerTimeout == 0 {
-				reg.Eventch <- NewChaincodeEvent(ccEvent.ChaincodeId, ccEvent.EventName, ccEvent.TxId)
-			} else {
-				select {
-				case reg.Eventch <- NewChaincodeEvent(ccEvent.ChaincodeId, ccEvent.EventName, ccEvent.TxId):
-				case <-time.After(ed.eventConsumerTimeout):
-					logger.Warnf("Timed out sending CC event.")
-				}
-			}
+func (ed *Dispatcher) sendCCEvent(reg *ChaincodeReg, event *fab.CCEvent) {
+	if ed.eventConsumerTimeout < 0 {
+		select {
+		case reg.Eventch <- event:
+		default:
+			logger.Warnf("Unable to send to CC event channel.")
+			ed.recordUndelivered(reg, event)
+		}
+	} else if ed.eventConsumerTimeout == 0 {
+		reg.Eventch <- event
+	} else {
+		select {
+		case reg.Eventch <- event:
+		case <-time.After(ed.eventConsumerTimeout):
+			logger.Warnf("Timed out sending CC event.")
+			ed.recordUndelivered(reg, event)
 		}
 	}
 }
 
-// RegisterHandler registers an event handler
+func newCCEvent(ccEvent *pb.ChaincodeEvent, blockNum uint64, txIndex, eventIndex int, sourceURL string) *fab.CCEvent {
+	event := NewChaincodeEvent(ccEvent.ChaincodeId, ccEvent.EventName, ccEvent.TxId)
+	event.BlockNumber = blockNum
+	event.TxIndex = txIndex
+	event.EventIndex = eventIndex
+	event.SourceURL = sourceURL
+	return event
+}
+
+// RegisterHandler registers an event handler. The handler is wrapped
+// by the interceptors that were provided in the options (if any).
 func (ed *Dispatcher) RegisterHandler(t interface{}, h Handler) {
 	htype := reflect.TypeOf(t)
 	if _, ok := ed.handlers[htype]; !ok {
 		logger.Debugf("Registering handler for %s on dispatcher %T", htype, ed)
-		ed.handlers[htype] = h
+		ed.handlers[htype] = ed.intercept(h)
 	} else {
 		logger.Debugf("Cannot register handler %s on dispatcher %T since it's already registered", htype, ed)
 	}
 }
 
-func getCCKey(ccID, eventFilter string) string {
-	return ccID + "/" + eventFilter
+// getCCKey returns the key of the given chaincode registration. The key is derived
+// from the compiled pattern so that registrations for the same filter with different
+// options (anchored, case-insensitive) are distinct.
+func getCCKey(reg *ChaincodeReg) string {
+	return reg.ChaincodeID + "/" + reg.pattern()
 }
 
-func toFilteredBlock(block *cb.Block) *pb.FilteredBlock {
+// toFilteredBlock converts the given block to a filtered block. Transactions that cannot be extracted
+// from the block are omitted, so the position within the block of each filtered transaction is also returned.
+// The channel ID is taken from the first transaction that can be extracted or, if there is none, the given
+// default channel ID is used.
+func toFilteredBlock(block *cb.Block, defaultChannelID string) (*pb.FilteredBlock, []int) {
 	var channelID string
 	var filteredTxs []*pb.FilteredTransaction
+	var txIndexes []int
 	txFilter := ledgerutil.TxValidationFlags(block.Metadata.Metadata[cb.BlockMetadataIndex_TRANSACTIONS_FILTER])
 
 	for i, data := range block.Data.Data {
@@ -496,15 +832,23 @@ func toFilteredBlock(block *cb.Block) *pb.FilteredBlock {
 			logger.Warnf("error extracting Envelope from block: %v", err)
 			continue
 		}
-		channelID = chID
+		if channelID == "" {
+			channelID = chID
+		}
 		filteredTxs = append(filteredTxs, filteredTx)
+		txIndexes = append(txIndexes, i)
+	}
+
+	if channelID == "" {
+		channelID = defaultChannelID
 	}
 
-	return &pb.FilteredBlock{
+	fblock := &pb.FilteredBlock{
 		ChannelId:  channelID,
 		Number:     block.Header.Number,
 		FilteredTx: filteredTxs,
 	}
+	return fblock, txIndexes
 }
 
 func getFilteredTx(data []byte, txValidationCode pb.TxValidationCode) (*pb.FilteredTransaction, string, error) {
@@ -7,6 +7,8 @@ SPDX-License-Identifier: Apache-2.0
 package dispatcher
 
 import (
+	"math"
+	"reflect"
 	"testing"
 	"time"
 
@@ -14,6 +16,7 @@ import (
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/service/blockfilter"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/service/blockfilter/headertypefilter"
 	servicemocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/events/service/mocks"
+	"github.com/hyperledger/fabric-sdk-go/pkg/options"
 	cb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
 	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
 )
@@ -231,6 +234,80 @@ func TestFilteredBlockEvents(t *testing.T) {
 	}
 }
 
+func TestConfiguredChannelID(t *testing.T) {
+	channelID := "testchannel"
+	dispatcher := New()
+	dispatcher.SetChannelID(channelID)
+	if err := dispatcher.Start(); err != nil {
+		t.Fatalf("Error starting dispatcher: %s", err)
+	}
+
+	dispatcherEventch, err := dispatcher.EventCh()
+	if err != nil {
+		t.Fatalf("Error getting event channel from dispatcher: %s", err)
+	}
+
+	regch := make(chan fab.Registration)
+	errch := make(chan error)
+	fbeventch := make(chan *fab.FilteredBlockEvent, 10)
+	dispatcherEventch <- NewRegisterFilteredBlockEvent(fbeventch, regch, errch)
+
+	select {
+	case <-regch:
+	case err := <-errch:
+		t.Fatalf("Error registering for filtered block events: %s", err)
+	}
+
+	producer := servicemocks.NewBlockProducer()
+
+	// Empty channel ID
+	emptyBlock := producer.NewFilteredBlock("", servicemocks.NewFilteredTx("txid1", pb.TxValidationCode_VALID))
+	dispatcherEventch <- emptyBlock
+	checkFilteredBlockChannelID(t, fbeventch, channelID)
+	if emptyBlock.ChannelId != "" {
+		t.Fatalf("Expecting the received filtered block not to be modified")
+	}
+
+	// Matching channel ID
+	dispatcherEventch <- producer.NewFilteredBlock(channelID, servicemocks.NewFilteredTx("txid2", pb.TxValidationCode_VALID))
+	checkFilteredBlockChannelID(t, fbeventch, channelID)
+
+	// Mismatched channel ID - the block is delivered as is
+	dispatcherEventch <- producer.NewFilteredBlock("otherchannel", servicemocks.NewFilteredTx("txid3", pb.TxValidationCode_VALID))
+	checkFilteredBlockChannelID(t, fbeventch, "otherchannel")
+
+	// A block whose channel ID is taken from the first envelope
+	dispatcherEventch <- producer.NewBlock("envchannel", servicemocks.NewTransaction("txid4", pb.TxValidationCode_VALID, cb.HeaderType_ENDORSER_TRANSACTION))
+	checkFilteredBlockChannelID(t, fbeventch, "envchannel")
+
+	// A block without any envelopes that can be parsed
+	block := producer.NewBlock("envchannel")
+	block.Data.Data = [][]byte{{0xff}}
+	block.Metadata.Metadata[cb.BlockMetadataIndex_TRANSACTIONS_FILTER] = []byte{byte(pb.TxValidationCode_VALID)}
+	dispatcherEventch <- block
+	checkFilteredBlockChannelID(t, fbeventch, channelID)
+
+	stopResp := make(chan error)
+	dispatcherEventch <- NewStopEvent(stopResp)
+	if err := <-stopResp; err != nil {
+		t.Fatalf("Error stopping dispatcher: %s", err)
+	}
+}
+
+func checkFilteredBlockChannelID(t *testing.T, fbeventch <-chan *fab.FilteredBlockEvent, expectedChannelID string) {
+	select {
+	case fbevent, ok := <-fbeventch:
+		if !ok {
+			t.Fatalf("unexpected closed channel")
+		}
+		if fbevent.FilteredBlock.ChannelId != expectedChannelID {
+			t.Fatalf("Expecting channel [%s] but got [%s]", expectedChannelID, fbevent.FilteredBlock.ChannelId)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for filtered block event")
+	}
+}
+
 func TestBlockAndFilteredBlockEvents(t *testing.T) {
 	channelID := "testchannel"
 	dispatcher := New()
@@ -536,6 +613,93 @@ func TestCCEvents(t *testing.T) {
 	}
 }
 
+func TestCCEventFilterOptions(t *testing.T) {
+	channelID := "testchannel"
+	dispatcher := New()
+	if err := dispatcher.Start(); err != nil {
+		t.Fatalf("Error starting dispatcher: %s", err)
+	}
+
+	dispatcherEventch, err := dispatcher.EventCh()
+	if err != nil {
+		t.Fatalf("Error getting event channel from dispatcher: %s", err)
+	}
+
+	ccID := "mycc1"
+	ccFilter := "pay"
+
+	errch := make(chan error)
+	regch := make(chan fab.Registration)
+
+	register := func(eventch chan<- *fab.CCEvent, opts ...options.Opt) fab.Registration {
+		dispatcherEventch <- NewRegisterChaincodeEvent(ccID, ccFilter, eventch, regch, errch, opts...)
+		select {
+		case reg := <-regch:
+			return reg
+		case err := <-errch:
+			t.Fatalf("error registering for chaincode events: %s", err)
+		}
+		return nil
+	}
+
+	unanchoredch := make(chan *fab.CCEvent, 10)
+	reg1 := register(unanchoredch)
+
+	// An anchored registration for the same filter is distinct from the unanchored one
+	anchoredch := make(chan *fab.CCEvent, 10)
+	reg2 := register(anchoredch, WithAnchoredFilter())
+
+	anchoredCIch := make(chan *fab.CCEvent, 10)
+	reg3 := register(anchoredCIch, WithAnchoredFilter(), WithCaseInsensitiveFilter())
+
+	// A second anchored registration for the same filter is a duplicate
+	dispatcherEventch <- NewRegisterChaincodeEvent(ccID, ccFilter, make(chan *fab.CCEvent, 10), regch, errch, WithAnchoredFilter())
+	select {
+	case <-regch:
+		t.Fatalf("expecting error registering multiple times for anchored chaincode events but got registration")
+	case <-errch:
+	}
+
+	dispatcherEventch <- servicemocks.NewBlockProducer().NewFilteredBlock(
+		channelID,
+		servicemocks.NewFilteredTxWithCCEvent("txid1", ccID, "pay"),
+		servicemocks.NewFilteredTxWithCCEvent("txid2", ccID, "payment_failed"),
+		servicemocks.NewFilteredTxWithCCEvent("txid3", ccID, "PAY"),
+	)
+
+	checkCCEventNames(t, unanchoredch, "pay", "payment_failed")
+	checkCCEventNames(t, anchoredch, "pay")
+	checkCCEventNames(t, anchoredCIch, "pay", "PAY")
+
+	dispatcherEventch <- NewUnregisterEvent(reg1)
+	dispatcherEventch <- NewUnregisterEvent(reg2)
+	dispatcherEventch <- NewUnregisterEvent(reg3)
+
+	stopResp := make(chan error)
+	dispatcherEventch <- NewStopEvent(stopResp)
+	if err := <-stopResp; err != nil {
+		t.Fatalf("Error stopping dispatcher: %s", err)
+	}
+}
+
+func checkCCEventNames(t *testing.T, eventch <-chan *fab.CCEvent, expectedEventNames ...string) {
+	var received []string
+	for {
+		select {
+		case event, ok := <-eventch:
+			if !ok {
+				t.Fatalf("unexpected closed channel")
+			}
+			received = append(received, event.EventName)
+		case <-time.After(500 * time.Millisecond):
+			if !reflect.DeepEqual(received, expectedEventNames) {
+				t.Fatalf("expecting CC events %v but received %v", expectedEventNames, received)
+			}
+			return
+		}
+	}
+}
+
 func checkTxStatusEvent(t *testing.T, event *fab.TxStatusEvent, expectedTxID string, expectedCode pb.TxValidationCode) {
 	if event.TxID != expectedTxID {
 		t.Fatalf("expecting event for TxID [%s] but received event for TxID [%s]", expectedTxID, event.TxID)
@@ -560,3 +724,309 @@ func checkCCEvent(t *testing.T, event *fab.CCEvent, expectedCCID string, expecte
 		t.Fatalf("expecting one of [%v] but received [%s]", expectedEventNames, event.EventName)
 	}
 }
+
+func TestCCEventOrdering(t *testing.T) {
+	channelID := "testchannel"
+	ccID := "mycc"
+	sourceURL := "grpcs://peer1.example.com:7051"
+
+	dispatcher := New()
+	dispatcher.SetSourceURL(sourceURL)
+	if err := dispatcher.Start(); err != nil {
+		t.Fatalf("Error starting dispatcher: %s", err)
+	}
+
+	dispatcherEventch, err := dispatcher.EventCh()
+	if err != nil {
+		t.Fatalf("Error getting event channel from dispatcher: %s", err)
+	}
+
+	errch := make(chan error)
+	regch := make(chan fab.Registration)
+	eventch := make(chan *fab.CCEvent, 10)
+	dispatcherEventch <- NewRegisterChaincodeEvent(ccID, "event.*", eventch, regch, errch)
+
+	select {
+	case <-regch:
+	case err := <-errch:
+		t.Fatalf("error registering for chaincode events: %s", err)
+	}
+
+	// A transaction with two chaincode events
+	multiEventTx := servicemocks.NewFilteredTxWithCCEvent("txid3", ccID, "event3")
+	multiEventTx.GetTransactionActions().ChaincodeActions = append(
+		multiEventTx.GetTransactionActions().ChaincodeActions,
+		&pb.FilteredChaincodeAction{CcEvent: &pb.ChaincodeEvent{ChaincodeId: ccID, EventName: "event4", TxId: "txid3"}},
+	)
+
+	producer := servicemocks.NewBlockProducer()
+	dispatcherEventch <- producer.NewFilteredBlock(
+		channelID,
+		servicemocks.NewFilteredTxWithCCEvent("txid1", ccID, "event1"),
+		servicemocks.NewFilteredTx("txid2", pb.TxValidationCode_MVCC_READ_CONFLICT),
+		multiEventTx,
+	)
+
+	// A block whose first transaction can't be extracted
+	block := producer.NewBlock(
+		channelID,
+		servicemocks.NewTransaction("txid5", pb.TxValidationCode_VALID, cb.HeaderType_ENDORSER_TRANSACTION),
+		servicemocks.NewTransactionWithCCEvent("txid6", pb.TxValidationCode_VALID, ccID, "event6"),
+	)
+	block.Data.Data = append([][]byte{{0xff}}, block.Data.Data...)
+	txFilter := block.Metadata.Metadata[cb.BlockMetadataIndex_TRANSACTIONS_FILTER]
+	block.Metadata.Metadata[cb.BlockMetadataIndex_TRANSACTIONS_FILTER] = append([]byte{byte(pb.TxValidationCode_VALID)}, txFilter...)
+	dispatcherEventch <- block
+
+	expected := []fab.CCEvent{
+		{TxID: "txid1", EventName: "event1", BlockNumber: 0, TxIndex: 0, EventIndex: 0},
+		{TxID: "txid3", EventName: "event3", BlockNumber: 0, TxIndex: 2, EventIndex: 0},
+		{TxID: "txid3", EventName: "event4", BlockNumber: 0, TxIndex: 2, EventIndex: 1},
+		{TxID: "txid6", EventName: "event6", BlockNumber: 1, TxIndex: 2, EventIndex: 0},
+	}
+
+	for _, exp := range expected {
+		select {
+		case event, ok := <-eventch:
+			if !ok {
+				t.Fatalf("unexpected closed channel")
+			}
+			if event.TxID != exp.TxID || event.EventName != exp.EventName {
+				t.Fatalf("expecting event [%s] for TxID [%s] but received event [%s] for TxID [%s]", exp.EventName, exp.TxID, event.EventName, event.TxID)
+			}
+			if event.BlockNumber != exp.BlockNumber || event.TxIndex != exp.TxIndex || event.EventIndex != exp.EventIndex {
+				t.Fatalf("expecting event [%s] at block %d, tx %d, event %d but received block %d, tx %d, event %d", exp.EventName, exp.BlockNumber, exp.TxIndex, exp.EventIndex, event.BlockNumber, event.TxIndex, event.EventIndex)
+			}
+			if event.SourceURL != sourceURL {
+				t.Fatalf("expecting source URL [%s] but received [%s]", sourceURL, event.SourceURL)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for CC event [%s]", exp.EventName)
+		}
+	}
+
+	stopResp := make(chan error)
+	dispatcherEventch <- NewStopEvent(stopResp)
+	if err := <-stopResp; err != nil {
+		t.Fatalf("Error stopping dispatcher: %s", err)
+	}
+}
+
+func TestReset(t *testing.T) {
+	channelID := "testchannel"
+	dispatcher := New()
+	if err := dispatcher.Start(); err != nil {
+		t.Fatalf("Error starting dispatcher: %s", err)
+	}
+
+	dispatcherEventch, err := dispatcher.EventCh()
+	if err != nil {
+		t.Fatalf("Error getting event channel from dispatcher: %s", err)
+	}
+
+	eventch := make(chan *fab.FilteredBlockEvent, 10)
+	regch := make(chan fab.Registration)
+	errch := make(chan error)
+
+	dispatcherEventch <- NewRegisterFilteredBlockEvent(eventch, regch, errch)
+
+	select {
+	case <-regch:
+	case err := <-errch:
+		t.Fatalf("Error registering for filtered block events: %s", err)
+	}
+
+	oldChainProducer := servicemocks.NewBlockProducer()
+	for i := 0; i < 5; i++ {
+		dispatcherEventch <- oldChainProducer.NewFilteredBlock(channelID)
+	}
+	checkFilteredBlockEvents(t, eventch, 5)
+
+	if dispatcher.LastBlockNum() != 4 {
+		t.Fatalf("expecting last block number 4 but got %d", dispatcher.LastBlockNum())
+	}
+
+	// The channel is re-created. Blocks from the new chain are rejected until we reset.
+	newChainProducer := servicemocks.NewBlockProducer()
+	dispatcherEventch <- newChainProducer.NewFilteredBlock(channelID)
+	checkFilteredBlockEvents(t, eventch, 0)
+
+	resetch := make(chan error)
+	dispatcherEventch <- NewResetEvent(resetch)
+	if err := <-resetch; err != nil {
+		t.Fatalf("Error resetting dispatcher: %s", err)
+	}
+
+	if dispatcher.LastBlockNum() != math.MaxUint64 {
+		t.Fatalf("expecting last block number to be reset but got %d", dispatcher.LastBlockNum())
+	}
+
+	dispatcherEventch <- newChainProducer.NewFilteredBlock(channelID)
+	checkFilteredBlockEvents(t, eventch, 1)
+
+	if dispatcher.LastBlockNum() != 1 {
+		t.Fatalf("expecting last block number 1 but got %d", dispatcher.LastBlockNum())
+	}
+
+	stopResp := make(chan error)
+	dispatcherEventch <- NewStopEvent(stopResp)
+	if err := <-stopResp; err != nil {
+		t.Fatalf("Error stopping dispatcher: %s", err)
+	}
+}
+
+func TestCheckLedgerHeight(t *testing.T) {
+	channelID := "testchannel"
+	dispatcher := New(WithBlockHeightResetMargin(2))
+	if err := dispatcher.Start(); err != nil {
+		t.Fatalf("Error starting dispatcher: %s", err)
+	}
+
+	dispatcherEventch, err := dispatcher.EventCh()
+	if err != nil {
+		t.Fatalf("Error getting event channel from dispatcher: %s", err)
+	}
+
+	eventch := make(chan *fab.FilteredBlockEvent, 10)
+	regch := make(chan fab.Registration)
+	errch := make(chan error)
+
+	dispatcherEventch <- NewRegisterFilteredBlockEvent(eventch, regch, errch)
+
+	select {
+	case <-regch:
+	case err := <-errch:
+		t.Fatalf("Error registering for filtered block events: %s", err)
+	}
+
+	// No blocks received yet
+	dispatcher.CheckLedgerHeight(0)
+
+	oldChainProducer := servicemocks.NewBlockProducer()
+	for i := 0; i < 5; i++ {
+		dispatcherEventch <- oldChainProducer.NewFilteredBlock(channelID)
+	}
+	checkFilteredBlockEvents(t, eventch, 5)
+
+	// A block lower than the last block (when no ledger info is available) is rejected
+	fblock := servicemocks.NewFilteredBlock(channelID)
+	fblock.Number = 0
+	dispatcherEventch <- fblock
+	checkFilteredBlockEvents(t, eventch, 0)
+
+	// The last block on the peer (2) is within the margin so the last block number is kept
+	dispatcher.CheckLedgerHeight(3)
+	if dispatcher.LastBlockNum() != 4 {
+		t.Fatalf("expecting last block number 4 but got %d", dispatcher.LastBlockNum())
+	}
+
+	// The last block on the peer (0) is lower than the last block number by more than the margin
+	dispatcher.CheckLedgerHeight(1)
+	if dispatcher.LastBlockNum() != math.MaxUint64 {
+		t.Fatalf("expecting last block number to be reset but got %d", dispatcher.LastBlockNum())
+	}
+
+	newChainProducer := servicemocks.NewBlockProducer()
+	dispatcherEventch <- newChainProducer.NewFilteredBlock(channelID)
+	checkFilteredBlockEvents(t, eventch, 1)
+
+	// No reset without a margin
+	noMarginDispatcher := New()
+	noMarginDispatcher.updateLastBlockNum(10)
+	noMarginDispatcher.CheckLedgerHeight(1)
+	if noMarginDispatcher.LastBlockNum() != 10 {
+		t.Fatalf("expecting last block number 10 but got %d", noMarginDispatcher.LastBlockNum())
+	}
+
+	stopResp := make(chan error)
+	dispatcherEventch <- NewStopEvent(stopResp)
+	if err := <-stopResp; err != nil {
+		t.Fatalf("Error stopping dispatcher: %s", err)
+	}
+}
+
+func checkFilteredBlockEvents(t *testing.T, eventch <-chan *fab.FilteredBlockEvent, expected int) {
+	numReceived := 0
+	for {
+		select {
+		case _, ok := <-eventch:
+			if !ok {
+				t.Fatalf("unexpected closed channel")
+			}
+			numReceived++
+		case <-time.After(500 * time.Millisecond):
+			if numReceived != expected {
+				t.Fatalf("expecting %d filtered block events but got %d", expected, numReceived)
+			}
+			return
+		}
+	}
+}
+
+type blockingEvent struct {
+	blockch chan struct{}
+}
+
+func TestForceStop(t *testing.T) {
+	dispatcher := New()
+	dispatcher.RegisterHandler(&blockingEvent{}, func(e Event) {
+		<-e.(*blockingEvent).blockch
+	})
+	if err := dispatcher.Start(); err != nil {
+		t.Fatalf("Error starting dispatcher: %s", err)
+	}
+
+	dispatcherEventch, err := dispatcher.EventCh()
+	if err != nil {
+		t.Fatalf("Error getting event channel from dispatcher: %s", err)
+	}
+
+	regch := make(chan fab.Registration)
+	errch := make(chan error)
+	eventch := make(chan *fab.BlockEvent, 10)
+	dispatcherEventch <- NewRegisterBlockEvent(func(block *cb.Block) bool { return true }, eventch, regch, errch)
+	getRegistration(t, regch, errch)
+
+	// Wedge the dispatcher
+	blockch := make(chan struct{})
+	dispatcherEventch <- &blockingEvent{blockch: blockch}
+
+	dispatcher.ForceStop()
+
+	if _, err := dispatcher.EventCh(); err == nil {
+		t.Fatalf("expecting error getting event channel from a stopped dispatcher")
+	}
+
+	// The registrations are cleared once the blocked handler returns
+	close(blockch)
+	select {
+	case _, ok := <-eventch:
+		if ok {
+			t.Fatalf("expecting block event channel to be closed")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for block event channel to be closed")
+	}
+
+	// Force stopping an idle dispatcher should also work
+	dispatcher = New()
+	if err := dispatcher.Start(); err != nil {
+		t.Fatalf("Error starting dispatcher: %s", err)
+	}
+	dispatcherEventch, err = dispatcher.EventCh()
+	if err != nil {
+		t.Fatalf("Error getting event channel from dispatcher: %s", err)
+	}
+	idleEventch := make(chan *fab.BlockEvent, 10)
+	dispatcherEventch <- NewRegisterBlockEvent(func(block *cb.Block) bool { return true }, idleEventch, regch, errch)
+	getRegistration(t, regch, errch)
+	dispatcher.ForceStop()
+	select {
+	case _, ok := <-idleEventch:
+		if ok {
+			t.Fatalf("expecting block event channel to be closed")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for block event channel to be closed")
+	}
+}
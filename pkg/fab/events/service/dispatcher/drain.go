@@ -0,0 +1,89 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package dispatcher
+
+import (
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+	"github.com/pkg/errors"
+)
+
+// RejectRegistrationsEvent tells the dispatcher to reject all subsequent registration requests
+// (for block, filtered block, chaincode and transaction status events). Events continue to be
+// dispatched to existing registrations, for example, while the event client drains its outstanding
+// transaction status registrations before closing.
+type RejectRegistrationsEvent struct {
+	ErrCh chan<- error
+}
+
+// NewRejectRegistrationsEvent creates a new RejectRegistrationsEvent
+func NewRejectRegistrationsEvent(errch chan<- error) *RejectRegistrationsEvent {
+	return &RejectRegistrationsEvent{
+		ErrCh: errch,
+	}
+}
+
+// RegistrationCountsEvent requests the number of registrations of each type
+type RegistrationCountsEvent struct {
+	// RespCh should be buffered so that the dispatcher doesn't block if the caller stops waiting
+	RespCh chan<- *RegistrationCounts
+}
+
+// NewRegistrationCountsEvent creates a new RegistrationCountsEvent
+func NewRegistrationCountsEvent(respch chan<- *RegistrationCounts) *RegistrationCountsEvent {
+	return &RegistrationCountsEvent{
+		RespCh: respch,
+	}
+}
+
+// RegistrationCounts contains the number of registrations of each type
+type RegistrationCounts struct {
+	Block         int
+	FilteredBlock int
+	Chaincode     int
+	TxStatus      int
+
+	// PendingTxIDs contains the IDs of the transactions whose registrations
+	// haven't yet been sent a transaction status event
+	PendingTxIDs []string
+}
+
+func (ed *Dispatcher) handleRejectRegistrationsEvent(e Event) {
+	event := e.(*RejectRegistrationsEvent)
+
+	logger.Debugf("Rejecting subsequent registrations")
+	ed.rejectRegistrations = true
+
+	event.ErrCh <- nil
+}
+
+func (ed *Dispatcher) handleRegistrationCountsEvent(e Event) {
+	event := e.(*RegistrationCountsEvent)
+
+	counts := &RegistrationCounts{
+		Block:         len(ed.blockRegistrations),
+		FilteredBlock: len(ed.filteredBlockRegistrations),
+		Chaincode:     len(ed.ccRegistrations),
+		TxStatus:      len(ed.txRegistrations),
+	}
+	for txID, reg := range ed.txRegistrations {
+		if !reg.fired {
+			counts.PendingTxIDs = append(counts.PendingTxIDs, txID)
+		}
+	}
+
+	event.RespCh <- counts
+}
+
+// acceptRegistration returns false (and sends an error to the requester) if registrations are being
+// rejected (see RejectRegistrationsEvent) or if the registration isn't authorized.
+func (ed *Dispatcher) acceptRegistration(event *RegisterEvent, reg fab.Registration) bool {
+	if ed.rejectRegistrations {
+		event.ErrCh <- errors.New("registrations are not being accepted")
+		return false
+	}
+	return ed.authorize(event, reg)
+}
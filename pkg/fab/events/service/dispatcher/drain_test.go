@@ -0,0 +1,99 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package dispatcher
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+	servicemocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/events/service/mocks"
+	cb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+)
+
+func TestRejectRegistrationsAndCounts(t *testing.T) {
+	channelID := "testchannel"
+	dispatcher := New()
+	if err := dispatcher.Start(); err != nil {
+		t.Fatalf("Error starting dispatcher: %s", err)
+	}
+
+	dispatcherEventch, err := dispatcher.EventCh()
+	if err != nil {
+		t.Fatalf("Error getting event channel from dispatcher: %s", err)
+	}
+
+	regch := make(chan fab.Registration)
+	errch := make(chan error)
+
+	dispatcherEventch <- NewRegisterBlockEvent(func(block *cb.Block) bool { return true }, make(chan *fab.BlockEvent, 10), regch, errch)
+	getRegistration(t, regch, errch)
+
+	txeventch := make(chan *fab.TxStatusEvent, 10)
+	dispatcherEventch <- NewRegisterTxStatusEvent("txid1", txeventch, regch, errch)
+	getRegistration(t, regch, errch)
+	dispatcherEventch <- NewRegisterTxStatusEvent("txid2", make(chan *fab.TxStatusEvent, 10), regch, errch)
+	getRegistration(t, regch, errch)
+
+	counts := registrationCounts(t, dispatcherEventch)
+	if counts.Block != 1 || counts.FilteredBlock != 0 || counts.Chaincode != 0 || counts.TxStatus != 2 || len(counts.PendingTxIDs) != 2 {
+		t.Fatalf("unexpected registration counts: %+v", counts)
+	}
+
+	rejecterrch := make(chan error, 1)
+	dispatcherEventch <- NewRejectRegistrationsEvent(rejecterrch)
+	if err := <-rejecterrch; err != nil {
+		t.Fatalf("Error rejecting registrations: %s", err)
+	}
+
+	dispatcherEventch <- NewRegisterFilteredBlockEvent(make(chan *fab.FilteredBlockEvent, 10), regch, errch)
+	select {
+	case <-regch:
+		t.Fatalf("expecting registration to be rejected")
+	case err := <-errch:
+		if err == nil {
+			t.Fatalf("expecting error from rejected registration")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for registration response")
+	}
+
+	// Events are still dispatched to existing registrations
+	dispatcherEventch <- servicemocks.NewBlockProducer().NewBlock(channelID,
+		servicemocks.NewTransaction("txid1", pb.TxValidationCode_VALID, cb.HeaderType_ENDORSER_TRANSACTION),
+	)
+	select {
+	case event := <-txeventch:
+		checkTxStatusEvent(t, event, "txid1", pb.TxValidationCode_VALID)
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for TxStatus event")
+	}
+
+	counts = registrationCounts(t, dispatcherEventch)
+	if counts.TxStatus != 2 || len(counts.PendingTxIDs) != 1 || counts.PendingTxIDs[0] != "txid2" {
+		t.Fatalf("unexpected registration counts: %+v", counts)
+	}
+
+	stopResp := make(chan error)
+	dispatcherEventch <- NewStopEvent(stopResp)
+	if err := <-stopResp; err != nil {
+		t.Fatalf("Error stopping dispatcher: %s", err)
+	}
+}
+
+func registrationCounts(t *testing.T, dispatcherEventch chan<- interface{}) *RegistrationCounts {
+	respch := make(chan *RegistrationCounts, 1)
+	dispatcherEventch <- NewRegistrationCountsEvent(respch)
+	select {
+	case counts := <-respch:
+		return counts
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for registration counts")
+	}
+	return nil
+}
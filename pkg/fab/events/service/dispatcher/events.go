@@ -8,6 +8,7 @@ package dispatcher
 
 import (
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/options"
 	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
 )
 
@@ -19,11 +20,26 @@ type Event interface{}
 type RegisterEvent struct {
 	RegCh chan<- fab.Registration
 	ErrCh chan<- error
+	// Scope identifies the requester (e.g. a tenant) and is passed to the
+	// registration authorizer (see WithRegistrationAuthorizer)
+	Scope string
+	// Replay indicates that the cached events from ReplayFromBlock onwards are to be delivered
+	// to the registrant when it registers (see WithEventCache and WithReplayFromCache)
+	Replay          bool
+	ReplayFromBlock uint64
 }
 
 // StopEvent tells the dispatcher to stop processing
 type StopEvent struct {
 	ErrCh chan<- error
+	// Reason is sent to the close notification channels of the registrations (see WithCloseNotification)
+	Reason CloseReason
+}
+
+// ResetEvent tells the dispatcher to reset the last block number, for example,
+// when the channel has been re-created
+type ResetEvent struct {
+	ErrCh chan<- error
 }
 
 // RegisterBlockEvent registers for block events
@@ -79,15 +95,24 @@ func NewUnregisterEvent(reg fab.Registration) *UnregisterEvent {
 }
 
 // NewRegisterChaincodeEvent creates a new RegisterChaincodeEvent
-func NewRegisterChaincodeEvent(ccID, eventFilter string, eventch chan<- *fab.CCEvent, respch chan<- fab.Registration, errCh chan<- error) *RegisterChaincodeEvent {
-	return &RegisterChaincodeEvent{
+func NewRegisterChaincodeEvent(ccID, eventFilter string, eventch chan<- *fab.CCEvent, respch chan<- fab.Registration, errCh chan<- error, opts ...options.Opt) *RegisterChaincodeEvent {
+	params := &ccRegParams{}
+	options.Apply(params, opts)
+
+	event := &RegisterChaincodeEvent{
 		Reg: &ChaincodeReg{
-			ChaincodeID: ccID,
-			EventFilter: eventFilter,
-			Eventch:     eventch,
+			ChaincodeID:     ccID,
+			EventFilter:     eventFilter,
+			Eventch:         eventch,
+			Anchored:        params.anchored,
+			CaseInsensitive: params.caseInsensitive,
+			CloseCh:         params.closeCh,
 		},
 		RegisterEvent: NewRegisterEvent(respch, errCh),
 	}
+	event.Replay = params.replay
+	event.ReplayFromBlock = params.replayFromBlock
+	return event
 }
 
 // NewRegisterTxStatusEvent creates a new RegisterTxStatusEvent
@@ -126,6 +151,14 @@ func NewTxStatusEvent(txID string, txValidationCode pb.TxValidationCode) *fab.Tx
 // NewStopEvent creates a new StopEvent
 func NewStopEvent(errch chan<- error) *StopEvent {
 	return &StopEvent{
+		ErrCh:  errch,
+		Reason: ServiceStopped,
+	}
+}
+
+// NewResetEvent creates a new ResetEvent
+func NewResetEvent(errch chan<- error) *ResetEvent {
+	return &ResetEvent{
 		ErrCh: errch,
 	}
 }
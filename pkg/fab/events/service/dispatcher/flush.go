@@ -0,0 +1,66 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package dispatcher
+
+import (
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+)
+
+// maxUndeliveredDetails is the maximum number of undelivered events that are described in a FlushReport.
+// Undelivered events beyond this number are only counted.
+const maxUndeliveredDetails = 100
+
+// FlushEvent is a barrier. Since events are processed in the order in which they are received,
+// all of the events that were submitted before the FlushEvent have been processed by the time
+// the FlushEvent is handled, i.e. the resulting events have been offered to each registration
+// according to the event consumer timeout (see WithEventConsumerTimeout). The dispatcher responds
+// with a report of the events that couldn't be delivered since the previous flush.
+type FlushEvent struct {
+	// RespCh should be buffered so that the dispatcher doesn't block if the caller stops waiting
+	RespCh chan<- *FlushReport
+}
+
+// NewFlushEvent creates a new FlushEvent
+func NewFlushEvent(respch chan<- *FlushReport) *FlushEvent {
+	return &FlushEvent{
+		RespCh: respch,
+	}
+}
+
+// FlushReport describes the events that couldn't be delivered to registrations since the previous flush,
+// either because the registration's event channel was full or because the event consumer timed out.
+type FlushReport struct {
+	// Undelivered is the number of events that couldn't be delivered
+	Undelivered uint64
+
+	// Details describes the undelivered events (up to a maximum of 100)
+	Details []UndeliveredEvent
+}
+
+// UndeliveredEvent is an event that couldn't be delivered to a registration
+type UndeliveredEvent struct {
+	Registration fab.Registration
+	Event        interface{}
+}
+
+func (ed *Dispatcher) handleFlushEvent(e Event) {
+	event := e.(*FlushEvent)
+
+	report := ed.undelivered
+	ed.undelivered = FlushReport{}
+
+	event.RespCh <- &report
+}
+
+// recordUndelivered records an event that couldn't be delivered to the given registration.
+// This function must be invoked from a dispatcher handler.
+func (ed *Dispatcher) recordUndelivered(reg fab.Registration, event interface{}) {
+	ed.undelivered.Undelivered++
+	if len(ed.undelivered.Details) < maxUndeliveredDetails {
+		ed.undelivered.Details = append(ed.undelivered.Details, UndeliveredEvent{Registration: reg, Event: event})
+	}
+}
@@ -0,0 +1,167 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package dispatcher
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/checkpointer"
+	servicemocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/events/service/mocks"
+	cb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+)
+
+func TestFlush(t *testing.T) {
+	channelID := "testchannel"
+	dispatcher := New(WithEventConsumerTimeout(-1))
+	if err := dispatcher.Start(); err != nil {
+		t.Fatalf("Error starting dispatcher: %s", err)
+	}
+
+	dispatcherEventch, err := dispatcher.EventCh()
+	if err != nil {
+		t.Fatalf("Error getting event channel from dispatcher: %s", err)
+	}
+
+	regch := make(chan fab.Registration)
+	errch := make(chan error)
+
+	beventch := make(chan *fab.BlockEvent, 2)
+	dispatcherEventch <- NewRegisterBlockEvent(func(block *cb.Block) bool { return true }, beventch, regch, errch)
+	breg := getRegistration(t, regch, errch)
+
+	eventProducer := servicemocks.NewBlockProducer()
+	for i := 0; i < 5; i++ {
+		dispatcherEventch <- eventProducer.NewBlock(channelID,
+			servicemocks.NewTransaction("txid", pb.TxValidationCode_VALID, cb.HeaderType_ENDORSER_TRANSACTION),
+		)
+	}
+
+	report := flush(t, dispatcherEventch)
+
+	// All of the blocks have been processed so there's no need to wait for the events
+	if len(beventch) != 2 {
+		t.Fatalf("expecting 2 block events to be delivered but got %d", len(beventch))
+	}
+	if report.Undelivered != 3 || len(report.Details) != 3 {
+		t.Fatalf("expecting 3 undelivered events but got %d (%d details)", report.Undelivered, len(report.Details))
+	}
+	for i, d := range report.Details {
+		if d.Registration != breg {
+			t.Fatalf("expecting undelivered event for the block registration")
+		}
+		if num := d.Event.(*fab.BlockEvent).Block.Header.Number; num != uint64(i+2) {
+			t.Fatalf("expecting undelivered block #%d but got #%d", i+2, num)
+		}
+	}
+
+	// A subsequent flush only reports events that were undelivered since the previous flush
+	if report := flush(t, dispatcherEventch); report.Undelivered != 0 || len(report.Details) != 0 {
+		t.Fatalf("expecting no undelivered events but got %d", report.Undelivered)
+	}
+
+	stopResp := make(chan error)
+	dispatcherEventch <- NewStopEvent(stopResp)
+	if err := <-stopResp; err != nil {
+		t.Fatalf("Error stopping dispatcher: %s", err)
+	}
+}
+
+func TestCheckpointUndelivered(t *testing.T) {
+	channelID := "testchannel"
+	cp := checkpointer.NewMemory()
+	dispatcher := New(WithEventConsumerTimeout(-1), WithCheckpointer("consumer1", cp))
+	if err := dispatcher.Start(); err != nil {
+		t.Fatalf("Error starting dispatcher: %s", err)
+	}
+
+	dispatcherEventch, err := dispatcher.EventCh()
+	if err != nil {
+		t.Fatalf("Error getting event channel from dispatcher: %s", err)
+	}
+
+	regch := make(chan fab.Registration)
+	errch := make(chan error)
+
+	// The event channel only has room for the events of the first two blocks
+	beventch := make(chan *fab.BlockEvent, 2)
+	dispatcherEventch <- NewRegisterBlockEvent(func(block *cb.Block) bool { return true }, beventch, regch, errch)
+	getRegistration(t, regch, errch)
+
+	eventProducer := servicemocks.NewBlockProducer()
+	sendBlocks := func(n int) {
+		for i := 0; i < n; i++ {
+			dispatcherEventch <- eventProducer.NewBlock(channelID)
+		}
+		flush(t, dispatcherEventch)
+	}
+
+	sendBlocks(2)
+	checkSavedCheckpoint(t, cp, "consumer1", 1, true)
+
+	// The event for block 2 is dropped so the checkpoint isn't advanced, even after the channel is drained
+	sendBlocks(1)
+	checkSavedCheckpoint(t, cp, "consumer1", 1, true)
+	for len(beventch) > 0 {
+		<-beventch
+	}
+	sendBlocks(1)
+	checkSavedCheckpoint(t, cp, "consumer1", 1, true)
+
+	// The checkpoint is cleared on reset and saved again for the following blocks
+	resetch := make(chan error, 1)
+	dispatcherEventch <- NewResetEvent(resetch)
+	if err := <-resetch; err != nil {
+		t.Fatalf("Error resetting dispatcher: %s", err)
+	}
+	checkSavedCheckpoint(t, cp, "consumer1", 0, false)
+	sendBlocks(1)
+	checkSavedCheckpoint(t, cp, "consumer1", 4, true)
+}
+
+func checkSavedCheckpoint(t *testing.T, cp checkpointer.Checkpointer, name string, expectedBlockNum uint64, expectedOK bool) {
+	blockNum, ok, err := cp.Load(name)
+	if err != nil {
+		t.Fatalf("error loading checkpoint: %s", err)
+	}
+	if ok != expectedOK || blockNum != expectedBlockNum {
+		t.Fatalf("expecting checkpoint %d (found: %t) but got %d (found: %t)", expectedBlockNum, expectedOK, blockNum, ok)
+	}
+}
+
+func TestFlushMaxDetails(t *testing.T) {
+	dispatcher := New()
+	for i := 0; i < maxUndeliveredDetails+10; i++ {
+		dispatcher.recordUndelivered("reg", i)
+	}
+
+	respch := make(chan *FlushReport, 1)
+	dispatcher.handleFlushEvent(NewFlushEvent(respch))
+	report := <-respch
+
+	if report.Undelivered != maxUndeliveredDetails+10 {
+		t.Fatalf("expecting %d undelivered events but got %d", maxUndeliveredDetails+10, report.Undelivered)
+	}
+	if len(report.Details) != maxUndeliveredDetails {
+		t.Fatalf("expecting %d details but got %d", maxUndeliveredDetails, len(report.Details))
+	}
+}
+
+func flush(t *testing.T, eventch chan<- interface{}) *FlushReport {
+	respch := make(chan *FlushReport, 1)
+	eventch <- NewFlushEvent(respch)
+
+	select {
+	case report := <-respch:
+		return report
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for flush")
+	}
+	return nil
+}
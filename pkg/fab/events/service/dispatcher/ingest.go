@@ -0,0 +1,183 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package dispatcher
+
+import (
+	"sync"
+	"time"
+)
+
+// IngestStats contains statistics about the dispatcher's ingest buffer (i.e. the event channel)
+// as seen from the producer side
+type IngestStats struct {
+	// Submitted is the number of events that were recorded by RecordEnqueue
+	Submitted uint64
+	// Blocked is the number of recorded events whose enqueue blocked because the buffer was full
+	Blocked uint64
+	// BlockedTime is the total time that producers were blocked on enqueue
+	BlockedTime time.Duration
+	// MaxBlockedTime is the longest time that a producer was blocked on enqueue
+	MaxBlockedTime time.Duration
+	// Saturation is the percentage (0-100) of recent samples in which the buffer was full
+	Saturation float64
+	// BufferLen is the number of events in the buffer at the time of the last sample
+	BufferLen int
+	// BufferCap is the capacity of the buffer
+	BufferCap int
+}
+
+// SaturationAlert is invoked when the saturation of the ingest buffer has exceeded
+// the configured threshold for longer than the configured duration
+type SaturationAlert func(stats IngestStats, since time.Duration)
+
+// ingestMonitor periodically samples the ingest buffer and keeps a rolling window of
+// samples from which the saturation is computed
+type ingestMonitor struct {
+	mutex          sync.RWMutex
+	stats          IngestStats
+	samples        []bool
+	next           int
+	numSamples     int
+	saturatedSince time.Time
+	alerted        bool
+	stopch         chan struct{}
+	stopOnce       sync.Once
+}
+
+func newIngestMonitor(windowSize uint) *ingestMonitor {
+	if windowSize == 0 {
+		windowSize = 1
+	}
+	return &ingestMonitor{
+		samples: make([]bool, windowSize),
+		stopch:  make(chan struct{}),
+	}
+}
+
+// RecordEnqueue records the enqueue of an event by a producer. The producer should indicate
+// whether the enqueue blocked (because the buffer was full) and for how long.
+func (ed *Dispatcher) RecordEnqueue(blocked bool, duration time.Duration) {
+	m := ed.ingest
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.stats.Submitted++
+	if !blocked {
+		return
+	}
+	m.stats.Blocked++
+	m.stats.BlockedTime += duration
+	if duration > m.stats.MaxBlockedTime {
+		m.stats.MaxBlockedTime = duration
+	}
+}
+
+// Enqueue sends the event to the given event channel (which should be the dispatcher's event
+// channel) and records whether or not the send blocked. Producers that don't go through the
+// event service should use this function so that their events are included in the statistics.
+func (ed *Dispatcher) Enqueue(eventch chan<- interface{}, event interface{}) {
+	select {
+	case eventch <- event:
+		ed.RecordEnqueue(false, 0)
+		return
+	default:
+	}
+
+	start := time.Now()
+	eventch <- event
+	ed.RecordEnqueue(true, time.Since(start))
+}
+
+// IngestStats returns the statistics of the dispatcher's ingest buffer
+func (ed *Dispatcher) IngestStats() IngestStats {
+	m := ed.ingest
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.stats
+}
+
+// startIngestMonitor starts sampling the ingest buffer at the configured interval
+func (ed *Dispatcher) startIngestMonitor() {
+	if ed.ingestSampleInterval <= 0 {
+		logger.Debugf("Ingest buffer sampling is disabled")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(ed.ingestSampleInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				ed.sampleIngest(time.Now())
+			case <-ed.ingest.stopch:
+				logger.Debugf("Stopping ingest buffer sampling")
+				return
+			}
+		}
+	}()
+}
+
+// stopIngestMonitor stops sampling the ingest buffer
+func (ed *Dispatcher) stopIngestMonitor() {
+	ed.ingest.stopOnce.Do(func() {
+		close(ed.ingest.stopch)
+	})
+}
+
+// sampleIngest records whether or not the ingest buffer is currently full, updates the
+// rolling saturation and fires the saturation alert if necessary
+func (ed *Dispatcher) sampleIngest(now time.Time) {
+	bufferLen := len(ed.eventch)
+	bufferCap := cap(ed.eventch)
+
+	m := ed.ingest
+	m.mutex.Lock()
+
+	m.samples[m.next] = bufferCap > 0 && bufferLen >= bufferCap
+	m.next = (m.next + 1) % len(m.samples)
+	if m.numSamples < len(m.samples) {
+		m.numSamples++
+	}
+
+	saturated := 0
+	for i := 0; i < m.numSamples; i++ {
+		if m.samples[i] {
+			saturated++
+		}
+	}
+
+	m.stats.Saturation = 100 * float64(saturated) / float64(m.numSamples)
+	m.stats.BufferLen = bufferLen
+	m.stats.BufferCap = bufferCap
+
+	stats := m.stats
+	var fire bool
+	var since time.Duration
+
+	if ed.saturationAlert != nil && stats.Saturation > ed.saturationThreshold {
+		if m.saturatedSince.IsZero() {
+			m.saturatedSince = now
+		}
+		since = now.Sub(m.saturatedSince)
+		if !m.alerted && since >= ed.saturationDuration {
+			m.alerted = true
+			fire = true
+		}
+	} else {
+		m.saturatedSince = time.Time{}
+		m.alerted = false
+	}
+
+	m.mutex.Unlock()
+
+	if fire {
+		logger.Warnf("Dispatcher ingest buffer saturation has been above %.1f%% for %s - current saturation: %.1f%%", ed.saturationThreshold, since, stats.Saturation)
+		ed.saturationAlert(stats, since)
+	}
+}
@@ -0,0 +1,114 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package dispatcher
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIngestSaturation(t *testing.T) {
+	saturationDuration := time.Second
+
+	var alerts []time.Duration
+	dispatcher := New(
+		WithEventConsumerBufferSize(2),
+		WithIngestSampling(0, 2),
+		WithSaturationAlert(50, saturationDuration, func(stats IngestStats, since time.Duration) {
+			alerts = append(alerts, since)
+		}),
+	)
+
+	now := time.Now()
+	dispatcher.sampleIngest(now)
+	checkSaturation(t, dispatcher, 0)
+
+	// Fill the buffer
+	dispatcher.eventch <- &StopEvent{}
+	dispatcher.eventch <- &StopEvent{}
+
+	dispatcher.sampleIngest(now)
+	checkSaturation(t, dispatcher, 50)
+
+	now = now.Add(100 * time.Millisecond)
+	dispatcher.sampleIngest(now)
+	checkSaturation(t, dispatcher, 100)
+	if len(alerts) != 0 {
+		t.Fatalf("expecting no alerts before the saturation duration has elapsed")
+	}
+
+	now = now.Add(saturationDuration)
+	dispatcher.sampleIngest(now)
+	if len(alerts) != 1 {
+		t.Fatalf("expecting 1 alert but got %d", len(alerts))
+	}
+	if alerts[0] != saturationDuration {
+		t.Fatalf("expecting alert after %s but got %s", saturationDuration, alerts[0])
+	}
+
+	// The alert should not fire again while the buffer remains saturated
+	now = now.Add(saturationDuration)
+	dispatcher.sampleIngest(now)
+	if len(alerts) != 1 {
+		t.Fatalf("expecting 1 alert but got %d", len(alerts))
+	}
+
+	// Drain the buffer. The alert is re-armed once the saturation drops below the threshold.
+	<-dispatcher.eventch
+	<-dispatcher.eventch
+
+	now = now.Add(100 * time.Millisecond)
+	dispatcher.sampleIngest(now)
+	checkSaturation(t, dispatcher, 50)
+
+	dispatcher.eventch <- &StopEvent{}
+	dispatcher.eventch <- &StopEvent{}
+
+	now = now.Add(100 * time.Millisecond)
+	dispatcher.sampleIngest(now)
+	now = now.Add(100 * time.Millisecond)
+	dispatcher.sampleIngest(now)
+	checkSaturation(t, dispatcher, 100)
+	now = now.Add(saturationDuration)
+	dispatcher.sampleIngest(now)
+	if len(alerts) != 2 {
+		t.Fatalf("expecting 2 alerts but got %d", len(alerts))
+	}
+
+	stats := dispatcher.IngestStats()
+	if stats.BufferLen != 2 || stats.BufferCap != 2 {
+		t.Fatalf("expecting buffer length 2 and capacity 2 but got %d and %d", stats.BufferLen, stats.BufferCap)
+	}
+}
+
+func TestRecordEnqueue(t *testing.T) {
+	dispatcher := New()
+
+	dispatcher.RecordEnqueue(false, 0)
+	dispatcher.RecordEnqueue(true, 2*time.Millisecond)
+	dispatcher.RecordEnqueue(true, 5*time.Millisecond)
+
+	stats := dispatcher.IngestStats()
+	if stats.Submitted != 3 {
+		t.Fatalf("expecting 3 submitted events but got %d", stats.Submitted)
+	}
+	if stats.Blocked != 2 {
+		t.Fatalf("expecting 2 blocked events but got %d", stats.Blocked)
+	}
+	if stats.BlockedTime != 7*time.Millisecond {
+		t.Fatalf("expecting blocked time of 7ms but got %s", stats.BlockedTime)
+	}
+	if stats.MaxBlockedTime != 5*time.Millisecond {
+		t.Fatalf("expecting max blocked time of 5ms but got %s", stats.MaxBlockedTime)
+	}
+}
+
+func checkSaturation(t *testing.T, dispatcher *Dispatcher, expected float64) {
+	if saturation := dispatcher.IngestStats().Saturation; saturation != expected {
+		t.Fatalf("expecting saturation %.1f%% but got %.1f%%", expected, saturation)
+	}
+}
@@ -0,0 +1,52 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package dispatcher
+
+import (
+	"reflect"
+	"time"
+)
+
+// Interceptor wraps an event handler. An interceptor is invoked within the dispatcher's Go routine
+// and may either invoke the next handler in the chain or drop the event by not invoking it.
+type Interceptor func(next Handler) Handler
+
+// LatencyCallback is invoked by the latency interceptor with the type of the event that
+// was handled and the time it took to handle it.
+type LatencyCallback func(eventType reflect.Type, duration time.Duration)
+
+// NewLoggingInterceptor returns an interceptor that logs each event that's handled by the dispatcher.
+func NewLoggingInterceptor() Interceptor {
+	return func(next Handler) Handler {
+		return func(e Event) {
+			logger.Debugf("Handling event: %v", reflect.TypeOf(e))
+			next(e)
+			logger.Debugf("... done handling event: %v", reflect.TypeOf(e))
+		}
+	}
+}
+
+// NewLatencyInterceptor returns an interceptor that measures the time it takes
+// to handle each event and reports it to the given callback.
+func NewLatencyInterceptor(callback LatencyCallback) Interceptor {
+	return func(next Handler) Handler {
+		return func(e Event) {
+			start := time.Now()
+			next(e)
+			callback(reflect.TypeOf(e), time.Since(start))
+		}
+	}
+}
+
+// intercept wraps the given handler with the configured interceptors. The first
+// interceptor that was added is the outermost, i.e. it is invoked first.
+func (ed *Dispatcher) intercept(h Handler) Handler {
+	for i := len(ed.interceptors) - 1; i >= 0; i-- {
+		h = ed.interceptors[i](h)
+	}
+	return h
+}
@@ -0,0 +1,148 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package dispatcher
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+	servicemocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/events/service/mocks"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+)
+
+func TestInterceptorOrder(t *testing.T) {
+	var mutex sync.Mutex
+	var calls []string
+
+	newInterceptor := func(name string) Interceptor {
+		return func(next Handler) Handler {
+			return func(e Event) {
+				if _, ok := e.(*pb.FilteredBlock); ok {
+					mutex.Lock()
+					calls = append(calls, name)
+					mutex.Unlock()
+				}
+				next(e)
+			}
+		}
+	}
+
+	dispatcher := New(
+		WithInterceptor(newInterceptor("first")),
+		WithInterceptor(newInterceptor("second")),
+		WithInterceptor(NewLoggingInterceptor()),
+	)
+	if err := dispatcher.Start(); err != nil {
+		t.Fatalf("Error starting dispatcher: %s", err)
+	}
+
+	dispatcherEventch, err := dispatcher.EventCh()
+	if err != nil {
+		t.Fatalf("Error getting event channel from dispatcher: %s", err)
+	}
+
+	eventch := make(chan *fab.FilteredBlockEvent, 10)
+	regch := make(chan fab.Registration)
+	errch := make(chan error)
+
+	dispatcherEventch <- NewRegisterFilteredBlockEvent(eventch, regch, errch)
+
+	select {
+	case <-regch:
+	case err := <-errch:
+		t.Fatalf("Error registering for filtered block events: %s", err)
+	}
+
+	dispatcherEventch <- servicemocks.NewBlockProducer().NewFilteredBlock("testchannel")
+	checkFilteredBlockEvents(t, eventch, 1)
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if !reflect.DeepEqual(calls, []string{"first", "second"}) {
+		t.Fatalf("unexpected interceptor invocation order: %v", calls)
+	}
+
+	stopResp := make(chan error)
+	dispatcherEventch <- NewStopEvent(stopResp)
+	if err := <-stopResp; err != nil {
+		t.Fatalf("Error stopping dispatcher: %s", err)
+	}
+}
+
+func TestInterceptorShortCircuit(t *testing.T) {
+	var mutex sync.Mutex
+	var latencies []reflect.Type
+
+	// Drops all filtered blocks with an even block number
+	dropEven := func(next Handler) Handler {
+		return func(e Event) {
+			if fblock, ok := e.(*pb.FilteredBlock); ok && fblock.Number%2 == 0 {
+				return
+			}
+			next(e)
+		}
+	}
+
+	dispatcher := New(
+		WithInterceptor(NewLatencyInterceptor(func(eventType reflect.Type, duration time.Duration) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			latencies = append(latencies, eventType)
+		})),
+		WithInterceptor(dropEven),
+	)
+	if err := dispatcher.Start(); err != nil {
+		t.Fatalf("Error starting dispatcher: %s", err)
+	}
+
+	dispatcherEventch, err := dispatcher.EventCh()
+	if err != nil {
+		t.Fatalf("Error getting event channel from dispatcher: %s", err)
+	}
+
+	eventch := make(chan *fab.FilteredBlockEvent, 10)
+	regch := make(chan fab.Registration)
+	errch := make(chan error)
+
+	dispatcherEventch <- NewRegisterFilteredBlockEvent(eventch, regch, errch)
+
+	select {
+	case <-regch:
+	case err := <-errch:
+		t.Fatalf("Error registering for filtered block events: %s", err)
+	}
+
+	producer := servicemocks.NewBlockProducer()
+	for i := 0; i < 4; i++ {
+		dispatcherEventch <- producer.NewFilteredBlock("testchannel")
+	}
+	checkFilteredBlockEvents(t, eventch, 2)
+
+	mutex.Lock()
+	numLatencies := 0
+	for _, eventType := range latencies {
+		if eventType == reflect.TypeOf(&pb.FilteredBlock{}) {
+			numLatencies++
+		}
+	}
+	mutex.Unlock()
+
+	// The latency interceptor is outermost so it should see all of the blocks, including the dropped ones
+	if numLatencies != 4 {
+		t.Fatalf("expecting latency to be measured for 4 filtered blocks but got %d", numLatencies)
+	}
+
+	stopResp := make(chan error)
+	dispatcherEventch <- NewStopEvent(stopResp)
+	if err := <-stopResp; err != nil {
+		t.Fatalf("Error stopping dispatcher: %s", err)
+	}
+}
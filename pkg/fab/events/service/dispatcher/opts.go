@@ -9,18 +9,31 @@ package dispatcher
 import (
 	"time"
 
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/checkpointer"
 	"github.com/hyperledger/fabric-sdk-go/pkg/options"
 )
 
 type params struct {
 	eventConsumerBufferSize uint
 	eventConsumerTimeout    time.Duration
+	blockHeightResetMargin  uint64
+	interceptors            []Interceptor
+	ingestSampleInterval    time.Duration
+	ingestWindowSize        uint
+	saturationThreshold     float64
+	saturationDuration      time.Duration
+	saturationAlert         SaturationAlert
+	authorizer              RegistrationAuthorizer
+	eventCacheSize          int
+	checkpointName          string
+	checkpointer            checkpointer.Checkpointer
 }
 
 func defaultParams() *params {
 	return &params{
 		eventConsumerBufferSize: 100,
 		eventConsumerTimeout:    500 * time.Millisecond,
+		ingestWindowSize:        20,
 	}
 }
 
@@ -45,6 +58,85 @@ func WithEventConsumerTimeout(value time.Duration) options.Opt {
 	}
 }
 
+// WithBlockHeightResetMargin sets the margin by which the ledger height reported by the peer when a connection
+// is established may be lower than the last block number before the dispatcher assumes that the channel was
+// re-created and resets the last block number. If 0 (default) then the last block number is never reset automatically.
+func WithBlockHeightResetMargin(value uint64) options.Opt {
+	return func(p options.Params) {
+		if setter, ok := p.(blockHeightResetMarginSetter); ok {
+			setter.SetBlockHeightResetMargin(value)
+		}
+	}
+}
+
+// WithInterceptor adds an interceptor that wraps each of the dispatcher's event handlers.
+// Interceptors are applied in the order in which they are provided, i.e. the first
+// interceptor is the first to be invoked when an event is dispatched.
+func WithInterceptor(value Interceptor) options.Opt {
+	return func(p options.Params) {
+		if setter, ok := p.(interceptorSetter); ok {
+			setter.AddInterceptor(value)
+		}
+	}
+}
+
+// WithIngestSampling sets the interval at which the dispatcher's ingest buffer is sampled and the
+// number of samples over which the rolling saturation is computed. Sampling is disabled by default
+// (or if the interval is 0), in which case the saturation isn't computed and the saturation alert isn't fired.
+func WithIngestSampling(interval time.Duration, windowSize uint) options.Opt {
+	return func(p options.Params) {
+		if setter, ok := p.(ingestSamplingSetter); ok {
+			setter.SetIngestSampling(interval, windowSize)
+		}
+	}
+}
+
+// WithSaturationAlert sets a callback that is invoked when the saturation (percentage 0-100) of the
+// dispatcher's ingest buffer has been above the given threshold for longer than the given duration.
+// The alert is fired once and is re-armed when the saturation drops below the threshold.
+func WithSaturationAlert(threshold float64, duration time.Duration, alert SaturationAlert) options.Opt {
+	return func(p options.Params) {
+		if setter, ok := p.(saturationAlertSetter); ok {
+			setter.SetSaturationAlert(threshold, duration, alert)
+		}
+	}
+}
+
+// WithRegistrationAuthorizer sets the authorizer that's consulted before block, filtered block,
+// chaincode and transaction status registrations are honored. If the authorizer returns an error
+// then the registration is rejected with that error.
+func WithRegistrationAuthorizer(value RegistrationAuthorizer) options.Opt {
+	return func(p options.Params) {
+		if setter, ok := p.(registrationAuthorizerSetter); ok {
+			setter.SetRegistrationAuthorizer(value)
+		}
+	}
+}
+
+// WithEventCache enables a cache of the given number of most recently dispatched filtered blocks. The events
+// in the cache may be replayed to a registrant that registers late (see WithReplayFromCache). When the cache is
+// full the oldest block is evicted. Full blocks aren't cached, so block registrations can't be replayed.
+// If 0 (default) then the cache is disabled.
+func WithEventCache(size int) options.Opt {
+	return func(p options.Params) {
+		if setter, ok := p.(eventCacheSetter); ok {
+			setter.SetEventCache(size)
+		}
+	}
+}
+
+// WithCheckpointer records the number of each block in the given checkpointer, under the given consumer name,
+// once the events for the block have been delivered to all registrations. The deliver client also resumes
+// from the block after the saved checkpoint when it connects (see the checkpointer package for the delivery
+// guarantees). An error saving the checkpoint is logged and doesn't affect the dispatching of events.
+func WithCheckpointer(name string, cp checkpointer.Checkpointer) options.Opt {
+	return func(p options.Params) {
+		if setter, ok := p.(checkpointerSetter); ok {
+			setter.SetCheckpointer(name, cp)
+		}
+	}
+}
+
 type eventConsumerBufferSizeSetter interface {
 	SetEventConsumerBufferSize(value uint)
 }
@@ -53,6 +145,34 @@ type eventEventConsumerTimeoutSetter interface {
 	SetEventConsumerTimeout(value time.Duration)
 }
 
+type blockHeightResetMarginSetter interface {
+	SetBlockHeightResetMargin(value uint64)
+}
+
+type interceptorSetter interface {
+	AddInterceptor(value Interceptor)
+}
+
+type ingestSamplingSetter interface {
+	SetIngestSampling(interval time.Duration, windowSize uint)
+}
+
+type saturationAlertSetter interface {
+	SetSaturationAlert(threshold float64, duration time.Duration, alert SaturationAlert)
+}
+
+type registrationAuthorizerSetter interface {
+	SetRegistrationAuthorizer(value RegistrationAuthorizer)
+}
+
+type eventCacheSetter interface {
+	SetEventCache(size int)
+}
+
+type checkpointerSetter interface {
+	SetCheckpointer(name string, cp checkpointer.Checkpointer)
+}
+
 func (p *params) SetEventConsumerBufferSize(value uint) {
 	logger.Debugf("EventConsumerBufferSize: %d", value)
 	p.eventConsumerBufferSize = value
@@ -62,3 +182,42 @@ func (p *params) SetEventConsumerTimeout(value time.Duration) {
 	logger.Debugf("EventConsumerTimeout: %s", value)
 	p.eventConsumerTimeout = value
 }
+
+func (p *params) SetBlockHeightResetMargin(value uint64) {
+	logger.Debugf("BlockHeightResetMargin: %d", value)
+	p.blockHeightResetMargin = value
+}
+
+func (p *params) AddInterceptor(value Interceptor) {
+	logger.Debugf("Interceptor: %#v", value)
+	p.interceptors = append(p.interceptors, value)
+}
+
+func (p *params) SetIngestSampling(interval time.Duration, windowSize uint) {
+	logger.Debugf("IngestSampling: interval: %s, window size: %d", interval, windowSize)
+	p.ingestSampleInterval = interval
+	p.ingestWindowSize = windowSize
+}
+
+func (p *params) SetSaturationAlert(threshold float64, duration time.Duration, alert SaturationAlert) {
+	logger.Debugf("SaturationAlert: threshold: %.1f%%, duration: %s", threshold, duration)
+	p.saturationThreshold = threshold
+	p.saturationDuration = duration
+	p.saturationAlert = alert
+}
+
+func (p *params) SetRegistrationAuthorizer(value RegistrationAuthorizer) {
+	logger.Debugf("RegistrationAuthorizer: %#v", value)
+	p.authorizer = value
+}
+
+func (p *params) SetEventCache(size int) {
+	logger.Debugf("EventCache: %d", size)
+	p.eventCacheSize = size
+}
+
+func (p *params) SetCheckpointer(name string, cp checkpointer.Checkpointer) {
+	logger.Debugf("Checkpointer: name: %s, checkpointer: %#v", name, cp)
+	p.checkpointName = name
+	p.checkpointer = cp
+}
@@ -0,0 +1,155 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package dispatcher
+
+import (
+	"reflect"
+	"runtime/debug"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+	"github.com/pkg/errors"
+
+	cb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+)
+
+// panicReplyTimeout is the maximum time to wait for the caller to receive
+// the error that's sent after a request-style handler panicked
+const panicReplyTimeout = 5 * time.Second
+
+// HandlerPanicEvent is published to the handler panic registrations when
+// a handler or a user-provided block filter panics
+type HandlerPanicEvent struct {
+	// EventType is the type of the event that was being handled when the panic occurred
+	EventType reflect.Type
+	// Registration is the registration whose filter panicked (nil if the panic occurred in a handler)
+	Registration fab.Registration
+	// Value is the value that was passed to panic
+	Value interface{}
+	// Stack is the stack trace of the Go routine at the time of the panic
+	Stack []byte
+}
+
+// HandlerPanicReg contains the data for a handler panic registration
+type HandlerPanicReg struct {
+	Eventch chan<- *HandlerPanicEvent
+}
+
+// RegisterHandlerPanicEvent registers for handler panic events
+type RegisterHandlerPanicEvent struct {
+	RegisterEvent
+	Reg *HandlerPanicReg
+}
+
+// NewRegisterHandlerPanicEvent creates a new RegisterHandlerPanicEvent
+func NewRegisterHandlerPanicEvent(eventch chan<- *HandlerPanicEvent, respch chan<- fab.Registration, errCh chan<- error) *RegisterHandlerPanicEvent {
+	return &RegisterHandlerPanicEvent{
+		Reg:           &HandlerPanicReg{Eventch: eventch},
+		RegisterEvent: NewRegisterEvent(respch, errCh),
+	}
+}
+
+func (ed *Dispatcher) handleRegisterHandlerPanicEvent(e Event) {
+	event := e.(*RegisterHandlerPanicEvent)
+	ed.panicRegistrations = append(ed.panicRegistrations, event.Reg)
+	event.RegCh <- event.Reg
+}
+
+func (ed *Dispatcher) unregisterHandlerPanicEvents(registration *HandlerPanicReg) error {
+	for i, reg := range ed.panicRegistrations {
+		if reg == registration {
+			// Move the 0'th item to i and then delete the 0'th item
+			ed.panicRegistrations[i] = ed.panicRegistrations[0]
+			ed.panicRegistrations = ed.panicRegistrations[1:]
+			close(reg.Eventch)
+			return nil
+		}
+	}
+	return errors.New("the provided registration is invalid")
+}
+
+// clearHandlerPanicRegistrations removes all handler panic registrations and closes the corresponding event channels.
+func (ed *Dispatcher) clearHandlerPanicRegistrations() {
+	for _, reg := range ed.panicRegistrations {
+		close(reg.Eventch)
+	}
+	ed.panicRegistrations = nil
+}
+
+// invoke invokes the given handler and recovers from a panic so
+// that the dispatcher Go routine stays alive. If the event expects
+// a response (i.e. it has an error channel) then an error is sent
+// so that the caller isn't blocked waiting for the response.
+func (ed *Dispatcher) invoke(handler Handler, e Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			stack := debug.Stack()
+			logger.Errorf("Recovered from panic in handler for event %v: %v\n%s", reflect.TypeOf(e), r, stack)
+			ed.publishHandlerPanicEvent(&HandlerPanicEvent{EventType: reflect.TypeOf(e), Value: r, Stack: stack})
+			replyError(e, errors.Errorf("panic in handler for event %v: %v", reflect.TypeOf(e), r))
+		}
+	}()
+	handler(e)
+}
+
+// replyError sends the given error to the event's error channel, if it has one.
+// The handler may have already responded before it panicked, in which case
+// nobody is receiving, so the error is sent from a separate Go routine that
+// gives up after a timeout instead of blocking the dispatcher.
+func replyError(e Event, err error) {
+	v := reflect.ValueOf(e)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return
+	}
+	field := v.FieldByName("ErrCh")
+	if !field.IsValid() || !field.CanInterface() {
+		return
+	}
+	errch, ok := field.Interface().(chan<- error)
+	if !ok || errch == nil {
+		return
+	}
+
+	go func() {
+		select {
+		case errch <- err:
+		case <-time.After(panicReplyTimeout):
+			logger.Debugf("Timed out sending handler panic error for event %v", reflect.TypeOf(e))
+		}
+	}()
+}
+
+// filterBlock invokes the block filter of the given registration. If the filter
+// panics then false is returned, i.e. the registration is skipped for the block.
+func (ed *Dispatcher) filterBlock(reg *BlockReg, block *cb.Block) (accept bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			stack := debug.Stack()
+			logger.Errorf("Recovered from panic in block filter of registration %p for block #%d: %v\n%s", reg, block.Header.Number, r, stack)
+			ed.publishHandlerPanicEvent(&HandlerPanicEvent{EventType: reflect.TypeOf(block), Registration: reg, Value: r, Stack: stack})
+			accept = false
+		}
+	}()
+	return reg.Filter(block)
+}
+
+// publishHandlerPanicEvent sends the given event to all handler panic registrations.
+// The event is dropped for a registration if its channel is full so that a slow
+// monitor doesn't block the dispatcher.
+func (ed *Dispatcher) publishHandlerPanicEvent(event *HandlerPanicEvent) {
+	for _, reg := range ed.panicRegistrations {
+		select {
+		case reg.Eventch <- event:
+		default:
+			logger.Warnf("Unable to send to handler panic event channel.")
+			ed.recordUndelivered(reg, event)
+		}
+	}
+}
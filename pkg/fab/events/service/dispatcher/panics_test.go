@@ -0,0 +1,214 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package dispatcher
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+	servicemocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/events/service/mocks"
+	cb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+)
+
+func TestBlockFilterPanic(t *testing.T) {
+	channelID := "testchannel"
+	dispatcher := New()
+	if err := dispatcher.Start(); err != nil {
+		t.Fatalf("Error starting dispatcher: %s", err)
+	}
+
+	dispatcherEventch, err := dispatcher.EventCh()
+	if err != nil {
+		t.Fatalf("Error getting event channel from dispatcher: %s", err)
+	}
+
+	regch := make(chan fab.Registration)
+	errch := make(chan error)
+
+	panicch := make(chan *HandlerPanicEvent, 10)
+	dispatcherEventch <- NewRegisterHandlerPanicEvent(panicch, regch, errch)
+	preg := getRegistration(t, regch, errch)
+
+	panicFilter := func(block *cb.Block) bool {
+		if block.Header.Number == 2 {
+			panic("filter panic")
+		}
+		return true
+	}
+
+	panicEventch := make(chan *fab.BlockEvent, 10)
+	dispatcherEventch <- NewRegisterBlockEvent(panicFilter, panicEventch, regch, errch)
+	panicReg := getRegistration(t, regch, errch)
+
+	beventch := make(chan *fab.BlockEvent, 10)
+	dispatcherEventch <- NewRegisterBlockEvent(func(block *cb.Block) bool { return true }, beventch, regch, errch)
+	breg := getRegistration(t, regch, errch)
+
+	eventProducer := servicemocks.NewBlockProducer()
+	for i := 0; i < 5; i++ {
+		dispatcherEventch <- eventProducer.NewBlock(channelID,
+			servicemocks.NewTransaction("txid", pb.TxValidationCode_VALID, cb.HeaderType_ENDORSER_TRANSACTION),
+		)
+	}
+
+	checkBlockNumbers(t, beventch, 0, 1, 2, 3, 4)
+	checkBlockNumbers(t, panicEventch, 0, 1, 3, 4)
+
+	select {
+	case event := <-panicch:
+		if event.Registration != panicReg {
+			t.Fatalf("expecting handler panic event for the registration whose filter panicked")
+		}
+		if event.Value != "filter panic" {
+			t.Fatalf("expecting panic value [filter panic] but got [%v]", event.Value)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for handler panic event")
+	}
+
+	dispatcherEventch <- NewUnregisterEvent(breg)
+	dispatcherEventch <- NewUnregisterEvent(panicReg)
+	dispatcherEventch <- NewUnregisterEvent(preg)
+
+	stopResp := make(chan error)
+	dispatcherEventch <- NewStopEvent(stopResp)
+	if err := <-stopResp; err != nil {
+		t.Fatalf("Error stopping dispatcher: %s", err)
+	}
+}
+
+type panicEvent struct{}
+
+func TestHandlerPanic(t *testing.T) {
+	dispatcher := New()
+	dispatcher.RegisterHandler(&panicEvent{}, func(e Event) {
+		panic("handler panic")
+	})
+	if err := dispatcher.Start(); err != nil {
+		t.Fatalf("Error starting dispatcher: %s", err)
+	}
+
+	dispatcherEventch, err := dispatcher.EventCh()
+	if err != nil {
+		t.Fatalf("Error getting event channel from dispatcher: %s", err)
+	}
+
+	regch := make(chan fab.Registration)
+	errch := make(chan error)
+
+	panicch := make(chan *HandlerPanicEvent, 10)
+	dispatcherEventch <- NewRegisterHandlerPanicEvent(panicch, regch, errch)
+	getRegistration(t, regch, errch)
+
+	dispatcherEventch <- &panicEvent{}
+
+	select {
+	case event := <-panicch:
+		if event.Registration != nil {
+			t.Fatalf("expecting nil registration for handler panic")
+		}
+		if len(event.Stack) == 0 {
+			t.Fatalf("expecting stack trace in handler panic event")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for handler panic event")
+	}
+
+	// The dispatcher should still be alive
+	stopResp := make(chan error)
+	dispatcherEventch <- NewStopEvent(stopResp)
+	select {
+	case err := <-stopResp:
+		if err != nil {
+			t.Fatalf("Error stopping dispatcher: %s", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out stopping dispatcher")
+	}
+
+	if _, ok := <-panicch; ok {
+		t.Fatalf("expecting handler panic event channel to be closed")
+	}
+}
+
+func getRegistration(t *testing.T, regch <-chan fab.Registration, errch <-chan error) fab.Registration {
+	select {
+	case reg := <-regch:
+		return reg
+	case err := <-errch:
+		t.Fatalf("Error registering: %s", err)
+	}
+	return nil
+}
+
+func checkBlockNumbers(t *testing.T, eventch <-chan *fab.BlockEvent, expected ...uint64) {
+	var received []uint64
+	for {
+		select {
+		case event, ok := <-eventch:
+			if !ok {
+				t.Fatalf("unexpected closed channel")
+			}
+			received = append(received, event.Block.Header.Number)
+		case <-time.After(500 * time.Millisecond):
+			if len(received) != len(expected) {
+				t.Fatalf("expecting blocks %v but received %v", expected, received)
+			}
+			for i, num := range expected {
+				if received[i] != num {
+					t.Fatalf("expecting blocks %v but received %v", expected, received)
+				}
+			}
+			return
+		}
+	}
+}
+
+type panicRequestEvent struct {
+	ErrCh chan<- error
+}
+
+func TestRequestHandlerPanic(t *testing.T) {
+	dispatcher := New()
+	dispatcher.RegisterHandler(&panicRequestEvent{}, func(e Event) {
+		panic("request handler panic")
+	})
+	dispatcher.RegisterHandler(&panicEvent{}, func(e Event) {
+		panic("handler panic")
+	})
+	if err := dispatcher.Start(); err != nil {
+		t.Fatalf("Error starting dispatcher: %s", err)
+	}
+
+	dispatcherEventch, err := dispatcher.EventCh()
+	if err != nil {
+		t.Fatalf("Error getting event channel from dispatcher: %s", err)
+	}
+
+	// An event without an error channel is simply dropped
+	dispatcherEventch <- &panicEvent{}
+
+	errch := make(chan error)
+	dispatcherEventch <- &panicRequestEvent{ErrCh: errch}
+
+	select {
+	case err := <-errch:
+		if err == nil {
+			t.Fatalf("expecting error from request handler that panicked")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for response from request handler that panicked")
+	}
+
+	stopResp := make(chan error)
+	dispatcherEventch <- NewStopEvent(stopResp)
+	if err := <-stopResp; err != nil {
+		t.Fatalf("Error stopping dispatcher: %s", err)
+	}
+}
@@ -10,17 +10,22 @@ import (
 	"regexp"
 
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
 )
 
 // BlockReg contains the data for a block registration
 type BlockReg struct {
 	Filter  fab.BlockFilter
 	Eventch chan<- *fab.BlockEvent
+	// CloseCh (optional) receives the reason that Eventch is closed (see WithCloseNotification)
+	CloseCh chan<- *RegistrationClosedEvent
 }
 
 // FilteredBlockReg contains the data for a filtered block registration
 type FilteredBlockReg struct {
 	Eventch chan<- *fab.FilteredBlockEvent
+	// CloseCh (optional) receives the reason that Eventch is closed (see WithCloseNotification)
+	CloseCh chan<- *RegistrationClosedEvent
 }
 
 // ChaincodeReg contains the data for a chaincode registration
@@ -29,10 +34,38 @@ type ChaincodeReg struct {
 	EventFilter string
 	EventRegExp *regexp.Regexp
 	Eventch     chan<- *fab.CCEvent
+	// Anchored indicates that the filter must match the entire event name
+	Anchored bool
+	// CaseInsensitive indicates that the filter is matched without regard to case
+	CaseInsensitive bool
+	// CloseCh (optional) receives the reason that Eventch is closed (see WithCloseNotification)
+	CloseCh chan<- *RegistrationClosedEvent
+}
+
+// pattern returns the regular expression that is compiled from the event filter
+// and the registration options
+func (r *ChaincodeReg) pattern() string {
+	pattern := r.EventFilter
+	if r.Anchored {
+		pattern = "^(?:" + pattern + ")$"
+	}
+	if r.CaseInsensitive {
+		pattern = "(?i)" + pattern
+	}
+	return pattern
+}
+
+// matches returns true if the given chaincode event matches the registration
+func (r *ChaincodeReg) matches(ccEvent *pb.ChaincodeEvent) bool {
+	return r.ChaincodeID == ccEvent.ChaincodeId && r.EventRegExp.MatchString(ccEvent.EventName)
 }
 
 // TxStatusReg contains the data for a transaction status registration
 type TxStatusReg struct {
 	TxID    string
 	Eventch chan<- *fab.TxStatusEvent
+	// CloseCh (optional) receives the reason that Eventch is closed (see WithCloseNotification)
+	CloseCh chan<- *RegistrationClosedEvent
+	// fired is set when the transaction status event has been sent to the registrant
+	fired bool
 }
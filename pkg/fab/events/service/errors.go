@@ -0,0 +1,62 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/service/dispatcher"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+)
+
+// ServiceNotStartedError is returned when an event is submitted to
+// an event service whose dispatcher hasn't been started
+type ServiceNotStartedError struct{}
+
+func (e *ServiceNotStartedError) Error() string {
+	return "event service not started"
+}
+
+// ServiceStoppedError is returned when an event is submitted to
+// an event service whose dispatcher has been stopped
+type ServiceStoppedError struct{}
+
+func (e *ServiceStoppedError) Error() string {
+	return "event service stopped"
+}
+
+// TimeoutError is returned when the dispatcher doesn't respond to a
+// request within the response timeout (see WithResponseTimeout)
+type TimeoutError struct {
+	// Operation is the request that timed out
+	Operation string
+	// Timeout is the response timeout
+	Timeout time.Duration
+	// State is the state of the dispatcher at the time of the timeout
+	State dispatcher.State
+	// QueueDepth is the number of events that were waiting to be processed by the
+	// dispatcher at the time of the timeout (or -1 if unknown)
+	QueueDepth int
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("timed out after %s waiting for %s - dispatcher state [%s], queue depth [%d]", e.Timeout, e.Operation, e.State, e.QueueDepth)
+}
+
+// TxValidationError is returned by WaitForTxStatus when the transaction
+// was committed with a validation code other than VALID
+type TxValidationError struct {
+	// TxID is the ID of the transaction
+	TxID string
+	// Code is the validation code of the transaction
+	Code pb.TxValidationCode
+}
+
+func (e *TxValidationError) Error() string {
+	return fmt.Sprintf("transaction [%s] failed validation with code [%s]", e.TxID, e.Code)
+}
@@ -6,17 +6,104 @@ SPDX-License-Identifier: Apache-2.0
 
 package service
 
+import (
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/options"
+)
+
 type params struct {
 	eventConsumerBufferSize uint
+	respTimeout             time.Duration
+	scope                   string
 }
 
 func defaultParams() *params {
 	return &params{
 		eventConsumerBufferSize: 100,
+		respTimeout:             5 * time.Second,
+	}
+}
+
+// WithResponseTimeout sets the maximum time to wait for the dispatcher to respond to a
+// registration request. If the timeout is reached then a TimeoutError is returned.
+// If zero then there is no timeout.
+func WithResponseTimeout(value time.Duration) options.Opt {
+	return func(p options.Params) {
+		if setter, ok := p.(responseTimeoutSetter); ok {
+			setter.SetResponseTimeout(value)
+		}
+	}
+}
+
+// WithScope sets the scope (e.g. a tenant) that identifies the requester of the service's registrations.
+// The scope is passed to the dispatcher's registration authorizer (see dispatcher.WithRegistrationAuthorizer).
+func WithScope(value string) options.Opt {
+	return func(p options.Params) {
+		if setter, ok := p.(scopeSetter); ok {
+			setter.SetScope(value)
+		}
 	}
 }
 
+type scopeSetter interface {
+	SetScope(value string)
+}
+
+type responseTimeoutSetter interface {
+	SetResponseTimeout(value time.Duration)
+}
+
 func (p *params) SetEventConsumerBufferSize(value uint) {
 	logger.Debugf("EventConsumerBufferSize: %d", value)
 	p.eventConsumerBufferSize = value
 }
+
+func (p *params) SetResponseTimeout(value time.Duration) {
+	logger.Debugf("ResponseTimeout: %s", value)
+	p.respTimeout = value
+}
+
+func (p *params) SetScope(value string) {
+	logger.Debugf("Scope: %s", value)
+	p.scope = value
+}
+
+// batchParams contains the options for SubmitBatch
+type batchParams struct {
+	timeout time.Duration
+}
+
+// WithBatchTimeout sets the maximum time that SubmitBatch waits for all of the events
+// in the batch to be enqueued. If not set (or zero) then SubmitBatch blocks until all
+// events have been enqueued.
+func WithBatchTimeout(value time.Duration) options.Opt {
+	return func(p options.Params) {
+		if setter, ok := p.(batchTimeoutSetter); ok {
+			setter.SetBatchTimeout(value)
+		}
+	}
+}
+
+type batchTimeoutSetter interface {
+	SetBatchTimeout(value time.Duration)
+}
+
+func (p *batchParams) SetBatchTimeout(value time.Duration) {
+	logger.Debugf("BatchTimeout: %s", value)
+	p.timeout = value
+}
+
+// BatchTimeoutError is returned from SubmitBatch when the events in
+// the batch could not all be enqueued within the batch timeout
+type BatchTimeoutError struct {
+	// Submitted is the number of events that were enqueued
+	Submitted int
+	// Total is the number of events in the batch
+	Total int
+}
+
+func (e *BatchTimeoutError) Error() string {
+	return fmt.Sprintf("timed out submitting batch - only %d of %d events were submitted", e.Submitted, e.Total)
+}
@@ -7,6 +7,8 @@ SPDX-License-Identifier: Apache-2.0
 package service
 
 import (
+	"context"
+	"fmt"
 	"runtime/debug"
 	"sync"
 	"time"
@@ -16,11 +18,12 @@ import (
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/service/dispatcher"
 	logging "github.com/hyperledger/fabric-sdk-go/pkg/logging"
 	"github.com/hyperledger/fabric-sdk-go/pkg/options"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
 	"github.com/pkg/errors"
 )
 
-const (
-	// stopTimeout is the time that we wait for the dispatcher to stop.
+var (
+	// stopTimeout is the time that we wait for the dispatcher to stop (or reset).
 	// It's hard-coded here since (at this point) it doesn't make sense to
 	// expose it as an option.
 	stopTimeout = 5 * time.Second
@@ -50,8 +53,10 @@ type Dispatcher interface {
 // Service allows clients to register for channel events, such as filtered block, chaincode, and transaction status events.
 type Service struct {
 	params
-	dispatcher   Dispatcher
-	registerOnce sync.Once
+	dispatcher    Dispatcher
+	registerOnce  sync.Once
+	callbackMutex sync.Mutex
+	callbacks     map[fab.Registration]chan struct{}
 }
 
 // New returns a new event service initialized with the given Dispatcher
@@ -72,13 +77,19 @@ func (s *Service) Start() error {
 
 // Stop stops the event service
 func (s *Service) Stop() {
+	if err := s.checkState(); err != nil {
+		logger.Warnf("Error stopping event service: %s", err)
+		return
+	}
+
 	eventch, err := s.dispatcher.EventCh()
 	if err != nil {
 		logger.Warnf("Error stopping event service: %s", err)
 		return
 	}
 
-	regch := make(chan error)
+	// The channel is buffered so that the dispatcher doesn't block if we time out
+	regch := make(chan error, 1)
 	eventch <- dispatcher.NewStopEvent(regch)
 
 	select {
@@ -91,7 +102,78 @@ func (s *Service) Stop() {
 	}
 }
 
-// Submit submits an event for processing
+// Reset resets the dispatcher's last block number so that blocks are accepted from the beginning
+// of the chain. This function should be invoked when the channel is known to have been re-created.
+func (s *Service) Reset() error {
+	// The channel is buffered so that the dispatcher doesn't block if we time out
+	errch := make(chan error, 1)
+	if err := s.Submit(dispatcher.NewResetEvent(errch)); err != nil {
+		return errors.WithMessage(err, "error submitting reset request")
+	}
+
+	select {
+	case err := <-errch:
+		return err
+	case <-time.After(stopTimeout):
+		return errors.New("timed out waiting for dispatcher to reset")
+	}
+}
+
+// Flush waits until all of the events that were submitted before the call have been processed, i.e.
+// each registration has been offered the resulting events according to the event consumer timeout
+// (see WithEventConsumerTimeout). Flush may be called concurrently with Submit, although it must not be
+// called from an event consumer that's blocking the dispatcher. A report of the events that couldn't be
+// delivered since the previous flush is returned. If ctx is done before the flush completes (for example,
+// because a consumer isn't reading from its event channel and the consumer timeout is 0) then ctx.Err()
+// is returned and the report of that flush is discarded.
+func (s *Service) Flush(ctx context.Context) (*dispatcher.FlushReport, error) {
+	respch := make(chan *dispatcher.FlushReport, 1)
+	if err := s.SubmitContext(ctx, dispatcher.NewFlushEvent(respch)); err != nil {
+		return nil, errors.WithMessage(err, "error submitting flush request")
+	}
+
+	select {
+	case report := <-respch:
+		return report, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// RejectRegistrations tells the dispatcher to reject all subsequent registration requests. Events
+// continue to be dispatched to existing registrations.
+func (s *Service) RejectRegistrations(ctx context.Context) error {
+	errch := make(chan error, 1)
+	if err := s.SubmitContext(ctx, dispatcher.NewRejectRegistrationsEvent(errch)); err != nil {
+		return errors.WithMessage(err, "error submitting reject registrations request")
+	}
+
+	select {
+	case err := <-errch:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// RegistrationCounts returns the number of registrations of each type, including the IDs of the
+// transactions whose status registrations haven't yet been sent an event
+func (s *Service) RegistrationCounts(ctx context.Context) (*dispatcher.RegistrationCounts, error) {
+	respch := make(chan *dispatcher.RegistrationCounts, 1)
+	if err := s.SubmitContext(ctx, dispatcher.NewRegistrationCountsEvent(respch)); err != nil {
+		return nil, errors.WithMessage(err, "error submitting registration counts request")
+	}
+
+	select {
+	case counts := <-respch:
+		return counts, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Submit submits an event for processing. If the dispatcher hasn't been started then a ServiceNotStartedError
+// is returned and if it has been stopped then a ServiceStoppedError is returned.
 func (s *Service) Submit(event interface{}) error {
 	defer func() {
 		// During shutdown, events may still be produced and we may
@@ -102,15 +184,277 @@ func (s *Service) Submit(event interface{}) error {
 		}
 	}()
 
+	if err := s.checkState(); err != nil {
+		return err
+	}
+
 	eventch, err := s.dispatcher.EventCh()
 	if err != nil {
 		return errors.WithMessage(err, "Error submitting to event dispatcher")
 	}
-	eventch <- event
+	s.enqueue(eventch, event)
+
+	return nil
+}
+
+// SubmitContext submits an event for processing. If the event can't be enqueued
+// before the context is done (for example, because the dispatcher isn't responding)
+// then ctx.Err() is returned.
+func (s *Service) SubmitContext(ctx context.Context, event interface{}) error {
+	defer func() {
+		// During shutdown, events may still be produced and we may
+		// get a 'send on closed channel' panic. Just log and ignore the error.
+		if p := recover(); p != nil {
+			logger.Warnf("panic while submitting event: %s", p)
+			debug.PrintStack()
+		}
+	}()
+
+	if err := s.checkState(); err != nil {
+		return err
+	}
+
+	eventch, err := s.dispatcher.EventCh()
+	if err != nil {
+		return errors.WithMessage(err, "Error submitting to event dispatcher")
+	}
+
+	select {
+	case eventch <- event:
+		s.recordEnqueue(false, 0)
+		return nil
+	default:
+	}
+
+	start := time.Now()
+	select {
+	case eventch <- event:
+		s.recordEnqueue(true, time.Since(start))
+		return nil
+	case <-ctx.Done():
+		s.recordEnqueue(true, time.Since(start))
+		return ctx.Err()
+	}
+}
+
+// ForceStop stops the dispatcher without waiting for it to process a stop request.
+// This function should only be invoked if the dispatcher isn't responding. It has
+// no effect if the dispatcher doesn't support being forcibly stopped.
+func (s *Service) ForceStop() {
+	if d, ok := s.dispatcher.(forceStopper); ok {
+		d.ForceStop()
+	} else {
+		logger.Warnf("Dispatcher doesn't support being forcibly stopped")
+	}
+}
+
+// SubmitBatch submits a batch of events for processing. The events are enqueued in order, one at a time,
+// so the only saving compared to calling Submit for each event is that the dispatcher's event channel is
+// retrieved (and its state checked) once for the whole batch. By default SubmitBatch blocks until all
+// events have been enqueued. If a timeout is provided (see WithBatchTimeout) and all of the events
+// could not be enqueued within the timeout then a BatchTimeoutError is returned.
+func (s *Service) SubmitBatch(events []interface{}, opts ...options.Opt) (err error) {
+	submitted := 0
+	defer func() {
+		// During shutdown, events may still be produced and we may
+		// get a 'send on closed channel' panic. Log and return an error
+		// so that the caller knows how many events were submitted.
+		if p := recover(); p != nil {
+			logger.Warnf("panic while submitting batch of events: %s", p)
+			debug.PrintStack()
+			err = errors.Errorf("panic while submitting batch - only %d of %d events were submitted: %v", submitted, len(events), p)
+		}
+	}()
+
+	params := &batchParams{}
+	options.Apply(params, opts)
+
+	if err := s.checkState(); err != nil {
+		return err
+	}
+
+	eventch, err := s.dispatcher.EventCh()
+	if err != nil {
+		return errors.WithMessage(err, "Error submitting batch to event dispatcher")
+	}
+
+	if params.timeout <= 0 {
+		for _, event := range events {
+			s.enqueue(eventch, event)
+			submitted++
+		}
+		return nil
+	}
+
+	timer := time.NewTimer(params.timeout)
+	defer timer.Stop()
+
+	for _, event := range events {
+		select {
+		case eventch <- event:
+			s.recordEnqueue(false, 0)
+			submitted++
+			continue
+		default:
+		}
+
+		start := time.Now()
+		select {
+		case eventch <- event:
+			s.recordEnqueue(true, time.Since(start))
+			submitted++
+		case <-timer.C:
+			s.recordEnqueue(true, time.Since(start))
+			return &BatchTimeoutError{Submitted: submitted, Total: len(events)}
+		}
+	}
 
 	return nil
 }
 
+// IngestStats returns the statistics of the dispatcher's ingest buffer. Empty statistics
+// are returned if the dispatcher doesn't collect them.
+func (s *Service) IngestStats() dispatcher.IngestStats {
+	if d, ok := s.dispatcher.(ingestStatsProvider); ok {
+		return d.IngestStats()
+	}
+	return dispatcher.IngestStats{}
+}
+
+type stateProvider interface {
+	State() dispatcher.State
+}
+
+type queueDepthProvider interface {
+	QueueDepth() int
+}
+
+type enqueueRecorder interface {
+	RecordEnqueue(blocked bool, duration time.Duration)
+}
+
+type forceStopper interface {
+	ForceStop()
+}
+
+type ingestStatsProvider interface {
+	IngestStats() dispatcher.IngestStats
+}
+
+// enqueue sends the event to the dispatcher's event channel and records
+// whether (and for how long) the send blocked because the buffer was full
+func (s *Service) enqueue(eventch chan<- interface{}, event interface{}) {
+	select {
+	case eventch <- event:
+		s.recordEnqueue(false, 0)
+		return
+	default:
+	}
+
+	start := time.Now()
+	eventch <- event
+	s.recordEnqueue(true, time.Since(start))
+}
+
+// checkState returns an error if the dispatcher isn't accepting events. If the dispatcher
+// doesn't report its state then the check is left to the dispatcher's EventCh function.
+func (s *Service) checkState() error {
+	sp, ok := s.dispatcher.(stateProvider)
+	if !ok {
+		return nil
+	}
+
+	switch sp.State() {
+	case dispatcher.StateInitial:
+		return &ServiceNotStartedError{}
+	case dispatcher.StateStopped:
+		return &ServiceStoppedError{}
+	default:
+		return nil
+	}
+}
+
+// SubmitRegistration submits a registration request that's handled by an extension of the dispatcher (for
+// example, a request to register for connection events) and waits for the response in the same way as the
+// registrations of the service: the request fails if the service isn't started, and a TimeoutError is returned
+// if no response is received within the response timeout. The channels must be buffered.
+func (s *Service) SubmitRegistration(operation string, event interface{}, regch <-chan fab.Registration, errch <-chan error) (fab.Registration, error) {
+	return s.register(operation, event, regch, errch)
+}
+
+// register submits the given registration request and waits (up to the response timeout) for the response.
+// A TimeoutError is returned if the request couldn't be submitted, or no response was received, within the timeout.
+func (s *Service) register(operation string, event interface{}, regch <-chan fab.Registration, errch <-chan error) (fab.Registration, error) {
+	ctx := context.Background()
+	if s.respTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.respTimeout)
+		defer cancel()
+	}
+
+	if err := s.SubmitContext(ctx, event); err != nil {
+		if err == ctx.Err() {
+			return nil, s.newTimeoutError(operation)
+		}
+		return nil, errors.WithMessage(err, "error "+operation)
+	}
+
+	select {
+	case response := <-regch:
+		return response, nil
+	case err := <-errch:
+		return nil, err
+	case <-ctx.Done():
+		// The dispatcher may still process the request, in which case the registration is removed
+		go s.unregisterLate(regch, errch)
+		return nil, s.newTimeoutError(operation)
+	}
+}
+
+// unregisterLate waits for the response to a registration request that timed out and removes
+// the registration if the request succeeded. It gives up once the dispatcher is stopped.
+func (s *Service) unregisterLate(regch <-chan fab.Registration, errch <-chan error) {
+	ticker := time.NewTicker(s.respTimeout)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case reg := <-regch:
+			logger.Warnf("Removing registration that completed after the response timeout")
+			s.Unregister(reg)
+			return
+		case <-errch:
+			return
+		case <-ticker.C:
+			if s.checkState() != nil {
+				return
+			}
+		}
+	}
+}
+
+func (s *Service) newTimeoutError(operation string) *TimeoutError {
+	err := &TimeoutError{
+		Operation:  operation,
+		Timeout:    s.respTimeout,
+		State:      -1,
+		QueueDepth: -1,
+	}
+	if sp, ok := s.dispatcher.(stateProvider); ok {
+		err.State = sp.State()
+	}
+	if qp, ok := s.dispatcher.(queueDepthProvider); ok {
+		err.QueueDepth = qp.QueueDepth()
+	}
+	return err
+}
+
+func (s *Service) recordEnqueue(blocked bool, duration time.Duration) {
+	if r, ok := s.dispatcher.(enqueueRecorder); ok {
+		r.RecordEnqueue(blocked, duration)
+	}
+}
+
 // Dispatcher returns the event dispatcher
 func (s *Service) Dispatcher() Dispatcher {
 	return s.dispatcher
@@ -120,8 +464,8 @@ func (s *Service) Dispatcher() Dispatcher {
 // block events then an error is returned.
 func (s *Service) RegisterBlockEvent(filter ...fab.BlockFilter) (fab.Registration, <-chan *fab.BlockEvent, error) {
 	eventch := make(chan *fab.BlockEvent, s.eventConsumerBufferSize)
-	regch := make(chan fab.Registration)
-	errch := make(chan error)
+	regch := make(chan fab.Registration, 1)
+	errch := make(chan error, 1)
 
 	blockFilter := blockfilter.AcceptAny
 	if len(filter) > 1 {
@@ -132,42 +476,39 @@ func (s *Service) RegisterBlockEvent(filter ...fab.BlockFilter) (fab.Registratio
 		blockFilter = filter[0]
 	}
 
-	if err := s.Submit(dispatcher.NewRegisterBlockEvent(blockFilter, eventch, regch, errch)); err != nil {
-		return nil, nil, errors.WithMessage(err, "error registering for block events")
-	}
+	request := dispatcher.NewRegisterBlockEvent(blockFilter, eventch, regch, errch)
+	request.Scope = s.scope
 
-	select {
-	case response := <-regch:
-		return response, eventch, nil
-	case err := <-errch:
+	reg, err := s.register("registering for block events", request, regch, errch)
+	if err != nil {
 		return nil, nil, err
 	}
+	return reg, eventch, nil
 }
 
 // RegisterFilteredBlockEvent registers for filtered block events. If the client is not authorized to receive
 // filtered block events then an error is returned.
 func (s *Service) RegisterFilteredBlockEvent() (fab.Registration, <-chan *fab.FilteredBlockEvent, error) {
 	eventch := make(chan *fab.FilteredBlockEvent, s.eventConsumerBufferSize)
-	regch := make(chan fab.Registration)
-	errch := make(chan error)
+	regch := make(chan fab.Registration, 1)
+	errch := make(chan error, 1)
 
-	if err := s.Submit(dispatcher.NewRegisterFilteredBlockEvent(eventch, regch, errch)); err != nil {
-		return nil, nil, errors.WithMessage(err, "error registering for filtered block events")
-	}
+	request := dispatcher.NewRegisterFilteredBlockEvent(eventch, regch, errch)
+	request.Scope = s.scope
 
-	select {
-	case response := <-regch:
-		return response, eventch, nil
-	case err := <-errch:
+	reg, err := s.register("registering for filtered block events", request, regch, errch)
+	if err != nil {
 		return nil, nil, err
 	}
+	return reg, eventch, nil
 }
 
 // RegisterChaincodeEvent registers for chaincode events. If the client is not authorized to receive
 // chaincode events then an error is returned.
 // - ccID is the chaincode ID for which events are to be received
-// - eventFilter is the chaincode event name for which events are to be received
-func (s *Service) RegisterChaincodeEvent(ccID, eventFilter string) (fab.Registration, <-chan *fab.CCEvent, error) {
+// - eventFilter is the chaincode event name for which events are to be received. The filter is
+//   unanchored by default (see dispatcher.WithAnchoredFilter and dispatcher.WithCaseInsensitiveFilter).
+func (s *Service) RegisterChaincodeEvent(ccID, eventFilter string, opts ...options.Opt) (fab.Registration, <-chan *fab.CCEvent, error) {
 	if ccID == "" {
 		return nil, nil, errors.New("chaincode ID is required")
 	}
@@ -176,19 +517,17 @@ func (s *Service) RegisterChaincodeEvent(ccID, eventFilter string) (fab.Registra
 	}
 
 	eventch := make(chan *fab.CCEvent, s.eventConsumerBufferSize)
-	regch := make(chan fab.Registration)
-	errch := make(chan error)
+	regch := make(chan fab.Registration, 1)
+	errch := make(chan error, 1)
 
-	if err := s.Submit(dispatcher.NewRegisterChaincodeEvent(ccID, eventFilter, eventch, regch, errch)); err != nil {
-		return nil, nil, errors.WithMessage(err, "error registering for chaincode events")
-	}
+	request := dispatcher.NewRegisterChaincodeEvent(ccID, eventFilter, eventch, regch, errch, opts...)
+	request.Scope = s.scope
 
-	select {
-	case response := <-regch:
-		return response, eventch, nil
-	case err := <-errch:
+	reg, err := s.register("registering for chaincode events", request, regch, errch)
+	if err != nil {
 		return nil, nil, err
 	}
+	return reg, eventch, nil
 }
 
 // RegisterTxStatusEvent registers for transaction status events. If the client is not authorized to receive
@@ -200,24 +539,75 @@ func (s *Service) RegisterTxStatusEvent(txID string) (fab.Registration, <-chan *
 	}
 
 	eventch := make(chan *fab.TxStatusEvent, s.eventConsumerBufferSize)
-	regch := make(chan fab.Registration)
-	errch := make(chan error)
+	regch := make(chan fab.Registration, 1)
+	errch := make(chan error, 1)
+
+	request := dispatcher.NewRegisterTxStatusEvent(txID, eventch, regch, errch)
+	request.Scope = s.scope
 
-	if err := s.Submit(dispatcher.NewRegisterTxStatusEvent(txID, eventch, regch, errch)); err != nil {
-		return nil, nil, errors.WithMessage(err, "error registering for Tx Status events")
+	reg, err := s.register("registering for Tx Status events", request, regch, errch)
+	if err != nil {
+		return nil, nil, err
 	}
+	return reg, eventch, nil
+}
 
+// WaitForTxStatus registers for the transaction status event of the given transaction and waits until the event is
+// received, the context is done or the event service is stopped. The registration is always removed before returning.
+// If the transaction was committed with a validation code other than VALID then the event is returned along with
+// a TxValidationError.
+func (s *Service) WaitForTxStatus(ctx context.Context, txID string) (*fab.TxStatusEvent, error) {
+	reg, eventch, err := s.RegisterTxStatusEvent(txID)
+	if err != nil {
+		return nil, errors.WithMessage(err, "error registering for TxStatus event")
+	}
+
+	var event *fab.TxStatusEvent
+	var ok bool
 	select {
-	case response := <-regch:
-		return response, eventch, nil
-	case err := <-errch:
+	case event, ok = <-eventch:
+	case <-ctx.Done():
+		// The event may have arrived at the same time that the context was done
+		select {
+		case event, ok = <-eventch:
+		default:
+			s.Unregister(reg)
+			return nil, errors.Wrapf(ctx.Err(), "timed out waiting for TxStatus event for TxID [%s]", txID)
+		}
+	}
+
+	if !ok {
+		// The registration was removed when the dispatcher was stopped
+		return nil, errors.WithMessage(&ServiceStoppedError{}, fmt.Sprintf("unable to receive TxStatus event for TxID [%s]", txID))
+	}
+
+	s.Unregister(reg)
+
+	if event.TxValidationCode != pb.TxValidationCode_VALID {
+		return event, &TxValidationError{TxID: event.TxID, Code: event.TxValidationCode}
+	}
+	return event, nil
+}
+
+// RegisterHandlerPanicEvent registers for handler panic events. An event is published to the
+// returned channel whenever an event handler or a user-provided block filter panics.
+// This registration is intended for monitoring purposes.
+func (s *Service) RegisterHandlerPanicEvent() (fab.Registration, <-chan *dispatcher.HandlerPanicEvent, error) {
+	eventch := make(chan *dispatcher.HandlerPanicEvent, s.eventConsumerBufferSize)
+	regch := make(chan fab.Registration, 1)
+	errch := make(chan error, 1)
+
+	reg, err := s.register("registering for handler panic events", dispatcher.NewRegisterHandlerPanicEvent(eventch, regch, errch), regch, errch)
+	if err != nil {
 		return nil, nil, err
 	}
+	return reg, eventch, nil
 }
 
 // Unregister unregisters the given registration.
 // - reg is the registration handle that was returned from one of the RegisterXXX functions
 func (s *Service) Unregister(reg fab.Registration) {
+	s.stopCallback(reg)
 	if err := s.Submit(dispatcher.NewUnregisterEvent(reg)); err != nil {
 		logger.Warnf("Error unregistering: %s", err)
 	}
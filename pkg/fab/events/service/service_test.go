@@ -7,7 +7,9 @@ SPDX-License-Identifier: Apache-2.0
 package service
 
 import (
+	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -329,6 +331,124 @@ func TestTxStatusEvents(t *testing.T) {
 	}
 }
 
+func TestWaitForTxStatus(t *testing.T) {
+	channelID := "mychannel"
+
+	t.Run("Committed", func(t *testing.T) {
+		eventService, eventProducer, err := newServiceWithMockProducer(defaultOpts, withFilteredBlockLedger())
+		if err != nil {
+			t.Fatalf("error creating event service: %s", err)
+		}
+		defer eventProducer.Close()
+		defer eventService.Stop()
+
+		resultch := waitForTxStatus(context.Background(), eventService, "txid1")
+		waitForTxRegistrations(t, eventService, 1)
+		eventProducer.Ledger().NewFilteredBlock(channelID, servicemocks.NewFilteredTx("txid1", pb.TxValidationCode_VALID))
+
+		result := <-resultch
+		if result.err != nil {
+			t.Fatalf("error waiting for TxStatus event: %s", result.err)
+		}
+		checkTxStatusEvent(t, result.event, "txid1", pb.TxValidationCode_VALID)
+		waitForTxRegistrations(t, eventService, 0)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		eventService, eventProducer, err := newServiceWithMockProducer(defaultOpts, withFilteredBlockLedger())
+		if err != nil {
+			t.Fatalf("error creating event service: %s", err)
+		}
+		defer eventProducer.Close()
+		defer eventService.Stop()
+
+		resultch := waitForTxStatus(context.Background(), eventService, "txid2")
+		waitForTxRegistrations(t, eventService, 1)
+		eventProducer.Ledger().NewFilteredBlock(channelID, servicemocks.NewFilteredTx("txid2", pb.TxValidationCode_MVCC_READ_CONFLICT))
+
+		result := <-resultch
+		validationErr, ok := result.err.(*TxValidationError)
+		if !ok {
+			t.Fatalf("expecting TxValidationError but got: %v", result.err)
+		}
+		if validationErr.TxID != "txid2" || validationErr.Code != pb.TxValidationCode_MVCC_READ_CONFLICT {
+			t.Fatalf("unexpected TxValidationError: %s", validationErr)
+		}
+		checkTxStatusEvent(t, result.event, "txid2", pb.TxValidationCode_MVCC_READ_CONFLICT)
+		waitForTxRegistrations(t, eventService, 0)
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		eventService, eventProducer, err := newServiceWithMockProducer(defaultOpts, withFilteredBlockLedger())
+		if err != nil {
+			t.Fatalf("error creating event service: %s", err)
+		}
+		defer eventProducer.Close()
+		defer eventService.Stop()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+
+		_, err = eventService.WaitForTxStatus(ctx, "txid3")
+		if errors.Cause(err) != context.DeadlineExceeded {
+			t.Fatalf("expecting deadline exceeded error but got: %v", err)
+		}
+		waitForTxRegistrations(t, eventService, 0)
+	})
+
+	t.Run("Stopped", func(t *testing.T) {
+		eventService, eventProducer, err := newServiceWithMockProducer(defaultOpts, withFilteredBlockLedger())
+		if err != nil {
+			t.Fatalf("error creating event service: %s", err)
+		}
+		defer eventProducer.Close()
+
+		resultch := waitForTxStatus(context.Background(), eventService, "txid4")
+		waitForTxRegistrations(t, eventService, 1)
+		eventService.Stop()
+
+		select {
+		case result := <-resultch:
+			if _, ok := errors.Cause(result.err).(*ServiceStoppedError); !ok {
+				t.Fatalf("expecting ServiceStoppedError but got: %v", result.err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for WaitForTxStatus to return")
+		}
+	})
+}
+
+type txStatusResult struct {
+	event *fab.TxStatusEvent
+	err   error
+}
+
+func waitForTxStatus(ctx context.Context, eventService *Service, txID string) <-chan txStatusResult {
+	resultch := make(chan txStatusResult, 1)
+	go func() {
+		event, err := eventService.WaitForTxStatus(ctx, txID)
+		resultch <- txStatusResult{event: event, err: err}
+	}()
+	return resultch
+}
+
+// waitForTxRegistrations waits until the dispatcher has the given number of TxStatus registrations
+func waitForTxRegistrations(t *testing.T, eventService *Service, expected int) {
+	var counts *dispatcher.RegistrationCounts
+	for i := 0; i < 50; i++ {
+		var err error
+		counts, err = eventService.RegistrationCounts(context.Background())
+		if err != nil {
+			t.Fatalf("error getting registration counts: %s", err)
+		}
+		if counts.TxStatus == expected {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("expecting %d TxStatus registrations but got %d", expected, counts.TxStatus)
+}
+
 func TestCCEvents(t *testing.T) {
 	channelID := "mychannel"
 	eventService, eventProducer, err := newServiceWithMockProducer(defaultOpts, withFilteredBlockLedger())
@@ -699,6 +819,482 @@ func checkCCEvent(t *testing.T, event *fab.CCEvent, expectedCCID string, expecte
 	}
 }
 
+func TestSubmitBatch(t *testing.T) {
+	channelID := "mychannel"
+	eventService, eventProducer, err := newServiceWithMockProducer(defaultOpts, withFilteredBlockLedger())
+	if err != nil {
+		t.Fatalf("error creating channel event client: %s", err)
+	}
+	defer eventProducer.Close()
+	defer eventService.Stop()
+
+	registration, eventch, err := eventService.RegisterFilteredBlockEvent()
+	if err != nil {
+		t.Fatalf("error registering for filtered block events: %s", err)
+	}
+	defer eventService.Unregister(registration)
+
+	numEvents := 50
+	blockProducer := servicemocks.NewBlockProducer()
+	var events []interface{}
+	for i := 0; i < numEvents; i++ {
+		events = append(events, blockProducer.NewFilteredBlock(channelID))
+	}
+
+	go func() {
+		if err := eventService.SubmitBatch(events); err != nil {
+			t.Errorf("error submitting batch: %s", err)
+		}
+	}()
+
+	for i := 0; i < numEvents; i++ {
+		select {
+		case fbevent, ok := <-eventch:
+			if !ok {
+				t.Fatalf("unexpected closed channel")
+			}
+			if fbevent.FilteredBlock.Number != uint64(i) {
+				t.Fatalf("expecting filtered block #%d but got #%d", i, fbevent.FilteredBlock.Number)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for filtered block event #%d", i)
+		}
+	}
+}
+
+func TestFlush(t *testing.T) {
+	channelID := "mychannel"
+	opts := []options.Opt{dispatcher.WithEventConsumerBufferSize(1), dispatcher.WithEventConsumerTimeout(0)}
+	eventService, eventProducer, err := newServiceWithMockProducer(opts)
+	if err != nil {
+		t.Fatalf("error creating channel event client: %s", err)
+	}
+	defer eventProducer.Close()
+	defer eventService.Stop()
+
+	registration, eventch, err := eventService.RegisterBlockEvent()
+	if err != nil {
+		t.Fatalf("error registering for block events: %s", err)
+	}
+	defer eventService.Unregister(registration)
+
+	// The first block fills the consumer's buffer and the dispatcher blocks on the second block
+	blockProducer := servicemocks.NewBlockProducer()
+	for i := 0; i < 2; i++ {
+		if err := eventService.Submit(blockProducer.NewBlock(channelID)); err != nil {
+			t.Fatalf("error submitting block: %s", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if _, err := eventService.Flush(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expecting flush to time out while the consumer is blocked but got: %v", err)
+	}
+
+	if event := <-eventch; event.Block.Header.Number != 0 {
+		t.Fatalf("expecting block #0 but got #%d", event.Block.Header.Number)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	report, err := eventService.Flush(ctx)
+	if err != nil {
+		t.Fatalf("error flushing: %s", err)
+	}
+	if report.Undelivered != 0 {
+		t.Fatalf("expecting all events to be delivered but %d were not", report.Undelivered)
+	}
+
+	// The second block must have been delivered by the time the flush returns
+	select {
+	case event := <-eventch:
+		if event.Block.Header.Number != 1 {
+			t.Fatalf("expecting block #1 but got #%d", event.Block.Header.Number)
+		}
+	default:
+		t.Fatalf("expecting block #1 to be delivered when the flush returns")
+	}
+}
+
+func TestResetTimeout(t *testing.T) {
+	defer func(timeout time.Duration) { stopTimeout = timeout }(stopTimeout)
+	stopTimeout = 100 * time.Millisecond
+
+	channelID := "mychannel"
+	opts := []options.Opt{dispatcher.WithEventConsumerBufferSize(1), dispatcher.WithEventConsumerTimeout(0)}
+	eventService, eventProducer, err := newServiceWithMockProducer(opts)
+	if err != nil {
+		t.Fatalf("error creating channel event client: %s", err)
+	}
+	defer eventProducer.Close()
+	defer eventService.Stop()
+
+	registration, eventch, err := eventService.RegisterBlockEvent()
+	if err != nil {
+		t.Fatalf("error registering for block events: %s", err)
+	}
+	defer eventService.Unregister(registration)
+
+	// The first block fills the consumer's buffer and the dispatcher blocks on the second block
+	blockProducer := servicemocks.NewBlockProducer()
+	for i := 0; i < 2; i++ {
+		if err := eventService.Submit(blockProducer.NewBlock(channelID)); err != nil {
+			t.Fatalf("error submitting block: %s", err)
+		}
+	}
+
+	if err := eventService.Reset(); err == nil {
+		t.Fatalf("expecting reset to time out while the dispatcher is blocked")
+	}
+
+	// The dispatcher processes the reset and the events that follow once the consumer is unblocked
+	for i := uint64(0); i < 2; i++ {
+		if event := <-eventch; event.Block.Header.Number != i {
+			t.Fatalf("expecting block #%d but got #%d", i, event.Block.Header.Number)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := eventService.Flush(ctx); err != nil {
+		t.Fatalf("expecting the dispatcher to process events after the reset timed out but got: %s", err)
+	}
+	if err := eventService.Submit(blockProducer.NewBlock(channelID)); err != nil {
+		t.Fatalf("error submitting block: %s", err)
+	}
+	select {
+	case <-eventch:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for block event after the reset")
+	}
+}
+
+type blockedDispatcher struct {
+	eventch chan interface{}
+}
+
+func (d *blockedDispatcher) Start() error                         { return nil }
+func (d *blockedDispatcher) EventCh() (chan<- interface{}, error) { return d.eventch, nil }
+func (d *blockedDispatcher) LastBlockNum() uint64                 { return 0 }
+
+func TestSubmitBatchTimeout(t *testing.T) {
+	eventService := New(&blockedDispatcher{eventch: make(chan interface{}, 2)})
+
+	events := []interface{}{&pb.FilteredBlock{}, &pb.FilteredBlock{}, &pb.FilteredBlock{}, &pb.FilteredBlock{}}
+
+	err := eventService.SubmitBatch(events, WithBatchTimeout(100*time.Millisecond))
+	if err == nil {
+		t.Fatalf("expecting timeout error submitting batch")
+	}
+	batchErr, ok := err.(*BatchTimeoutError)
+	if !ok {
+		t.Fatalf("expecting BatchTimeoutError but got %T", err)
+	}
+	if batchErr.Submitted != 2 || batchErr.Total != len(events) {
+		t.Fatalf("expecting 2 of %d events to be submitted but got %d of %d", len(events), batchErr.Submitted, batchErr.Total)
+	}
+}
+
+func TestSubmitBatchClosed(t *testing.T) {
+	eventch := make(chan interface{}, 1)
+	close(eventch)
+	eventService := New(&blockedDispatcher{eventch: eventch})
+
+	events := []interface{}{&pb.FilteredBlock{}, &pb.FilteredBlock{}, &pb.FilteredBlock{}}
+
+	err := eventService.SubmitBatch(events)
+	if err == nil {
+		t.Fatalf("expecting error submitting batch to closed event channel")
+	}
+	if !strings.Contains(err.Error(), "only 0 of 3 events were submitted") {
+		t.Fatalf("expecting error with the number of submitted events but got: %s", err)
+	}
+}
+
+func TestRegisterNotStarted(t *testing.T) {
+	eventService := New(dispatcher.New())
+
+	_, _, err := eventService.RegisterBlockEvent()
+	if _, ok := errors.Cause(err).(*ServiceNotStartedError); !ok {
+		t.Fatalf("expecting ServiceNotStartedError registering before start but got: %v", err)
+	}
+	if err := eventService.Submit(&pb.FilteredBlock{}); err == nil {
+		t.Fatalf("expecting error submitting event before start")
+	}
+	if _, ok := eventService.SubmitBatch([]interface{}{&pb.FilteredBlock{}}).(*ServiceNotStartedError); !ok {
+		t.Fatalf("expecting ServiceNotStartedError submitting batch before start")
+	}
+}
+
+func TestRegisterStopped(t *testing.T) {
+	eventService := New(dispatcher.New())
+	if err := eventService.Start(); err != nil {
+		t.Fatalf("error starting event service: %s", err)
+	}
+	eventService.Stop()
+
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := eventService.RegisterChaincodeEvent("mycc", "event1")
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if _, ok := errors.Cause(err).(*ServiceStoppedError); !ok {
+			t.Fatalf("expecting ServiceStoppedError registering after stop but got: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expecting registration after stop to fail immediately")
+	}
+
+	// Stopping again should also return immediately
+	eventService.Stop()
+}
+
+type saturatedDispatcher struct {
+	blockedDispatcher
+}
+
+func (d *saturatedDispatcher) State() dispatcher.State { return dispatcher.StateStarted }
+func (d *saturatedDispatcher) QueueDepth() int         { return len(d.eventch) }
+
+func TestRegisterTimeout(t *testing.T) {
+	// The dispatcher never reads from its event channel
+	d := &saturatedDispatcher{blockedDispatcher: blockedDispatcher{eventch: make(chan interface{}, 2)}}
+	eventService := New(d, WithResponseTimeout(100*time.Millisecond))
+
+	// The registration is enqueued but no response is received
+	_, _, err := eventService.RegisterTxStatusEvent("txid1")
+	timeoutErr, ok := err.(*TimeoutError)
+	if !ok {
+		t.Fatalf("expecting TimeoutError but got: %v", err)
+	}
+	if timeoutErr.State != dispatcher.StateStarted || timeoutErr.QueueDepth != 1 {
+		t.Fatalf("expecting dispatcher state [started] and queue depth 1 but got: %s", timeoutErr)
+	}
+
+	// The queue is now full so the registration can't be enqueued
+	if err := eventService.Submit(&pb.FilteredBlock{}); err != nil {
+		t.Fatalf("error submitting event: %s", err)
+	}
+
+	start := time.Now()
+	_, _, err = eventService.RegisterFilteredBlockEvent()
+	timeoutErr, ok = err.(*TimeoutError)
+	if !ok {
+		t.Fatalf("expecting TimeoutError but got: %v", err)
+	}
+	if timeoutErr.QueueDepth != 2 {
+		t.Fatalf("expecting queue depth 2 but got: %s", timeoutErr)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expecting registration to time out after 100ms but it took %s", elapsed)
+	}
+}
+
+func TestRegisterTimeoutLateResponse(t *testing.T) {
+	d := &saturatedDispatcher{blockedDispatcher: blockedDispatcher{eventch: make(chan interface{}, 2)}}
+	eventService := New(d, WithResponseTimeout(50*time.Millisecond))
+
+	if _, _, err := eventService.RegisterFilteredBlockEvent(); err == nil {
+		t.Fatalf("expecting registration to time out")
+	}
+
+	// Respond to the registration after the timeout. The registration should be removed.
+	regEvent := (<-d.eventch).(*dispatcher.RegisterFilteredBlockEvent)
+	regEvent.RegCh <- regEvent.Reg
+
+	select {
+	case e := <-d.eventch:
+		unregEvent, ok := e.(*dispatcher.UnregisterEvent)
+		if !ok || unregEvent.Reg != regEvent.Reg {
+			t.Fatalf("expecting unregister event for the late registration but got %#v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expecting late registration to be removed")
+	}
+}
+
+// allowlistAuthorizer permits chaincode registrations for the chaincodes that are allowed for
+// the requester's scope. Other registrations are permitted for known scopes only.
+type allowlistAuthorizer map[string][]string
+
+func (a allowlistAuthorizer) Authorize(scope string, reg fab.Registration) error {
+	allowed, ok := a[scope]
+	if !ok {
+		return errors.Errorf("unknown scope [%s]", scope)
+	}
+
+	ccReg, ok := reg.(*dispatcher.ChaincodeReg)
+	if !ok {
+		return nil
+	}
+	for _, ccID := range allowed {
+		if ccID == ccReg.ChaincodeID {
+			return nil
+		}
+	}
+	return errors.Errorf("scope [%s] may not register for events of chaincode [%s]", scope, ccReg.ChaincodeID)
+}
+
+func TestRegistrationAuthorizer(t *testing.T) {
+	authorizer := allowlistAuthorizer{
+		"tenantA": {"ccA"},
+		"tenantB": {"ccB"},
+	}
+
+	d := dispatcher.New(dispatcher.WithRegistrationAuthorizer(authorizer))
+	tenantA := New(d, WithScope("tenantA"))
+	tenantB := New(d, WithScope("tenantB"))
+	unscoped := New(d)
+
+	if err := tenantA.Start(); err != nil {
+		t.Fatalf("error starting event service: %s", err)
+	}
+	defer tenantA.Stop()
+
+	// Tenant A may not register for tenant B's chaincode events
+	if _, _, err := tenantA.RegisterChaincodeEvent("ccB", "event1"); err == nil || !strings.Contains(err.Error(), "may not register") {
+		t.Fatalf("expecting registration for another tenant's chaincode events to be denied but got: %v", err)
+	}
+
+	// The denied registration wasn't recorded so tenant B may register with the same chaincode and filter
+	regB, eventchB, err := tenantB.RegisterChaincodeEvent("ccB", "event1")
+	if err != nil {
+		t.Fatalf("error registering for chaincode events: %s", err)
+	}
+	defer tenantB.Unregister(regB)
+
+	regA, _, err := tenantA.RegisterChaincodeEvent("ccA", "event1")
+	if err != nil {
+		t.Fatalf("error registering for chaincode events: %s", err)
+	}
+	defer tenantA.Unregister(regA)
+
+	// Requests without a known scope are denied for all registration types
+	if _, _, err := unscoped.RegisterBlockEvent(); err == nil {
+		t.Fatalf("expecting unscoped block registration to be denied")
+	}
+	if _, _, err := unscoped.RegisterFilteredBlockEvent(); err == nil {
+		t.Fatalf("expecting unscoped filtered block registration to be denied")
+	}
+	if _, _, err := unscoped.RegisterTxStatusEvent("txid1"); err == nil {
+		t.Fatalf("expecting unscoped Tx Status registration to be denied")
+	}
+
+	// The denied Tx Status registration wasn't recorded
+	regTx, _, err := tenantA.RegisterTxStatusEvent("txid1")
+	if err != nil {
+		t.Fatalf("error registering for Tx Status events: %s", err)
+	}
+	defer tenantA.Unregister(regTx)
+
+	// Only the authorized registration receives the chaincode event
+	if err := tenantA.Submit(servicemocks.NewBlockProducer().NewFilteredBlock("mychannel", servicemocks.NewFilteredTxWithCCEvent("txid2", "ccB", "event1"))); err != nil {
+		t.Fatalf("error submitting block: %s", err)
+	}
+	select {
+	case event := <-eventchB:
+		checkCCEvent(t, event, "ccB", "event1")
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for chaincode event")
+	}
+}
+
+type recordingDispatcher struct {
+	blockedDispatcher
+	stats dispatcher.IngestStats
+}
+
+func (d *recordingDispatcher) RecordEnqueue(blocked bool, duration time.Duration) {
+	d.stats.Submitted++
+	if blocked {
+		d.stats.Blocked++
+		d.stats.BlockedTime += duration
+	}
+}
+
+func (d *recordingDispatcher) IngestStats() dispatcher.IngestStats {
+	return d.stats
+}
+
+func TestIngestStats(t *testing.T) {
+	eventService := New(&recordingDispatcher{blockedDispatcher: blockedDispatcher{eventch: make(chan interface{}, 2)}})
+
+	if err := eventService.Submit(&pb.FilteredBlock{}); err != nil {
+		t.Fatalf("error submitting event: %s", err)
+	}
+
+	events := []interface{}{&pb.FilteredBlock{}, &pb.FilteredBlock{}}
+	if err := eventService.SubmitBatch(events, WithBatchTimeout(50*time.Millisecond)); err == nil {
+		t.Fatalf("expecting timeout error submitting batch")
+	}
+
+	stats := eventService.IngestStats()
+	if stats.Submitted != 3 {
+		t.Fatalf("expecting 3 submitted events but got %d", stats.Submitted)
+	}
+	if stats.Blocked != 1 {
+		t.Fatalf("expecting 1 blocked event but got %d", stats.Blocked)
+	}
+	if stats.BlockedTime < 50*time.Millisecond {
+		t.Fatalf("expecting blocked time of at least 50ms but got %s", stats.BlockedTime)
+	}
+}
+
+const numBenchmarkBlocks = 10000
+
+func BenchmarkSubmit(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		eventService, events := newBenchmarkService(b)
+		b.StartTimer()
+		for _, event := range events {
+			if err := eventService.Submit(event); err != nil {
+				b.Fatalf("error submitting event: %s", err)
+			}
+		}
+		waitForDispatcher(b, eventService)
+	}
+}
+
+func BenchmarkSubmitBatch(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		eventService, events := newBenchmarkService(b)
+		b.StartTimer()
+		if err := eventService.SubmitBatch(events); err != nil {
+			b.Fatalf("error submitting batch: %s", err)
+		}
+		waitForDispatcher(b, eventService)
+	}
+}
+
+func newBenchmarkService(b *testing.B) (*Service, []interface{}) {
+	b.StopTimer()
+
+	eventService := New(dispatcher.New())
+	if err := eventService.Start(); err != nil {
+		b.Fatalf("error starting event service: %s", err)
+	}
+
+	blockProducer := servicemocks.NewBlockProducer()
+	events := make([]interface{}, numBenchmarkBlocks)
+	for i := range events {
+		events[i] = blockProducer.NewFilteredBlock("mychannel")
+	}
+	return eventService, events
+}
+
+// waitForDispatcher waits for all submitted events to be processed and then stops the service
+func waitForDispatcher(b *testing.B, eventService *Service) {
+	// The reset event is processed after all previously submitted events
+	if err := eventService.Reset(); err != nil {
+		b.Fatalf("error resetting event service: %s", err)
+	}
+	b.StopTimer()
+	eventService.Stop()
+}
+
 type producerOpts struct {
 	ledger *servicemocks.MockLedger
 }
@@ -8,10 +8,6 @@ package identity
 
 import (
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
-	"github.com/hyperledger/fabric-sdk-go/pkg/core/config/cryptoutil"
-	"github.com/hyperledger/fabric-sdk-go/pkg/fab/keyvaluestore"
-
-	contextApi "github.com/hyperledger/fabric-sdk-go/pkg/context/api"
 	"github.com/pkg/errors"
 )
 
@@ -19,19 +15,7 @@ import (
 // Only user's enrollment cert is stored, in pem format.
 // File naming is <user>@<org>-cert.pem
 type CertFileUserStore struct {
-	store       *keyvaluestore.FileKeyValueStore
-	cryptoSuite core.CryptoSuite
-}
-
-func userKeyFromUser(user contextApi.User) contextApi.UserKey {
-	return contextApi.UserKey{
-		MspID: user.MspID(),
-		Name:  user.Name(),
-	}
-}
-
-func storeKeyFromUserKey(key contextApi.UserKey) string {
-	return key.Name + "@" + key.MspID + "-cert.pem"
+	*CredentialUserStore
 }
 
 // NewCertFileUserStore creates a new instance of CertFileUserStore
@@ -42,58 +26,13 @@ func NewCertFileUserStore(path string, cryptoSuite core.CryptoSuite) (*CertFileU
 	if cryptoSuite == nil {
 		return nil, errors.New("cryptoSuite is nil")
 	}
-	store, err := keyvaluestore.New(&keyvaluestore.FileKeyValueStoreOptions{
-		Path: path,
-	})
-	if err != nil {
-		return nil, errors.Wrap(err, "user store creation failed")
-	}
-	return &CertFileUserStore{
-		store:       store,
-		cryptoSuite: cryptoSuite,
-	}, nil
-}
-
-// Load returns the User stored in the store for a key.
-func (s *CertFileUserStore) Load(key contextApi.UserKey) (contextApi.User, error) {
-	cert, err := s.store.Load(storeKeyFromUserKey(key))
+	store, err := NewFileCredentialStore(path)
 	if err != nil {
-		if err == contextApi.ErrNotFound {
-			return nil, contextApi.ErrUserNotFound
-		}
 		return nil, err
 	}
-	certBytes, ok := cert.([]byte)
-	if !ok {
-		return nil, errors.New("user is not of proper type")
-	}
-	pubKey, err := cryptoutil.GetPublicKeyFromCert(certBytes, s.cryptoSuite)
-	if err != nil {
-		return nil, errors.WithMessage(err, "fetching public key from cert failed")
-	}
-	pk, err := s.cryptoSuite.GetKey(pubKey.SKI())
+	userStore, err := NewCredentialUserStore(store, cryptoSuite)
 	if err != nil {
-		return nil, errors.Wrap(err, "cryptoSuite GetKey failed")
-	}
-	u := &User{
-		mspID: key.MspID,
-		name:  key.Name,
-		enrollmentCertificate: certBytes,
-		privateKey:            pk,
-	}
-	return u, nil
-}
-
-// Store stores a User into store
-func (s *CertFileUserStore) Store(user contextApi.User) error {
-	if user == nil {
-		return errors.New("user is nil")
+		return nil, err
 	}
-	key := storeKeyFromUserKey(userKeyFromUser(user))
-	return s.store.Store(key, user.EnrollmentCertificate())
-}
-
-// Delete deletes a User from store
-func (s *CertFileUserStore) Delete(user contextApi.User) error {
-	return s.store.Delete(storeKeyFromUserKey(userKeyFromUser(user)))
+	return &CertFileUserStore{CredentialUserStore: userStore}, nil
 }
@@ -0,0 +1,111 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package identity
+
+import (
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config/cryptoutil"
+
+	contextApi "github.com/hyperledger/fabric-sdk-go/pkg/context/api"
+	"github.com/pkg/errors"
+)
+
+// CredentialUserStore is a UserStore that stores the users in a credential store (e.g. FileCredentialStore
+// or SQLCredentialStore). The private keys of the users are loaded from the crypto suite.
+type CredentialUserStore struct {
+	store       contextApi.CredentialStore
+	cryptoSuite core.CryptoSuite
+}
+
+// enrollmentTimer is implemented by credential stores that know when a user was stored
+type enrollmentTimer interface {
+	EnrollmentTime(key contextApi.UserKey) (time.Time, error)
+}
+
+// NewCredentialUserStore creates a new instance of CredentialUserStore
+func NewCredentialUserStore(store contextApi.CredentialStore, cryptoSuite core.CryptoSuite) (*CredentialUserStore, error) {
+	if store == nil {
+		return nil, errors.New("credential store is nil")
+	}
+	if cryptoSuite == nil {
+		return nil, errors.New("cryptoSuite is nil")
+	}
+	return &CredentialUserStore{
+		store:       store,
+		cryptoSuite: cryptoSuite,
+	}, nil
+}
+
+// Load returns the User stored in the store for a key.
+func (s *CredentialUserStore) Load(key contextApi.UserKey) (contextApi.User, error) {
+	userData, err := s.store.Load(key)
+	if err != nil {
+		return nil, err
+	}
+
+	keyRef := userData.KeyRef
+	if len(keyRef) == 0 {
+		pubKey, err := cryptoutil.GetPublicKeyFromCert(userData.EnrollmentCertificate, s.cryptoSuite)
+		if err != nil {
+			return nil, errors.WithMessage(err, "fetching public key from cert failed")
+		}
+		keyRef = pubKey.SKI()
+	}
+	pk, err := s.cryptoSuite.GetKey(keyRef)
+	if err != nil {
+		return nil, errors.Wrap(err, "cryptoSuite GetKey failed")
+	}
+	u := &User{
+		mspID:                 key.MspID,
+		name:                  key.Name,
+		enrollmentCertificate: userData.EnrollmentCertificate,
+		privateKey:            pk,
+	}
+	return u, nil
+}
+
+// Store stores a User into store. The SKI of the private key (if any) is stored as the key reference.
+func (s *CredentialUserStore) Store(user contextApi.User) error {
+	if user == nil {
+		return errors.New("user is nil")
+	}
+	userData := contextApi.UserData{
+		MspID:                 user.MspID(),
+		Name:                  user.Name(),
+		EnrollmentCertificate: user.EnrollmentCertificate(),
+	}
+	if pk := user.PrivateKey(); pk != nil {
+		userData.KeyRef = pk.SKI()
+	}
+	return s.store.Store(userData)
+}
+
+// Delete deletes a User from store
+func (s *CredentialUserStore) Delete(user contextApi.User) error {
+	if user == nil {
+		return errors.New("user is nil")
+	}
+	return s.store.Delete(userKeyFromUser(user))
+}
+
+// List returns the keys of the stored users
+func (s *CredentialUserStore) List() ([]contextApi.UserKey, error) {
+	return s.store.List()
+}
+
+// EnrollmentTime returns the time at which the user was last stored (i.e. enrolled).
+// If the user was not found, returns ErrUserNotFound. If the credential store doesn't record
+// the time at which users are stored, the zero time is returned.
+func (s *CredentialUserStore) EnrollmentTime(key contextApi.UserKey) (time.Time, error) {
+	timer, ok := s.store.(enrollmentTimer)
+	if !ok {
+		return time.Time{}, nil
+	}
+	return timer.EnrollmentTime(key)
+}
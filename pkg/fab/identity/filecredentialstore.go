@@ -0,0 +1,139 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package identity
+
+import (
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/keyvaluestore"
+
+	contextApi "github.com/hyperledger/fabric-sdk-go/pkg/context/api"
+	"github.com/pkg/errors"
+)
+
+const certFileSuffix = "-cert.pem"
+
+// FileCredentialStore stores each user in a separate file.
+// Only user's enrollment cert is stored, in pem format.
+// File naming is <user>@<org>-cert.pem
+type FileCredentialStore struct {
+	store *keyvaluestore.FileKeyValueStore
+}
+
+func userKeyFromUser(user contextApi.User) contextApi.UserKey {
+	return contextApi.UserKey{
+		MspID: user.MspID(),
+		Name:  user.Name(),
+	}
+}
+
+func storeKeyFromUserKey(key contextApi.UserKey) string {
+	return key.Name + "@" + key.MspID + certFileSuffix
+}
+
+// userKeyFromStoreKey returns the user key of a file name, the MSP ID is after the last '@'
+func userKeyFromStoreKey(storeKey string) (contextApi.UserKey, bool) {
+	if !strings.HasSuffix(storeKey, certFileSuffix) {
+		return contextApi.UserKey{}, false
+	}
+	key := strings.TrimSuffix(storeKey, certFileSuffix)
+	i := strings.LastIndex(key, "@")
+	if i <= 0 || i == len(key)-1 {
+		return contextApi.UserKey{}, false
+	}
+	return contextApi.UserKey{MspID: key[i+1:], Name: key[:i]}, true
+}
+
+// NewFileCredentialStore creates a new instance of FileCredentialStore
+func NewFileCredentialStore(path string) (*FileCredentialStore, error) {
+	if path == "" {
+		return nil, errors.New("path is empty")
+	}
+	store, err := keyvaluestore.New(&keyvaluestore.FileKeyValueStoreOptions{
+		Path: path,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "user store creation failed")
+	}
+	return &FileCredentialStore{store: store}, nil
+}
+
+// Load returns the data of the user stored in the store for a key.
+func (s *FileCredentialStore) Load(key contextApi.UserKey) (contextApi.UserData, error) {
+	cert, err := s.store.Load(storeKeyFromUserKey(key))
+	if err != nil {
+		if err == contextApi.ErrNotFound {
+			return contextApi.UserData{}, contextApi.ErrUserNotFound
+		}
+		return contextApi.UserData{}, err
+	}
+	certBytes, ok := cert.([]byte)
+	if !ok {
+		return contextApi.UserData{}, errors.New("user is not of proper type")
+	}
+	return contextApi.UserData{MspID: key.MspID, Name: key.Name, EnrollmentCertificate: certBytes}, nil
+}
+
+// Store stores the data of a user into store. The key reference isn't stored, the key is
+// found by the SKI of the enrollment certificate.
+func (s *FileCredentialStore) Store(user contextApi.UserData) error {
+	if len(user.EnrollmentCertificate) == 0 {
+		return errors.New("enrollment certificate is required")
+	}
+	key := storeKeyFromUserKey(contextApi.UserKey{MspID: user.MspID, Name: user.Name})
+	return s.store.Store(key, user.EnrollmentCertificate)
+}
+
+// Delete deletes a user from store
+func (s *FileCredentialStore) Delete(key contextApi.UserKey) error {
+	return s.store.Delete(storeKeyFromUserKey(key))
+}
+
+// List returns the keys of the stored users, sorted by MSP ID and name
+func (s *FileCredentialStore) List() ([]contextApi.UserKey, error) {
+	files, err := ioutil.ReadDir(s.store.GetPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "reading user store directory failed")
+	}
+	var keys []contextApi.UserKey
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		if key, ok := userKeyFromStoreKey(file.Name()); ok {
+			keys = append(keys, key)
+		}
+	}
+	sortUserKeys(keys)
+	return keys, nil
+}
+
+// EnrollmentTime returns the time at which the user was last stored (i.e. enrolled).
+// If the user was not found, returns ErrUserNotFound
+func (s *FileCredentialStore) EnrollmentTime(key contextApi.UserKey) (time.Time, error) {
+	t, err := s.store.ModTime(storeKeyFromUserKey(key))
+	if err == contextApi.ErrNotFound {
+		return time.Time{}, contextApi.ErrUserNotFound
+	}
+	return t, err
+}
+
+func sortUserKeys(keys []contextApi.UserKey) {
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].MspID != keys[j].MspID {
+			return keys[i].MspID < keys[j].MspID
+		}
+		return keys[i].Name < keys[j].Name
+	})
+}
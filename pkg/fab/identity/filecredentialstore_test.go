@@ -0,0 +1,64 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package identity
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api"
+)
+
+func TestFileCredentialStoreList(t *testing.T) {
+	cleanup(t, storePathRoot)
+	defer cleanup(t, storePathRoot)
+
+	store, err := NewFileCredentialStore(storePath)
+	if err != nil {
+		t.Fatalf("NewFileCredentialStore failed [%s]", err)
+	}
+
+	// The store directory doesn't exist until a user is stored
+	keys, err := store.List()
+	if err != nil || len(keys) != 0 {
+		t.Fatalf("List of empty store failed [%v] %v", err, keys)
+	}
+
+	users := []api.UserData{
+		{MspID: "Org2", Name: "user2", EnrollmentCertificate: []byte(testCert2)},
+		{MspID: "Org1", Name: "User1@org1.example.com", EnrollmentCertificate: []byte(testCert1)},
+	}
+	for _, user := range users {
+		if err := store.Store(user); err != nil {
+			t.Fatalf("Store %s failed [%s]", user.Name, err)
+		}
+	}
+	// Other files are ignored
+	if err := os.MkdirAll(path.Join(storePath, "subdir"), 0700); err != nil {
+		t.Fatalf("MkdirAll failed [%s]", err)
+	}
+	if err := ioutil.WriteFile(path.Join(storePath, "other.txt"), []byte("other"), 0600); err != nil {
+		t.Fatalf("WriteFile failed [%s]", err)
+	}
+
+	keys, err = store.List()
+	if err != nil {
+		t.Fatalf("List failed [%s]", err)
+	}
+	expected := []api.UserKey{{MspID: "Org1", Name: "User1@org1.example.com"}, {MspID: "Org2", Name: "user2"}}
+	if fmt.Sprint(keys) != fmt.Sprint(expected) {
+		t.Fatalf("List returned unexpected keys %v", keys)
+	}
+
+	userData, err := store.Load(expected[0])
+	if err != nil || string(userData.EnrollmentCertificate) != testCert1 {
+		t.Fatalf("Load failed [%v]", err)
+	}
+}
@@ -0,0 +1,156 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package identity
+
+import (
+	"database/sql"
+	"encoding/hex"
+	"time"
+
+	contextApi "github.com/hyperledger/fabric-sdk-go/pkg/context/api"
+	"github.com/pkg/errors"
+)
+
+// The statements use $n placeholders and the upsert syntax that are supported by both PostgreSQL and SQLite.
+const (
+	sqlCreateCredentialTable = `CREATE TABLE IF NOT EXISTS fabric_sdk_credentials (
+	msp_id VARCHAR(255) NOT NULL,
+	name VARCHAR(255) NOT NULL,
+	cert TEXT NOT NULL,
+	key_ref VARCHAR(255) NOT NULL,
+	updated BIGINT NOT NULL,
+	PRIMARY KEY (msp_id, name))`
+	sqlStoreCredential = `INSERT INTO fabric_sdk_credentials (msp_id, name, cert, key_ref, updated) VALUES ($1, $2, $3, $4, $5)
+	ON CONFLICT (msp_id, name) DO UPDATE SET cert = excluded.cert, key_ref = excluded.key_ref, updated = excluded.updated`
+	sqlLoadCredential     = `SELECT cert, key_ref FROM fabric_sdk_credentials WHERE msp_id = $1 AND name = $2`
+	sqlLoadEnrollmentTime = `SELECT updated FROM fabric_sdk_credentials WHERE msp_id = $1 AND name = $2`
+	sqlDeleteCredential   = `DELETE FROM fabric_sdk_credentials WHERE msp_id = $1 AND name = $2`
+	sqlListCredentials    = `SELECT msp_id, name FROM fabric_sdk_credentials ORDER BY msp_id, name`
+)
+
+// SQLCredentialStore stores the users in the fabric_sdk_credentials table of an SQL database (PostgreSQL
+// or SQLite), so that the users can be shared by several instances of a service. The enrollment
+// certificate and the SKI of the private key are stored; the private key stays in the key store
+// of the crypto suite.
+type SQLCredentialStore struct {
+	db     *sql.DB
+	ownsDB bool
+}
+
+// NewSQLCredentialStore creates a new instance of SQLCredentialStore that uses the given database.
+// The table of the store is created if it doesn't exist. The database isn't closed by the store.
+func NewSQLCredentialStore(db *sql.DB) (*SQLCredentialStore, error) {
+	if db == nil {
+		return nil, errors.New("database is nil")
+	}
+	if _, err := db.Exec(sqlCreateCredentialTable); err != nil {
+		return nil, errors.Wrap(err, "creating credential table failed")
+	}
+	return &SQLCredentialStore{db: db}, nil
+}
+
+// OpenSQLCredentialStore opens the database with the given database/sql driver (which must be
+// registered by the application, e.g. by importing github.com/lib/pq) and data source, and creates
+// a new instance of SQLCredentialStore that closes the database when it's closed.
+func OpenSQLCredentialStore(driverName, dataSourceName string) (*SQLCredentialStore, error) {
+	if driverName == "" || dataSourceName == "" {
+		return nil, errors.New("driver name and data source name are required")
+	}
+	db, err := sql.Open(driverName, dataSourceName)
+	if err != nil {
+		return nil, errors.Wrap(err, "opening credential database failed")
+	}
+	store, err := NewSQLCredentialStore(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	store.ownsDB = true
+	return store, nil
+}
+
+// Load returns the data of the user stored in the store for a key.
+func (s *SQLCredentialStore) Load(key contextApi.UserKey) (contextApi.UserData, error) {
+	var cert, keyRef string
+	err := s.db.QueryRow(sqlLoadCredential, key.MspID, key.Name).Scan(&cert, &keyRef)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return contextApi.UserData{}, contextApi.ErrUserNotFound
+		}
+		return contextApi.UserData{}, errors.Wrap(err, "loading user failed")
+	}
+	keyRefBytes, err := hex.DecodeString(keyRef)
+	if err != nil {
+		return contextApi.UserData{}, errors.Wrap(err, "decoding key reference failed")
+	}
+	return contextApi.UserData{MspID: key.MspID, Name: key.Name, EnrollmentCertificate: []byte(cert), KeyRef: keyRefBytes}, nil
+}
+
+// Store stores the data of a user into store, replacing the data that was stored for the user.
+func (s *SQLCredentialStore) Store(user contextApi.UserData) error {
+	if len(user.EnrollmentCertificate) == 0 {
+		return errors.New("enrollment certificate is required")
+	}
+	_, err := s.db.Exec(sqlStoreCredential, user.MspID, user.Name, string(user.EnrollmentCertificate),
+		hex.EncodeToString(user.KeyRef), time.Now().UnixNano())
+	if err != nil {
+		return errors.Wrap(err, "storing user failed")
+	}
+	return nil
+}
+
+// Delete deletes a user from store
+func (s *SQLCredentialStore) Delete(key contextApi.UserKey) error {
+	if _, err := s.db.Exec(sqlDeleteCredential, key.MspID, key.Name); err != nil {
+		return errors.Wrap(err, "deleting user failed")
+	}
+	return nil
+}
+
+// List returns the keys of the stored users, sorted by MSP ID and name
+func (s *SQLCredentialStore) List() ([]contextApi.UserKey, error) {
+	rows, err := s.db.Query(sqlListCredentials)
+	if err != nil {
+		return nil, errors.Wrap(err, "listing users failed")
+	}
+	defer rows.Close()
+
+	var keys []contextApi.UserKey
+	for rows.Next() {
+		var key contextApi.UserKey
+		if err := rows.Scan(&key.MspID, &key.Name); err != nil {
+			return nil, errors.Wrap(err, "listing users failed")
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "listing users failed")
+	}
+	return keys, nil
+}
+
+// EnrollmentTime returns the time at which the user was last stored (i.e. enrolled).
+// If the user was not found, returns ErrUserNotFound
+func (s *SQLCredentialStore) EnrollmentTime(key contextApi.UserKey) (time.Time, error) {
+	var updated int64
+	err := s.db.QueryRow(sqlLoadEnrollmentTime, key.MspID, key.Name).Scan(&updated)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return time.Time{}, contextApi.ErrUserNotFound
+		}
+		return time.Time{}, errors.Wrap(err, "loading enrollment time failed")
+	}
+	return time.Unix(0, updated), nil
+}
+
+// Close closes the database if it was opened by OpenSQLCredentialStore
+func (s *SQLCredentialStore) Close() error {
+	if !s.ownsDB {
+		return nil
+	}
+	return s.db.Close()
+}
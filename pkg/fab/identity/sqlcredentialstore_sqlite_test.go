@@ -0,0 +1,85 @@
+// +build sqlite
+
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package identity
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api"
+	// Registers the sqlite3 driver (requires cgo)
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// The tests of SQLCredentialStore are run against SQLite so that the statements are checked by a
+// real database engine: go test -tags sqlite ./pkg/fab/identity
+
+const sqliteDriver = "sqlite3"
+
+func TestSQLiteCredentialStore(t *testing.T) {
+	store, _, cleanup := newTestSQLiteCredentialStore(t)
+	defer cleanup()
+	testSQLCredentialStore(t, store)
+}
+
+func TestSQLiteCredentialUserStore(t *testing.T) {
+	store, _, cleanup := newTestSQLiteCredentialStore(t)
+	defer cleanup()
+	testSQLCredentialUserStore(t, store)
+}
+
+func TestSQLiteCredentialStoreConcurrency(t *testing.T) {
+	store, _, cleanup := newTestSQLiteCredentialStore(t)
+	defer cleanup()
+	testSQLCredentialStoreConcurrency(t, store)
+}
+
+func TestSQLiteCredentialStoreReopen(t *testing.T) {
+	store, dataSource, cleanup := newTestSQLiteCredentialStore(t)
+	defer cleanup()
+
+	user := api.UserData{MspID: "Org1", Name: "user1", EnrollmentCertificate: []byte(testCert1), KeyRef: []byte{1, 2, 3}}
+	if err := store.Store(user); err != nil {
+		t.Fatalf("Store failed [%s]", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close failed [%s]", err)
+	}
+
+	// The existing table is reused and the users stored before are loaded
+	store, err := OpenSQLCredentialStore(sqliteDriver, dataSource)
+	if err != nil {
+		t.Fatalf("OpenSQLCredentialStore failed [%s]", err)
+	}
+	defer store.Close()
+	if err := checkUserData(store, user); err != nil {
+		t.Fatalf("checkUserData failed [%s]", err)
+	}
+}
+
+// newTestSQLiteCredentialStore opens a store on a new SQLite database file. The busy timeout lets
+// the concurrent writers wait for the database lock instead of failing.
+func newTestSQLiteCredentialStore(t *testing.T) (*SQLCredentialStore, string, func()) {
+	dir, err := ioutil.TempDir("", "sqlcredentialstore")
+	if err != nil {
+		t.Fatalf("TempDir failed [%s]", err)
+	}
+	dataSource := "file:" + filepath.Join(dir, "credentials.db") + "?_busy_timeout=10000"
+	store, err := OpenSQLCredentialStore(sqliteDriver, dataSource)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatalf("OpenSQLCredentialStore failed [%s]", err)
+	}
+	return store, dataSource, func() {
+		store.Close()
+		os.RemoveAll(dir)
+	}
+}
@@ -0,0 +1,377 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package identity
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"testing"
+
+	fabricCaUtil "github.com/hyperledger/fabric-sdk-go/internal/github.com/hyperledger/fabric-ca/util"
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api"
+	"github.com/pkg/errors"
+)
+
+const fakeSQLDriver = "fakesql"
+
+func init() {
+	sql.Register(fakeSQLDriver, &fakeDriver{dbs: make(map[string]*fakeDB)})
+}
+
+func TestSQLCredentialStore(t *testing.T) {
+	testSQLCredentialStore(t, newTestSQLCredentialStore(t, "TestSQLCredentialStore"))
+}
+
+func TestSQLCredentialUserStore(t *testing.T) {
+	testSQLCredentialUserStore(t, newTestSQLCredentialStore(t, "TestSQLCredentialUserStore"))
+}
+
+func TestSQLCredentialStoreConcurrency(t *testing.T) {
+	testSQLCredentialStoreConcurrency(t, newTestSQLCredentialStore(t, "TestSQLCredentialStoreConcurrency"))
+}
+
+// The test functions below are also run against SQLite (see sqlcredentialstore_sqlite_test.go)
+func testSQLCredentialStore(t *testing.T, store *SQLCredentialStore) {
+	user1 := api.UserData{MspID: "Org1", Name: "user1", EnrollmentCertificate: []byte(testCert1), KeyRef: []byte{1, 2, 3}}
+	user2 := api.UserData{MspID: "Org2", Name: "user2", EnrollmentCertificate: []byte(testCert2)}
+	if err := store.Store(user1); err != nil {
+		t.Fatalf("Store %s failed [%s]", user1.Name, err)
+	}
+	if err := store.Store(user2); err != nil {
+		t.Fatalf("Store %s failed [%s]", user2.Name, err)
+	}
+	if err := store.Store(api.UserData{MspID: "Org1", Name: "user3"}); err == nil {
+		t.Fatal("Store without enrollment certificate should throw error")
+	}
+
+	if err := checkUserData(store, user1); err != nil {
+		t.Fatalf("checkUserData %s failed [%s]", user1.Name, err)
+	}
+	if err := checkUserData(store, user2); err != nil {
+		t.Fatalf("checkUserData %s failed [%s]", user2.Name, err)
+	}
+
+	// Storing a user again replaces its data
+	user1.EnrollmentCertificate = []byte(testCert2)
+	user1.KeyRef = []byte{4, 5, 6}
+	if err := store.Store(user1); err != nil {
+		t.Fatalf("Store %s failed [%s]", user1.Name, err)
+	}
+	if err := checkUserData(store, user1); err != nil {
+		t.Fatalf("checkUserData %s failed [%s]", user1.Name, err)
+	}
+
+	keys, err := store.List()
+	if err != nil {
+		t.Fatalf("List failed [%s]", err)
+	}
+	if fmt.Sprint(keys) != fmt.Sprint([]api.UserKey{{MspID: "Org1", Name: "user1"}, {MspID: "Org2", Name: "user2"}}) {
+		t.Fatalf("List returned unexpected keys %v", keys)
+	}
+
+	enrolled, err := store.EnrollmentTime(api.UserKey{MspID: "Org1", Name: "user1"})
+	if err != nil || enrolled.IsZero() {
+		t.Fatalf("EnrollmentTime failed [%v]", err)
+	}
+
+	if err := store.Delete(api.UserKey{MspID: "Org1", Name: "user1"}); err != nil {
+		t.Fatalf("Delete %s failed [%s]", user1.Name, err)
+	}
+	if _, err := store.Load(api.UserKey{MspID: "Org1", Name: "user1"}); err != api.ErrUserNotFound {
+		t.Fatalf("Load of deleted user should return ErrUserNotFound, got: %v", err)
+	}
+	if _, err := store.EnrollmentTime(api.UserKey{MspID: "Org1", Name: "user1"}); err != api.ErrUserNotFound {
+		t.Fatalf("EnrollmentTime of deleted user should return ErrUserNotFound, got: %v", err)
+	}
+}
+
+func testSQLCredentialUserStore(t *testing.T, store *SQLCredentialStore) {
+	cleanup(t, storePathRoot)
+	defer cleanup(t, storePathRoot)
+
+	crypto := crypto(t)
+	privateKey, err := fabricCaUtil.ImportBCCSPKeyFromPEMBytes([]byte(testPrivKey1), crypto, false)
+	if err != nil {
+		t.Fatalf("ImportBCCSPKeyFromPEMBytes failed [%s]", err)
+	}
+
+	userStore, err := NewCredentialUserStore(store, crypto)
+	if err != nil {
+		t.Fatalf("NewCredentialUserStore failed [%s]", err)
+	}
+
+	user := &User{mspID: "Org1", name: "user1", enrollmentCertificate: []byte(testCert1), privateKey: privateKey}
+	if err := userStore.Store(user); err != nil {
+		t.Fatalf("Store failed [%s]", err)
+	}
+
+	// The private key is loaded from the crypto suite by the stored key reference
+	loaded, err := userStore.Load(userKeyFromUser(user))
+	if err != nil {
+		t.Fatalf("Load failed [%s]", err)
+	}
+	if !bytes.Equal(loaded.EnrollmentCertificate(), user.EnrollmentCertificate()) {
+		t.Fatal("Load returned wrong enrollment certificate")
+	}
+	if loaded.PrivateKey() == nil || !bytes.Equal(loaded.PrivateKey().SKI(), privateKey.SKI()) {
+		t.Fatal("Load returned wrong private key")
+	}
+
+	if err := userStore.Delete(user); err != nil {
+		t.Fatalf("Delete failed [%s]", err)
+	}
+	if _, err := userStore.Load(userKeyFromUser(user)); err != api.ErrUserNotFound {
+		t.Fatalf("Load of deleted user should return ErrUserNotFound, got: %v", err)
+	}
+}
+
+func testSQLCredentialStoreConcurrency(t *testing.T, store *SQLCredentialStore) {
+	const routines = 10
+	const iterations = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, routines*iterations)
+	for r := 0; r < routines; r++ {
+		wg.Add(1)
+		go func(r int) {
+			defer wg.Done()
+			for i := 0; i < iterations; i++ {
+				// Each routine stores its own user and the user shared by all routines
+				own := api.UserData{MspID: "Org1", Name: fmt.Sprintf("user%d", r), EnrollmentCertificate: []byte(testCert1), KeyRef: []byte{byte(i)}}
+				shared := api.UserData{MspID: "Org1", Name: "shared", EnrollmentCertificate: []byte(testCert2), KeyRef: []byte{byte(r)}}
+				for _, user := range []api.UserData{own, shared} {
+					if err := store.Store(user); err != nil {
+						errs <- err
+						return
+					}
+				}
+				if err := checkUserData(store, own); err != nil {
+					errs <- err
+					return
+				}
+				if _, err := store.Load(api.UserKey{MspID: "Org1", Name: "shared"}); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}(r)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("Concurrent Store/Load failed [%s]", err)
+	}
+
+	keys, err := store.List()
+	if err != nil {
+		t.Fatalf("List failed [%s]", err)
+	}
+	if len(keys) != routines+1 {
+		t.Fatalf("Expected %d users but got %d", routines+1, len(keys))
+	}
+}
+
+func TestOpenSQLCredentialStore(t *testing.T) {
+	if _, err := NewSQLCredentialStore(nil); err == nil {
+		t.Fatal("NewSQLCredentialStore(nil) should throw error")
+	}
+	if _, err := OpenSQLCredentialStore("", "TestOpenSQLCredentialStore"); err == nil {
+		t.Fatal("OpenSQLCredentialStore without driver should throw error")
+	}
+	if _, err := OpenSQLCredentialStore("unknowndriver", "TestOpenSQLCredentialStore"); err == nil {
+		t.Fatal("OpenSQLCredentialStore with unknown driver should throw error")
+	}
+
+	// The database opened by the store is closed when the store is closed
+	store, err := OpenSQLCredentialStore(fakeSQLDriver, "TestOpenSQLCredentialStore")
+	if err != nil {
+		t.Fatalf("OpenSQLCredentialStore failed [%s]", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close failed [%s]", err)
+	}
+	if _, err := store.List(); err == nil {
+		t.Fatal("List should fail once the store is closed")
+	}
+}
+
+func newTestSQLCredentialStore(t *testing.T, dataSource string) *SQLCredentialStore {
+	db, err := sql.Open(fakeSQLDriver, dataSource)
+	if err != nil {
+		t.Fatalf("sql.Open failed [%s]", err)
+	}
+	store, err := NewSQLCredentialStore(db)
+	if err != nil {
+		t.Fatalf("NewSQLCredentialStore failed [%s]", err)
+	}
+	return store
+}
+
+func checkUserData(store api.CredentialStore, expected api.UserData) error {
+	userData, err := store.Load(api.UserKey{MspID: expected.MspID, Name: expected.Name})
+	if err != nil {
+		return err
+	}
+	if userData.MspID != expected.MspID || userData.Name != expected.Name {
+		return errors.Errorf("loaded user %s@%s instead of %s@%s", userData.Name, userData.MspID, expected.Name, expected.MspID)
+	}
+	if !bytes.Equal(userData.EnrollmentCertificate, expected.EnrollmentCertificate) {
+		return errors.New("loaded wrong enrollment certificate")
+	}
+	if !bytes.Equal(userData.KeyRef, expected.KeyRef) {
+		return errors.Errorf("loaded key reference %v instead of %v", userData.KeyRef, expected.KeyRef)
+	}
+	return nil
+}
+
+// fakeDriver is an in-memory database/sql driver that executes the statements of SQLCredentialStore
+type fakeDriver struct {
+	mutex sync.Mutex
+	dbs   map[string]*fakeDB
+}
+
+type fakeDB struct {
+	mutex   sync.Mutex
+	created bool
+	rows    map[api.UserKey][]driver.Value
+}
+
+func (d *fakeDriver) Open(name string) (driver.Conn, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	db, ok := d.dbs[name]
+	if !ok {
+		db = &fakeDB{rows: make(map[api.UserKey][]driver.Value)}
+		d.dbs[name] = db
+	}
+	return &fakeConn{db: db}, nil
+}
+
+type fakeConn struct {
+	db *fakeDB
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{db: c.db, query: query}, nil
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions aren't supported")
+}
+
+type fakeStmt struct {
+	db    *fakeDB
+	query string
+}
+
+func (s *fakeStmt) Close() error {
+	return nil
+}
+
+func (s *fakeStmt) NumInput() int {
+	return -1
+}
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.db.mutex.Lock()
+	defer s.db.mutex.Unlock()
+
+	switch s.query {
+	case sqlCreateCredentialTable:
+		s.db.created = true
+		return driver.RowsAffected(0), nil
+	case sqlStoreCredential:
+		if err := s.db.checkTable(); err != nil {
+			return nil, err
+		}
+		s.db.rows[userKeyFromArgs(args)] = args
+		return driver.RowsAffected(1), nil
+	case sqlDeleteCredential:
+		if err := s.db.checkTable(); err != nil {
+			return nil, err
+		}
+		delete(s.db.rows, userKeyFromArgs(args))
+		return driver.RowsAffected(1), nil
+	}
+	return nil, errors.Errorf("unexpected statement: %s", s.query)
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.db.mutex.Lock()
+	defer s.db.mutex.Unlock()
+
+	if err := s.db.checkTable(); err != nil {
+		return nil, err
+	}
+	switch s.query {
+	case sqlLoadCredential, sqlLoadEnrollmentTime:
+		row, ok := s.db.rows[userKeyFromArgs(args)]
+		if !ok {
+			return &fakeRows{}, nil
+		}
+		if s.query == sqlLoadCredential {
+			return &fakeRows{values: [][]driver.Value{{row[2], row[3]}}}, nil
+		}
+		return &fakeRows{values: [][]driver.Value{{row[4]}}}, nil
+	case sqlListCredentials:
+		var keys []api.UserKey
+		for key := range s.db.rows {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
+		rows := &fakeRows{}
+		for _, key := range keys {
+			rows.values = append(rows.values, []driver.Value{key.MspID, key.Name})
+		}
+		return rows, nil
+	}
+	return nil, errors.Errorf("unexpected query: %s", s.query)
+}
+
+func (db *fakeDB) checkTable() error {
+	if !db.created {
+		return errors.New("table fabric_sdk_credentials doesn't exist")
+	}
+	return nil
+}
+
+func userKeyFromArgs(args []driver.Value) api.UserKey {
+	return api.UserKey{MspID: args[0].(string), Name: args[1].(string)}
+}
+
+type fakeRows struct {
+	values [][]driver.Value
+	next   int
+}
+
+func (r *fakeRows) Columns() []string {
+	if len(r.values) == 0 {
+		return nil
+	}
+	return make([]string, len(r.values[0]))
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.values) {
+		return io.EOF
+	}
+	copy(dest, r.values[r.next])
+	r.next++
+	return nil
+}
@@ -21,10 +21,13 @@ import (
 // in order to transact with Fabric.
 func (im *IdentityManager) initCAClient() error {
 	if im.caClient == nil {
-		caClient, err := newCAClient(im.orgName, im.config, im.cryptoSuite)
+		caClient, err := newCAClient(im.orgName, im.config, im.cryptoSuite, im.httpOpts)
 		if err != nil {
 			return errors.Wrapf(err, "failed to initialie Fabric CA client")
 		}
+		if im.signingMgr != nil {
+			caClient.TokenSigner = im.signingMgr.Sign
+		}
 		im.caClient = caClient
 		caConfig, err := im.config.CAConfig(im.orgName)
 		if err != nil {
@@ -35,11 +38,19 @@ func (im *IdentityManager) initCAClient() error {
 	return nil
 }
 
-func newCAClient(org string, config config.Config, cryptoSuite core.CryptoSuite) (*calib.Client, error) {
+func newCAClient(org string, config config.Config, cryptoSuite core.CryptoSuite, opts httpOpts) (*calib.Client, error) {
 
 	// Create new Fabric-ca client without configs
 	c := &calib.Client{
-		Config: &calib.ClientConfig{},
+		Config:     &calib.ClientConfig{},
+		HTTPClient: opts.client,
+		Transport:  opts.transport,
+	}
+	if opts.requestHook != nil {
+		c.RequestHook = calib.RequestHook(opts.requestHook)
+	}
+	if opts.responseHook != nil {
+		c.ResponseHook = calib.ResponseHook(opts.responseHook)
 	}
 
 	conf, err := config.CAConfig(org)
@@ -55,21 +66,35 @@ func newCAClient(org string, config config.Config, cryptoSuite core.CryptoSuite)
 	c.Config.CAName = conf.CAName
 	//set server URL
 	c.Config.URL = urlutil.ToAddress(conf.URL)
-	//certs file list
-	c.Config.TLS.CertFiles, err = config.CAServerCertPaths(org)
-	if err != nil {
-		return nil, err
+	//server certs, embedded in the config or file list
+	if len(conf.TLSCACerts.Pem) > 0 {
+		for _, pem := range conf.TLSCACerts.Pem {
+			c.Config.TLS.CertPems = append(c.Config.TLS.CertPems, []byte(pem))
+		}
+	} else {
+		c.Config.TLS.CertFiles, err = config.CAServerCertPaths(org)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	// set key file and cert file
-	c.Config.TLS.Client.CertFile, err = config.CAClientCertPath(org)
-	if err != nil {
-		return nil, err
+	// set client cert and key, embedded in the config or files
+	if conf.TLSCACerts.Client.Cert.Pem != "" {
+		c.Config.TLS.Client.CertPem = []byte(conf.TLSCACerts.Client.Cert.Pem)
+	} else {
+		c.Config.TLS.Client.CertFile, err = config.CAClientCertPath(org)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	c.Config.TLS.Client.KeyFile, err = config.CAClientKeyPath(org)
-	if err != nil {
-		return nil, err
+	if conf.TLSCACerts.Client.Key.Pem != "" {
+		c.Config.TLS.Client.KeyPem = []byte(conf.TLSCACerts.Client.Key.Pem)
+	} else {
+		c.Config.TLS.Client.KeyFile, err = config.CAClientKeyPath(org)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// get Client configs
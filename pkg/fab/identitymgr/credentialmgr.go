@@ -11,6 +11,7 @@ import (
 	"io/ioutil"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/hyperledger/fabric-sdk-go/pkg/core/config/cryptoutil"
 
@@ -77,6 +78,29 @@ func (mgr *IdentityManager) GetSigningIdentity(userName string) (*api.SigningIde
 	return signingIdentity, nil
 }
 
+// enrollmentTimer is implemented by user stores that know when a user was enrolled
+type enrollmentTimer interface {
+	EnrollmentTime(key api.UserKey) (time.Time, error)
+}
+
+// EnrollmentTime returns the time at which the user was last enrolled, i.e. stored in the credential store.
+// The zero time is returned if the user's credentials aren't in the credential store (e.g. they are embedded
+// in the configuration) or if the credential store doesn't record enrollment times.
+func (mgr *IdentityManager) EnrollmentTime(userName string) (time.Time, error) {
+	timer, ok := mgr.userStore.(enrollmentTimer)
+	if !ok {
+		return time.Time{}, nil
+	}
+	t, err := timer.EnrollmentTime(api.UserKey{MspID: mgr.orgMspID, Name: userName})
+	if err != nil {
+		if err == api.ErrUserNotFound {
+			return time.Time{}, nil
+		}
+		return time.Time{}, errors.Wrapf(err, "getting enrollment time failed")
+	}
+	return t, nil
+}
+
 func (mgr *IdentityManager) getEmbeddedCertBytes(userName string) ([]byte, error) {
 	certPem := mgr.embeddedUsers[strings.ToLower(userName)].Cert.Pem
 	certPath := mgr.embeddedUsers[strings.ToLower(userName)].Cert.Path
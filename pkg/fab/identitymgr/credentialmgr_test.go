@@ -105,6 +105,9 @@ func TestCredentialManager(t *testing.T) {
 	if err := checkSigningIdentity(credentialMgr, testUserName); err != api.ErrUserNotFound {
 		t.Fatalf("expected ErrUserNotFound, got: %s", err)
 	}
+	if enrolled, err := credentialMgr.EnrollmentTime(testUserName); err != nil || !enrolled.IsZero() {
+		t.Fatalf("expected no enrollment time before enrollment, got: %s, %v", enrolled, err)
+	}
 
 	// "Manually" enroll User1
 	_, err = fabricCaUtil.ImportBCCSPKeyFromPEMBytes([]byte(testPrivKey), cryptoSuite, false)
@@ -122,6 +125,9 @@ func TestCredentialManager(t *testing.T) {
 	if err := checkSigningIdentity(credentialMgr, testUserName); err != nil {
 		t.Fatalf("checkSigningIdentity failed: %s", err)
 	}
+	if enrolled, err := credentialMgr.EnrollmentTime(testUserName); err != nil || enrolled.IsZero() {
+		t.Fatalf("expected enrollment time after enrollment, got: %s, %v", enrolled, err)
+	}
 }
 
 func checkSigningIdentity(credentialMgr api.CredentialManager, user string) error {
@@ -26,6 +26,9 @@ import (
 
 var logger = logging.NewLogger("fabric_sdk_go")
 
+// ecertSuffix is the suffix of attribute values that are added to the enrollment certificate by default
+const ecertSuffix = ":ecert"
+
 // IdentityManager implements fab/IdentityManager
 type IdentityManager struct {
 	orgName         string
@@ -39,16 +42,19 @@ type IdentityManager struct {
 	userStore       contextApi.UserStore
 
 	// CA Client state
-	caClient  *calib.Client
-	registrar config.EnrollCredentials
+	caClient   *calib.Client
+	registrar  config.EnrollCredentials
+	httpOpts   httpOpts
+	signingMgr contextApi.SigningManager
 }
 
 // New creates a new instance of IdentityManager
 // @param {string} organization for this CA
 // @param {Config} client config for fabric-ca services
+// @param {[]Option} options
 // @returns {IdentityManager} IdentityManager instance
 // @returns {error} error, if any
-func New(orgName string, config config.Config, cryptoSuite core.CryptoSuite) (*IdentityManager, error) {
+func New(orgName string, config config.Config, cryptoSuite core.CryptoSuite, opts ...Option) (*IdentityManager, error) {
 
 	netConfig, err := config.NetworkConfig()
 	if err != nil {
@@ -85,7 +91,7 @@ func New(orgName string, config config.Config, cryptoSuite core.CryptoSuite) (*I
 		logger.Warnf("Cryptopath not provided for organization [%s], MSP stores not created", orgName)
 	}
 
-	// In the future, shared UserStore from the SDK context will be used
+	// The file store may be replaced by a shared credential store (see WithCredentialStore)
 	var userStore contextApi.UserStore
 	if config.CredentialStorePath() != "" {
 		userStore, err = identity.NewCertFileUserStore(config.CredentialStorePath(), cryptoSuite)
@@ -111,6 +117,13 @@ func New(orgName string, config config.Config, cryptoSuite core.CryptoSuite) (*I
 		userStore:       userStore,
 		// CA Client state is created lazily, when (if) needed
 	}
+
+	for _, opt := range opts {
+		if err := opt(mgr); err != nil {
+			return nil, errors.WithMessage(err, "failed to apply option")
+		}
+	}
+
 	return mgr, nil
 }
 
@@ -124,36 +137,53 @@ func (im *IdentityManager) CAName() string {
 // enrollmentSecret The secret associated with the enrollment ID
 // Returns X509 certificate
 func (im *IdentityManager) Enroll(enrollmentID string, enrollmentSecret string) (core.Key, []byte, error) {
+	return im.EnrollWithRequest(&fab.EnrollmentRequest{Name: enrollmentID, Secret: enrollmentSecret})
+}
+
+// EnrollWithRequest enrolls a registered user in order to receive a signed X509 certificate.
+// The private key is generated by the crypto suite and the user is stored in the credential store.
+// request: Enrollment Request (enrollment ID, secret and the optional CSR hosts, profile and attribute requests)
+// Returns X509 certificate
+func (im *IdentityManager) EnrollWithRequest(request *fab.EnrollmentRequest) (core.Key, []byte, error) {
 	if err := im.initCAClient(); err != nil {
 		return nil, nil, err
 	}
-	if enrollmentID == "" {
+	if request == nil {
+		return nil, nil, errors.New("enrollment request is required")
+	}
+	if request.Name == "" {
 		return nil, nil, errors.New("enrollmentID is required")
 	}
-	if enrollmentSecret == "" {
+	if request.Secret == "" {
 		return nil, nil, errors.New("enrollmentSecret is required")
 	}
-	// TODO add attributes
+	if im.userStore == nil {
+		return nil, nil, errors.New("credential store is required to store the enrolled user")
+	}
 	careq := &caapi.EnrollmentRequest{
-		CAName: im.caClient.Config.CAName,
-		Name:   enrollmentID,
-		Secret: enrollmentSecret,
+		CAName:   im.caClient.Config.CAName,
+		Name:     request.Name,
+		Secret:   request.Secret,
+		Profile:  request.Profile,
+		AttrReqs: attributeRequests(request.AttrReqs),
+	}
+	if len(request.Hosts) > 0 {
+		careq.CSR = &caapi.CSRInfo{Hosts: request.Hosts}
 	}
 	caresp, err := im.caClient.Enroll(careq)
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "enroll failed")
 	}
-	user := identity.NewUser(im.orgMspID, enrollmentID)
-	user.SetEnrollmentCertificate(caresp.Identity.GetECert().Cert())
-	user.SetPrivateKey(caresp.Identity.GetECert().Key())
-	err = im.userStore.Store(user)
-	if err != nil {
+	key := caresp.Identity.GetECert().Key()
+	cert := caresp.Identity.GetECert().Cert()
+	if err := im.storeUser(im.orgMspID, request.Name, key, cert); err != nil {
 		return nil, nil, errors.Wrap(err, "enroll failed")
 	}
-	return caresp.Identity.GetECert().Key(), caresp.Identity.GetECert().Cert(), nil
+	return key, cert, nil
 }
 
-// Reenroll an enrolled user in order to receive a signed X509 certificate
+// Reenroll an enrolled user in order to receive a signed X509 certificate.
+// If a credential store is configured then the reenrolled user is stored in it.
 // Returns X509 certificate
 func (im *IdentityManager) Reenroll(user contextApi.User) (core.Key, []byte, error) {
 	if err := im.initCAClient(); err != nil {
@@ -180,7 +210,14 @@ func (im *IdentityManager) Reenroll(user contextApi.User) (core.Key, []byte, err
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "reenroll failed")
 	}
-	return reenrollmentResponse.Identity.GetECert().Key(), reenrollmentResponse.Identity.GetECert().Cert(), nil
+	key := reenrollmentResponse.Identity.GetECert().Key()
+	cert := reenrollmentResponse.Identity.GetECert().Cert()
+	if im.userStore != nil {
+		if err := im.storeUser(user.MspID(), user.Name(), key, cert); err != nil {
+			return nil, nil, errors.Wrap(err, "reenroll failed")
+		}
+	}
+	return key, cert, nil
 }
 
 // Register a User with the Fabric CA
@@ -197,6 +234,13 @@ func (im *IdentityManager) Register(request *fab.RegistrationRequest) (string, e
 	if request.Name == "" {
 		return "", errors.New("request.Name is required")
 	}
+	if err := validateAffiliation(request.Affiliation); err != nil {
+		return "", err
+	}
+	attributes, err := registrationAttributes(request.Attributes)
+	if err != nil {
+		return "", err
+	}
 	registrar, err := im.getRegistrar()
 	if err != nil {
 		return "", errors.Wrapf(err, "failed to get registrar")
@@ -207,11 +251,6 @@ func (im *IdentityManager) Register(request *fab.RegistrationRequest) (string, e
 		return "", errors.Wrap(err, "failed to create request for signing identity")
 	}
 	// Contruct request for Fabric CA client
-	var attributes []caapi.Attribute
-	for i := range request.Attributes {
-		attributes = append(attributes, caapi.Attribute{Name: request.
-			Attributes[i].Key, Value: request.Attributes[i].Value})
-	}
 	var req = caapi.RegistrationRequest{
 		CAName:         request.CAName,
 		Name:           request.Name,
@@ -241,6 +280,9 @@ func (im *IdentityManager) Revoke(request *fab.RevocationRequest) (*fab.Revocati
 	if request == nil {
 		return nil, errors.New("revocation request is required")
 	}
+	if request.Name == "" && (request.Serial == "" || request.AKI == "") {
+		return nil, errors.New("either request.Name or both request.Serial and request.AKI are required")
+	}
 	registrar, err := im.getRegistrar()
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to ret registrar")
@@ -257,6 +299,7 @@ func (im *IdentityManager) Revoke(request *fab.RevocationRequest) (*fab.Revocati
 		Serial: request.Serial,
 		AKI:    request.AKI,
 		Reason: request.Reason,
+		GenCRL: request.GenCRL,
 	}
 
 	resp, err := identity.Revoke(&req)
@@ -273,7 +316,6 @@ func (im *IdentityManager) Revoke(request *fab.RevocationRequest) (*fab.Revocati
 			})
 	}
 
-	// TODO complete the response mapping
 	return &fab.RevocationResponse{
 		RevokedCerts: revokedCerts,
 		CRL:          resp.CRL,
@@ -313,3 +355,57 @@ func (im *IdentityManager) createSigningIdentity(user contextApi.User) (*calib.I
 	}
 	return im.caClient.NewIdentity(key, cert)
 }
+
+// storeUser stores the user with the given credentials in the credential store
+func (im *IdentityManager) storeUser(mspID string, name string, key core.Key, cert []byte) error {
+	user := identity.NewUser(mspID, name)
+	user.SetEnrollmentCertificate(cert)
+	user.SetPrivateKey(key)
+	return im.userStore.Store(user)
+}
+
+// attributeRequests converts the attribute requests to the requests of the CA client
+func attributeRequests(attrReqs []fab.AttributeRequest) []*caapi.AttributeRequest {
+	var reqs []*caapi.AttributeRequest
+	for _, attrReq := range attrReqs {
+		reqs = append(reqs, &caapi.AttributeRequest{Name: attrReq.Name, Optional: attrReq.Optional})
+	}
+	return reqs
+}
+
+// registrationAttributes converts the attributes to the attributes of the CA client. The name of an attribute
+// is its Name (or its Key if Name is empty) and the ":ecert" suffix of its value sets ECert.
+func registrationAttributes(attrs []fab.Attribute) ([]caapi.Attribute, error) {
+	var attributes []caapi.Attribute
+	for _, attr := range attrs {
+		name := attr.Name
+		if name == "" {
+			name = attr.Key
+		}
+		if name == "" {
+			return nil, errors.New("attribute name is required")
+		}
+		value := attr.Value
+		ecert := attr.ECert
+		if strings.HasSuffix(value, ecertSuffix) {
+			value = strings.TrimSuffix(value, ecertSuffix)
+			ecert = true
+		}
+		attributes = append(attributes, caapi.Attribute{Name: name, Value: value, ECert: ecert})
+	}
+	return attributes, nil
+}
+
+// validateAffiliation checks that the affiliation is empty (the affiliation of the registrar is used)
+// or a dot separated path of non-empty names, e.g. org1.department1
+func validateAffiliation(affiliation string) error {
+	if affiliation == "" {
+		return nil
+	}
+	for _, name := range strings.Split(affiliation, ".") {
+		if name == "" || strings.ContainsAny(name, " \t\n") {
+			return errors.Errorf("invalid affiliation %q", affiliation)
+		}
+	}
+	return nil
+}
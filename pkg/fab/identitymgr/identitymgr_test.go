@@ -7,9 +7,17 @@ SPDX-License-Identifier: Apache-2.0
 package identitymgr
 
 import (
+	"bytes"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
 	"fmt"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -17,6 +25,7 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/pkg/errors"
 
+	caapi "github.com/hyperledger/fabric-sdk-go/internal/github.com/hyperledger/fabric-ca/api"
 	contextApi "github.com/hyperledger/fabric-sdk-go/pkg/context/api"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core/mocks"
@@ -156,6 +165,109 @@ func TestEnrollAndReenroll(t *testing.T) {
 
 }
 
+// enrollmentRequestNet is the body of an enrollment request received by the CA
+type enrollmentRequestNet struct {
+	Hosts    []string `json:"hosts"`
+	Request  string   `json:"certificate_request"`
+	Profile  string   `json:"profile"`
+	CAName   string   `json:"caname"`
+	AttrReqs []struct {
+		Name     string `json:"name"`
+		Optional bool   `json:"optional"`
+	} `json:"attr_reqs"`
+}
+
+// TestEnrollWithRequest tests that the CSR sent to the CA contains the options of the request and that
+// the enrolled user is stored
+func TestEnrollWithRequest(t *testing.T) {
+	var received []enrollmentRequestNet
+	ca := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var body enrollmentRequestNet
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		received = append(received, body)
+		mocks.Enroll(w, req)
+	}))
+	defer ca.Close()
+
+	caURL, err := url.Parse(ca.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse CA URL: %v", err)
+	}
+	identityManager, err := New(org1, fullConfig, cryptoSuite, WithHTTPTransport(&http.Transport{Proxy: http.ProxyURL(caURL)}))
+	if err != nil {
+		t.Fatalf("New return error: %v", err)
+	}
+
+	_, _, err = identityManager.EnrollWithRequest(nil)
+	if err == nil {
+		t.Fatalf("Expected error with nil request")
+	}
+
+	request := &fab.EnrollmentRequest{
+		Name:     "csrUser",
+		Secret:   "csrSecret",
+		Hosts:    []string{"peer0.org1.example.com", "127.0.0.1"},
+		Profile:  "tls",
+		AttrReqs: []fab.AttributeRequest{{Name: "hf.Affiliation"}, {Name: "app.role", Optional: true}},
+	}
+	_, cert, err := identityManager.EnrollWithRequest(request)
+	if err != nil {
+		t.Fatalf("EnrollWithRequest return error: %v", err)
+	}
+
+	if len(received) != 1 {
+		t.Fatalf("Expected 1 enrollment request but got %d", len(received))
+	}
+	body := received[0]
+	if body.Profile != "tls" || !reflect.DeepEqual(body.Hosts, request.Hosts) {
+		t.Fatalf("Unexpected profile %s or hosts %v", body.Profile, body.Hosts)
+	}
+	if len(body.AttrReqs) != 2 || body.AttrReqs[0].Name != "hf.Affiliation" || body.AttrReqs[0].Optional || !body.AttrReqs[1].Optional {
+		t.Fatalf("Unexpected attribute requests %v", body.AttrReqs)
+	}
+
+	block, _ := pem.Decode([]byte(body.Request))
+	if block == nil {
+		t.Fatalf("Failed to decode CSR PEM")
+	}
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		t.Fatalf("Failed to parse CSR: %v", err)
+	}
+	if err := csr.CheckSignature(); err != nil {
+		t.Fatalf("CSR signature verification failed: %v", err)
+	}
+	if csr.Subject.CommonName != "csrUser" {
+		t.Fatalf("Expected CSR common name csrUser but got %s", csr.Subject.CommonName)
+	}
+	if !reflect.DeepEqual(csr.DNSNames, []string{"peer0.org1.example.com"}) || len(csr.IPAddresses) != 1 || csr.IPAddresses[0].String() != "127.0.0.1" {
+		t.Fatalf("Unexpected CSR hosts %v %v", csr.DNSNames, csr.IPAddresses)
+	}
+
+	// The enrolled user is stored in the credential store
+	signingIdentity, err := identityManager.GetSigningIdentity("csrUser")
+	if err != nil {
+		t.Fatalf("GetSigningIdentity return error: %v", err)
+	}
+	if !bytes.Equal(cert, signingIdentity.EnrollmentCert) {
+		t.Fatalf("Expected the enrollment certificate to be stored")
+	}
+
+	// The reenrolled user is stored in the credential store
+	user := mocks.NewMockUser("csrUser")
+	user.SetEnrollmentCertificate(signingIdentity.EnrollmentCert)
+	user.SetPrivateKey(signingIdentity.PrivateKey)
+	if _, _, err := identityManager.Reenroll(user); err != nil {
+		t.Fatalf("Reenroll return error: %v", err)
+	}
+	if _, err := userStore.Load(contextApi.UserKey{Name: "csrUser"}); err != nil {
+		t.Fatalf("Expected the reenrolled user to be stored: %v", err)
+	}
+}
+
 // TestRegister tests multiple scenarios of registering a test (mocked or nil user) and their certs
 func TestRegister(t *testing.T) {
 
@@ -216,7 +328,165 @@ func TestRevoke(t *testing.T) {
 
 	_, err = identityManager.Revoke(&fab.RevocationRequest{})
 	if err == nil {
-		t.Fatalf("Expected decoding error with test cert")
+		t.Fatalf("Expected error without name or serial and AKI")
+	}
+}
+
+// caRequest is a request received by the CA of newRecordingCA
+type caRequest struct {
+	token string
+	body  []byte
+}
+
+// newRecordingCA starts a mock CA that records the requests by endpoint and returns an identity manager
+// that sends its requests to the mock CA
+func newRecordingCA(t *testing.T) (*IdentityManager, map[string][]caRequest, func()) {
+	received := make(map[string][]caRequest)
+	ca := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		endpoint := req.URL.Path[strings.LastIndex(req.URL.Path, "/")+1:]
+		received[endpoint] = append(received[endpoint], caRequest{token: req.Header.Get("Authorization"), body: body})
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		switch endpoint {
+		case "register":
+			mocks.Register(w, req)
+		case "revoke":
+			mocks.Revoke(w, req)
+		default:
+			mocks.Enroll(w, req)
+		}
+	}))
+
+	caURL, err := url.Parse(ca.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse CA URL: %v", err)
+	}
+	identityManager, err := New(org1, fullConfig, cryptoSuite, WithHTTPTransport(&http.Transport{Proxy: http.ProxyURL(caURL)}))
+	if err != nil {
+		t.Fatalf("New return error: %v", err)
+	}
+	return identityManager, received, ca.Close
+}
+
+// TestRegisterRequest tests that the registration request is signed by the registrar and contains the attributes
+func TestRegisterRequest(t *testing.T) {
+	identityManager, received, closeCA := newRecordingCA(t)
+	defer closeCA()
+
+	request := &fab.RegistrationRequest{
+		Name:           "peer1",
+		Type:           "peer",
+		Affiliation:    "org1.department1",
+		MaxEnrollments: 2,
+		Secret:         "peer1pw",
+		Attributes: []fab.Attribute{
+			{Name: "app.role", Value: "admin:ecert"},
+			{Key: "app.level", Value: "2"},
+			{Name: "app.region", Value: "west", ECert: true},
+		},
+	}
+	secret, err := identityManager.Register(request)
+	if err != nil {
+		t.Fatalf("Register return error: %v", err)
+	}
+	if secret != "mockSecretValue" {
+		t.Fatalf("Register return wrong secret %s", secret)
+	}
+
+	if len(received["register"]) != 1 {
+		t.Fatalf("Expected 1 registration request but got %d", len(received["register"]))
+	}
+	registration := received["register"][0]
+	if err := verifyToken(registration.token, registration.body); err != nil {
+		t.Fatalf("Token verification failed: %v", err)
+	}
+	var body caapi.RegistrationRequest
+	if err := json.Unmarshal(registration.body, &body); err != nil {
+		t.Fatalf("Failed to unmarshal registration request: %v", err)
+	}
+	expected := caapi.RegistrationRequest{
+		Name:           "peer1",
+		Type:           "peer",
+		Affiliation:    "org1.department1",
+		MaxEnrollments: 2,
+		Secret:         "peer1pw",
+		Attributes: []caapi.Attribute{
+			{Name: "app.role", Value: "admin", ECert: true},
+			{Name: "app.level", Value: "2"},
+			{Name: "app.region", Value: "west", ECert: true},
+		},
+	}
+	if !reflect.DeepEqual(expected, body) {
+		t.Fatalf("Unexpected registration request %+v", body)
+	}
+
+	// Invalid requests aren't sent to the CA
+	invalid := []*fab.RegistrationRequest{
+		{Name: "peer2", Affiliation: "org1..department1"},
+		{Name: "peer2", Affiliation: "org1.depart ment1"},
+		{Name: "peer2", Attributes: []fab.Attribute{{Value: "admin"}}},
+	}
+	for _, request := range invalid {
+		if _, err := identityManager.Register(request); err == nil {
+			t.Fatalf("Expected error for invalid request %+v", request)
+		}
+	}
+	if len(received["register"]) != 1 {
+		t.Fatalf("Expected invalid requests not to be sent")
+	}
+}
+
+// TestRevokeRequest tests that the revocation request is signed by the registrar and returns the CRL
+func TestRevokeRequest(t *testing.T) {
+	identityManager, received, closeCA := newRecordingCA(t)
+	defer closeCA()
+
+	resp, err := identityManager.Revoke(&fab.RevocationRequest{Serial: "1234", AKI: "abcd", Reason: "keyCompromise", GenCRL: true})
+	if err != nil {
+		t.Fatalf("Revoke return error: %v", err)
+	}
+	if !reflect.DeepEqual([]fab.RevokedCert{{Serial: "1234", AKI: "abcd"}}, resp.RevokedCerts) {
+		t.Fatalf("Unexpected revoked certificates %v", resp.RevokedCerts)
+	}
+	if string(resp.CRL) != mocks.MockCRL {
+		t.Fatalf("Expected the CRL but got %s", resp.CRL)
+	}
+
+	if len(received["revoke"]) != 1 {
+		t.Fatalf("Expected 1 revocation request but got %d", len(received["revoke"]))
+	}
+	revocation := received["revoke"][0]
+	if err := verifyToken(revocation.token, revocation.body); err != nil {
+		t.Fatalf("Token verification failed: %v", err)
+	}
+	var body caapi.RevocationRequest
+	if err := json.Unmarshal(revocation.body, &body); err != nil {
+		t.Fatalf("Failed to unmarshal revocation request: %v", err)
+	}
+	if !reflect.DeepEqual(caapi.RevocationRequest{Serial: "1234", AKI: "abcd", Reason: "keyCompromise", GenCRL: true}, body) {
+		t.Fatalf("Unexpected revocation request %+v", body)
+	}
+
+	// Without GenCRL, no CRL is returned
+	resp, err = identityManager.Revoke(&fab.RevocationRequest{Name: "peer1"})
+	if err != nil {
+		t.Fatalf("Revoke return error: %v", err)
+	}
+	if len(resp.CRL) != 0 {
+		t.Fatalf("Expected no CRL but got %s", resp.CRL)
+	}
+
+	// Either the name or the serial and AKI are required
+	if _, err := identityManager.Revoke(&fab.RevocationRequest{Serial: "1234"}); err == nil {
+		t.Fatalf("Expected error without AKI")
+	}
+	if len(received["revoke"]) != 2 {
+		t.Fatalf("Expected 2 revocation requests but got %d", len(received["revoke"]))
 	}
 }
 
@@ -329,6 +599,46 @@ func TestCreateNewidentityManagerClientKeyFileErrorFailure(t *testing.T) {
 	}
 }
 
+// TestCAClientInlinePems tests that the CA client uses the TLS certs and key that are embedded in the config
+func TestCAClientInlinePems(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cakeystore")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	c, err := config.FromFile("../../../test/fixtures/config/config_test_inline_pems.yaml")()
+	if err != nil {
+		t.Fatalf("Failed to read config: %s", err)
+	}
+	inlineConfig := c.(*config.Config)
+	if err := inlineConfig.Set("client.credentialStore.cryptoStore.path", dir); err != nil {
+		t.Fatalf("Failed to set the CA key store path: %s", err)
+	}
+
+	// The embedded client cert has expired
+	_, err = newCAClient(org1, c, cryptoSuite, httpOpts{})
+	if err == nil || !strings.Contains(err.Error(), "expired") {
+		t.Fatalf("Expected error from the embedded client cert. Got: %v", err)
+	}
+
+	// Without the client cert the embedded CA server cert is used on its own
+	if err := inlineConfig.Set("certificateAuthorities.ca.org1.example.com.tlsCACerts.client.cert.pem", ""); err != nil {
+		t.Fatalf("Failed to remove the client cert: %s", err)
+	}
+	if _, err := newCAClient(org1, c, cryptoSuite, httpOpts{}); err != nil {
+		t.Fatalf("Failed to create CA client from embedded certs: %s", err)
+	}
+
+	// An invalid embedded CA server cert is reported
+	if err := inlineConfig.Set("certificateAuthorities.ca.org1.example.com.tlsCACerts.pem", []string{"invalid"}); err != nil {
+		t.Fatalf("Failed to set the CA server cert: %s", err)
+	}
+	if _, err := newCAClient(org1, c, cryptoSuite, httpOpts{}); err == nil || !strings.Contains(err.Error(), "embedded certificate") {
+		t.Fatalf("Expected error from the invalid embedded CA server cert. Got: %v", err)
+	}
+}
+
 // TestCreateValidBCCSPOptsForNewFabricClient test newidentityManager Client creation with valid inputs, successful scenario
 func TestCreateValidBCCSPOptsForNewFabricClient(t *testing.T) {
 
@@ -7,6 +7,7 @@ SPDX-License-Identifier: Apache-2.0
 package mocks
 
 import (
+	"encoding/json"
 	"net/http"
 
 	cfapi "github.com/cloudflare/cfssl/api"
@@ -53,6 +54,16 @@ type enrollmentResponseNet struct {
 	ServerInfo serverInfoResponseNet
 }
 
+// The revocation response from the server
+type revocationResponseNet struct {
+	RevokedCerts []api.RevokedCert
+	// Base64 encoded PEM-encoded CRL
+	CRL string
+}
+
+// MockCRL is the CRL returned by the mock server for revocation requests with GenCRL
+const MockCRL = "MockCRL"
+
 // The response to the GET /info request
 type serverInfoResponseNet struct {
 	// CAName is a unique name associated with fabric-ca-server's CA
@@ -74,6 +85,7 @@ func StartFabricCAMockServer(address string, cs core.CryptoSuite) error {
 	http.HandleFunc("/register", Register)
 	http.HandleFunc("/enroll", Enroll)
 	http.HandleFunc("/reenroll", Enroll)
+	http.HandleFunc("/revoke", Revoke)
 
 	server := &http.Server{
 		Addr:      address,
@@ -110,6 +122,20 @@ func Reenroll(w http.ResponseWriter, req *http.Request) {
 	cfapi.SendResponse(w, resp)
 }
 
+// Revoke user, the serial and AKI of the request (if any) are returned as the revoked certificate
+func Revoke(w http.ResponseWriter, req *http.Request) {
+	var revocationReq api.RevocationRequest
+	if err := json.NewDecoder(req.Body).Decode(&revocationReq); err != nil {
+		cfsslapi.HandleError(w, err)
+		return
+	}
+	resp := &revocationResponseNet{RevokedCerts: []api.RevokedCert{{Serial: revocationReq.Serial, AKI: revocationReq.AKI}}}
+	if revocationReq.GenCRL {
+		resp.CRL = util.B64Encode([]byte(MockCRL))
+	}
+	cfsslapi.SendResponse(w, resp)
+}
+
 // Fill the CA info structure appropriately
 func fillCAInfo(info *serverInfoResponseNet) {
 	info.CAName = "MockCAName"
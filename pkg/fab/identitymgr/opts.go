@@ -0,0 +1,106 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package identitymgr
+
+import (
+	"net/http"
+
+	"github.com/pkg/errors"
+
+	contextApi "github.com/hyperledger/fabric-sdk-go/pkg/context/api"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/identity"
+)
+
+// RequestHook is invoked with each request before it is signed and sent to the CA.
+// The hook may modify the request (e.g. add headers). Returning an error aborts the request.
+type RequestHook func(req *http.Request) error
+
+// ResponseHook is invoked with each response that is received from the CA.
+type ResponseHook func(resp *http.Response)
+
+// Option configures the IdentityManager
+type Option func(*IdentityManager) error
+
+// httpOpts contains the options used to create the CA client's HTTP client
+type httpOpts struct {
+	client       *http.Client
+	transport    http.RoundTripper
+	requestHook  RequestHook
+	responseHook ResponseHook
+}
+
+// WithHTTPClient sets a custom HTTP client that is used to communicate with the CA.
+// If set, the transport provided by WithHTTPTransport is ignored.
+func WithHTTPClient(client *http.Client) Option {
+	return func(im *IdentityManager) error {
+		if client == nil {
+			return errors.New("HTTP client is nil")
+		}
+		im.httpOpts.client = client
+		return nil
+	}
+}
+
+// WithHTTPTransport sets a custom HTTP transport that is used to communicate with the CA.
+// If the transport is an *http.Transport without a TLS config and the CA URL is secured
+// then a copy of the transport is used with the TLS config set from the CA configuration.
+func WithHTTPTransport(transport http.RoundTripper) Option {
+	return func(im *IdentityManager) error {
+		if transport == nil {
+			return errors.New("HTTP transport is nil")
+		}
+		im.httpOpts.transport = transport
+		return nil
+	}
+}
+
+// WithRequestHook sets a hook that is invoked with each request before it is signed and sent to the CA.
+// The authorization token is computed over the request body after the hook is invoked.
+func WithRequestHook(hook RequestHook) Option {
+	return func(im *IdentityManager) error {
+		im.httpOpts.requestHook = hook
+		return nil
+	}
+}
+
+// WithResponseHook sets a hook that is invoked with each response received from the CA.
+func WithResponseHook(hook ResponseHook) Option {
+	return func(im *IdentityManager) error {
+		im.httpOpts.responseHook = hook
+		return nil
+	}
+}
+
+// WithSigningManager sets the signing manager that signs the authorization tokens of the requests to the CA,
+// so that the tokens are signed by the same signer as the proposals and transactions (e.g. a remote signer).
+// By default the tokens are signed with the crypto suite.
+func WithSigningManager(signingMgr contextApi.SigningManager) Option {
+	return func(im *IdentityManager) error {
+		if signingMgr == nil {
+			return errors.New("signing manager is nil")
+		}
+		im.signingMgr = signingMgr
+		return nil
+	}
+}
+
+// WithCredentialStore sets the store of the enrolled users, e.g. an identity.SQLCredentialStore that is
+// shared by several instances of a service. It replaces the file store at the credential store path
+// of the configuration.
+func WithCredentialStore(store contextApi.CredentialStore) Option {
+	return func(im *IdentityManager) error {
+		if store == nil {
+			return errors.New("credential store is nil")
+		}
+		userStore, err := identity.NewCredentialUserStore(store, im.cryptoSuite)
+		if err != nil {
+			return err
+		}
+		im.userStore = userStore
+		return nil
+	}
+}
@@ -0,0 +1,437 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package identitymgr
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	contextApi "github.com/hyperledger/fabric-sdk-go/pkg/context/api"
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config/cryptoutil"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/identitymgr/mocks"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/signingmgr"
+	"github.com/pkg/errors"
+)
+
+const hookHeader = "X-Test-Hook"
+
+// recordingTransport records the requests that pass through it
+type recordingTransport struct {
+	mutex    sync.Mutex
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (rt *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	rt.mutex.Lock()
+	rt.requests = append(rt.requests, req)
+	rt.bodies = append(rt.bodies, body)
+	rt.mutex.Unlock()
+
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// TestHTTPHooks tests that the request and response hooks are invoked and that a custom transport is used
+func TestHTTPHooks(t *testing.T) {
+	transport := &recordingTransport{}
+
+	var responses int
+	identityManager, err := New(org1, fullConfig, cryptoSuite,
+		WithHTTPTransport(transport),
+		WithRequestHook(func(req *http.Request) error {
+			req.Header.Set(hookHeader, "value")
+			return nil
+		}),
+		WithResponseHook(func(resp *http.Response) {
+			responses++
+		}),
+	)
+	if err != nil {
+		t.Fatalf("New return error: %v", err)
+	}
+
+	if _, _, err := identityManager.Enroll("enrollmentID", "enrollmentSecret"); err != nil {
+		t.Fatalf("Enroll return error: %v", err)
+	}
+
+	if len(transport.requests) != 1 {
+		t.Fatalf("expecting 1 request through the custom transport but got %d", len(transport.requests))
+	}
+	req := transport.requests[0]
+	if req.Header.Get(hookHeader) != "value" {
+		t.Fatalf("expecting header %s to be set by the request hook", hookHeader)
+	}
+	if req.Header.Get("Authorization") == "" {
+		t.Fatalf("expecting Authorization header to be set")
+	}
+	if responses != 1 {
+		t.Fatalf("expecting response hook to be invoked once but was invoked %d times", responses)
+	}
+}
+
+// TestRequestHookModifiesBody tests that the body set by the request hook is the one sent
+func TestRequestHookModifiesBody(t *testing.T) {
+	transport := &recordingTransport{}
+
+	var hookBody []byte
+	identityManager, err := New(org1, fullConfig, cryptoSuite,
+		WithHTTPTransport(transport),
+		WithRequestHook(func(req *http.Request) error {
+			body, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				return err
+			}
+			hookBody = append(bytes.TrimSuffix(body, []byte("}")), []byte(`,"extra":"value"}`)...)
+			req.Body = ioutil.NopCloser(bytes.NewReader(hookBody))
+			return nil
+		}),
+	)
+	if err != nil {
+		t.Fatalf("New return error: %v", err)
+	}
+
+	if _, _, err := identityManager.Enroll("enrollmentID", "enrollmentSecret"); err != nil {
+		t.Fatalf("Enroll return error: %v", err)
+	}
+
+	if len(transport.bodies) != 1 {
+		t.Fatalf("expecting 1 request through the custom transport but got %d", len(transport.bodies))
+	}
+	if !bytes.Equal(transport.bodies[0], hookBody) {
+		t.Fatalf("expecting body [%s] but got [%s]", hookBody, transport.bodies[0])
+	}
+	if transport.requests[0].ContentLength != int64(len(hookBody)) {
+		t.Fatalf("expecting content length %d but got %d", len(hookBody), transport.requests[0].ContentLength)
+	}
+}
+
+// TestRequestHookError tests that an error returned by the request hook aborts the request
+func TestRequestHookError(t *testing.T) {
+	transport := &recordingTransport{}
+
+	identityManager, err := New(org1, fullConfig, cryptoSuite,
+		WithHTTPClient(&http.Client{Transport: transport}),
+		WithRequestHook(func(req *http.Request) error {
+			return errors.New("hook error")
+		}),
+	)
+	if err != nil {
+		t.Fatalf("New return error: %v", err)
+	}
+
+	_, _, err = identityManager.Enroll("enrollmentID", "enrollmentSecret")
+	if err == nil || !strings.Contains(err.Error(), "hook error") {
+		t.Fatalf("expecting hook error but got %v", err)
+	}
+	if len(transport.requests) != 0 {
+		t.Fatalf("expecting no requests to be sent but got %d", len(transport.requests))
+	}
+}
+
+// TestInvalidHTTPOptions tests that nil HTTP client and transport are rejected
+func TestInvalidHTTPOptions(t *testing.T) {
+	if _, err := New(org1, fullConfig, cryptoSuite, WithHTTPClient(nil)); err == nil {
+		t.Fatalf("expecting error for nil HTTP client")
+	}
+	if _, err := New(org1, fullConfig, cryptoSuite, WithHTTPTransport(nil)); err == nil {
+		t.Fatalf("expecting error for nil HTTP transport")
+	}
+}
+
+// memoryCredentialStore is a credential store that keeps the users in memory
+type memoryCredentialStore struct {
+	mutex sync.Mutex
+	users map[contextApi.UserKey]contextApi.UserData
+}
+
+func (s *memoryCredentialStore) Store(user contextApi.UserData) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.users[contextApi.UserKey{MspID: user.MspID, Name: user.Name}] = user
+	return nil
+}
+
+func (s *memoryCredentialStore) Load(key contextApi.UserKey) (contextApi.UserData, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	user, ok := s.users[key]
+	if !ok {
+		return contextApi.UserData{}, contextApi.ErrUserNotFound
+	}
+	return user, nil
+}
+
+func (s *memoryCredentialStore) Delete(key contextApi.UserKey) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.users, key)
+	return nil
+}
+
+func (s *memoryCredentialStore) List() ([]contextApi.UserKey, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	var keys []contextApi.UserKey
+	for key := range s.users {
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// TestWithCredentialStore tests that the enrolled users are stored in the credential store of the option
+func TestWithCredentialStore(t *testing.T) {
+	if _, err := New(org1, fullConfig, cryptoSuite, WithCredentialStore(nil)); err == nil {
+		t.Fatalf("expecting error for nil credential store")
+	}
+
+	store := &memoryCredentialStore{users: make(map[contextApi.UserKey]contextApi.UserData)}
+	identityManager, err := New(org1, fullConfig, cryptoSuite, WithCredentialStore(store))
+	if err != nil {
+		t.Fatalf("New return error: %v", err)
+	}
+
+	key, cert, err := identityManager.EnrollWithRequest(&fab.EnrollmentRequest{Name: "storedUser", Secret: "storedSecret"})
+	if err != nil {
+		t.Fatalf("EnrollWithRequest return error: %v", err)
+	}
+	userData, err := store.Load(contextApi.UserKey{MspID: identityManager.orgMspID, Name: "storedUser"})
+	if err != nil {
+		t.Fatalf("expecting the enrolled user in the credential store: %v", err)
+	}
+	if !bytes.Equal(cert, userData.EnrollmentCertificate) || !bytes.Equal(key.SKI(), userData.KeyRef) {
+		t.Fatalf("expecting the certificate and the key reference of the enrolled user")
+	}
+
+	signingIdentity, err := identityManager.GetSigningIdentity("storedUser")
+	if err != nil {
+		t.Fatalf("GetSigningIdentity return error: %v", err)
+	}
+	if !bytes.Equal(cert, signingIdentity.EnrollmentCert) || !bytes.Equal(key.SKI(), signingIdentity.PrivateKey.SKI()) {
+		t.Fatalf("expecting the signing identity from the credential store")
+	}
+}
+
+const gatewayHeader = "X-Gateway-Authorization"
+
+// TestTokenAuthOverProxy tests that the token authorization header is computed over the body modified
+// by the request hook and that requests are sent through the proxy configured on the transport
+func TestTokenAuthOverProxy(t *testing.T) {
+	var mutex sync.Mutex
+	var proxied []*http.Request
+	var tokenErrs []error
+
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		mutex.Lock()
+		proxied = append(proxied, req)
+		if strings.Contains(req.URL.Path, "reenroll") {
+			tokenErrs = append(tokenErrs, verifyToken(req.Header.Get("Authorization"), body))
+		}
+		mutex.Unlock()
+
+		// Act as the CA server
+		mocks.Enroll(w, req)
+	}))
+	defer proxy.Close()
+
+	proxyURL, err := url.Parse(proxy.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse proxy URL: %v", err)
+	}
+	transport := &http.Transport{Proxy: http.ProxyURL(proxyURL)}
+
+	identityManager, err := New(org1, fullConfig, cryptoSuite,
+		WithHTTPTransport(transport),
+		WithRequestHook(func(req *http.Request) error {
+			req.Header.Set(gatewayHeader, "gateway-token")
+			body, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				return err
+			}
+			if len(body) > 0 {
+				body = append(bytes.TrimSuffix(body, []byte("}")), []byte(`,"extra":"value"}`)...)
+			}
+			req.Body = ioutil.NopCloser(bytes.NewReader(body))
+			return nil
+		}),
+	)
+	if err != nil {
+		t.Fatalf("New return error: %v", err)
+	}
+
+	_, cert, err := identityManager.Enroll("enrollmentID", "enrollmentSecret")
+	if err != nil {
+		t.Fatalf("Enroll return error: %v", err)
+	}
+
+	// The mock CA always returns the same certificate whose key is in the crypto suite
+	key, err := cryptoutil.GetPrivateKeyFromCert(cert, cryptoSuite)
+	if err != nil {
+		t.Fatalf("Failed to get private key from cert: %v", err)
+	}
+	user := mocks.NewMockUser("user1")
+	user.SetEnrollmentCertificate(cert)
+	user.SetPrivateKey(key)
+
+	if _, _, err := identityManager.Reenroll(user); err != nil {
+		t.Fatalf("Reenroll return error: %v", err)
+	}
+
+	if len(proxied) != 2 {
+		t.Fatalf("expecting 2 requests through the proxy but got %d", len(proxied))
+	}
+	for _, req := range proxied {
+		if req.Header.Get(gatewayHeader) != "gateway-token" {
+			t.Fatalf("expecting header %s to be set by the request hook", gatewayHeader)
+		}
+		if req.Host != strings.TrimPrefix(caServerURL, "http://") {
+			t.Fatalf("expecting request for host %s but got %s", caServerURL, req.Host)
+		}
+	}
+	if len(tokenErrs) != 1 {
+		t.Fatalf("expecting 1 reenroll request but got %d", len(tokenErrs))
+	}
+	if tokenErrs[0] != nil {
+		t.Fatalf("token verification failed: %v", tokenErrs[0])
+	}
+}
+
+// verifyToken verifies that the token was computed over the given body
+func verifyToken(token string, body []byte) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return errors.Errorf("invalid token format: %s", token)
+	}
+	certPEM, err := base64.StdEncoding.DecodeString(parts[0])
+	if err != nil {
+		return errors.Wrap(err, "failed to decode cert")
+	}
+	sig, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return errors.Wrap(err, "failed to decode signature")
+	}
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		return errors.New("failed to decode cert PEM")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse cert")
+	}
+	publicKey, ok := cert.PublicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return errors.New("expecting ECDSA public key")
+	}
+	digest := sha256.Sum256([]byte(base64.StdEncoding.EncodeToString(body) + "." + parts[0]))
+	if !ecdsa.VerifyASN1(publicKey, digest[:], sig) {
+		return errors.New("signature doesn't match the body")
+	}
+	return nil
+}
+
+// countingSigningManager counts the objects signed by the signing manager
+type countingSigningManager struct {
+	contextApi.SigningManager
+	signed int
+}
+
+func (m *countingSigningManager) Sign(object []byte, key core.Key) ([]byte, error) {
+	m.signed++
+	return m.SigningManager.Sign(object, key)
+}
+
+// TestWithSigningManager tests that the token authorization header is signed by the signing manager
+func TestWithSigningManager(t *testing.T) {
+	if _, err := New(org1, fullConfig, cryptoSuite, WithSigningManager(nil)); err == nil {
+		t.Fatalf("expecting error for nil signing manager")
+	}
+
+	var tokenErr error
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if strings.Contains(req.URL.Path, "reenroll") {
+			body, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			tokenErr = verifyToken(req.Header.Get("Authorization"), body)
+			req.Body = ioutil.NopCloser(bytes.NewReader(body))
+		}
+		mocks.Enroll(w, req)
+	}))
+	defer server.Close()
+
+	proxyURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse server URL: %v", err)
+	}
+
+	signingMgr, err := signingmgr.NewWithSigner(signingmgr.NewCryptoSuiteSigner(cryptoSuite, cryptosuite.GetSHAOpts()))
+	if err != nil {
+		t.Fatalf("Failed to create signing manager: %v", err)
+	}
+	countingMgr := &countingSigningManager{SigningManager: signingMgr}
+
+	identityManager, err := New(org1, fullConfig, cryptoSuite,
+		WithHTTPTransport(&http.Transport{Proxy: http.ProxyURL(proxyURL)}),
+		WithSigningManager(countingMgr),
+	)
+	if err != nil {
+		t.Fatalf("New return error: %v", err)
+	}
+
+	_, cert, err := identityManager.Enroll("enrollmentID", "enrollmentSecret")
+	if err != nil {
+		t.Fatalf("Enroll return error: %v", err)
+	}
+	key, err := cryptoutil.GetPrivateKeyFromCert(cert, cryptoSuite)
+	if err != nil {
+		t.Fatalf("Failed to get private key from cert: %v", err)
+	}
+	user := mocks.NewMockUser("user1")
+	user.SetEnrollmentCertificate(cert)
+	user.SetPrivateKey(key)
+
+	if _, _, err := identityManager.Reenroll(user); err != nil {
+		t.Fatalf("Reenroll return error: %v", err)
+	}
+	if countingMgr.signed != 1 {
+		t.Fatalf("expecting the token to be signed once by the signing manager but was signed %d times", countingMgr.signed)
+	}
+	if tokenErr != nil {
+		t.Fatalf("token verification failed: %v", tokenErr)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path"
+	"time"
 
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api"
 	"github.com/hyperledger/fabric-sdk-go/pkg/logging"
@@ -129,6 +130,23 @@ func (fkvs *FileKeyValueStore) Load(key interface{}) (interface{}, error) {
 	return fkvs.unmarshaller(bytes)
 }
 
+// ModTime returns the time at which the value for a key was last stored.
+// If a value for the key was not found, returns ErrNotFound
+func (fkvs *FileKeyValueStore) ModTime(key interface{}) (time.Time, error) {
+	file, err := fkvs.keySerializer(key)
+	if err != nil {
+		return time.Time{}, err
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return time.Time{}, api.ErrNotFound
+		}
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
+
 // Store sets the value for the key.
 func (fkvs *FileKeyValueStore) Store(key interface{}, value interface{}) error {
 	if key == nil {
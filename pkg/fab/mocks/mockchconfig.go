@@ -9,16 +9,22 @@ package mocks
 import (
 	"github.com/hyperledger/fabric-sdk-go/pkg/context"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
 	msp "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/msp"
+	ab "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/orderer"
 )
 
 // MockChannelCfg contains mock channel configuration
 type MockChannelCfg struct {
-	MockName        string
-	MockMsps        []*msp.MSPConfig
-	MockAnchorPeers []*fab.OrgAnchorPeer
-	MockOrderers    []string
-	MockVersions    *fab.Versions
+	MockName           string
+	MockMsps           []*msp.MSPConfig
+	MockMSPIDs         []string
+	MockAnchorPeers    []*fab.OrgAnchorPeer
+	MockOrderers       []string
+	MockBatchSize      *ab.BatchSize
+	MockConsortium     string
+	MockVersions       *fab.Versions
+	MockConfigEnvelope *common.ConfigEnvelope
 }
 
 // NewMockChannelCfg ...
@@ -36,6 +42,11 @@ func (cfg *MockChannelCfg) Msps() []*msp.MSPConfig {
 	return cfg.MockMsps
 }
 
+// MSPIDs returns the IDs of the msps
+func (cfg *MockChannelCfg) MSPIDs() []string {
+	return cfg.MockMSPIDs
+}
+
 // AnchorPeers returns anchor peers
 func (cfg *MockChannelCfg) AnchorPeers() []*fab.OrgAnchorPeer {
 	return cfg.MockAnchorPeers
@@ -46,11 +57,31 @@ func (cfg *MockChannelCfg) Orderers() []string {
 	return cfg.MockOrderers
 }
 
+// BatchSize returns the batch size of the orderer
+func (cfg *MockChannelCfg) BatchSize() *ab.BatchSize {
+	return cfg.MockBatchSize
+}
+
+// Consortium returns the name of the consortium
+func (cfg *MockChannelCfg) Consortium() string {
+	return cfg.MockConsortium
+}
+
 // Versions returns versions
 func (cfg *MockChannelCfg) Versions() *fab.Versions {
 	return cfg.MockVersions
 }
 
+// ConfigEnvelope returns the config envelope
+func (cfg *MockChannelCfg) ConfigEnvelope() *common.ConfigEnvelope {
+	return cfg.MockConfigEnvelope
+}
+
+// FormatVersion returns the format version
+func (cfg *MockChannelCfg) FormatVersion() int {
+	return fab.ChannelCfgFormatVersion
+}
+
 // MockChannelConfig mocks query channel configuration
 type MockChannelConfig struct {
 	channelID string
@@ -17,6 +17,8 @@ type MockChannelProvider struct {
 	ctx        context.ProviderContext
 	channels   map[string]fab.Channel
 	transactor fab.Transactor
+	ledger     fab.ChannelLedger
+	eventHub   fab.EventHub
 }
 
 // MockChannelService holds a mock channel service.
@@ -24,6 +26,8 @@ type MockChannelService struct {
 	provider   *MockChannelProvider
 	channelID  string
 	transactor fab.Transactor
+	ledger     fab.ChannelLedger
+	eventHub   fab.EventHub
 }
 
 // NewMockChannelProvider returns a mock ChannelProvider
@@ -48,18 +52,33 @@ func (cp *MockChannelProvider) SetTransactor(transactor fab.Transactor) {
 	cp.transactor = transactor
 }
 
+// SetLedger sets the default ledger for all mock channel services
+func (cp *MockChannelProvider) SetLedger(ledger fab.ChannelLedger) {
+	cp.ledger = ledger
+}
+
+// SetEventHub sets the default event hub for all mock channel services
+func (cp *MockChannelProvider) SetEventHub(eventHub fab.EventHub) {
+	cp.eventHub = eventHub
+}
+
 // ChannelService returns a mock ChannelService
 func (cp *MockChannelProvider) ChannelService(ic context.IdentityContext, channelID string) (fab.ChannelService, error) {
 	cs := MockChannelService{
 		provider:   cp,
 		channelID:  channelID,
 		transactor: cp.transactor,
+		ledger:     cp.ledger,
+		eventHub:   cp.eventHub,
 	}
 	return &cs, nil
 }
 
 // EventHub ...
 func (cs *MockChannelService) EventHub() (fab.EventHub, error) {
+	if cs.eventHub != nil {
+		return cs.eventHub, nil
+	}
 	return NewMockEventHub(), nil
 }
 
@@ -90,5 +109,5 @@ func (cs *MockChannelService) Config() (fab.ChannelConfig, error) {
 
 // Ledger ...
 func (cs *MockChannelService) Ledger() (fab.ChannelLedger, error) {
-	return nil, nil
+	return cs.ledger, nil
 }
@@ -21,6 +21,7 @@ type MockConfig struct {
 	tlsEnabled       bool
 	mutualTLSEnabled bool
 	errorCase        bool
+	timeouts         map[config.TimeoutType]time.Duration
 }
 
 // NewMockConfig ...
@@ -33,6 +34,11 @@ func NewMockConfigCustomized(tlsEnabled, mutualTLSEnabled, errorCase bool) confi
 	return &MockConfig{tlsEnabled: tlsEnabled, mutualTLSEnabled: mutualTLSEnabled, errorCase: errorCase}
 }
 
+// NewMockConfigWithTimeouts returns a mock config that has the given timeouts (the other timeouts have their defaults)
+func NewMockConfigWithTimeouts(timeouts map[config.TimeoutType]time.Duration) config.Config {
+	return &MockConfig{timeouts: timeouts}
+}
+
 // Client ...
 func (c *MockConfig) Client() (*config.ClientConfig, error) {
 	clientConfig := config.ClientConfig{}
@@ -105,6 +111,20 @@ func (c *MockConfig) TimeoutOrDefault(arg config.TimeoutType) time.Duration {
 	return time.Second * 5
 }
 
+// Timeout returns the configured timeout of the given type, or the default timeout
+func (c *MockConfig) Timeout(arg config.TimeoutType) time.Duration {
+	if timeout, ok := c.timeouts[arg]; ok {
+		return timeout
+	}
+	if arg == config.EventReconnectInitialDelay {
+		return 0
+	}
+	if arg == config.ConnectionIdle {
+		return time.Second * 30
+	}
+	return time.Second * 5
+}
+
 // PeersConfig Retrieves the fabric peers from the config file provided
 func (c *MockConfig) PeersConfig(org string) ([]config.PeerConfig, error) {
 	return nil, nil
@@ -248,6 +268,11 @@ func (c *MockConfig) SecurityProviderPin() string {
 	return ""
 }
 
+// SecurityProviderSessionPool ...
+func (c *MockConfig) SecurityProviderSessionPool() config.SessionPoolConfig {
+	return config.SessionPoolConfig{}
+}
+
 //SoftVerify flag
 func (c *MockConfig) SoftVerify() bool {
 	return false
@@ -25,6 +25,7 @@ type MockProviderContext struct {
 	config         config.Config
 	cryptoSuite    core.CryptoSuite
 	signingManager api.SigningManager
+	connector      api.Connector
 }
 
 // NewMockProviderContext creates a MockProviderContext consisting of defaults
@@ -67,6 +68,17 @@ func (pc *MockProviderContext) SigningManager() api.SigningManager {
 	return pc.signingManager
 }
 
+// Connector returns the mock connector. The connector is nil unless it's set with SetConnector,
+// in which case the clients dial a new connection for each request.
+func (pc *MockProviderContext) Connector() api.Connector {
+	return pc.connector
+}
+
+// SetConnector sets the mock connector.
+func (pc *MockProviderContext) SetConnector(connector api.Connector) {
+	pc.connector = connector
+}
+
 // MockContext holds core providers and identity to enable mocking.
 type MockContext struct {
 	*MockProviderContext
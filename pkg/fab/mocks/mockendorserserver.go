@@ -8,6 +8,9 @@ package mocks
 
 import (
 	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
 	"fmt"
 	"net"
 
@@ -16,6 +19,7 @@ import (
 
 	rwsetutil "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/rwsetutil"
 	kvrwset "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/ledger/rwset/kvrwset"
+	msp "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/msp"
 	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
 )
 
@@ -23,6 +27,9 @@ import (
 type MockEndorserServer struct {
 	ProposalError error
 	AddkvWrite    bool
+	// Signer (if set) signs the endorsements of the Org1MSP endorser, which are
+	// otherwise returned with the serialized identity "endorser" and a fake signature
+	Signer crypto.Signer
 }
 
 // ProcessProposal mock implementation that returns success if error is not set
@@ -30,10 +37,15 @@ type MockEndorserServer struct {
 func (m *MockEndorserServer) ProcessProposal(context context.Context,
 	proposal *pb.SignedProposal) (*pb.ProposalResponse, error) {
 	if m.ProposalError == nil {
+		payload := m.createProposalResponsePayload()
+		endorsement, err := m.endorse(payload)
+		if err != nil {
+			return nil, err
+		}
 		return &pb.ProposalResponse{Response: &pb.Response{
 			Status: 200,
-		}, Endorsement: &pb.Endorsement{Endorser: []byte("endorser"), Signature: []byte("signature")},
-			Payload: m.createProposalResponsePayload()}, nil
+		}, Endorsement: endorsement,
+			Payload: payload}, nil
 	}
 	return &pb.ProposalResponse{Response: &pb.Response{
 		Status:  500,
@@ -41,6 +53,24 @@ func (m *MockEndorserServer) ProcessProposal(context context.Context,
 	}}, m.ProposalError
 }
 
+// endorse signs the payload of the proposal response and the endorser's identity, as the peers do
+func (m *MockEndorserServer) endorse(payload []byte) (*pb.Endorsement, error) {
+	if m.Signer == nil {
+		return &pb.Endorsement{Endorser: []byte("endorser"), Signature: []byte("signature")}, nil
+	}
+
+	endorser, err := proto.Marshal(&msp.SerializedIdentity{Mspid: "Org1MSP", IdBytes: []byte(certPem)})
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256(append(payload, endorser...))
+	signature, err := m.Signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.Endorsement{Endorser: endorser, Signature: signature}, nil
+}
+
 func (m *MockEndorserServer) createProposalResponsePayload() []byte {
 
 	prp := &pb.ProposalResponsePayload{}
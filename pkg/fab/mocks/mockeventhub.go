@@ -17,12 +17,16 @@ import (
 
 // MockEventHub Mock EventHub
 type MockEventHub struct {
-	RegisteredTxCallbacks chan func(fab.TransactionID, pb.TxValidationCode, error)
+	RegisteredTxCallbacks       chan func(fab.TransactionID, pb.TxValidationCode, error)
+	RegisteredTxStatusCallbacks chan func(fab.TransactionID, pb.TxValidationCode, uint64, error)
 }
 
 // NewMockEventHub creates a new mock EventHub
 func NewMockEventHub() *MockEventHub {
-	return &MockEventHub{RegisteredTxCallbacks: make(chan func(fab.TransactionID, pb.TxValidationCode, error))}
+	return &MockEventHub{
+		RegisteredTxCallbacks:       make(chan func(fab.TransactionID, pb.TxValidationCode, error)),
+		RegisteredTxStatusCallbacks: make(chan func(fab.TransactionID, pb.TxValidationCode, uint64, error)),
+	}
 }
 
 // SetPeerAddr not implemented
@@ -61,6 +65,11 @@ func (m *MockEventHub) RegisterTxEvent(txnID fab.TransactionID, callback func(fa
 	return
 }
 
+// RegisterTxStatusEvent reports the callback to the RegisteredTxStatusCallbacks channel
+func (m *MockEventHub) RegisterTxStatusEvent(txnID fab.TransactionID, callback func(fab.TransactionID, pb.TxValidationCode, uint64, error)) {
+	go func() { m.RegisteredTxStatusCallbacks <- callback }()
+}
+
 // UnregisterTxEvent not implemented
 func (m *MockEventHub) UnregisterTxEvent(txnID fab.TransactionID) {
 	return
@@ -32,6 +32,8 @@ type MockPeer struct {
 	Status               int32
 	ProcessProposalCalls int
 	Endorser             []byte
+	// Payload of the proposal response (which contains the read/write set)
+	ProposalResponsePayload []byte
 }
 
 // NewMockPeer creates basic mock peer
@@ -106,7 +108,7 @@ func (p *MockPeer) ProcessTransactionProposal(tp fab.ProcessProposalRequest) (*f
 	return &fab.TransactionProposalResponse{
 		Endorser: p.MockURL,
 		Status:   p.Status,
-		ProposalResponse: &pb.ProposalResponse{Response: &pb.Response{
+		ProposalResponse: &pb.ProposalResponse{Payload: p.ProposalResponsePayload, Response: &pb.Response{
 			Message: p.ResponseMessage, Status: p.Status, Payload: p.Payload},
 			Endorsement: &pb.Endorsement{Endorser: p.Endorser, Signature: []byte("signature")}},
 	}, p.Error
@@ -12,6 +12,7 @@ import (
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
 	"github.com/hyperledger/fabric-sdk-go/pkg/core/config/comm"
 	"github.com/hyperledger/fabric-sdk-go/pkg/core/config/urlutil"
+	fabcomm "github.com/hyperledger/fabric-sdk-go/pkg/fab/comm"
 	"github.com/spf13/cast"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
@@ -21,15 +22,20 @@ import (
 // NewOrderer Returns a Orderer instance
 // Deprecated: use orderer.New() instead
 func NewOrderer(url string, certPath string, serverHostOverride string, config core.Config,
+	kap keepalive.ClientParameters) (*Orderer, error) {
+	return newOrderer(url, core.TLSConfig{Path: certPath}, serverHostOverride, config, kap)
+}
+
+// newOrderer returns an Orderer instance whose TLS CA cert is either embedded in the config or loaded from a path
+func newOrderer(url string, certConfig core.TLSConfig, serverHostOverride string, config core.Config,
 	kap keepalive.ClientParameters) (*Orderer, error) {
 	var opts []grpc.DialOption
 
-	timeout := config.TimeoutOrDefault(core.OrdererConnection)
+	timeout := config.Timeout(core.OrdererConnection)
 	if kap.Time > 0 || kap.Timeout > 0 {
 		opts = append(opts, grpc.WithKeepaliveParams(kap))
 	}
 	if urlutil.IsTLSEnabled(url) {
-		certConfig := core.TLSConfig{Path: certPath}
 		certificate, err := certConfig.TLSCert()
 
 		if err != nil {
@@ -45,7 +51,7 @@ func NewOrderer(url string, certPath string, serverHostOverride string, config c
 	} else {
 		opts = append(opts, grpc.WithInsecure())
 	}
-	return &Orderer{url: urlutil.ToAddress(url), grpcDialOption: opts, dialTimeout: timeout}, nil
+	return &Orderer{url: urlutil.ToAddress(url), grpcDialOption: opts, dialTimeout: timeout, connector: &fabcomm.DirectConnector{}}, nil
 }
 
 // NewOrdererFromConfig returns an Orderer instance constructed from orderer config
@@ -68,5 +74,5 @@ func NewOrdererFromConfig(ordererCfg *core.OrdererConfig, config core.Config) (*
 		kap.PermitWithoutStream = cast.ToBool(kaPermit)
 	}
 
-	return NewOrderer(ordererCfg.URL, ordererCfg.TLSCACerts.Path, serverHostOverride, config, kap)
+	return newOrderer(ordererCfg.URL, ordererCfg.TLSCACerts, serverHostOverride, config, kap)
 }
@@ -8,10 +8,10 @@ package orderer
 
 import (
 	grpcContext "context"
+	"crypto/tls"
 	"time"
 
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/keepalive"
 	grpcstatus "google.golang.org/grpc/status"
 
@@ -23,9 +23,11 @@ import (
 
 	"crypto/x509"
 
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
 	"github.com/hyperledger/fabric-sdk-go/pkg/core/config/urlutil"
+	fabcomm "github.com/hyperledger/fabric-sdk-go/pkg/fab/comm"
 	"github.com/hyperledger/fabric-sdk-go/pkg/logging"
 	"github.com/pkg/errors"
 )
@@ -34,17 +36,23 @@ var logger = logging.NewLogger("fabric_sdk_go")
 
 // Orderer allows a client to broadcast a transaction.
 type Orderer struct {
-	config               core.Config
-	url                  string
-	tlsCACert            *x509.Certificate
-	serverName           string
-	grpcDialOption       []grpc.DialOption
-	kap                  keepalive.ClientParameters
-	dialTimeout          time.Duration
-	failFast             bool
-	transportCredentials credentials.TransportCredentials
-	secured              bool
-	allowInsecure        bool
+	config         core.Config
+	url            string
+	tlsCACert      *x509.Certificate
+	serverName     string
+	grpcDialOption []grpc.DialOption
+	kap            keepalive.ClientParameters
+	dialTimeout    time.Duration
+	failFast       bool
+	maxRecvMsgSize int
+	maxSendMsgSize int
+	tlsConfig      *tls.Config
+	secured        bool
+	allowInsecure  bool
+	tlsCertHash    []byte
+	proxyURL       string
+	connector      api.Connector
+	dialKey        string
 }
 
 // Option describes a functional parameter for the New constructor
@@ -61,12 +69,18 @@ func New(config core.Config, opts ...Option) (*Orderer, error) {
 			return nil, err
 		}
 	}
-	var grpcOpts []grpc.DialOption
-	if orderer.kap.Time > 0 {
-		grpcOpts = append(grpcOpts, grpc.WithKeepaliveParams(orderer.kap))
+	settings := fabcomm.DialSettings{
+		KeepAlive:      orderer.kap,
+		FailFast:       orderer.failFast,
+		MaxRecvMsgSize: orderer.maxRecvMsgSize,
+		MaxSendMsgSize: orderer.maxSendMsgSize,
+		ProxyURL:       orderer.proxyURL,
 	}
-	grpcOpts = append(grpcOpts, grpc.WithDefaultCallOptions(grpc.FailFast(orderer.failFast)))
-	orderer.dialTimeout = config.TimeoutOrDefault(core.OrdererConnection)
+	grpcOpts, err := settings.DialOptions(orderer.url)
+	if err != nil {
+		return nil, err
+	}
+	orderer.dialTimeout = config.Timeout(core.OrdererConnection)
 
 	//tls config
 	tlsConfig, err := comm.TLSConfig(orderer.tlsCACert, orderer.serverName, config)
@@ -75,9 +89,16 @@ func New(config core.Config, opts ...Option) (*Orderer, error) {
 	}
 
 	orderer.grpcDialOption = grpcOpts
-	orderer.transportCredentials = credentials.NewTLS(tlsConfig)
+	orderer.dialKey = settings.Key()
+	orderer.tlsConfig = tlsConfig
+	if orderer.connector == nil {
+		orderer.connector = &fabcomm.DirectConnector{}
+	}
 	orderer.secured = urlutil.AttemptSecured(orderer.url)
 	orderer.url = urlutil.ToAddress(orderer.url)
+	if orderer.secured {
+		orderer.tlsCertHash = comm.TLSCertHashFromTLSConfig(tlsConfig)
+	}
 
 	return orderer, nil
 }
@@ -118,6 +139,28 @@ func WithInsecure() Option {
 	}
 }
 
+// WithConnector is a functional option for the orderer.New constructor that configures the connector that dials
+// the connections to the orderer, e.g. the connector of the SDK that shares the connections between the clients.
+// By default a new connection is dialed for each request.
+func WithConnector(connector api.Connector) Option {
+	return func(o *Orderer) error {
+		o.connector = connector
+
+		return nil
+	}
+}
+
+// WithProxyURL is a functional option for the orderer.New constructor that configures the URL of the proxy
+// through which the orderer is dialed (e.g. http://proxy:3128 or socks5://proxy:1080).
+// By default the orderer is dialed through the proxy of the environment (HTTPS_PROXY and NO_PROXY), if any.
+func WithProxyURL(proxyURL string) Option {
+	return func(o *Orderer) error {
+		o.proxyURL = proxyURL
+
+		return nil
+	}
+}
+
 // FromOrdererConfig is a functional option for the orderer.New constructor that configures a new orderer
 // from a apiconfig.OrdererConfig struct
 func FromOrdererConfig(ordererCfg *core.OrdererConfig) Option {
@@ -139,7 +182,10 @@ func FromOrdererConfig(ordererCfg *core.OrdererConfig) Option {
 		o.serverName = getServerNameOverride(ordererCfg)
 		o.kap = getKeepAliveOptions(ordererCfg)
 		o.failFast = getFailFast(ordererCfg)
+		o.maxRecvMsgSize = cast.ToInt(ordererCfg.GRPCOptions["max-recv-msg-size"])
+		o.maxSendMsgSize = cast.ToInt(ordererCfg.GRPCOptions["max-send-msg-size"])
 		o.allowInsecure = isInsecureConnectionAllowed(ordererCfg)
+		o.proxyURL = fabcomm.ProxyURL(ordererCfg.GRPCOptions)
 
 		return nil
 	}
@@ -179,13 +225,13 @@ func getFailFast(ordererCfg *core.OrdererConfig) bool {
 func getKeepAliveOptions(ordererCfg *core.OrdererConfig) keepalive.ClientParameters {
 
 	var kap keepalive.ClientParameters
-	if kaTime, ok := ordererCfg.GRPCOptions["keep-alive-time"].(time.Duration); ok {
+	if kaTime, ok := ordererCfg.GRPCOptions["keep-alive-time"]; ok {
 		kap.Time = cast.ToDuration(kaTime)
 	}
-	if kaTimeout, ok := ordererCfg.GRPCOptions["keep-alive-timeout"].(time.Duration); ok {
+	if kaTimeout, ok := ordererCfg.GRPCOptions["keep-alive-timeout"]; ok {
 		kap.Timeout = cast.ToDuration(kaTimeout)
 	}
-	if kaPermit, ok := ordererCfg.GRPCOptions["keep-alive-permit"].(time.Duration); ok {
+	if kaPermit, ok := ordererCfg.GRPCOptions["keep-alive-permit"]; ok {
 		kap.PermitWithoutStream = cast.ToBool(kaPermit)
 	}
 	return kap
@@ -207,6 +253,12 @@ func (o *Orderer) URL() string {
 	return o.url
 }
 
+// TLSCertHash returns the hash of the client TLS certificate used on the connection to the orderer.
+// Nil is returned if the connection to the orderer doesn't use a client certificate.
+func (o *Orderer) TLSCertHash() []byte {
+	return o.tlsCertHash
+}
+
 // SendBroadcast Send the created transaction to Orderer.
 func (o *Orderer) SendBroadcast(envelope *fab.SignedEnvelope) (*common.Status, error) {
 	return o.sendBroadcast(envelope, o.secured)
@@ -214,24 +266,15 @@ func (o *Orderer) SendBroadcast(envelope *fab.SignedEnvelope) (*common.Status, e
 
 // SendBroadcast Send the created transaction to Orderer.
 func (o *Orderer) sendBroadcast(envelope *fab.SignedEnvelope, secured bool) (*common.Status, error) {
-	var grpcOpts []grpc.DialOption
-	grpcOpts = append(grpcOpts, o.grpcDialOption...)
-	if secured {
-		grpcOpts = append(grpcOpts, grpc.WithTransportCredentials(o.transportCredentials))
-	} else {
-		grpcOpts = append(grpcOpts, grpc.WithInsecure())
-	}
-
 	ctx := grpcContext.Background()
 	ctx, cancel := grpcContext.WithTimeout(ctx, o.dialTimeout)
 	defer cancel()
 
-	conn, err := grpc.DialContext(ctx, o.url, grpcOpts...)
-
+	conn, err := o.conn(ctx, secured)
 	if err != nil {
 		return nil, status.New(status.OrdererClientStatus, status.ConnectionFailed.ToInt32(), err.Error(), nil)
 	}
-	defer conn.Close()
+	defer o.connector.ReleaseConn(conn)
 	broadcastStream, err := ab.NewAtomicBroadcastClient(conn).Broadcast(ctx)
 	if err != nil {
 		rpcStatus, ok := grpcstatus.FromError(err)
@@ -301,18 +344,10 @@ func (o *Orderer) sendDeliver(envelope *fab.SignedEnvelope, secured bool) (chan
 	errs := make(chan error, 1)
 
 	// Establish connection to Ordering Service
-	var grpcOpts []grpc.DialOption
-	grpcOpts = append(grpcOpts, o.grpcDialOption...)
-	if secured {
-		grpcOpts = append(o.grpcDialOption, grpc.WithTransportCredentials(o.transportCredentials))
-	} else {
-		grpcOpts = append(o.grpcDialOption, grpc.WithInsecure())
-	}
-
 	ctx := grpcContext.Background()
 	ctx, cancel := grpcContext.WithTimeout(ctx, o.dialTimeout)
 
-	conn, err := grpc.DialContext(ctx, o.url, grpcOpts...)
+	conn, err := o.conn(ctx, secured)
 	if err != nil {
 		errs <- err
 		return responses, errs, cancel
@@ -321,6 +356,7 @@ func (o *Orderer) sendDeliver(envelope *fab.SignedEnvelope, secured bool) (chan
 	// Create atomic broadcast client
 	broadcastStream, err := ab.NewAtomicBroadcastClient(conn).Deliver(ctx)
 	if err != nil {
+		o.connector.ReleaseConn(conn)
 		logger.Error("NewAtomicBroadcastClient failed, cause : ", err)
 		if secured && o.allowInsecure {
 			//If secured mode failed and allow insecure is enabled then retry in insecure mode
@@ -338,13 +374,14 @@ func (o *Orderer) sendDeliver(envelope *fab.SignedEnvelope, secured bool) (chan
 		Payload:   envelope.Payload,
 		Signature: envelope.Signature,
 	}); err != nil {
+		o.connector.ReleaseConn(conn)
 		errs <- errors.Wrap(err, "failed to send block request to orderer")
 		return responses, errs, cancel
 	}
 
 	// Receive blocks from the GRPC stream and put them on the channel
 	go func() {
-		defer conn.Close()
+		defer o.connector.ReleaseConn(conn)
 		for {
 			response, err := broadcastStream.Recv()
 			if err != nil {
@@ -378,3 +415,12 @@ func (o *Orderer) sendDeliver(envelope *fab.SignedEnvelope, secured bool) (chan
 	}()
 	return responses, errs, cancel
 }
+
+// conn returns a connection to the orderer from the connector
+func (o *Orderer) conn(ctx grpcContext.Context, secured bool) (*grpc.ClientConn, error) {
+	var tlsConfig *tls.Config
+	if secured {
+		tlsConfig = o.tlsConfig
+	}
+	return o.connector.DialContext(fabcomm.WithDialKey(ctx, o.dialKey), o.url, tlsConfig, o.grpcDialOption...)
+}
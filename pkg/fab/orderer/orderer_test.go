@@ -351,8 +351,9 @@ func TestBroadcastBadDial(t *testing.T) {
 	defer mockCtrl.Finish()
 	config := mock_core.NewMockConfig(mockCtrl)
 
-	config.EXPECT().TimeoutOrDefault(core.OrdererConnection).Return(time.Second * 1)
+	config.EXPECT().Timeout(core.OrdererConnection).Return(time.Second * 1)
 	config.EXPECT().TLSCACertPool(gomock.Any()).Return(x509.NewCertPool(), nil).AnyTimes()
+	config.EXPECT().TLSClientCerts().Return(nil, nil).AnyTimes()
 
 	orderer, err := New(config, WithURL("grpc://127.0.0.1:0"))
 	assert.Nil(t, err)
@@ -407,6 +408,39 @@ func TestFailFast(t *testing.T) {
 	assert.EqualValues(t, failFast, false)
 }
 
+func TestOrdererGRPCOptions(t *testing.T) {
+	ordererConfig := &core.OrdererConfig{
+		URL: "grpc://" + testOrdererURL,
+		GRPCOptions: map[string]interface{}{
+			"keep-alive-time":    "10s",
+			"keep-alive-timeout": "20s",
+			"keep-alive-permit":  true,
+			"max-recv-msg-size":  16 * 1024 * 1024,
+			"max-send-msg-size":  8 * 1024 * 1024,
+		},
+	}
+	orderer, err := New(mocks.NewMockConfig(), FromOrdererConfig(ordererConfig))
+	if err != nil {
+		t.Fatalf("Failed to create new orderer FromOrdererConfig (%v)", err)
+	}
+	// Durations may be given as strings, as they are in the configuration
+	assert.EqualValues(t, 10*time.Second, orderer.kap.Time)
+	assert.EqualValues(t, 20*time.Second, orderer.kap.Timeout)
+	assert.True(t, orderer.kap.PermitWithoutStream)
+	assert.Equal(t, 16*1024*1024, orderer.maxRecvMsgSize)
+	assert.Equal(t, 8*1024*1024, orderer.maxSendMsgSize)
+	// keep-alive and default call options (fail-fast and message sizes)
+	assert.Len(t, orderer.grpcDialOption, 2)
+
+	// The gRPC defaults apply if the options aren't configured
+	ordererConfig.GRPCOptions = nil
+	orderer, err = New(mocks.NewMockConfig(), FromOrdererConfig(ordererConfig))
+	if err != nil {
+		t.Fatalf("Failed to create new orderer FromOrdererConfig (%v)", err)
+	}
+	assert.Len(t, orderer.grpcDialOption, 1)
+}
+
 func getGRPCOpts(addr string, failFast bool, keepAliveOptions bool) *core.OrdererConfig {
 	grpcOpts := make(map[string]interface{})
 	//fail fast
@@ -533,7 +567,7 @@ func TestNewOrdererSecured(t *testing.T) {
 	defer mockCtrl.Finish()
 
 	config := mock_core.DefaultMockConfig(mockCtrl)
-	config.EXPECT().TimeoutOrDefault(core.OrdererConnection).Return(time.Second * 1).AnyTimes()
+	config.EXPECT().Timeout(core.OrdererConnection).Return(time.Second * 1).AnyTimes()
 
 	//Test grpc URL
 	url := "grpc://0.0.0.0:1234"
@@ -0,0 +1,224 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package orderer
+
+import (
+	grpcContext "context"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/errors/status"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+)
+
+// defaultFailureExpiry is the time during which an orderer that failed is tried after the other orderers
+const defaultFailureExpiry = 30 * time.Second
+
+// SelectionPolicy is the order in which a Selection tries the orderers
+type SelectionPolicy int
+
+const (
+	// RoundRobin starts each broadcast with the orderer following the one the previous broadcast started with
+	RoundRobin SelectionPolicy = iota
+	// Priority starts each broadcast with the first orderer, the next ones being tried only if it fails
+	Priority
+)
+
+// Selection broadcasts envelopes to one of a set of orderers (typically all the orderers of a channel),
+// failing over to the next orderer when an orderer is unavailable. An envelope that's rejected by an
+// orderer (e.g. BAD_REQUEST or FORBIDDEN) isn't sent to the other orderers.
+//
+// The health of the orderers is recorded: an orderer that failed is tried after the other orderers
+// until it succeeds again or until the failure expires.
+type Selection struct {
+	orderers      []fab.Orderer
+	policy        SelectionPolicy
+	failureExpiry time.Duration
+
+	mutex  sync.Mutex
+	next   int
+	health []ordererHealth
+}
+
+type ordererHealth struct {
+	lastSuccess time.Time
+	lastFailure time.Time
+}
+
+// failing tells whether the orderer failed after its last success, within the expiry
+func (h ordererHealth) failing(now time.Time, expiry time.Duration) bool {
+	return h.lastFailure.After(h.lastSuccess) && now.Sub(h.lastFailure) < expiry
+}
+
+// SelectionOption describes a functional parameter for NewSelection
+type SelectionOption func(*Selection)
+
+// WithSelectionPolicy sets the order in which the orderers are tried (RoundRobin by default)
+func WithSelectionPolicy(policy SelectionPolicy) SelectionOption {
+	return func(s *Selection) {
+		s.policy = policy
+	}
+}
+
+// WithFailureExpiry sets the time during which an orderer that failed is tried after the other orderers
+func WithFailureExpiry(expiry time.Duration) SelectionOption {
+	return func(s *Selection) {
+		s.failureExpiry = expiry
+	}
+}
+
+// NewSelection returns a Selection of the given orderers
+func NewSelection(orderers []fab.Orderer, opts ...SelectionOption) *Selection {
+	s := &Selection{
+		orderers:      orderers,
+		policy:        RoundRobin,
+		failureExpiry: defaultFailureExpiry,
+		health:        make([]ordererHealth, len(orderers)),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Orderers returns the orderers of the selection
+func (s *Selection) Orderers() []fab.Orderer {
+	return s.orderers
+}
+
+// Broadcast sends the envelope to the orderers in the order of the selection policy, until an orderer
+// accepts it. As with txn.BroadcastEnvelope, the failure of the orderers is returned as the error of the
+// transaction response; it's a *BroadcastError with the result of each attempt.
+func (s *Selection) Broadcast(envelope *fab.SignedEnvelope) (*fab.TransactionResponse, error) {
+	if len(s.orderers) == 0 {
+		return nil, errors.New("orderers not set")
+	}
+
+	var attempts []BroadcastAttempt
+	for _, i := range s.candidates() {
+		o := s.orderers[i]
+		logger.Debugf("Broadcasting envelope to orderer [%s]", o.URL())
+		_, err := o.SendBroadcast(envelope)
+		if err == nil {
+			s.record(i, true)
+			return &fab.TransactionResponse{Orderer: o.URL()}, nil
+		}
+
+		attempts = append(attempts, BroadcastAttempt{Orderer: o.URL(), Err: err})
+		if !failover(err) {
+			logger.Debugf("Orderer [%s] rejected the envelope: %s", o.URL(), err)
+			break
+		}
+		logger.Debugf("Broadcast to orderer [%s] failed, trying the next orderer: %s", o.URL(), err)
+		s.record(i, false)
+	}
+
+	last := attempts[len(attempts)-1]
+	return &fab.TransactionResponse{Orderer: last.Orderer, Err: &BroadcastError{Attempts: attempts}}, nil
+}
+
+// candidates returns the indexes of the orderers in the order they're tried: the healthy orderers in
+// the order of the policy, followed by the failing orderers from the least recent failure
+func (s *Selection) candidates() []int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	start := 0
+	if s.policy == RoundRobin {
+		start = s.next
+		s.next = (s.next + 1) % len(s.orderers)
+	}
+
+	now := time.Now()
+	var healthy, failing []int
+	for j := range s.orderers {
+		i := (start + j) % len(s.orderers)
+		if s.health[i].failing(now, s.failureExpiry) {
+			failing = append(failing, i)
+		} else {
+			healthy = append(healthy, i)
+		}
+	}
+	sort.SliceStable(failing, func(a, b int) bool {
+		return s.health[failing[a]].lastFailure.Before(s.health[failing[b]].lastFailure)
+	})
+	return append(healthy, failing...)
+}
+
+func (s *Selection) record(i int, success bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if success {
+		s.health[i].lastSuccess = time.Now()
+	} else {
+		s.health[i].lastFailure = time.Now()
+	}
+}
+
+// failover tells whether the error of an orderer is a failure of the orderer (it's unreachable, it timed
+// out or it's unavailable), in which case the envelope is sent to the next orderer
+func failover(err error) bool {
+	if s, ok := status.FromError(err); ok {
+		switch s.Group {
+		case status.OrdererClientStatus:
+			return s.Code == status.ConnectionFailed.ToInt32() || s.Code == status.Timeout.ToInt32()
+		case status.GRPCTransportStatus:
+			code := status.ToGRPCStatusCode(s.Code)
+			return code == codes.Unavailable || code == codes.DeadlineExceeded
+		case status.OrdererServerStatus:
+			return s.Code == int32(common.Status_SERVICE_UNAVAILABLE)
+		}
+		return false
+	}
+
+	cause := errors.Cause(err)
+	if cause == grpcContext.DeadlineExceeded || cause == io.EOF {
+		return true
+	}
+	_, ok := cause.(net.Error)
+	return ok
+}
+
+// BroadcastAttempt is the result of sending an envelope to an orderer
+type BroadcastAttempt struct {
+	Orderer string
+	Err     error
+}
+
+// BroadcastError is returned if none of the orderers of a Selection accepted the envelope
+type BroadcastError struct {
+	Attempts []BroadcastAttempt
+}
+
+func (e *BroadcastError) Error() string {
+	results := make([]string, len(e.Attempts))
+	for i, attempt := range e.Attempts {
+		results[i] = fmt.Sprintf("orderer [%s]: %s", attempt.Orderer, attempt.Err)
+	}
+	return fmt.Sprintf("broadcast failed after %d attempt(s) (%s)", len(e.Attempts), strings.Join(results, "; "))
+}
+
+// Status returns the status of the last attempt, so that the error is handled (e.g. retried) like the
+// error of that orderer
+func (e *BroadcastError) Status() *status.Status {
+	if len(e.Attempts) > 0 {
+		if s, ok := status.FromError(e.Attempts[len(e.Attempts)-1].Err); ok {
+			return s
+		}
+	}
+	return status.New(status.OrdererClientStatus, status.Unknown.ToInt32(), e.Error(), nil)
+}
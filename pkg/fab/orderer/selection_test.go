@@ -0,0 +1,209 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package orderer
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	grpccodes "google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/errors/status"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+)
+
+var testEnvelope = &fab.SignedEnvelope{Payload: []byte("payload"), Signature: []byte("signature")}
+
+func TestSelectionFailover(t *testing.T) {
+	orderer1, listener1 := newSelectionMockOrderer("orderer1")
+	orderer2, listener2 := newSelectionMockOrderer("orderer2")
+	orderer1.EnqueueSendBroadcastError(status.New(status.OrdererClientStatus, status.ConnectionFailed.ToInt32(), "connection refused", nil))
+
+	selection := NewSelection([]fab.Orderer{orderer1, orderer2})
+	resp, err := selection.Broadcast(testEnvelope)
+	if err != nil || resp.Err != nil {
+		t.Fatalf("expected the broadcast to succeed but got %v, %v", err, resp.Err)
+	}
+	if resp.Orderer != "orderer2" {
+		t.Fatalf("expected the envelope to be delivered by orderer2 but got [%s]", resp.Orderer)
+	}
+	expectBroadcast(t, listener1)
+	expectBroadcast(t, listener2)
+}
+
+func TestSelectionFailoverToOrderer(t *testing.T) {
+	grpcServer := grpc.NewServer()
+	defer grpcServer.Stop()
+	addr := startCustomizedMockServer(t, testOrdererURL, grpcServer, &mocks.MockBroadcastServer{})
+
+	// nothing listens on the address of the first orderer
+	orderer1, err := New(mocks.NewMockConfig(), WithURL("grpc://127.0.0.1:0"), WithInsecure())
+	if err != nil {
+		t.Fatalf("error creating orderer: %s", err)
+	}
+	orderer1.dialTimeout = time.Second
+	orderer2, err := New(mocks.NewMockConfig(), WithURL("grpc://"+addr), WithInsecure())
+	if err != nil {
+		t.Fatalf("error creating orderer: %s", err)
+	}
+
+	selection := NewSelection([]fab.Orderer{orderer1, orderer2}, WithSelectionPolicy(Priority))
+	resp, err := selection.Broadcast(testEnvelope)
+	if err != nil || resp.Err != nil {
+		t.Fatalf("expected the broadcast to succeed but got %v, %v", err, resp.Err)
+	}
+	if resp.Orderer != orderer2.URL() {
+		t.Fatalf("expected the envelope to be delivered by [%s] but got [%s]", orderer2.URL(), resp.Orderer)
+	}
+}
+
+func TestSelectionRejected(t *testing.T) {
+	orderer1, listener1 := newSelectionMockOrderer("orderer1")
+	orderer2, listener2 := newSelectionMockOrderer("orderer2")
+	orderer1.EnqueueSendBroadcastError(status.New(status.OrdererServerStatus, int32(common.Status_BAD_REQUEST), "bad request", nil))
+
+	selection := NewSelection([]fab.Orderer{orderer1, orderer2})
+	resp, err := selection.Broadcast(testEnvelope)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	broadcastErr, ok := resp.Err.(*BroadcastError)
+	if !ok || len(broadcastErr.Attempts) != 1 {
+		t.Fatalf("expected a broadcast error with one attempt but got %v", resp.Err)
+	}
+	s, ok := status.FromError(errors.Wrap(resp.Err, "orderer failed"))
+	if !ok || s.Group != status.OrdererServerStatus || s.Code != int32(common.Status_BAD_REQUEST) {
+		t.Fatalf("expected the status of the rejection but got %v", s)
+	}
+	expectBroadcast(t, listener1)
+	expectNoBroadcast(t, listener2)
+}
+
+func TestSelectionAllFailed(t *testing.T) {
+	orderer1, _ := newSelectionMockOrderer("orderer1")
+	orderer2, _ := newSelectionMockOrderer("orderer2")
+	orderer1.EnqueueSendBroadcastError(errors.Wrap(status.NewFromGRPCStatus(grpcstatus.New(grpccodes.Unavailable, "unavailable")), "broadcast recv failed"))
+	orderer2.EnqueueSendBroadcastError(status.New(status.OrdererClientStatus, status.ConnectionFailed.ToInt32(), "connection refused", nil))
+
+	selection := NewSelection([]fab.Orderer{orderer1, orderer2}, WithSelectionPolicy(Priority))
+	resp, err := selection.Broadcast(testEnvelope)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	broadcastErr, ok := resp.Err.(*BroadcastError)
+	if !ok || len(broadcastErr.Attempts) != 2 {
+		t.Fatalf("expected a broadcast error with two attempts but got %v", resp.Err)
+	}
+	if resp.Orderer != "orderer2" {
+		t.Fatalf("expected the response of the last orderer but got [%s]", resp.Orderer)
+	}
+	for _, url := range []string{"orderer1", "orderer2"} {
+		if !strings.Contains(resp.Err.Error(), url) {
+			t.Fatalf("expected the error to include the attempt of [%s] but got %s", url, resp.Err)
+		}
+	}
+	if s := broadcastErr.Status(); s.Group != status.OrdererClientStatus || s.Code != status.ConnectionFailed.ToInt32() {
+		t.Fatalf("expected the status of the last attempt but got %v", s)
+	}
+}
+
+func TestSelectionHealth(t *testing.T) {
+	orderer1, listener1 := newSelectionMockOrderer("orderer1")
+	orderer2, listener2 := newSelectionMockOrderer("orderer2")
+	orderer1.EnqueueSendBroadcastError(errors.Wrap(status.NewFromGRPCStatus(grpcstatus.New(grpccodes.DeadlineExceeded, "timeout")), "broadcast recv failed"))
+
+	selection := NewSelection([]fab.Orderer{orderer1, orderer2}, WithSelectionPolicy(Priority))
+	broadcast(t, selection, "orderer2")
+	expectBroadcast(t, listener1)
+	expectBroadcast(t, listener2)
+
+	// the orderer that failed is tried last until its failure expires
+	broadcast(t, selection, "orderer2")
+	expectNoBroadcast(t, listener1)
+	expectBroadcast(t, listener2)
+
+	selection.failureExpiry = 0
+	broadcast(t, selection, "orderer1")
+	expectBroadcast(t, listener1)
+	expectNoBroadcast(t, listener2)
+}
+
+func TestSelectionRoundRobin(t *testing.T) {
+	orderer1, _ := newSelectionMockOrderer("orderer1")
+	orderer2, _ := newSelectionMockOrderer("orderer2")
+
+	selection := NewSelection([]fab.Orderer{orderer1, orderer2})
+	for _, url := range []string{"orderer1", "orderer2", "orderer1", "orderer2"} {
+		broadcast(t, selection, url)
+	}
+
+	if _, err := NewSelection(nil).Broadcast(testEnvelope); err == nil {
+		t.Fatalf("expected error broadcasting without orderers")
+	}
+}
+
+func TestFailover(t *testing.T) {
+	tests := []struct {
+		err      error
+		failover bool
+	}{
+		{status.New(status.OrdererClientStatus, status.ConnectionFailed.ToInt32(), "", nil), true},
+		{status.New(status.OrdererClientStatus, status.Timeout.ToInt32(), "", nil), true},
+		{status.NewFromGRPCStatus(grpcstatus.New(grpccodes.Unavailable, "")), true},
+		{status.NewFromGRPCStatus(grpcstatus.New(grpccodes.DeadlineExceeded, "")), true},
+		{status.NewFromGRPCStatus(grpcstatus.New(grpccodes.PermissionDenied, "")), false},
+		{status.New(status.OrdererServerStatus, int32(common.Status_SERVICE_UNAVAILABLE), "", nil), true},
+		{status.New(status.OrdererServerStatus, int32(common.Status_BAD_REQUEST), "", nil), false},
+		{status.New(status.OrdererServerStatus, int32(common.Status_FORBIDDEN), "", nil), false},
+		{errors.New("unknown"), false},
+	}
+	for _, test := range tests {
+		if failover := failover(test.err); failover != test.failover {
+			t.Errorf("expected failover=%t for error [%s]", test.failover, test.err)
+		}
+	}
+}
+
+func newSelectionMockOrderer(url string) (mocks.MockOrderer, chan *fab.SignedEnvelope) {
+	listener := make(chan *fab.SignedEnvelope, 10)
+	return mocks.NewMockOrderer(url, listener).(mocks.MockOrderer), listener
+}
+
+func broadcast(t *testing.T, selection *Selection, expectedOrderer string) {
+	resp, err := selection.Broadcast(testEnvelope)
+	if err != nil || resp.Err != nil {
+		t.Fatalf("expected the broadcast to succeed but got %v, %v", err, resp.Err)
+	}
+	if resp.Orderer != expectedOrderer {
+		t.Fatalf("expected the envelope to be delivered by [%s] but got [%s]", expectedOrderer, resp.Orderer)
+	}
+}
+
+func expectBroadcast(t *testing.T, listener chan *fab.SignedEnvelope) {
+	select {
+	case envelope := <-listener:
+		if envelope != testEnvelope {
+			t.Fatalf("unexpected envelope %v", envelope)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected the envelope to be broadcast")
+	}
+}
+
+func expectNoBroadcast(t *testing.T, listener chan *fab.SignedEnvelope) {
+	select {
+	case envelope := <-listener:
+		t.Fatalf("unexpected broadcast of %v", envelope)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
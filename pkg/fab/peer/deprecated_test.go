@@ -31,7 +31,7 @@ func TestDeprecatedNewPeerWithCertNoTLS(t *testing.T) {
 	defer mockCtrl.Finish()
 	url := "http://example.com"
 	config := mock_core.NewMockConfig(mockCtrl)
-	config.EXPECT().TimeoutOrDefault(core.Endorser).Return(time.Second * 5)
+	config.EXPECT().Timeout(core.Endorser).Return(time.Second * 5)
 	config.EXPECT().TLSCACertPool(gomock.Any()).Return(mock_core.CertPool, nil).AnyTimes()
 
 	p, err := NewPeer(url, config)
@@ -103,7 +103,7 @@ func TestDeprecatedEnrollmentCert(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
 	config := mock_core.NewMockConfig(mockCtrl)
-	config.EXPECT().TimeoutOrDefault(core.Endorser).Return(time.Second * 5)
+	config.EXPECT().Timeout(core.Endorser).Return(time.Second * 5)
 	config.EXPECT().TLSCACertPool(gomock.Any()).Return(mock_core.CertPool, nil).AnyTimes()
 
 	peer, err := NewPeer(peer1URL, config)
@@ -134,7 +134,7 @@ func TestDeprecatedRoles(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
 	config := mock_core.NewMockConfig(mockCtrl)
-	config.EXPECT().TimeoutOrDefault(core.Endorser).Return(time.Second * 5)
+	config.EXPECT().Timeout(core.Endorser).Return(time.Second * 5)
 	config.EXPECT().TLSCACertPool(gomock.Any()).Return(mock_core.CertPool, nil).AnyTimes()
 
 	peer, err := NewPeer(peer1URL, config)
@@ -161,7 +161,7 @@ func TestDeprecatedNames(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
 	config := mock_core.NewMockConfig(mockCtrl)
-	config.EXPECT().TimeoutOrDefault(core.Endorser).Return(time.Second * 5)
+	config.EXPECT().Timeout(core.Endorser).Return(time.Second * 5)
 	config.EXPECT().TLSCACertPool(gomock.Any()).Return(mock_core.CertPool, nil).AnyTimes()
 
 	peer, err := NewPeer(peer1URL, config)
@@ -204,7 +204,7 @@ func TestDeprecatedPeersToTxnProcessors(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
 	config := mock_core.NewMockConfig(mockCtrl)
-	config.EXPECT().TimeoutOrDefault(core.Endorser).Return(time.Second * 5)
+	config.EXPECT().Timeout(core.Endorser).Return(time.Second * 5)
 	config.EXPECT().TLSCACertPool(gomock.Any()).Return(mock_core.CertPool, nil).AnyTimes()
 
 	peer1, err := NewPeer(peer1URL, config)
@@ -212,7 +212,7 @@ func TestDeprecatedPeersToTxnProcessors(t *testing.T) {
 		t.Fatalf("Failed to create NewPeer error(%v)", err)
 	}
 
-	config.EXPECT().TimeoutOrDefault(core.Endorser).Return(time.Second * 5)
+	config.EXPECT().Timeout(core.Endorser).Return(time.Second * 5)
 	peer2, err := NewPeer(peer2URL, config)
 	if err != nil {
 		t.Fatalf("Failed to create NewPeer error(%v)", err)
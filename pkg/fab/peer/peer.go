@@ -12,10 +12,12 @@ import (
 
 	"crypto/x509"
 
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
 	"github.com/hyperledger/fabric-sdk-go/pkg/core/config/urlutil"
 	"github.com/hyperledger/fabric-sdk-go/pkg/errors/status"
+	fabcomm "github.com/hyperledger/fabric-sdk-go/pkg/fab/comm"
 	"github.com/hyperledger/fabric-sdk-go/pkg/logging"
 	"github.com/spf13/cast"
 	"google.golang.org/grpc/keepalive"
@@ -41,7 +43,11 @@ type Peer struct {
 	url                   string
 	kap                   keepalive.ClientParameters
 	failFast              bool
+	maxRecvMsgSize        int
+	maxSendMsgSize        int
 	inSecure              bool
+	proxyURL              string
+	connector             api.Connector
 }
 
 // Option describes a functional parameter for the New constructor
@@ -70,7 +76,11 @@ func New(config core.Config, opts ...Option) (*Peer, error) {
 			config:             peer.config,
 			kap:                peer.kap,
 			failFast:           peer.failFast,
+			maxRecvMsgSize:     peer.maxRecvMsgSize,
+			maxSendMsgSize:     peer.maxSendMsgSize,
 			allowInsecure:      peer.inSecure,
+			proxyURL:           peer.proxyURL,
+			connector:          peer.connector,
 		}
 		peer.processor, err = newPeerEndorser(&endorseRequest)
 
@@ -118,6 +128,28 @@ func WithInsecure() Option {
 	}
 }
 
+// WithConnector is a functional option for the peer.New constructor that configures the connector that dials
+// the connections to the peer, e.g. the connector of the SDK that shares the connections between the clients.
+// By default a new connection is dialed for each proposal.
+func WithConnector(connector api.Connector) Option {
+	return func(p *Peer) error {
+		p.connector = connector
+
+		return nil
+	}
+}
+
+// WithProxyURL is a functional option for the peer.New constructor that configures the URL of the proxy
+// through which the peer is dialed (e.g. http://proxy:3128 or socks5://proxy:1080).
+// By default the peer is dialed through the proxy of the environment (HTTPS_PROXY and NO_PROXY), if any.
+func WithProxyURL(proxyURL string) Option {
+	return func(p *Peer) error {
+		p.proxyURL = proxyURL
+
+		return nil
+	}
+}
+
 // FromPeerConfig is a functional option for the peer.New constructor that configures a new peer
 // from a apiconfig.NetworkPeer struct
 func FromPeerConfig(peerCfg *core.NetworkPeer) Option {
@@ -142,6 +174,9 @@ func FromPeerConfig(peerCfg *core.NetworkPeer) Option {
 		p.mspID = peerCfg.MspID
 		p.kap = getKeepAliveOptions(peerCfg)
 		p.failFast = getFailFast(peerCfg)
+		p.maxRecvMsgSize = cast.ToInt(peerCfg.GRPCOptions["max-recv-msg-size"])
+		p.maxSendMsgSize = cast.ToInt(peerCfg.GRPCOptions["max-send-msg-size"])
+		p.proxyURL = fabcomm.ProxyURL(peerCfg.GRPCOptions)
 		return nil
 	}
 }
@@ -267,3 +302,12 @@ func PeersToTxnProcessors(peers []fab.Peer) []fab.ProposalProcessor {
 	}
 	return tpp
 }
+
+// TLSCertHash returns the hash of the client TLS certificate used on the connection to the peer.
+// Nil is returned if the connection to the peer doesn't use a client certificate.
+func (p *Peer) TLSCertHash() []byte {
+	if hasher, ok := p.processor.(fab.TLSCertHasher); ok {
+		return hasher.TLSCertHash()
+	}
+	return nil
+}
@@ -80,7 +80,7 @@ func TestNewPeerTLSFromCertBad(t *testing.T) {
 
 	//apiconfig := mock_core.DefaultMockConfig(mockCtrl)
 	config := mock_core.NewMockConfig(mockCtrl)
-	config.EXPECT().TimeoutOrDefault(core.Endorser).Return(time.Second * 5)
+	config.EXPECT().Timeout(core.Endorser).Return(time.Second * 5)
 	config.EXPECT().TLSCACertPool(gomock.Any()).Return(nil, errors.New("failed to get certpool")).AnyTimes()
 
 	url := "grpcs://0.0.0.0:1234"
@@ -300,6 +300,59 @@ func TestPeerOptions(t *testing.T) {
 	}
 }
 
+// TestPeerGRPCOptions validates that the gRPC options of a peer entry are passed to the endorser
+func TestPeerGRPCOptions(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	config := mock_core.DefaultMockConfig(mockCtrl)
+
+	networkPeer := &core.NetworkPeer{
+		PeerConfig: core.PeerConfig{
+			URL: "grpc://abc.com",
+			GRPCOptions: map[string]interface{}{
+				"keep-alive-time":    "10s",
+				"keep-alive-timeout": "20s",
+				"keep-alive-permit":  true,
+				"fail-fast":          false,
+				"max-recv-msg-size":  16 * 1024 * 1024,
+				"max-send-msg-size":  8 * 1024 * 1024,
+			},
+		},
+		MspID: "Org1MSP",
+	}
+	p, err := New(config, FromPeerConfig(networkPeer))
+	if err != nil {
+		t.Fatalf("Failed to create new peer FromPeerConfig (%v)", err)
+	}
+	if p.kap.Time != 10*time.Second || p.kap.Timeout != 20*time.Second || !p.kap.PermitWithoutStream || p.failFast {
+		t.Fatalf("Expected the configured keep-alive and fail-fast options but got %+v and %t", p.kap, p.failFast)
+	}
+	if p.maxRecvMsgSize != 16*1024*1024 || p.maxSendMsgSize != 8*1024*1024 {
+		t.Fatalf("Expected the configured message sizes but got %d and %d", p.maxRecvMsgSize, p.maxSendMsgSize)
+	}
+	endorser, ok := p.processor.(*peerEndorser)
+	if !ok {
+		t.Fatalf("Expected a peer endorser but got %T", p.processor)
+	}
+	// keep-alive and default call options (fail-fast and message sizes) plus blocking dial
+	if len(endorser.grpcDialOption) != 3 {
+		t.Fatalf("Expected 3 dial options but got %d", len(endorser.grpcDialOption))
+	}
+
+	// The gRPC defaults apply if the options aren't configured
+	networkPeer.GRPCOptions = nil
+	p, err = New(config, FromPeerConfig(networkPeer))
+	if err != nil {
+		t.Fatalf("Failed to create new peer FromPeerConfig (%v)", err)
+	}
+	if p.maxRecvMsgSize != 0 || p.maxSendMsgSize != 0 || p.kap.Time != 0 || !p.failFast {
+		t.Fatalf("Expected the default options but got %+v", p)
+	}
+	if len(p.processor.(*peerEndorser).grpcDialOption) != 2 {
+		t.Fatalf("Expected 2 dial options but got %d", len(p.processor.(*peerEndorser).grpcDialOption))
+	}
+}
+
 // TestNewPeerSecured validates that insecure option
 func TestNewPeerSecured(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
@@ -8,32 +8,37 @@ package peer
 
 import (
 	grpccontext "context"
+	"crypto/tls"
 	"crypto/x509"
 	"time"
 
 	"github.com/pkg/errors"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/keepalive"
 	grpcstatus "google.golang.org/grpc/status"
 
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
 	"github.com/hyperledger/fabric-sdk-go/pkg/core/config/comm"
 	"github.com/hyperledger/fabric-sdk-go/pkg/core/config/urlutil"
 	"github.com/hyperledger/fabric-sdk-go/pkg/errors/status"
+	fabcomm "github.com/hyperledger/fabric-sdk-go/pkg/fab/comm"
 	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
 )
 
 // peerEndorser enables access to a GRPC-based endorser for running transaction proposal simulations
 type peerEndorser struct {
-	grpcDialOption       []grpc.DialOption
-	target               string
-	dialTimeout          time.Duration
-	failFast             bool
-	transportCredentials credentials.TransportCredentials
-	secured              bool
-	allowInsecure        bool
+	grpcDialOption []grpc.DialOption
+	target         string
+	dialTimeout    time.Duration
+	failFast       bool
+	tlsConfig      *tls.Config
+	secured        bool
+	allowInsecure  bool
+	tlsCertHash    []byte
+	connector      api.Connector
+	dialKey        string
 }
 
 type peerEndorserRequest struct {
@@ -44,7 +49,11 @@ type peerEndorserRequest struct {
 	config             core.Config
 	kap                keepalive.ClientParameters
 	failFast           bool
+	maxRecvMsgSize     int
+	maxSendMsgSize     int
 	allowInsecure      bool
+	proxyURL           string
+	connector          api.Connector
 }
 
 func newPeerEndorser(endorseReq *peerEndorserRequest) (*peerEndorser, error) {
@@ -53,30 +62,50 @@ func newPeerEndorser(endorseReq *peerEndorserRequest) (*peerEndorser, error) {
 	}
 
 	// Construct dialer options for the connection
-	var opts []grpc.DialOption
-	if endorseReq.kap.Time > 0 || endorseReq.kap.Timeout > 0 {
-		opts = append(opts, grpc.WithKeepaliveParams(endorseReq.kap))
+	settings := endorseReq.dialSettings()
+	opts, err := settings.DialOptions(endorseReq.target)
+	if err != nil {
+		return nil, err
 	}
-	opts = append(opts, grpc.WithDefaultCallOptions(grpc.FailFast(endorseReq.failFast)))
 
-	timeout := endorseReq.config.TimeoutOrDefault(core.Endorser)
-
-	if endorseReq.dialBlocking { // TODO: configurable?
-		opts = append(opts, grpc.WithBlock())
-	}
+	timeout := endorseReq.config.Timeout(core.Endorser)
 
 	tlsConfig, err := comm.TLSConfig(endorseReq.certificate, endorseReq.serverHostOverride, endorseReq.config)
 	if err != nil {
 		return nil, err
 	}
 
+	secured := urlutil.AttemptSecured(endorseReq.target)
+
+	var tlsCertHash []byte
+	if secured {
+		tlsCertHash = comm.TLSCertHashFromTLSConfig(tlsConfig)
+	}
+
+	connector := endorseReq.connector
+	if connector == nil {
+		connector = &fabcomm.DirectConnector{}
+	}
+
 	pc := &peerEndorser{grpcDialOption: opts, target: urlutil.ToAddress(endorseReq.target), dialTimeout: timeout,
-		transportCredentials: credentials.NewTLS(tlsConfig), secured: urlutil.AttemptSecured(endorseReq.target),
-		allowInsecure: endorseReq.allowInsecure}
+		tlsConfig: tlsConfig, secured: secured, allowInsecure: endorseReq.allowInsecure,
+		tlsCertHash: tlsCertHash, connector: connector, dialKey: settings.Key()}
 
 	return pc, nil
 }
 
+// dialSettings returns the settings of the dial options of the connections to the endorser
+func (r *peerEndorserRequest) dialSettings() fabcomm.DialSettings {
+	return fabcomm.DialSettings{
+		KeepAlive:      r.kap,
+		FailFast:       r.failFast,
+		MaxRecvMsgSize: r.maxRecvMsgSize,
+		MaxSendMsgSize: r.maxSendMsgSize,
+		ProxyURL:       r.proxyURL,
+		Block:          r.dialBlocking,
+	}
+}
+
 // ProcessTransactionProposal sends the transaction proposal to a peer and returns the response.
 func (p *peerEndorser) ProcessTransactionProposal(request fab.ProcessProposalRequest) (*fab.TransactionProposalResponse, error) {
 	logger.Debugf("Processing proposal using endorser: %s", p.target)
@@ -95,24 +124,27 @@ func (p *peerEndorser) ProcessTransactionProposal(request fab.ProcessProposalReq
 	return &tpr, nil
 }
 
+// TLSCertHash returns the hash of the client TLS certificate presented to the endorser
+// (nil if the connection is insecure or no client certificate is configured)
+func (p *peerEndorser) TLSCertHash() []byte {
+	return p.tlsCertHash
+}
+
 func (p *peerEndorser) conn(secured bool) (*grpc.ClientConn, error) {
-	// Establish connection to Ordering Service
-	var grpcOpts []grpc.DialOption
+	var tlsConfig *tls.Config
 	if secured {
-		grpcOpts = append(p.grpcDialOption, grpc.WithTransportCredentials(p.transportCredentials))
-	} else {
-		grpcOpts = append(p.grpcDialOption, grpc.WithInsecure())
+		tlsConfig = p.tlsConfig
 	}
 
-	ctx := grpccontext.Background()
+	ctx := fabcomm.WithDialKey(grpccontext.Background(), p.dialKey)
 	ctx, cancel := grpccontext.WithTimeout(ctx, p.dialTimeout)
 	defer cancel()
 
-	return grpc.DialContext(ctx, p.target, grpcOpts...)
+	return p.connector.DialContext(ctx, p.target, tlsConfig, p.grpcDialOption...)
 }
 
 func (p *peerEndorser) releaseConn(conn *grpc.ClientConn) {
-	conn.Close()
+	p.connector.ReleaseConn(conn)
 }
 
 func (p *peerEndorser) sendProposal(proposal fab.ProcessProposalRequest, secured bool) (*pb.ProposalResponse, error) {
@@ -7,10 +7,15 @@ SPDX-License-Identifier: Apache-2.0
 package peer
 
 import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
 	"crypto/x509"
 	"fmt"
 	"net"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -18,14 +23,18 @@ import (
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/grpc"
 	grpcCodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/keepalive"
+	grpcpeer "google.golang.org/grpc/peer"
 
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
 	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
 
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core/mocks"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config/cryptoutil/testutils"
 	"github.com/hyperledger/fabric-sdk-go/pkg/errors/status"
+	fabcomm "github.com/hyperledger/fabric-sdk-go/pkg/fab/comm"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
 )
 
@@ -279,11 +288,43 @@ func TestProcessProposalGoodDial(t *testing.T) {
 	}
 }
 
+func TestProcessProposalMaxRecvMsgSize(t *testing.T) {
+	grpcServer := grpc.NewServer()
+	defer grpcServer.Stop()
+	_, addr := startEndorserServer(t, grpcServer)
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	config := mock_core.DefaultMockConfig(mockCtrl)
+	config.EXPECT().Timeout(gomock.Any()).Return(time.Second * 1).AnyTimes()
+
+	// The response is larger than the configured maximum
+	request := getPeerEndorserRequest("grpc://"+addr, nil, "", true, config, kap, false, true)
+	request.maxRecvMsgSize = 10
+	conn, err := newPeerEndorser(request)
+	if err != nil {
+		t.Fatalf("Peer conn construction error (%v)", err)
+	}
+	_, err = conn.ProcessTransactionProposal(mockProcessProposalRequest())
+	if err == nil || !strings.Contains(err.Error(), "larger than max") {
+		t.Fatalf("Expected error for a response that's larger than the maximum message size but got: %v", err)
+	}
+
+	request.maxRecvMsgSize = 1024 * 1024
+	conn, err = newPeerEndorser(request)
+	if err != nil {
+		t.Fatalf("Peer conn construction error (%v)", err)
+	}
+	if _, err = conn.ProcessTransactionProposal(mockProcessProposalRequest()); err != nil {
+		t.Fatalf("Process proposal failed (%v)", err)
+	}
+}
+
 func testProcessProposal(t *testing.T, url string) (*fab.TransactionProposalResponse, error) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
 	config := mock_core.DefaultMockConfig(mockCtrl)
-	config.EXPECT().TimeoutOrDefault(gomock.Any()).Return(time.Second * 1).AnyTimes()
+	config.EXPECT().Timeout(gomock.Any()).Return(time.Second * 1).AnyTimes()
 
 	conn, err := newPeerEndorser(getPeerEndorserRequest(url, nil, "", true, config, kap, false, true))
 	if err != nil {
@@ -359,3 +400,138 @@ func TestEndorserRPCError(t *testing.T) {
 	grpcCode := status.ToGRPCStatusCode(statusError.Code)
 	assert.Equal(t, grpcCodes.Unknown, grpcCode)
 }
+
+// TestProcessProposalSharedConnection validates that sequential proposals to the same endorser
+// reuse the connection of the connector.
+func TestProcessProposalSharedConnection(t *testing.T) {
+	grpcServer := grpc.NewServer()
+	defer grpcServer.Stop()
+
+	lis, err := net.Listen("tcp", testAddress)
+	if err != nil {
+		t.Fatalf("Error starting test server %s", err)
+	}
+	countingLis := &countingListener{Listener: lis}
+	pb.RegisterEndorserServer(grpcServer, &mocks.MockEndorserServer{})
+	go grpcServer.Serve(countingLis)
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	config := mock_core.DefaultMockConfig(mockCtrl)
+	config.EXPECT().Timeout(gomock.Any()).Return(time.Second * 1).AnyTimes()
+
+	connector := fabcomm.NewCachingConnector(time.Minute)
+	defer connector.Close()
+
+	request := getPeerEndorserRequest("grpc://"+lis.Addr().String(), nil, "", true, config, kap, false, true)
+	request.connector = connector
+	conn, err := newPeerEndorser(request)
+	if err != nil {
+		t.Fatalf("Peer conn construction error (%v)", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := conn.ProcessTransactionProposal(mockProcessProposalRequest()); err != nil {
+			t.Fatalf("Process proposal failed (%v)", err)
+		}
+	}
+
+	assert.Equal(t, 1, countingLis.accepted(), "Expected the proposals to share one connection")
+	assert.Equal(t, 1, connector.NumConns(), "Expected the connector to cache the connection")
+}
+
+// countingListener counts the accepted connections
+type countingListener struct {
+	net.Listener
+	mutex sync.Mutex
+	count int
+}
+
+func (l *countingListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err == nil {
+		l.mutex.Lock()
+		l.count++
+		l.mutex.Unlock()
+	}
+	return conn, err
+}
+
+func (l *countingListener) accepted() int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.count
+}
+
+// TestProcessProposalTLSCertHash validates that the TLS cert hash exposed by the endorser
+// is the hash of the client certificate that's presented to the endorser server.
+func TestProcessProposalTLSCertHash(t *testing.T) {
+	server := testutils.NewServerCert(t, "127.0.0.1")
+	serverCert, serverX509 := server.TLS, server.X509
+	clientCert := testutils.NewClientCert(t, "client").TLS
+
+	certPool := x509.NewCertPool()
+	certPool.AddCert(serverX509)
+
+	grpcServer := grpc.NewServer(grpc.Creds(credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientAuth:   tls.RequireAnyClientCert,
+	})))
+	defer grpcServer.Stop()
+
+	lis, err := net.Listen("tcp", testAddress)
+	if err != nil {
+		t.Fatalf("Error starting test server %s", err)
+	}
+	endorserServer := &tlsEndorserServer{MockEndorserServer: &mocks.MockEndorserServer{}}
+	pb.RegisterEndorserServer(grpcServer, endorserServer)
+	go grpcServer.Serve(lis)
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	config := mock_core.NewMockConfig(mockCtrl)
+	config.EXPECT().TLSCACertPool().Return(certPool, nil).AnyTimes()
+	config.EXPECT().TLSCACertPool(gomock.Any()).Return(certPool, nil).AnyTimes()
+	config.EXPECT().TLSClientCerts().Return([]tls.Certificate{clientCert}, nil).AnyTimes()
+	config.EXPECT().Timeout(gomock.Any()).Return(time.Second * 5).AnyTimes()
+
+	conn, err := newPeerEndorser(getPeerEndorserRequest("grpcs://"+lis.Addr().String(), nil, "", true, config, kap, false, false))
+	if err != nil {
+		t.Fatalf("Peer conn construction error (%v)", err)
+	}
+
+	if _, err := conn.ProcessTransactionProposal(mockProcessProposalRequest()); err != nil {
+		t.Fatalf("Process proposal failed (%v)", err)
+	}
+
+	presentedCert := endorserServer.clientCert()
+	if presentedCert == nil {
+		t.Fatalf("Expecting client certificate to be presented to the endorser server")
+	}
+	expectedHash := sha256.Sum256(presentedCert.Raw)
+	assert.Equal(t, expectedHash[:], conn.TLSCertHash(), "TLS cert hash does not match the certificate presented to the server")
+}
+
+// tlsEndorserServer records the client certificate presented on the TLS connection
+type tlsEndorserServer struct {
+	*mocks.MockEndorserServer
+	mutex sync.Mutex
+	cert  *x509.Certificate
+}
+
+func (s *tlsEndorserServer) ProcessProposal(ctx context.Context, proposal *pb.SignedProposal) (*pb.ProposalResponse, error) {
+	if p, ok := grpcpeer.FromContext(ctx); ok {
+		if tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo); ok && len(tlsInfo.State.PeerCertificates) > 0 {
+			s.mutex.Lock()
+			s.cert = tlsInfo.State.PeerCertificates[0]
+			s.mutex.Unlock()
+		}
+	}
+	return s.MockEndorserServer.ProcessProposal(ctx, proposal)
+}
+
+func (s *tlsEndorserServer) clientCert() *x509.Certificate {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.cert
+}
@@ -0,0 +1,255 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package policy builds signature policies, such as the endorsement policies of chaincodes, and converts
+// them from and to their string form, for example AND('Org1MSP.member', OR('Org2MSP.admin', 'Org3MSP.member')).
+package policy
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/common/cauthdsl"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+	mb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/msp"
+)
+
+// Role is the MSP role of the signers that satisfy a policy
+type Role string
+
+const (
+	// Member is any member of the MSP
+	Member Role = "member"
+	// Admin is an admin of the MSP
+	Admin Role = "admin"
+	// Client is a client of the MSP
+	Client Role = "client"
+	// Peer is a peer of the MSP
+	Peer Role = "peer"
+	// Orderer is an orderer of the MSP
+	Orderer Role = "orderer"
+)
+
+var roles = map[Role]mb.MSPRole_MSPRoleType{
+	Member:  mb.MSPRole_MEMBER,
+	Admin:   mb.MSPRole_ADMIN,
+	Client:  mb.MSPRole_CLIENT,
+	Peer:    mb.MSPRole_PEER,
+	Orderer: mb.MSPRole_ORDERER,
+}
+
+// SignedBy returns a policy that requires the signature of a signer with the given role in the given MSP.
+// The policy of an unknown role is rejected by String and by the peers.
+func SignedBy(mspID string, role Role) *common.SignaturePolicyEnvelope {
+	// marshalling an MSPRole doesn't fail
+	principal, _ := proto.Marshal(&mb.MSPRole{MspIdentifier: mspID, Role: mspRole(role)})
+
+	return &common.SignaturePolicyEnvelope{
+		Rule:       cauthdsl.SignedBy(0),
+		Identities: []*mb.MSPPrincipal{{PrincipalClassification: mb.MSPPrincipal_ROLE, Principal: principal}},
+	}
+}
+
+// And returns a policy that requires all of the given policies
+func And(policies ...*common.SignaturePolicyEnvelope) *common.SignaturePolicyEnvelope {
+	return OutOf(len(nonNil(policies)), policies...)
+}
+
+// Or returns a policy that requires one of the given policies
+func Or(policies ...*common.SignaturePolicyEnvelope) *common.SignaturePolicyEnvelope {
+	return OutOf(1, policies...)
+}
+
+// OutOf returns a policy that requires n of the given policies. The identities of the policies are
+// merged, so that each identity appears once in the returned policy. Nil policies are ignored.
+func OutOf(n int, policies ...*common.SignaturePolicyEnvelope) *common.SignaturePolicyEnvelope {
+	envelope := &common.SignaturePolicyEnvelope{}
+	var rules []*common.SignaturePolicy
+	for _, policy := range nonNil(policies) {
+		indexes := make([]int32, len(policy.Identities))
+		for i, identity := range policy.Identities {
+			indexes[i] = addIdentity(envelope, identity)
+		}
+		rules = append(rules, reindex(policy.Rule, indexes))
+	}
+	envelope.Rule = cauthdsl.NOutOf(int32(n), rules)
+	return envelope
+}
+
+// FromString parses a policy in string form. A policy is one of the gates AND(P, ...), OR(P, ...) and
+// OutOf(N, P, ...), where each P is either a principal 'MSPID.role' (role is one of member, admin, client,
+// peer or orderer) or another gate.
+func FromString(policy string) (*common.SignaturePolicyEnvelope, error) {
+	if strings.TrimSpace(policy) == "" {
+		return nil, errors.New("policy is empty")
+	}
+
+	envelope, err := parse(policy)
+	if err != nil {
+		return nil, errors.WithMessage(err, fmt.Sprintf("invalid policy %s", policy))
+	}
+
+	if _, err := String(envelope); err != nil {
+		return nil, errors.WithMessage(err, fmt.Sprintf("invalid policy %s", policy))
+	}
+	return envelope, nil
+}
+
+// parse parses the policy with the cauthdsl parser, which may panic on malformed input
+func parse(policy string) (envelope *common.SignaturePolicyEnvelope, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Errorf("parse failed: %v", r)
+		}
+	}()
+
+	envelope, err = cauthdsl.FromString(policy)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse failed")
+	}
+	if envelope.Rule == nil {
+		return nil, errors.New("policy is not a principal nor a gate")
+	}
+	return envelope, nil
+}
+
+// String returns the canonical string form of the policy, for example
+// AND('Org1MSP.member', OR('Org2MSP.admin', 'Org3MSP.member')). A gate that requires all of its policies
+// is written as AND, a gate that requires one of several policies as OR, and any other gate as OutOf.
+// A policy that only requires a principal is written as the gate AND of the principal. Only policies of
+// role principals can be written.
+func String(envelope *common.SignaturePolicyEnvelope) (string, error) {
+	if envelope == nil || envelope.Rule == nil {
+		return "", errors.New("policy is empty")
+	}
+
+	principals := make([]string, len(envelope.Identities))
+	for i, identity := range envelope.Identities {
+		principal, err := principalString(identity)
+		if err != nil {
+			return "", errors.WithMessage(err, fmt.Sprintf("invalid principal at index %d", i))
+		}
+		principals[i] = principal
+	}
+
+	s, err := ruleString(envelope.Rule, principals)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := envelope.Rule.Type.(*common.SignaturePolicy_SignedBy); ok {
+		return "AND(" + s + ")", nil
+	}
+	return s, nil
+}
+
+func ruleString(rule *common.SignaturePolicy, principals []string) (string, error) {
+	switch t := rule.Type.(type) {
+	case *common.SignaturePolicy_SignedBy:
+		if t.SignedBy < 0 || int(t.SignedBy) >= len(principals) {
+			return "", errors.Errorf("signed-by index %d is out of range", t.SignedBy)
+		}
+		return principals[t.SignedBy], nil
+	case *common.SignaturePolicy_NOutOf_:
+		if t.NOutOf == nil || len(t.NOutOf.Rules) == 0 {
+			return "", errors.New("gate has no policies")
+		}
+		n := int(t.NOutOf.N)
+		if n < 1 || n > len(t.NOutOf.Rules) {
+			return "", errors.Errorf("gate requires %d of %d policies", n, len(t.NOutOf.Rules))
+		}
+
+		rules := make([]string, len(t.NOutOf.Rules))
+		for i, r := range t.NOutOf.Rules {
+			s, err := ruleString(r, principals)
+			if err != nil {
+				return "", err
+			}
+			rules[i] = s
+		}
+		list := strings.Join(rules, ", ")
+
+		switch {
+		case n == len(rules):
+			return "AND(" + list + ")", nil
+		case n == 1:
+			return "OR(" + list + ")", nil
+		default:
+			return fmt.Sprintf("OutOf(%d, %s)", n, list), nil
+		}
+	default:
+		return "", errors.Errorf("unsupported signature policy type: %T", rule.Type)
+	}
+}
+
+func principalString(principal *mb.MSPPrincipal) (string, error) {
+	if principal.PrincipalClassification != mb.MSPPrincipal_ROLE {
+		return "", errors.Errorf("unsupported principal classification: %s", principal.PrincipalClassification)
+	}
+
+	role := &mb.MSPRole{}
+	if err := proto.Unmarshal(principal.Principal, role); err != nil {
+		return "", errors.Wrap(err, "unmarshal of MSP role failed")
+	}
+	if role.MspIdentifier == "" {
+		return "", errors.New("MSP ID is empty")
+	}
+	if _, ok := mb.MSPRole_MSPRoleType_name[int32(role.Role)]; !ok {
+		return "", errors.Errorf("unknown role %d", role.Role)
+	}
+	return fmt.Sprintf("'%s.%s'", role.MspIdentifier, strings.ToLower(role.Role.String())), nil
+}
+
+// mspRole returns the MSP role type of the role, or an unknown role type
+func mspRole(role Role) mb.MSPRole_MSPRoleType {
+	if r, ok := roles[role]; ok {
+		return r
+	}
+	return mb.MSPRole_MSPRoleType(-1)
+}
+
+// addIdentity adds the identity to the envelope if it isn't already one of its identities and returns its index
+func addIdentity(envelope *common.SignaturePolicyEnvelope, identity *mb.MSPPrincipal) int32 {
+	for i, id := range envelope.Identities {
+		if proto.Equal(id, identity) {
+			return int32(i)
+		}
+	}
+	envelope.Identities = append(envelope.Identities, identity)
+	return int32(len(envelope.Identities) - 1)
+}
+
+// reindex returns a copy of the rule that refers to the identities at the given indexes
+func reindex(rule *common.SignaturePolicy, indexes []int32) *common.SignaturePolicy {
+	switch t := rule.GetType().(type) {
+	case *common.SignaturePolicy_SignedBy:
+		if t.SignedBy < 0 || int(t.SignedBy) >= len(indexes) {
+			// out of range indexes stay out of range
+			return cauthdsl.SignedBy(-1)
+		}
+		return cauthdsl.SignedBy(indexes[t.SignedBy])
+	case *common.SignaturePolicy_NOutOf_:
+		var rules []*common.SignaturePolicy
+		for _, r := range t.NOutOf.GetRules() {
+			rules = append(rules, reindex(r, indexes))
+		}
+		return cauthdsl.NOutOf(t.NOutOf.GetN(), rules)
+	default:
+		return rule
+	}
+}
+
+func nonNil(policies []*common.SignaturePolicyEnvelope) []*common.SignaturePolicyEnvelope {
+	var result []*common.SignaturePolicyEnvelope
+	for _, policy := range policies {
+		if policy != nil && policy.Rule != nil {
+			result = append(result, policy)
+		}
+	}
+	return result
+}
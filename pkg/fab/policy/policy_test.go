@@ -0,0 +1,115 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package policy
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/common/cauthdsl"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
+	mb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/msp"
+)
+
+func TestRoundTrip(t *testing.T) {
+	policies := map[string]string{
+		"AND('Org1MSP.member', OR('Org2MSP.admin','Org3MSP.member'))":         "AND('Org1MSP.member', OR('Org2MSP.admin', 'Org3MSP.member'))",
+		"OR('Org1MSP.peer', 'Org2MSP.client')":                                "OR('Org1MSP.peer', 'Org2MSP.client')",
+		"OutOf(2, 'Org1MSP.member', 'Org2MSP.member', 'Org3MSP.orderer')":     "OutOf(2, 'Org1MSP.member', 'Org2MSP.member', 'Org3MSP.orderer')",
+		"outof(1, 'Org1MSP.member', and('Org2MSP.member', 'Org3MSP.member'))": "OR('Org1MSP.member', AND('Org2MSP.member', 'Org3MSP.member'))",
+		"AND('Org1MSP.member')": "AND('Org1MSP.member')",
+	}
+
+	for policy, canonical := range policies {
+		envelope, err := FromString(policy)
+		if err != nil {
+			t.Fatalf("Failed to parse %s: %s", policy, err)
+		}
+		s, err := String(envelope)
+		assert.Nil(t, err, "String failed for %s", policy)
+		assert.Equal(t, canonical, s)
+
+		// The canonical form is parsed to the same policy
+		reparsed, err := FromString(s)
+		assert.Nil(t, err, "failed to parse canonical form %s", s)
+		assert.True(t, proto.Equal(envelope, reparsed), "expected the canonical form of %s to be parsed to the same policy", policy)
+	}
+}
+
+func TestRejectMalformed(t *testing.T) {
+	malformed := []string{
+		"",
+		"   ",
+		"AND('Org1MSP.member'",
+		"AND('Org1MSP.member', 'Org2MSP.member'))",
+		"AND('Org1MSP.superuser')",
+		"AND('Org1MSP')",
+		"XOR('Org1MSP.member', 'Org2MSP.member')",
+		"AND(Org1MSP.member)",
+		"OutOf(3, 'Org1MSP.member', 'Org2MSP.member')",
+		"OutOf(0, 'Org1MSP.member', 'Org2MSP.member')",
+		"OutOf('Org1MSP.member', 'Org2MSP.member')",
+		"AND()",
+		"'Org1MSP.member' 'Org2MSP.member'",
+	}
+
+	for _, policy := range malformed {
+		_, err := FromString(policy)
+		assert.NotNil(t, err, "expected %q to be rejected", policy)
+	}
+}
+
+func TestBuilder(t *testing.T) {
+	envelope := And(SignedBy("Org1MSP", Member), Or(SignedBy("Org2MSP", Admin), SignedBy("Org3MSP", Member)))
+
+	s, err := String(envelope)
+	assert.Nil(t, err)
+	assert.Equal(t, "AND('Org1MSP.member', OR('Org2MSP.admin', 'Org3MSP.member'))", s)
+
+	// The parser may order the identities differently, the policies are the same
+	parsed, err := FromString(s)
+	assert.Nil(t, err)
+	reparsed, err := String(parsed)
+	assert.Nil(t, err)
+	assert.Equal(t, s, reparsed, "expected the built policy to match the parsed policy")
+
+	s, err = String(SignedBy("Org1MSP", Orderer))
+	assert.Nil(t, err)
+	assert.Equal(t, "AND('Org1MSP.orderer')", s, "expected a principal to be written as a gate")
+
+	// Identities are merged
+	envelope = OutOf(2, SignedBy("Org1MSP", Peer), And(SignedBy("Org1MSP", Peer), SignedBy("Org2MSP", Peer)), nil)
+	assert.Len(t, envelope.Identities, 2, "expected each identity once")
+	s, err = String(envelope)
+	assert.Nil(t, err)
+	assert.Equal(t, "AND('Org1MSP.peer', AND('Org1MSP.peer', 'Org2MSP.peer'))", s)
+
+	// Invalid policies can be built but not written
+	_, err = String(And(SignedBy("Org1MSP", Role("superuser"))))
+	assert.NotNil(t, err, "expected an error for an unknown role")
+	_, err = String(OutOf(3, SignedBy("Org1MSP", Member), SignedBy("Org2MSP", Member)))
+	assert.NotNil(t, err, "expected an error for an unsatisfiable gate")
+	_, err = String(And())
+	assert.NotNil(t, err, "expected an error for a gate without policies")
+}
+
+func TestStringUnsupported(t *testing.T) {
+	_, err := String(nil)
+	assert.NotNil(t, err)
+
+	envelope := cauthdsl.SignedByMspMember("Org1MSP")
+	envelope.Identities = append(envelope.Identities, &mb.MSPPrincipal{PrincipalClassification: mb.MSPPrincipal_IDENTITY})
+	envelope.Rule = cauthdsl.Or(cauthdsl.SignedBy(0), cauthdsl.SignedBy(1))
+	_, err = String(envelope)
+	assert.NotNil(t, err, "expected an error for an identity principal")
+
+	envelope = &common.SignaturePolicyEnvelope{Rule: cauthdsl.SignedBy(0)}
+	_, err = String(envelope)
+	assert.NotNil(t, err, "expected an error for an out of range signed-by index")
+}
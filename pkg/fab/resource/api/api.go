@@ -54,6 +54,9 @@ type InstallChaincodeRequest struct {
 	Version string
 	// required - package (chaincode package type and bytes)
 	Package *CCPackage
+	// optional - deployment package (a chaincode deployment spec or a signed package, see ccpackager) that
+	// is installed as is; Name, Path, Version and Package aren't required with a deployment package
+	DeploymentPackage []byte
 	// required - proposal processor list
 	Targets []fab.ProposalProcessor
 }
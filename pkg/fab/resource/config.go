@@ -79,3 +79,43 @@ func ExtractChannelConfig(configEnvelope []byte) ([]byte, error) {
 
 	return configUpdateEnvelope.ConfigUpdate, nil
 }
+
+// ExtractConfigUpdateEnvelope extracts the protobuf 'ConfigUpdateEnvelope' object out of a configtx envelope.
+// The envelope must contain a config update transaction, for the creation or the update of a channel.
+func ExtractConfigUpdateEnvelope(configEnvelope []byte) (*common.ConfigUpdateEnvelope, error) {
+
+	envelope := &common.Envelope{}
+	err := proto.Unmarshal(configEnvelope, envelope)
+	if err != nil {
+		return nil, errors.Wrap(err, "unmarshal config envelope failed")
+	}
+
+	payload := &common.Payload{}
+	err = proto.Unmarshal(envelope.Payload, payload)
+	if err != nil {
+		return nil, errors.Wrap(err, "unmarshal envelope payload failed")
+	}
+	if payload.Header == nil {
+		return nil, errors.New("envelope payload header is missing")
+	}
+
+	channelHeader := &common.ChannelHeader{}
+	err = proto.Unmarshal(payload.Header.ChannelHeader, channelHeader)
+	if err != nil {
+		return nil, errors.Wrap(err, "unmarshal channel header failed")
+	}
+	if channelHeader.Type != int32(common.HeaderType_CONFIG_UPDATE) {
+		return nil, errors.Errorf("envelope of type %s isn't a config update", common.HeaderType(channelHeader.Type))
+	}
+
+	configUpdateEnvelope := &common.ConfigUpdateEnvelope{}
+	err = proto.Unmarshal(payload.Data, configUpdateEnvelope)
+	if err != nil {
+		return nil, errors.Wrap(err, "unmarshal config update envelope")
+	}
+	if len(configUpdateEnvelope.ConfigUpdate) == 0 {
+		return nil, errors.New("config update is missing")
+	}
+
+	return configUpdateEnvelope, nil
+}
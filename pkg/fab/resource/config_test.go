@@ -7,11 +7,15 @@ SPDX-License-Identifier: Apache-2.0
 package resource
 
 import (
+	"bytes"
 	"io/ioutil"
 	"path"
 	"testing"
 
+	"github.com/golang/protobuf/proto"
+
 	"github.com/hyperledger/fabric-sdk-go/test/metadata"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
 )
 
 func TestExtractChannelConfig(t *testing.T) {
@@ -26,6 +30,44 @@ func TestExtractChannelConfig(t *testing.T) {
 	}
 }
 
+func TestExtractConfigUpdateEnvelope(t *testing.T) {
+	configTx, err := ioutil.ReadFile(path.Join("../../../", metadata.ChannelConfigPath, "mychannelOrg1MSPanchors.tx"))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	configUpdateEnvelope, err := ExtractConfigUpdateEnvelope(configTx)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	configUpdate, err := ExtractChannelConfig(configTx)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if !bytes.Equal(configUpdate, configUpdateEnvelope.ConfigUpdate) {
+		t.Fatalf("Expected the config update of the envelope")
+	}
+
+	// Envelopes that aren't config updates are rejected
+	blockBytes, err := ioutil.ReadFile(path.Join("../../../", metadata.ChannelConfigPath, "twoorgs.genesis.block"))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	block := &common.Block{}
+	if err := proto.Unmarshal(blockBytes, block); err != nil {
+		t.Fatalf(err.Error())
+	}
+	_, err = ExtractConfigUpdateEnvelope(block.Data.Data[0])
+	if err == nil {
+		t.Fatalf("Expected error extracting config update from config envelope")
+	}
+
+	_, err = ExtractConfigUpdateEnvelope(nil)
+	if err == nil {
+		t.Fatalf("Expected error extracting config update from empty envelope")
+	}
+}
+
 func TestCreateConfigSignature(t *testing.T) {
 	client := setupTestClient()
 
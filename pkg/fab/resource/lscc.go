@@ -29,6 +29,9 @@ type ChaincodeInstallRequest struct {
 	Path    string
 	Version string
 	Package *ChaincodePackage
+	// DeploymentPackage is a chaincode deployment spec or a signed package that is installed instead
+	// of a chaincode deployment spec created from the other fields
+	DeploymentPackage []byte
 }
 
 // ChaincodePackage contains package type and bytes required to create CDS
@@ -50,10 +53,30 @@ func CreateChaincodeInstallProposal(txh fab.TransactionHeader, request Chaincode
 func createInstallInvokeRequest(request ChaincodeInstallRequest) (fab.ChaincodeInvokeRequest, error) {
 	// Generate arguments for install
 	args := [][]byte{}
+	ccdsBytes := request.DeploymentPackage
+	if len(ccdsBytes) == 0 {
+		var err error
+		ccdsBytes, err = createInstallCCDS(request)
+		if err != nil {
+			return fab.ChaincodeInvokeRequest{}, err
+		}
+	}
+	args = append(args, ccdsBytes)
+
+	cir := fab.ChaincodeInvokeRequest{
+		ChaincodeID: lscc,
+		Fcn:         lsccInstall,
+		Args:        args,
+	}
+	return cir, nil
+}
+
+// createInstallCCDS creates the chaincode deployment spec of the install request
+func createInstallCCDS(request ChaincodeInstallRequest) ([]byte, error) {
 	timestamp := time.Now()
 	ts, err := ptypes.TimestampProto(timestamp)
 	if err != nil {
-		return fab.ChaincodeInvokeRequest{}, errors.Wrap(err, "failed to create timestamp in install proposal")
+		return nil, errors.Wrap(err, "failed to create timestamp in install proposal")
 	}
 
 	ccds := &pb.ChaincodeDeploymentSpec{ChaincodeSpec: &pb.ChaincodeSpec{
@@ -62,16 +85,9 @@ func createInstallInvokeRequest(request ChaincodeInstallRequest) (fab.ChaincodeI
 
 	ccdsBytes, err := protos_utils.Marshal(ccds)
 	if err != nil {
-		return fab.ChaincodeInvokeRequest{}, errors.WithMessage(err, "marshal of chaincode deployment spec failed")
+		return nil, errors.WithMessage(err, "marshal of chaincode deployment spec failed")
 	}
-	args = append(args, ccdsBytes)
-
-	cir := fab.ChaincodeInvokeRequest{
-		ChaincodeID: lscc,
-		Fcn:         lsccInstall,
-		Args:        args,
-	}
-	return cir, nil
+	return ccdsBytes, nil
 }
 
 func createInstalledChaincodesInvokeRequest() fab.ChaincodeInvokeRequest {
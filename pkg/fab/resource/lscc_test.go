@@ -36,3 +36,12 @@ func TestCreateChaincodeInstallProposal(t *testing.T) {
 	_, err = txn.SendProposal(c.clientContext, prop, []fab.ProposalProcessor{&peer})
 	assert.Nil(t, err, "sending mock proposal failed")
 }
+
+func TestCreateInstallInvokeRequestWithDeploymentPackage(t *testing.T) {
+	request := ChaincodeInstallRequest{DeploymentPackage: []byte("package")}
+
+	cir, err := createInstallInvokeRequest(request)
+	assert.Nil(t, err, "createInstallInvokeRequest failed")
+	assert.Equal(t, lsccInstall, cir.Fcn)
+	assert.Equal(t, [][]byte{[]byte("package")}, cir.Args, "expected the deployment package to be installed as is")
+}
@@ -16,8 +16,8 @@ import (
 	ab "github.com/hyperledger/fabric-sdk-go/internal/github.com/hyperledger/fabric/protos/orderer"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
-	ccomm "github.com/hyperledger/fabric-sdk-go/pkg/core/config/comm"
 	"github.com/hyperledger/fabric-sdk-go/pkg/errors/multi"
+	"github.com/hyperledger/fabric-sdk-go/pkg/errors/status"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/resource/api"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/txn"
 	"github.com/hyperledger/fabric-sdk-go/pkg/logging"
@@ -139,7 +139,10 @@ func (c *Resource) GenesisBlockFromOrderer(channelName string, orderer fab.Order
 		return nil, errors.Wrap(err, "marshaling of seek info header failed")
 	}
 
-	tlsCertHash := ccomm.TLSCertHash(c.clientContext.Config())
+	tlsCertHash, err := txn.OrderersTLSCertHash(c.clientContext.Config(), orderers)
+	if err != nil {
+		return nil, err
+	}
 	channelHeaderOpts := txn.ChannelHeaderOpts{
 		TxnHeader:   txh,
 		TLSCertHash: tlsCertHash,
@@ -204,9 +207,14 @@ func (c *Resource) createOrUpdateChannel(txh *txn.TransactionHeader, request api
 		return errors.Wrap(err, "marshal configUpdateEnvelope failed")
 	}
 
+	tlsCertHash, err := txn.OrderersTLSCertHash(c.clientContext.Config(), []fab.Orderer{request.Orderer})
+	if err != nil {
+		return err
+	}
+
 	channelHeaderOpts := txn.ChannelHeaderOpts{
 		TxnHeader:   txh,
-		TLSCertHash: ccomm.TLSCertHash(c.clientContext.Config()),
+		TLSCertHash: tlsCertHash,
 	}
 	channelHeader, err := txn.CreateChannelHeader(common.HeaderType_CONFIG_UPDATE, channelHeaderOpts)
 	if err != nil {
@@ -270,29 +278,36 @@ func (c *Resource) QueryInstalledChaincodes(peer fab.ProposalProcessor) (*pb.Cha
 }
 
 // InstallChaincode sends an install proposal to one or more endorsing peers.
+// The deployment package of the request is installed as is, otherwise the chaincode deployment spec is
+// created from the name, path, version and package of the request.
 func (c *Resource) InstallChaincode(req api.InstallChaincodeRequest) ([]*fab.TransactionProposalResponse, fab.TransactionID, error) {
 
-	if req.Name == "" {
-		return nil, fab.EmptyTransactionID, errors.New("chaincode name required")
-	}
-	if req.Path == "" {
-		return nil, fab.EmptyTransactionID, errors.New("chaincode path required")
-	}
-	if req.Version == "" {
-		return nil, fab.EmptyTransactionID, errors.New("chaincode version required")
-	}
-	if req.Package == nil {
-		return nil, fab.EmptyTransactionID, errors.New("chaincode package is required")
-	}
+	var propReq ChaincodeInstallRequest
+	if len(req.DeploymentPackage) > 0 {
+		propReq.DeploymentPackage = req.DeploymentPackage
+	} else {
+		if req.Name == "" {
+			return nil, fab.EmptyTransactionID, errors.New("chaincode name required")
+		}
+		if req.Path == "" {
+			return nil, fab.EmptyTransactionID, errors.New("chaincode path required")
+		}
+		if req.Version == "" {
+			return nil, fab.EmptyTransactionID, errors.New("chaincode version required")
+		}
+		if req.Package == nil {
+			return nil, fab.EmptyTransactionID, errors.New("chaincode package is required")
+		}
 
-	propReq := ChaincodeInstallRequest{
-		Name:    req.Name,
-		Path:    req.Path,
-		Version: req.Version,
-		Package: &ChaincodePackage{
-			Type: req.Package.Type,
-			Code: req.Package.Code,
-		},
+		propReq = ChaincodeInstallRequest{
+			Name:    req.Name,
+			Path:    req.Path,
+			Version: req.Version,
+			Package: &ChaincodePackage{
+				Type: req.Package.Type,
+				Code: req.Package.Code,
+			},
+		}
 	}
 
 	txh, err := txn.NewHeader(c.clientContext, fab.SystemChannel)
@@ -352,9 +367,11 @@ func (c *Resource) queryChaincodeWithTarget(request fab.ChaincodeInvokeRequest,
 	return tpr[0].ProposalResponse.GetResponse().Payload, nil
 }
 
+// validateResponse returns the status of the response as an error if the target didn't accept the proposal
 func validateResponse(response *fab.TransactionProposalResponse) error {
 	if response.Status != http.StatusOK {
-		return errors.Errorf("bad status from %s (%d)", response.Endorser, response.Status)
+		s := status.New(status.EndorserServerStatus, response.Status, response.ProposalResponse.GetResponse().GetMessage(), []interface{}{response.Endorser})
+		return errors.WithMessage(s, "bad status from "+response.Endorser)
 	}
 
 	return nil
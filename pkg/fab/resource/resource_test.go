@@ -15,11 +15,15 @@ import (
 	"testing"
 	"time"
 
+	"github.com/golang/protobuf/proto"
+
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/errors/status"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/peer"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/resource/api"
 	"github.com/hyperledger/fabric-sdk-go/test/metadata"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
 	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
 	"github.com/pkg/errors"
 	"google.golang.org/grpc"
@@ -101,6 +105,66 @@ func TestCreateChannel(t *testing.T) {
 	}
 }
 
+func TestCreateChannelWithSignatures(t *testing.T) {
+	client := setupTestClient()
+
+	configTx, err := ioutil.ReadFile(path.Join("../../../", metadata.ChannelConfigPath, "mychannel.tx"))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	configUpdate, err := ExtractChannelConfig(configTx)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	signature, err := CreateConfigSignature(client.clientContext, configUpdate)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	verifyBroadcast := make(chan *fab.SignedEnvelope)
+	orderer := mocks.NewMockOrderer(fmt.Sprintf("0.0.0.0:1234"), verifyBroadcast)
+
+	txID, err := client.CreateChannel(api.CreateChannelRequest{
+		Orderer:    orderer,
+		Name:       "mychannel",
+		Config:     configUpdate,
+		Signatures: []*common.ConfigSignature{signature},
+	})
+	if err != nil {
+		t.Fatalf("Did not expect error from create channel. Got error: %v", err)
+	}
+
+	var envelope *fab.SignedEnvelope
+	select {
+	case envelope = <-verifyBroadcast:
+	case <-time.After(time.Second):
+		t.Fatalf("Expected broadcast")
+	}
+
+	// The envelope is a config update transaction of the channel, containing the signed config update
+	payload := &common.Payload{}
+	if err := proto.Unmarshal(envelope.Payload, payload); err != nil {
+		t.Fatalf("Failed to unmarshal payload: %s", err)
+	}
+	channelHeader := &common.ChannelHeader{}
+	if err := proto.Unmarshal(payload.Header.ChannelHeader, channelHeader); err != nil {
+		t.Fatalf("Failed to unmarshal channel header: %s", err)
+	}
+	if channelHeader.Type != int32(common.HeaderType_CONFIG_UPDATE) || channelHeader.ChannelId != "mychannel" || channelHeader.TxId != string(txID) {
+		t.Fatalf("Unexpected channel header: %v", channelHeader)
+	}
+	configUpdateEnvelope := &common.ConfigUpdateEnvelope{}
+	if err := proto.Unmarshal(payload.Data, configUpdateEnvelope); err != nil {
+		t.Fatalf("Failed to unmarshal config update envelope: %s", err)
+	}
+	if !bytes.Equal(configUpdate, configUpdateEnvelope.ConfigUpdate) || len(configUpdateEnvelope.Signatures) != 1 || !proto.Equal(signature, configUpdateEnvelope.Signatures[0]) {
+		t.Fatalf("Expected the signed config update in the envelope")
+	}
+	if len(envelope.Signature) == 0 {
+		t.Fatalf("Expected the envelope to be signed")
+	}
+}
+
 func TestJoinChannel(t *testing.T) {
 	var peers []fab.ProposalProcessor
 
@@ -190,6 +254,10 @@ func TestQueryByChaincodeBadStatus(t *testing.T) {
 	if err == nil {
 		t.Fatalf("expected failure due to bad status")
 	}
+	s, ok := status.FromError(err)
+	if !ok || s.Group != status.EndorserServerStatus || s.Code != 99 {
+		t.Fatalf("expected the status of the response but got %v", err)
+	}
 }
 
 func TestQueryByChaincodeError(t *testing.T) {
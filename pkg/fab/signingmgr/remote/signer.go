@@ -0,0 +1,230 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package remote is a sample signer that delegates signing to a remote HTTP signing service,
+// so that the private keys aren't held by the application. It is used as the signer of the
+// signing manager, e.g. signingmgr.NewWithSigner(remote.New(url, remote.WithCredentials(id, secret))).
+//
+// The signer posts a JSON request {"keyRef": "<hex SKI>", "message": "<base64>"} to the service
+// and expects a JSON response {"signature": "<base64>"}. The service hashes the message with the
+// hash function of the security config and returns the DER encoded signature of the hash.
+//
+// The requests are signed with HMAC-SHA256 over the timestamp and the body of the request, failed
+// requests are retried and the latency of the requests is recorded (see Metrics).
+package remote
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/errors/retry"
+	"github.com/hyperledger/fabric-sdk-go/pkg/errors/status"
+	"github.com/pkg/errors"
+)
+
+// The headers of a signed request
+const (
+	ClientIDHeader  = "X-Signer-Client"
+	TimestampHeader = "X-Signer-Timestamp"
+	SignatureHeader = "X-Signer-Signature"
+)
+
+// DefaultRetryableCodes are the HTTP status codes of the signing service (and the failure to connect
+// to the service) that are retried by default
+var DefaultRetryableCodes = map[status.Group][]status.Code{
+	status.HTTPTransportStatus: []status.Code{
+		status.ConnectionFailed,
+		http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+	},
+}
+
+// Metrics are the request metrics of a signer
+type Metrics struct {
+	// Requests is the number of requests sent to the signing service (including retries)
+	Requests uint64
+	// Failures is the number of failed requests
+	Failures uint64
+	// Retries is the number of retried requests
+	Retries uint64
+	// TotalLatency is the sum of the latency of the requests
+	TotalLatency time.Duration
+	// LastLatency is the latency of the last request
+	LastLatency time.Duration
+}
+
+// AverageLatency returns the average latency of the requests
+func (m Metrics) AverageLatency() time.Duration {
+	if m.Requests == 0 {
+		return 0
+	}
+	return m.TotalLatency / time.Duration(m.Requests)
+}
+
+// Signer signs messages with the keys of a remote signing service
+type Signer struct {
+	url        string
+	httpClient *http.Client
+	clientID   string
+	secret     []byte
+	retryOpts  retry.Opts
+
+	mutex   sync.Mutex
+	metrics Metrics
+}
+
+// Option configures the signer
+type Option func(*Signer)
+
+// WithHTTPClient sets the HTTP client that is used to communicate with the signing service
+func WithHTTPClient(client *http.Client) Option {
+	return func(s *Signer) {
+		s.httpClient = client
+	}
+}
+
+// WithCredentials sets the ID of the client and the secret that signs the requests
+func WithCredentials(clientID string, secret []byte) Option {
+	return func(s *Signer) {
+		s.clientID = clientID
+		s.secret = secret
+	}
+}
+
+// WithRetryOpts sets the retry options of the requests. The retryable codes default to DefaultRetryableCodes.
+func WithRetryOpts(opts retry.Opts) Option {
+	return func(s *Signer) {
+		s.retryOpts = opts
+	}
+}
+
+// New returns a signer for the signing service at the given URL
+func New(url string, opts ...Option) *Signer {
+	retryOpts := retry.DefaultOpts
+	retryOpts.RetryableCodes = DefaultRetryableCodes
+
+	s := &Signer{url: url, httpClient: http.DefaultClient, retryOpts: retryOpts}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if len(s.retryOpts.RetryableCodes) == 0 {
+		s.retryOpts.RetryableCodes = DefaultRetryableCodes
+	}
+	return s
+}
+
+type signRequest struct {
+	KeyRef  string `json:"keyRef"`
+	Message []byte `json:"message"`
+}
+
+type signResponse struct {
+	Signature []byte `json:"signature"`
+}
+
+// Sign signs the message with the key of the signing service that has the given reference
+func (s *Signer) Sign(msg []byte, keyRef string) ([]byte, error) {
+	body, err := json.Marshal(&signRequest{KeyRef: keyRef, Message: msg})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal sign request")
+	}
+
+	retryHandler := retry.New(s.retryOpts)
+	for {
+		start := time.Now()
+		signature, err := s.send(body)
+		s.record(time.Since(start), err)
+		if err == nil {
+			return signature, nil
+		}
+		if !retryHandler.Required(err) {
+			return nil, errors.WithMessage(err, "remote signing failed")
+		}
+		s.recordRetry()
+	}
+}
+
+// Metrics returns a snapshot of the request metrics
+func (s *Signer) Metrics() Metrics {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.metrics
+}
+
+func (s *Signer) send(body []byte) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create sign request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.secret != nil {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(ClientIDHeader, s.clientID)
+		req.Header.Set(TimestampHeader, timestamp)
+		req.Header.Set(SignatureHeader, RequestSignature(s.secret, timestamp, body))
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, status.New(status.HTTPTransportStatus, status.ConnectionFailed.ToInt32(), err.Error(), nil)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, status.New(status.HTTPTransportStatus, status.ConnectionFailed.ToInt32(), err.Error(), nil)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, status.New(status.HTTPTransportStatus, int32(resp.StatusCode), string(respBody), nil)
+	}
+
+	var signResp signResponse
+	if err := json.Unmarshal(respBody, &signResp); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal sign response")
+	}
+	if len(signResp.Signature) == 0 {
+		return nil, errors.New("signing service returned an empty signature")
+	}
+	return signResp.Signature, nil
+}
+
+func (s *Signer) record(latency time.Duration, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.metrics.Requests++
+	s.metrics.TotalLatency += latency
+	s.metrics.LastLatency = latency
+	if err != nil {
+		s.metrics.Failures++
+	}
+}
+
+func (s *Signer) recordRetry() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.metrics.Retries++
+}
+
+// RequestSignature returns the base64 encoded HMAC-SHA256 of the timestamp and the body of a request,
+// for the signing service to verify the requests
+func RequestSignature(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("\n"))
+	mac.Write(body)
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,142 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package remote
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/errors/retry"
+)
+
+var (
+	testClientID = "client1"
+	testSecret   = []byte("secret")
+	testRetry    = retry.Opts{Attempts: 2, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond, BackoffFactor: 1}
+)
+
+// newSigningService returns a signing service that fails the first failures requests with the given status
+func newSigningService(t *testing.T, failures int32, failureStatus int) (*httptest.Server, *int32) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&requests, 1)
+
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("Failed to read request: %v", err)
+		}
+		if r.Header.Get(ClientIDHeader) != testClientID {
+			http.Error(w, "unknown client", http.StatusUnauthorized)
+			return
+		}
+		expected := RequestSignature(testSecret, r.Header.Get(TimestampHeader), body)
+		if r.Header.Get(SignatureHeader) != expected {
+			http.Error(w, "invalid request signature", http.StatusUnauthorized)
+			return
+		}
+		if n <= failures {
+			http.Error(w, "unavailable", failureStatus)
+			return
+		}
+
+		var req signRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(&signResponse{Signature: append([]byte(req.KeyRef+":"), req.Message...)})
+	}))
+	return server, &requests
+}
+
+func TestSign(t *testing.T) {
+	server, _ := newSigningService(t, 0, 0)
+	defer server.Close()
+
+	signer := New(server.URL, WithCredentials(testClientID, testSecret), WithRetryOpts(testRetry))
+	signature, err := signer.Sign([]byte("Hello"), "0a0b")
+	if err != nil {
+		t.Fatalf("Failed to sign: %v", err)
+	}
+	if !bytes.Equal(signature, []byte("0a0b:Hello")) {
+		t.Fatalf("Unexpected signature %s", signature)
+	}
+
+	metrics := signer.Metrics()
+	if metrics.Requests != 1 || metrics.Failures != 0 || metrics.Retries != 0 {
+		t.Fatalf("Unexpected metrics %+v", metrics)
+	}
+	if metrics.LastLatency <= 0 || metrics.AverageLatency() != metrics.TotalLatency {
+		t.Fatalf("Expected the latency of the request to be recorded, got %+v", metrics)
+	}
+}
+
+func TestSignRetry(t *testing.T) {
+	server, requests := newSigningService(t, 2, http.StatusServiceUnavailable)
+	defer server.Close()
+
+	signer := New(server.URL, WithCredentials(testClientID, testSecret), WithRetryOpts(testRetry))
+	if _, err := signer.Sign([]byte("Hello"), "0a0b"); err != nil {
+		t.Fatalf("Expected the request to succeed after retries: %v", err)
+	}
+	if *requests != 3 {
+		t.Fatalf("Expected 3 requests, got %d", *requests)
+	}
+	metrics := signer.Metrics()
+	if metrics.Requests != 3 || metrics.Failures != 2 || metrics.Retries != 2 {
+		t.Fatalf("Unexpected metrics %+v", metrics)
+	}
+}
+
+func TestSignRetriesExhausted(t *testing.T) {
+	server, requests := newSigningService(t, 10, http.StatusServiceUnavailable)
+	defer server.Close()
+
+	signer := New(server.URL, WithCredentials(testClientID, testSecret), WithRetryOpts(testRetry))
+	if _, err := signer.Sign([]byte("Hello"), "0a0b"); err == nil {
+		t.Fatalf("Expected error after the retries are exhausted")
+	}
+	if *requests != 3 {
+		t.Fatalf("Expected 3 requests, got %d", *requests)
+	}
+}
+
+func TestSignNotRetryable(t *testing.T) {
+	server, requests := newSigningService(t, 0, 0)
+	defer server.Close()
+
+	// the request isn't signed with the secret of the client
+	signer := New(server.URL, WithCredentials(testClientID, []byte("wrong")), WithRetryOpts(testRetry))
+	_, err := signer.Sign([]byte("Hello"), "0a0b")
+	if err == nil || !strings.Contains(err.Error(), "invalid request signature") {
+		t.Fatalf("Expected request signature error, got %v", err)
+	}
+	if *requests != 1 {
+		t.Fatalf("Expected the request not to be retried, got %d requests", *requests)
+	}
+}
+
+func TestSignConnectionFailed(t *testing.T) {
+	server, _ := newSigningService(t, 0, 0)
+	url := server.URL
+	server.Close()
+
+	signer := New(url, WithCredentials(testClientID, testSecret), WithRetryOpts(testRetry))
+	if _, err := signer.Sign([]byte("Hello"), "0a0b"); err == nil {
+		t.Fatalf("Expected connection error")
+	}
+	if metrics := signer.Metrics(); metrics.Requests != 3 || metrics.Retries != 2 {
+		t.Fatalf("Expected the connection failures to be retried, got %+v", metrics)
+	}
+}
@@ -0,0 +1,92 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package signingmgr
+
+import (
+	"encoding/hex"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
+	"github.com/pkg/errors"
+)
+
+// CryptoSuiteSigner is the default signer, it hashes and signs the messages with the crypto suite
+type CryptoSuiteSigner struct {
+	cryptoSuite core.CryptoSuite
+	hashOpts    core.HashOpts
+	signerOpts  core.SignerOpts
+}
+
+// NewCryptoSuiteSigner returns a signer that signs with the keys of the given crypto suite.
+// The messages are hashed with the given hash options before they are signed.
+func NewCryptoSuiteSigner(cryptoSuite core.CryptoSuite, hashOpts core.HashOpts) *CryptoSuiteSigner {
+	return &CryptoSuiteSigner{cryptoSuite: cryptoSuite, hashOpts: hashOpts}
+}
+
+// Sign signs the message with the private key of the crypto suite that has the given reference (see KeyRef)
+func (s *CryptoSuiteSigner) Sign(msg []byte, keyRef string) ([]byte, error) {
+	ski, err := hex.DecodeString(keyRef)
+	if err != nil || len(ski) == 0 {
+		return nil, errors.Errorf("invalid key reference [%s]", keyRef)
+	}
+	key, err := s.cryptoSuite.GetKey(ski)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get key "+keyRef)
+	}
+	if !key.Private() {
+		return nil, errors.Errorf("key %s is not a private key", keyRef)
+	}
+	return s.SignWithKey(msg, key)
+}
+
+// SignWithKey signs the message with the given private key
+func (s *CryptoSuiteSigner) SignWithKey(msg []byte, key core.Key) ([]byte, error) {
+	digest, err := s.cryptoSuite.Hash(msg, s.hashOpts)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := s.cryptoSuite.Sign(key, digest, s.signerOpts)
+	if err != nil {
+		return nil, err
+	}
+	return signature, nil
+}
+
+// KeyRef returns the reference by which a signer identifies the key: the hex encoded SKI of the key
+func KeyRef(key core.Key) string {
+	return hex.EncodeToString(key.SKI())
+}
+
+// NewPrivateKeyRef returns a reference to the private key of the given public key for identities whose
+// private key isn't held by the application (e.g. the key is held by a remote signing service).
+// It can be used as the private key of a user; it only carries the SKI and can't be exported.
+func NewPrivateKeyRef(publicKey core.Key) core.Key {
+	return &privateKeyRef{publicKey: publicKey}
+}
+
+type privateKeyRef struct {
+	publicKey core.Key
+}
+
+func (k *privateKeyRef) Bytes() ([]byte, error) {
+	return nil, errors.New("not supported: the private key is held by the signer")
+}
+
+func (k *privateKeyRef) SKI() []byte {
+	return k.publicKey.SKI()
+}
+
+func (k *privateKeyRef) Symmetric() bool {
+	return false
+}
+
+func (k *privateKeyRef) Private() bool {
+	return true
+}
+
+func (k *privateKeyRef) PublicKey() (core.Key, error) {
+	return k.publicKey, nil
+}
@@ -7,6 +7,7 @@ SPDX-License-Identifier: Apache-2.0
 package signingmgr
 
 import (
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
 
 	"github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite"
@@ -15,9 +16,13 @@ import (
 
 // SigningManager is used for signing objects with private key
 type SigningManager struct {
-	cryptoProvider core.CryptoSuite
-	hashOpts       core.HashOpts
-	signerOpts     core.SignerOpts
+	signer api.Signer
+}
+
+// keySigner is implemented by signers that can sign with the key itself rather than
+// looking it up by its reference (e.g. ephemeral keys that aren't stored by the crypto suite)
+type keySigner interface {
+	SignWithKey(msg []byte, key core.Key) ([]byte, error)
 }
 
 // New Constructor for a signing manager.
@@ -25,7 +30,22 @@ type SigningManager struct {
 // @param {Config} config - configuration provider
 // @returns {SigningManager} new signing manager
 func New(cryptoProvider core.CryptoSuite, config core.Config) (*SigningManager, error) {
-	return &SigningManager{cryptoProvider: cryptoProvider, hashOpts: cryptosuite.GetSHAOpts()}, nil
+	// the objects are hashed with the hash function of the security config
+	hashOpts, err := cryptosuite.GetHashOptsByConfig(config)
+	if err != nil {
+		return nil, errors.WithMessage(err, "invalid security config")
+	}
+	return NewWithSigner(NewCryptoSuiteSigner(cryptoProvider, hashOpts))
+}
+
+// NewWithSigner returns a signing manager that signs the objects with the given signer,
+// e.g. a signer that delegates to a remote signing service (see package signingmgr/remote).
+// The signer is passed the reference of the key (see KeyRef) rather than the key itself.
+func NewWithSigner(signer api.Signer) (*SigningManager, error) {
+	if signer == nil {
+		return nil, errors.New("signer is required")
+	}
+	return &SigningManager{signer: signer}, nil
 }
 
 // Sign will sign the given object using provided key
@@ -39,11 +59,11 @@ func (mgr *SigningManager) Sign(object []byte, key core.Key) ([]byte, error) {
 		return nil, errors.New("key (for signing) required")
 	}
 
-	digest, err := mgr.cryptoProvider.Hash(object, mgr.hashOpts)
-	if err != nil {
-		return nil, err
+	if s, ok := mgr.signer.(keySigner); ok {
+		return s.SignWithKey(object, key)
 	}
-	signature, err := mgr.cryptoProvider.Sign(key, digest, mgr.signerOpts)
+
+	signature, err := mgr.signer.Sign(object, KeyRef(key))
 	if err != nil {
 		return nil, err
 	}
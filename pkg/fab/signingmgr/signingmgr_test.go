@@ -8,11 +8,20 @@ package signingmgr
 
 import (
 	"bytes"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
 	"testing"
 
+	"github.com/golang/mock/gomock"
+	"github.com/hyperledger/fabric-sdk-go/internal/github.com/hyperledger/fabric/bccsp"
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core/mocks"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite/bccsp/sw"
 	bccspwrapper "github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite/bccsp/wrapper"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/identitymgr/mocks"
 	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	"github.com/pkg/errors"
 )
 
 func TestSigningManager(t *testing.T) {
@@ -48,3 +57,130 @@ func TestSigningManager(t *testing.T) {
 	}
 
 }
+
+// hashConfig selects the hash function of the security config
+type hashConfig struct {
+	fcmocks.MockConfig
+	family string
+	level  int
+}
+
+func (c *hashConfig) SecurityAlgorithm() string {
+	return c.family
+}
+
+func (c *hashConfig) SecurityLevel() int {
+	return c.level
+}
+
+func TestSigningManagerHashByConfig(t *testing.T) {
+	signingMgr, err := New(&fcmocks.MockCryptoSuite{}, &hashConfig{family: "SHA3", level: 384})
+	if err != nil {
+		t.Fatalf("Failed to create signing manager: %s", err)
+	}
+	hashOpts := signingMgr.signer.(*CryptoSuiteSigner).hashOpts
+	if _, ok := hashOpts.(*bccsp.SHA3_384Opts); !ok {
+		t.Fatalf("Expected the SHA3-384 hash of the security config, got %T", hashOpts)
+	}
+
+	if _, err := New(&fcmocks.MockCryptoSuite{}, &hashConfig{family: "SHA2", level: 512}); err == nil {
+		t.Fatalf("Expected error for an unsupported security level")
+	}
+}
+
+// recordingSigner records the key references it's asked to sign with
+type recordingSigner struct {
+	keyRefs []string
+}
+
+func (s *recordingSigner) Sign(msg []byte, keyRef string) ([]byte, error) {
+	s.keyRefs = append(s.keyRefs, keyRef)
+	if keyRef == "" {
+		return nil, errors.New("key reference required")
+	}
+	return []byte("remoteSignature"), nil
+}
+
+func TestSigningManagerWithSigner(t *testing.T) {
+	if _, err := NewWithSigner(nil); err == nil {
+		t.Fatalf("Expected error creating signing manager without signer")
+	}
+
+	signer := &recordingSigner{}
+	signingMgr, err := NewWithSigner(signer)
+	if err != nil {
+		t.Fatalf("Failed to create signing manager: %s", err)
+	}
+
+	if _, err := signingMgr.Sign([]byte("Hello"), nil); err == nil {
+		t.Fatalf("Should have failed to sign object with nil key")
+	}
+
+	publicKey := bccspwrapper.GetKey(&mocks.MockKey{})
+	signedObj, err := signingMgr.Sign([]byte("Hello"), NewPrivateKeyRef(publicKey))
+	if err != nil {
+		t.Fatalf("Failed to sign object: %s", err)
+	}
+	if !bytes.Equal(signedObj, []byte("remoteSignature")) {
+		t.Fatalf("Expected the signature of the signer, got %s", signedObj)
+	}
+	if len(signer.keyRefs) != 1 || signer.keyRefs[0] != hex.EncodeToString(publicKey.SKI()) {
+		t.Fatalf("Expected the signer to be invoked once with the SKI of the key, got %v", signer.keyRefs)
+	}
+}
+
+func TestCryptoSuiteSignerByKeyRef(t *testing.T) {
+	// keys are looked up by reference in the key store
+	keyStorePath, err := ioutil.TempDir("", "signingmgr")
+	if err != nil {
+		t.Fatalf("Failed to create key store directory: %s", err)
+	}
+	defer os.RemoveAll(keyStorePath)
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockConfig := mock_core.NewMockConfig(mockCtrl)
+	mockConfig.EXPECT().SecurityProvider().Return("SW")
+	mockConfig.EXPECT().SecurityAlgorithm().Return("SHA2")
+	mockConfig.EXPECT().SecurityLevel().Return(256)
+	mockConfig.EXPECT().KeyStorePath().Return(keyStorePath)
+	mockConfig.EXPECT().Ephemeral().Return(false)
+
+	cs, err := sw.GetSuiteByConfig(mockConfig)
+	if err != nil {
+		t.Fatalf("Failed to get crypto suite: %s", err)
+	}
+	privateKey, err := cs.KeyGen(cryptosuite.GetECDSAP256KeyGenOpts(false))
+	if err != nil {
+		t.Fatalf("Failed to generate key: %s", err)
+	}
+	publicKey, err := privateKey.PublicKey()
+	if err != nil {
+		t.Fatalf("Failed to get public key: %s", err)
+	}
+
+	signer := NewCryptoSuiteSigner(cs, cryptosuite.GetSHAOpts())
+	msg := []byte("Hello")
+	signature, err := signer.Sign(msg, KeyRef(privateKey))
+	if err != nil {
+		t.Fatalf("Failed to sign by key reference: %s", err)
+	}
+	digest, err := cs.Hash(msg, cryptosuite.GetSHAOpts())
+	if err != nil {
+		t.Fatalf("Failed to hash message: %s", err)
+	}
+	if valid, err := cs.Verify(publicKey, signature, digest, nil); err != nil || !valid {
+		t.Fatalf("Failed to verify signature: %v", err)
+	}
+
+	if _, err := signer.Sign(msg, "not hex"); err == nil {
+		t.Fatalf("Expected error signing with an invalid key reference")
+	}
+	ephemeralKey, err := cs.KeyGen(cryptosuite.GetECDSAP256KeyGenOpts(true))
+	if err != nil {
+		t.Fatalf("Failed to generate ephemeral key: %s", err)
+	}
+	if _, err := signer.Sign(msg, KeyRef(ephemeralKey)); err == nil {
+		t.Fatalf("Expected error signing with a key that isn't in the key store")
+	}
+}
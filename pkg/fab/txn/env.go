@@ -7,6 +7,8 @@ SPDX-License-Identifier: Apache-2.0
 package txn
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"encoding/hex"
 	"hash"
 	"time"
@@ -17,7 +19,9 @@ import (
 
 	"github.com/hyperledger/fabric-sdk-go/internal/github.com/hyperledger/fabric/common/crypto"
 	contextApi "github.com/hyperledger/fabric-sdk-go/pkg/context"
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+	ccomm "github.com/hyperledger/fabric-sdk-go/pkg/core/config/comm"
 	"github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite"
 	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
 	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
@@ -65,8 +69,8 @@ func NewHeader(ctx contextApi.Context, channelID string) (*TransactionHeader, er
 		return nil, errors.WithMessage(err, "identity from context failed")
 	}
 
-	ho := cryptosuite.GetSHA256Opts() // TODO: make configurable
-	h, err := ctx.CryptoSuite().GetHash(ho)
+	// the peers compute the transaction ID with SHA256, whatever the security config of the SDK
+	h, err := ctx.CryptoSuite().GetHash(cryptosuite.GetSHA256Opts())
 	if err != nil {
 		return nil, errors.WithMessage(err, "hash function creation failed")
 	}
@@ -86,6 +90,43 @@ func NewHeader(ctx contextApi.Context, channelID string) (*TransactionHeader, er
 	return &txnID, nil
 }
 
+// NewHeaderWithCreator holds the metadata to create transaction proposals for the given creator, whose
+// identity is managed outside of the SDK (e.g. when the proposal is signed by an external service). The
+// TransactionID is computed from the given nonce and creator in the same way as NewHeader does, so that
+// it can be reproduced by whoever supplies them.
+func NewHeaderWithCreator(channelID string, creator []byte, nonce []byte) (*TransactionHeader, error) {
+	if len(creator) == 0 {
+		return nil, errors.New("creator is required")
+	}
+	if len(nonce) == 0 {
+		return nil, errors.New("nonce is required")
+	}
+
+	id, err := ComputeTxnID(nonce, creator)
+	if err != nil {
+		return nil, errors.WithMessage(err, "txn ID computation failed")
+	}
+
+	txnID := TransactionHeader{
+		id:        id,
+		creator:   creator,
+		nonce:     nonce,
+		channelID: channelID,
+	}
+
+	return &txnID, nil
+}
+
+// ComputeTxnID computes the TransactionID of a transaction from its nonce and creator (the SHA256 hash of
+// the nonce followed by the creator's identity bytes, as computed by the peers)
+func ComputeTxnID(nonce, creator []byte) (fab.TransactionID, error) {
+	id, err := computeTxnID(nonce, creator, sha256.New())
+	if err != nil {
+		return "", err
+	}
+	return fab.TransactionID(id), nil
+}
+
 func computeTxnID(nonce, creator []byte, h hash.Hash) (string, error) {
 	b := append(nonce, creator...)
 
@@ -123,6 +164,28 @@ type ChannelHeaderOpts struct {
 	TLSCertHash []byte
 }
 
+// OrderersTLSCertHash returns the TLS cert hash to set in the channel header of a request that is sent
+// to the given orderers, i.e. the hash of the client TLS certificate presented to them. The hash of the
+// configured client certificate is used for orderers that don't expose the certificate of their
+// connection. An error is returned if the orderers present different certificates.
+func OrderersTLSCertHash(config core.Config, orderers []fab.Orderer) ([]byte, error) {
+	configHash := ccomm.TLSCertHash(config)
+
+	var tlsCertHash []byte
+	for i, orderer := range orderers {
+		hash := configHash
+		if hasher, ok := orderer.(fab.TLSCertHasher); ok {
+			hash = hasher.TLSCertHash()
+		}
+		if i == 0 {
+			tlsCertHash = hash
+		} else if !bytes.Equal(tlsCertHash, hash) {
+			return nil, errors.New("orderers present different client TLS certificates but a request may only be bound to one TLS cert hash")
+		}
+	}
+	return tlsCertHash, nil
+}
+
 // CreateChannelHeader is a utility method to build a common chain header (TODO refactor)
 //
 // TODO: Determine if this function should be exported after refactoring is completed.
@@ -7,6 +7,7 @@ SPDX-License-Identifier: Apache-2.0
 package txn
 
 import (
+	"bytes"
 	"sync"
 
 	"github.com/golang/protobuf/proto"
@@ -74,7 +75,80 @@ func signProposal(ctx context, proposal *pb.Proposal) (*pb.SignedProposal, error
 	return &pb.SignedProposal{ProposalBytes: proposalBytes, Signature: signature}, nil
 }
 
+// ProposalBytes returns the bytes of the proposal that are signed by its creator, for signing the proposal
+// outside of the SDK (see NewSignedProposal). If the targets that the proposal will be sent to connect
+// using a client TLS certificate then the TLS cert hash is set in the proposal first (see SendProposal),
+// since the proposal can't be changed once it's signed.
+func ProposalBytes(proposal *fab.TransactionProposal, targets ...fab.ProposalProcessor) ([]byte, error) {
+	if proposal == nil || proposal.Proposal == nil {
+		return nil, errors.New("proposal is required")
+	}
+
+	tlsCertHash, err := targetsTLSCertHash(targets)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tlsCertHash) > 0 {
+		proposal.Proposal, err = proposalWithTLSCertHash(proposal.Proposal, tlsCertHash)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	proposalBytes, err := proto.Marshal(proposal.Proposal)
+	if err != nil {
+		return nil, errors.Wrap(err, "mashal proposal failed")
+	}
+	return proposalBytes, nil
+}
+
+// NewSignedProposal creates a SignedProposal from the bytes of a proposal (see ProposalBytes) and the
+// signature of the given creator over them. An error is returned if the proposal wasn't created for
+// the creator, or if its transaction ID doesn't match its nonce and creator.
+func NewSignedProposal(proposalBytes []byte, signature []byte, creator []byte) (*pb.SignedProposal, error) {
+	if len(signature) == 0 {
+		return nil, errors.New("signature is required")
+	}
+
+	proposal := &pb.Proposal{}
+	if err := proto.Unmarshal(proposalBytes, proposal); err != nil {
+		return nil, errors.Wrap(err, "unmarshal proposal failed")
+	}
+	hdr, err := protos_utils.GetHeader(proposal.Header)
+	if err != nil {
+		return nil, errors.Wrap(err, "unmarshal proposal header failed")
+	}
+	signatureHeader, err := protos_utils.GetSignatureHeader(hdr.SignatureHeader)
+	if err != nil {
+		return nil, errors.Wrap(err, "unmarshal signature header failed")
+	}
+	channelHeader, err := protos_utils.UnmarshalChannelHeader(hdr.ChannelHeader)
+	if err != nil {
+		return nil, errors.Wrap(err, "unmarshal channel header failed")
+	}
+
+	if !bytes.Equal(signatureHeader.Creator, creator) {
+		return nil, errors.New("proposal wasn't created for the given creator")
+	}
+	txnID, err := ComputeTxnID(signatureHeader.Nonce, signatureHeader.Creator)
+	if err != nil {
+		return nil, errors.WithMessage(err, "txn ID computation failed")
+	}
+	if string(txnID) != channelHeader.TxId {
+		return nil, errors.Errorf("transaction ID %s of the proposal doesn't match its nonce and creator", channelHeader.TxId)
+	}
+
+	return &pb.SignedProposal{ProposalBytes: proposalBytes, Signature: signature}, nil
+}
+
 // SendProposal sends a TransactionProposal to ProposalProcessor.
+//
+// If the targets connect using a client TLS certificate (i.e. they implement fab.TLSCertHasher) then
+// the TLS cert hash in the proposal's channel header is set to the hash of that certificate. Since the
+// hash is embedded in the proposal responses, all such targets must present the same certificate. The
+// given proposal is updated with the new header so that the transaction created from the responses
+// contains the same header that was endorsed.
 func SendProposal(ctx context, proposal *fab.TransactionProposal, targets []fab.ProposalProcessor) ([]*fab.TransactionProposalResponse, error) {
 
 	if proposal == nil {
@@ -85,11 +159,36 @@ func SendProposal(ctx context, proposal *fab.TransactionProposal, targets []fab.
 		return nil, errors.New("targets is required")
 	}
 
+	tlsCertHash, err := targetsTLSCertHash(targets)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tlsCertHash) > 0 {
+		proposal.Proposal, err = proposalWithTLSCertHash(proposal.Proposal, tlsCertHash)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	signedProposal, err := signProposal(ctx, proposal.Proposal)
 	if err != nil {
 		return nil, errors.WithMessage(err, "sign proposal failed")
 	}
 
+	return SendSignedProposal(signedProposal, targets)
+}
+
+// SendSignedProposal sends a proposal that has already been signed (see NewSignedProposal) to the targets
+func SendSignedProposal(signedProposal *pb.SignedProposal, targets []fab.ProposalProcessor) ([]*fab.TransactionProposalResponse, error) {
+	if signedProposal == nil {
+		return nil, errors.New("signed proposal is required")
+	}
+
+	if len(targets) < 1 {
+		return nil, errors.New("targets is required")
+	}
+
 	request := fab.ProcessProposalRequest{SignedProposal: signedProposal}
 
 	var responseMtx sync.Mutex
@@ -120,3 +219,62 @@ func SendProposal(ctx context, proposal *fab.TransactionProposal, targets []fab.
 
 	return transactionProposalResponses, errs.ToError()
 }
+
+// targetsTLSCertHash returns the hash of the client TLS certificate presented to the given targets
+// (nil if none of the targets expose a certificate). An error is returned if the targets present
+// different certificates since a proposal may only be bound to one certificate.
+func targetsTLSCertHash(targets []fab.ProposalProcessor) ([]byte, error) {
+	var tlsCertHash []byte
+	for _, target := range targets {
+		hasher, ok := target.(fab.TLSCertHasher)
+		if !ok {
+			continue
+		}
+		hash := hasher.TLSCertHash()
+		if len(hash) == 0 {
+			continue
+		}
+		if tlsCertHash == nil {
+			tlsCertHash = hash
+		} else if !bytes.Equal(tlsCertHash, hash) {
+			return nil, errors.New("targets present different client TLS certificates but a proposal may only be bound to one TLS cert hash")
+		}
+	}
+	return tlsCertHash, nil
+}
+
+// proposalWithTLSCertHash returns a copy of the given proposal with the TLS cert hash of the channel header
+// set to the given hash. The proposal is returned unchanged if the header already contains the hash.
+func proposalWithTLSCertHash(proposal *pb.Proposal, tlsCertHash []byte) (*pb.Proposal, error) {
+	hdr, err := protos_utils.GetHeader(proposal.Header)
+	if err != nil {
+		return nil, errors.Wrap(err, "unmarshal proposal header failed")
+	}
+
+	channelHeader, err := protos_utils.UnmarshalChannelHeader(hdr.ChannelHeader)
+	if err != nil {
+		return nil, errors.Wrap(err, "unmarshal channel header failed")
+	}
+
+	if bytes.Equal(channelHeader.TlsCertHash, tlsCertHash) {
+		return proposal, nil
+	}
+
+	channelHeader.TlsCertHash = tlsCertHash
+
+	hdr.ChannelHeader, err = proto.Marshal(channelHeader)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal channel header failed")
+	}
+
+	hdrBytes, err := proto.Marshal(hdr)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal proposal header failed")
+	}
+
+	return &pb.Proposal{
+		Header:    hdrBytes,
+		Payload:   proposal.Payload,
+		Extension: proposal.Extension,
+	}, nil
+}
@@ -6,7 +6,13 @@ SPDX-License-Identifier: Apache-2.0
 package txn
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/asn1"
 	"fmt"
+	"math/big"
 	"reflect"
 	"testing"
 
@@ -19,6 +25,7 @@ import (
 	"github.com/hyperledger/fabric-sdk-go/pkg/errors/multi"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
 	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+	protos_utils "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/utils"
 )
 
 const (
@@ -242,6 +249,87 @@ func TestProposalResponseError(t *testing.T) {
 	assert.Equal(t, testError, errs[0])
 }
 
+func TestSendProposalWithTLSCertHash(t *testing.T) {
+	user := mocks.NewMockUserWithMSPID("test", "1234")
+	ctx := mocks.NewMockContext(user)
+
+	request := fab.ChaincodeInvokeRequest{
+		ChaincodeID: "cc",
+		Fcn:         "Hello",
+	}
+
+	txh, err := NewHeader(ctx, testChannel)
+	if err != nil {
+		t.Fatalf("create transaction ID failed: %s", err)
+	}
+
+	tp, err := CreateChaincodeInvokeProposal(txh, request)
+	if err != nil {
+		t.Fatalf("new transaction proposal failed: %s", err)
+	}
+
+	hash1 := []byte("hash1")
+	hash2 := []byte("hash2")
+
+	proc1 := &mockTLSProcessor{tlsCertHash: hash1}
+	proc2 := &mockTLSProcessor{tlsCertHash: hash1}
+	proc3 := &mockTLSProcessor{}
+
+	_, err = SendProposal(ctx, tp, []fab.ProposalProcessor{proc1, proc2, proc3})
+	if err != nil {
+		t.Fatalf("send transaction proposal failed: %s", err)
+	}
+
+	for _, proc := range []*mockTLSProcessor{proc1, proc2, proc3} {
+		assert.Equal(t, hash1, proc.receivedTLSCertHash(t), "unexpected TLS cert hash in channel header")
+	}
+
+	// The transaction is created from the proposal so it must contain the endorsed header
+	assert.Equal(t, hash1, proposalTLSCertHash(t, tp.Proposal), "expecting TLS cert hash to be set in the proposal")
+
+	// A proposal may only be bound to one client certificate
+	proc4 := &mockTLSProcessor{tlsCertHash: hash2}
+	_, err = SendProposal(ctx, tp, []fab.ProposalProcessor{proc1, proc4})
+	if err == nil {
+		t.Fatalf("expecting error sending proposal to targets with different TLS certs")
+	}
+	assert.Nil(t, proc4.request.SignedProposal, "expecting proposal not to be sent to targets with different TLS certs")
+}
+
+type mockTLSProcessor struct {
+	tlsCertHash []byte
+	request     fab.ProcessProposalRequest
+}
+
+func (p *mockTLSProcessor) TLSCertHash() []byte {
+	return p.tlsCertHash
+}
+
+func (p *mockTLSProcessor) ProcessTransactionProposal(request fab.ProcessProposalRequest) (*fab.TransactionProposalResponse, error) {
+	p.request = request
+	return &fab.TransactionProposalResponse{Endorser: "example.com", Status: 200}, nil
+}
+
+func (p *mockTLSProcessor) receivedTLSCertHash(t *testing.T) []byte {
+	proposal := &pb.Proposal{}
+	if err := proto.Unmarshal(p.request.SignedProposal.ProposalBytes, proposal); err != nil {
+		t.Fatalf("unmarshal proposal failed: %s", err)
+	}
+	return proposalTLSCertHash(t, proposal)
+}
+
+func proposalTLSCertHash(t *testing.T, proposal *pb.Proposal) []byte {
+	hdr, err := protos_utils.GetHeader(proposal.Header)
+	if err != nil {
+		t.Fatalf("unmarshal header failed: %s", err)
+	}
+	channelHeader, err := protos_utils.UnmarshalChannelHeader(hdr.ChannelHeader)
+	if err != nil {
+		t.Fatalf("unmarshal channel header failed: %s", err)
+	}
+	return channelHeader.TlsCertHash
+}
+
 func setupMassiveTestPeers(numberOfPeers int) []fab.ProposalProcessor {
 	peers := []fab.ProposalProcessor{}
 
@@ -253,3 +341,135 @@ func setupMassiveTestPeers(numberOfPeers int) []fab.ProposalProcessor {
 
 	return peers
 }
+
+// signWithKey signs the digest of the given bytes with a key that's held outside of the SDK
+func signWithKey(t *testing.T, key *ecdsa.PrivateKey, b []byte) []byte {
+	digest := sha256.Sum256(b)
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatalf("Failed to sign: %s", err)
+	}
+	signature, err := asn1.Marshal(ecdsaSignature{R: r, S: s})
+	if err != nil {
+		t.Fatalf("Failed to marshal signature: %s", err)
+	}
+	return signature
+}
+
+func verifyWithKey(t *testing.T, key *ecdsa.PrivateKey, b []byte, signature []byte) {
+	var sig ecdsaSignature
+	if _, err := asn1.Unmarshal(signature, &sig); err != nil {
+		t.Fatalf("Failed to unmarshal signature: %s", err)
+	}
+	digest := sha256.Sum256(b)
+	assert.True(t, ecdsa.Verify(&key.PublicKey, digest[:], sig.R, sig.S), "expected the signature to be valid")
+}
+
+type ecdsaSignature struct {
+	R, S *big.Int
+}
+
+func TestOfflineSigning(t *testing.T) {
+	user := mocks.NewMockUserWithMSPID("test", "1234")
+	ctx := mocks.NewMockContext(user)
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %s", err)
+	}
+	request := fab.ChaincodeInvokeRequest{ChaincodeID: "cc", Fcn: "invoke", Args: [][]byte{[]byte("a"), []byte("b")}}
+
+	// The normal path, signed with the context
+	txh, err := NewHeader(ctx, testChannel)
+	assert.Nil(t, err, "NewHeader failed")
+	proposal, err := CreateChaincodeInvokeProposal(txh, request)
+	assert.Nil(t, err, "CreateChaincodeInvokeProposal failed")
+	signedProposal, err := signProposal(ctx, proposal.Proposal)
+	assert.Nil(t, err, "signProposal failed")
+
+	// The offline path, with the nonce and creator supplied by the external signer
+	offlineTxh, err := NewHeaderWithCreator(testChannel, txh.Creator(), txh.Nonce())
+	assert.Nil(t, err, "NewHeaderWithCreator failed")
+	assert.Equal(t, txh.TransactionID(), offlineTxh.TransactionID(), "expected the transaction ID to be reproducible")
+
+	offlineProposal, err := CreateChaincodeInvokeProposal(offlineTxh, request)
+	assert.Nil(t, err, "CreateChaincodeInvokeProposal failed")
+	proposalBytes, err := ProposalBytes(offlineProposal)
+	assert.Nil(t, err, "ProposalBytes failed")
+	offlineSignedProposal, err := NewSignedProposal(proposalBytes, signWithKey(t, key, proposalBytes), txh.Creator())
+	assert.Nil(t, err, "NewSignedProposal failed")
+	verifyWithKey(t, key, offlineSignedProposal.ProposalBytes, offlineSignedProposal.Signature)
+
+	// The proposals only differ by their timestamps
+	expected := &pb.Proposal{}
+	assert.Nil(t, proto.Unmarshal(signedProposal.ProposalBytes, expected))
+	actual := &pb.Proposal{}
+	assert.Nil(t, proto.Unmarshal(offlineSignedProposal.ProposalBytes, actual))
+	assert.Equal(t, expected.Payload, actual.Payload, "expected the same proposal payload")
+	expectedHdr, err := protos_utils.GetHeader(expected.Header)
+	assert.Nil(t, err)
+	actualHdr, err := protos_utils.GetHeader(actual.Header)
+	assert.Nil(t, err)
+	assert.Equal(t, expectedHdr.SignatureHeader, actualHdr.SignatureHeader, "expected the same nonce and creator")
+	expectedCh, err := protos_utils.UnmarshalChannelHeader(expectedHdr.ChannelHeader)
+	assert.Nil(t, err)
+	actualCh, err := protos_utils.UnmarshalChannelHeader(actualHdr.ChannelHeader)
+	assert.Nil(t, err)
+	actualCh.Timestamp = expectedCh.Timestamp
+	assert.True(t, proto.Equal(expectedCh, actualCh), "expected the same channel header")
+
+	peer := mocks.NewMockPeer("Peer1", "http://peer1.com")
+	responses, err := SendSignedProposal(offlineSignedProposal, []fab.ProposalProcessor{peer})
+	assert.Nil(t, err, "SendSignedProposal failed")
+	assert.Len(t, responses, 1)
+
+	// The transaction envelope
+	tx, err := New(fab.TransactionRequest{Proposal: offlineProposal, ProposalResponses: responses})
+	assert.Nil(t, err, "New failed")
+	envelope, err := SignTransaction(ctx, tx)
+	assert.Nil(t, err, "SignTransaction failed")
+
+	payloadBytes, err := TransactionPayloadBytes(tx)
+	assert.Nil(t, err, "TransactionPayloadBytes failed")
+	assert.Equal(t, envelope.Payload, payloadBytes, "expected the payload of the normal path")
+	offlineEnvelope, err := NewSignedEnvelope(payloadBytes, signWithKey(t, key, payloadBytes))
+	assert.Nil(t, err, "NewSignedEnvelope failed")
+	verifyWithKey(t, key, offlineEnvelope.Payload, offlineEnvelope.Signature)
+
+	broadcasts := make(chan *fab.SignedEnvelope, 1)
+	resp, err := BroadcastEnvelope(offlineEnvelope, []fab.Orderer{mocks.NewMockOrderer("", broadcasts)})
+	assert.Nil(t, err, "BroadcastEnvelope failed")
+	assert.Nil(t, resp.Err, "expected the orderer to accept the envelope")
+	assert.Equal(t, offlineEnvelope, <-broadcasts)
+}
+
+func TestNewSignedProposalErrors(t *testing.T) {
+	creator := []byte("creator")
+	txh, err := NewHeaderWithCreator(testChannel, creator, []byte("nonce"))
+	assert.Nil(t, err, "NewHeaderWithCreator failed")
+	request := fab.ChaincodeInvokeRequest{ChaincodeID: "cc", Fcn: "invoke"}
+
+	proposal, err := CreateChaincodeInvokeProposal(txh, request)
+	assert.Nil(t, err)
+	proposalBytes, err := ProposalBytes(proposal)
+	assert.Nil(t, err)
+
+	_, err = NewSignedProposal(proposalBytes, nil, creator)
+	assert.EqualError(t, err, "signature is required")
+
+	_, err = NewSignedProposal(proposalBytes, []byte("signature"), []byte("other creator"))
+	assert.EqualError(t, err, "proposal wasn't created for the given creator")
+
+	// A transaction ID that wasn't computed from the nonce and creator
+	forged := &TransactionHeader{id: "forged", creator: creator, nonce: []byte("nonce"), channelID: testChannel}
+	proposal, err = CreateChaincodeInvokeProposal(forged, request)
+	assert.Nil(t, err)
+	proposalBytes, err = ProposalBytes(proposal)
+	assert.Nil(t, err)
+	_, err = NewSignedProposal(proposalBytes, []byte("signature"), creator)
+	assert.EqualError(t, err, "transaction ID forged of the proposal doesn't match its nonce and creator")
+
+	_, err = NewHeaderWithCreator(testChannel, nil, []byte("nonce"))
+	assert.EqualError(t, err, "creator is required")
+	_, err = NewHeaderWithCreator(testChannel, creator, nil)
+	assert.EqualError(t, err, "nonce is required")
+}
@@ -13,6 +13,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/pkg/errors"
 
 	contextApi "github.com/hyperledger/fabric-sdk-go/pkg/context"
@@ -114,6 +115,61 @@ func Send(ctx context, tx *fab.Transaction, orderers []fab.Orderer) (*fab.Transa
 	if orderers == nil || len(orderers) == 0 {
 		return nil, errors.New("orderers is nil")
 	}
+
+	payload, err := newTransactionPayload(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	transactionResponse, err := BroadcastPayload(ctx, payload, orderers)
+	if err != nil {
+		return nil, err
+	}
+
+	return transactionResponse, nil
+}
+
+// SignTransaction creates the signed envelope of a transaction, without sending it to the orderers.
+// The envelope can be broadcast later (e.g. by another process) with the Broadcast method of an orderer.
+func SignTransaction(ctx context, tx *fab.Transaction) (*fab.SignedEnvelope, error) {
+	payload, err := newTransactionPayload(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return signPayload(ctx, payload)
+}
+
+// TransactionPayloadBytes returns the bytes of the transaction's payload that are signed by its creator,
+// for signing the transaction outside of the SDK (see NewSignedEnvelope)
+func TransactionPayloadBytes(tx *fab.Transaction) ([]byte, error) {
+	payload, err := newTransactionPayload(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	payloadBytes, err := proto.Marshal(payload)
+	if err != nil {
+		return nil, errors.WithMessage(err, "marshaling of payload failed")
+	}
+	return payloadBytes, nil
+}
+
+// NewSignedEnvelope creates the envelope of a transaction from the bytes of its payload (see
+// TransactionPayloadBytes) and the signature of its creator over them. The envelope is sent to
+// the orderer with BroadcastEnvelope.
+func NewSignedEnvelope(payloadBytes []byte, signature []byte) (*fab.SignedEnvelope, error) {
+	if len(signature) == 0 {
+		return nil, errors.New("signature is required")
+	}
+	if err := proto.Unmarshal(payloadBytes, &common.Payload{}); err != nil {
+		return nil, errors.Wrap(err, "unmarshal payload failed")
+	}
+	return &fab.SignedEnvelope{Payload: payloadBytes, Signature: signature}, nil
+}
+
+// newTransactionPayload creates the payload of a transaction, using the header of its proposal
+func newTransactionPayload(tx *fab.Transaction) (*common.Payload, error) {
 	if tx == nil {
 		return nil, errors.New("transaction is nil")
 	}
@@ -132,15 +188,7 @@ func Send(ctx context, tx *fab.Transaction, orderers []fab.Orderer) (*fab.Transa
 		return nil, err
 	}
 
-	// create the payload
-	payload := common.Payload{Header: hdr, Data: txBytes}
-
-	transactionResponse, err := BroadcastPayload(ctx, &payload, orderers)
-	if err != nil {
-		return nil, err
-	}
-
-	return transactionResponse, nil
+	return &common.Payload{Header: hdr, Data: txBytes}, nil
 }
 
 // BroadcastPayload will send the given payload to some orderer, picking random endpoints
@@ -156,12 +204,12 @@ func BroadcastPayload(ctx context, payload *common.Payload, orderers []fab.Order
 		return nil, err
 	}
 
-	return broadcastEnvelope(ctx, envelope, orderers)
+	return BroadcastEnvelope(envelope, orderers)
 }
 
-// broadcastEnvelope will send the given envelope to some orderer, picking random endpoints
+// BroadcastEnvelope will send the given signed envelope to some orderer, picking random endpoints
 // until all are exhausted
-func broadcastEnvelope(ctx context, envelope *fab.SignedEnvelope, orderers []fab.Orderer) (*fab.TransactionResponse, error) {
+func BroadcastEnvelope(envelope *fab.SignedEnvelope, orderers []fab.Orderer) (*fab.TransactionResponse, error) {
 	// Check if orderers are defined
 	if len(orderers) == 0 {
 		return nil, errors.New("orderers not set")
@@ -250,7 +298,7 @@ func sendEnvelope(ctx context, envelope *fab.SignedEnvelope, orderers []fab.Orde
 				}
 				mutex.Unlock()
 
-			case <-time.After(ctx.Config().TimeoutOrDefault(core.OrdererResponse)):
+			case <-time.After(ctx.Config().Timeout(core.OrdererResponse)):
 				mutex.Lock()
 				if errorResponse == nil {
 					errorResponse = errors.New("timeout waiting for response from orderer")
@@ -288,13 +336,18 @@ type Status struct {
 // returns a TxValidationCode channel which receives the validation code when the
 // transaction completes. If the code is TxValidationCode_VALID then
 // the transaction committed successfully, otherwise the code indicates the error
-// that occurred.
+// that occurred. The channel is buffered so that the event hub isn't blocked if
+// the status is no longer awaited.
 func RegisterStatus(txID fab.TransactionID, eventHub fab.EventHub) chan Status {
-	statusNotifier := make(chan Status)
+	statusNotifier := make(chan Status, 1)
 
 	eventHub.RegisterTxEvent(txID, func(txId fab.TransactionID, code pb.TxValidationCode, err error) {
 		logger.Debugf("Received code(%s) for txid(%s) and err(%s)\n", code, txId, err)
-		statusNotifier <- Status{Code: code, Error: err}
+		select {
+		case statusNotifier <- Status{Code: code, Error: err}:
+		default:
+			// The status has already been reported
+		}
 	})
 
 	return statusNotifier
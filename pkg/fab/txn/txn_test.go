@@ -8,6 +8,8 @@ package txn
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"strconv"
@@ -15,13 +17,17 @@ import (
 	"testing"
 	"time"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite/bccsp/sw"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
 	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/common"
 	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+	protos_utils "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/utils"
 )
 
 func TestNewTransaction(t *testing.T) {
@@ -140,9 +146,6 @@ func (r *mockReader) Read(p []byte) (int, error) {
 }
 
 func TestBroadcastEnvelope(t *testing.T) {
-	user := mocks.NewMockUserWithMSPID("test", "1234")
-	ctx := mocks.NewMockContext(user)
-
 	lsnr1 := make(chan *fab.SignedEnvelope)
 	lsnr2 := make(chan *fab.SignedEnvelope)
 	//Create mock orderers
@@ -155,7 +158,7 @@ func TestBroadcastEnvelope(t *testing.T) {
 		Signature: []byte(""),
 		Payload:   []byte(""),
 	}
-	res, err := broadcastEnvelope(ctx, sigEnvelope, orderers)
+	res, err := BroadcastEnvelope(sigEnvelope, orderers)
 
 	if err != nil || res.Err != nil {
 		t.Fatalf("Test Broadcast Envelope Failed, cause %v %v", err, res)
@@ -185,7 +188,7 @@ func TestBroadcastEnvelope(t *testing.T) {
 	}
 	// It should always succeed even though one of them has failed
 	for i := 0; i < broadcastCount; i++ {
-		if res, err := broadcastEnvelope(ctx, sigEnvelope, orderers); err != nil || res.Err != nil {
+		if res, err := BroadcastEnvelope(sigEnvelope, orderers); err != nil || res.Err != nil {
 			t.Fatalf("Test Broadcast Envelope Failed, cause %v %v", err, res)
 		}
 	}
@@ -197,7 +200,7 @@ func TestBroadcastEnvelope(t *testing.T) {
 	}
 
 	for i := 0; i < broadcastCount; i++ {
-		res, err := broadcastEnvelope(ctx, sigEnvelope, orderers)
+		res, err := BroadcastEnvelope(sigEnvelope, orderers)
 		if err != nil {
 			t.Fatalf("Test Broadcast sending failed, cause %v", err)
 		}
@@ -210,7 +213,7 @@ func TestBroadcastEnvelope(t *testing.T) {
 	}
 
 	emptyOrderers := []fab.Orderer{}
-	_, err = broadcastEnvelope(ctx, sigEnvelope, emptyOrderers)
+	_, err = BroadcastEnvelope(sigEnvelope, emptyOrderers)
 
 	if err == nil || err.Error() != "orderers not set" {
 		t.Fatal("orderers not set validation on broadcast envelope is not working as expected")
@@ -288,6 +291,39 @@ func TestSendTransaction(t *testing.T) {
 	}
 }
 
+func TestSignTransaction(t *testing.T) {
+	user := mocks.NewMockUserWithMSPID("test", "1234")
+	ctx := mocks.NewMockContext(user)
+
+	_, err := SignTransaction(ctx, nil)
+	assert.EqualError(t, err, "transaction is nil")
+
+	txh, err := NewHeader(ctx, "testchannel")
+	assert.Nil(t, err, "NewHeader failed")
+	proposal, err := CreateChaincodeInvokeProposal(txh, fab.ChaincodeInvokeRequest{ChaincodeID: "cc", Fcn: "invoke"})
+	assert.Nil(t, err, "CreateChaincodeInvokeProposal failed")
+
+	tx := fab.Transaction{
+		Proposal:    proposal,
+		Transaction: &pb.Transaction{Actions: []*pb.TransactionAction{{Header: []byte("creator")}}},
+	}
+
+	envelope, err := SignTransaction(ctx, &tx)
+	assert.Nil(t, err, "SignTransaction failed")
+	assert.NotEmpty(t, envelope.Signature, "envelope should be signed")
+
+	payload, err := protos_utils.GetPayload(&common.Envelope{Payload: envelope.Payload, Signature: envelope.Signature})
+	assert.Nil(t, err, "envelope payload should unmarshal")
+
+	hdr, err := protos_utils.GetHeader(proposal.Proposal.Header)
+	assert.Nil(t, err)
+	assert.True(t, proto.Equal(hdr, payload.Header), "envelope header should be the proposal header")
+
+	txBytes, err := protos_utils.GetBytesTransaction(tx.Transaction)
+	assert.Nil(t, err)
+	assert.Equal(t, txBytes, payload.Data, "envelope data should be the transaction")
+}
+
 func TestBuildChannelHeader(t *testing.T) {
 	user := mocks.NewMockUserWithMSPID("test", "1234")
 	ctx := mocks.NewMockContext(user)
@@ -357,3 +393,52 @@ func setupMassiveTestOrderer(numberOfOrderers int) []fab.Orderer {
 
 	return orderers
 }
+
+// hashConfig selects the hash function of the security config
+type hashConfig struct {
+	core.Config
+	family string
+	level  int
+}
+
+func (c *hashConfig) SecurityAlgorithm() string {
+	return c.family
+}
+
+func (c *hashConfig) SecurityLevel() int {
+	return c.level
+}
+
+func TestNewHeaderSHA256(t *testing.T) {
+	cs, err := sw.GetSuiteWithDefaultEphemeral()
+	assert.Nil(t, err, "crypto suite creation failed")
+	user := mocks.NewMockUserWithMSPID("test", "1234")
+
+	// the transaction ID is computed with SHA256 (as the peers do) whatever the hash function of the security config
+	configs := []struct {
+		family string
+		level  int
+	}{
+		{"SHA2", 256},
+		{"SHA2", 384},
+		{"SHA3", 256},
+		{"SHA3", 384},
+	}
+	for _, c := range configs {
+		config := &hashConfig{Config: mocks.NewMockConfig(), family: c.family, level: c.level}
+		ctx := &mocks.MockContext{
+			MockProviderContext: mocks.NewMockProviderContextCustom(config, cs, mocks.NewMockSigningManager()),
+			IdentityContext:     user,
+		}
+
+		txh, err := NewHeader(ctx, "testchannel")
+		assert.Nil(t, err, "NewHeader failed")
+
+		expected := sha256.Sum256(append(txh.Nonce(), txh.Creator()...))
+		assert.Equal(t, hex.EncodeToString(expected[:]), string(txh.TransactionID()), "transaction ID with %s-%d", c.family, c.level)
+
+		txnID, err := ComputeTxnID(txh.Nonce(), txh.Creator())
+		assert.Nil(t, err, "ComputeTxnID failed")
+		assert.Equal(t, txh.TransactionID(), txnID, "ComputeTxnID should match NewHeader with %s-%d", c.family, c.level)
+	}
+}
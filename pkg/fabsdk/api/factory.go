@@ -14,6 +14,9 @@ import (
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
 )
 
+// The factories, and the providers that they create, may implement io.Closer in order to release their
+// resources (e.g. connections or sessions) when the SDK is closed (see FabricSDK.Close).
+
 // CoreProviderFactory allows overriding of primitives and the fabric core object provider
 type CoreProviderFactory interface {
 	CreateStateStoreProvider(config core.Config) (api.KVStore, error)
@@ -12,6 +12,7 @@ import (
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/resource/api"
+	"github.com/hyperledger/fabric-sdk-go/pkg/options"
 )
 
 // FabricProvider enables access to fabric objects such as peer and user based on config or
@@ -22,6 +23,7 @@ type FabricProvider interface {
 	CreateResourceClient(user context.IdentityContext) (api.Resource, error)
 	CreateChannelTransactor(ic context.IdentityContext, cfg fab.ChannelCfg) (fab.Transactor, error)
 	CreateEventHub(ic context.IdentityContext, name string) (fab.EventHub, error)
+	CreateEventService(ic context.IdentityContext, name string, discovery fab.DiscoveryService, opts ...options.Opt) (fab.EventService, error)
 	CreateIdentityManager(orgID string) (fab.IdentityManager, error)
 
 	CreatePeerFromConfig(peerCfg *core.NetworkPeer) (fab.Peer, error)
@@ -41,6 +43,7 @@ type CoreProviders interface {
 	StateStore() contextApi.KVStore
 	Config() core.Config
 	SigningManager() contextApi.SigningManager
+	Connector() contextApi.Connector
 	FabricProvider() FabricProvider
 }
 
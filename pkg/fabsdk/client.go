@@ -8,6 +8,9 @@ package fabsdk
 
 import (
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/common/discovery"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/idmgmt"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/ledger"
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/resmgmt"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
@@ -79,6 +82,10 @@ func (sdk *FabricSDK) NewClient(identityOpt IdentityOption, opts ...ContextOptio
 	// delay execution of the following logic to avoid error return from this function.
 	// this is done to allow a cleaner API - i.e., client, err := sdk.NewClient(args).<Desired Interface>(extra args)
 	provider := func() (*clientContext, error) {
+		if sdk.isClosed() {
+			return nil, errSDKClosed
+		}
+
 		o, err := newContextOptions(sdk.config, opts)
 		if err != nil {
 			return nil, errors.WithMessage(err, "unable to retrieve configuration from SDK")
@@ -142,6 +149,30 @@ func newClientOptions(options []ClientOption) (*clientOptions, error) {
 	return &opts, nil
 }
 
+// IdentityMgmt returns a client API for enrolling the identities of an organization with its CA. The
+// organization defaults to the client organization of the configuration (see WithOrg). The enrolled
+// identities are stored in the credential store, so they can be used right away with WithUser.
+func (sdk *FabricSDK) IdentityMgmt(opts ...ContextOption) (*idmgmt.Client, error) {
+	if sdk.isClosed() {
+		return nil, errSDKClosed
+	}
+
+	o, err := newContextOptions(sdk.config, opts)
+	if err != nil {
+		return nil, errors.WithMessage(err, "unable to retrieve configuration from SDK")
+	}
+
+	identityManager, err := sdk.fabricProvider.CreateIdentityManager(o.orgID)
+	if err != nil {
+		if sdk.isUnknownOrg(o.orgID) {
+			return nil, &UnknownOrgError{Org: o.orgID}
+		}
+		return nil, errors.WithMessage(err, "failed to create identity manager")
+	}
+
+	return idmgmt.New(idmgmt.Context{IdentityManager: identityManager})
+}
+
 // ResourceMgmt returns a client API for managing system resources.
 func (c *ClientContext) ResourceMgmt(opts ...ClientOption) (*resmgmt.Client, error) {
 	p, err := c.provider()
@@ -197,6 +228,36 @@ func (c *ClientContext) Channel(id string, opts ...ClientOption) (*channel.Clien
 	return client, nil
 }
 
+// Ledger returns a client API for querying the ledger of a channel.
+func (c *ClientContext) Ledger(id string, opts ...ClientOption) (*ledger.Client, error) {
+	p, err := c.provider()
+	if err != nil {
+		return nil, errors.WithMessage(err, "unable to get client provider context")
+	}
+	o, err := newClientOptions(opts)
+	if err != nil {
+		return nil, errors.WithMessage(err, "unable to retrieve client options")
+	}
+
+	session := newSession(p.identity, p.providers.ChannelProvider())
+	chService, err := p.providers.ChannelProvider().ChannelService(session, id)
+	if err != nil {
+		return nil, errors.WithMessage(err, "create channel service failed")
+	}
+
+	discoveryService, err := p.providers.DiscoveryProvider().NewDiscoveryService(id)
+	if err != nil {
+		return nil, errors.WithMessage(err, "create discovery service failed")
+	}
+
+	ctx := ledger.Context{
+		ChannelID:        id,
+		DiscoveryService: discovery.NewDiscoveryFilterService(discoveryService, o.targetFilter),
+		ChannelService:   chService,
+	}
+	return ledger.New(ctx)
+}
+
 // ChannelService returns a client API for interacting with a channel.
 func (c *ClientContext) ChannelService(id string) (fab.ChannelService, error) {
 	p, err := c.provider()
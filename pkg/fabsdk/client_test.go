@@ -104,6 +104,29 @@ func TestWithOrg(t *testing.T) {
 	}
 }
 
+func TestIdentityMgmt(t *testing.T) {
+	sdk, err := New(configImpl.FromFile(clientConfigFile))
+	if err != nil {
+		t.Fatalf("Expected no error from New, but got %v", err)
+	}
+
+	_, err = sdk.IdentityMgmt()
+	if err != nil {
+		t.Fatalf("Expected no error from IdentityMgmt, but got %v", err)
+	}
+
+	_, err = sdk.IdentityMgmt(WithOrg("notarealorg"))
+	if _, ok := errors.Cause(err).(*UnknownOrgError); !ok {
+		t.Fatalf("Expected UnknownOrgError from IdentityMgmt, but got %v", err)
+	}
+
+	sdk.Close()
+	_, err = sdk.IdentityMgmt()
+	if err == nil {
+		t.Fatal("Expected error from IdentityMgmt after the SDK is closed")
+	}
+}
+
 func TestWithFilter(t *testing.T) {
 	tf := mockTargetFilter{}
 	opt := WithTargetFilter(&tf)
@@ -0,0 +1,71 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package fabsdk
+
+import (
+	"io"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/errors/multi"
+	"github.com/pkg/errors"
+)
+
+// defaultCloseTimeout is the maximum time that Close waits for the providers to be closed (see WithCloseTimeout)
+const defaultCloseTimeout = 10 * time.Second
+
+// errSDKClosed is returned when clients are requested from an SDK that has been closed
+var errSDKClosed = errors.New("SDK closed")
+
+// Close releases the resources of the SDK. The event hubs and the event service clients that were created
+// by the SDK and that are still open are closed, and the providers and the factories that implement io.Closer
+// are closed in the reverse order of their creation. Close waits for them to be closed until the close timeout (see WithCloseTimeout) expires.
+// Clients can't be created once the SDK is closed; clients that were already created should no longer be
+// used. Closing an SDK that's already closed has no effect.
+func (sdk *FabricSDK) Close() error {
+	sdk.closeMutex.Lock()
+	if sdk.closed {
+		sdk.closeMutex.Unlock()
+		return nil
+	}
+	sdk.closed = true
+	sdk.closeMutex.Unlock()
+
+	// The providers are followed by the factories that created them
+	closers := []interface{}{sdk.channelProvider, sdk.selectionProvider, sdk.discoveryProvider, sdk.fabricProvider,
+		sdk.connector, sdk.signingManager, sdk.credentialStore, sdk.stateStore, sdk.cryptoSuite,
+		sdk.opts.Session, sdk.opts.Context, sdk.opts.Service, sdk.opts.Core}
+
+	done := make(chan error, 1)
+	go func() {
+		var errs multi.Errors
+		for _, c := range closers {
+			if closer, ok := c.(io.Closer); ok {
+				if err := closer.Close(); err != nil {
+					errs = append(errs, err)
+				}
+			}
+		}
+		done <- errs.ToError()
+	}()
+
+	timeout := sdk.opts.CloseTimeout
+	if timeout <= 0 {
+		timeout = defaultCloseTimeout
+	}
+	select {
+	case err := <-done:
+		return errors.WithMessage(err, "failed to close SDK")
+	case <-time.After(timeout):
+		return errors.Errorf("timed out after %s waiting for the SDK providers to close", timeout)
+	}
+}
+
+func (sdk *FabricSDK) isClosed() bool {
+	sdk.closeMutex.Lock()
+	defer sdk.closeMutex.Unlock()
+	return sdk.closed
+}
@@ -0,0 +1,201 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package fabsdk
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	configImpl "github.com/hyperledger/fabric-sdk-go/pkg/core/config"
+	clientmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/events/client/mocks"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/deliverclient"
+	eventmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/events/mocks"
+	fabmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk/factory/defcore"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+)
+
+func TestClose(t *testing.T) {
+	server, address := startTestEventServer(t)
+	defer server.Stop()
+
+	// The SDK shouldn't leave any goroutines running once it's closed
+	before := goroutines()
+
+	sdk, err := New(configImpl.FromFile(sdkConfigFile),
+		WithSetting("peers.peer0.org1.example.com.eventUrl", "grpc://"+address))
+	if err != nil {
+		t.Fatalf("Error initializing SDK: %s", err)
+	}
+
+	identity, err := sdk.newUser(sdkValidClientOrg1, sdkValidClientUser)
+	if err != nil {
+		t.Fatalf("Unexpected error loading identity: %s", err)
+	}
+	eventHub, err := sdk.FabricProvider().CreateEventHub(identity, "mychannel")
+	if err != nil {
+		t.Fatalf("Unexpected error creating event hub: %s", err)
+	}
+	if err := eventHub.Connect(); err != nil {
+		t.Fatalf("Unexpected error connecting event hub: %s", err)
+	}
+	discovery := clientmocks.NewDiscoveryService(fabmocks.NewMockPeer("peer1", "grpc://"+address))
+	eventService, err := sdk.FabricProvider().CreateEventService(identity, "mychannel", discovery)
+	if err != nil {
+		t.Fatalf("Unexpected error creating event service: %s", err)
+	}
+
+	if err := sdk.Close(); err != nil {
+		t.Fatalf("Unexpected error closing SDK: %s", err)
+	}
+	if eventHub.IsConnected() {
+		t.Fatal("Expected the event hub to be disconnected when the SDK is closed")
+	}
+	if !eventService.(*deliverclient.Client).Stopped() {
+		t.Fatal("Expected the event service to be closed when the SDK is closed")
+	}
+	if _, err := sdk.connector.DialContext(context.Background(), address, nil); err == nil {
+		t.Fatal("Expected the connections of the connector to be closed when the SDK is closed")
+	}
+
+	// Closing the SDK again has no effect
+	if err := sdk.Close(); err != nil {
+		t.Fatalf("Unexpected error closing SDK again: %s", err)
+	}
+
+	if _, err := sdk.NewClient(WithUser(sdkValidClientUser)).ResourceMgmt(); errors.Cause(err) != errSDKClosed {
+		t.Fatalf("Expected SDK closed error, got %v", err)
+	}
+	if _, err := sdk.Context(WithUser(sdkValidClientUser)).Identity(); errors.Cause(err) != errSDKClosed {
+		t.Fatalf("Expected SDK closed error, got %v", err)
+	}
+
+	if leaked := leakedGoroutines(before, 5*time.Second); len(leaked) > 0 {
+		t.Fatalf("Goroutines are still running after the SDK was closed:\n%s", strings.Join(leaked, "\n\n"))
+	}
+}
+
+func TestCloseProviders(t *testing.T) {
+	core := &closerCoreFactory{ProviderFactory: defcore.NewProviderFactory()}
+	sdk, err := New(configImpl.FromFile(sdkConfigFile), WithCorePkg(core))
+	if err != nil {
+		t.Fatalf("Error initializing SDK: %s", err)
+	}
+
+	if err := sdk.Close(); err != nil {
+		t.Fatalf("Unexpected error closing SDK: %s", err)
+	}
+	if core.closed != 1 {
+		t.Fatalf("Expected the core factory to be closed once, got %d", core.closed)
+	}
+	if err := sdk.Close(); err != nil || core.closed != 1 {
+		t.Fatalf("Expected closing the SDK again to have no effect: %v", err)
+	}
+}
+
+func TestCloseTimeout(t *testing.T) {
+	core := &closerCoreFactory{ProviderFactory: defcore.NewProviderFactory(), delay: time.Second}
+	sdk, err := New(configImpl.FromFile(sdkConfigFile), WithCorePkg(core), WithCloseTimeout(10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("Error initializing SDK: %s", err)
+	}
+
+	err = sdk.Close()
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("Expected close timeout error, got %v", err)
+	}
+}
+
+type closerCoreFactory struct {
+	*defcore.ProviderFactory
+	delay  time.Duration
+	closed int
+}
+
+func (f *closerCoreFactory) Close() error {
+	time.Sleep(f.delay)
+	f.closed++
+	return nil
+}
+
+// testEventServer is an event server that acknowledges registrations and ends the
+// stream when the client closes it
+type testEventServer struct{}
+
+func (s *testEventServer) Chat(srv pb.Events_ChatServer) error {
+	for {
+		in, err := srv.Recv()
+		if err != nil {
+			return nil
+		}
+		evt := &pb.Event{}
+		if err := proto.Unmarshal(in.EventBytes, evt); err != nil {
+			return err
+		}
+		switch evt.Event.(type) {
+		case *pb.Event_Register:
+			srv.Send(&pb.Event{Event: &pb.Event_Register{Register: &pb.Register{}}})
+		case *pb.Event_Unregister:
+			srv.Send(&pb.Event{Event: &pb.Event_Unregister{Unregister: &pb.Unregister{}}})
+		}
+	}
+}
+
+func startTestEventServer(t *testing.T) (*grpc.Server, string) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error starting test event server: %s", err)
+	}
+	server := grpc.NewServer()
+	pb.RegisterEventsServer(server, &testEventServer{})
+	pb.RegisterDeliverServer(server, eventmocks.NewMockDeliverServer())
+	go server.Serve(lis)
+	return server, lis.Addr().String()
+}
+
+// goroutines returns the stacks of the running goroutines by goroutine ID
+func goroutines() map[string]string {
+	buf := make([]byte, 1<<20)
+	buf = buf[:runtime.Stack(buf, true)]
+
+	stacks := make(map[string]string)
+	for _, stack := range bytes.Split(buf, []byte("\n\n")) {
+		header := strings.SplitN(string(stack), " ", 3)
+		if len(header) < 3 {
+			continue
+		}
+		stacks[header[1]] = string(stack)
+	}
+	return stacks
+}
+
+// leakedGoroutines returns the stacks of the goroutines that weren't running at the time of the given
+// snapshot and that are still running after the timeout
+func leakedGoroutines(before map[string]string, timeout time.Duration) []string {
+	deadline := time.Now().Add(timeout)
+	for {
+		var leaked []string
+		for id, stack := range goroutines() {
+			if _, ok := before[id]; ok || strings.Contains(stack, "leakedGoroutines") {
+				continue
+			}
+			leaked = append(leaked, stack)
+		}
+		if len(leaked) == 0 || time.Now().After(deadline) {
+			return leaked
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
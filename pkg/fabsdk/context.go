@@ -38,6 +38,11 @@ func (c *fabContext) SigningManager() contextApi.SigningManager {
 	return c.sdk.signingManager
 }
 
+// Connector returns the connector that shares the connections between the clients
+func (c *fabContext) Connector() contextApi.Connector {
+	return c.sdk.connector
+}
+
 // StateStore returns state store
 func (c *sdkContext) StateStore() contextApi.KVStore {
 	return c.sdk.stateStore
@@ -89,7 +94,9 @@ func WithUser(name string) IdentityOption {
 	}
 }
 
-// WithIdentity uses a pre-constructed identity object as the credential for the session
+// WithIdentity uses a pre-constructed identity object as the credential for the session. The identity may be
+// created in memory from a certificate and private key (see msp.NewSigningIdentity), in which case the
+// credential store isn't involved.
 func WithIdentity(identity context.IdentityContext) IdentityOption {
 	return func(o *identityOptions, sdk *FabricSDK, orgName string) error {
 		if o.ok {
@@ -7,9 +7,11 @@ SPDX-License-Identifier: Apache-2.0
 package fabsdk
 
 import (
+	"bytes"
 	"testing"
 
 	configImpl "github.com/hyperledger/fabric-sdk-go/pkg/core/config"
+	"github.com/hyperledger/fabric-sdk-go/pkg/msp"
 )
 
 const (
@@ -73,3 +75,42 @@ func TestWithIdentity(t *testing.T) {
 		t.Fatal("Expected identity to be populated")
 	}
 }
+
+func TestWithSigningIdentity(t *testing.T) {
+	sdk, err := New(configImpl.FromFile(identityOptConfigFile))
+	if err != nil {
+		t.Fatalf("Expected no error from New, but got %v", err)
+	}
+	defer sdk.Close()
+
+	credentialMgr, err := sdk.opts.Context.CreateCredentialManager(identityValidOptOrg, sdk.config, sdk.cryptoSuite)
+	if err != nil {
+		t.Fatalf("Unexpected error creating credential manager: %v", err)
+	}
+	user, err := credentialMgr.GetSigningIdentity(identityValidOptUser)
+	if err != nil {
+		t.Fatalf("Unexpected error loading identity: %v", err)
+	}
+
+	// The in-memory identity is used as is, the credential store isn't involved
+	signingIdentity, err := msp.NewSigningIdentity(user.MspID, user.EnrollmentCert, user.PrivateKey, sdk.SigningManager())
+	if err != nil {
+		t.Fatalf("Expected no error from NewSigningIdentity, but got %v", err)
+	}
+	session, err := sdk.NewClient(WithIdentity(signingIdentity)).Session()
+	if err != nil {
+		t.Fatalf("Expected no error from Session, but got %v", err)
+	}
+
+	expected, err := signingIdentity.Identity()
+	if err != nil {
+		t.Fatalf("Expected no error from Identity, but got %v", err)
+	}
+	serialized, err := session.Identity()
+	if err != nil {
+		t.Fatalf("Expected no error from session Identity, but got %v", err)
+	}
+	if !bytes.Equal(expected, serialized) || session.PrivateKey() != signingIdentity.PrivateKey() {
+		t.Fatal("Expected the session to use the signing identity")
+	}
+}
@@ -88,5 +88,8 @@ func (sdk *FabricSDK) newSessionFromIdentityName(orgID string, id string) (*sess
 //
 // Deprecated: the system client is being replaced with the interfaces supplied by NewClient()
 func (sdk *FabricSDK) NewSystemClient(s context.SessionContext) (api.Resource, error) {
+	if sdk.isClosed() {
+		return nil, errSDKClosed
+	}
 	return sdk.fabricProvider.CreateResourceClient(s)
 }
@@ -0,0 +1,48 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package fabsdk
+
+import (
+	"fmt"
+)
+
+// UnknownOrgError is returned when an identity is requested for an organization
+// that isn't configured
+type UnknownOrgError struct {
+	// Org is the name of the organization
+	Org string
+}
+
+func (e *UnknownOrgError) Error() string {
+	return fmt.Sprintf("organization [%s] is not configured", e.Org)
+}
+
+// UnknownUserError is returned when the credential store of the organization
+// has no credentials for the user
+type UnknownUserError struct {
+	// Org is the name of the organization
+	Org string
+	// User is the name of the user
+	User string
+}
+
+func (e *UnknownUserError) Error() string {
+	return fmt.Sprintf("user [%s] not found in organization [%s]", e.User, e.Org)
+}
+
+// NotEnrolledError is returned when the credentials of the user are incomplete,
+// i.e. the enrollment certificate or the private key is missing
+type NotEnrolledError struct {
+	// Org is the name of the organization
+	Org string
+	// User is the name of the user
+	User string
+}
+
+func (e *NotEnrolledError) Error() string {
+	return fmt.Sprintf("user [%s] of organization [%s] is not enrolled", e.User, e.Org)
+}
@@ -9,6 +9,8 @@ package fabsdk
 
 import (
 	"math/rand"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
@@ -17,11 +19,15 @@ import (
 	"github.com/hyperledger/fabric-sdk-go/pkg/context"
 	contextApi "github.com/hyperledger/fabric-sdk-go/pkg/context/api"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
+	configImpl "github.com/hyperledger/fabric-sdk-go/pkg/core/config"
 	"github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/comm"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/identitymgr"
 	sdkApi "github.com/hyperledger/fabric-sdk-go/pkg/fabsdk/api"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk/provider/chpvdr"
 	"github.com/hyperledger/fabric-sdk-go/pkg/logging"
 	"github.com/pkg/errors"
+	"google.golang.org/grpc"
 )
 
 // FabricSDK provides access (and context) to clients being managed by the SDK.
@@ -30,12 +36,17 @@ type FabricSDK struct {
 
 	config            core.Config
 	stateStore        contextApi.KVStore
+	credentialStore   contextApi.CredentialStore
 	cryptoSuite       core.CryptoSuite
 	discoveryProvider fab.DiscoveryProvider
 	selectionProvider fab.SelectionProvider
 	signingManager    contextApi.SigningManager
+	connector         contextApi.Connector
 	fabricProvider    sdkApi.FabricProvider
 	channelProvider   *chpvdr.ChannelProvider
+
+	closeMutex sync.Mutex
+	closed     bool
 }
 
 type options struct {
@@ -44,6 +55,23 @@ type options struct {
 	Context sdkApi.OrgClientFactory
 	Session sdkApi.SessionClientFactory
 	Logger  api.LoggerProvider
+
+	IdentityManager      []identitymgr.Option
+	CredentialStore      contextApi.CredentialStore
+	Settings             []setting
+	SkipConfigValidation bool
+	CloseTimeout         time.Duration
+	EagerInit            bool
+	SessionCacheSize     int
+	SessionCacheTTL      time.Duration
+	UnaryInterceptors    []grpc.UnaryClientInterceptor
+	StreamInterceptors   []grpc.StreamClientInterceptor
+}
+
+// setting is a configuration setting that overrides the loaded configuration
+type setting struct {
+	key   string
+	value interface{}
 }
 
 // Option configures the SDK.
@@ -69,6 +97,21 @@ func WithConfig(config core.Config) core.ConfigProvider {
 	}
 }
 
+// WithConfigRaw returns a ConfigProvider that loads the configuration from the given bytes
+// rather than from a file, e.g. a connection profile that was received over an API.
+// format can be "json" or "yaml". Environment variable overrides apply as for a config file.
+func WithConfigRaw(data []byte, format string) core.ConfigProvider {
+	return configImpl.FromRaw(data, format)
+}
+
+// WithConfigFiles returns a ConfigProvider that loads the given config files in order and merges them,
+// e.g. so that credentials and network topology may be kept in separate files. Maps are merged recursively;
+// scalars and lists in a later file replace those in an earlier file. The merged configuration may be
+// dumped for troubleshooting (see config.Config.Dump).
+func WithConfigFiles(paths ...string) core.ConfigProvider {
+	return configImpl.FromFiles(paths)
+}
+
 // fromPkgSuite creates an SDK based on the implementations in the provided pkg suite.
 // TODO: For now leaving this method as private until we have more usage.
 func fromPkgSuite(config core.Config, pkgSuite PkgSuite, opts ...Option) (*FabricSDK, error) {
@@ -116,46 +159,180 @@ func fromPkgSuite(config core.Config, pkgSuite PkgSuite, opts ...Option) (*Fabri
 	return &sdk, err
 }
 
-// WithCorePkg injects the core implementation into the SDK.
+// WithCorePkg injects the core implementation into the SDK. The other factories of the default
+// package suite are kept, so only the core factory needs to be implemented in order to customize
+// e.g. the crypto suite. If the option is given more than once then the last one wins.
 func WithCorePkg(core sdkApi.CoreProviderFactory) Option {
 	return func(opts *options) error {
+		if core == nil {
+			return errors.New("core pkg is nil")
+		}
 		opts.Core = core
 		return nil
 	}
 }
 
-// WithServicePkg injects the service implementation into the SDK.
+// WithServicePkg injects the service implementation into the SDK in place of the default
+// service factory (see WithCorePkg).
 func WithServicePkg(service sdkApi.ServiceProviderFactory) Option {
 	return func(opts *options) error {
+		if service == nil {
+			return errors.New("service pkg is nil")
+		}
 		opts.Service = service
 		return nil
 	}
 }
 
-// WithContextPkg injects the context implementation into the SDK.
+// WithContextPkg injects the context implementation into the SDK in place of the default
+// context factory (see WithCorePkg).
 func WithContextPkg(context sdkApi.OrgClientFactory) Option {
 	return func(opts *options) error {
+		if context == nil {
+			return errors.New("context pkg is nil")
+		}
 		opts.Context = context
 		return nil
 	}
 }
 
-// WithSessionPkg injects the session implementation into the SDK.
+// WithSessionPkg injects the session implementation into the SDK in place of the default
+// session factory (see WithCorePkg).
 func WithSessionPkg(session sdkApi.SessionClientFactory) Option {
 	return func(opts *options) error {
+		if session == nil {
+			return errors.New("session pkg is nil")
+		}
 		opts.Session = session
 		return nil
 	}
 }
 
-// WithLoggerPkg injects the logger implementation into the SDK.
+// WithLoggerPkg injects the logger implementation into the SDK in place of the default
+// logger provider (see WithCorePkg).
 func WithLoggerPkg(logger api.LoggerProvider) Option {
 	return func(opts *options) error {
+		if logger == nil {
+			return errors.New("logger pkg is nil")
+		}
 		opts.Logger = logger
 		return nil
 	}
 }
 
+// WithIdentityManagerOptions provides options for the identity managers that are created by the SDK,
+// for example a custom HTTP transport or request/response hooks for the CA client.
+func WithIdentityManagerOptions(opts ...identitymgr.Option) Option {
+	return func(o *options) error {
+		o.IdentityManager = append(o.IdentityManager, opts...)
+		return nil
+	}
+}
+
+// WithCredentialStore sets the store of the enrolled users, e.g. an identity.SQLCredentialStore that is
+// shared by several instances of a service. It replaces the store selected by client.credentialStore.type
+// in the configuration; the store isn't closed when the SDK is closed.
+func WithCredentialStore(store contextApi.CredentialStore) Option {
+	return func(o *options) error {
+		if store == nil {
+			return errors.New("credential store is nil")
+		}
+		o.CredentialStore = store
+		return nil
+	}
+}
+
+// WithSetting overrides the setting with the given key (e.g. "peers.peer0.org1.example.com.url" or
+// "client.peer.timeout.connection") in the configuration that's loaded by the config provider. Settings are
+// applied in order before any of the SDK's providers are created, so they're visible to all of the factories
+// and clients. Environment variable overrides still take precedence. Note that the settings are applied to
+// the loaded configuration, i.e. to the given configuration itself in the case of WithConfig.
+func WithSetting(key string, value interface{}) Option {
+	return func(opts *options) error {
+		opts.Settings = append(opts.Settings, setting{key: key, value: value})
+		return nil
+	}
+}
+
+// WithConfigValidation enables or disables the validation of the configuration when the SDK is created
+// (enabled by default). The SDK isn't created if the configuration references undefined peers or orderers,
+// files that don't exist or invalid URLs (see config.Config.Validate). Configurations that don't support
+// validation aren't validated.
+func WithConfigValidation(value bool) Option {
+	return func(opts *options) error {
+		opts.SkipConfigValidation = !value
+		return nil
+	}
+}
+
+// WithCloseTimeout sets the maximum time that Close waits for the providers (and the event hubs) to be closed
+// (10 seconds by default).
+func WithCloseTimeout(timeout time.Duration) Option {
+	return func(opts *options) error {
+		opts.CloseTimeout = timeout
+		return nil
+	}
+}
+
+// WithEagerInit creates the providers that would otherwise be created on first use (e.g. the crypto suite,
+// state store and signing manager of the default core factory) when the SDK is created, so that New fails
+// if one of them can't be initialized.
+func WithEagerInit() Option {
+	return func(opts *options) error {
+		opts.EagerInit = true
+		return nil
+	}
+}
+
+// WithGRPCInterceptors adds client interceptors to the gRPC calls and streams of the SDK's connections to the
+// peers and the orderers (the endorsements, the broadcasts and the event streams), e.g. to add metadata or tracing
+// spans to the calls. The interceptors are invoked in the order in which they're given; the target of the call is
+// available to the interceptors from the context (see comm.TargetFromContext). The calls may be logged with the
+// comm.LoggingUnaryInterceptor and comm.LoggingStreamInterceptor interceptors.
+func WithGRPCInterceptors(unary []grpc.UnaryClientInterceptor, stream []grpc.StreamClientInterceptor) Option {
+	return func(opts *options) error {
+		opts.UnaryInterceptors = append(opts.UnaryInterceptors, unary...)
+		opts.StreamInterceptors = append(opts.StreamInterceptors, stream...)
+		return nil
+	}
+}
+
+// configValidator is implemented by configurations that support validation
+type configValidator interface {
+	Validate() []error
+}
+
+// configSetter is implemented by configurations that support overriding settings
+type configSetter interface {
+	Set(key string, value interface{}) error
+}
+
+// identityManagerOptionsSetter is implemented by factories and providers that create identity managers
+type identityManagerOptionsSetter interface {
+	SetIdentityManagerOptions(opts ...identitymgr.Option)
+}
+
+// credentialStoreFactory is implemented by core factories that create the store of the enrolled users
+type credentialStoreFactory interface {
+	CreateCredentialStore(config core.Config) (contextApi.CredentialStore, error)
+}
+
+// connectorFactory is implemented by core factories that create the connector that shares the connections
+// between the clients (see comm.CachingConnector)
+type connectorFactory interface {
+	CreateConnector(config core.Config) (contextApi.Connector, error)
+}
+
+// connectorSetter is implemented by providers that create peers (e.g. from the configuration)
+type connectorSetter interface {
+	SetConnector(connector contextApi.Connector)
+}
+
+// lazyInitializer is implemented by providers that are initialized on first use (see WithEagerInit)
+type lazyInitializer interface {
+	Init() error
+}
+
 // providerInit interface allows for initializing providers
 // TODO: minimize interface
 type providerInit interface {
@@ -176,12 +353,41 @@ func initSDK(sdk *FabricSDK, opts []Option) error {
 	}
 	logging.InitLogger(sdk.opts.Logger)
 
+	if err := applySettings(sdk.config, sdk.opts.Settings); err != nil {
+		return err
+	}
+
+	if !sdk.opts.SkipConfigValidation {
+		if err := validateConfig(sdk.config); err != nil {
+			return err
+		}
+	}
+
+	if err := sdk.initCredentialStore(); err != nil {
+		return errors.WithMessage(err, "failed to initialize credential store")
+	}
+
+	if len(sdk.opts.IdentityManager) > 0 {
+		if setter, ok := sdk.opts.Context.(identityManagerOptionsSetter); ok {
+			setter.SetIdentityManagerOptions(sdk.opts.IdentityManager...)
+		}
+	}
+
+	if sdk.opts.SessionCacheSize > 0 {
+		if setter, ok := sdk.opts.Session.(sessionCacheSetter); ok {
+			setter.SetSessionCache(sdk.opts.SessionCacheSize, sdk.opts.SessionCacheTTL)
+		}
+	}
+
 	// Initialize crypto provider
 	cs, err := sdk.opts.Core.CreateCryptoSuiteProvider(sdk.config)
 	if err != nil {
 		return errors.WithMessage(err, "failed to initialize crypto suite")
 	}
 
+	if err := sdk.eagerInit(cs); err != nil {
+		return errors.WithMessage(err, "failed to initialize crypto suite")
+	}
 	sdk.cryptoSuite = cs
 
 	// Initialize rand (TODO: should probably be optional)
@@ -195,6 +401,9 @@ func initSDK(sdk *FabricSDK, opts []Option) error {
 	if err != nil {
 		return errors.WithMessage(err, "failed to initialize state store")
 	}
+	if err := sdk.eagerInit(store); err != nil {
+		return errors.WithMessage(err, "failed to initialize state store")
+	}
 	sdk.stateStore = store
 
 	// Initialize Signing Manager
@@ -202,13 +411,26 @@ func initSDK(sdk *FabricSDK, opts []Option) error {
 	if err != nil {
 		return errors.WithMessage(err, "failed to initialize signing manager")
 	}
+	if err := sdk.eagerInit(signingMgr); err != nil {
+		return errors.WithMessage(err, "failed to initialize signing manager")
+	}
 	sdk.signingManager = signingMgr
 
+	// Initialize the connector that's shared by the clients
+	if err := sdk.initConnector(); err != nil {
+		return errors.WithMessage(err, "failed to initialize connector")
+	}
+
 	// Initialize Fabric Provider
 	fabricProvider, err := sdk.opts.Core.CreateFabricProvider(sdk.fabContext())
 	if err != nil {
 		return errors.WithMessage(err, "failed to initialize core fabric provider")
 	}
+	if len(sdk.opts.IdentityManager) > 0 {
+		if setter, ok := fabricProvider.(identityManagerOptionsSetter); ok {
+			setter.SetIdentityManagerOptions(sdk.opts.IdentityManager...)
+		}
+	}
 	sdk.fabricProvider = fabricProvider
 
 	// Initialize discovery provider
@@ -219,6 +441,9 @@ func initSDK(sdk *FabricSDK, opts []Option) error {
 	if pi, ok := discoveryProvider.(providerInit); ok {
 		pi.Initialize(sdk)
 	}
+	if setter, ok := discoveryProvider.(connectorSetter); ok {
+		setter.SetConnector(sdk.connector)
+	}
 	sdk.discoveryProvider = discoveryProvider
 
 	// Initialize selection provider (for selecting endorsing peers)
@@ -229,6 +454,9 @@ func initSDK(sdk *FabricSDK, opts []Option) error {
 	if pi, ok := selectionProvider.(providerInit); ok {
 		pi.Initialize(sdk)
 	}
+	if setter, ok := selectionProvider.(connectorSetter); ok {
+		setter.SetConnector(sdk.connector)
+	}
 	sdk.selectionProvider = selectionProvider
 
 	channelProvider, err := chpvdr.New(fabricProvider)
@@ -237,6 +465,129 @@ func initSDK(sdk *FabricSDK, opts []Option) error {
 	}
 	sdk.channelProvider = channelProvider
 
+	// Providers that cache items from the configuration refresh them when the configuration is reloaded
+	if notifier, ok := sdk.config.(configNotifier); ok {
+		notifier.AddListener(sdk.handleConfigUpdate)
+	}
+
+	return nil
+}
+
+// initCredentialStore selects the store of the enrolled users (the store of the options or the store created
+// by the core factory) and passes it to the identity managers
+func (sdk *FabricSDK) initCredentialStore() error {
+	store := sdk.opts.CredentialStore
+	if store == nil {
+		factory, ok := sdk.opts.Core.(credentialStoreFactory)
+		if !ok {
+			return nil
+		}
+		created, err := factory.CreateCredentialStore(sdk.config)
+		if err != nil {
+			return err
+		}
+		if created == nil {
+			return nil
+		}
+		// the store that's created by the factory is closed with the SDK
+		store = created
+		sdk.credentialStore = created
+	}
+	sdk.opts.IdentityManager = append([]identitymgr.Option{identitymgr.WithCredentialStore(store)}, sdk.opts.IdentityManager...)
+	return nil
+}
+
+// initConnector creates the connector of the core factory. The clients dial a new connection for each
+// request if the factory doesn't create a connector. The interceptors of the options are applied to the
+// connections of the connector.
+func (sdk *FabricSDK) initConnector() error {
+	var connector contextApi.Connector = &comm.DirectConnector{}
+	if factory, ok := sdk.opts.Core.(connectorFactory); ok {
+		var err error
+		connector, err = factory.CreateConnector(sdk.config)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(sdk.opts.UnaryInterceptors) > 0 || len(sdk.opts.StreamInterceptors) > 0 {
+		connector = comm.NewInterceptingConnector(connector, sdk.opts.UnaryInterceptors, sdk.opts.StreamInterceptors)
+	}
+	sdk.connector = connector
+	return nil
+}
+
+// eagerInit initializes a provider that's initialized on first use if the SDK was created with WithEagerInit
+func (sdk *FabricSDK) eagerInit(provider interface{}) error {
+	if !sdk.opts.EagerInit {
+		return nil
+	}
+	if li, ok := provider.(lazyInitializer); ok {
+		return li.Init()
+	}
+	return nil
+}
+
+// validateConfig returns an error that lists the problems found in the configuration (if it supports validation)
+func validateConfig(config core.Config) error {
+	validator, ok := config.(configValidator)
+	if !ok {
+		return nil
+	}
+	errs := validator.Validate()
+	if len(errs) == 0 {
+		return nil
+	}
+
+	problems := make([]string, len(errs))
+	for i, err := range errs {
+		problems[i] = err.Error()
+	}
+	return errors.Errorf("invalid configuration (see fabsdk.WithConfigValidation): %s", strings.Join(problems, "; "))
+}
+
+// configNotifier is implemented by configurations that notify listeners when they're reloaded
+type configNotifier interface {
+	AddListener(listener core.ConfigListener)
+}
+
+// configUpdateHandler is implemented by providers that refresh cached items when the configuration is reloaded
+type configUpdateHandler interface {
+	HandleConfigUpdate(event *core.ConfigUpdatedEvent)
+}
+
+func (sdk *FabricSDK) handleConfigUpdate(event *core.ConfigUpdatedEvent) {
+	if sdk.isClosed() {
+		return
+	}
+	providers := []interface{}{sdk.cryptoSuite, sdk.stateStore, sdk.signingManager, sdk.connector, sdk.fabricProvider,
+		sdk.discoveryProvider, sdk.selectionProvider, sdk.channelProvider}
+	for _, provider := range providers {
+		if handler, ok := provider.(configUpdateHandler); ok {
+			handler.HandleConfigUpdate(event)
+		}
+	}
+
+	// The identities of the users are loaded again, e.g. from the reloaded credential store path
+	if cache := sdk.sessionCache(); cache != nil {
+		cache.Clear()
+	}
+}
+
+func applySettings(config core.Config, settings []setting) error {
+	if len(settings) == 0 {
+		return nil
+	}
+
+	setter, ok := config.(configSetter)
+	if !ok {
+		return errors.New("configuration does not support overriding settings")
+	}
+	for _, s := range settings {
+		if err := setter.Set(s.key, s.value); err != nil {
+			return errors.WithMessage(err, "failed to apply configuration setting")
+		}
+	}
 	return nil
 }
 
@@ -245,6 +596,12 @@ func (sdk *FabricSDK) Config() core.Config {
 	return sdk.config
 }
 
+// SigningManager returns the SDK's signing manager, which signs with the signer of the core provider
+// factory, e.g. to create a signing identity in memory (see msp.NewSigningIdentity).
+func (sdk *FabricSDK) SigningManager() contextApi.SigningManager {
+	return sdk.signingManager
+}
+
 func (sdk *FabricSDK) fabContext() *fabContext {
 	c := fabContext{
 		sdk: sdk,
@@ -260,21 +617,64 @@ func (sdk *FabricSDK) context() *sdkContext {
 }
 
 func (sdk *FabricSDK) newUser(orgID string, userName string) (context.IdentityContext, error) {
+	if sdk.isClosed() {
+		return nil, errSDKClosed
+	}
+
+	cache := sdk.sessionCache()
+	if cache != nil {
+		if cached, ok := cache.Get(orgID, userName); ok {
+			if u := cached.(*cachedUser); !u.reenrolled(userName) {
+				return u.identity, nil
+			}
+			cache.Invalidate(orgID, userName)
+		}
+	}
 
 	credentialMgr, err := sdk.opts.Context.CreateCredentialManager(orgID, sdk.config, sdk.cryptoSuite)
 	if err != nil {
+		if sdk.isUnknownOrg(orgID) {
+			return nil, &UnknownOrgError{Org: orgID}
+		}
 		return nil, errors.WithMessage(err, "failed to get credential manager")
 	}
 
+	var cached *cachedUser
+	if cache != nil {
+		cached = newCachedUser(credentialMgr, userName)
+	}
+
 	signingIdentity, err := credentialMgr.GetSigningIdentity(userName)
 	if err != nil {
+		if errors.Cause(err) == contextApi.ErrUserNotFound {
+			return nil, &UnknownUserError{Org: orgID, User: userName}
+		}
 		return nil, errors.WithMessage(err, "failed to get signing identity")
 	}
+	if len(signingIdentity.EnrollmentCert) == 0 || signingIdentity.PrivateKey == nil {
+		return nil, &NotEnrolledError{Org: orgID, User: userName}
+	}
 
 	user, err := sdk.fabricProvider.CreateUser(userName, signingIdentity)
 	if err != nil {
 		return nil, errors.WithMessage(err, "NewPreEnrolledUser returned error")
 	}
 
+	if cached != nil {
+		cached.identity = user
+		cache.Put(orgID, userName, cached)
+	}
+
 	return user, nil
 }
+
+// isUnknownOrg returns true if the network configuration doesn't contain the given organization
+func (sdk *FabricSDK) isUnknownOrg(orgID string) bool {
+	networkConfig, err := sdk.config.NetworkConfig()
+	if err != nil || networkConfig == nil {
+		return false
+	}
+	// viper lowercases all key maps
+	_, ok := networkConfig.Organizations[strings.ToLower(orgID)]
+	return !ok
+}
@@ -45,6 +45,11 @@ func verifySDK(t *testing.T, sdk *FabricSDK) {
 	if err != nil {
 		t.Fatalf("Failed to create new channel client: %s", err)
 	}
+
+	_, err = c.Ledger("orgchannel")
+	if err != nil {
+		t.Fatalf("Failed to create new ledger client: %s", err)
+	}
 }
 
 func TestWithConfigOpt(t *testing.T) {
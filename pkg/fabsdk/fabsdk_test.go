@@ -7,13 +7,36 @@ SPDX-License-Identifier: Apache-2.0
 package fabsdk
 
 import (
+	"io/ioutil"
+	"net/http"
 	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/hyperledger/fabric-sdk-go/pkg/context"
+	contextApi "github.com/hyperledger/fabric-sdk-go/pkg/context/api"
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
 	configImpl "github.com/hyperledger/fabric-sdk-go/pkg/core/config"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite/bccsp/sw"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/comm"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/identitymgr"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/txn"
+	sdkApi "github.com/hyperledger/fabric-sdk-go/pkg/fabsdk/api"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk/factory/defclient"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk/factory/defcore"
 	mockapisdk "github.com/hyperledger/fabric-sdk-go/pkg/fabsdk/mocks"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk/provider/fabpvdr"
+	"github.com/hyperledger/fabric-sdk-go/pkg/logging/modlog"
 	"github.com/pkg/errors"
+	"google.golang.org/grpc"
 )
 
 const (
@@ -103,6 +126,48 @@ func TestWithServicePkg(t *testing.T) {
 	}
 }
 
+func TestWithServicePkgKeepsDefaults(t *testing.T) {
+	c, err := configImpl.FromFile(sdkConfigFile)()
+	if err != nil {
+		t.Fatalf("Unexpected error from config: %v", err)
+	}
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	// The first service factory is never used since the last option wins
+	unused := mockapisdk.NewMockServiceProviderFactory(mockCtrl)
+	factory := mockapisdk.NewMockServiceProviderFactory(mockCtrl)
+	factory.EXPECT().CreateDiscoveryProvider(c).Return(nil, nil)
+	factory.EXPECT().CreateSelectionProvider(c).Return(nil, nil)
+
+	sdk, err := New(WithConfig(c), WithServicePkg(unused), WithServicePkg(factory))
+	if err != nil {
+		t.Fatalf("Error initializing SDK: %s", err)
+	}
+
+	if sdk.opts.Service != factory {
+		t.Fatal("Expected the service factory to be substituted")
+	}
+	if _, ok := sdk.opts.Core.(*defcore.ProviderFactory); !ok {
+		t.Fatalf("Expected the default core factory, got %T", sdk.opts.Core)
+	}
+	if _, ok := sdk.opts.Context.(*defclient.OrgClientFactory); !ok {
+		t.Fatalf("Expected the default context factory, got %T", sdk.opts.Context)
+	}
+	if _, ok := sdk.opts.Session.(*defclient.SessionClientFactory); !ok {
+		t.Fatalf("Expected the default session factory, got %T", sdk.opts.Session)
+	}
+	if _, ok := sdk.opts.Logger.(*modlog.Provider); !ok {
+		t.Fatalf("Expected the default logger provider, got %T", sdk.opts.Logger)
+	}
+
+	_, err = New(WithConfig(c), WithServicePkg(nil))
+	if err == nil {
+		t.Fatal("Expected error for nil service pkg")
+	}
+}
+
 func TestWithContextPkg(t *testing.T) {
 	// Test New SDK with valid config file
 	c, err := configImpl.FromFile(sdkConfigFile)()
@@ -295,3 +360,470 @@ func TestWithConfigFailure(t *testing.T) {
 		t.Fatal("Expected failure due to invalid config")
 	}
 }
+
+func TestWithIdentityManagerOptions(t *testing.T) {
+	c, err := configImpl.FromFile(sdkConfigFile)()
+	if err != nil {
+		t.Fatalf("Unexpected error from config: %v", err)
+	}
+
+	var hookInvoked bool
+	opt := identitymgr.WithRequestHook(func(req *http.Request) error {
+		hookInvoked = true
+		return nil
+	})
+
+	core := &identityMgrOptsCoreFactory{ProviderFactory: defcore.NewProviderFactory()}
+	context := &identityMgrOptsOrgClientFactory{OrgClientFactory: defclient.NewOrgClientFactory()}
+
+	_, err = New(WithConfig(c), WithCorePkg(core), WithContextPkg(context), WithIdentityManagerOptions(opt))
+	if err != nil {
+		t.Fatalf("Error initializing SDK: %s", err)
+	}
+
+	// The credential store option of the configured store precedes the provided options
+	if len(context.opts) != 2 {
+		t.Fatalf("Expecting identity manager options to be passed to the context factory")
+	}
+	if core.provider == nil || len(core.provider.opts) != 2 {
+		t.Fatalf("Expecting identity manager options to be passed to the fabric provider")
+	}
+
+	// Make sure that the options that were passed along are the ones that were provided
+	if err := context.opts[1](&identitymgr.IdentityManager{}); err != nil {
+		t.Fatalf("Unexpected error applying option: %s", err)
+	}
+	if hookInvoked {
+		t.Fatalf("Expecting request hook not to be invoked when the option is applied")
+	}
+}
+
+func TestWithCredentialStore(t *testing.T) {
+	if _, err := New(configImpl.FromFile(sdkConfigFile), WithCredentialStore(nil)); err == nil {
+		t.Fatal("Expected error from New with nil credential store")
+	}
+
+	store := &recordingCredentialStore{}
+	sdk, err := New(configImpl.FromFile(sdkConfigFile), WithCredentialStore(store))
+	if err != nil {
+		t.Fatalf("Error initializing SDK: %s", err)
+	}
+	if sdk.credentialStore != nil {
+		t.Fatal("Expecting the provided credential store not to be closed with the SDK")
+	}
+
+	// The user is looked up in the provided credential store before the MSP directory
+	if _, err := sdk.NewClient(WithUser(sdkValidClientUser), WithOrg(sdkValidClientOrg1)).Session(); err != nil {
+		t.Fatalf("Unexpected error creating session: %s", err)
+	}
+	if len(store.loaded) == 0 || store.loaded[0].Name != sdkValidClientUser {
+		t.Fatalf("Expecting the user to be loaded from the credential store, got %v", store.loaded)
+	}
+}
+
+// recordingCredentialStore records the users that are loaded, it doesn't store any user
+type recordingCredentialStore struct {
+	loaded []contextApi.UserKey
+}
+
+func (s *recordingCredentialStore) Store(user contextApi.UserData) error {
+	return errors.New("not supported")
+}
+
+func (s *recordingCredentialStore) Load(key contextApi.UserKey) (contextApi.UserData, error) {
+	s.loaded = append(s.loaded, key)
+	return contextApi.UserData{}, contextApi.ErrUserNotFound
+}
+
+func (s *recordingCredentialStore) Delete(key contextApi.UserKey) error {
+	return nil
+}
+
+func (s *recordingCredentialStore) List() ([]contextApi.UserKey, error) {
+	return nil, nil
+}
+
+type identityMgrOptsOrgClientFactory struct {
+	*defclient.OrgClientFactory
+	opts []identitymgr.Option
+}
+
+func (f *identityMgrOptsOrgClientFactory) SetIdentityManagerOptions(opts ...identitymgr.Option) {
+	f.opts = opts
+	f.OrgClientFactory.SetIdentityManagerOptions(opts...)
+}
+
+type identityMgrOptsFabricProvider struct {
+	*fabpvdr.FabricProvider
+	opts []identitymgr.Option
+}
+
+func (f *identityMgrOptsFabricProvider) SetIdentityManagerOptions(opts ...identitymgr.Option) {
+	f.opts = opts
+	f.FabricProvider.SetIdentityManagerOptions(opts...)
+}
+
+type identityMgrOptsCoreFactory struct {
+	*defcore.ProviderFactory
+	provider *identityMgrOptsFabricProvider
+}
+
+func (f *identityMgrOptsCoreFactory) CreateFabricProvider(context context.ProviderContext) (sdkApi.FabricProvider, error) {
+	f.provider = &identityMgrOptsFabricProvider{FabricProvider: fabpvdr.New(context)}
+	return f.provider, nil
+}
+
+func TestWithConfigRaw(t *testing.T) {
+	configBytes, err := ioutil.ReadFile(sdkConfigFile)
+	if err != nil {
+		t.Fatalf("Failed to read config file: %s", err)
+	}
+
+	os.Setenv("FABRIC_SDK_CLIENT_BCCSP_SECURITY_LEVEL", "384")
+	defer os.Unsetenv("FABRIC_SDK_CLIENT_BCCSP_SECURITY_LEVEL")
+
+	fileConfig, err := configImpl.FromFile(sdkConfigFile)()
+	if err != nil {
+		t.Fatalf("Unexpected error from config file: %s", err)
+	}
+	rawConfig, err := WithConfigRaw(configBytes, "yaml")()
+	if err != nil {
+		t.Fatalf("Unexpected error from raw config: %s", err)
+	}
+
+	filePeers, err := fileConfig.NetworkPeers()
+	if err != nil {
+		t.Fatalf("Error getting network peers: %s", err)
+	}
+	rawPeers, err := rawConfig.NetworkPeers()
+	if err != nil {
+		t.Fatalf("Error getting network peers: %s", err)
+	}
+	if len(rawPeers) == 0 || !reflect.DeepEqual(peersByURL(filePeers), peersByURL(rawPeers)) {
+		t.Fatalf("Expected the same network peers from raw config as from config file")
+	}
+
+	fileOrderers, err := fileConfig.OrderersConfig()
+	if err != nil {
+		t.Fatalf("Error getting orderers: %s", err)
+	}
+	rawOrderers, err := rawConfig.OrderersConfig()
+	if err != nil {
+		t.Fatalf("Error getting orderers: %s", err)
+	}
+	if len(rawOrderers) == 0 || !reflect.DeepEqual(orderersByURL(fileOrderers), orderersByURL(rawOrderers)) {
+		t.Fatalf("Expected the same orderers from raw config as from config file")
+	}
+
+	fileNetwork, err := fileConfig.NetworkConfig()
+	if err != nil {
+		t.Fatalf("Error getting network config: %s", err)
+	}
+	rawNetwork, err := rawConfig.NetworkConfig()
+	if err != nil {
+		t.Fatalf("Error getting network config: %s", err)
+	}
+	if len(rawNetwork.Channels) == 0 || !reflect.DeepEqual(fileNetwork.Channels, rawNetwork.Channels) {
+		t.Fatalf("Expected the same channels from raw config as from config file")
+	}
+
+	// Environment variable overrides and path substitution apply to the raw config
+	if level := rawConfig.SecurityLevel(); level != 384 {
+		t.Fatalf("Expected security level from environment variable but got %d", level)
+	}
+	if rawConfig.CryptoConfigPath() != fileConfig.CryptoConfigPath() {
+		t.Fatalf("Expected crypto config path [%s] but got [%s]", fileConfig.CryptoConfigPath(), rawConfig.CryptoConfigPath())
+	}
+
+	if _, err := New(WithConfigRaw(configBytes, "yaml")); err != nil {
+		t.Fatalf("Error initializing SDK from raw config: %s", err)
+	}
+
+	if _, err := New(WithConfigRaw([]byte("client: [\n"), "yaml")); err == nil {
+		t.Fatalf("Expected error initializing SDK from invalid raw config")
+	}
+}
+
+// peersByURL returns the given peers keyed by URL since the order of the peers isn't defined
+func peersByURL(peers []core.NetworkPeer) map[string]core.NetworkPeer {
+	m := make(map[string]core.NetworkPeer)
+	for _, p := range peers {
+		m[p.URL] = p
+	}
+	return m
+}
+
+// orderersByURL returns the given orderers keyed by URL since the order of the orderers isn't defined
+func orderersByURL(orderers []core.OrdererConfig) map[string]core.OrdererConfig {
+	m := make(map[string]core.OrdererConfig)
+	for _, o := range orderers {
+		m[o.URL] = o
+	}
+	return m
+}
+
+func TestWithSetting(t *testing.T) {
+	const peerURL = "grpcs://localhost:17051"
+
+	sdk, err := New(configImpl.FromFile(sdkConfigFile),
+		WithSetting("peers.peer0.org1.example.com.url", peerURL),
+		WithSetting("client.peer.timeout.connection", 10*time.Second),
+	)
+	if err != nil {
+		t.Fatalf("Error initializing SDK: %s", err)
+	}
+
+	peer, err := sdk.Config().PeerConfig("org1", "peer0.org1.example.com")
+	if err != nil {
+		t.Fatalf("Error getting peer config: %s", err)
+	}
+	if peer.URL != peerURL {
+		t.Fatalf("Expected overridden peer URL but got %s", peer.URL)
+	}
+	if timeout := sdk.Config().TimeoutOrDefault(core.Endorser); timeout != 10*time.Second {
+		t.Fatalf("Expected overridden timeout but got %s", timeout)
+	}
+
+	// The peers that are discovered by the discovery provider use the overridden URL
+	discovery, err := sdk.discoveryProvider.NewDiscoveryService("mychannel")
+	if err != nil {
+		t.Fatalf("Error creating discovery service: %s", err)
+	}
+	peers, err := discovery.GetPeers()
+	if err != nil {
+		t.Fatalf("Error getting peers: %s", err)
+	}
+	var found bool
+	for _, p := range peers {
+		found = found || p.URL() == peerURL
+	}
+	if !found {
+		t.Fatalf("Expected a discovered peer with the overridden URL")
+	}
+
+	if _, err := New(configImpl.FromFile(sdkConfigFile), WithSetting("peers", "invalid")); err == nil {
+		t.Fatalf("Expected error for an invalid setting")
+	}
+	if _, err := New(WithConfig(mocks.NewMockConfig()), WithSetting("client.peer.timeout.connection", "10s")); err == nil {
+		t.Fatalf("Expected error for a configuration that doesn't support settings")
+	}
+}
+
+func TestConfigValidation(t *testing.T) {
+	const tlsCertSetting = "peers.peer0.org1.example.com.tlsCACerts.path"
+
+	_, err := New(configImpl.FromFile(sdkConfigFile), WithSetting(tlsCertSetting, "/does/not/exist/tls-cert.pem"))
+	if err == nil || !strings.Contains(err.Error(), tlsCertSetting+": file [/does/not/exist/tls-cert.pem] is not readable") {
+		t.Fatalf("Expected validation error for a TLS certificate that doesn't exist but got: %v", err)
+	}
+
+	_, err = New(configImpl.FromFile(sdkConfigFile), WithSetting(tlsCertSetting, "/does/not/exist/tls-cert.pem"), WithConfigValidation(false))
+	if err != nil {
+		t.Fatalf("Expected no error with the validation disabled but got: %s", err)
+	}
+
+	// Configurations that don't support validation aren't validated
+	if _, err := New(WithConfig(mocks.NewMockConfig())); err != nil {
+		t.Fatalf("Error initializing SDK: %s", err)
+	}
+}
+
+func TestWithConfigFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatalf("Failed to create config dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	overrideFile := filepath.Join(dir, "override.yaml")
+
+	overrideConfig := `
+client:
+  peer:
+    timeout:
+      connection: 10s
+peers:
+  peer0.org1.example.com:
+    url: grpcs://localhost:17051
+`
+	if err := ioutil.WriteFile(overrideFile, []byte(overrideConfig), 0600); err != nil {
+		t.Fatalf("Failed to write config file: %s", err)
+	}
+
+	sdk, err := New(WithConfigFiles(sdkConfigFile, overrideFile))
+	if err != nil {
+		t.Fatalf("Error initializing SDK: %s", err)
+	}
+
+	peer, err := sdk.Config().PeerConfig("org1", "peer0.org1.example.com")
+	if err != nil {
+		t.Fatalf("Error getting peer config: %s", err)
+	}
+	if peer.URL != "grpcs://localhost:17051" || peer.EventURL != "peer0.org1.example.com:7053" {
+		t.Fatalf("Expected peer config to be merged from both files but got %+v", peer)
+	}
+	if timeout := sdk.Config().TimeoutOrDefault(core.Endorser); timeout != 10*time.Second {
+		t.Fatalf("Expected overridden timeout but got %s", timeout)
+	}
+
+	if _, err := New(WithConfigFiles(sdkConfigFile, "notarealfile.yaml")); err == nil {
+		t.Fatalf("Expected error for a missing config file")
+	}
+}
+
+const sdkPKCS11ConfigFile = "../../test/fixtures/config/config_pkcs11_test.yaml"
+
+func TestWithEagerInit(t *testing.T) {
+	// The PKCS11 crypto suite isn't registered unless its package is imported, so the PKCS11
+	// crypto suite fails when it's first used rather than when the SDK is created
+	sdk, err := New(configImpl.FromFile(sdkPKCS11ConfigFile))
+	if err != nil {
+		t.Fatalf("Error initializing SDK: %s", err)
+	}
+	if _, err := sdk.cryptoSuite.Hash([]byte("msg"), nil); err == nil || !strings.Contains(err.Error(), "failed to initialize crypto suite") {
+		t.Fatalf("Expected error initializing crypto suite on first use, got %v", err)
+	}
+
+	_, err = New(configImpl.FromFile(sdkPKCS11ConfigFile), WithEagerInit())
+	if err == nil || !strings.Contains(err.Error(), "failed to initialize crypto suite") {
+		t.Fatalf("Expected error initializing crypto suite, got %v", err)
+	}
+
+	if _, err := New(configImpl.FromFile(sdkConfigFile), WithEagerInit()); err != nil {
+		t.Fatalf("Error initializing SDK: %s", err)
+	}
+}
+
+// countingCryptoSuite is a crypto suite provider registered by a third party
+type countingCryptoSuite struct {
+	core.CryptoSuite
+	signed int32
+}
+
+func (cs *countingCryptoSuite) Sign(k core.Key, digest []byte, opts core.SignerOpts) ([]byte, error) {
+	atomic.AddInt32(&cs.signed, 1)
+	return cs.CryptoSuite.Sign(k, digest, opts)
+}
+
+// swConfig lets the registered provider delegate to the SW crypto suite
+type swConfig struct {
+	core.Config
+}
+
+func (c *swConfig) SecurityProvider() string {
+	return "SW"
+}
+
+var countingSuite *countingCryptoSuite
+
+func init() {
+	cryptosuite.Register("COUNTING", func(config core.Config) (core.CryptoSuite, error) {
+		suite, err := sw.GetSuiteByConfig(&swConfig{config})
+		if err != nil {
+			return nil, err
+		}
+		countingSuite = &countingCryptoSuite{CryptoSuite: suite}
+		return countingSuite, nil
+	})
+}
+
+func TestRegisteredCryptoSuite(t *testing.T) {
+	sdk, err := New(configImpl.FromFile(sdkConfigFile),
+		WithSetting("client.BCCSP.security.default.provider", "COUNTING"),
+		WithEagerInit())
+	if err != nil {
+		t.Fatalf("Error initializing SDK: %s", err)
+	}
+	defer sdk.Close()
+
+	session, err := sdk.NewClient(WithUser(sdkValidClientUser), WithOrg(sdkValidClientOrg1)).Session()
+	if err != nil {
+		t.Fatalf("Error creating session: %s", err)
+	}
+
+	// A proposal is signed by the signing manager with the registered crypto suite
+	ctx := struct {
+		context.ProviderContext
+		context.IdentityContext
+	}{&fabContext{sdk: sdk}, session}
+	txh, err := txn.NewHeader(ctx, "mychannel")
+	if err != nil {
+		t.Fatalf("Error creating transaction header: %s", err)
+	}
+	proposal, err := txn.CreateChaincodeInvokeProposal(txh, fab.ChaincodeInvokeRequest{ChaincodeID: "example_cc", Fcn: "invoke"})
+	if err != nil {
+		t.Fatalf("Error creating proposal: %s", err)
+	}
+	proposalBytes, err := txn.ProposalBytes(proposal)
+	if err != nil {
+		t.Fatalf("Error marshalling proposal: %s", err)
+	}
+	signature, err := ctx.SigningManager().Sign(proposalBytes, session.PrivateKey())
+	if err != nil {
+		t.Fatalf("Error signing proposal: %s", err)
+	}
+	if _, err := txn.NewSignedProposal(proposalBytes, signature, txh.Creator()); err != nil {
+		t.Fatalf("Error creating signed proposal: %s", err)
+	}
+
+	if countingSuite == nil || atomic.LoadInt32(&countingSuite.signed) != 1 {
+		t.Fatalf("Expected the proposal to be signed with the registered crypto suite")
+	}
+
+	_, err = New(configImpl.FromFile(sdkConfigFile),
+		WithSetting("client.BCCSP.security.default.provider", "UNREGISTERED"),
+		WithEagerInit())
+	if err == nil || !strings.Contains(err.Error(), "COUNTING") {
+		t.Fatalf("Expected error listing the registered providers, got %v", err)
+	}
+}
+
+func TestWithGRPCInterceptors(t *testing.T) {
+	sdk, err := New(configImpl.FromFile(sdkConfigFile))
+	if err != nil {
+		t.Fatalf("Error initializing SDK: %s", err)
+	}
+	defer sdk.Close()
+	if _, ok := sdk.connector.(*comm.CachingConnector); !ok {
+		t.Fatalf("Expected the caching connector of the default core factory, got %T", sdk.connector)
+	}
+
+	sdk, err = New(configImpl.FromFile(sdkConfigFile),
+		WithGRPCInterceptors([]grpc.UnaryClientInterceptor{comm.LoggingUnaryInterceptor}, []grpc.StreamClientInterceptor{comm.LoggingStreamInterceptor}))
+	if err != nil {
+		t.Fatalf("Error initializing SDK: %s", err)
+	}
+	defer sdk.Close()
+	connector, ok := sdk.connector.(*comm.InterceptingConnector)
+	if !ok {
+		t.Fatalf("Expected an intercepting connector, got %T", sdk.connector)
+	}
+	if _, ok := connector.Connector.(*comm.CachingConnector); !ok {
+		t.Fatalf("Expected the interceptors to be applied to the caching connector, got %T", connector.Connector)
+	}
+}
+
+func BenchmarkNew(b *testing.B) {
+	benchmarkNew(b, sdkConfigFile)
+}
+
+func BenchmarkNewEagerInit(b *testing.B) {
+	benchmarkNew(b, sdkConfigFile, WithEagerInit())
+}
+
+func BenchmarkNewPKCS11(b *testing.B) {
+	benchmarkNew(b, sdkPKCS11ConfigFile)
+}
+
+func benchmarkNew(b *testing.B, configFile string, opts ...Option) {
+	c, err := configImpl.FromFile(configFile)()
+	if err != nil {
+		b.Fatalf("Unexpected error from config: %v", err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := New(WithConfig(c), opts...); err != nil {
+			b.Fatalf("Error initializing SDK: %s", err)
+		}
+	}
+}
@@ -13,7 +13,9 @@ import (
 )
 
 // OrgClientFactory represents the default org provider factory.
-type OrgClientFactory struct{}
+type OrgClientFactory struct {
+	identityMgrOpts []identitymgr.Option
+}
 
 // NewOrgClientFactory returns the default org provider factory.
 func NewOrgClientFactory() *OrgClientFactory {
@@ -23,5 +25,11 @@ func NewOrgClientFactory() *OrgClientFactory {
 
 // CreateCredentialManager returns a new default implementation of the credential manager
 func (f *OrgClientFactory) CreateCredentialManager(orgName string, config core.Config, cryptoProvider core.CryptoSuite) (api.CredentialManager, error) {
-	return identitymgr.New(orgName, config, cryptoProvider)
+	return identitymgr.New(orgName, config, cryptoProvider, f.identityMgrOpts...)
+}
+
+// SetIdentityManagerOptions sets the options that are used when creating the credential manager
+// (for example, a custom HTTP transport or request hooks for the CA client)
+func (f *OrgClientFactory) SetIdentityManagerOptions(opts ...identitymgr.Option) {
+	f.identityMgrOpts = opts
 }
@@ -0,0 +1,126 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package defclient
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SessionCache is a least recently used cache of session state (e.g. the identity of a user)
+// keyed by organization and user. Entries expire after the cache's TTL. It's safe for concurrent use.
+type SessionCache struct {
+	mutex   sync.Mutex
+	size    int
+	ttl     time.Duration
+	lru     *list.List
+	entries map[sessionKey]*list.Element
+	now     func() time.Time
+}
+
+type sessionKey struct {
+	org  string
+	user string
+}
+
+type sessionEntry struct {
+	key     sessionKey
+	value   interface{}
+	expires time.Time
+}
+
+// NewSessionCache returns a cache that holds up to size entries. Entries expire after ttl;
+// a ttl of zero means that entries don't expire.
+func NewSessionCache(size int, ttl time.Duration) *SessionCache {
+	return &SessionCache{
+		size:    size,
+		ttl:     ttl,
+		lru:     list.New(),
+		entries: make(map[sessionKey]*list.Element),
+		now:     time.Now,
+	}
+}
+
+func newSessionKey(org, user string) sessionKey {
+	// Organization names are case insensitive in the configuration
+	return sessionKey{org: strings.ToLower(org), user: user}
+}
+
+// Get returns the cached value for the user of the organization, if it's cached and hasn't expired.
+func (c *SessionCache) Get(org, user string) (interface{}, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	e, ok := c.entries[newSessionKey(org, user)]
+	if !ok {
+		return nil, false
+	}
+	entry := e.Value.(*sessionEntry)
+	if c.ttl > 0 && !c.now().Before(entry.expires) {
+		c.remove(e)
+		return nil, false
+	}
+	c.lru.MoveToFront(e)
+	return entry.value, true
+}
+
+// Put caches the value for the user of the organization. The least recently used entry is evicted
+// if the cache is full.
+func (c *SessionCache) Put(org, user string, value interface{}) {
+	if c.size <= 0 {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	key := newSessionKey(org, user)
+	entry := &sessionEntry{key: key, value: value, expires: c.now().Add(c.ttl)}
+	if e, ok := c.entries[key]; ok {
+		e.Value = entry
+		c.lru.MoveToFront(e)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(entry)
+	for c.lru.Len() > c.size {
+		c.remove(c.lru.Back())
+	}
+}
+
+// Invalidate removes the cached value for the user of the organization.
+func (c *SessionCache) Invalidate(org, user string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if e, ok := c.entries[newSessionKey(org, user)]; ok {
+		c.remove(e)
+	}
+}
+
+// Clear removes all of the cached values.
+func (c *SessionCache) Clear() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.lru.Init()
+	c.entries = make(map[sessionKey]*list.Element)
+}
+
+// Len returns the number of cached entries (including those that have expired but haven't been evicted yet).
+func (c *SessionCache) Len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.lru.Len()
+}
+
+func (c *SessionCache) remove(e *list.Element) {
+	c.lru.Remove(e)
+	delete(c.entries, e.Value.(*sessionEntry).key)
+}
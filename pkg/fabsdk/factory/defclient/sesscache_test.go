@@ -0,0 +1,163 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package defclient
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSessionCacheHit(t *testing.T) {
+	cache := NewSessionCache(10, 0)
+
+	if _, ok := cache.Get("Org1", "User1"); ok {
+		t.Fatal("Expected cache miss for empty cache")
+	}
+
+	cache.Put("Org1", "User1", "session1")
+	v, ok := cache.Get("org1", "User1")
+	if !ok || v != "session1" {
+		t.Fatalf("Expected cache hit (org names are case insensitive), got %v", v)
+	}
+
+	// Users are case sensitive
+	if _, ok := cache.Get("Org1", "user1"); ok {
+		t.Fatal("Expected cache miss for other user")
+	}
+
+	cache.Put("Org1", "User1", "session2")
+	if v, _ := cache.Get("Org1", "User1"); v != "session2" || cache.Len() != 1 {
+		t.Fatalf("Expected the cached value to be replaced, got %v", v)
+	}
+}
+
+func TestSessionCacheEviction(t *testing.T) {
+	cache := NewSessionCache(2, 0)
+
+	cache.Put("Org1", "User1", "session1")
+	cache.Put("Org1", "User2", "session2")
+
+	// User1 becomes the most recently used, so User2 is evicted
+	cache.Get("Org1", "User1")
+	cache.Put("Org1", "User3", "session3")
+
+	if cache.Len() != 2 {
+		t.Fatalf("Expected 2 cached entries, got %d", cache.Len())
+	}
+	if _, ok := cache.Get("Org1", "User2"); ok {
+		t.Fatal("Expected the least recently used entry to be evicted")
+	}
+	if _, ok := cache.Get("Org1", "User1"); !ok {
+		t.Fatal("Expected the recently used entry to be cached")
+	}
+	if _, ok := cache.Get("Org1", "User3"); !ok {
+		t.Fatal("Expected the new entry to be cached")
+	}
+}
+
+func TestSessionCacheTTL(t *testing.T) {
+	now := time.Now()
+	cache := NewSessionCache(10, time.Minute)
+	cache.now = func() time.Time { return now }
+
+	cache.Put("Org1", "User1", "session1")
+
+	now = now.Add(59 * time.Second)
+	if _, ok := cache.Get("Org1", "User1"); !ok {
+		t.Fatal("Expected cache hit before the TTL expires")
+	}
+
+	now = now.Add(time.Second)
+	if _, ok := cache.Get("Org1", "User1"); ok {
+		t.Fatal("Expected cache miss after the TTL expires")
+	}
+	if cache.Len() != 0 {
+		t.Fatalf("Expected the expired entry to be evicted, got %d entries", cache.Len())
+	}
+}
+
+func TestSessionCacheInvalidate(t *testing.T) {
+	cache := NewSessionCache(10, 0)
+
+	cache.Put("Org1", "User1", "session1")
+	cache.Put("Org2", "User1", "session2")
+	cache.Invalidate("ORG1", "User1")
+
+	if _, ok := cache.Get("Org1", "User1"); ok {
+		t.Fatal("Expected cache miss after invalidation")
+	}
+	if _, ok := cache.Get("Org2", "User1"); !ok {
+		t.Fatal("Expected the other organization's entry to be cached")
+	}
+
+	// Invalidating an entry that isn't cached has no effect
+	cache.Invalidate("Org3", "User1")
+}
+
+func TestSessionCacheClear(t *testing.T) {
+	cache := NewSessionCache(10, 0)
+
+	cache.Put("Org1", "User1", "session1")
+	cache.Put("Org2", "User1", "session2")
+	cache.Clear()
+
+	if cache.Len() != 0 {
+		t.Fatalf("Expected no entries after clearing the cache, got %d entries", cache.Len())
+	}
+	if _, ok := cache.Get("Org1", "User1"); ok {
+		t.Fatal("Expected cache miss after clearing the cache")
+	}
+
+	cache.Put("Org1", "User1", "session3")
+	if v, ok := cache.Get("Org1", "User1"); !ok || v != "session3" {
+		t.Fatalf("Expected the entry to be cached after clearing the cache, got %v", v)
+	}
+}
+
+func TestSessionCacheConcurrency(t *testing.T) {
+	cache := NewSessionCache(5, time.Minute)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				user := fmt.Sprintf("User%d", j%10)
+				cache.Put("Org1", user, i)
+				cache.Get("Org1", user)
+				if j%7 == 0 {
+					cache.Invalidate("Org1", user)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if cache.Len() > 5 {
+		t.Fatalf("Expected at most 5 cached entries, got %d", cache.Len())
+	}
+}
+
+func TestSetSessionCache(t *testing.T) {
+	factory := NewSessionClientFactory()
+	if factory.SessionCache() != nil {
+		t.Fatal("Expected sessions not to be cached by default")
+	}
+
+	factory.SetSessionCache(10, time.Minute)
+	if factory.SessionCache() == nil {
+		t.Fatal("Expected session cache")
+	}
+
+	factory.SetSessionCache(0, time.Minute)
+	if factory.SessionCache() != nil {
+		t.Fatal("Expected the session cache to be disabled")
+	}
+}
@@ -7,6 +7,8 @@ SPDX-License-Identifier: Apache-2.0
 package defclient
 
 import (
+	"time"
+
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/common/discovery"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context"
@@ -16,7 +18,9 @@ import (
 )
 
 // SessionClientFactory represents the default implementation of a session client.
-type SessionClientFactory struct{}
+type SessionClientFactory struct {
+	cache *SessionCache
+}
 
 // NewSessionClientFactory creates a new default session client factory.
 func NewSessionClientFactory() *SessionClientFactory {
@@ -24,6 +28,21 @@ func NewSessionClientFactory() *SessionClientFactory {
 	return &f
 }
 
+// SetSessionCache enables caching of sessions (i.e. of user identities) by organization and user, with the given
+// maximum size and TTL (see NewSessionCache). A size of zero disables the cache.
+func (f *SessionClientFactory) SetSessionCache(size int, ttl time.Duration) {
+	if size <= 0 {
+		f.cache = nil
+		return
+	}
+	f.cache = NewSessionCache(size, ttl)
+}
+
+// SessionCache returns the session cache, or nil if sessions aren't cached.
+func (f *SessionClientFactory) SessionCache() *SessionCache {
+	return f.cache
+}
+
 // CreateChannelClient returns a client that can execute transactions on specified channel
 func (f *SessionClientFactory) CreateChannelClient(providers api.Providers, session context.SessionContext, channelID string, targetFilter fab.TargetFilter) (*channel.Client, error) {
 
@@ -11,7 +11,9 @@ import (
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
 	"github.com/hyperledger/fabric-sdk-go/pkg/logging/api"
 
-	cryptosuiteimpl "github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite/bccsp/sw"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/comm"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/identity"
 	kvs "github.com/hyperledger/fabric-sdk-go/pkg/fab/keyvaluestore"
 	signingMgr "github.com/hyperledger/fabric-sdk-go/pkg/fab/signingmgr"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk/provider/fabpvdr"
@@ -23,7 +25,14 @@ import (
 )
 
 // ProviderFactory represents the default SDK provider factory.
+//
+// The crypto suite, state store and signing manager are created on first use rather than by the
+// factory methods, so that an SDK that doesn't use them (e.g. for ledger queries with a PKCS11
+// crypto suite) doesn't pay for their initialization. An error that occurs while creating a
+// provider is returned to the first caller (and to each subsequent caller) of the provider.
+// The providers may be created up front with fabsdk.WithEagerInit.
 type ProviderFactory struct {
+	signer contextApi.Signer
 }
 
 // NewProviderFactory returns the default SDK provider factory.
@@ -32,9 +41,23 @@ func NewProviderFactory() *ProviderFactory {
 	return &f
 }
 
-// CreateStateStoreProvider creates a KeyValueStore using the SDK's default implementation
+// NewProviderFactoryWithSigner returns the default SDK provider factory with a signing manager that
+// signs with the given signer rather than the crypto suite, e.g. the signer of a remote signing service
+// (see package signingmgr/remote) that is passed to fabsdk.New with fabsdk.WithCorePkg.
+func NewProviderFactoryWithSigner(signer contextApi.Signer) *ProviderFactory {
+	f := ProviderFactory{signer: signer}
+	return &f
+}
+
+// CreateStateStoreProvider creates a KeyValueStore using the SDK's default implementation.
+// The store is created on first use.
 func (f *ProviderFactory) CreateStateStoreProvider(config core.Config) (contextApi.KVStore, error) {
+	return newLazyStateStore(func() (contextApi.KVStore, error) {
+		return createStateStore(config)
+	}), nil
+}
 
+func createStateStore(config core.Config) (contextApi.KVStore, error) {
 	clientCofig, err := config.Client()
 	if err != nil {
 		return nil, errors.WithMessage(err, "Unable to retrieve client config")
@@ -48,15 +71,60 @@ func (f *ProviderFactory) CreateStateStoreProvider(config core.Config) (contextA
 	return stateStore, nil
 }
 
-// CreateCryptoSuiteProvider returns a new default implementation of BCCSP
+// The types of credential store (see client.credentialStore.type)
+const (
+	fileCredentialStore = "file"
+	sqlCredentialStore  = "sql"
+)
+
+// CreateCredentialStore creates the store of the enrolled users selected by client.credentialStore.type:
+// "file" (the default) stores the users in the client.credentialStore.path directory and "sql" stores
+// them in the client.credentialStore.sql database. Nil is returned if no store is configured.
+func (f *ProviderFactory) CreateCredentialStore(config core.Config) (contextApi.CredentialStore, error) {
+	clientConfig, err := config.Client()
+	if err != nil {
+		return nil, errors.WithMessage(err, "Unable to retrieve client config")
+	}
+	storeConfig := clientConfig.CredentialStore
+
+	switch storeConfig.Type {
+	case "", fileCredentialStore:
+		if config.CredentialStorePath() == "" {
+			return nil, nil
+		}
+		return identity.NewFileCredentialStore(config.CredentialStorePath())
+	case sqlCredentialStore:
+		return identity.OpenSQLCredentialStore(storeConfig.SQL.Driver, storeConfig.SQL.DataSource)
+	}
+	return nil, errors.Errorf("unsupported credential store type [%s]", storeConfig.Type)
+}
+
+// CreateCryptoSuiteProvider returns the crypto suite of the security provider of the config
+// (client.BCCSP.security.default.provider), created by the factory registered for the provider
+// with cryptosuite.Register. The crypto suite is created on first use.
 func (f *ProviderFactory) CreateCryptoSuiteProvider(config core.Config) (core.CryptoSuite, error) {
-	cryptoSuiteProvider, err := cryptosuiteimpl.GetSuiteByConfig(config)
-	return cryptoSuiteProvider, err
+	return newLazyCryptoSuite(func() (core.CryptoSuite, error) {
+		return cryptosuite.GetSuiteByConfig(config)
+	}), nil
 }
 
-// CreateSigningManager returns a new default implementation of signing manager
+// CreateSigningManager returns a new default implementation of signing manager.
+// The objects are signed with the signer of the factory if set, otherwise with the crypto suite.
+// The signing manager is created on first use.
 func (f *ProviderFactory) CreateSigningManager(cryptoProvider core.CryptoSuite, config core.Config) (contextApi.SigningManager, error) {
-	return signingMgr.New(cryptoProvider, config)
+	return newLazySigningManager(func() (contextApi.SigningManager, error) {
+		if f.signer != nil {
+			return signingMgr.NewWithSigner(f.signer)
+		}
+		return signingMgr.New(cryptoProvider, config)
+	}), nil
+}
+
+// CreateConnector returns a connector that shares the connections to the peers and orderers between the
+// clients of the SDK. The connections that aren't used for the idle timeout (client.timeouts.connectionIdle)
+// are closed.
+func (f *ProviderFactory) CreateConnector(config core.Config) (contextApi.Connector, error) {
+	return comm.NewCachingConnector(config.Timeout(core.ConnectionIdle)), nil
 }
 
 // CreateFabricProvider returns a new default implementation of fabric primitives
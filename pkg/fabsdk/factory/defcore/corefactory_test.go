@@ -8,6 +8,9 @@ package defcore
 
 import (
 	"errors"
+	"io"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/golang/mock/gomock"
@@ -15,6 +18,8 @@ import (
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core/mocks"
 	cryptosuitewrapper "github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite/bccsp/wrapper"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/identity"
+	idmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/identitymgr/mocks"
 	kvs "github.com/hyperledger/fabric-sdk-go/pkg/fab/keyvaluestore"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
 	signingMgr "github.com/hyperledger/fabric-sdk-go/pkg/fab/signingmgr"
@@ -32,12 +37,62 @@ func TestCreateStateStoreProvider(t *testing.T) {
 		t.Fatalf("Unexpected error creating state store provider %v", err)
 	}
 
-	_, ok := stateStore.(*kvs.FileKeyValueStore)
+	_, ok := unwrapStateStore(t, stateStore).(*kvs.FileKeyValueStore)
 	if !ok {
 		t.Fatalf("Unexpected state store provider created")
 	}
 }
 
+func TestCreateCredentialStore(t *testing.T) {
+	factory := NewProviderFactory()
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockConfig := mock_core.NewMockConfig(mockCtrl)
+
+	// The file store is the default
+	mockConfig.EXPECT().Client().Return(&core.ClientConfig{}, nil)
+	mockConfig.EXPECT().CredentialStorePath().Return("/tmp/fabsdkgo_test/credentials").AnyTimes()
+	store, err := factory.CreateCredentialStore(mockConfig)
+	if err != nil {
+		t.Fatalf("Unexpected error creating credential store %v", err)
+	}
+	if _, ok := store.(*identity.FileCredentialStore); !ok {
+		t.Fatalf("Unexpected credential store created")
+	}
+
+	sqlConfig := core.ClientConfig{}
+	sqlConfig.CredentialStore.Type = "sql"
+	sqlConfig.CredentialStore.SQL.Driver = "notaregistereddriver"
+	sqlConfig.CredentialStore.SQL.DataSource = "credentials"
+	mockConfig.EXPECT().Client().Return(&sqlConfig, nil)
+	if _, err := factory.CreateCredentialStore(mockConfig); err == nil {
+		t.Fatalf("Expected error creating SQL credential store with unregistered driver")
+	}
+
+	unknownConfig := core.ClientConfig{}
+	unknownConfig.CredentialStore.Type = "unknown"
+	mockConfig.EXPECT().Client().Return(&unknownConfig, nil)
+	if _, err := factory.CreateCredentialStore(mockConfig); err == nil {
+		t.Fatalf("Expected error creating credential store of unknown type")
+	}
+}
+
+func TestCreateCredentialStoreWithoutPath(t *testing.T) {
+	factory := NewProviderFactory()
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockConfig := mock_core.NewMockConfig(mockCtrl)
+	mockConfig.EXPECT().Client().Return(&core.ClientConfig{}, nil)
+	mockConfig.EXPECT().CredentialStorePath().Return("")
+
+	store, err := factory.CreateCredentialStore(mockConfig)
+	if err != nil || store != nil {
+		t.Fatalf("Expected no credential store without path, got %v %v", store, err)
+	}
+}
+
 func newMockStateStore(t *testing.T) api.KVStore {
 	factory := NewProviderFactory()
 
@@ -56,7 +111,7 @@ func newMockStateStore(t *testing.T) api.KVStore {
 	if err != nil {
 		t.Fatalf("Unexpected error creating state store provider %v", err)
 	}
-	return stateStore
+	return unwrapStateStore(t, stateStore)
 }
 func TestCreateStateStoreProviderByConfig(t *testing.T) {
 	stateStore := newMockStateStore(t)
@@ -77,9 +132,12 @@ func TestCreateStateStoreProviderEmptyConfig(t *testing.T) {
 	mockClientConfig := core.ClientConfig{}
 	mockConfig.EXPECT().Client().Return(&mockClientConfig, nil)
 
-	_, err := factory.CreateStateStoreProvider(mockConfig)
-	if err == nil {
-		t.Fatal("Expected error creating state store provider")
+	stateStore, err := factory.CreateStateStoreProvider(mockConfig)
+	if err != nil {
+		t.Fatalf("Unexpected error creating state store provider %v", err)
+	}
+	if _, err := stateStore.Load("key"); err == nil {
+		t.Fatal("Expected error initializing state store")
 	}
 }
 
@@ -92,9 +150,12 @@ func TestCreateStateStoreProviderFailConfig(t *testing.T) {
 
 	mockConfig.EXPECT().Client().Return(nil, errors.New("error"))
 
-	_, err := factory.CreateStateStoreProvider(mockConfig)
-	if err == nil {
-		t.Fatal("Expected error creating state store provider")
+	stateStore, err := factory.CreateStateStoreProvider(mockConfig)
+	if err != nil {
+		t.Fatalf("Unexpected error creating state store provider %v", err)
+	}
+	if err := stateStore.Store("key", "value"); err == nil {
+		t.Fatal("Expected error initializing state store")
 	}
 }
 
@@ -107,7 +168,11 @@ func TestCreateCryptoSuiteProvider(t *testing.T) {
 		t.Fatalf("Unexpected error creating cryptosuite provider %v", err)
 	}
 
-	_, ok := cryptosuite.(*cryptosuitewrapper.CryptoSuite)
+	suite, err := cryptosuite.(*lazyCryptoSuite).suite()
+	if err != nil {
+		t.Fatalf("Unexpected error initializing cryptosuite provider %v", err)
+	}
+	_, ok := suite.(*cryptosuitewrapper.CryptoSuite)
 	if !ok {
 		t.Fatalf("Unexpected cryptosuite provider created")
 	}
@@ -127,12 +192,111 @@ func TestCreateSigningManager(t *testing.T) {
 		t.Fatalf("Unexpected error creating signing manager %v", err)
 	}
 
-	_, ok := signer.(*signingMgr.SigningManager)
+	if err := signer.(*lazySigningManager).Init(); err != nil {
+		t.Fatalf("Unexpected error initializing signing manager %v", err)
+	}
+	_, ok := signer.(*lazySigningManager).provider.(*signingMgr.SigningManager)
 	if !ok {
 		t.Fatalf("Unexpected signing manager created")
 	}
 }
 
+type keyRefSigner struct {
+	keyRefs []string
+}
+
+func (s *keyRefSigner) Sign(msg []byte, keyRef string) ([]byte, error) {
+	s.keyRefs = append(s.keyRefs, keyRef)
+	return []byte("signature"), nil
+}
+
+func TestCreateSigningManagerWithSigner(t *testing.T) {
+	signer := &keyRefSigner{}
+	factory := NewProviderFactoryWithSigner(signer)
+
+	signingManager, err := factory.CreateSigningManager(&mocks.MockCryptoSuite{}, mocks.NewMockConfig())
+	if err != nil {
+		t.Fatalf("Unexpected error creating signing manager %v", err)
+	}
+
+	key := cryptosuitewrapper.GetKey(&idmocks.MockKey{})
+	if _, err := signingManager.Sign([]byte("Hello"), key); err != nil {
+		t.Fatalf("Unexpected error signing %v", err)
+	}
+	if len(signer.keyRefs) != 1 || signer.keyRefs[0] != signingMgr.KeyRef(key) {
+		t.Fatalf("Expected the signer of the factory to sign with the key reference, got %v", signer.keyRefs)
+	}
+}
+
+func TestCreateProvidersLazily(t *testing.T) {
+	factory := NewProviderFactory()
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockConfig := mock_core.NewMockConfig(mockCtrl)
+
+	// Nothing is read from the config until the providers are used (the mock config fails on unexpected calls)
+	cryptosuite, err := factory.CreateCryptoSuiteProvider(mockConfig)
+	if err != nil {
+		t.Fatalf("Unexpected error creating cryptosuite provider %v", err)
+	}
+	stateStore, err := factory.CreateStateStoreProvider(mockConfig)
+	if err != nil {
+		t.Fatalf("Unexpected error creating state store provider %v", err)
+	}
+
+	// The initialization error is returned to the first caller and to the subsequent callers
+	mockConfig.EXPECT().SecurityProvider().Return("UNKNOWN")
+	if _, err := cryptosuite.Hash([]byte("msg"), nil); err == nil || !strings.Contains(err.Error(), "Unsupported security provider") {
+		t.Fatalf("Expected error initializing cryptosuite, got %v", err)
+	}
+	if _, err := cryptosuite.GetKey(nil); err == nil {
+		t.Fatal("Expected the initialization error to be kept")
+	}
+
+	// Closing a provider that hasn't been created doesn't create it
+	if err := stateStore.(io.Closer).Close(); err != nil {
+		t.Fatalf("Unexpected error closing state store %v", err)
+	}
+	if err := stateStore.(*lazyStateStore).Init(); err == nil {
+		t.Fatal("Expected error using a closed state store")
+	}
+}
+
+func TestCreateProvidersConcurrently(t *testing.T) {
+	factory := NewProviderFactory()
+	stateStore, err := factory.CreateStateStoreProvider(mocks.NewMockConfig())
+	if err != nil {
+		t.Fatalf("Unexpected error creating state store provider %v", err)
+	}
+	lazy := stateStore.(*lazyStateStore)
+
+	var wg sync.WaitGroup
+	stores := make([]api.KVStore, 10)
+	for i := range stores {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			stores[i], _ = lazy.store()
+		}(i)
+	}
+	wg.Wait()
+
+	for _, store := range stores {
+		if store == nil || store != stores[0] {
+			t.Fatal("Expected all callers to share the same state store")
+		}
+	}
+}
+
+func unwrapStateStore(t *testing.T, stateStore api.KVStore) api.KVStore {
+	store, err := stateStore.(*lazyStateStore).store()
+	if err != nil {
+		t.Fatalf("Unexpected error initializing state store provider %v", err)
+	}
+	return store
+}
+
 func TestNewFactoryFabricProvider(t *testing.T) {
 	factory := NewProviderFactory()
 	ctx := mocks.NewMockProviderContext()
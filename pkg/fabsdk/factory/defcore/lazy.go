@@ -0,0 +1,197 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package defcore
+
+import (
+	"hash"
+	"io"
+	"sync"
+
+	contextApi "github.com/hyperledger/fabric-sdk-go/pkg/context/api"
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
+	"github.com/pkg/errors"
+)
+
+// lazyProvider creates a provider on first use. The provider (or the error that occurred
+// while creating it) is kept, so it's created at most once and all callers share it.
+type lazyProvider struct {
+	once     sync.Once
+	create   func() (interface{}, error)
+	provider interface{}
+	err      error
+}
+
+func (l *lazyProvider) get() (interface{}, error) {
+	l.once.Do(func() {
+		l.provider, l.err = l.create()
+		if l.err == nil && l.provider == nil {
+			l.err = errors.New("provider is nil")
+		}
+	})
+	return l.provider, l.err
+}
+
+// Init creates the provider if it hasn't been created yet and returns the error that occurred
+// while creating it (if any). The SDK calls Init when it's created with fabsdk.WithEagerInit.
+func (l *lazyProvider) Init() error {
+	_, err := l.get()
+	return err
+}
+
+// Close closes the provider if it has been created and implements io.Closer. A provider that
+// hasn't been created yet won't be created.
+func (l *lazyProvider) Close() error {
+	l.once.Do(func() {
+		l.err = errors.New("provider is closed")
+	})
+	if closer, ok := l.provider.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// lazyCryptoSuite is a crypto suite that's created on first use
+type lazyCryptoSuite struct {
+	lazyProvider
+}
+
+func newLazyCryptoSuite(create func() (core.CryptoSuite, error)) *lazyCryptoSuite {
+	return &lazyCryptoSuite{lazyProvider{create: func() (interface{}, error) { return create() }}}
+}
+
+func (cs *lazyCryptoSuite) suite() (core.CryptoSuite, error) {
+	p, err := cs.get()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to initialize crypto suite")
+	}
+	return p.(core.CryptoSuite), nil
+}
+
+// KeyGen generates a key using opts.
+func (cs *lazyCryptoSuite) KeyGen(opts core.KeyGenOpts) (core.Key, error) {
+	suite, err := cs.suite()
+	if err != nil {
+		return nil, err
+	}
+	return suite.KeyGen(opts)
+}
+
+// KeyImport imports a key from its raw representation using opts.
+func (cs *lazyCryptoSuite) KeyImport(raw interface{}, opts core.KeyImportOpts) (core.Key, error) {
+	suite, err := cs.suite()
+	if err != nil {
+		return nil, err
+	}
+	return suite.KeyImport(raw, opts)
+}
+
+// GetKey returns the key this CSP associates to the Subject Key Identifier ski.
+func (cs *lazyCryptoSuite) GetKey(ski []byte) (core.Key, error) {
+	suite, err := cs.suite()
+	if err != nil {
+		return nil, err
+	}
+	return suite.GetKey(ski)
+}
+
+// Hash hashes messages msg using options opts.
+func (cs *lazyCryptoSuite) Hash(msg []byte, opts core.HashOpts) ([]byte, error) {
+	suite, err := cs.suite()
+	if err != nil {
+		return nil, err
+	}
+	return suite.Hash(msg, opts)
+}
+
+// GetHash returns and instance of hash.Hash using options opts.
+func (cs *lazyCryptoSuite) GetHash(opts core.HashOpts) (hash.Hash, error) {
+	suite, err := cs.suite()
+	if err != nil {
+		return nil, err
+	}
+	return suite.GetHash(opts)
+}
+
+// Sign signs digest using key k.
+func (cs *lazyCryptoSuite) Sign(k core.Key, digest []byte, opts core.SignerOpts) ([]byte, error) {
+	suite, err := cs.suite()
+	if err != nil {
+		return nil, err
+	}
+	return suite.Sign(k, digest, opts)
+}
+
+// Verify verifies signature against key k and digest
+func (cs *lazyCryptoSuite) Verify(k core.Key, signature, digest []byte, opts core.SignerOpts) (bool, error) {
+	suite, err := cs.suite()
+	if err != nil {
+		return false, err
+	}
+	return suite.Verify(k, signature, digest, opts)
+}
+
+// lazyStateStore is a state store that's created on first use
+type lazyStateStore struct {
+	lazyProvider
+}
+
+func newLazyStateStore(create func() (contextApi.KVStore, error)) *lazyStateStore {
+	return &lazyStateStore{lazyProvider{create: func() (interface{}, error) { return create() }}}
+}
+
+func (s *lazyStateStore) store() (contextApi.KVStore, error) {
+	p, err := s.get()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to initialize state store")
+	}
+	return p.(contextApi.KVStore), nil
+}
+
+// Store sets the value for the key.
+func (s *lazyStateStore) Store(key interface{}, value interface{}) error {
+	store, err := s.store()
+	if err != nil {
+		return err
+	}
+	return store.Store(key, value)
+}
+
+// Load returns the value stored in the store for a key.
+func (s *lazyStateStore) Load(key interface{}) (interface{}, error) {
+	store, err := s.store()
+	if err != nil {
+		return nil, err
+	}
+	return store.Load(key)
+}
+
+// Delete deletes the value for a key.
+func (s *lazyStateStore) Delete(key interface{}) error {
+	store, err := s.store()
+	if err != nil {
+		return err
+	}
+	return store.Delete(key)
+}
+
+// lazySigningManager is a signing manager that's created on first use
+type lazySigningManager struct {
+	lazyProvider
+}
+
+func newLazySigningManager(create func() (contextApi.SigningManager, error)) *lazySigningManager {
+	return &lazySigningManager{lazyProvider{create: func() (interface{}, error) { return create() }}}
+}
+
+// Sign signs the object using the key
+func (m *lazySigningManager) Sign(object []byte, key core.Key) ([]byte, error) {
+	p, err := m.get()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to initialize signing manager")
+	}
+	return p.(contextApi.SigningManager).Sign(object, key)
+}
@@ -0,0 +1,94 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package fabsdk
+
+import (
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+)
+
+// Report describes the channel state held by an SDK instance (e.g. for an operational dashboard).
+// A report can be marshalled to JSON and never contains keys, certificates or other credentials.
+//
+// Event services and connections are created on demand by the clients that use them and
+// aren't held by the SDK, so they are not part of the report.
+type Report struct {
+	Channels []ChannelReport `json:"channels"`
+}
+
+// ChannelReport describes the state held by the SDK for a channel
+type ChannelReport struct {
+	ChannelID string `json:"channelID"`
+
+	// ConfigCached is true if the channel configuration has been loaded. The remaining
+	// configuration fields are only set if the configuration is cached.
+	ConfigCached bool `json:"configCached"`
+
+	// ConfigSequence is the sequence number of the cached channel configuration (if known)
+	ConfigSequence *uint64 `json:"configSequence,omitempty"`
+
+	Orderers    []string           `json:"orderers,omitempty"`
+	AnchorPeers []AnchorPeerReport `json:"anchorPeers,omitempty"`
+
+	// Identities are the identities for which a channel service has been created
+	Identities []IdentityReport `json:"identities,omitempty"`
+}
+
+// AnchorPeerReport describes an anchor peer of a channel
+type AnchorPeerReport struct {
+	Org  string `json:"org"`
+	Host string `json:"host"`
+	Port int32  `json:"port"`
+}
+
+// IdentityReport describes an identity that is in use on a channel
+type IdentityReport struct {
+	MspID string `json:"mspID"`
+	Name  string `json:"name,omitempty"`
+}
+
+// configSequencer is implemented by channel configurations that know their sequence number
+type configSequencer interface {
+	Sequence() uint64
+}
+
+// Inspect returns a report of the channel state held by the SDK. Only state that has
+// already been loaded is reported - inspecting the SDK never creates channel services or
+// queries channel configuration.
+func (sdk *FabricSDK) Inspect() *Report {
+	report := Report{Channels: []ChannelReport{}}
+
+	for _, info := range sdk.channelProvider.Channels() {
+		chReport := ChannelReport{ChannelID: info.ChannelID}
+
+		if info.Config != nil {
+			fillChannelConfig(&chReport, info.Config)
+		}
+
+		for _, id := range info.Identities {
+			chReport.Identities = append(chReport.Identities, IdentityReport{MspID: id.MspID, Name: id.Name})
+		}
+
+		report.Channels = append(report.Channels, chReport)
+	}
+
+	return &report
+}
+
+func fillChannelConfig(chReport *ChannelReport, cfg fab.ChannelCfg) {
+	chReport.ConfigCached = true
+
+	if s, ok := cfg.(configSequencer); ok {
+		sequence := s.Sequence()
+		chReport.ConfigSequence = &sequence
+	}
+
+	chReport.Orderers = append(chReport.Orderers, cfg.Orderers()...)
+
+	for _, ap := range cfg.AnchorPeers() {
+		chReport.AnchorPeers = append(chReport.AnchorPeers, AnchorPeerReport{Org: ap.Org, Host: ap.Host, Port: ap.Port})
+	}
+}
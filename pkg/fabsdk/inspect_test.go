@@ -0,0 +1,141 @@
+// +build testing
+
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package fabsdk
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/context"
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+	configImpl "github.com/hyperledger/fabric-sdk-go/pkg/core/config"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	sdkApi "github.com/hyperledger/fabric-sdk-go/pkg/fabsdk/api"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk/factory/defcore"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk/provider/fabpvdr"
+)
+
+func TestInspect(t *testing.T) {
+	core := &inspectCoreFactory{ProviderFactory: defcore.NewProviderFactory()}
+	sdk, err := New(configImpl.FromFile(sdkConfigFile), WithCorePkg(core))
+	if err != nil {
+		t.Fatalf("Error initializing SDK: %s", err)
+	}
+
+	report := sdk.Inspect()
+	if len(report.Channels) != 0 {
+		t.Fatalf("Expecting no channels to be reported but got %d", len(report.Channels))
+	}
+
+	// A cached channel config without channel services
+	cfg := mocks.NewMockChannelCfg("orgchannel").(*mocks.MockChannelCfg)
+	cfg.MockOrderers = []string{"orderer.example.com"}
+	cfg.MockAnchorPeers = []*fab.OrgAnchorPeer{{Org: "Org1MSP", Host: "peer0.org1.example.com", Port: 7051}}
+	sdk.channelProvider.SetChannelConfig(cfg)
+	sdk.channelProvider.SetChannelConfig(mocks.NewMockChannelCfg("mychannel"))
+
+	c := sdk.NewClient(WithUser(sdkValidClientUser), WithOrg(sdkValidClientOrg1))
+	if _, err := c.ChannelService("mychannel"); err != nil {
+		t.Fatalf("Failed to create channel service: %s", err)
+	}
+
+	report = sdk.Inspect()
+	if core.provider.created != 0 {
+		t.Fatalf("Expecting inspection not to create any channel components but %d were created", core.provider.created)
+	}
+
+	if len(report.Channels) != 2 {
+		t.Fatalf("Expecting two channels to be reported but got %d", len(report.Channels))
+	}
+
+	mychannel := report.Channels[0]
+	if mychannel.ChannelID != "mychannel" || !mychannel.ConfigCached {
+		t.Fatalf("Unexpected report for mychannel: %+v", mychannel)
+	}
+	if len(mychannel.Identities) != 1 || mychannel.Identities[0].MspID != "Org1MSP" || mychannel.Identities[0].Name != sdkValidClientUser {
+		t.Fatalf("Unexpected identities reported for mychannel: %+v", mychannel.Identities)
+	}
+
+	orgchannel := report.Channels[1]
+	if orgchannel.ChannelID != "orgchannel" || !orgchannel.ConfigCached || len(orgchannel.Identities) != 0 {
+		t.Fatalf("Unexpected report for orgchannel: %+v", orgchannel)
+	}
+	if len(orgchannel.Orderers) != 1 || orgchannel.Orderers[0] != "orderer.example.com" {
+		t.Fatalf("Unexpected orderers reported for orgchannel: %v", orgchannel.Orderers)
+	}
+	if len(orgchannel.AnchorPeers) != 1 || orgchannel.AnchorPeers[0].Host != "peer0.org1.example.com" {
+		t.Fatalf("Unexpected anchor peers reported for orgchannel: %v", orgchannel.AnchorPeers)
+	}
+
+	// Make sure that no credentials are included in the report
+	session, err := c.Session()
+	if err != nil {
+		t.Fatalf("Failed to get session: %s", err)
+	}
+	identity, err := session.Identity()
+	if err != nil {
+		t.Fatalf("Failed to get identity: %s", err)
+	}
+	reportBytes, err := json.Marshal(report)
+	if err != nil {
+		t.Fatalf("Failed to marshal report: %s", err)
+	}
+	reportJSON := string(reportBytes)
+	secrets := []string{"-----BEGIN", base64.StdEncoding.EncodeToString(identity)}
+	if keyBytes, err := session.PrivateKey().Bytes(); err == nil {
+		secrets = append(secrets, base64.StdEncoding.EncodeToString(keyBytes))
+	}
+	for _, secret := range secrets {
+		if strings.Contains(reportJSON, secret) {
+			t.Fatalf("Expecting credentials to be excluded from the report: %s", reportJSON)
+		}
+	}
+}
+
+type inspectFabricProvider struct {
+	*fabpvdr.FabricProvider
+	created int
+}
+
+func (f *inspectFabricProvider) CreateChannelClient(ic context.IdentityContext, cfg fab.ChannelCfg) (fab.Channel, error) {
+	f.created++
+	return f.FabricProvider.CreateChannelClient(ic, cfg)
+}
+
+func (f *inspectFabricProvider) CreateChannelLedger(ic context.IdentityContext, channelName string) (fab.ChannelLedger, error) {
+	f.created++
+	return f.FabricProvider.CreateChannelLedger(ic, channelName)
+}
+
+func (f *inspectFabricProvider) CreateEventHub(ic context.IdentityContext, channelID string) (fab.EventHub, error) {
+	f.created++
+	return f.FabricProvider.CreateEventHub(ic, channelID)
+}
+
+func (f *inspectFabricProvider) CreateChannelConfig(ic context.IdentityContext, channelID string) (fab.ChannelConfig, error) {
+	f.created++
+	return f.FabricProvider.CreateChannelConfig(ic, channelID)
+}
+
+func (f *inspectFabricProvider) CreateChannelTransactor(ic context.IdentityContext, cfg fab.ChannelCfg) (fab.Transactor, error) {
+	f.created++
+	return f.FabricProvider.CreateChannelTransactor(ic, cfg)
+}
+
+type inspectCoreFactory struct {
+	*defcore.ProviderFactory
+	provider *inspectFabricProvider
+}
+
+func (f *inspectCoreFactory) CreateFabricProvider(context context.ProviderContext) (sdkApi.FabricProvider, error) {
+	f.provider = &inspectFabricProvider{FabricProvider: fabpvdr.New(context)}
+	return f.provider, nil
+}
@@ -51,6 +51,18 @@ func (mr *MockCoreProvidersMockRecorder) Config() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Config", reflect.TypeOf((*MockCoreProviders)(nil).Config))
 }
 
+// Connector mocks base method
+func (m *MockCoreProviders) Connector() api.Connector {
+	ret := m.ctrl.Call(m, "Connector")
+	ret0, _ := ret[0].(api.Connector)
+	return ret0
+}
+
+// Connector indicates an expected call of Connector
+func (mr *MockCoreProvidersMockRecorder) Connector() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Connector", reflect.TypeOf((*MockCoreProviders)(nil).Connector))
+}
+
 // CryptoSuite mocks base method
 func (m *MockCoreProviders) CryptoSuite() core.CryptoSuite {
 	ret := m.ctrl.Call(m, "CryptoSuite")
@@ -205,6 +217,18 @@ func (mr *MockProvidersMockRecorder) Config() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Config", reflect.TypeOf((*MockProviders)(nil).Config))
 }
 
+// Connector mocks base method
+func (m *MockProviders) Connector() api.Connector {
+	ret := m.ctrl.Call(m, "Connector")
+	ret0, _ := ret[0].(api.Connector)
+	return ret0
+}
+
+// Connector indicates an expected call of Connector
+func (mr *MockProvidersMockRecorder) Connector() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Connector", reflect.TypeOf((*MockProviders)(nil).Connector))
+}
+
 // CryptoSuite mocks base method
 func (m *MockProviders) CryptoSuite() core.CryptoSuite {
 	ret := m.ctrl.Call(m, "CryptoSuite")
@@ -0,0 +1,140 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package fabsdk
+
+import (
+	"sync"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/resmgmt"
+	"github.com/hyperledger/fabric-sdk-go/pkg/context"
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/errors/multi"
+	"github.com/pkg/errors"
+)
+
+// Context is a client context that's scoped to a user of an organization (see FabricSDK.Context).
+// The user's identity is loaded from the credential store when it's first needed and is cached on
+// the context, so the clients that are created from a context share the identity. Contexts are
+// independent of each other: closing a context doesn't affect the other contexts.
+type Context struct {
+	sdk       *FabricSDK
+	client    *ClientContext
+	mutex     sync.Mutex
+	cached    *clientContext
+	eventHubs []fab.EventHub
+	closed    bool
+}
+
+// Context returns a client context for the user and organization given by the options, e.g.
+//
+//	ctx := sdk.Context(fabsdk.WithUser("Admin"), fabsdk.WithOrg("Org1"))
+//
+// The organization defaults to the client organization of the configuration. If the identity
+// can't be loaded then the cause of the error returned by the context is an *UnknownOrgError,
+// *UnknownUserError or *NotEnrolledError (or the error of the credential store).
+func (sdk *FabricSDK) Context(identityOpt IdentityOption, opts ...ContextOption) *Context {
+	ctx := &Context{sdk: sdk}
+	ctx.client = &ClientContext{provider: ctx.cachedProvider(sdk.NewClient(identityOpt, opts...).provider)}
+	return ctx
+}
+
+// cachedProvider returns a provider that caches the client context created by the given provider.
+// Errors aren't cached, so the identity is loaded again if the context is used after e.g. the user is enrolled.
+func (c *Context) cachedProvider(provider clientProvider) clientProvider {
+	return func() (*clientContext, error) {
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+
+		if c.closed {
+			return nil, errors.New("context is closed")
+		}
+		if c.sdk.isClosed() {
+			return nil, errSDKClosed
+		}
+		if c.cached != nil {
+			return c.cached, nil
+		}
+
+		cc, err := provider()
+		if err != nil {
+			return nil, err
+		}
+		c.cached = cc
+		return cc, nil
+	}
+}
+
+// Identity returns the identity of the context's user.
+func (c *Context) Identity() (context.IdentityContext, error) {
+	p, err := c.client.provider()
+	if err != nil {
+		return nil, errors.WithMessage(err, "unable to get client provider context")
+	}
+	return p.identity, nil
+}
+
+// ResourceMgmt returns a client API for managing system resources.
+func (c *Context) ResourceMgmt(opts ...ClientOption) (*resmgmt.Client, error) {
+	return c.client.ResourceMgmt(opts...)
+}
+
+// Channel returns a client API for transacting on a channel.
+func (c *Context) Channel(id string, opts ...ClientOption) (*channel.Client, error) {
+	return c.client.Channel(id, opts...)
+}
+
+// ChannelService returns a client API for interacting with a channel.
+func (c *Context) ChannelService(id string) (fab.ChannelService, error) {
+	return c.client.ChannelService(id)
+}
+
+// EventHub returns the event hub of a channel. The event hub is disconnected when the context is closed.
+func (c *Context) EventHub(channelID string) (fab.EventHub, error) {
+	cs, err := c.ChannelService(channelID)
+	if err != nil {
+		return nil, err
+	}
+	eventHub, err := cs.EventHub()
+	if err != nil {
+		return nil, errors.WithMessage(err, "unable to create event hub")
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.closed {
+		return nil, errors.New("context is closed")
+	}
+	c.eventHubs = append(c.eventHubs, eventHub)
+	return eventHub, nil
+}
+
+// Close releases the cached identity and disconnects the event hubs that were returned by the context.
+// The context can't be used after it's closed. Clients that were already created aren't affected.
+func (c *Context) Close() error {
+	c.mutex.Lock()
+	if c.closed {
+		c.mutex.Unlock()
+		return nil
+	}
+	c.closed = true
+	c.cached = nil
+	eventHubs := c.eventHubs
+	c.eventHubs = nil
+	c.mutex.Unlock()
+
+	var errs multi.Errors
+	for _, eventHub := range eventHubs {
+		if !eventHub.IsConnected() {
+			continue
+		}
+		if err := eventHub.Disconnect(); err != nil {
+			errs = append(errs, errors.WithMessage(err, "unable to disconnect event hub"))
+		}
+	}
+	return errs.ToError()
+}
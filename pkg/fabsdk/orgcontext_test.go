@@ -0,0 +1,197 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package fabsdk
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api"
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
+	configImpl "github.com/hyperledger/fabric-sdk-go/pkg/core/config"
+	"github.com/pkg/errors"
+)
+
+const (
+	contextNotEnrolledUser = "NotEnrolled"
+	contextUnknownUser     = "Unknown"
+	contextUnknownOrg      = "OrgUnknown"
+)
+
+func TestContexts(t *testing.T) {
+	store := newMockCredentialStore()
+	store.add(sdkValidClientOrg1, sdkValidClientUser, "Org1MSP")
+	store.add(sdkValidClientOrg2, clientValidAdmin, "Org2MSP")
+
+	sdk, err := New(configImpl.FromFile(sdkConfigFile), WithContextPkg(store))
+	if err != nil {
+		t.Fatalf("Error initializing SDK: %s", err)
+	}
+
+	ctx1 := sdk.Context(WithUser(sdkValidClientUser), WithOrg(sdkValidClientOrg1))
+	ctx2 := sdk.Context(WithUser(clientValidAdmin), WithOrg(sdkValidClientOrg2))
+
+	// The identities are loaded lazily
+	if loads := store.loadCount(); loads != 0 {
+		t.Fatalf("Expected no identities to be loaded before the contexts are used, got %d loads", loads)
+	}
+
+	if _, err := ctx1.ResourceMgmt(); err != nil {
+		t.Fatalf("Unexpected error creating resource management client: %s", err)
+	}
+	if _, err := ctx1.ResourceMgmt(); err != nil {
+		t.Fatalf("Unexpected error creating resource management client: %s", err)
+	}
+	id1, err := ctx1.Identity()
+	if err != nil {
+		t.Fatalf("Unexpected error getting identity: %s", err)
+	}
+	if loads := store.loadCount(); loads != 1 {
+		t.Fatalf("Expected the identity to be cached on the context, got %d loads", loads)
+	}
+
+	id2, err := ctx2.Identity()
+	if err != nil {
+		t.Fatalf("Unexpected error getting identity: %s", err)
+	}
+	if id1.MspID() != "Org1MSP" || id2.MspID() != "Org2MSP" {
+		t.Fatalf("Expected the identities of the contexts' organizations, got [%s] and [%s]", id1.MspID(), id2.MspID())
+	}
+	if loads := store.loadCount(); loads != 2 {
+		t.Fatalf("Expected each context to load its own identity, got %d loads", loads)
+	}
+
+	// Closing a context doesn't affect the other context
+	if err := ctx1.Close(); err != nil {
+		t.Fatalf("Unexpected error closing context: %s", err)
+	}
+	if _, err := ctx1.ResourceMgmt(); err == nil || !strings.Contains(err.Error(), "context is closed") {
+		t.Fatalf("Expected closed context error, got %v", err)
+	}
+	if _, err := ctx2.ResourceMgmt(); err != nil {
+		t.Fatalf("Unexpected error creating resource management client after closing another context: %s", err)
+	}
+	id, err := ctx2.Identity()
+	if err != nil || id != id2 {
+		t.Fatalf("Expected the cached identity to be kept after closing another context: %v", err)
+	}
+	if err := ctx2.Close(); err != nil {
+		t.Fatalf("Unexpected error closing context: %s", err)
+	}
+}
+
+func TestContextErrors(t *testing.T) {
+	store := newMockCredentialStore()
+	store.add(sdkValidClientOrg1, sdkValidClientUser, "Org1MSP")
+	store.addNotEnrolled(sdkValidClientOrg1, contextNotEnrolledUser, "Org1MSP")
+
+	sdk, err := New(configImpl.FromFile(sdkConfigFile), WithContextPkg(store))
+	if err != nil {
+		t.Fatalf("Error initializing SDK: %s", err)
+	}
+
+	_, err = sdk.Context(WithUser(sdkValidClientUser), WithOrg(contextUnknownOrg)).Identity()
+	if _, ok := errors.Cause(err).(*UnknownOrgError); !ok {
+		t.Fatalf("Expected unknown org error, got %v", err)
+	}
+
+	_, err = sdk.Context(WithUser(contextUnknownUser), WithOrg(sdkValidClientOrg1)).Identity()
+	if e, ok := errors.Cause(err).(*UnknownUserError); !ok || e.User != contextUnknownUser {
+		t.Fatalf("Expected unknown user error, got %v", err)
+	}
+
+	// The enrollment is checked again after a failure
+	ctx := sdk.Context(WithUser(contextNotEnrolledUser), WithOrg(sdkValidClientOrg1))
+	_, err = ctx.Identity()
+	if _, ok := errors.Cause(err).(*NotEnrolledError); !ok {
+		t.Fatalf("Expected not enrolled error, got %v", err)
+	}
+	store.add(sdkValidClientOrg1, contextNotEnrolledUser, "Org1MSP")
+	if _, err := ctx.Identity(); err != nil {
+		t.Fatalf("Unexpected error getting identity after enrollment: %s", err)
+	}
+}
+
+// mockCredentialStore is an org client factory whose credential managers
+// return the signing identities that were added to the store
+type mockCredentialStore struct {
+	mutex      sync.Mutex
+	identities map[string]map[string]*api.SigningIdentity
+	enrolled   map[string]time.Time
+	loads      int
+}
+
+func newMockCredentialStore() *mockCredentialStore {
+	return &mockCredentialStore{identities: make(map[string]map[string]*api.SigningIdentity), enrolled: make(map[string]time.Time)}
+}
+
+func (s *mockCredentialStore) add(org, user, mspID string) {
+	s.put(org, user, &api.SigningIdentity{MspID: mspID, EnrollmentCert: []byte("cert-" + user), PrivateKey: &mockKey{}})
+}
+
+func (s *mockCredentialStore) addNotEnrolled(org, user, mspID string) {
+	s.put(org, user, &api.SigningIdentity{MspID: mspID})
+}
+
+func (s *mockCredentialStore) put(org, user string, identity *api.SigningIdentity) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	org = strings.ToLower(org)
+	if s.identities[org] == nil {
+		s.identities[org] = make(map[string]*api.SigningIdentity)
+	}
+	s.identities[org][user] = identity
+	s.enrolled[org+"/"+user] = time.Now()
+}
+
+func (s *mockCredentialStore) loadCount() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.loads
+}
+
+func (s *mockCredentialStore) CreateCredentialManager(orgName string, config core.Config, cryptoProvider core.CryptoSuite) (api.CredentialManager, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, ok := s.identities[strings.ToLower(orgName)]; !ok {
+		return nil, errors.New("org config retrieval failed")
+	}
+	return &mockCredentialManager{store: s, org: strings.ToLower(orgName)}, nil
+}
+
+type mockCredentialManager struct {
+	store *mockCredentialStore
+	org   string
+}
+
+func (m *mockCredentialManager) GetSigningIdentity(name string) (*api.SigningIdentity, error) {
+	m.store.mutex.Lock()
+	defer m.store.mutex.Unlock()
+	m.store.loads++
+	identity, ok := m.store.identities[m.org][name]
+	if !ok {
+		return nil, api.ErrUserNotFound
+	}
+	return identity, nil
+}
+
+// EnrollmentTime returns the time at which the user was added to the store
+func (m *mockCredentialManager) EnrollmentTime(name string) (time.Time, error) {
+	m.store.mutex.Lock()
+	defer m.store.mutex.Unlock()
+	return m.store.enrolled[m.org+"/"+name], nil
+}
+
+type mockKey struct{}
+
+func (k *mockKey) Bytes() ([]byte, error)       { return nil, nil }
+func (k *mockKey) SKI() []byte                  { return nil }
+func (k *mockKey) Symmetric() bool              { return false }
+func (k *mockKey) Private() bool                { return true }
+func (k *mockKey) PublicKey() (core.Key, error) { return k, nil }
@@ -7,9 +7,11 @@ SPDX-License-Identifier: Apache-2.0
 package chpvdr
 
 import (
+	"sort"
 	"sync"
 
 	"github.com/hyperledger/fabric-sdk-go/pkg/context"
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/chconfig"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk/api"
@@ -24,6 +26,31 @@ import (
 type ChannelProvider struct {
 	fabricProvider api.FabricProvider
 	chCfgMap       sync.Map
+	identities     sync.Map
+}
+
+// ChannelInfo is a snapshot of the state held by the ChannelProvider for a channel.
+// Config is nil if the channel configuration hasn't been loaded.
+type ChannelInfo struct {
+	ChannelID  string
+	Config     fab.ChannelCfg
+	Identities []IdentityInfo
+}
+
+// IdentityInfo describes an identity for which a ChannelService has been created
+type IdentityInfo struct {
+	MspID string
+	Name  string
+}
+
+type channelIdentity struct {
+	channelID string
+	IdentityInfo
+}
+
+// namedIdentity is implemented by identities that have a name (e.g. users)
+type namedIdentity interface {
+	Name() string
 }
 
 // New creates a ChannelProvider based on a context
@@ -58,6 +85,10 @@ func (cp *ChannelProvider) ChannelService(ic context.IdentityContext, channelID
 		cfg = chconfig.NewChannelCfg("")
 	}
 
+	if channelID != "" {
+		cp.identities.Store(channelIdentity{channelID: channelID, IdentityInfo: newIdentityInfo(ic)}, struct{}{})
+	}
+
 	cs := ChannelService{
 		provider:        cp,
 		fabricProvider:  cp.fabricProvider,
@@ -68,6 +99,64 @@ func (cp *ChannelProvider) ChannelService(ic context.IdentityContext, channelID
 	return &cs, nil
 }
 
+// HandleConfigUpdate clears the cached channel configurations when the SDK configuration is reloaded, so
+// that the channel configuration is queried again (using the reloaded peers and orderers) by the channel
+// services that are created subsequently. Existing channel services are unaffected.
+func (cp *ChannelProvider) HandleConfigUpdate(event *core.ConfigUpdatedEvent) {
+	cp.chCfgMap.Range(func(key, value interface{}) bool {
+		cp.chCfgMap.Delete(key)
+		return true
+	})
+}
+
+// Channels returns a snapshot of the channels for which a channel configuration is cached
+// or a ChannelService has been created. Only state that is already held by the provider is
+// returned, i.e. channel configuration is never queried.
+func (cp *ChannelProvider) Channels() []ChannelInfo {
+	channels := make(map[string]*ChannelInfo)
+	channelInfo := func(channelID string) *ChannelInfo {
+		info, ok := channels[channelID]
+		if !ok {
+			info = &ChannelInfo{ChannelID: channelID}
+			channels[channelID] = info
+		}
+		return info
+	}
+
+	cp.chCfgMap.Range(func(key, value interface{}) bool {
+		channelInfo(key.(string)).Config = value.(fab.ChannelCfg)
+		return true
+	})
+	cp.identities.Range(func(key, value interface{}) bool {
+		id := key.(channelIdentity)
+		info := channelInfo(id.channelID)
+		info.Identities = append(info.Identities, id.IdentityInfo)
+		return true
+	})
+
+	var infos []ChannelInfo
+	for _, info := range channels {
+		sort.Slice(info.Identities, func(i, j int) bool {
+			if info.Identities[i].MspID != info.Identities[j].MspID {
+				return info.Identities[i].MspID < info.Identities[j].MspID
+			}
+			return info.Identities[i].Name < info.Identities[j].Name
+		})
+		infos = append(infos, *info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].ChannelID < infos[j].ChannelID })
+
+	return infos
+}
+
+func newIdentityInfo(ic context.IdentityContext) IdentityInfo {
+	info := IdentityInfo{MspID: ic.MspID()}
+	if ni, ok := ic.(namedIdentity); ok {
+		info.Name = ni.Name()
+	}
+	return info
+}
+
 // ChannelService provides Channel clients and maintains contexts for them.
 // the identity context is used
 //
@@ -10,6 +10,7 @@ import (
 	"testing"
 
 	"github.com/hyperledger/fabric-sdk-go/pkg/context"
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
 	channelImpl "github.com/hyperledger/fabric-sdk-go/pkg/fab/channel"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/chconfig"
@@ -105,3 +106,79 @@ func (f *MockProviderFactory) CreateFabricProvider(context context.ProviderConte
 	}
 	return &cfp, nil
 }
+
+func TestChannels(t *testing.T) {
+	ctx := mocks.NewMockProviderContext()
+	pf := &MockProviderFactory{}
+
+	fp, err := pf.CreateFabricProvider(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error creating Fabric Provider: %v", err)
+	}
+
+	cp, err := New(fp)
+	if err != nil {
+		t.Fatalf("Unexpected error creating Channel Provider: %v", err)
+	}
+
+	if len(cp.Channels()) != 0 {
+		t.Fatalf("Expecting no channels")
+	}
+
+	for _, name := range []string{"user2", "user1", "user1"} {
+		if _, err := cp.ChannelService(mocks.NewMockUser(name), "mychannel"); err != nil {
+			t.Fatalf("Unexpected error creating Channel Service: %v", err)
+		}
+	}
+
+	// System channel isn't reported
+	if _, err := cp.ChannelService(mocks.NewMockUser("user1"), ""); err != nil {
+		t.Fatalf("Unexpected error creating Channel Service: %v", err)
+	}
+
+	channels := cp.Channels()
+	if len(channels) != 1 {
+		t.Fatalf("Expecting one channel but got %d", len(channels))
+	}
+	if channels[0].ChannelID != "mychannel" || channels[0].Config == nil {
+		t.Fatalf("Unexpected channel info: %+v", channels[0])
+	}
+	if len(channels[0].Identities) != 2 || channels[0].Identities[0].Name != "user1" || channels[0].Identities[1].Name != "user2" {
+		t.Fatalf("Unexpected identities: %+v", channels[0].Identities)
+	}
+}
+
+func TestHandleConfigUpdate(t *testing.T) {
+	ctx := mocks.NewMockProviderContext()
+	pf := &MockProviderFactory{}
+
+	fp, err := pf.CreateFabricProvider(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error creating Fabric Provider: %v", err)
+	}
+
+	cp, err := New(fp)
+	if err != nil {
+		t.Fatalf("Unexpected error creating Channel Provider: %v", err)
+	}
+
+	if _, err := cp.ChannelService(mocks.NewMockUser("user1"), "mychannel"); err != nil {
+		t.Fatalf("Unexpected error creating Channel Service: %v", err)
+	}
+	if channels := cp.Channels(); len(channels) != 1 || channels[0].Config == nil {
+		t.Fatalf("Expecting the channel configuration to be cached")
+	}
+
+	cp.HandleConfigUpdate(&core.ConfigUpdatedEvent{Config: ctx.Config()})
+
+	// The channel configuration is queried again by the next channel service
+	if channels := cp.Channels(); len(channels) != 1 || channels[0].Config != nil {
+		t.Fatalf("Expecting the cached channel configuration to be cleared")
+	}
+	if _, err := cp.ChannelService(mocks.NewMockUser("user1"), "mychannel"); err != nil {
+		t.Fatalf("Unexpected error creating Channel Service: %v", err)
+	}
+	if channels := cp.Channels(); channels[0].Config == nil {
+		t.Fatalf("Expecting the channel configuration to be cached again")
+	}
+}
@@ -7,25 +7,34 @@ SPDX-License-Identifier: Apache-2.0
 package fabpvdr
 
 import (
+	"sync"
+
 	"github.com/hyperledger/fabric-sdk-go/pkg/context"
 	contextApi "github.com/hyperledger/fabric-sdk-go/pkg/context/api"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/errors/multi"
 	channelImpl "github.com/hyperledger/fabric-sdk-go/pkg/fab/channel"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/chconfig"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/deliverclient"
 	identityImpl "github.com/hyperledger/fabric-sdk-go/pkg/fab/identity"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/identitymgr"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/orderer"
 	peerImpl "github.com/hyperledger/fabric-sdk-go/pkg/fab/peer"
 	clientImpl "github.com/hyperledger/fabric-sdk-go/pkg/fab/resource"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/resource/api"
+	"github.com/hyperledger/fabric-sdk-go/pkg/options"
 	"github.com/pkg/errors"
 )
 
 // FabricProvider represents the default implementation of Fabric objects.
 type FabricProvider struct {
 	providerContext context.ProviderContext
+	identityMgrOpts []identitymgr.Option
+
+	mutex   sync.Mutex
+	clients map[interface{}]func() error
 }
 
 type fabContext struct {
@@ -37,6 +46,7 @@ type fabContext struct {
 func New(ctx context.ProviderContext) *FabricProvider {
 	f := FabricProvider{
 		providerContext: ctx,
+		clients:         make(map[interface{}]func() error),
 	}
 	return &f
 }
@@ -104,7 +114,92 @@ func (f *FabricProvider) CreateEventHub(ic context.IdentityContext, channelID st
 		ProviderContext: f.providerContext,
 		IdentityContext: ic,
 	}
-	return events.FromConfig(eventCtx, &eventSource.PeerConfig)
+	eventHub, err := events.FromConfig(eventCtx, &eventSource.PeerConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	// The event hub is disconnected when the provider is closed, unless it was disconnected before
+	return &trackedEventHub{EventHub: eventHub, provider: f}, nil
+}
+
+// CreateEventService returns a new event service client that receives the events of the channel from
+// the peers of the discovery service. The client is closed when the provider is closed.
+func (f *FabricProvider) CreateEventService(ic context.IdentityContext, channelID string, discovery fab.DiscoveryService, opts ...options.Opt) (fab.EventService, error) {
+	ctx := &fabContext{
+		ProviderContext: f.providerContext,
+		IdentityContext: ic,
+	}
+	eventService, err := deliverclient.New(ctx, channelID, discovery, opts...)
+	if err != nil {
+		return nil, errors.WithMessage(err, "creating event service failed")
+	}
+
+	f.track(eventService, func() error {
+		eventService.Close()
+		return nil
+	})
+	eventService.AddAfterCloseHandler(func() { f.untrack(eventService) })
+	if eventService.Stopped() {
+		// The client was closed before the handler was added
+		f.untrack(eventService)
+	}
+
+	return eventService, nil
+}
+
+// Close closes the event service clients and disconnects the event hubs that were created by the
+// provider and that are still open.
+func (f *FabricProvider) Close() error {
+	f.mutex.Lock()
+	clients := f.clients
+	f.clients = make(map[interface{}]func() error)
+	f.mutex.Unlock()
+
+	var errs multi.Errors
+	for _, closeClient := range clients {
+		if err := closeClient(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs.ToError()
+}
+
+// track records a client that's closed by Close
+func (f *FabricProvider) track(client interface{}, closeClient func() error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.clients[client] = closeClient
+}
+
+// untrack forgets a client that was closed (or disconnected) by the application
+func (f *FabricProvider) untrack(client interface{}) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	delete(f.clients, client)
+}
+
+// trackedEventHub is tracked by the provider while it's connected
+type trackedEventHub struct {
+	fab.EventHub
+	provider *FabricProvider
+}
+
+// Connect connects the event hub, which is disconnected when the provider is closed
+func (h *trackedEventHub) Connect() error {
+	if err := h.EventHub.Connect(); err != nil {
+		return err
+	}
+	h.provider.track(h, func() error {
+		return errors.WithMessage(h.EventHub.Disconnect(), "event hub disconnect failed")
+	})
+	return nil
+}
+
+// Disconnect disconnects the event hub, which is no longer tracked by the provider
+func (h *trackedEventHub) Disconnect() error {
+	h.provider.untrack(h)
+	return h.EventHub.Disconnect()
 }
 
 // CreateChannelConfig initializes the channel config
@@ -131,7 +226,19 @@ func (f *FabricProvider) CreateChannelTransactor(ic context.IdentityContext, cfg
 
 // CreateIdentityManager returns a new IdentityManager for an organization
 func (f *FabricProvider) CreateIdentityManager(orgID string) (fab.IdentityManager, error) {
-	return identitymgr.New(orgID, f.providerContext.Config(), f.providerContext.CryptoSuite())
+	// the authorization tokens of the CA are signed by the signing manager of the SDK
+	var opts []identitymgr.Option
+	if signingMgr := f.providerContext.SigningManager(); signingMgr != nil {
+		opts = append(opts, identitymgr.WithSigningManager(signingMgr))
+	}
+	opts = append(opts, f.identityMgrOpts...)
+	return identitymgr.New(orgID, f.providerContext.Config(), f.providerContext.CryptoSuite(), opts...)
+}
+
+// SetIdentityManagerOptions sets the options that are used when creating an IdentityManager
+// (for example, a custom HTTP transport or request hooks for the CA client)
+func (f *FabricProvider) SetIdentityManagerOptions(opts ...identitymgr.Option) {
+	f.identityMgrOpts = opts
 }
 
 // CreateUser returns a new default implementation of a User.
@@ -147,12 +254,12 @@ func (f *FabricProvider) CreateUser(name string, signingIdentity *contextApi.Sig
 
 // CreatePeerFromConfig returns a new default implementation of Peer based configuration
 func (f *FabricProvider) CreatePeerFromConfig(peerCfg *core.NetworkPeer) (fab.Peer, error) {
-	return peerImpl.New(f.providerContext.Config(), peerImpl.FromPeerConfig(peerCfg))
+	return peerImpl.New(f.providerContext.Config(), peerImpl.FromPeerConfig(peerCfg), peerImpl.WithConnector(f.providerContext.Connector()))
 }
 
 // CreateOrdererFromConfig creates a default implementation of Orderer based on configuration.
 func (f *FabricProvider) CreateOrdererFromConfig(cfg *core.OrdererConfig) (fab.Orderer, error) {
-	orderer, err := orderer.New(f.providerContext.Config(), orderer.FromOrdererConfig(cfg))
+	orderer, err := orderer.New(f.providerContext.Config(), orderer.FromOrdererConfig(cfg), orderer.WithConnector(f.providerContext.Connector()))
 	if err != nil {
 		return nil, errors.WithMessage(err, "creating orderer failed")
 	}
@@ -8,6 +8,7 @@ package fabpvdr
 
 import (
 	"fmt"
+	"net"
 	"testing"
 
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
@@ -15,11 +16,16 @@ import (
 	"github.com/hyperledger/fabric-sdk-go/pkg/core/config"
 	"github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite/bccsp/sw"
 	channelImpl "github.com/hyperledger/fabric-sdk-go/pkg/fab/channel"
+	clientmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/events/client/mocks"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/events/deliverclient"
+	eventmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/events/mocks"
 	identityImpl "github.com/hyperledger/fabric-sdk-go/pkg/fab/identity"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/identitymgr"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
 	peerImpl "github.com/hyperledger/fabric-sdk-go/pkg/fab/peer"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/resource"
+	pb "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/peer"
+	"google.golang.org/grpc"
 )
 
 func TestCreateFabricProvider(t *testing.T) {
@@ -144,6 +150,54 @@ func TestCreateUser(t *testing.T) {
 	}
 }
 
+func TestCloseEventServices(t *testing.T) {
+	grpcServer := grpc.NewServer()
+	defer grpcServer.Stop()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error starting deliver server: %s", err)
+	}
+	pb.RegisterDeliverServer(grpcServer, eventmocks.NewMockDeliverServer())
+	go grpcServer.Serve(lis)
+
+	p := newMockFabricProvider(t)
+	discovery := clientmocks.NewDiscoveryService(mocks.NewMockPeer("peer1", "grpc://"+lis.Addr().String()))
+
+	var eventServices []*deliverclient.Client
+	for i := 0; i < 2; i++ {
+		eventService, err := p.CreateEventService(mocks.NewMockUser("user"), "mychannel", discovery)
+		if err != nil {
+			t.Fatalf("Unexpected error creating event service %v", err)
+		}
+		eventServices = append(eventServices, eventService.(*deliverclient.Client))
+	}
+	if n := numClients(p); n != 2 {
+		t.Fatalf("Expected 2 clients to be tracked but got %d", n)
+	}
+
+	// A client that's closed by the application is no longer tracked
+	eventServices[0].Close()
+	if n := numClients(p); n != 1 {
+		t.Fatalf("Expected 1 client to be tracked once a client is closed but got %d", n)
+	}
+
+	if err := p.Close(); err != nil {
+		t.Fatalf("Unexpected error closing provider %v", err)
+	}
+	if !eventServices[1].Stopped() {
+		t.Fatal("Expected the event service to be closed when the provider is closed")
+	}
+	if n := numClients(p); n != 0 {
+		t.Fatalf("Expected no clients to be tracked once the provider is closed but got %d", n)
+	}
+}
+
+func numClients(p *FabricProvider) int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return len(p.clients)
+}
+
 func newMockFabricProvider(t *testing.T) *FabricProvider {
 	cfg, err := config.FromFile("../../../../test/fixtures/config/config_test.yaml")()
 	if err != nil {
@@ -0,0 +1,88 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package fabsdk
+
+import (
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/context"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk/factory/defclient"
+)
+
+// sessionCacheSetter is implemented by session factories that can cache sessions (see WithSessionCache)
+type sessionCacheSetter interface {
+	SetSessionCache(size int, ttl time.Duration)
+}
+
+// sessionCacheProvider is implemented by session factories that cache sessions
+type sessionCacheProvider interface {
+	SessionCache() *defclient.SessionCache
+}
+
+// enrollmentTimer is implemented by credential managers that know when a user was last enrolled
+type enrollmentTimer interface {
+	EnrollmentTime(userName string) (time.Time, error)
+}
+
+// WithSessionCache caches the identities of the users that are loaded from the credential stores, so that the
+// user's certificate and private key aren't read again for each client. Up to size identities are cached for ttl
+// (a ttl of zero means that identities don't expire). A cached identity is discarded when the credential store
+// reports that the user was enrolled again, and may be discarded explicitly with InvalidateSession.
+// The option has no effect if the session factory doesn't support caching.
+func WithSessionCache(size int, ttl time.Duration) Option {
+	return func(opts *options) error {
+		opts.SessionCacheSize = size
+		opts.SessionCacheTTL = ttl
+		return nil
+	}
+}
+
+// InvalidateSession discards the cached identity of the user of the organization (see WithSessionCache),
+// e.g. after the user's credentials have been replaced in the credential store.
+func (sdk *FabricSDK) InvalidateSession(orgID string, userName string) {
+	if cache := sdk.sessionCache(); cache != nil {
+		cache.Invalidate(orgID, userName)
+	}
+}
+
+// sessionCache returns the session cache of the session factory, or nil if sessions aren't cached
+func (sdk *FabricSDK) sessionCache() *defclient.SessionCache {
+	if p, ok := sdk.opts.Session.(sessionCacheProvider); ok {
+		return p.SessionCache()
+	}
+	return nil
+}
+
+// cachedUser is an identity that's held by the session cache. The credential manager that loaded the identity
+// (if it knows when users are enrolled) is kept so that the identity is discarded when the user is enrolled again.
+type cachedUser struct {
+	identity context.IdentityContext
+	timer    enrollmentTimer
+	enrolled time.Time
+}
+
+// reenrolled returns true if the user was enrolled again since the identity was loaded
+func (u *cachedUser) reenrolled(userName string) bool {
+	if u.timer == nil {
+		return false
+	}
+	enrolled, err := u.timer.EnrollmentTime(userName)
+	return err != nil || !enrolled.Equal(u.enrolled)
+}
+
+// newCachedUser returns the cache entry for an identity. The enrollment time is read before the identity is
+// loaded, so that an enrollment that happens while the identity is loaded causes the identity to be reloaded.
+func newCachedUser(credentialMgr interface{}, userName string) *cachedUser {
+	u := &cachedUser{}
+	if timer, ok := credentialMgr.(enrollmentTimer); ok {
+		if enrolled, err := timer.EnrollmentTime(userName); err == nil {
+			u.timer = timer
+			u.enrolled = enrolled
+		}
+	}
+	return u
+}
@@ -0,0 +1,115 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package fabsdk
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/context"
+	configImpl "github.com/hyperledger/fabric-sdk-go/pkg/core/config"
+)
+
+func TestSessionCache(t *testing.T) {
+	store := newMockCredentialStore()
+	store.add(sdkValidClientOrg1, sdkValidClientUser, "Org1MSP")
+
+	sdk, err := New(configImpl.FromFile(sdkConfigFile), WithContextPkg(store), WithSessionCache(10, time.Minute))
+	if err != nil {
+		t.Fatalf("Error initializing SDK: %s", err)
+	}
+
+	// Cache hit
+	id1 := loadUser(t, sdk, sdkValidClientUser)
+	id2 := loadUser(t, sdk, sdkValidClientUser)
+	if id1 != id2 || store.loadCount() != 1 {
+		t.Fatalf("Expected the identity to be cached, got %d loads", store.loadCount())
+	}
+	if _, err := sdk.NewClient(WithUser(sdkValidClientUser)).ResourceMgmt(); err != nil {
+		t.Fatalf("Unexpected error creating resource management client: %s", err)
+	}
+	if store.loadCount() != 1 {
+		t.Fatalf("Expected the clients to use the cached identity, got %d loads", store.loadCount())
+	}
+
+	// Explicit invalidation
+	sdk.InvalidateSession(sdkValidClientOrg1, sdkValidClientUser)
+	loadUser(t, sdk, sdkValidClientUser)
+	if store.loadCount() != 2 {
+		t.Fatalf("Expected the identity to be loaded again after invalidation, got %d loads", store.loadCount())
+	}
+
+	// Re-enrollment
+	time.Sleep(time.Millisecond)
+	store.add(sdkValidClientOrg1, sdkValidClientUser, "Org1MSP")
+	loadUser(t, sdk, sdkValidClientUser)
+	if store.loadCount() != 3 {
+		t.Fatalf("Expected the identity to be loaded again after re-enrollment, got %d loads", store.loadCount())
+	}
+	loadUser(t, sdk, sdkValidClientUser)
+	if store.loadCount() != 3 {
+		t.Fatalf("Expected the re-enrolled identity to be cached, got %d loads", store.loadCount())
+	}
+
+	// Reloaded configuration
+	if err := sdk.Config().(*configImpl.Config).Reload(); err != nil {
+		t.Fatalf("Unexpected error reloading the configuration: %s", err)
+	}
+	loadUser(t, sdk, sdkValidClientUser)
+	if store.loadCount() != 4 {
+		t.Fatalf("Expected the identity to be loaded again after the configuration is reloaded, got %d loads", store.loadCount())
+	}
+}
+
+func TestSessionCacheTTL(t *testing.T) {
+	store := newMockCredentialStore()
+	store.add(sdkValidClientOrg1, sdkValidClientUser, "Org1MSP")
+
+	sdk, err := New(configImpl.FromFile(sdkConfigFile), WithContextPkg(store), WithSessionCache(10, 50*time.Millisecond))
+	if err != nil {
+		t.Fatalf("Error initializing SDK: %s", err)
+	}
+
+	loadUser(t, sdk, sdkValidClientUser)
+	loadUser(t, sdk, sdkValidClientUser)
+	if store.loadCount() != 1 {
+		t.Fatalf("Expected the identity to be cached, got %d loads", store.loadCount())
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	loadUser(t, sdk, sdkValidClientUser)
+	if store.loadCount() != 2 {
+		t.Fatalf("Expected the identity to be loaded again after the TTL expired, got %d loads", store.loadCount())
+	}
+}
+
+func TestNoSessionCache(t *testing.T) {
+	store := newMockCredentialStore()
+	store.add(sdkValidClientOrg1, sdkValidClientUser, "Org1MSP")
+
+	sdk, err := New(configImpl.FromFile(sdkConfigFile), WithContextPkg(store))
+	if err != nil {
+		t.Fatalf("Error initializing SDK: %s", err)
+	}
+
+	loadUser(t, sdk, sdkValidClientUser)
+	loadUser(t, sdk, sdkValidClientUser)
+	if store.loadCount() != 2 {
+		t.Fatalf("Expected the identity to be loaded each time when sessions aren't cached, got %d loads", store.loadCount())
+	}
+
+	// Invalidating a session has no effect
+	sdk.InvalidateSession(sdkValidClientOrg1, sdkValidClientUser)
+}
+
+func loadUser(t *testing.T, sdk *FabricSDK, userName string) context.IdentityContext {
+	identity, err := sdk.newUser(sdkValidClientOrg1, userName)
+	if err != nil {
+		t.Fatalf("Unexpected error loading identity: %s", err)
+	}
+	return identity
+}
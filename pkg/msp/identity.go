@@ -0,0 +1,155 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package msp creates identities of an MSP from certificates and private keys held in memory, without
+// any file based MSP or credential store. A signing identity can be used as the identity of a session,
+// e.g. sdk.NewClient(fabsdk.WithIdentity(signingIdentity)). It signs with the signing manager it's given,
+// typically the SDK's (see FabricSDK.SigningManager), so that it uses the configured signer and hash.
+package msp
+
+import (
+	"bytes"
+	"encoding/pem"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger/fabric-sdk-go/internal/github.com/hyperledger/fabric-ca/util"
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api"
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config/cryptoutil"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite"
+	pb_msp "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/msp"
+)
+
+// Identity is an identity of an MSP: the MSP ID and the X.509 certificate of the identity
+type Identity interface {
+	MspID() string
+	// EnrollmentCertificate returns the PEM encoded certificate of the identity
+	EnrollmentCertificate() []byte
+	// Identity returns the identity serialized as SerializedIdentity
+	Identity() ([]byte, error)
+}
+
+// SigningIdentity is an identity with the private key of its certificate
+type SigningIdentity interface {
+	Identity
+	// PrivateKey returns the crypto suite representation of the private key
+	PrivateKey() core.Key
+	// Sign signs the hash of the message with the private key
+	Sign(msg []byte) ([]byte, error)
+}
+
+type identity struct {
+	mspID string
+	cert  []byte
+}
+
+type signingIdentity struct {
+	identity
+	privateKey core.Key
+	signingMgr api.SigningManager
+}
+
+// NewIdentity returns the identity of the MSP with the given PEM encoded certificate
+func NewIdentity(mspID string, cert []byte) (Identity, error) {
+	id, err := newIdentity(mspID, cert)
+	if err != nil {
+		return nil, err
+	}
+	return id, nil
+}
+
+// NewSigningIdentity returns the signing identity of the MSP with the given PEM encoded certificate and
+// private key of the default crypto suite (the crypto suite configured by the SDK). The messages are signed
+// with the given signing manager (see FabricSDK.SigningManager).
+func NewSigningIdentity(mspID string, cert []byte, privateKey core.Key, signingMgr api.SigningManager) (SigningIdentity, error) {
+	return newSigningIdentity(mspID, cert, privateKey, cryptosuite.GetDefault(), signingMgr)
+}
+
+// NewSigningIdentityFromPEM returns the signing identity of the MSP with the given PEM encoded certificate
+// and private key. The key is imported into the default crypto suite as an ephemeral key, i.e. it isn't
+// stored in the key store. The messages are signed with the given signing manager (see FabricSDK.SigningManager).
+func NewSigningIdentityFromPEM(mspID string, cert []byte, keyPEM []byte, signingMgr api.SigningManager) (SigningIdentity, error) {
+	cs := cryptosuite.GetDefault()
+	privateKey, err := util.ImportBCCSPKeyFromPEMBytes(keyPEM, cs, true)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to import private key")
+	}
+	return newSigningIdentity(mspID, cert, privateKey, cs, signingMgr)
+}
+
+func newIdentity(mspID string, cert []byte) (*identity, error) {
+	if mspID == "" {
+		return nil, errors.New("MSP ID is required")
+	}
+	block, _ := pem.Decode(cert)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errors.New("certificate must be PEM encoded")
+	}
+	return &identity{mspID: mspID, cert: cert}, nil
+}
+
+func newSigningIdentity(mspID string, cert []byte, privateKey core.Key, cs core.CryptoSuite, signingMgr api.SigningManager) (*signingIdentity, error) {
+	id, err := newIdentity(mspID, cert)
+	if err != nil {
+		return nil, err
+	}
+	if privateKey == nil || !privateKey.Private() {
+		return nil, errors.New("private key is required")
+	}
+	if signingMgr == nil {
+		return nil, errors.New("signing manager is required")
+	}
+
+	// the private key must belong to the certificate
+	publicKey, err := cryptoutil.GetPublicKeyFromCert(cert, cs)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get public key of certificate")
+	}
+	if !bytes.Equal(publicKey.SKI(), privateKey.SKI()) {
+		return nil, errors.New("private key doesn't match the certificate")
+	}
+
+	return &signingIdentity{identity: *id, privateKey: privateKey, signingMgr: signingMgr}, nil
+}
+
+// MspID returns the MSP ID of the identity
+func (id *identity) MspID() string {
+	return id.mspID
+}
+
+// EnrollmentCertificate returns the PEM encoded certificate of the identity
+func (id *identity) EnrollmentCertificate() []byte {
+	return id.cert
+}
+
+// Identity returns the identity serialized as SerializedIdentity
+func (id *identity) Identity() ([]byte, error) {
+	serializedIdentity := &pb_msp.SerializedIdentity{Mspid: id.mspID, IdBytes: id.cert}
+	identity, err := proto.Marshal(serializedIdentity)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal serializedIdentity failed")
+	}
+	return identity, nil
+}
+
+// PrivateKey returns the crypto suite representation of the private key
+func (id *signingIdentity) PrivateKey() core.Key {
+	return id.privateKey
+}
+
+// Sign signs the hash of the message with the private key, using the signing manager of the identity
+func (id *signingIdentity) Sign(msg []byte) ([]byte, error) {
+	if len(msg) == 0 {
+		return nil, errors.New("message (to sign) required")
+	}
+	signature, err := id.signingMgr.Sign(msg, id.privateKey)
+	if err != nil {
+		return nil, errors.WithMessage(err, "signing of message failed")
+	}
+	return signature, nil
+}
@@ -0,0 +1,151 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package msp
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/context"
+	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config/cryptoutil"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config/cryptoutil/testutils"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/signingmgr"
+	pb_msp "github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/protos/msp"
+)
+
+const mspID = "Org1MSP"
+
+// countingSigningManager counts the signatures of a signing manager that signs with the default crypto suite
+type countingSigningManager struct {
+	signingMgr *signingmgr.SigningManager
+	count      int
+}
+
+func newCountingSigningManager(t *testing.T) *countingSigningManager {
+	signingMgr, err := signingmgr.NewWithSigner(signingmgr.NewCryptoSuiteSigner(cryptosuite.GetDefault(), cryptosuite.GetSHAOpts()))
+	if err != nil {
+		t.Fatalf("Failed to create signing manager: %s", err)
+	}
+	return &countingSigningManager{signingMgr: signingMgr}
+}
+
+func (m *countingSigningManager) Sign(object []byte, key core.Key) ([]byte, error) {
+	m.count++
+	return m.signingMgr.Sign(object, key)
+}
+
+func TestNewIdentity(t *testing.T) {
+	cert := testutils.NewClientCert(t, "user1").CertPEM
+
+	id, err := NewIdentity(mspID, cert)
+	if err != nil {
+		t.Fatalf("NewIdentity failed: %s", err)
+	}
+	assert.Equal(t, mspID, id.MspID())
+	assert.Equal(t, cert, id.EnrollmentCertificate())
+	verifySerializedIdentity(t, id, cert)
+
+	_, err = NewIdentity("", cert)
+	assert.NotNil(t, err, "expected an error without MSP ID")
+	_, err = NewIdentity(mspID, []byte("invalid"))
+	assert.NotNil(t, err, "expected an error for a certificate that isn't PEM encoded")
+}
+
+func TestNewSigningIdentityFromPEM(t *testing.T) {
+	user := testutils.NewClientCert(t, "user1")
+	cert, keyPEM := user.CertPEM, user.KeyPEM
+
+	signingMgr := newCountingSigningManager(t)
+	id, err := NewSigningIdentityFromPEM(mspID, cert, keyPEM, signingMgr)
+	if err != nil {
+		t.Fatalf("NewSigningIdentityFromPEM failed: %s", err)
+	}
+	assert.True(t, id.PrivateKey().Private(), "expected the private key")
+	verifySerializedIdentity(t, id, cert)
+	verifySignature(t, id, cert)
+	assert.Equal(t, 1, signingMgr.count, "expected the message to be signed by the signing manager")
+
+	// The signing identity can be used as the identity of a session
+	var _ context.IdentityContext = id
+
+	_, err = NewSigningIdentityFromPEM(mspID, cert, []byte("invalid"), signingMgr)
+	assert.NotNil(t, err, "expected an error for an invalid key")
+	_, err = NewSigningIdentityFromPEM(mspID, cert, keyPEM, nil)
+	assert.NotNil(t, err, "expected an error without signing manager")
+
+	// The key must belong to the certificate
+	otherCert := testutils.NewClientCert(t, "user1").CertPEM
+	_, err = NewSigningIdentityFromPEM(mspID, otherCert, keyPEM, signingMgr)
+	assert.NotNil(t, err, "expected an error for a key of another certificate")
+}
+
+func TestNewSigningIdentity(t *testing.T) {
+	user := testutils.NewClientCert(t, "user1")
+	cert, keyPEM := user.CertPEM, user.KeyPEM
+	signingMgr := newCountingSigningManager(t)
+	pemID, err := NewSigningIdentityFromPEM(mspID, cert, keyPEM, signingMgr)
+	if err != nil {
+		t.Fatalf("NewSigningIdentityFromPEM failed: %s", err)
+	}
+
+	id, err := NewSigningIdentity(mspID, cert, pemID.PrivateKey(), signingMgr)
+	if err != nil {
+		t.Fatalf("NewSigningIdentity failed: %s", err)
+	}
+	verifySerializedIdentity(t, id, cert)
+	verifySignature(t, id, cert)
+	assert.Equal(t, 1, signingMgr.count, "expected the message to be signed by the signing manager")
+
+	_, err = NewSigningIdentity(mspID, cert, nil, signingMgr)
+	assert.NotNil(t, err, "expected an error without private key")
+
+	publicKey, err := cryptoutil.GetPublicKeyFromCert(cert, cryptosuite.GetDefault())
+	assert.Nil(t, err)
+	_, err = NewSigningIdentity(mspID, cert, publicKey, signingMgr)
+	assert.NotNil(t, err, "expected an error for a public key")
+}
+
+func verifySerializedIdentity(t *testing.T, id Identity, cert []byte) {
+	serialized, err := id.Identity()
+	if err != nil {
+		t.Fatalf("Identity failed: %s", err)
+	}
+	serializedIdentity := &pb_msp.SerializedIdentity{}
+	if err := proto.Unmarshal(serialized, serializedIdentity); err != nil {
+		t.Fatalf("Failed to unmarshal serialized identity: %s", err)
+	}
+	assert.Equal(t, mspID, serializedIdentity.Mspid)
+	assert.Equal(t, cert, serializedIdentity.IdBytes)
+}
+
+func verifySignature(t *testing.T, id SigningIdentity, cert []byte) {
+	msg := []byte("message")
+	signature, err := id.Sign(msg)
+	if err != nil {
+		t.Fatalf("Sign failed: %s", err)
+	}
+
+	cs := cryptosuite.GetDefault()
+	publicKey, err := cryptoutil.GetPublicKeyFromCert(cert, cs)
+	if err != nil {
+		t.Fatalf("Failed to get public key: %s", err)
+	}
+	digest, err := cs.Hash(msg, cryptosuite.GetSHAOpts())
+	if err != nil {
+		t.Fatalf("Hash failed: %s", err)
+	}
+	valid, err := cs.Verify(publicKey, signature, digest, nil)
+	assert.Nil(t, err, "Verify failed")
+	assert.True(t, valid, "expected a valid signature")
+
+	_, err = id.Sign(nil)
+	assert.NotNil(t, err, "expected an error without message")
+}
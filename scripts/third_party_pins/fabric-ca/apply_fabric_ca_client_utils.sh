@@ -36,6 +36,8 @@ declare -a FILES=(
     "lib/util.go"
     "lib/serverrevoke.go"
     "lib/sdkpatch_serverstruct.go"
+    "lib/sdkpatch_httphooks.go"
+    "lib/sdkpatch_tokensigner.go"
 
     "lib/tls/tls.go"
 
@@ -73,6 +75,7 @@ FILTERS_ENABLED="fn"
 FILTER_FILENAME="lib/client.go"
 FILTER_FN="Enroll,GenCSR,SendReq,Init,newPost,newEnrollmentResponse,newCertificateRequest"
 FILTER_FN+=",getURL,NormalizeURL,initHTTPClient,net2LocalServerInfo,NewIdentity,newCfsslBasicKeyRequest"
+FILTER_FN+=",clientTLSConfig"
 gofilter
 sed -i'' -e 's/util.GetServerPort()/\"\"/g' "${TMP_PROJECT_PATH}/${FILTER_FILENAME}"
 sed -i'' -e '/log "github.com\// a\
@@ -116,7 +119,7 @@ FILTER_FN="GetCertID,BytesToX509Cert,addQueryParm"
 gofilter
 
 FILTER_FILENAME="lib/tls/tls.go"
-FILTER_FN="GetClientTLSConfig,AbsTLSClient,checkCertDates"
+FILTER_FN="GetClientTLSConfig,AbsTLSClient,checkCertDates,loadClientCertPEM,checkCertPEMDates"
 gofilter
 sed -i'' -e '/log "github.com\// a\
 "github.com\/hyperledger\/fabric-sdk-go\/pkg\/context\/api\/core"\
@@ -126,6 +129,7 @@ sed -i'' -e 's/bccsp.BCCSP/core.CryptoSuite/g' "${TMP_PROJECT_PATH}/${FILTER_FIL
 
 FILTER_FILENAME="util/csp.go"
 FILTER_FN=",getBCCSPKeyOpts,ImportBCCSPKeyFromPEM,LoadX509KeyPair,GetSignerFromCert,BCCSPKeyRequestGenerate"
+FILTER_FN+=",LoadX509KeyPairPEM,loadX509KeyPair"
 gofilter
 sed -i'' -e '/_.\"time\"/d' "${TMP_PROJECT_PATH}/${FILTER_FILENAME}"
 sed -i'' -e '/\"github.com\/cloudflare\/cfssl\/cli\"/d' "${TMP_PROJECT_PATH}/${FILTER_FILENAME}"
@@ -12,6 +12,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/hyperledger/fabric-sdk-go/pkg/context"
 	"github.com/hyperledger/fabric-sdk-go/pkg/context/api/core"
 
 	"github.com/hyperledger/fabric-sdk-go/test/integration"
@@ -60,8 +61,8 @@ func Run(t *testing.T, configOpt core.ConfigProvider, sdkOpts ...fabsdk.Option)
 	orgAdminUser := session
 
 	// Create channel
-	req := resmgmt.SaveChannelRequest{ChannelID: channelID, ChannelConfig: path.Join("../../../", metadata.ChannelConfigPath, "mychannel.tx"), SigningIdentity: orgAdminUser}
-	if err = chMgmtClient.SaveChannel(req); err != nil {
+	req := resmgmt.SaveChannelRequest{ChannelID: channelID, ChannelConfigPath: path.Join("../../../", metadata.ChannelConfigPath, "mychannel.tx"), SigningIdentities: []context.IdentityContext{orgAdminUser}}
+	if _, err = chMgmtClient.SaveChannel(req); err != nil {
 		t.Fatal(err)
 	}
 
@@ -75,7 +76,7 @@ func Run(t *testing.T, configOpt core.ConfigProvider, sdkOpts ...fabsdk.Option)
 	}
 
 	// Org peers join channel
-	if err = orgResMgmt.JoinChannel(channelID); err != nil {
+	if _, err = orgResMgmt.JoinChannel(channelID); err != nil {
 		t.Fatalf("Org peers failed to JoinChannel: %s", err)
 	}
 
@@ -96,7 +97,7 @@ func Run(t *testing.T, configOpt core.ConfigProvider, sdkOpts ...fabsdk.Option)
 	ccPolicy := cauthdsl.SignedByAnyMember([]string{"Org1MSP"})
 
 	// Org resource manager will instantiate 'example_cc' on channel
-	err = orgResMgmt.InstantiateCC(channelID, resmgmt.InstantiateCCRequest{Name: ccID, Path: "github.com/example_cc", Version: "0", Args: integration.ExampleCCInitArgs(), Policy: ccPolicy})
+	_, err = orgResMgmt.InstantiateCC(channelID, resmgmt.InstantiateCCRequest{Name: ccID, Path: "github.com/example_cc", Version: "0", Args: integration.ExampleCCInitArgs(), Policy: ccPolicy})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -63,8 +63,8 @@ func TestOrgsEndToEnd(t *testing.T) {
 
 	// Create channel (or update if it already exists)
 	org1AdminUser := loadOrgUser(t, sdk, org1, "Admin")
-	req := resmgmt.SaveChannelRequest{ChannelID: "orgchannel", ChannelConfig: path.Join("../../../", metadata.ChannelConfigPath, "orgchannel.tx"), SigningIdentity: org1AdminUser}
-	if err = chMgmtClient.SaveChannel(req); err != nil {
+	req := resmgmt.SaveChannelRequest{ChannelID: "orgchannel", ChannelConfigPath: path.Join("../../../", metadata.ChannelConfigPath, "orgchannel.tx"), SigningIdentities: []context.IdentityContext{org1AdminUser}}
+	if _, err = chMgmtClient.SaveChannel(req); err != nil {
 		t.Fatal(err)
 	}
 
@@ -78,7 +78,7 @@ func TestOrgsEndToEnd(t *testing.T) {
 	}
 
 	// Org1 peers join channel
-	if err = org1ResMgmt.JoinChannel("orgchannel"); err != nil {
+	if _, err = org1ResMgmt.JoinChannel("orgchannel"); err != nil {
 		t.Fatalf("Org1 peers failed to JoinChannel: %s", err)
 	}
 
@@ -89,7 +89,7 @@ func TestOrgsEndToEnd(t *testing.T) {
 	}
 
 	// Org2 peers join channel
-	if err = org2ResMgmt.JoinChannel("orgchannel"); err != nil {
+	if _, err = org2ResMgmt.JoinChannel("orgchannel"); err != nil {
 		t.Fatalf("Org2 peers failed to JoinChannel: %s", err)
 	}
 
@@ -117,7 +117,7 @@ func TestOrgsEndToEnd(t *testing.T) {
 	ccPolicy := cauthdsl.SignedByAnyMember([]string{"Org1MSP", "Org2MSP"})
 
 	// Org1 resource manager will instantiate 'example_cc' on 'orgchannel'
-	err = org1ResMgmt.InstantiateCC("orgchannel", resmgmt.InstantiateCCRequest{Name: "exampleCC", Path: "github.com/example_cc", Version: "0", Args: integration.ExampleCCInitArgs(), Policy: ccPolicy})
+	_, err = org1ResMgmt.InstantiateCC("orgchannel", resmgmt.InstantiateCCRequest{Name: "exampleCC", Path: "github.com/example_cc", Version: "0", Args: integration.ExampleCCInitArgs(), Policy: ccPolicy})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -175,7 +175,7 @@ func TestOrgsEndToEnd(t *testing.T) {
 	}
 
 	// Org1 resource manager will instantiate 'example_cc' version 1 on 'orgchannel'
-	err = org1ResMgmt.UpgradeCC("orgchannel", resmgmt.UpgradeCCRequest{Name: "exampleCC", Path: "github.com/example_cc", Version: "1", Args: integration.ExampleCCUpgradeArgs(), Policy: org1Andorg2Policy})
+	_, err = org1ResMgmt.UpgradeCC("orgchannel", resmgmt.UpgradeCCRequest{Name: "exampleCC", Path: "github.com/example_cc", Version: "1", Args: integration.ExampleCCUpgradeArgs(), Policy: org1Andorg2Policy})
 	if err != nil {
 		t.Fatal(err)
 	}
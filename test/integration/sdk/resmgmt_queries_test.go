@@ -60,14 +60,14 @@ func TestResMgmtClientQueries(t *testing.T) {
 
 func testInstalledChaincodes(t *testing.T, ccID string, target fab.ProposalProcessor, client *resmgmt.Client) {
 
-	chaincodeQueryResponse, err := client.QueryInstalledChaincodes(target)
+	chaincodes, err := client.QueryInstalledChaincodes(target)
 	if err != nil {
 		t.Fatalf("QueryInstalledChaincodes return error: %v", err)
 	}
 
 	found := false
-	for _, chaincode := range chaincodeQueryResponse.Chaincodes {
-		t.Logf("**InstalledCC: %s", chaincode)
+	for _, chaincode := range chaincodes {
+		t.Logf("**InstalledCC: %v", chaincode)
 		if chaincode.Name == ccID {
 			found = true
 		}
@@ -91,7 +91,7 @@ func CreateChannel(sdk *fabsdk.FabricSDK, req resmgmt.SaveChannelRequest) (bool,
 	}
 
 	// Create channel (or update if it already exists)
-	if err = resMgmtClient.SaveChannel(req); err != nil {
+	if _, err = resMgmtClient.SaveChannel(req); err != nil {
 		return false, nil
 	}
 
@@ -107,7 +107,7 @@ func JoinChannel(sdk *fabsdk.FabricSDK, name string) (bool, error) {
 		return false, errors.WithMessage(err, "Failed to create new resource management client")
 	}
 
-	if err = resMgmtClient.JoinChannel(name); err != nil {
+	if _, err = resMgmtClient.JoinChannel(name); err != nil {
 		return false, nil
 	}
 	return true, nil